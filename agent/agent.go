@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
@@ -37,6 +38,7 @@ import (
 	"github.com/coder/coder/agent/usershell"
 	"github.com/coder/coder/buildinfo"
 	"github.com/coder/coder/coderd/gitauth"
+	"github.com/coder/coder/coderd/sshca"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/pty"
 	"github.com/coder/coder/tailnet"
@@ -69,6 +71,12 @@ type Client interface {
 	AgentReportStats(ctx context.Context, log slog.Logger, stats func() *codersdk.AgentStats) (io.Closer, error)
 	PostWorkspaceAgentAppHealth(ctx context.Context, req codersdk.PostWorkspaceAppHealthsRequest) error
 	PostWorkspaceAgentVersion(ctx context.Context, version string) error
+	PatchStartupLogs(ctx context.Context, req codersdk.PatchStartupLogs) error
+	PostWorkspaceAgentLifecycle(ctx context.Context, req codersdk.PostWorkspaceAgentLifecycleRequest) error
+	WaitForWorkspaceAgentShutdown(ctx context.Context) error
+	PostWorkspaceAgentMetadata(ctx context.Context, key string, req codersdk.PostWorkspaceAgentMetadataRequest) error
+	WatchWorkspaceAgentDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error)
+	PostWorkspaceAgentSessionRecording(ctx context.Context, typ codersdk.SessionRecordingType, userID uuid.UUID, recording io.Reader) (codersdk.WorkspaceSessionRecording, error)
 }
 
 func New(options Options) io.Closer {
@@ -89,6 +97,9 @@ func New(options Options) io.Closer {
 		exchangeToken:          options.ExchangeToken,
 		filesystem:             options.Filesystem,
 		stats:                  &Stats{},
+		lifecycleUpdate:        make(chan struct{}, 1),
+		lifecycleReported:      make(chan codersdk.WorkspaceAgentLifecycle, 1),
+		lifecycleStates:        []codersdk.WorkspaceAgentLifecycle{codersdk.WorkspaceAgentLifecycleCreated},
 	}
 	server.init(ctx)
 	return server
@@ -113,8 +124,21 @@ type agent struct {
 	metadata  atomic.Value
 	sshServer *ssh.Server
 
+	// shutdownOnce ensures the shutdown script runs at most once, whether
+	// coderd signals a stop or the agent is closed.
+	shutdownOnce sync.Once
+
+	lifecycleUpdate   chan struct{}
+	lifecycleReported chan codersdk.WorkspaceAgentLifecycle
+	lifecycleMu       sync.RWMutex // Protects following.
+	lifecycleStates   []codersdk.WorkspaceAgentLifecycle
+
 	network *tailnet.Conn
-	stats   *Stats
+	// statsNetwork is the network connection stats are collected from.
+	// Close holds closeMutex while it waits for the stats reporter to exit,
+	// so the reporter can't read network.
+	statsNetwork atomic.Pointer[tailnet.Conn]
+	stats        *Stats
 }
 
 // runLoop attempts to start the agent in a retry loop.
@@ -168,15 +192,27 @@ func (a *agent) run(ctx context.Context) error {
 
 	// The startup script should only execute on the first run!
 	if oldMetadata == nil {
+		a.setLifecycle(ctx, codersdk.WorkspaceAgentLifecycleStarting)
+
 		go func() {
-			err := a.runStartupScript(ctx, metadata.StartupScript)
+			err := a.runStartupScript(ctx, metadata.StartupScript, metadata.StartupScriptTimeout)
 			if errors.Is(err, context.Canceled) {
 				return
 			}
-			if err != nil {
+			lifecycleState := codersdk.WorkspaceAgentLifecycleReady
+			if errors.Is(err, context.DeadlineExceeded) {
+				a.logger.Warn(ctx, "agent script timed out", slog.F("timeout", metadata.StartupScriptTimeout))
+				lifecycleState = codersdk.WorkspaceAgentLifecycleStartTimeout
+			} else if err != nil {
 				a.logger.Warn(ctx, "agent script failed", slog.Error(err))
+				lifecycleState = codersdk.WorkspaceAgentLifecycleStartError
 			}
+			a.setLifecycle(ctx, lifecycleState)
 		}()
+
+		if metadata.ShutdownScript != "" {
+			go a.waitForShutdown(ctx)
+		}
 	}
 
 	if metadata.GitAuthConfigs > 0 {
@@ -191,6 +227,7 @@ func (a *agent) run(ctx context.Context) error {
 	defer appReporterCtxCancel()
 	go NewWorkspaceAppHealthReporter(
 		a.logger, metadata.Apps, a.client.PostWorkspaceAgentAppHealth)(appReporterCtx)
+	go a.reportMetadataLoop(appReporterCtx, metadata.Metadata)
 
 	a.logger.Debug(ctx, "running tailnet with derpmap", slog.F("derpmap", metadata.DERPMap))
 
@@ -206,10 +243,12 @@ func (a *agent) run(ctx context.Context) error {
 		a.closeMutex.Lock()
 		a.network = network
 		a.closeMutex.Unlock()
+		a.statsNetwork.Store(network)
 	} else {
 		// Update the DERP map!
 		network.SetDERPMap(metadata.DERPMap)
 	}
+	go a.watchDERPMap(appReporterCtx, network)
 
 	a.logger.Debug(ctx, "running coordinator")
 	err = a.runCoordinator(ctx, network)
@@ -220,6 +259,22 @@ func (a *agent) run(ctx context.Context) error {
 	return nil
 }
 
+// watchDERPMap applies DERP map updates from coderd until the context is
+// canceled, so the agent stops relaying through unhealthy regions without
+// waiting for a reconnect.
+func (a *agent) watchDERPMap(ctx context.Context, network *tailnet.Conn) {
+	derpMaps, err := a.client.WatchWorkspaceAgentDERPMap(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			a.logger.Debug(ctx, "watch derp map", slog.Error(err))
+		}
+		return
+	}
+	for derpMap := range derpMaps {
+		network.SetDERPMap(derpMap)
+	}
+}
+
 func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*tailnet.Conn, error) {
 	network, err := tailnet.NewConn(&tailnet.Options{
 		Addresses: []netip.Prefix{netip.PrefixFrom(codersdk.TailnetIP, 128)},
@@ -237,6 +292,10 @@ func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*t
 		}
 		return a.stats.wrapConn(conn)
 	})
+	// Connections to the agent's own listeners, e.g. SSH, aren't filtered.
+	network.SetForwardTCPFilter(func(port uint16) bool {
+		return a.portForwardingAllowed(uint32(port))
+	})
 
 	sshListener, err := network.Listen("tcp", ":"+strconv.Itoa(codersdk.TailnetSSHPort))
 	if err != nil {
@@ -252,7 +311,21 @@ func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*t
 			if err != nil {
 				return
 			}
-			go a.sshServer.HandleConn(a.stats.wrapConn(conn))
+			go func() {
+				// The SSH server only tracks connections after they
+				// authenticate, so close the ones that never do when the
+				// agent closes.
+				handled := make(chan struct{})
+				defer close(handled)
+				go func() {
+					select {
+					case <-a.closed:
+						_ = conn.Close()
+					case <-handled:
+					}
+				}()
+				a.sshServer.HandleConn(a.stats.wrapConn(conn))
+			}()
 		}
 	}()
 
@@ -330,7 +403,7 @@ func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*t
 		defer a.connCloseWait.Done()
 		defer statisticsListener.Close()
 		server := &http.Server{
-			Handler:           a.statisticsHandler(),
+			Handler:           a.statisticsHandler(network),
 			ReadTimeout:       20 * time.Second,
 			ReadHeaderTimeout: 20 * time.Second,
 			WriteTimeout:      20 * time.Second,
@@ -347,6 +420,34 @@ func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (*t
 		}
 	}()
 
+	filesListener, err := network.Listen("tcp", ":"+strconv.Itoa(codersdk.TailnetFilesPort))
+	if err != nil {
+		return nil, xerrors.Errorf("listen for files: %w", err)
+	}
+	a.closeMutex.Lock()
+	a.connCloseWait.Add(1)
+	a.closeMutex.Unlock()
+	go func() {
+		defer a.connCloseWait.Done()
+		defer filesListener.Close()
+		// Transfers are bounded by the client, since large files can take
+		// longer than any fixed timeout.
+		server := &http.Server{
+			Handler:           a.filesHandler(),
+			ReadHeaderTimeout: 20 * time.Second,
+			ErrorLog:          slog.Stdlib(ctx, a.logger.Named("files_http_server"), slog.LevelInfo),
+		}
+		go func() {
+			<-ctx.Done()
+			_ = server.Close()
+		}()
+
+		err := server.Serve(filesListener)
+		if err != nil && !xerrors.Is(err, http.ErrServerClosed) && !strings.Contains(err.Error(), "use of closed network connection") {
+			a.logger.Critical(ctx, "serve files HTTP server", slog.Error(err))
+		}
+	}()
+
 	return network, nil
 }
 
@@ -369,8 +470,14 @@ func (a *agent) runCoordinator(ctx context.Context, network *tailnet.Conn) error
 	}
 }
 
-func (a *agent) runStartupScript(ctx context.Context, script string) error {
+// runStartupScript runs the startup script and streams its output to
+// coderd. If timeout is non-zero and the script does not finish in time,
+// it's killed and context.DeadlineExceeded is returned.
+func (a *agent) runStartupScript(ctx context.Context, script string, timeout time.Duration) error {
+	logs := newStartupLogsSender(a.logger, a.client)
 	if script == "" {
+		// Signal the end of logs so clients waiting on them can continue.
+		logs.flushWithEOF(ctx)
 		return nil
 	}
 
@@ -378,10 +485,136 @@ func (a *agent) runStartupScript(ctx context.Context, script string) error {
 	if err != nil {
 		return xerrors.Errorf("open startup script log file: %w", err)
 	}
+
+	// An *os.File is used for output so that cmd.Wait doesn't block on
+	// processes the script leaves running in the background.
+	outputReader, outputWriter, err := os.Pipe()
+	if err != nil {
+		_ = writer.Close()
+		return xerrors.Errorf("create startup script output pipe: %w", err)
+	}
+	defer func() {
+		_ = outputWriter.Close()
+	}()
+	scanDone := make(chan struct{})
+	go func() {
+		defer close(scanDone)
+		defer func() {
+			_ = outputReader.Close()
+			_ = writer.Close()
+		}()
+		// Background processes may keep writing after the script exits,
+		// so their output continues to go to the log file.
+		logs.scan(io.TeeReader(outputReader, writer))
+	}()
+
+	cmdCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd, err := a.createCommand(cmdCtx, script, nil)
+	if err != nil {
+		return xerrors.Errorf("create command: %w", err)
+	}
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+
+	flushCtx, flushCancel := context.WithCancel(ctx)
+	flushDone := make(chan struct{})
+	go func() {
+		defer close(flushDone)
+		logs.flushPeriodically(flushCtx)
+	}()
+
+	err = cmd.Start()
+	if err == nil {
+		// Only the child holds the write end from now on.
+		_ = outputWriter.Close()
+		err = cmd.Wait()
+		// Give the scanner a moment to read output the script wrote
+		// right before exiting.
+		select {
+		case <-scanDone:
+		case <-time.After(startupLogsFlushInterval):
+		}
+	}
+	flushCancel()
+	<-flushDone
+	logs.flushWithEOF(ctx)
+	if err != nil {
+		// cmd.Wait does not return a context canceled error, it returns "signal: killed".
+		if cmdCtx.Err() != nil {
+			return cmdCtx.Err()
+		}
+
+		return xerrors.Errorf("run: %w", err)
+	}
+
+	return nil
+}
+
+// waitForShutdown runs the shutdown script once coderd signals that the
+// workspace is stopping.
+func (a *agent) waitForShutdown(ctx context.Context) {
+	for retrier := retry.New(time.Second, 15*time.Second); retrier.Wait(ctx); {
+		err := a.client.WaitForWorkspaceAgentShutdown(ctx)
+		if err == nil {
+			a.logger.Info(ctx, "received shutdown signal from coderd")
+			a.runShutdownScriptOnce(ctx)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		a.logger.Debug(ctx, "wait for shutdown signal", slog.Error(err))
+	}
+}
+
+// runShutdownScriptOnce runs the shutdown script if there is one and it
+// hasn't run yet, and reports the result as the lifecycle state.
+func (a *agent) runShutdownScriptOnce(ctx context.Context) {
+	a.shutdownOnce.Do(func() {
+		metadata, ok := a.metadata.Load().(codersdk.WorkspaceAgentMetadata)
+		if !ok || metadata.ShutdownScript == "" {
+			return
+		}
+		a.setLifecycle(ctx, codersdk.WorkspaceAgentLifecycleShuttingDown)
+
+		// Close waits for the script while holding closeMutex, so it must
+		// always be bounded.
+		timeout := metadata.ShutdownScriptTimeout
+		if timeout <= 0 {
+			timeout = codersdk.DefaultShutdownScriptTimeout
+		}
+		lifecycleState := codersdk.WorkspaceAgentLifecycleOff
+		err := a.runShutdownScript(ctx, metadata.ShutdownScript, timeout)
+		if errors.Is(err, context.DeadlineExceeded) {
+			a.logger.Warn(ctx, "shutdown script timed out", slog.F("timeout", timeout))
+			lifecycleState = codersdk.WorkspaceAgentLifecycleShutdownTimeout
+		} else if err != nil {
+			a.logger.Warn(ctx, "shutdown script failed", slog.Error(err))
+			lifecycleState = codersdk.WorkspaceAgentLifecycleShutdownError
+		}
+		a.setLifecycle(ctx, lifecycleState)
+	})
+}
+
+// runShutdownScript runs the shutdown script with its output written to a
+// log file. If the script does not finish within timeout, it's killed and
+// context.DeadlineExceeded is returned.
+func (a *agent) runShutdownScript(ctx context.Context, script string, timeout time.Duration) error {
+	writer, err := os.OpenFile(filepath.Join(os.TempDir(), "coder-shutdown-script.log"), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return xerrors.Errorf("open shutdown script log file: %w", err)
+	}
 	defer func() {
 		_ = writer.Close()
 	}()
 
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 	cmd, err := a.createCommand(ctx, script, nil)
 	if err != nil {
 		return xerrors.Errorf("create command: %w", err)
@@ -394,13 +627,86 @@ func (a *agent) runStartupScript(ctx context.Context, script string) error {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
-
 		return xerrors.Errorf("run: %w", err)
 	}
-
 	return nil
 }
 
+// reportLifecycleLoop reports every lifecycle state change to coderd in
+// the order they happened. Failed reports are retried.
+func (a *agent) reportLifecycleLoop(ctx context.Context) {
+	// The initial "created" state is set by coderd, so it's not reported.
+	lastReportedIndex := 0
+	for {
+		select {
+		case <-a.lifecycleUpdate:
+		case <-ctx.Done():
+			return
+		}
+
+		for {
+			a.lifecycleMu.RLock()
+			if lastReportedIndex >= len(a.lifecycleStates)-1 {
+				a.lifecycleMu.RUnlock()
+				break
+			}
+			state := a.lifecycleStates[lastReportedIndex+1]
+			a.lifecycleMu.RUnlock()
+
+			err := a.reportLifecycle(ctx, state)
+			if err != nil {
+				return
+			}
+			lastReportedIndex++
+
+			// Replace any state that hasn't been read yet.
+			select {
+			case <-a.lifecycleReported:
+			default:
+			}
+			a.lifecycleReported <- state
+		}
+	}
+}
+
+// reportLifecycle posts a lifecycle state to coderd, retrying until it
+// succeeds or the context is canceled.
+func (a *agent) reportLifecycle(ctx context.Context, state codersdk.WorkspaceAgentLifecycle) error {
+	a.logger.Debug(ctx, "post lifecycle state", slog.F("state", state))
+	retrier := retry.New(time.Second, 15*time.Second)
+	for {
+		err := a.client.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+			State: state,
+		})
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		// Failing to report the state isn't fatal, so keep trying.
+		a.logger.Error(ctx, "post lifecycle state", slog.F("state", state), slog.Error(err))
+		if !retrier.Wait(ctx) {
+			return ctx.Err()
+		}
+	}
+}
+
+// setLifecycle records a lifecycle state change and notifies the
+// lifecycle loop.
+func (a *agent) setLifecycle(ctx context.Context, state codersdk.WorkspaceAgentLifecycle) {
+	a.lifecycleMu.Lock()
+	a.lifecycleStates = append(a.lifecycleStates, state)
+	a.lifecycleMu.Unlock()
+
+	a.logger.Debug(ctx, "set lifecycle state", slog.F("state", state))
+
+	select {
+	case a.lifecycleUpdate <- struct{}{}:
+	default:
+	}
+}
+
 func (a *agent) init(ctx context.Context) {
 	a.logger.Info(ctx, "generating host key")
 	// Clients' should ignore the host key when connecting.
@@ -442,7 +748,12 @@ func (a *agent) init(ctx context.Context) {
 		},
 		HostSigners: []ssh.Signer{randomSigner},
 		LocalPortForwardingCallback: func(ctx ssh.Context, destinationHost string, destinationPort uint32) bool {
-			// Allow local port forwarding all!
+			if !a.portForwardingAllowed(destinationPort) {
+				sshLogger.Debug(ctx, "local port forward denied by template allowlist",
+					slog.F("destination-host", destinationHost),
+					slog.F("destination-port", destinationPort))
+				return false
+			}
 			sshLogger.Debug(ctx, "local port forward",
 				slog.F("destination-host", destinationHost),
 				slog.F("destination-port", destinationPort))
@@ -452,8 +763,13 @@ func (a *agent) init(ctx context.Context) {
 			return true
 		},
 		ReversePortForwardingCallback: func(ctx ssh.Context, bindHost string, bindPort uint32) bool {
-			// Allow reverse port forwarding all!
-			sshLogger.Debug(ctx, "local port forward",
+			if !a.portForwardingAllowed(bindPort) {
+				sshLogger.Debug(ctx, "reverse port forward denied by template allowlist",
+					slog.F("bind-host", bindHost),
+					slog.F("bind-port", bindPort))
+				return false
+			}
+			sshLogger.Debug(ctx, "reverse port forward",
 				slog.F("bind-host", bindHost),
 				slog.F("bind-port", bindPort))
 			return true
@@ -462,9 +778,14 @@ func (a *agent) init(ctx context.Context) {
 			"tcpip-forward":        forwardHandler.HandleSSHRequest,
 			"cancel-tcpip-forward": forwardHandler.HandleSSHRequest,
 		},
+		PublicKeyHandler: func(ctx ssh.Context, key ssh.PublicKey) bool {
+			return a.sshCertificateAllowed(ctx, key)
+		},
 		ServerConfigCallback: func(ctx ssh.Context) *gossh.ServerConfig {
 			return &gossh.ServerConfig{
-				NoClientAuth: true,
+				// Clients are authorized by coderd before they can reach the
+				// agent over tailnet, unless a certificate is required too.
+				NoClientAuth: !a.sshRequireCertificate(),
 			}
 		},
 		SubsystemHandlers: map[string]ssh.SubsystemHandler{
@@ -497,9 +818,12 @@ func (a *agent) init(ctx context.Context) {
 		},
 	}
 
+	go a.reportLifecycleLoop(ctx)
 	go a.runLoop(ctx)
 	cl, err := a.client.AgentReportStats(ctx, a.logger, func() *codersdk.AgentStats {
-		return a.stats.Copy()
+		stats := a.stats.Copy()
+		stats.Connections = a.connectionStats(ctx)
+		return stats
 	})
 	if err != nil {
 		a.logger.Error(ctx, "report stats", slog.Error(err))
@@ -513,6 +837,84 @@ func (a *agent) init(ctx context.Context) {
 	}()
 }
 
+// connectionStats returns a record for every peer of the agent's tailnet.
+func (a *agent) connectionStats(ctx context.Context) []codersdk.ConnectionStat {
+	network := a.statsNetwork.Load()
+	if network == nil {
+		return nil
+	}
+	// Bound the time spent pinging peers, so a peer that went away doesn't
+	// delay the report.
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return codersdk.ConnectionStatsFromPeers(network.PeerStats(ctx))
+}
+
+// portForwardingAllowed returns true if the template allows forwarding
+// port, whether with SSH or over tailnet, e.g. "coder port-forward" and
+// apps.
+func (a *agent) portForwardingAllowed(port uint32) bool {
+	metadata, ok := a.metadata.Load().(codersdk.WorkspaceAgentMetadata)
+	if !ok {
+		// Nobody can connect before the agent has metadata anyways.
+		return false
+	}
+	return codersdk.PortForwardingAllowed(metadata.PortForwardingAllowlist, port)
+}
+
+// sshRequireCertificate only applies to the SSH server. Reconnecting PTYs
+// and the file API are used by coderd's web terminal and "coder cp", which
+// coderd authorizes before brokering the tailnet connection, so they don't
+// take certificates.
+func (a *agent) sshRequireCertificate() bool {
+	metadata, ok := a.metadata.Load().(codersdk.WorkspaceAgentMetadata)
+	if !ok {
+		// Nobody can connect before the agent has metadata anyways.
+		return true
+	}
+	return metadata.SSHRequireCertificate
+}
+
+// sshCertificateAllowed accepts certificates that coderd issued for this
+// agent. Plain public keys are never accepted, since coderd doesn't know
+// about them.
+func (a *agent) sshCertificateAllowed(ctx ssh.Context, key ssh.PublicKey) bool {
+	metadata, ok := a.metadata.Load().(codersdk.WorkspaceAgentMetadata)
+	if !ok {
+		return false
+	}
+	cert, ok := key.(*gossh.Certificate)
+	if !ok || cert.CertType != gossh.UserCert {
+		return false
+	}
+	trusted := false
+	for _, rawAuthority := range metadata.SSHCertificateAuthorities {
+		authority, _, _, _, err := gossh.ParseAuthorizedKey([]byte(rawAuthority))
+		if err != nil {
+			a.logger.Warn(ctx, "parse ssh certificate authority", slog.Error(err))
+			continue
+		}
+		if bytes.Equal(authority.Marshal(), cert.SignatureKey.Marshal()) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		a.logger.Debug(ctx, "ssh certificate signed by unknown authority", slog.F("key_id", cert.KeyId))
+		return false
+	}
+	err := (&gossh.CertChecker{}).CheckCert(metadata.SSHCertificatePrincipal, cert)
+	if err != nil {
+		a.logger.Debug(ctx, "ssh certificate rejected", slog.F("key_id", cert.KeyId), slog.Error(err))
+		return false
+	}
+	a.logger.Debug(ctx, "ssh certificate accepted",
+		slog.F("key_id", cert.KeyId),
+		slog.F("user_id", cert.Extensions[sshca.ExtensionUserID]),
+	)
+	return true
+}
+
 // createCommand processes raw command input with OpenSSH-like behavior.
 // If the rawCommand provided is empty, it will default to the users shell.
 // This injects environment variables specified by the user at launch too.
@@ -645,19 +1047,38 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 				}
 			}
 		}()
+		recording, err := a.startSessionRecording(codersdk.SessionRecordingTypeSSH, sshSessionUserID(session), session.RawCommand(), uint16(sshPty.Window.Width), uint16(sshPty.Window.Height))
+		if err != nil {
+			return xerrors.Errorf("start session recording: %w", err)
+		}
+		outputDone := make(chan struct{})
+		defer func() {
+			// Upload in the background, so the client doesn't wait for it
+			// to exit. The output is only complete once the PTY is closed.
+			a.closeMutex.Lock()
+			a.connCloseWait.Add(1)
+			a.closeMutex.Unlock()
+			go func() {
+				defer a.connCloseWait.Done()
+				<-outputDone
+				a.uploadSessionRecording(recording)
+			}()
+		}()
 		go func() {
 			for win := range windowSize {
 				resizeErr := ptty.Resize(uint16(win.Height), uint16(win.Width))
 				if resizeErr != nil {
 					a.logger.Warn(ctx, "failed to resize tty", slog.Error(resizeErr))
 				}
+				recording.Resize(uint16(win.Width), uint16(win.Height))
 			}
 		}()
 		go func() {
 			_, _ = io.Copy(ptty.Input(), session)
 		}()
 		go func() {
-			_, _ = io.Copy(session, ptty.Output())
+			defer close(outputDone)
+			_, _ = io.Copy(io.MultiWriter(session, recording), ptty.Output())
 		}()
 		err = process.Wait()
 		var exitErr *exec.ExitError
@@ -699,6 +1120,14 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			a.logger.Error(ctx, "found invalid type in reconnecting pty map", slog.F("id", msg.ID))
 			return
 		}
+	} else if msg.AttachOnly {
+		if msg.Resume {
+			err := writeReconnectingPTYAttach(conn, codersdk.ReconnectingPTYAttach{Exited: true})
+			if err != nil {
+				a.logger.Debug(ctx, "write reconnecting pty attach", slog.F("id", msg.ID), slog.Error(err))
+			}
+		}
+		return
 	} else {
 		// Empty command will default to the users shell!
 		cmd, err := a.createCommand(ctx, msg.Command, nil)
@@ -720,6 +1149,13 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			a.logger.Error(ctx, "start reconnecting pty command", slog.F("id", msg.ID), slog.Error(err))
 			return
 		}
+		recording, err := a.startSessionRecording(codersdk.SessionRecordingTypeReconnectingPTY, msg.UserID, msg.Command, msg.Width, msg.Height)
+		if err != nil {
+			a.logger.Error(ctx, "start reconnecting pty session recording", slog.F("id", msg.ID), slog.Error(err))
+			_ = process.Kill()
+			_ = ptty.Close()
+			return
+		}
 
 		a.closeMutex.Lock()
 		a.connCloseWait.Add(1)
@@ -731,6 +1167,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			// Timeouts created with an after func can be reset!
 			timeout:        time.AfterFunc(a.reconnectingPTYTimeout, cancelFunc),
 			circularBuffer: circularBuffer,
+			recording:      recording,
 		}
 		a.reconnectingPTYs.Store(msg.ID, rpty)
 		go func() {
@@ -757,18 +1194,24 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 					break
 				}
 				part := buffer[:read]
+				// Buffering and broadcasting happen under the same lock
+				// that attaching connections hold, so a connection sees
+				// each byte exactly once: either replayed from the
+				// buffer or broadcast.
+				rpty.activeConnsMutex.Lock()
 				rpty.circularBufferMutex.Lock()
 				_, err = rpty.circularBuffer.Write(part)
 				rpty.circularBufferMutex.Unlock()
 				if err != nil {
+					rpty.activeConnsMutex.Unlock()
 					a.logger.Error(ctx, "reconnecting pty write buffer", slog.Error(err), slog.F("id", msg.ID))
 					break
 				}
-				rpty.activeConnsMutex.Lock()
 				for _, conn := range rpty.activeConns {
 					_, _ = conn.Write(part)
 				}
 				rpty.activeConnsMutex.Unlock()
+				_, _ = rpty.recording.Write(part)
 			}
 
 			// Cleanup the process, PTY, and delete it's
@@ -776,6 +1219,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			_ = process.Kill()
 			rpty.Close()
 			a.reconnectingPTYs.Delete(msg.ID)
+			a.uploadSessionRecording(rpty.recording)
 			a.connCloseWait.Done()
 		}()
 	}
@@ -785,20 +1229,46 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 		// We can continue after this, it's not fatal!
 		a.logger.Error(ctx, "resize reconnecting pty", slog.F("id", msg.ID), slog.Error(err))
 	}
+	connectionID := uuid.NewString()
+	rpty.activeConnsMutex.Lock()
+	if rpty.closed {
+		// The process exited after we loaded the PTY.
+		rpty.activeConnsMutex.Unlock()
+		if msg.Resume {
+			err = writeReconnectingPTYAttach(conn, codersdk.ReconnectingPTYAttach{Exited: true})
+			if err != nil {
+				a.logger.Debug(ctx, "write reconnecting pty attach", slog.F("id", msg.ID), slog.Error(err))
+			}
+		}
+		return
+	}
 	// Write any previously stored data for the TTY.
 	rpty.circularBufferMutex.RLock()
-	_, err = conn.Write(rpty.circularBuffer.Bytes())
+	buffered := rpty.circularBuffer.Bytes()
+	offset := rpty.circularBuffer.TotalWritten() - int64(len(buffered))
 	rpty.circularBufferMutex.RUnlock()
+	if msg.Resume {
+		if skip := msg.Offset - offset; skip > 0 {
+			if skip > int64(len(buffered)) {
+				skip = int64(len(buffered))
+			}
+			buffered = buffered[skip:]
+			offset += skip
+		}
+		err = writeReconnectingPTYAttach(conn, codersdk.ReconnectingPTYAttach{Offset: offset})
+	}
+	if err == nil {
+		_, err = conn.Write(buffered)
+	}
 	if err != nil {
+		rpty.activeConnsMutex.Unlock()
 		a.logger.Warn(ctx, "write reconnecting pty buffer", slog.F("id", msg.ID), slog.Error(err))
 		return
 	}
-	connectionID := uuid.NewString()
 	// Multiple connections to the same TTY are permitted.
 	// This could easily be used for terminal sharing, but
 	// we do it because it's a nice user experience to
 	// copy/paste a terminal URL and have it _just work_.
-	rpty.activeConnsMutex.Lock()
 	rpty.activeConns[connectionID] = conn
 	rpty.activeConnsMutex.Unlock()
 	// Resetting this timeout prevents the PTY from exiting.
@@ -853,6 +1323,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			// We can continue after this, it's not fatal!
 			a.logger.Error(ctx, "resize reconnecting pty", slog.F("id", msg.ID), slog.Error(err))
 		}
+		rpty.recording.Resize(req.Width, req.Height)
 	}
 }
 
@@ -872,6 +1343,33 @@ func (a *agent) Close() error {
 	if a.isClosed() {
 		return nil
 	}
+
+	ctx := context.Background()
+	// This waits for the shutdown script if coderd already started it.
+	a.runShutdownScriptOnce(ctx)
+	a.lifecycleMu.RLock()
+	lastState := a.lifecycleStates[len(a.lifecycleStates)-1]
+	a.lifecycleMu.RUnlock()
+	if !lastState.ShutDown() {
+		a.setLifecycle(ctx, codersdk.WorkspaceAgentLifecycleShuttingDown)
+		a.setLifecycle(ctx, codersdk.WorkspaceAgentLifecycleOff)
+	}
+	// Wait for the lifecycle to be reported, but don't wait forever so
+	// that we don't break user expectations.
+	reportCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+lifecycleWaitLoop:
+	for {
+		select {
+		case <-reportCtx.Done():
+			break lifecycleWaitLoop
+		case s := <-a.lifecycleReported:
+			if s.ShutDown() {
+				break lifecycleWaitLoop
+			}
+		}
+	}
+
 	close(a.closed)
 	a.closeCancel()
 	if a.network != nil {
@@ -885,11 +1383,15 @@ func (a *agent) Close() error {
 type reconnectingPTY struct {
 	activeConnsMutex sync.Mutex
 	activeConns      map[string]net.Conn
+	// closed is set once the PTY has been closed, so connections that
+	// loaded it before it was removed from the map don't attach.
+	closed bool
 
 	circularBuffer      *circbuf.Buffer
 	circularBufferMutex sync.RWMutex
 	timeout             *time.Timer
 	ptty                pty.PTY
+	recording           *sessionRecording
 }
 
 // Close ends all connections to the reconnecting
@@ -897,6 +1399,7 @@ type reconnectingPTY struct {
 func (r *reconnectingPTY) Close() {
 	r.activeConnsMutex.Lock()
 	defer r.activeConnsMutex.Unlock()
+	r.closed = true
 	for _, conn := range r.activeConns {
 		_ = conn.Close()
 	}
@@ -907,6 +1410,19 @@ func (r *reconnectingPTY) Close() {
 	r.timeout.Stop()
 }
 
+// writeReconnectingPTYAttach writes the attach header using the same framing
+// as codersdk.ReconnectingPTYInit.
+func writeReconnectingPTYAttach(conn net.Conn, attach codersdk.ReconnectingPTYAttach) error {
+	data, err := json.Marshal(attach)
+	if err != nil {
+		return err
+	}
+	data = append(make([]byte, 2), data...)
+	binary.LittleEndian.PutUint16(data, uint16(len(data)-2))
+	_, err = conn.Write(data)
+	return err
+}
+
 // Bicopy copies all of the data between the two connections and will close them
 // after one or both of them are done writing. If the context is canceled, both
 // of the connections will be closed.
@@ -2,11 +2,17 @@ package agent_test
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"net/netip"
 	"os"
 	"os/exec"
@@ -20,9 +26,11 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/xerrors"
 	"tailscale.com/net/speedtest"
+	"tailscale.com/tailcfg"
 
 	scp "github.com/bramvdbogaerde/go-scp"
 	"github.com/google/uuid"
@@ -39,6 +47,8 @@ import (
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/agent"
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/coderd/sshca"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/pty/ptytest"
 	"github.com/coder/coder/tailnet"
@@ -98,6 +108,83 @@ func TestAgent(t *testing.T) {
 		})
 	})
 
+	t.Run("SSHCertificate", func(t *testing.T) {
+		t.Parallel()
+		ca := generateSSHCertificateAuthority(t)
+		agentID := uuid.New()
+		conn, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{
+			SSHCertificateAuthorities: []string{string(ssh.MarshalAuthorizedKey(ca.PublicKey()))},
+			SSHCertificatePrincipal:   sshca.Principal(agentID),
+			SSHRequireCertificate:     true,
+		}, 0)
+
+		t.Run("Valid", func(t *testing.T) {
+			t.Parallel()
+			sshClient, err := conn.SSHClientWithSigner(issueSSHCertificate(t, ca, agentID, uuid.New()))
+			require.NoError(t, err)
+			defer sshClient.Close()
+			session, err := sshClient.NewSession()
+			require.NoError(t, err)
+			defer session.Close()
+			output, err := session.Output("echo test")
+			require.NoError(t, err)
+			require.Equal(t, "test", strings.TrimSpace(string(output)))
+		})
+
+		t.Run("NoCertificate", func(t *testing.T) {
+			t.Parallel()
+			_, err := conn.SSHClient()
+			require.Error(t, err)
+		})
+
+		t.Run("OtherAgent", func(t *testing.T) {
+			t.Parallel()
+			_, err := conn.SSHClientWithSigner(issueSSHCertificate(t, ca, uuid.New(), uuid.New()))
+			require.Error(t, err)
+		})
+
+		t.Run("UnknownAuthority", func(t *testing.T) {
+			t.Parallel()
+			other := generateSSHCertificateAuthority(t)
+			_, err := conn.SSHClientWithSigner(issueSSHCertificate(t, other, agentID, uuid.New()))
+			require.Error(t, err)
+		})
+
+		// Certificates only govern the SSH server. Reconnecting PTYs and the
+		// file API are authorized by coderd before it brokers the connection.
+		t.Run("ReconnectingPTYNotRequired", func(t *testing.T) {
+			t.Parallel()
+			netConn, err := conn.ReconnectingPTY(uuid.NewString(), 128, 128, "/bin/bash")
+			require.NoError(t, err)
+			defer netConn.Close()
+			err = netConn.SetDeadline(time.Now().Add(testutil.WaitLong))
+			require.NoError(t, err)
+			data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+				Data: "echo certificate-not-required\r\n",
+			})
+			require.NoError(t, err)
+			_, err = netConn.Write(data)
+			require.NoError(t, err)
+			bufRead := bufio.NewReader(netConn)
+			for {
+				line, err := bufRead.ReadString('\n')
+				require.NoError(t, err)
+				if strings.Contains(line, "certificate-not-required") && !strings.Contains(line, "echo") {
+					break
+				}
+			}
+		})
+
+		t.Run("FilesNotRequired", func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+			defer cancel()
+			filePath := filepath.Join(t.TempDir(), "file")
+			_, err := conn.UploadFile(ctx, filePath, strings.NewReader("hello"))
+			require.NoError(t, err)
+		})
+	})
+
 	t.Run("SessionExec", func(t *testing.T) {
 		t.Parallel()
 		session := setupSSHSession(t, codersdk.WorkspaceAgentMetadata{})
@@ -180,6 +267,118 @@ func TestAgent(t *testing.T) {
 		}
 	})
 
+	t.Run("SessionRecording", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("ConPTY appears to be inconsistent on Windows.")
+		}
+		ca := generateSSHCertificateAuthority(t)
+		agentID := uuid.New()
+		agentClient := &client{
+			t:       t,
+			agentID: agentID,
+			metadata: codersdk.WorkspaceAgentMetadata{
+				DERPMap:        tailnettest.RunDERPAndSTUN(t),
+				RecordSessions: true,
+				// Recordings of SSH sessions are attributed to the user of
+				// the certificate.
+				SSHCertificateAuthorities: []string{string(ssh.MarshalAuthorizedKey(ca.PublicKey()))},
+				SSHCertificatePrincipal:   sshca.Principal(agentID),
+				SSHRequireCertificate:     true,
+			},
+			statsChan:   make(chan *codersdk.AgentStats),
+			coordinator: tailnet.NewCoordinator(),
+			// The agent must retry the upload that fails.
+			recordingFailures: 1,
+		}
+		conn := connectAgent(t, agentClient, 0)
+
+		// recorded returns the output in the recordings of the given type.
+		recorded := func(typ codersdk.SessionRecordingType) string {
+			var output strings.Builder
+			for _, recording := range agentClient.getSessionRecordings(typ) {
+				reader, err := asciicast.NewReader(bytes.NewReader(recording))
+				if !assert.NoError(t, err) {
+					return ""
+				}
+				for {
+					event, err := reader.Next()
+					if xerrors.Is(err, io.EOF) {
+						break
+					}
+					if !assert.NoError(t, err) {
+						return ""
+					}
+					if event.Type == asciicast.EventTypeOutput {
+						output.WriteString(event.Data)
+					}
+				}
+			}
+			return output.String()
+		}
+
+		t.Run("SSH", func(t *testing.T) {
+			t.Parallel()
+			userID := uuid.New()
+			sshClient, err := conn.SSHClientWithSigner(issueSSHCertificate(t, ca, agentID, userID))
+			require.NoError(t, err)
+			defer sshClient.Close()
+			session, err := sshClient.NewSession()
+			require.NoError(t, err)
+			defer session.Close()
+			err = session.RequestPty("xterm", 128, 128, ssh.TerminalModes{})
+			require.NoError(t, err)
+			output, err := session.Output("echo recorded-ssh")
+			require.NoError(t, err)
+			require.Contains(t, string(output), "recorded-ssh")
+
+			require.Eventually(t, func() bool {
+				return strings.Contains(recorded(codersdk.SessionRecordingTypeSSH), "recorded-ssh")
+			}, testutil.WaitLong, testutil.IntervalFast)
+			require.Equal(t, []uuid.UUID{userID}, agentClient.getSessionRecordingUserIDs(codersdk.SessionRecordingTypeSSH))
+
+			// Recordings are streamed from temporary files, which are
+			// removed once they're uploaded.
+			files := agentClient.getSessionRecordingFiles()
+			require.NotEmpty(t, files)
+			require.Eventually(t, func() bool {
+				for _, name := range files {
+					_, err := os.Stat(name)
+					if !os.IsNotExist(err) {
+						return false
+					}
+				}
+				return true
+			}, testutil.WaitLong, testutil.IntervalFast)
+		})
+
+		t.Run("ReconnectingPTY", func(t *testing.T) {
+			t.Parallel()
+			ctx, _ := testutil.Context(t)
+			userID := uuid.New()
+			netConn, err := conn.DialReconnectingPTY(ctx, codersdk.ReconnectingPTYInit{
+				ID:      uuid.NewString(),
+				Height:  128,
+				Width:   128,
+				Command: "/bin/bash",
+				UserID:  userID,
+			})
+			require.NoError(t, err)
+			defer netConn.Close()
+			data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+				Data: "echo recorded-rpty; exit\r\n",
+			})
+			require.NoError(t, err)
+			_, err = netConn.Write(data)
+			require.NoError(t, err)
+
+			require.Eventually(t, func() bool {
+				return strings.Contains(recorded(codersdk.SessionRecordingTypeReconnectingPTY), "recorded-rpty\r\n")
+			}, testutil.WaitLong, testutil.IntervalFast)
+			require.Equal(t, []uuid.UUID{userID}, agentClient.getSessionRecordingUserIDs(codersdk.SessionRecordingTypeReconnectingPTY))
+		})
+	})
+
 	t.Run("LocalForwarding", func(t *testing.T) {
 		t.Parallel()
 		random, err := net.Listen("tcp", "127.0.0.1:0")
@@ -214,6 +413,61 @@ func TestAgent(t *testing.T) {
 		<-done
 	})
 
+	t.Run("LocalForwardingAllowlist", func(t *testing.T) {
+		t.Parallel()
+		allowed, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer allowed.Close()
+		denied, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer denied.Close()
+		serve := func(listener net.Listener, data string) {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				_, _ = conn.Write([]byte(data))
+				_ = conn.Close()
+			}
+		}
+		go serve(allowed, "allowed")
+		go serve(denied, "denied")
+
+		allowedPort := allowed.Addr().(*net.TCPAddr).Port
+		deniedPort := denied.Addr().(*net.TCPAddr).Port
+		conn, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{
+			PortForwardingAllowlist: []string{strconv.Itoa(allowedPort)},
+		}, 0)
+		sshClient, err := conn.SSHClient()
+		require.NoError(t, err)
+		defer sshClient.Close()
+
+		forwarded, err := sshClient.Dial("tcp", allowed.Addr().String())
+		require.NoError(t, err)
+		_ = forwarded.Close()
+		_, err = sshClient.Dial("tcp", denied.Addr().String())
+		require.Error(t, err)
+
+		// Ports forwarded over tailnet, e.g. with "coder port-forward" or
+		// apps, are restricted too.
+		ctx, _ := testutil.Context(t)
+		readTailnet := func(port int) (string, error) {
+			tailnetConn, err := conn.DialContextTCP(ctx, netip.AddrPortFrom(codersdk.TailnetIP, uint16(port)))
+			if err != nil {
+				return "", err
+			}
+			defer tailnetConn.Close()
+			data, err := io.ReadAll(tailnetConn)
+			return string(data), err
+		}
+		data, err := readTailnet(allowedPort)
+		require.NoError(t, err)
+		require.Equal(t, "allowed", data)
+		data, _ = readTailnet(deniedPort)
+		require.Empty(t, data)
+	})
+
 	t.Run("SFTP", func(t *testing.T) {
 		t.Parallel()
 		u, err := user.Current()
@@ -346,6 +600,216 @@ func TestAgent(t *testing.T) {
 		}
 	})
 
+	t.Run("StartupLogs", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("This test uses a POSIX shell script.")
+		}
+		agentClient, _ := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+			StartupScript: "echo hello; echo world",
+		})
+
+		var logs []codersdk.StartupLog
+		require.Eventually(t, func() bool {
+			logs = agentClient.getStartupLogs()
+			return len(logs) > 0 && logs[len(logs)-1].EOF
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Len(t, logs, 3)
+		require.Equal(t, "hello", logs[0].Output)
+		require.Equal(t, "world", logs[1].Output)
+		require.Empty(t, logs[2].Output)
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("This test uses a POSIX shell script.")
+		}
+		agentClient, _ := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+			Metadata: []codersdk.WorkspaceAgentMetadataDescription{
+				{Key: "greeting", Script: "echo hello", Interval: 1},
+				{Key: "broken", Script: "echo oops; exit 1", Interval: 10},
+				// Two-byte runes after a single byte, so the limit falls
+				// within a rune.
+				{Key: "long", Script: "printf a; yes é | head -n 100000 | tr -d '\\n'", Interval: 10},
+			},
+		})
+
+		var first codersdk.WorkspaceAgentMetadataResult
+		require.Eventually(t, func() bool {
+			results := agentClient.getMetadataResults()
+			if len(results) != 3 {
+				return false
+			}
+			first = results["greeting"]
+			return true
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Equal(t, "hello", first.Value)
+		require.Empty(t, first.Error)
+
+		broken := agentClient.getMetadataResults()["broken"]
+		require.Equal(t, "oops", broken.Value)
+		require.NotEmpty(t, broken.Error)
+
+		long := agentClient.getMetadataResults()["long"]
+		require.Empty(t, long.Error)
+		require.Len(t, long.Value, 10<<10-1)
+		require.True(t, utf8.ValidString(long.Value))
+
+		// The greeting is collected again once its interval elapses.
+		require.Eventually(t, func() bool {
+			return agentClient.getMetadataResults()["greeting"].CollectedAt.After(first.CollectedAt)
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Ready", func(t *testing.T) {
+			t.Parallel()
+			agentClient, _ := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript: "true",
+			})
+			want := []codersdk.WorkspaceAgentLifecycle{
+				codersdk.WorkspaceAgentLifecycleStarting,
+				codersdk.WorkspaceAgentLifecycleReady,
+			}
+			var got []codersdk.WorkspaceAgentLifecycle
+			assert.Eventually(t, func() bool {
+				got = agentClient.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == want[len(want)-1]
+			}, testutil.WaitShort, testutil.IntervalFast)
+			require.Equal(t, want, got)
+		})
+
+		t.Run("StartTimeout", func(t *testing.T) {
+			t.Parallel()
+			agentClient, _ := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript:        "sleep 5",
+				StartupScriptTimeout: time.Nanosecond,
+			})
+			want := []codersdk.WorkspaceAgentLifecycle{
+				codersdk.WorkspaceAgentLifecycleStarting,
+				codersdk.WorkspaceAgentLifecycleStartTimeout,
+			}
+			var got []codersdk.WorkspaceAgentLifecycle
+			assert.Eventually(t, func() bool {
+				got = agentClient.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == want[len(want)-1]
+			}, testutil.WaitShort, testutil.IntervalFast)
+			require.Equal(t, want, got)
+		})
+
+		t.Run("StartError", func(t *testing.T) {
+			t.Parallel()
+			agentClient, _ := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript: "false",
+			})
+			want := []codersdk.WorkspaceAgentLifecycle{
+				codersdk.WorkspaceAgentLifecycleStarting,
+				codersdk.WorkspaceAgentLifecycleStartError,
+			}
+			var got []codersdk.WorkspaceAgentLifecycle
+			assert.Eventually(t, func() bool {
+				got = agentClient.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == want[len(want)-1]
+			}, testutil.WaitShort, testutil.IntervalFast)
+			require.Equal(t, want, got)
+		})
+
+		t.Run("Off", func(t *testing.T) {
+			t.Parallel()
+			agentClient, closer := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript: "true",
+			})
+			assert.Eventually(t, func() bool {
+				got := agentClient.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleReady
+			}, testutil.WaitShort, testutil.IntervalFast)
+
+			// Close reports the final state before returning.
+			err := closer.Close()
+			require.NoError(t, err)
+			got := agentClient.getLifecycleStates()
+			require.Equal(t, codersdk.WorkspaceAgentLifecycleOff, got[len(got)-1])
+		})
+
+		t.Run("ShutdownScript", func(t *testing.T) {
+			t.Parallel()
+			tempPath := filepath.Join(t.TempDir(), "shutdown.txt")
+			agentClient, _ := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript:  "true",
+				ShutdownScript: "echo bye > " + tempPath,
+			})
+			assert.Eventually(t, func() bool {
+				got := agentClient.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleReady
+			}, testutil.WaitShort, testutil.IntervalFast)
+
+			// Signal the agent the same way coderd does on a stop transition.
+			close(agentClient.shutdown)
+			want := []codersdk.WorkspaceAgentLifecycle{
+				codersdk.WorkspaceAgentLifecycleStarting,
+				codersdk.WorkspaceAgentLifecycleReady,
+				codersdk.WorkspaceAgentLifecycleShuttingDown,
+				codersdk.WorkspaceAgentLifecycleOff,
+			}
+			var got []codersdk.WorkspaceAgentLifecycle
+			assert.Eventually(t, func() bool {
+				got = agentClient.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == want[len(want)-1]
+			}, testutil.WaitShort, testutil.IntervalFast)
+			require.Equal(t, want, got)
+
+			content, err := os.ReadFile(tempPath)
+			require.NoError(t, err)
+			require.Equal(t, "bye", strings.TrimSpace(string(content)))
+		})
+
+		t.Run("ShutdownError", func(t *testing.T) {
+			t.Parallel()
+			agentClient, closer := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript:  "true",
+				ShutdownScript: "false",
+			})
+			assert.Eventually(t, func() bool {
+				got := agentClient.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleReady
+			}, testutil.WaitShort, testutil.IntervalFast)
+
+			// Closing the agent runs the shutdown script if coderd never
+			// signaled it.
+			err := closer.Close()
+			require.NoError(t, err)
+			got := agentClient.getLifecycleStates()
+			require.Equal(t, []codersdk.WorkspaceAgentLifecycle{
+				codersdk.WorkspaceAgentLifecycleStarting,
+				codersdk.WorkspaceAgentLifecycleReady,
+				codersdk.WorkspaceAgentLifecycleShuttingDown,
+				codersdk.WorkspaceAgentLifecycleShutdownError,
+			}, got)
+		})
+
+		t.Run("ShutdownTimeout", func(t *testing.T) {
+			t.Parallel()
+			agentClient, closer := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript:         "true",
+				ShutdownScript:        "sleep 30",
+				ShutdownScriptTimeout: time.Millisecond,
+			})
+			assert.Eventually(t, func() bool {
+				got := agentClient.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleReady
+			}, testutil.WaitShort, testutil.IntervalFast)
+
+			// Close must not wait for the script past its timeout.
+			err := closer.Close()
+			require.NoError(t, err)
+			got := agentClient.getLifecycleStates()
+			require.Equal(t, codersdk.WorkspaceAgentLifecycleShutdownTimeout, got[len(got)-1])
+		})
+	})
+
 	t.Run("StartupScript", func(t *testing.T) {
 		t.Parallel()
 		tempPath := filepath.Join(t.TempDir(), "content.txt")
@@ -434,6 +898,86 @@ func TestAgent(t *testing.T) {
 		expectLine(matchEchoOutput)
 	})
 
+	t.Run("ReconnectingPTYResume", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("ConPTY appears to be inconsistent on Windows.")
+		}
+
+		conn, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		id := uuid.NewString()
+
+		// Attaching to a PTY that doesn't exist must not start one.
+		_, attach, err := conn.AttachReconnectingPTY(ctx, codersdk.ReconnectingPTYInit{
+			ID:         id,
+			Height:     100,
+			Width:      100,
+			Command:    "/bin/bash",
+			AttachOnly: true,
+		})
+		require.NoError(t, err)
+		require.True(t, attach.Exited)
+
+		netConn, attach, err := conn.AttachReconnectingPTY(ctx, codersdk.ReconnectingPTYInit{
+			ID:      id,
+			Height:  100,
+			Width:   100,
+			Command: "/bin/bash",
+		})
+		require.NoError(t, err)
+		require.False(t, attach.Exited)
+		require.EqualValues(t, 0, attach.Offset)
+		counter := &countingReader{r: netConn}
+		bufRead := bufio.NewReader(counter)
+
+		time.Sleep(100 * time.Millisecond)
+		data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+			Data: "echo one\r\n",
+		})
+		require.NoError(t, err)
+		_, err = netConn.Write(data)
+		require.NoError(t, err)
+		for {
+			line, err := bufRead.ReadString('\n')
+			require.NoError(t, err)
+			if strings.Contains(line, "one") && !strings.Contains(line, "echo") {
+				break
+			}
+		}
+		_ = netConn.Close()
+
+		// Resuming replays only what came after the received output.
+		netConn, attach, err = conn.AttachReconnectingPTY(ctx, codersdk.ReconnectingPTYInit{
+			ID:         id,
+			Height:     100,
+			Width:      100,
+			Offset:     counter.n,
+			AttachOnly: true,
+		})
+		require.NoError(t, err)
+		require.False(t, attach.Exited)
+		require.Equal(t, counter.n, attach.Offset)
+		defer netConn.Close()
+		bufRead = bufio.NewReader(netConn)
+
+		data, err = json.Marshal(codersdk.ReconnectingPTYRequest{
+			Data: "echo two\r\n",
+		})
+		require.NoError(t, err)
+		_, err = netConn.Write(data)
+		require.NoError(t, err)
+		for {
+			line, err := bufRead.ReadString('\n')
+			require.NoError(t, err)
+			require.NotContains(t, line, "one", "output was replayed twice")
+			if strings.Contains(line, "two") && !strings.Contains(line, "echo") {
+				break
+			}
+		}
+	})
+
 	t.Run("Dial", func(t *testing.T) {
 		t.Parallel()
 
@@ -500,6 +1044,79 @@ func TestAgent(t *testing.T) {
 		}
 	})
 
+	t.Run("Files", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		conn, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "file")
+		content := []byte("hello from the workspace")
+		sum := sha256.Sum256(content)
+
+		uploaded, err := conn.UploadFile(ctx, filePath, bytes.NewReader(content))
+		require.NoError(t, err)
+		require.Equal(t, filePath, uploaded.Path)
+		require.EqualValues(t, len(content), uploaded.Size)
+		require.Equal(t, hex.EncodeToString(sum[:]), uploaded.SHA256)
+		written, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		require.Equal(t, content, written)
+
+		checksum, err := conn.FileChecksum(ctx, filePath)
+		require.NoError(t, err)
+		require.Equal(t, uploaded, checksum)
+
+		download, size, err := conn.DownloadFile(ctx, filePath, 6)
+		require.NoError(t, err)
+		defer download.Close()
+		downloaded, err := io.ReadAll(download)
+		require.NoError(t, err)
+		require.EqualValues(t, len(content)-6, size)
+		require.Equal(t, content[6:], downloaded)
+
+		download, _, err = conn.DownloadFile(ctx, filePath, int64(len(content)))
+		require.NoError(t, err)
+		downloaded, err = io.ReadAll(download)
+		require.NoError(t, err)
+		require.Empty(t, downloaded)
+
+		var apiErr *codersdk.Error
+		_, _, err = conn.DownloadFile(ctx, filepath.Join(dir, "missing"), 0)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+		_, err = conn.FileChecksum(ctx, dir)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		_, err = conn.UploadFile(ctx, filepath.Join(dir, "missing", "file"), bytes.NewReader(content))
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("FilesSlowTransfer", func(t *testing.T) {
+		t.Parallel()
+		if testing.Short() {
+			t.Skip("Transfers for longer than the statistics server timeout.")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong*2)
+		defer cancel()
+		conn, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		filePath := filepath.Join(t.TempDir(), "file")
+
+		// The statistics server times out requests after 20 seconds, the
+		// file API must not.
+		content := bytes.Repeat([]byte("a"), 22)
+		uploaded, err := conn.UploadFile(ctx, filePath, &slowReader{
+			r:     bytes.NewReader(content),
+			delay: time.Second,
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, len(content), uploaded.Size)
+		written, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		require.Equal(t, content, written)
+	})
+
 	t.Run("Speedtest", func(t *testing.T) {
 		t.Parallel()
 		t.Skip("This test is relatively flakey because of Tailscale's speedtest code...")
@@ -552,6 +1169,33 @@ func TestAgent(t *testing.T) {
 		}, testutil.WaitShort, testutil.IntervalFast)
 	})
 
+	t.Run("DERPMapUpdate", func(t *testing.T) {
+		t.Parallel()
+		client, _ := setupAgentWithClient(t, codersdk.WorkspaceAgentMetadata{})
+		require.Eventually(t, func() bool {
+			node := client.coordinator.Node(client.agentID)
+			return node != nil && node.PreferredDERP == 1
+		}, testutil.WaitLong, testutil.IntervalFast)
+
+		// Move the agent to a different region without reconnecting.
+		derpMap := tailnettest.RunDERPAndSTUN(t)
+		region := derpMap.Regions[1]
+		region.RegionID = 2
+		region.Nodes[0].RegionID = 2
+		derpMap.Regions = map[int]*tailcfg.DERPRegion{2: region}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out pushing derp map")
+		case client.derpMaps <- derpMap:
+		}
+		require.Eventually(t, func() bool {
+			return client.coordinator.Node(client.agentID).PreferredDERP == 2
+		}, testutil.WaitLong, testutil.IntervalFast)
+	})
+
 	t.Run("WriteVSCodeConfigs", func(t *testing.T) {
 		t.Parallel()
 		client := &client{
@@ -635,6 +1279,34 @@ func setupSSHSession(t *testing.T, options codersdk.WorkspaceAgentMetadata) *ssh
 	return session
 }
 
+func generateSSHCertificateAuthority(t *testing.T) ssh.Signer {
+	t.Helper()
+	key, err := sshca.GenerateKey()
+	require.NoError(t, err)
+	ca, err := sshca.ParseKey(key)
+	require.NoError(t, err)
+	return ca
+}
+
+func issueSSHCertificate(t *testing.T, ca ssh.Signer, agentID, userID uuid.UUID) ssh.Signer {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	cert, err := sshca.Issue(ca, sshca.IssueOptions{
+		PublicKey: signer.PublicKey(),
+		Username:  "test",
+		UserID:    userID,
+		AgentID:   agentID,
+		TTL:       time.Hour,
+	})
+	require.NoError(t, err)
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	require.NoError(t, err)
+	return certSigner
+}
+
 type closeFunc func() error
 
 func (c closeFunc) Close() error {
@@ -648,17 +1320,23 @@ func setupAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata, ptyTimeo
 	if metadata.DERPMap == nil {
 		metadata.DERPMap = tailnettest.RunDERPAndSTUN(t)
 	}
-	coordinator := tailnet.NewCoordinator()
-	agentID := uuid.New()
 	statsCh := make(chan *codersdk.AgentStats)
+	conn := connectAgent(t, &client{
+		t:           t,
+		agentID:     uuid.New(),
+		metadata:    metadata,
+		statsChan:   statsCh,
+		coordinator: tailnet.NewCoordinator(),
+	}, ptyTimeout)
+	return conn, statsCh
+}
+
+// connectAgent starts an agent with the given fake client and returns a
+// connection to it.
+func connectAgent(t *testing.T, agentClient *client, ptyTimeout time.Duration) *codersdk.AgentConn {
+	t.Helper()
 	closer := agent.New(agent.Options{
-		Client: &client{
-			t:           t,
-			agentID:     agentID,
-			metadata:    metadata,
-			statsChan:   statsCh,
-			coordinator: coordinator,
-		},
+		Client:                 agentClient,
 		Logger:                 slogtest.Make(t, nil).Leveled(slog.LevelDebug),
 		ReconnectingPTYTimeout: ptyTimeout,
 	})
@@ -667,7 +1345,7 @@ func setupAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata, ptyTimeo
 	})
 	conn, err := tailnet.NewConn(&tailnet.Options{
 		Addresses: []netip.Prefix{netip.PrefixFrom(tailnet.IP(), 128)},
-		DERPMap:   metadata.DERPMap,
+		DERPMap:   agentClient.metadata.DERPMap,
 		Logger:    slogtest.Make(t, nil).Named("client").Leveled(slog.LevelDebug),
 	})
 	require.NoError(t, err)
@@ -677,14 +1355,40 @@ func setupAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata, ptyTimeo
 		_ = serverConn.Close()
 		_ = conn.Close()
 	})
-	go coordinator.ServeClient(serverConn, uuid.New(), agentID)
+	go agentClient.coordinator.ServeClient(serverConn, uuid.New(), agentClient.agentID)
 	sendNode, _ := tailnet.ServeCoordinator(clientConn, func(node []*tailnet.Node) error {
 		return conn.UpdateNodes(node)
 	})
 	conn.SetNodeCallback(sendNode)
 	return &codersdk.AgentConn{
 		Conn: conn,
-	}, statsCh
+	}
+}
+
+// setupAgentWithClient starts an agent with a fake client, for tests that
+// inspect what the agent reports rather than connecting to it.
+func setupAgentWithClient(t *testing.T, metadata codersdk.WorkspaceAgentMetadata) (*client, io.Closer) {
+	t.Helper()
+	if metadata.DERPMap == nil {
+		metadata.DERPMap = tailnettest.RunDERPAndSTUN(t)
+	}
+	agentClient := &client{
+		t:           t,
+		agentID:     uuid.New(),
+		metadata:    metadata,
+		statsChan:   make(chan *codersdk.AgentStats),
+		coordinator: tailnet.NewCoordinator(),
+		shutdown:    make(chan struct{}),
+		derpMaps:    make(chan *tailcfg.DERPMap),
+	}
+	closer := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Leveled(slog.LevelDebug),
+	})
+	t.Cleanup(func() {
+		_ = closer.Close()
+	})
+	return agentClient, closer
 }
 
 var dialTestPayload = []byte("dean-was-here123")
@@ -725,6 +1429,25 @@ type client struct {
 	statsChan          chan *codersdk.AgentStats
 	coordinator        tailnet.Coordinator
 	lastWorkspaceAgent func()
+
+	// shutdown is closed by tests to signal the agent to shut down.
+	shutdown chan struct{}
+	// derpMaps is sent to by tests to push a DERP map to the agent.
+	derpMaps chan *tailcfg.DERPMap
+
+	mu              sync.Mutex // Protects following.
+	startupLogs     []codersdk.StartupLog
+	lifecycleStates []codersdk.WorkspaceAgentLifecycle
+	metadataResults map[string]codersdk.WorkspaceAgentMetadataResult
+	recordings      map[codersdk.SessionRecordingType][][]byte
+	// recordingFailures is how many session recording uploads fail before
+	// they start succeeding.
+	recordingFailures int
+	// recordingFiles are the names of the files recordings were uploaded
+	// from.
+	recordingFiles []string
+	// recordingUserIDs are the users recordings were attributed to.
+	recordingUserIDs map[codersdk.SessionRecordingType][]uuid.UUID
 }
 
 func (c *client) WorkspaceAgentMetadata(_ context.Context) (codersdk.WorkspaceAgentMetadata, error) {
@@ -787,3 +1510,149 @@ func (*client) PostWorkspaceAgentAppHealth(_ context.Context, _ codersdk.PostWor
 func (*client) PostWorkspaceAgentVersion(_ context.Context, _ string) error {
 	return nil
 }
+
+func (c *client) PatchStartupLogs(_ context.Context, req codersdk.PatchStartupLogs) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.startupLogs = append(c.startupLogs, req.Logs...)
+	return nil
+}
+
+func (c *client) PostWorkspaceAgentLifecycle(_ context.Context, req codersdk.PostWorkspaceAgentLifecycleRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lifecycleStates = append(c.lifecycleStates, req.State)
+	return nil
+}
+
+func (c *client) WaitForWorkspaceAgentShutdown(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.shutdown:
+		return nil
+	}
+}
+
+func (c *client) PostWorkspaceAgentMetadata(_ context.Context, key string, req codersdk.PostWorkspaceAgentMetadataRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metadataResults == nil {
+		c.metadataResults = make(map[string]codersdk.WorkspaceAgentMetadataResult)
+	}
+	c.metadataResults[key] = codersdk.WorkspaceAgentMetadataResult(req)
+	return nil
+}
+
+func (c *client) PostWorkspaceAgentSessionRecording(_ context.Context, typ codersdk.SessionRecordingType, userID uuid.UUID, recording io.Reader) (codersdk.WorkspaceSessionRecording, error) {
+	data, err := io.ReadAll(recording)
+	if err != nil {
+		return codersdk.WorkspaceSessionRecording{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if file, ok := recording.(*os.File); ok {
+		c.recordingFiles = append(c.recordingFiles, file.Name())
+	}
+	if c.recordingFailures > 0 {
+		c.recordingFailures--
+		return codersdk.WorkspaceSessionRecording{}, xerrors.New("coderd is unavailable")
+	}
+	if c.recordings == nil {
+		c.recordings = make(map[codersdk.SessionRecordingType][][]byte)
+		c.recordingUserIDs = make(map[codersdk.SessionRecordingType][]uuid.UUID)
+	}
+	c.recordings[typ] = append(c.recordings[typ], data)
+	c.recordingUserIDs[typ] = append(c.recordingUserIDs[typ], userID)
+	return codersdk.WorkspaceSessionRecording{
+		ID:     uuid.New(),
+		Type:   typ,
+		UserID: userID,
+	}, nil
+}
+
+func (c *client) WatchWorkspaceAgentDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error) {
+	derpMaps := make(chan *tailcfg.DERPMap)
+	go func() {
+		defer close(derpMaps)
+		for {
+			var derpMap *tailcfg.DERPMap
+			select {
+			case <-ctx.Done():
+				return
+			case derpMap = <-c.derpMaps:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case derpMaps <- derpMap:
+			}
+		}
+	}()
+	return derpMaps, nil
+}
+
+func (c *client) getMetadataResults() map[string]codersdk.WorkspaceAgentMetadataResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	results := make(map[string]codersdk.WorkspaceAgentMetadataResult, len(c.metadataResults))
+	for k, v := range c.metadataResults {
+		results[k] = v
+	}
+	return results
+}
+
+func (c *client) getLifecycleStates() []codersdk.WorkspaceAgentLifecycle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]codersdk.WorkspaceAgentLifecycle{}, c.lifecycleStates...)
+}
+
+func (c *client) getStartupLogs() []codersdk.StartupLog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]codersdk.StartupLog{}, c.startupLogs...)
+}
+
+func (c *client) getSessionRecordings(typ codersdk.SessionRecordingType) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recordings[typ]
+}
+
+func (c *client) getSessionRecordingUserIDs(typ codersdk.SessionRecordingType) []uuid.UUID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]uuid.UUID(nil), c.recordingUserIDs[typ]...)
+}
+
+func (c *client) getSessionRecordingFiles() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.recordingFiles...)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// slowReader reads one byte at a time, waiting delay before each read.
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.delay)
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return r.r.Read(p)
+}
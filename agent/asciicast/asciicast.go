@@ -0,0 +1,211 @@
+// Package asciicast reads and writes terminal recordings in the asciicast v2
+// format: https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+)
+
+// ContentType is the media type of asciicast recordings.
+const ContentType = "application/x-asciicast"
+
+// Header is the first line of a recording.
+type Header struct {
+	Version int `json:"version"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+	// Timestamp is the unix time the recording started at.
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+type EventType string
+
+const (
+	EventTypeOutput EventType = "o"
+	EventTypeInput  EventType = "i"
+	// EventTypeResize events hold the new size of the terminal as
+	// "<width>x<height>".
+	EventTypeResize EventType = "r"
+)
+
+// Event is a single line of a recording after the header.
+type Event struct {
+	// Time is how long after the start of the recording the event happened.
+	Time time.Duration
+	Type EventType
+	Data string
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Time.Seconds(), e.Type, e.Data})
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return xerrors.Errorf("event has %d elements, expected 3", len(raw))
+	}
+	var seconds float64
+	err = json.Unmarshal(raw[0], &seconds)
+	if err != nil {
+		return xerrors.Errorf("unmarshal time: %w", err)
+	}
+	err = json.Unmarshal(raw[1], &e.Type)
+	if err != nil {
+		return xerrors.Errorf("unmarshal type: %w", err)
+	}
+	err = json.Unmarshal(raw[2], &e.Data)
+	if err != nil {
+		return xerrors.Errorf("unmarshal data: %w", err)
+	}
+	e.Time = time.Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// Writer writes the events of a recording as they happen. It's safe for
+// concurrent use.
+type Writer struct {
+	mutex   sync.Mutex
+	w       io.Writer
+	encoder *json.Encoder
+	start   time.Time
+	// partial holds the end of the last output if it was cut in the middle
+	// of a UTF-8 sequence, since event data must be valid UTF-8.
+	partial []byte
+}
+
+// NewWriter writes the header to w and returns a Writer for the events.
+// The recording starts now.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	start := time.Now()
+	header.Version = 2
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(header)
+	if err != nil {
+		return nil, xerrors.Errorf("write header: %w", err)
+	}
+	return &Writer{
+		w:       w,
+		encoder: encoder,
+		start:   start,
+	}, nil
+}
+
+// Write records p as output of the terminal.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	data := append(w.partial, p...)
+	w.partial = nil
+	// Hold back an incomplete UTF-8 sequence at the end until the rest of it
+	// is written.
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if !utf8.RuneStart(data[len(data)-i]) {
+			continue
+		}
+		if !utf8.FullRune(data[len(data)-i:]) {
+			w.partial = append([]byte{}, data[len(data)-i:]...)
+			data = data[:len(data)-i]
+		}
+		break
+	}
+	if len(data) == 0 {
+		return len(p), nil
+	}
+	err := w.write(EventTypeOutput, string(data))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Resize records a change in the size of the terminal.
+func (w *Writer) Resize(width, height int) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.write(EventTypeResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+func (w *Writer) write(typ EventType, data string) error {
+	err := w.encoder.Encode(Event{
+		Time: time.Since(w.start),
+		Type: typ,
+		Data: data,
+	})
+	if err != nil {
+		return xerrors.Errorf("write event: %w", err)
+	}
+	return nil
+}
+
+// Reader reads the events of a recording.
+type Reader struct {
+	scanner *bufio.Scanner
+	header  Header
+}
+
+// NewReader reads the header of the recording in r.
+func NewReader(r io.Reader) (*Reader, error) {
+	scanner := bufio.NewScanner(r)
+	// Output events can be much longer than the default maximum line length.
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	reader := &Reader{scanner: scanner}
+	if !scanner.Scan() {
+		if scanner.Err() != nil {
+			return nil, xerrors.Errorf("read header: %w", scanner.Err())
+		}
+		return nil, xerrors.New("recording is empty")
+	}
+	err := json.Unmarshal(scanner.Bytes(), &reader.header)
+	if err != nil {
+		return nil, xerrors.Errorf("unmarshal header: %w", err)
+	}
+	if reader.header.Version != 2 {
+		return nil, xerrors.Errorf("unsupported asciicast version %d", reader.header.Version)
+	}
+	return reader, nil
+}
+
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next event of the recording, or io.EOF after the last
+// one.
+func (r *Reader) Next() (Event, error) {
+	for r.scanner.Scan() {
+		if len(r.scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		err := json.Unmarshal(r.scanner.Bytes(), &event)
+		if err != nil {
+			return Event{}, xerrors.Errorf("unmarshal event: %w", err)
+		}
+		return event, nil
+	}
+	if r.scanner.Err() != nil {
+		return Event{}, xerrors.Errorf("read event: %w", r.scanner.Err())
+	}
+	return Event{}, io.EOF
+}
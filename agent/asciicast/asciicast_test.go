@@ -0,0 +1,81 @@
+package asciicast_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent/asciicast"
+)
+
+func TestAsciicast(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		w, err := asciicast.NewWriter(&buf, asciicast.Header{
+			Width:   80,
+			Height:  24,
+			Command: "bash",
+		})
+		require.NoError(t, err)
+		_, err = w.Write([]byte("hello\r\n"))
+		require.NoError(t, err)
+		err = w.Resize(120, 40)
+		require.NoError(t, err)
+
+		r, err := asciicast.NewReader(&buf)
+		require.NoError(t, err)
+		header := r.Header()
+		require.Equal(t, 2, header.Version)
+		require.Equal(t, 80, header.Width)
+		require.Equal(t, 24, header.Height)
+		require.Equal(t, "bash", header.Command)
+		require.NotZero(t, header.Timestamp)
+
+		event, err := r.Next()
+		require.NoError(t, err)
+		require.Equal(t, asciicast.EventTypeOutput, event.Type)
+		require.Equal(t, "hello\r\n", event.Data)
+		event, err = r.Next()
+		require.NoError(t, err)
+		require.Equal(t, asciicast.EventTypeResize, event.Type)
+		require.Equal(t, "120x40", event.Data)
+		_, err = r.Next()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("SplitRune", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		w, err := asciicast.NewWriter(&buf, asciicast.Header{Width: 80, Height: 24})
+		require.NoError(t, err)
+		// "é" is two bytes in UTF-8.
+		data := []byte("café")
+		n, err := w.Write(data[:len(data)-1])
+		require.NoError(t, err)
+		require.Equal(t, len(data)-1, n)
+		_, err = w.Write(data[len(data)-1:])
+		require.NoError(t, err)
+
+		r, err := asciicast.NewReader(&buf)
+		require.NoError(t, err)
+		event, err := r.Next()
+		require.NoError(t, err)
+		require.Equal(t, "caf", event.Data)
+		event, err = r.Next()
+		require.NoError(t, err)
+		require.Equal(t, "é", event.Data)
+	})
+
+	t.Run("InvalidHeader", func(t *testing.T) {
+		t.Parallel()
+		_, err := asciicast.NewReader(bytes.NewBufferString(`{"version": 1}`))
+		require.Error(t, err)
+		_, err = asciicast.NewReader(&bytes.Buffer{})
+		require.Error(t, err)
+	})
+}
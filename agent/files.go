@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/go-chi/chi"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+// filesHandler serves the file transfer API. It's served separately from
+// the statistics endpoints, without read or write timeouts, since large
+// files can take a long time to transfer.
+func (a *agent) filesHandler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/api/v0/files", a.handleDownloadFile)
+	r.Put("/api/v0/files", a.handleUploadFile)
+	r.Get("/api/v0/files/checksum", a.handleFileChecksum)
+	return r
+}
+
+// resolveFilePath makes relative paths relative to the home directory of
+// the user, like the SFTP server does.
+func resolveFilePath(path string) (string, error) {
+	if path == "" {
+		return "", xerrors.New("path is required")
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	currentUser, err := user.Current()
+	if err != nil {
+		return "", xerrors.Errorf("get current user: %w", err)
+	}
+	return filepath.Join(currentUser.HomeDir, path), nil
+}
+
+// writeFileError responds with the status that matches a filesystem error.
+func writeFileError(rw http.ResponseWriter, r *http.Request, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		status = http.StatusForbidden
+	}
+	httpapi.Write(r.Context(), rw, status, codersdk.Response{
+		Message: message,
+		Detail:  err.Error(),
+	})
+}
+
+// openRegularFile opens a file for reading, and fails for directories and
+// other files that can't be downloaded.
+func openRegularFile(rw http.ResponseWriter, r *http.Request) (*os.File, fs.FileInfo, bool) {
+	path, err := resolveFilePath(r.URL.Query().Get("path"))
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid path.",
+			Detail:  err.Error(),
+		})
+		return nil, nil, false
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		writeFileError(rw, r, fmt.Sprintf("Failed to open %q.", path), err)
+		return nil, nil, false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		writeFileError(rw, r, fmt.Sprintf("Failed to stat %q.", path), err)
+		return nil, nil, false
+	}
+	if !info.Mode().IsRegular() {
+		_ = file.Close()
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("%q is not a regular file.", path),
+		})
+		return nil, nil, false
+	}
+	return file, info, true
+}
+
+// handleDownloadFile serves the contents of a file. Range requests are
+// supported, so interrupted downloads can be resumed.
+func (*agent) handleDownloadFile(rw http.ResponseWriter, r *http.Request) {
+	file, info, ok := openRegularFile(rw, r)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	rw.Header().Set("Content-Type", "application/octet-stream")
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": info.Name(),
+	}))
+	http.ServeContent(rw, r, info.Name(), info.ModTime(), file)
+}
+
+// handleUploadFile writes the body of the request to a file. The file is
+// only replaced once the upload is complete.
+func (*agent) handleUploadFile(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path, err := resolveFilePath(r.URL.Query().Get("path"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid path.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	mode := fs.FileMode(0o644)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && !info.Mode().IsRegular():
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("%q is not a regular file.", path),
+		})
+		return
+	case err == nil:
+		mode = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		writeFileError(rw, r, fmt.Sprintf("Failed to stat %q.", path), err)
+		return
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".upload-*")
+	if err != nil {
+		writeFileError(rw, r, fmt.Sprintf("Failed to create %q.", path), err)
+		return
+	}
+	defer func() {
+		// This fails once the file has been renamed.
+		_ = os.Remove(file.Name())
+	}()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), r.Body)
+	if err != nil {
+		_ = file.Close()
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to read the file from the request.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	err = file.Close()
+	if err != nil {
+		writeFileError(rw, r, fmt.Sprintf("Failed to write %q.", path), err)
+		return
+	}
+	err = os.Chmod(file.Name(), mode)
+	if err != nil {
+		writeFileError(rw, r, fmt.Sprintf("Failed to write %q.", path), err)
+		return
+	}
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		writeFileError(rw, r, fmt.Sprintf("Failed to write %q.", path), err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.AgentFileChecksum{
+		Path:   path,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+}
+
+func (*agent) handleFileChecksum(rw http.ResponseWriter, r *http.Request) {
+	file, _, ok := openRegularFile(rw, r)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		writeFileError(rw, r, fmt.Sprintf("Failed to read %q.", file.Name()), err)
+		return
+	}
+	httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.AgentFileChecksum{
+		Path:   file.Name(),
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+}
@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cdr.dev/slog"
+	utilstrings "github.com/coder/coder/coderd/util/strings"
+	"github.com/coder/coder/codersdk"
+)
+
+// metadataTickInterval is how often the agent checks whether any metadata
+// item is due to be collected. Item intervals are rounded up to it.
+var metadataTickInterval = time.Second
+
+// maxMetadataOutput limits how much of a metadata script's output is kept.
+const maxMetadataOutput = 10 << 10
+
+// reportMetadataLoop periodically runs the metadata scripts defined on the
+// agent and reports their results until the context is canceled. Each item
+// runs at most once at a time, so a slow script delays only itself.
+func (a *agent) reportMetadataLoop(ctx context.Context, descriptions []codersdk.WorkspaceAgentMetadataDescription) {
+	if len(descriptions) == 0 {
+		return
+	}
+
+	var (
+		lastCollected = make(map[string]time.Time, len(descriptions))
+		inFlight      = make(map[string]struct{}, len(descriptions))
+		done          = make(chan string, len(descriptions))
+	)
+
+	ticker := time.NewTicker(metadataTickInterval)
+	defer ticker.Stop()
+
+	collect := func() {
+		for _, md := range descriptions {
+			if _, ok := inFlight[md.Key]; ok {
+				continue
+			}
+			interval := time.Duration(md.Interval) * time.Second
+			if last, ok := lastCollected[md.Key]; ok && time.Since(last) < interval {
+				continue
+			}
+			inFlight[md.Key] = struct{}{}
+			lastCollected[md.Key] = time.Now()
+			go func(md codersdk.WorkspaceAgentMetadataDescription) {
+				defer func() {
+					done <- md.Key
+				}()
+				result := a.collectMetadata(ctx, md)
+				err := a.client.PostWorkspaceAgentMetadata(ctx, md.Key, codersdk.PostWorkspaceAgentMetadataRequest(result))
+				if err != nil && ctx.Err() == nil {
+					a.logger.Warn(ctx, "report metadata", slog.F("key", md.Key), slog.Error(err))
+				}
+			}(md)
+		}
+	}
+
+	collect()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case key := <-done:
+			delete(inFlight, key)
+		case <-ticker.C:
+			collect()
+		}
+	}
+}
+
+// collectMetadata runs the script of a single metadata item. The value is
+// the trimmed standard output; failures are reported in the error field.
+func (a *agent) collectMetadata(ctx context.Context, md codersdk.WorkspaceAgentMetadataDescription) codersdk.WorkspaceAgentMetadataResult {
+	// Keep a few bytes more than the limit, so the output can be truncated
+	// without splitting a rune.
+	var out bytes.Buffer
+	writer := &limitedWriter{w: &out, n: maxMetadataOutput + utf8.UTFMax}
+	result := codersdk.WorkspaceAgentMetadataResult{
+		// CollectedAt is set before running the script so the value
+		// reflects when collection began.
+		CollectedAt: time.Now(),
+	}
+
+	// A timeout of zero falls back to the interval, so a hung script
+	// can't stop the item from being collected again.
+	timeout := time.Duration(md.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = time.Duration(md.Interval) * time.Second
+	}
+	if timeout == 0 {
+		timeout = metadataTickInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd, err := a.createCommand(ctx, md.Script, nil)
+	if err != nil {
+		result.Error = fmt.Sprintf("create command: %s", err)
+		return result
+	}
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	// The error isn't fatal, the output is still useful to report.
+	err = cmd.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			result.Error = fmt.Sprintf("script timed out after %s", timeout)
+		} else {
+			result.Error = fmt.Sprintf("run cmd: %s", err)
+		}
+	}
+
+	result.Value = strings.TrimSpace(utilstrings.Truncate(out.String(), maxMetadataOutput))
+	return result
+}
+
+// limitedWriter writes at most n bytes to w and discards the rest. Writes
+// never fail because of the limit, so the command isn't interrupted by a
+// broken pipe.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.n <= 0 {
+		return len(p), nil
+	}
+	data := p
+	if len(data) > l.n {
+		data = data[:l.n]
+	}
+	n, err := l.w.Write(data)
+	l.n -= n
+	if err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
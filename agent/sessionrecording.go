@@ -0,0 +1,265 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/google/uuid"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/coderd/sshca"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/retry"
+)
+
+const (
+	// sessionRecordingChunkSize is the most output recorded as one event.
+	sessionRecordingChunkSize = 32 << 10
+	// sessionRecordingEventOverhead is the most an event takes besides its
+	// data, e.g. for the time.
+	sessionRecordingEventOverhead = 64
+)
+
+// sessionRecordingEventSize bounds the size of an event with n bytes of
+// output. JSON escapes control characters and invalid UTF-8 with six bytes
+// each.
+func sessionRecordingEventSize(n int) int64 {
+	return int64(n)*6 + sessionRecordingEventOverhead
+}
+
+// sessionRecording records the output of a terminal session to temporary
+// files, which are uploaded to coderd. A long session is split into parts
+// that each stay under the size coderd accepts. Each part is uploaded once
+// it's full, and the last one when the session ends. It does nothing if the
+// template doesn't record sessions.
+type sessionRecording struct {
+	typ codersdk.SessionRecordingType
+	// userID is who connected to the session, or uuid.Nil if it's unknown.
+	userID  uuid.UUID
+	command string
+	maxSize int64
+	// upload uploads a full part and removes its file.
+	upload func(part *os.File)
+	// uploads are the uploads of full parts that are running.
+	uploads sync.WaitGroup
+
+	mutex         sync.Mutex
+	width, height int
+	file          *os.File
+	written       *countingWriter
+	// events is the number of events in the current part.
+	events int
+	// cast is nil once the session ended.
+	cast *asciicast.Writer
+	// err is the first error writing the recording. Later output isn't
+	// recorded, but the session continues.
+	err error
+}
+
+func (a *agent) startSessionRecording(typ codersdk.SessionRecordingType, userID uuid.UUID, command string, width, height uint16) (*sessionRecording, error) {
+	metadata, ok := a.metadata.Load().(codersdk.WorkspaceAgentMetadata)
+	if !ok || !metadata.RecordSessions {
+		return &sessionRecording{}, nil
+	}
+	return newSessionRecording(typ, userID, command, int(width), int(height), codersdk.SessionRecordingMaxSize, func(part *os.File) {
+		a.uploadSessionRecordingPart(typ, userID, part)
+	})
+}
+
+func newSessionRecording(typ codersdk.SessionRecordingType, userID uuid.UUID, command string, width, height int, maxSize int64, upload func(part *os.File)) (*sessionRecording, error) {
+	r := &sessionRecording{
+		typ:     typ,
+		userID:  userID,
+		command: command,
+		maxSize: maxSize,
+		upload:  upload,
+		width:   width,
+		height:  height,
+	}
+	err := r.startPart()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// startPart starts recording to a new file.
+func (r *sessionRecording) startPart() error {
+	file, err := os.CreateTemp("", "coder-session-*.cast")
+	if err != nil {
+		return xerrors.Errorf("create recording file: %w", err)
+	}
+	written := &countingWriter{w: file}
+	cast, err := asciicast.NewWriter(written, asciicast.Header{
+		Width:   r.width,
+		Height:  r.height,
+		Command: r.command,
+	})
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return xerrors.Errorf("start recording: %w", err)
+	}
+	r.file = file
+	r.written = written
+	r.events = 0
+	r.cast = cast
+	return nil
+}
+
+// reserve makes room for an event of the given size, starting a new part
+// and uploading the current one if it would grow too large.
+func (r *sessionRecording) reserve(size int64) error {
+	if r.events == 0 || r.written.n+size <= r.maxSize {
+		r.events++
+		return nil
+	}
+	full := r.file
+	err := r.startPart()
+	if err != nil {
+		return err
+	}
+	r.events++
+	r.uploads.Add(1)
+	go func() {
+		defer r.uploads.Done()
+		r.upload(full)
+	}()
+	return nil
+}
+
+// Write records output of the session. It never fails, so a broken
+// recording doesn't interrupt the session.
+func (r *sessionRecording) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for rest := p; len(rest) > 0 && r.cast != nil && r.err == nil; {
+		chunk := rest
+		if len(chunk) > sessionRecordingChunkSize {
+			chunk = chunk[:sessionRecordingChunkSize]
+		}
+		rest = rest[len(chunk):]
+		r.err = r.reserve(sessionRecordingEventSize(len(chunk)))
+		if r.err == nil {
+			_, r.err = r.cast.Write(chunk)
+		}
+	}
+	return len(p), nil
+}
+
+func (r *sessionRecording) Resize(width, height uint16) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	// New parts start with the current size.
+	r.width, r.height = int(width), int(height)
+	if r.cast != nil && r.err == nil {
+		r.err = r.reserve(sessionRecordingEventOverhead)
+	}
+	if r.cast != nil && r.err == nil {
+		r.err = r.cast.Resize(int(width), int(height))
+	}
+}
+
+// finish ends the recording and returns the file of its last part, which
+// is nil if the session wasn't recorded.
+func (r *sessionRecording) finish() (*os.File, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cast = nil
+	return r.file, r.err
+}
+
+// uploadSessionRecording uploads the last part of the recording of a
+// session that ended, and waits for the uploads of the other parts.
+func (a *agent) uploadSessionRecording(r *sessionRecording) {
+	file, err := r.finish()
+	if err != nil {
+		a.logger.Error(context.Background(), "session recording is incomplete", slog.F("type", r.typ), slog.Error(err))
+	}
+	if file != nil {
+		a.uploadSessionRecordingPart(r.typ, r.userID, file)
+	}
+	r.uploads.Wait()
+}
+
+// uploadSessionRecordingPart uploads a part of a recording, retrying until
+// it succeeds or a minute passes. The file is removed either way, so failed
+// uploads don't fill up the disk.
+func (a *agent) uploadSessionRecordingPart(typ codersdk.SessionRecordingType, userID uuid.UUID, file *os.File) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	logger := a.logger.With(slog.F("type", typ), slog.F("path", file.Name()))
+	defer func() {
+		err := os.Remove(file.Name())
+		if err != nil {
+			logger.Error(ctx, "remove session recording", slog.Error(err))
+		}
+	}()
+
+	err := file.Close()
+	if err != nil {
+		logger.Error(ctx, "close session recording", slog.Error(err))
+		return
+	}
+
+	for retrier := retry.New(time.Second, 15*time.Second); retrier.Wait(ctx); {
+		recording, err := a.postSessionRecording(ctx, typ, userID, file.Name())
+		if err == nil {
+			logger.Debug(ctx, "uploaded session recording", slog.F("id", recording.ID))
+			return
+		}
+		var sdkErr *codersdk.Error
+		if xerrors.As(err, &sdkErr) && sdkErr.StatusCode() < http.StatusInternalServerError && sdkErr.StatusCode() != http.StatusTooManyRequests {
+			// Retrying won't change the outcome.
+			logger.Error(ctx, "upload session recording", slog.Error(err))
+			return
+		}
+		logger.Warn(ctx, "upload session recording", slog.Error(err))
+	}
+	logger.Error(ctx, "gave up uploading session recording", slog.Error(ctx.Err()))
+}
+
+// postSessionRecording streams a recording file to coderd. The file is
+// opened for each attempt, since the HTTP client closes request bodies.
+func (a *agent) postSessionRecording(ctx context.Context, typ codersdk.SessionRecordingType, userID uuid.UUID, name string) (codersdk.WorkspaceSessionRecording, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return codersdk.WorkspaceSessionRecording{}, xerrors.Errorf("open recording: %w", err)
+	}
+	defer file.Close()
+	return a.client.PostWorkspaceAgentSessionRecording(ctx, typ, userID, file)
+}
+
+// sshSessionUserID returns the user the certificate of an SSH session was
+// issued to, or uuid.Nil if the client didn't authenticate with one.
+func sshSessionUserID(session ssh.Session) uuid.UUID {
+	cert, ok := session.PublicKey().(*gossh.Certificate)
+	if !ok {
+		return uuid.Nil
+	}
+	userID, err := uuid.Parse(cert.Extensions[sshca.ExtensionUserID])
+	if err != nil {
+		return uuid.Nil
+	}
+	return userID
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
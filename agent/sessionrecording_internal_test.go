@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/codersdk"
+)
+
+func TestSessionRecordingParts(t *testing.T) {
+	t.Parallel()
+
+	const maxSize = 4 * sessionRecordingChunkSize * 6
+	var (
+		mutex sync.Mutex
+		parts []string
+	)
+	readPart := func(file *os.File) {
+		defer os.Remove(file.Name())
+		_ = file.Close()
+		data, err := os.ReadFile(file.Name())
+		require.NoError(t, err)
+		mutex.Lock()
+		parts = append(parts, string(data))
+		mutex.Unlock()
+	}
+	recording, err := newSessionRecording(codersdk.SessionRecordingTypeSSH, uuid.New(), "bash", 80, 24, maxSize, readPart)
+	require.NoError(t, err)
+
+	// Control characters are escaped, so the output takes six times its size.
+	output := strings.Repeat("\x01", maxSize/2)
+	_, err = recording.Write([]byte(output))
+	require.NoError(t, err)
+	recording.Resize(100, 30)
+	_, err = recording.Write([]byte(output))
+	require.NoError(t, err)
+
+	file, err := recording.finish()
+	require.NoError(t, err)
+	readPart(file)
+	recording.uploads.Wait()
+
+	require.Greater(t, len(parts), 1)
+	var recorded strings.Builder
+	for _, part := range parts {
+		require.LessOrEqual(t, len(part), maxSize)
+		reader, err := asciicast.NewReader(strings.NewReader(part))
+		require.NoError(t, err)
+		require.Equal(t, "bash", reader.Header().Command)
+		for {
+			event, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			if event.Type == asciicast.EventTypeOutput {
+				recorded.WriteString(event.Data)
+			}
+		}
+	}
+	require.Equal(t, len(output)*2, recorded.Len())
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/retry"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	// startupLogMaxLength is the maximum length of a single line of
+	// startup script output. Longer lines are truncated.
+	startupLogMaxLength = 1024
+	// startupLogsFlushInterval is how often queued startup logs are sent.
+	startupLogsFlushInterval = 250 * time.Millisecond
+)
+
+// startupLogsSender queues lines of startup script output and sends them
+// to coderd in batches.
+type startupLogsSender struct {
+	logger slog.Logger
+	client Client
+
+	mu     sync.Mutex
+	queued []codersdk.StartupLog
+	// overflowed is set when coderd refuses more logs because the
+	// agent has sent too many.
+	overflowed bool
+	// eof is set once the final log has been queued. Output after that
+	// is only written to the log file.
+	eof bool
+}
+
+func newStartupLogsSender(logger slog.Logger, client Client) *startupLogsSender {
+	return &startupLogsSender{
+		logger: logger.Named("startup-logs"),
+		client: client,
+	}
+}
+
+// scan reads lines from r and queues them until r is closed.
+func (s *startupLogsSender) scan(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		s.queue(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		s.logger.Warn(context.Background(), "scan startup script output", slog.Error(err))
+	}
+	// Drain anything left so the writer never blocks.
+	_, _ = io.Copy(io.Discard, r)
+}
+
+func (s *startupLogsSender) queue(output string) {
+	if len(output) > startupLogMaxLength {
+		output = output[:startupLogMaxLength]
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overflowed || s.eof {
+		return
+	}
+	s.queued = append(s.queued, codersdk.StartupLog{
+		CreatedAt: time.Now(),
+		Output:    strings.ToValidUTF8(output, ""),
+	})
+}
+
+// flushPeriodically sends queued logs until the context is canceled.
+func (s *startupLogsSender) flushPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(startupLogsFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := s.flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warn(ctx, "send startup logs", slog.Error(err))
+		}
+	}
+}
+
+// flushWithEOF queues the final log and sends everything that remains,
+// retrying until it succeeds or the context is canceled.
+func (s *startupLogsSender) flushWithEOF(ctx context.Context) {
+	s.mu.Lock()
+	s.eof = true
+	s.queued = append(s.queued, codersdk.StartupLog{
+		CreatedAt: time.Now(),
+		EOF:       true,
+	})
+	s.mu.Unlock()
+	for r := retry.New(100*time.Millisecond, 5*time.Second); r.Wait(ctx); {
+		err := s.flush(ctx)
+		if err == nil {
+			return
+		}
+		s.logger.Warn(ctx, "send final startup logs", slog.Error(err))
+	}
+}
+
+// flush sends all queued logs. Logs are only removed from the queue once
+// coderd has accepted them.
+func (s *startupLogsSender) flush(ctx context.Context) error {
+	s.mu.Lock()
+	if s.overflowed || len(s.queued) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	logs := s.queued
+	s.mu.Unlock()
+
+	err := s.client.PatchStartupLogs(ctx, codersdk.PatchStartupLogs{
+		Logs: logs,
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sdkErr *codersdk.Error
+	if errors.As(err, &sdkErr) && sdkErr.StatusCode() == http.StatusRequestEntityTooLarge {
+		s.logger.Warn(ctx, "startup logs exceeded the limit, no more will be sent")
+		s.overflowed = true
+		s.queued = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.queued = s.queued[len(logs):]
+	return nil
+}
@@ -7,11 +7,14 @@ import (
 
 	"github.com/go-chi/chi"
 
+	"cdr.dev/slog"
+
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet"
 )
 
-func (*agent) statisticsHandler() http.Handler {
+func (a *agent) statisticsHandler(network *tailnet.Conn) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", func(rw http.ResponseWriter, r *http.Request) {
 		httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.Response{
@@ -21,6 +24,18 @@ func (*agent) statisticsHandler() http.Handler {
 
 	lp := &listeningPortsHandler{}
 	r.Get("/api/v0/listening-ports", lp.handler)
+	r.Get("/api/v0/netcheck", func(rw http.ResponseWriter, r *http.Request) {
+		report, err := network.Netcheck(r.Context())
+		if err != nil {
+			a.logger.Warn(r.Context(), "netcheck", slog.Error(err))
+			httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Failed to run netcheck.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		httpapi.Write(r.Context(), rw, http.StatusOK, report)
+	})
 
 	return r
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/codersdk"
@@ -20,6 +21,12 @@ type AgentOptions struct {
 	Fetch         func(context.Context) (codersdk.WorkspaceAgent, error)
 	FetchInterval time.Duration
 	WarnInterval  time.Duration
+	// Wait blocks until the agent is ready, i.e. its startup script has
+	// finished. Otherwise a notice is printed if it's still starting.
+	Wait bool
+	// FetchLogs is optional. When set with Wait, startup script logs are
+	// printed while waiting for the agent to be ready.
+	FetchLogs func(ctx context.Context, agentID uuid.UUID, after int64, follow bool) (<-chan []codersdk.WorkspaceAgentStartupLog, io.Closer, error)
 }
 
 // Agent displays a spinning indicator that waits for a workspace agent to connect.
@@ -36,7 +43,7 @@ func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 		return xerrors.Errorf("fetch: %w", err)
 	}
 	if agent.Status == codersdk.WorkspaceAgentConnected {
-		return nil
+		return waitForReady(ctx, writer, agent, opts)
 	}
 	if agent.Status == codersdk.WorkspaceAgentDisconnected {
 		opts.WarnInterval = 0
@@ -102,6 +109,104 @@ func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 			continue
 		}
 		resourceMutex.Unlock()
+		spin.Stop()
+		return waitForReady(ctx, writer, agent, opts)
+	}
+}
+
+// waitForReady waits for the agent lifecycle to leave the starting states
+// if opts.Wait is set, printing the startup script logs in the meantime.
+func waitForReady(ctx context.Context, writer io.Writer, agent codersdk.WorkspaceAgent, opts AgentOptions) error {
+	if !agent.LifecycleState.Starting() {
+		printLifecycleWarning(writer, agent.LifecycleState)
+		return nil
+	}
+	if !opts.Wait {
+		_, _ = fmt.Fprintf(writer, "%s\n", Styles.Paragraph.Render(Styles.Prompt.String()+"Notice: The startup script is still running and your workspace may be incomplete."))
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(writer, "%s\n", Styles.Paragraph.Render(Styles.Prompt.String()+"Waiting for the startup script of "+Styles.Field.Render(agent.Name)+" to finish..."))
+	err := tailStartupLogs(ctx, writer, agent, opts)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(opts.FetchInterval)
+	defer ticker.Stop()
+	for agent.LifecycleState.Starting() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		agent, err = opts.Fetch(ctx)
+		if err != nil {
+			return xerrors.Errorf("fetch: %w", err)
+		}
+	}
+	printLifecycleWarning(writer, agent.LifecycleState)
+	return nil
+}
+
+func printLifecycleWarning(writer io.Writer, state codersdk.WorkspaceAgentLifecycle) {
+	var message string
+	switch state {
+	case codersdk.WorkspaceAgentLifecycleStartError:
+		message = "Warning: The startup script exited with an error and your workspace may be incomplete."
+	case codersdk.WorkspaceAgentLifecycleStartTimeout:
+		message = "Warning: The startup script timed out and your workspace may be incomplete."
+	default:
+		return
+	}
+	_, _ = fmt.Fprintf(writer, "%s\n", Styles.Paragraph.Render(Styles.Warn.Render(message)))
+}
+
+// tailStartupLogs prints the startup script logs of the agent until the
+// script finishes. Nothing is printed if it has already finished.
+func tailStartupLogs(ctx context.Context, writer io.Writer, agent codersdk.WorkspaceAgent, opts AgentOptions) error {
+	if opts.FetchLogs == nil {
+		return nil
+	}
+	logs, closer, err := opts.FetchLogs(ctx, agent.ID, 0, false)
+	if err != nil {
+		return xerrors.Errorf("fetch startup logs: %w", err)
+	}
+	backlog := <-logs
+	_ = closer.Close()
+	if agent.StartupLogsOverflowed {
 		return nil
 	}
+	var lastID int64
+	for _, log := range backlog {
+		if log.EOF {
+			return nil
+		}
+		lastID = log.ID
+	}
+
+	for _, log := range backlog {
+		_, _ = fmt.Fprintln(writer, log.Output)
+	}
+	logs, closer, err = opts.FetchLogs(ctx, agent.ID, lastID, true)
+	if err != nil {
+		return xerrors.Errorf("follow startup logs: %w", err)
+	}
+	defer closer.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chunk, ok := <-logs:
+			if !ok {
+				return nil
+			}
+			for _, log := range chunk {
+				if log.EOF {
+					continue
+				}
+				_, _ = fmt.Fprintln(writer, log.Output)
+			}
+		}
+	}
 }
@@ -2,9 +2,11 @@ package cliui_test
 
 import (
 	"context"
+	"io"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
@@ -49,3 +51,86 @@ func TestAgent(t *testing.T) {
 	disconnected.Store(true)
 	<-done
 }
+
+func TestAgentWait(t *testing.T) {
+	t.Parallel()
+	var ready atomic.Bool
+	ptty := ptytest.New(t)
+	followed := make(chan []codersdk.WorkspaceAgentStartupLog, 1)
+	cmd := &cobra.Command{
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cliui.Agent(cmd.Context(), cmd.OutOrStdout(), cliui.AgentOptions{
+				WorkspaceName: "example",
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					agent := codersdk.WorkspaceAgent{
+						Status:         codersdk.WorkspaceAgentConnected,
+						LifecycleState: codersdk.WorkspaceAgentLifecycleStarting,
+					}
+					if ready.Load() {
+						agent.LifecycleState = codersdk.WorkspaceAgentLifecycleStartError
+					}
+					return agent, nil
+				},
+				FetchLogs: func(_ context.Context, _ uuid.UUID, after int64, follow bool) (<-chan []codersdk.WorkspaceAgentStartupLog, io.Closer, error) {
+					if !follow {
+						logs := make(chan []codersdk.WorkspaceAgentStartupLog, 1)
+						logs <- []codersdk.WorkspaceAgentStartupLog{{ID: 1, Output: "first"}}
+						close(logs)
+						return logs, io.NopCloser(nil), nil
+					}
+					assert.EqualValues(t, 1, after)
+					return followed, io.NopCloser(nil), nil
+				},
+				FetchInterval: time.Millisecond,
+				Wait:          true,
+			})
+			return err
+		},
+	}
+	cmd.SetOutput(ptty.Output())
+	cmd.SetIn(ptty.Input())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := cmd.Execute()
+		assert.NoError(t, err)
+	}()
+	ptty.ExpectMatch("Waiting for the startup script")
+	ptty.ExpectMatch("first")
+	followed <- []codersdk.WorkspaceAgentStartupLog{{ID: 2, Output: "second"}, {ID: 3, EOF: true}}
+	ptty.ExpectMatch("second")
+	close(followed)
+	ready.Store(true)
+	ptty.ExpectMatch("exited with an error")
+	<-done
+}
+
+func TestAgentNoWait(t *testing.T) {
+	t.Parallel()
+	ptty := ptytest.New(t)
+	cmd := &cobra.Command{
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cliui.Agent(cmd.Context(), cmd.OutOrStdout(), cliui.AgentOptions{
+				WorkspaceName: "example",
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return codersdk.WorkspaceAgent{
+						Status:         codersdk.WorkspaceAgentConnected,
+						LifecycleState: codersdk.WorkspaceAgentLifecycleStarting,
+					}, nil
+				},
+				FetchInterval: time.Millisecond,
+			})
+			return err
+		},
+	}
+	cmd.SetOutput(ptty.Output())
+	cmd.SetIn(ptty.Input())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := cmd.Execute()
+		assert.NoError(t, err)
+	}()
+	ptty.ExpectMatch("still running")
+	<-done
+}
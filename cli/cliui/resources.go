@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strconv"
 
+	"github.com/google/uuid"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"golang.org/x/mod/semver"
 
@@ -20,6 +21,9 @@ type WorkspaceResourcesOptions struct {
 	HideAccess     bool
 	Title          string
 	ServerVersion  string
+	// AgentMetadata contains the latest metadata of each agent, keyed by
+	// agent ID. Agents without an entry display no metadata.
+	AgentMetadata map[uuid.UUID][]codersdk.WorkspaceAgentMetadatum
 }
 
 // WorkspaceResources displays the connection status and tree-view of provided resources.
@@ -110,6 +114,17 @@ func WorkspaceResources(writer io.Writer, resources []codersdk.WorkspaceResource
 				row = append(row, sshCommand)
 			}
 			tableWriter.AppendRow(row)
+
+			// Display the agent's metadata below it, continuing the tree.
+			indent := "│"
+			if index == len(resource.Agents)-1 {
+				indent = " "
+			}
+			for _, datum := range options.AgentMetadata[agent.ID] {
+				tableWriter.AppendRow(table.Row{
+					fmt.Sprintf("%s    %s", indent, renderAgentMetadatum(datum)),
+				})
+			}
 		}
 		tableWriter.AppendSeparator()
 	}
@@ -117,6 +132,21 @@ func WorkspaceResources(writer io.Writer, resources []codersdk.WorkspaceResource
 	return err
 }
 
+func renderAgentMetadatum(datum codersdk.WorkspaceAgentMetadatum) string {
+	name := datum.Description.DisplayName
+	if name == "" {
+		name = datum.Description.Key
+	}
+	value := datum.Result.Value
+	switch {
+	case datum.Result.Error != "":
+		value = Styles.Error.Render(datum.Result.Error)
+	case datum.Result.CollectedAt.IsZero():
+		value = Styles.Placeholder.Render("(not collected yet)")
+	}
+	return Styles.Keyword.Render(name+":") + " " + value
+}
+
 func renderAgentStatus(agent codersdk.WorkspaceAgent) string {
 	switch agent.Status {
 	case codersdk.WorkspaceAgentConnecting:
@@ -147,3 +177,58 @@ func renderAgentVersion(agentVersion, serverVersion string) string {
 	}
 	return Styles.Keyword.Render(agentVersion)
 }
+
+// WorkspaceUpdateDiff displays the resources and parameters that change when a
+// workspace is updated.
+// ┌──────────────────────────────────────────────────┐
+// │ Update Preview                                   │
+// ├──────────────────────────────────────────────────┤
+// │ CHANGE    RESOURCE / PARAMETER                   │
+// │ + added   docker_volume.home                     │
+// │ ~ changed docker_container.workspace             │
+// │ ~ changed region: us-east → eu-west              │
+// └──────────────────────────────────────────────────┘
+func WorkspaceUpdateDiff(writer io.Writer, diff codersdk.WorkspaceUpdateDiff) error {
+	if len(diff.Resources) == 0 && len(diff.Parameters) == 0 {
+		_, err := fmt.Fprintln(writer, Styles.Paragraph.Render("Updating won't change any resources or parameters."))
+		return err
+	}
+
+	tableWriter := table.NewWriter()
+	tableWriter.SetTitle("Update Preview")
+	tableWriter.SetStyle(table.StyleLight)
+	tableWriter.Style().Options.SeparateColumns = false
+	tableWriter.AppendHeader(table.Row{"Change", "Resource / Parameter"})
+	for _, resource := range diff.Resources {
+		tableWriter.AppendRow(table.Row{
+			renderDiffChange(resource.Change),
+			resource.Type + "." + resource.Name,
+		})
+	}
+	for _, parameter := range diff.Parameters {
+		value := parameter.OldValue + " → " + parameter.NewValue
+		switch parameter.Change {
+		case codersdk.WorkspaceUpdateDiffChangeAdded:
+			value = parameter.NewValue
+		case codersdk.WorkspaceUpdateDiffChangeRemoved:
+			value = parameter.OldValue
+		}
+		tableWriter.AppendRow(table.Row{
+			renderDiffChange(parameter.Change),
+			parameter.Name + ": " + Styles.Placeholder.Render(value),
+		})
+	}
+	_, err := fmt.Fprintln(writer, tableWriter.Render())
+	return err
+}
+
+func renderDiffChange(change codersdk.WorkspaceUpdateDiffChange) string {
+	switch change {
+	case codersdk.WorkspaceUpdateDiffChangeAdded:
+		return Styles.Keyword.Render("+ added")
+	case codersdk.WorkspaceUpdateDiffChangeRemoved:
+		return Styles.Error.Render("- removed")
+	default:
+		return Styles.Warn.Render("~ changed")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/coder/coder/cli/cliui"
@@ -39,6 +40,45 @@ func TestWorkspaceResources(t *testing.T) {
 		<-done
 	})
 
+	t.Run("AgentMetadata", func(t *testing.T) {
+		t.Parallel()
+		ptty := ptytest.New(t)
+		agentID := uuid.New()
+		done := make(chan struct{})
+		go func() {
+			err := cliui.WorkspaceResources(ptty.Output(), []codersdk.WorkspaceResource{{
+				Type:       "google_compute_instance",
+				Name:       "dev",
+				Transition: codersdk.WorkspaceTransitionStart,
+				Agents: []codersdk.WorkspaceAgent{{
+					ID:              agentID,
+					Name:            "dev",
+					Status:          codersdk.WorkspaceAgentConnected,
+					Architecture:    "amd64",
+					OperatingSystem: "linux",
+				}},
+			}}, cliui.WorkspaceResourcesOptions{
+				WorkspaceName: "example",
+				AgentMetadata: map[uuid.UUID][]codersdk.WorkspaceAgentMetadatum{
+					agentID: {{
+						Description: codersdk.WorkspaceAgentMetadataDescription{Key: "cpu", DisplayName: "CPU Usage"},
+						Result:      codersdk.WorkspaceAgentMetadataResult{Value: "42%", CollectedAt: database.Now()},
+					}, {
+						Description: codersdk.WorkspaceAgentMetadataDescription{Key: "branch"},
+						Result:      codersdk.WorkspaceAgentMetadataResult{Error: "not a git repository", CollectedAt: database.Now()},
+					}},
+				},
+			})
+			assert.NoError(t, err)
+			close(done)
+		}()
+		ptty.ExpectMatch("CPU Usage:")
+		ptty.ExpectMatch("42%")
+		ptty.ExpectMatch("branch:")
+		ptty.ExpectMatch("not a git repository")
+		<-done
+	})
+
 	t.Run("MultipleStates", func(t *testing.T) {
 		t.Parallel()
 		ptty := ptytest.New(t)
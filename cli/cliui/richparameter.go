@@ -0,0 +1,90 @@
+package cliui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/coder/coder/codersdk"
+)
+
+// RichParameter prompts for the value of a coder_parameter. Parameters with
+// options (and booleans) are displayed as a select, all others are prompted
+// as text and validated against the parameter's rules.
+func RichParameter(cmd *cobra.Command, templateVersionParameter codersdk.TemplateVersionParameter) (string, error) {
+	label := templateVersionParameter.Name
+	if templateVersionParameter.DisplayName != "" {
+		label = templateVersionParameter.DisplayName
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), Styles.Bold.Render(label))
+	if templateVersionParameter.Description != "" {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "  "+strings.TrimSpace(strings.Join(strings.Split(templateVersionParameter.Description, "\n"), "\n  "))+"\n")
+	}
+
+	var options []string
+	optionValues := map[string]string{}
+	for _, option := range templateVersionParameter.Options {
+		options = append(options, option.Name)
+		optionValues[option.Name] = option.Value
+	}
+	if len(options) == 0 && templateVersionParameter.Type == "bool" {
+		options = []string{"true", "false"}
+		optionValues["true"] = "true"
+		optionValues["false"] = "false"
+	}
+
+	var (
+		value string
+		err   error
+	)
+	if len(options) > 0 {
+		var defaultOption string
+		for name, optionValue := range optionValues {
+			if optionValue == templateVersionParameter.DefaultValue {
+				defaultOption = name
+			}
+		}
+		// Move the cursor up a single line for nicer display!
+		_, _ = fmt.Fprint(cmd.OutOrStdout(), "\033[1A")
+		var selected string
+		selected, err = Select(cmd, SelectOptions{
+			Options:    options,
+			Default:    defaultOption,
+			HideSearch: true,
+		})
+		if err == nil {
+			value = optionValues[selected]
+			_, _ = fmt.Fprintln(cmd.OutOrStdout())
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "  "+Styles.Prompt.String()+Styles.Field.Render(selected))
+		}
+	} else {
+		text := "Enter a value"
+		if templateVersionParameter.DefaultValue != "" {
+			text += fmt.Sprintf(" (default: %q)", templateVersionParameter.DefaultValue)
+		}
+		text += ":"
+
+		value, err = Prompt(cmd, PromptOptions{
+			Text: Styles.Bold.Render(text),
+			Validate: func(value string) error {
+				value = strings.TrimSpace(value)
+				if value == "" {
+					value = templateVersionParameter.DefaultValue
+				}
+				return codersdk.ValidateWorkspaceBuildParameter(templateVersionParameter, value)
+			},
+		})
+		value = strings.TrimSpace(value)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// If they didn't specify anything, use the default value if set.
+	if len(options) == 0 && value == "" {
+		value = templateVersionParameter.DefaultValue
+	}
+
+	return value, nil
+}
@@ -46,6 +46,21 @@ func (r Root) PostgresPort() File {
 	return File(filepath.Join(r.PostgresPath(), "port"))
 }
 
+func (r Root) SSHPath() string {
+	return filepath.Join(string(r), "ssh")
+}
+
+// SSHKey is the private key "coder config-ssh --use-certificates" points
+// OpenSSH at.
+func (r Root) SSHKey() File {
+	return File(filepath.Join(r.SSHPath(), "id_ed25519"))
+}
+
+// SSHCertificate is the certificate for the key that's valid for the host.
+func (r Root) SSHCertificate(host string) File {
+	return File(filepath.Join(r.SSHPath(), host+"-cert.pub"))
+}
+
 func (r Root) DeploymentConfigPath() string {
 	return filepath.Join(string(r), "server.yaml")
 }
@@ -13,17 +13,23 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/cli/safeexec"
+	"github.com/google/uuid"
 	"github.com/pkg/diff"
 	"github.com/pkg/diff/write"
 	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliflag"
 	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/cli/config"
+	"github.com/coder/coder/coderd/gitsshkey"
+	"github.com/coder/coder/coderd/sshca"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -45,10 +51,14 @@ const (
 // sshConfigOptions represents options that can be stored and read
 // from the coder config in ~/.ssh/coder.
 type sshConfigOptions struct {
-	sshOptions []string
+	sshOptions      []string
+	useCertificates bool
 }
 
 func (o sshConfigOptions) equal(other sshConfigOptions) bool {
+	if o.useCertificates != other.useCertificates {
+		return false
+	}
 	// Compare without side-effects or regard to order.
 	opt1 := slices.Clone(o.sshOptions)
 	sort.Strings(opt1)
@@ -61,6 +71,9 @@ func (o sshConfigOptions) asList() (list []string) {
 	for _, opt := range o.sshOptions {
 		list = append(list, fmt.Sprintf("ssh-option: %s", opt))
 	}
+	if o.useCertificates {
+		list = append(list, "use-certificates: true")
+	}
 	return list
 }
 
@@ -184,6 +197,22 @@ func configSSH() *cobra.Command {
 				return xerrors.Errorf("escape global config for ssh failed: %w", err)
 			}
 
+			var escapedSSHKey, matchExecCoderBinary, matchExecGlobalConfig string
+			if sshConfigOpts.useCertificates {
+				escapedSSHKey, err = sshConfigExecEscape(string(root.SSHKey()))
+				if err != nil {
+					return xerrors.Errorf("escape ssh key path for ssh failed: %w", err)
+				}
+				matchExecCoderBinary, err = sshConfigMatchExecEscape(coderBinary)
+				if err != nil {
+					return xerrors.Errorf("escape coder binary for ssh failed: %w", err)
+				}
+				matchExecGlobalConfig, err = sshConfigMatchExecEscape(string(root))
+				if err != nil {
+					return xerrors.Errorf("escape global config for ssh failed: %w", err)
+				}
+			}
+
 			homedir, err := os.UserHomeDir()
 			if err != nil {
 				return xerrors.Errorf("user home dir failed: %w", err)
@@ -287,6 +316,16 @@ func configSSH() *cobra.Command {
 						// message from appearing on every SSH. This happens because we ignore the known hosts.
 						"\tLogLevel ERROR",
 					)
+					if sshConfigOpts.useCertificates {
+						escapedCertificate, err := sshConfigExecEscape(string(root.SSHCertificate(hostname)))
+						if err != nil {
+							return xerrors.Errorf("escape ssh certificate path for ssh failed: %w", err)
+						}
+						configOptions = append(configOptions,
+							"\tIdentityFile "+escapedSSHKey,
+							"\tCertificateFile "+escapedCertificate,
+						)
+					}
 					if !skipProxyCommand {
 						configOptions = append(
 							configOptions,
@@ -296,6 +335,18 @@ func configSSH() *cobra.Command {
 							),
 						)
 					}
+					if sshConfigOpts.useCertificates && !skipProxyCommand {
+						// OpenSSH runs Match exec commands while reading its
+						// config, so the certificate is renewed before it's
+						// loaded. The ProxyCommand runs too late for that.
+						configOptions = append(
+							configOptions,
+							fmt.Sprintf(
+								"Match originalhost coder.%s exec \"%s --global-config %s ssh --renew-certificate %s\"",
+								hostname, matchExecCoderBinary, matchExecGlobalConfig, hostname,
+							),
+						)
+					}
 
 					_, _ = buf.WriteString(strings.Join(configOptions, "\n"))
 					_ = buf.WriteByte('\n')
@@ -312,6 +363,15 @@ func configSSH() *cobra.Command {
 				configModified = buf.Bytes()
 			}
 
+			generateSSHKey := false
+			if sshConfigOpts.useCertificates {
+				_, err = os.Stat(string(root.SSHKey()))
+				if errors.Is(err, fs.ErrNotExist) {
+					generateSSHKey = true
+					changes = append(changes, fmt.Sprintf("Generate an SSH key for certificates in %s", root.SSHKey()))
+				}
+			}
+
 			if len(changes) == 0 {
 				_, _ = fmt.Fprintf(out, "No changes to make.\n")
 				return nil
@@ -347,6 +407,17 @@ func configSSH() *cobra.Command {
 				}
 			}
 
+			if generateSSHKey {
+				privateKey, _, err := gitsshkey.Generate(gitsshkey.AlgorithmEd25519)
+				if err != nil {
+					return xerrors.Errorf("generate ssh key: %w", err)
+				}
+				err = root.SSHKey().Write(privateKey)
+				if err != nil {
+					return xerrors.Errorf("write ssh key: %w", err)
+				}
+			}
+
 			if !bytes.Equal(configRaw, configModified) {
 				err = writeWithTempFileAndMove(sshConfigFile, bytes.NewReader(configModified))
 				if err != nil {
@@ -368,6 +439,7 @@ func configSSH() *cobra.Command {
 	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Perform a trial run with no changes made, showing a diff at the end.")
 	cmd.Flags().BoolVarP(&skipProxyCommand, "skip-proxy-command", "", false, "Specifies whether the ProxyCommand option should be skipped. Useful for testing.")
 	_ = cmd.Flags().MarkHidden("skip-proxy-command")
+	cliflag.BoolVarP(cmd.Flags(), &sshConfigOpts.useCertificates, "use-certificates", "", "CODER_SSH_USE_CERTIFICATES", false, "Specifies whether OpenSSH should authenticate with short-lived certificates issued by Coder. Required if the deployment requires SSH certificates.")
 	cliflag.BoolVarP(cmd.Flags(), &usePreviousOpts, "use-previous-options", "", "CODER_SSH_USE_PREVIOUS_OPTIONS", false, "Specifies whether or not to keep options from previous run of config-ssh.")
 	cliui.AllowSkipPrompt(cmd)
 
@@ -383,11 +455,14 @@ func sshConfigWriteSectionHeader(w io.Writer, addNewline bool, o sshConfigOption
 	_, _ = fmt.Fprint(w, nl+sshStartToken+"\n")
 	_, _ = fmt.Fprint(w, sshConfigSectionHeader)
 	_, _ = fmt.Fprint(w, sshConfigDocsHeader)
-	if len(o.sshOptions) > 0 {
+	if len(o.sshOptions) > 0 || o.useCertificates {
 		_, _ = fmt.Fprint(w, sshConfigOptionsHeader)
 		for _, opt := range o.sshOptions {
 			_, _ = fmt.Fprintf(w, "# :%s=%s\n", "ssh-option", opt)
 		}
+		if o.useCertificates {
+			_, _ = fmt.Fprintf(w, "# :%s=%t\n", "use-certificates", true)
+		}
 	}
 	_, _ = fmt.Fprint(w, "#\n")
 }
@@ -406,6 +481,8 @@ func sshConfigParseLastOptions(r io.Reader) (o sshConfigOptions) {
 			switch parts[0] {
 			case "ssh-option":
 				o.sshOptions = append(o.sshOptions, parts[1])
+			case "use-certificates":
+				o.useCertificates = parts[1] == "true"
 			default:
 				// Unknown option, ignore.
 			}
@@ -540,6 +617,66 @@ func sshConfigExecEscape(path string) (string, error) {
 	return path, nil
 }
 
+// sshConfigMatchExecEscape quotes a path for use in the command of a Match
+// exec criteria. The command is already double quoted and OpenSSH doesn't
+// support nested double quotes, so single quotes are used for the shell
+// instead.
+func sshConfigMatchExecEscape(path string) (string, error) {
+	if strings.ContainsAny(path, "\n\"'") {
+		return "", xerrors.Errorf("path can't be used in a Match exec command: %s", path)
+	}
+	if strings.ContainsAny(path, " \t") {
+		path = "'" + path + "'"
+	}
+	return path, nil
+}
+
+// sshRenewCertificate issues a certificate for the key config-ssh created,
+// unless the current certificate for the host is still valid for the agent
+// for at least half of its lifetime.
+func sshRenewCertificate(ctx context.Context, root config.Root, client *codersdk.Client, agentID uuid.UUID, host string) error {
+	rawKey, err := root.SSHKey().Read()
+	if err != nil {
+		return xerrors.Errorf("read ssh key, run \"coder config-ssh --use-certificates\" to create it: %w", err)
+	}
+	signer, err := gossh.ParsePrivateKey([]byte(rawKey))
+	if err != nil {
+		return xerrors.Errorf("parse ssh key: %w", err)
+	}
+	certFile := root.SSHCertificate(host)
+	rawCert, err := certFile.Read()
+	if err == nil {
+		cert, err := codersdk.ParseSSHCertificate(rawCert)
+		if err == nil && sshCertificateFresh(cert, signer.PublicKey(), agentID) {
+			return nil
+		}
+	}
+
+	res, err := client.IssueWorkspaceAgentSSHCertificate(ctx, agentID, codersdk.IssueWorkspaceAgentSSHCertificateRequest{
+		PublicKey: string(gossh.MarshalAuthorizedKey(signer.PublicKey())),
+	})
+	if err != nil {
+		return xerrors.Errorf("issue ssh certificate: %w", err)
+	}
+	err = certFile.Write(res.Certificate)
+	if err != nil {
+		return xerrors.Errorf("write ssh certificate: %w", err)
+	}
+	return nil
+}
+
+func sshCertificateFresh(cert *gossh.Certificate, key gossh.PublicKey, agentID uuid.UUID) bool {
+	if !bytes.Equal(cert.Key.Marshal(), key.Marshal()) {
+		return false
+	}
+	if !slices.Contains(cert.ValidPrincipals, sshca.Principal(agentID)) {
+		return false
+	}
+	validAfter := time.Unix(int64(cert.ValidAfter), 0)
+	validBefore := time.Unix(int64(cert.ValidBefore), 0)
+	return time.Now().Before(validAfter.Add(validBefore.Sub(validAfter) / 2))
+}
+
 // currentBinPath returns the path to the coder binary suitable for use in ssh
 // ProxyCommand.
 func currentBinPath(w io.Writer) (string, error) {
@@ -0,0 +1,463 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func cp() *cobra.Command {
+	var (
+		recursive bool
+		resume    bool
+	)
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "cp <source> <destination>",
+		Short:       "Copy files to or from a workspace",
+		Long: "Copy files between this machine and a workspace without an SSH client. " +
+			"Paths in a workspace are written as \"<workspace>[.<agent>]:<path>\" and are relative to the home directory unless they're absolute. " +
+			"Local paths that contain a colon must start with \"./\". " +
+			"Copied files are verified with SHA-256 checksums.",
+		Example: formatExamples(
+			example{
+				Description: "Copy a file to the home directory of a workspace",
+				Command:     "coder cp ./notes.txt my-workspace:",
+			},
+			example{
+				Description: "Copy a directory from a workspace",
+				Command:     "coder cp -r my-workspace:project/build ./build",
+			},
+			example{
+				Description: "Resume copying a large file after the connection was lost",
+				Command:     "coder cp --resume ./dataset.tar my-workspace:data/",
+			},
+		),
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			src, dst := parseCopyPath(args[0]), parseCopyPath(args[1])
+			if (src.workspace == "") == (dst.workspace == "") {
+				return xerrors.New("exactly one of the source and the destination must be in a workspace, e.g. \"my-workspace:path\"")
+			}
+			remote := src.workspace
+			if remote == "" {
+				remote = dst.workspace
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, remote, false)
+			if err != nil {
+				return err
+			}
+			err = cliui.Agent(ctx, cmd.ErrOrStderr(), cliui.AgentOptions{
+				WorkspaceName: workspace.Name,
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+				},
+				FetchLogs: client.WorkspaceAgentStartupLogsAfter,
+				Wait:      !workspaceAgent.LoginBeforeReady,
+			})
+			if err != nil {
+				return xerrors.Errorf("await agent: %w", err)
+			}
+
+			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, nil)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			signer, err := client.WorkspaceAgentSSHSigner(ctx, workspaceAgent.ID)
+			if err != nil {
+				return xerrors.Errorf("get ssh certificate: %w", err)
+			}
+			sshClient, err := conn.SSHClientWithSigner(signer)
+			if err != nil {
+				return err
+			}
+			defer sshClient.Close()
+			sftpClient, err := sftp.NewClient(sshClient, sftp.UseConcurrentWrites(true))
+			if err != nil {
+				return xerrors.Errorf("start sftp client: %w", err)
+			}
+			defer sftpClient.Close()
+
+			c := &copier{
+				ctx:       ctx,
+				recursive: recursive,
+				resume:    resume,
+				stderr:    cmd.ErrOrStderr(),
+			}
+			remoteFS := &remoteCopyFS{client: sftpClient, conn: conn}
+			if src.workspace != "" {
+				c.src, c.dst = remoteFS, localCopyFS{}
+			} else {
+				c.src, c.dst = localCopyFS{}, remoteFS
+			}
+			return c.copy(src.path, dst.path)
+		},
+	}
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Copy directories and their contents.")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Continue copying files that were partially copied before, instead of starting over.")
+	return cmd
+}
+
+type copyPath struct {
+	// workspace is empty for local paths.
+	workspace string
+	path      string
+}
+
+// parseCopyPath splits "<workspace>:<path>" like scp does. Absolute local
+// paths, including ones with a volume name like "C:\", and paths starting
+// with "." are always local.
+func parseCopyPath(arg string) copyPath {
+	if filepath.IsAbs(arg) || filepath.VolumeName(arg) != "" || strings.HasPrefix(arg, ".") {
+		return copyPath{path: arg}
+	}
+	workspace, remotePath, ok := strings.Cut(arg, ":")
+	if !ok {
+		return copyPath{path: arg}
+	}
+	if remotePath == "" {
+		remotePath = "."
+	}
+	return copyPath{workspace: workspace, path: remotePath}
+}
+
+// copyFS is one side of a copy.
+type copyFS interface {
+	Stat(name string) (fs.FileInfo, error)
+	// ReadDir doesn't follow symbolic links in the directory.
+	ReadDir(name string) ([]fs.FileInfo, error)
+	Open(name string) (io.ReadSeekCloser, error)
+	// Create opens a file for writing at offset, and truncates it there.
+	Create(name string, offset int64, perm fs.FileMode) (io.WriteCloser, error)
+	Mkdir(name string, perm fs.FileMode) error
+	Join(elem ...string) string
+	Base(name string) string
+	// Checksum returns the hex encoded SHA-256 checksum of a file.
+	Checksum(ctx context.Context, name string) (string, error)
+}
+
+type localCopyFS struct{}
+
+func (localCopyFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (localCopyFS) ReadDir(name string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (localCopyFS) Open(name string) (io.ReadSeekCloser, error) {
+	return os.Open(name)
+}
+
+func (localCopyFS) Create(name string, offset int64, perm fs.FileMode) (io.WriteCloser, error) {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE, perm)
+	if err != nil {
+		return nil, err
+	}
+	err = file.Truncate(offset)
+	if err == nil {
+		_, err = file.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (localCopyFS) Mkdir(name string, perm fs.FileMode) error {
+	return os.Mkdir(name, perm)
+}
+
+func (localCopyFS) Join(elem ...string) string {
+	return filepath.Join(elem...)
+}
+
+func (localCopyFS) Base(name string) string {
+	return filepath.Base(name)
+}
+
+func (localCopyFS) Checksum(_ context.Context, name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// remoteCopyFS copies files over SFTP. Checksums are computed by the agent,
+// so files don't have to be downloaded again to verify them.
+type remoteCopyFS struct {
+	client *sftp.Client
+	conn   *codersdk.AgentConn
+}
+
+func (r *remoteCopyFS) Stat(name string) (fs.FileInfo, error) {
+	return r.client.Stat(name)
+}
+
+func (r *remoteCopyFS) ReadDir(name string) ([]fs.FileInfo, error) {
+	return r.client.ReadDir(name)
+}
+
+func (r *remoteCopyFS) Open(name string) (io.ReadSeekCloser, error) {
+	return r.client.Open(name)
+}
+
+func (r *remoteCopyFS) Create(name string, offset int64, perm fs.FileMode) (io.WriteCloser, error) {
+	file, err := r.client.OpenFile(name, os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		return nil, err
+	}
+	err = file.Truncate(offset)
+	if err == nil && offset == 0 {
+		err = file.Chmod(perm)
+	}
+	if err == nil {
+		_, err = file.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (r *remoteCopyFS) Mkdir(name string, perm fs.FileMode) error {
+	err := r.client.Mkdir(name)
+	if err != nil {
+		return err
+	}
+	return r.client.Chmod(name, perm)
+}
+
+func (*remoteCopyFS) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (*remoteCopyFS) Base(name string) string {
+	return path.Base(name)
+}
+
+func (r *remoteCopyFS) Checksum(ctx context.Context, name string) (string, error) {
+	checksum, err := r.conn.FileChecksum(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return checksum.SHA256, nil
+}
+
+type copier struct {
+	ctx       context.Context
+	src, dst  copyFS
+	recursive bool
+	resume    bool
+	stderr    io.Writer
+}
+
+func (c *copier) copy(src, dst string) error {
+	info, err := c.src.Stat(src)
+	if err != nil {
+		return xerrors.Errorf("stat %s: %w", src, err)
+	}
+	if info.IsDir() && !c.recursive {
+		return xerrors.Errorf("%s is a directory, use --recursive to copy it", src)
+	}
+	// Like cp, copy into the destination if it's an existing directory.
+	dstInfo, err := c.dst.Stat(dst)
+	if err == nil && dstInfo.IsDir() {
+		dst = c.dst.Join(dst, c.src.Base(src))
+	}
+	if info.IsDir() {
+		return c.copyDir(src, dst, info)
+	}
+	return c.copyFile(src, dst, info)
+}
+
+func (c *copier) copyDir(src, dst string, info fs.FileInfo) error {
+	err := c.dst.Mkdir(dst, info.Mode().Perm())
+	if err != nil {
+		// The directory exists if it was partially copied before.
+		dstInfo, statErr := c.dst.Stat(dst)
+		if statErr != nil || !dstInfo.IsDir() {
+			return xerrors.Errorf("create directory %s: %w", dst, err)
+		}
+	}
+	entries, err := c.src.ReadDir(src)
+	if err != nil {
+		return xerrors.Errorf("read directory %s: %w", src, err)
+	}
+	for _, entry := range entries {
+		if c.ctx.Err() != nil {
+			return c.ctx.Err()
+		}
+		srcPath := c.src.Join(src, entry.Name())
+		dstPath := c.dst.Join(dst, entry.Name())
+		switch {
+		case entry.IsDir():
+			err = c.copyDir(srcPath, dstPath, entry)
+		case entry.Mode().IsRegular():
+			err = c.copyFile(srcPath, dstPath, entry)
+		default:
+			_, _ = fmt.Fprintf(c.stderr, "Skipping %s, which isn't a regular file or directory.\n", srcPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *copier) copyFile(src, dst string, info fs.FileInfo) error {
+	var offset int64
+	if c.resume {
+		dstInfo, err := c.dst.Stat(dst)
+		if err == nil && dstInfo.Mode().IsRegular() && dstInfo.Size() <= info.Size() {
+			offset = dstInfo.Size()
+		}
+	}
+
+	srcFile, err := c.src.Open(src)
+	if err != nil {
+		return xerrors.Errorf("open %s: %w", src, err)
+	}
+	defer srcFile.Close()
+	_, err = srcFile.Seek(offset, io.SeekStart)
+	if err != nil {
+		return xerrors.Errorf("seek %s: %w", src, err)
+	}
+	dstFile, err := c.dst.Create(dst, offset, info.Mode().Perm())
+	if err != nil {
+		return xerrors.Errorf("create %s: %w", dst, err)
+	}
+	defer dstFile.Close()
+
+	progress := &copyProgress{
+		w:     c.stderr,
+		name:  dst,
+		total: info.Size(),
+		done:  offset,
+	}
+	if remoteFile, ok := srcFile.(*sftp.File); ok {
+		// Remote files are read with concurrent requests, which is much
+		// faster than reading them in sequence.
+		_, err = remoteFile.WriteTo(io.MultiWriter(dstFile, progress))
+	} else {
+		// Remote files are written with concurrent requests when the size
+		// of the reader is known.
+		_, err = io.Copy(dstFile, &io.LimitedReader{
+			R: io.TeeReader(srcFile, progress),
+			N: info.Size() - offset,
+		})
+	}
+	if err != nil {
+		return xerrors.Errorf("copy %s to %s: %w", src, dst, err)
+	}
+	err = dstFile.Close()
+	if err != nil {
+		return xerrors.Errorf("close %s: %w", dst, err)
+	}
+
+	srcChecksum, err := c.src.Checksum(c.ctx, src)
+	if err != nil {
+		return xerrors.Errorf("checksum %s: %w", src, err)
+	}
+	dstChecksum, err := c.dst.Checksum(c.ctx, dst)
+	if err != nil {
+		return xerrors.Errorf("checksum %s: %w", dst, err)
+	}
+	if srcChecksum != dstChecksum {
+		if offset > 0 {
+			return xerrors.Errorf("checksum of %s doesn't match %s after resuming, copy it again without --resume", dst, src)
+		}
+		return xerrors.Errorf("checksum of %s doesn't match %s", dst, src)
+	}
+	progress.finish()
+	return nil
+}
+
+// copyProgress prints how much of a file was copied on a single line.
+type copyProgress struct {
+	w           io.Writer
+	name        string
+	total, done int64
+	printed     time.Time
+}
+
+func (p *copyProgress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if time.Since(p.printed) > 100*time.Millisecond {
+		p.print()
+	}
+	return len(b), nil
+}
+
+func (p *copyProgress) print() {
+	percent := int64(100)
+	if p.total > 0 {
+		percent = p.done * 100 / p.total
+	}
+	_, _ = fmt.Fprintf(p.w, "\r%s  %s / %s  %d%%", p.name, formatFileSize(p.done), formatFileSize(p.total), percent)
+	p.printed = time.Now()
+}
+
+func (p *copyProgress) finish() {
+	p.print()
+	_, _ = fmt.Fprintln(p.w)
+}
+
+func formatFileSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseCopyPath(t *testing.T) {
+	t.Parallel()
+
+	absPath := filepath.Join(t.TempDir(), "a:b")
+	tests := []struct {
+		arg  string
+		want copyPath
+	}{
+		{arg: "file", want: copyPath{path: "file"}},
+		{arg: "./a:b", want: copyPath{path: "./a:b"}},
+		{arg: "dev:", want: copyPath{workspace: "dev", path: "."}},
+		{arg: "dev:project/main.go", want: copyPath{workspace: "dev", path: "project/main.go"}},
+		{arg: "dev.main:/tmp", want: copyPath{workspace: "dev.main", path: "/tmp"}},
+		{arg: "kyle/dev:notes.txt", want: copyPath{workspace: "kyle/dev", path: "notes.txt"}},
+		{arg: absPath, want: copyPath{path: absPath}},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, struct {
+			arg  string
+			want copyPath
+		}{arg: `C:\Users\kyle`, want: copyPath{path: `C:\Users\kyle`}})
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.arg, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, parseCopyPath(tt.arg))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestCp(t *testing.T) {
+	t.Parallel()
+
+	// The agent runs on this machine, so paths in the workspace are local
+	// paths too.
+	setup := func(t *testing.T) (*codersdk.Client, codersdk.Workspace) {
+		client, workspace, agentToken := setupWorkspaceForAgent(t)
+		agentClient := codersdk.New(client.URL)
+		agentClient.SessionToken = agentToken
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent"),
+		})
+		t.Cleanup(func() {
+			_ = agentCloser.Close()
+		})
+		return client, workspace
+	}
+	run := func(t *testing.T, client *codersdk.Client, args ...string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		cmd, root := clitest.New(t, append([]string{"cp"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		return cmd.ExecuteContext(ctx)
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		t.Parallel()
+		client, workspace := setup(t)
+		dir := t.TempDir()
+		src := filepath.Join(dir, "src")
+		err := os.WriteFile(src, []byte("hello"), 0o600)
+		require.NoError(t, err)
+		remoteDir := t.TempDir()
+
+		err = run(t, client, src, workspace.Name+":"+remoteDir)
+		require.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(remoteDir, "src"))
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(content))
+	})
+
+	t.Run("DownloadRecursive", func(t *testing.T) {
+		t.Parallel()
+		client, workspace := setup(t)
+		remoteDir := filepath.Join(t.TempDir(), "project")
+		err := os.MkdirAll(filepath.Join(remoteDir, "nested"), 0o755)
+		require.NoError(t, err)
+		err = os.WriteFile(filepath.Join(remoteDir, "a"), []byte("a"), 0o600)
+		require.NoError(t, err)
+		err = os.WriteFile(filepath.Join(remoteDir, "nested", "b"), []byte("b"), 0o600)
+		require.NoError(t, err)
+		dst := filepath.Join(t.TempDir(), "copy")
+
+		err = run(t, client, workspace.Name+":"+remoteDir, dst)
+		require.ErrorContains(t, err, "--recursive")
+
+		err = run(t, client, "-r", workspace.Name+":"+remoteDir, dst)
+		require.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(dst, "a"))
+		require.NoError(t, err)
+		require.Equal(t, "a", string(content))
+		content, err = os.ReadFile(filepath.Join(dst, "nested", "b"))
+		require.NoError(t, err)
+		require.Equal(t, "b", string(content))
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		t.Parallel()
+		client, workspace := setup(t)
+		remoteFile := filepath.Join(t.TempDir(), "large")
+		err := os.WriteFile(remoteFile, []byte("0123456789"), 0o600)
+		require.NoError(t, err)
+		dst := filepath.Join(t.TempDir(), "large")
+
+		// Only the rest of a partially copied file is copied.
+		err = os.WriteFile(dst, []byte("01234"), 0o600)
+		require.NoError(t, err)
+		err = run(t, client, "--resume", workspace.Name+":"+remoteFile, dst)
+		require.NoError(t, err)
+		content, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "0123456789", string(content))
+
+		// A partial copy that doesn't match the source fails verification.
+		err = os.WriteFile(dst, []byte("abcde"), 0o600)
+		require.NoError(t, err)
+		err = run(t, client, "--resume", workspace.Name+":"+remoteFile, dst)
+		require.ErrorContains(t, err, "checksum")
+		err = run(t, client, workspace.Name+":"+remoteFile, dst)
+		require.NoError(t, err)
+		content, err = os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "0123456789", string(content))
+	})
+
+	t.Run("BothLocal", func(t *testing.T) {
+		t.Parallel()
+		client, _ := setup(t)
+		err := run(t, client, "./a", "./b")
+		require.ErrorContains(t, err, "exactly one")
+	})
+}
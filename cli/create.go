@@ -5,6 +5,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slices"
 	"golang.org/x/xerrors"
@@ -121,7 +122,7 @@ func create() *cobra.Command {
 				schedSpec = ptr.Ref(sched.String())
 			}
 
-			parameters, err := prepWorkspaceBuild(cmd, client, prepWorkspaceBuildArgs{
+			buildParams, err := prepWorkspaceBuild(cmd, client, prepWorkspaceBuildArgs{
 				Template:         template,
 				ExistingParams:   []codersdk.Parameter{},
 				ParameterFile:    parameterFile,
@@ -141,11 +142,12 @@ func create() *cobra.Command {
 
 			after := time.Now()
 			workspace, err := client.CreateWorkspace(cmd.Context(), organization.ID, codersdk.Me, codersdk.CreateWorkspaceRequest{
-				TemplateID:        template.ID,
-				Name:              workspaceName,
-				AutostartSchedule: schedSpec,
-				TTLMillis:         ptr.Ref(stopAfter.Milliseconds()),
-				ParameterValues:   parameters,
+				TemplateID:          template.ID,
+				Name:                workspaceName,
+				AutostartSchedule:   schedSpec,
+				TTLMillis:           ptr.Ref(stopAfter.Milliseconds()),
+				ParameterValues:     buildParams.parameters,
+				RichParameterValues: buildParams.richParameters,
 			})
 			if err != nil {
 				return err
@@ -170,15 +172,30 @@ func create() *cobra.Command {
 }
 
 type prepWorkspaceBuildArgs struct {
-	Template         codersdk.Template
-	ExistingParams   []codersdk.Parameter
-	ParameterFile    string
-	NewWorkspaceName string
+	Template           codersdk.Template
+	ExistingParams     []codersdk.Parameter
+	ExistingRichParams []codersdk.WorkspaceBuildParameter
+	ParameterFile      string
+	NewWorkspaceName   string
+	// WorkspaceID is set when updating an existing workspace. The dry-run is
+	// run against its current state, and the changes are displayed.
+	WorkspaceID uuid.UUID
+
+	// AlwaysPrompt prompts for mutable rich parameters even when they
+	// already have a value from a previous build.
+	AlwaysPrompt bool
+}
+
+type buildParameters struct {
+	// Parameters contains legacy parameters stored in /parameters.
+	parameters []codersdk.CreateParameterRequest
+	// Rich parameters stores values for build parameters annotated with description, icon, type, etc.
+	richParameters []codersdk.WorkspaceBuildParameter
 }
 
 // prepWorkspaceBuild will ensure a workspace build will succeed on the latest template version.
 // Any missing params will be prompted to the user.
-func prepWorkspaceBuild(cmd *cobra.Command, client *codersdk.Client, args prepWorkspaceBuildArgs) ([]codersdk.CreateParameterRequest, error) {
+func prepWorkspaceBuild(cmd *cobra.Command, client *codersdk.Client, args prepWorkspaceBuildArgs) (*buildParameters, error) {
 	ctx := cmd.Context()
 	templateVersion, err := client.TemplateVersion(ctx, args.Template.ActiveVersionID)
 	if err != nil {
@@ -188,6 +205,10 @@ func prepWorkspaceBuild(cmd *cobra.Command, client *codersdk.Client, args prepWo
 	if err != nil {
 		return nil, err
 	}
+	templateVersionParameters, err := client.TemplateVersionRichParameters(ctx, templateVersion.ID)
+	if err != nil {
+		return nil, err
+	}
 
 	// parameterMapFromFile can be nil if parameter file is not specified
 	var parameterMapFromFile map[string]string
@@ -237,11 +258,42 @@ PromptParamLoop:
 	}
 	_, _ = fmt.Fprintln(cmd.OutOrStdout())
 
+	richParameters := make([]codersdk.WorkspaceBuildParameter, 0)
+	if len(templateVersionParameters) > 0 {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), cliui.Styles.Paragraph.Render("This template has rich parameters. Immutable parameters can't be changed after the workspace is created.")+"\r\n")
+	}
+RichParamLoop:
+	for _, templateVersionParameter := range templateVersionParameters {
+		if !useParamFile {
+			for _, e := range args.ExistingRichParams {
+				// Parameters with an existing value are carried over by the
+				// server, so only prompt for them when asked to.
+				if e.Name == templateVersionParameter.Name && (!args.AlwaysPrompt || !templateVersionParameter.Mutable) {
+					continue RichParamLoop
+				}
+			}
+		}
+
+		parameterValue, err := getRichParameterValueFromMapOrInput(cmd, parameterMapFromFile, templateVersionParameter)
+		if err != nil {
+			return nil, err
+		}
+		richParameters = append(richParameters, codersdk.WorkspaceBuildParameter{
+			Name:  templateVersionParameter.Name,
+			Value: parameterValue,
+		})
+	}
+	if len(templateVersionParameters) > 0 {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout())
+	}
+
 	// Run a dry-run with the given parameters to check correctness
 	after := time.Now()
 	dryRun, err := client.CreateTemplateVersionDryRun(cmd.Context(), templateVersion.ID, codersdk.CreateTemplateVersionDryRunRequest{
-		WorkspaceName:   args.NewWorkspaceName,
-		ParameterValues: parameters,
+		WorkspaceName:       args.NewWorkspaceName,
+		ParameterValues:     parameters,
+		RichParameterValues: richParameters,
+		WorkspaceID:         args.WorkspaceID,
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("begin workspace dry-run: %w", err)
@@ -281,5 +333,19 @@ PromptParamLoop:
 		return nil, err
 	}
 
-	return parameters, nil
+	if args.WorkspaceID != uuid.Nil {
+		diff, err := client.TemplateVersionDryRunDiff(cmd.Context(), templateVersion.ID, dryRun.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("get workspace dry-run diff: %w", err)
+		}
+		err = cliui.WorkspaceUpdateDiff(cmd.OutOrStdout(), diff)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &buildParameters{
+		parameters:     parameters,
+		richParameters: richParameters,
+	}, nil
 }
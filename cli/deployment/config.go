@@ -95,6 +95,12 @@ func newConfig() *codersdk.DeploymentConfig {
 					Usage: "Path to read a DERP mapping from. See: https://tailscale.com/kb/1118/custom-derp-servers/",
 					Flag:  "derp-config-path",
 				},
+				RefreshInterval: &codersdk.DeploymentConfigField[time.Duration]{
+					Name:    "DERP Config Refresh Interval",
+					Usage:   "How often the DERP mapping is refetched and its regions are health checked. Unhealthy regions are removed from the map given to agents and clients until they recover.",
+					Flag:    "derp-config-refresh-interval",
+					Default: time.Minute,
+				},
 			},
 		},
 		GitAuth: &codersdk.DeploymentConfigField[[]codersdk.GitAuthConfig]{
@@ -143,7 +149,7 @@ func newConfig() *codersdk.DeploymentConfig {
 			Name:    "Cache Directory",
 			Usage:   "The directory to cache temporary files. If unspecified and $CACHE_DIRECTORY is set, it will be used for compatibility with systemd.",
 			Flag:    "cache-dir",
-			Default: defaultCacheDir(),
+			Default: DefaultCacheDir(),
 		},
 		InMemoryDatabase: &codersdk.DeploymentConfigField[bool]{
 			Name:   "In Memory Database",
@@ -221,6 +227,18 @@ func newConfig() *codersdk.DeploymentConfig {
 				Usage: "Email domain that clients logging in with OIDC must match.",
 				Flag:  "oidc-email-domain",
 			},
+			GroupField: &codersdk.DeploymentConfigField[string]{
+				Name:       "OIDC Group Field",
+				Usage:      "Claim that lists the groups of the user. If set, group memberships are synced on every login and missing groups are created.",
+				Flag:       "oidc-group-field",
+				Enterprise: true,
+			},
+			GroupMapping: &codersdk.DeploymentConfigField[string]{
+				Name:       "OIDC Group Mapping",
+				Usage:      "JSON object that maps groups of the identity provider to Coder groups, e.g. {\"okta-admins\": \"admins\"}. Groups that aren't mapped keep their name.",
+				Flag:       "oidc-group-mapping",
+				Enterprise: true,
+			},
 			IssuerURL: &codersdk.DeploymentConfigField[string]{
 				Name:  "OIDC Issuer URL",
 				Usage: "Issuer URL to use for Login with OIDC.",
@@ -232,6 +250,16 @@ func newConfig() *codersdk.DeploymentConfig {
 				Flag:    "oidc-scopes",
 				Default: []string{oidc.ScopeOpenID, "profile", "email"},
 			},
+			UserRoleField: &codersdk.DeploymentConfigField[string]{
+				Name:  "OIDC User Role Field",
+				Usage: "Claim that lists the roles of the user. If set, site and organization roles are synced on every login.",
+				Flag:  "oidc-user-role-field",
+			},
+			UserRoleMapping: &codersdk.DeploymentConfigField[string]{
+				Name:  "OIDC User Role Mapping",
+				Usage: "JSON object that maps roles of the identity provider to lists of Coder roles, e.g. {\"platform\": [\"template-admin\", \"organization-admin\"]}. Roles that aren't mapped are used as Coder role names.",
+				Flag:  "oidc-user-role-mapping",
+			},
 		},
 
 		Telemetry: &codersdk.TelemetryConfig{
@@ -313,6 +341,17 @@ func newConfig() *codersdk.DeploymentConfig {
 			Flag:    "ssh-keygen-algorithm",
 			Default: "ed25519",
 		},
+		SSHCertificateTTL: &codersdk.DeploymentConfigField[time.Duration]{
+			Name:    "SSH Certificate TTL",
+			Usage:   "How long SSH certificates issued for connecting to workspace agents are valid for.",
+			Flag:    "ssh-certificate-ttl",
+			Default: time.Hour,
+		},
+		SSHRequireCertificates: &codersdk.DeploymentConfigField[bool]{
+			Name:  "SSH Require Certificates",
+			Usage: "Workspace agents reject SSH connections that don't authenticate with a certificate issued by Coder. Use \"coder config-ssh --use-certificates\" to connect with OpenSSH. The web terminal, resumable sessions and file transfers don't use SSH and aren't affected.",
+			Flag:  "ssh-require-certificates",
+		},
 		AutoImportTemplates: &codersdk.DeploymentConfigField[[]string]{
 			Name:   "Auto Import Templates",
 			Usage:  "Templates to auto-import. Available auto-importable templates are: kubernetes",
@@ -359,6 +398,19 @@ func newConfig() *codersdk.DeploymentConfig {
 			Flag:       "user-workspace-quota",
 			Enterprise: true,
 		},
+		ProvisionerDaemonPSK: &codersdk.DeploymentConfigField[string]{
+			Name:       "Provisioner Daemon Pre-Shared Key",
+			Usage:      "Enables external provisioner daemons and sets the pre-shared key they must use to authenticate with \"coder provisionerd start --psk\".",
+			Flag:       "provisioner-daemon-psk",
+			Enterprise: true,
+			Secret:     true,
+		},
+		MaxServiceTokenLifetime: &codersdk.DeploymentConfigField[time.Duration]{
+			Name:    "Max Service Account Token Lifetime",
+			Usage:   "The longest lifetime a token of a service account can be created with.",
+			Flag:    "max-service-account-token-lifetime",
+			Default: 30 * 24 * time.Hour,
+		},
 	}
 }
 
@@ -619,7 +671,9 @@ func formatEnv(key string) string {
 	return "CODER_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
 }
 
-func defaultCacheDir() string {
+// DefaultCacheDir returns the default directory for caching temporary
+// files, like Terraform binaries and providers.
+func DefaultCacheDir() string {
 	defaultCacheDir, err := os.UserCacheDir()
 	if err != nil {
 		defaultCacheDir = os.TempDir()
@@ -2,6 +2,7 @@ package deployment_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
@@ -57,18 +58,20 @@ func TestConfig(t *testing.T) {
 	}, {
 		Name: "DERP",
 		Env: map[string]string{
-			"CODER_DERP_CONFIG_PATH":           "/example/path",
-			"CODER_DERP_CONFIG_URL":            "https://google.com",
-			"CODER_DERP_SERVER_ENABLE":         "false",
-			"CODER_DERP_SERVER_REGION_CODE":    "something",
-			"CODER_DERP_SERVER_REGION_ID":      "123",
-			"CODER_DERP_SERVER_REGION_NAME":    "Code-Land",
-			"CODER_DERP_SERVER_RELAY_URL":      "1.1.1.1",
-			"CODER_DERP_SERVER_STUN_ADDRESSES": "google.org",
+			"CODER_DERP_CONFIG_PATH":             "/example/path",
+			"CODER_DERP_CONFIG_URL":              "https://google.com",
+			"CODER_DERP_CONFIG_REFRESH_INTERVAL": "30s",
+			"CODER_DERP_SERVER_ENABLE":           "false",
+			"CODER_DERP_SERVER_REGION_CODE":      "something",
+			"CODER_DERP_SERVER_REGION_ID":        "123",
+			"CODER_DERP_SERVER_REGION_NAME":      "Code-Land",
+			"CODER_DERP_SERVER_RELAY_URL":        "1.1.1.1",
+			"CODER_DERP_SERVER_STUN_ADDRESSES":   "google.org",
 		},
 		Valid: func(config *codersdk.DeploymentConfig) {
 			require.Equal(t, config.DERP.Config.Path.Value, "/example/path")
 			require.Equal(t, config.DERP.Config.URL.Value, "https://google.com")
+			require.Equal(t, config.DERP.Config.RefreshInterval.Value, 30*time.Second)
 			require.Equal(t, config.DERP.Server.Enable.Value, false)
 			require.Equal(t, config.DERP.Server.RegionCode.Value, "something")
 			require.Equal(t, config.DERP.Server.RegionID.Value, 123)
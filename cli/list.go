@@ -45,6 +45,10 @@ func workspaceListRowFromWorkspace(now time.Time, usersByID map[uuid.UUID]coders
 		}
 	}
 
+	if workspace.DormantAt != nil {
+		status = "Dormant"
+	}
+
 	user := usersByID[workspace.OwnerID]
 	return workspaceListRow{
 		Workspace:  user.Username + "/" + workspace.Name,
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+)
+
+func organizationMembers() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "members",
+		Aliases: []string{"member"},
+		Short:   "Manage the members of the current organization",
+		Long:    "Users can only use the templates and groups of organizations they are a member of.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		listOrganizationMembers(),
+		addOrganizationMember(),
+		removeOrganizationMember(),
+	)
+
+	return cmd
+}
+
+type organizationMemberRow struct {
+	Username string `table:"Username"`
+	Email    string `table:"Email"`
+	Roles    string `table:"Roles"`
+}
+
+func listOrganizationMembers() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the members of the current organization",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			members, err := client.OrganizationMembers(cmd.Context(), org.ID)
+			if err != nil {
+				return xerrors.Errorf("get organization members: %w", err)
+			}
+
+			rows := make([]organizationMemberRow, 0, len(members))
+			for _, member := range members {
+				roles := make([]string, 0, len(member.Roles))
+				for _, role := range member.Roles {
+					roles = append(roles, role.DisplayName)
+				}
+				rows = append(rows, organizationMemberRow{
+					Username: member.Username,
+					Email:    member.Email,
+					Roles:    strings.Join(roles, ", "),
+				})
+			}
+
+			out, err := cliui.DisplayTable(rows, "", nil)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	return cmd
+}
+
+func addOrganizationMember() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <username|user_id>",
+		Short: "Add a user to the current organization",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			_, err = client.AddOrganizationMember(cmd.Context(), org.ID, args[0])
+			if err != nil {
+				return xerrors.Errorf("add organization member: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Added %s to organization %s!\n",
+				cliui.Styles.Keyword.Render(args[0]), cliui.Styles.Keyword.Render(org.Name))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func removeOrganizationMember() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "remove <username|user_id>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a user from the current organization and its groups",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+				Text:      fmt.Sprintf("Remove %s from organization %s?", cliui.Styles.Code.Render(args[0]), cliui.Styles.Code.Render(org.Name)),
+				IsConfirm: true,
+				Default:   cliui.ConfirmNo,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = client.RemoveOrganizationMember(cmd.Context(), org.ID, args[0])
+			if err != nil {
+				return xerrors.Errorf("remove organization member: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Removed %s from organization %s!\n",
+				cliui.Styles.Keyword.Render(args[0]), cliui.Styles.Keyword.Render(org.Name))
+			return nil
+		},
+	}
+	cliui.AllowSkipPrompt(cmd)
+
+	return cmd
+}
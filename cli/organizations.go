@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func organizations() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "organizations",
+		Short:   "Manage organizations",
+		Long:    "Templates, groups and workspaces belong to an organization. Commands use the organization passed with --org, the one selected with \"coder organizations switch\", or your first organization.",
+		Aliases: []string{"organization", "orgs", "org"},
+		Example: formatExamples(
+			example{
+				Description: "Use the \"platform\" organization for all following commands",
+				Command:     "coder organizations switch platform",
+			},
+			example{
+				Description: "List the templates of another organization once",
+				Command:     "coder templates list --org data-science",
+			},
+			example{
+				Description: "Add a user to the current organization",
+				Command:     "coder organizations members add alice",
+			},
+			example{
+				Description: "Limit each member of the current organization to 3 workspaces",
+				Command:     "coder organizations edit --user-workspace-quota 3",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		listOrganizations(),
+		showOrganization(),
+		switchOrganization(),
+		createOrganization(),
+		editOrganization(),
+		organizationMembers(),
+	)
+
+	return cmd
+}
+
+type organizationRow struct {
+	Name               string `table:"Name"`
+	ID                 string `table:"ID"`
+	UserWorkspaceQuota string `table:"User Workspace Quota"`
+	Current            string `table:"Current"`
+}
+
+func organizationRows(orgs []codersdk.Organization, current codersdk.Organization) []organizationRow {
+	rows := make([]organizationRow, 0, len(orgs))
+	for _, org := range orgs {
+		quota := "default"
+		if org.UserWorkspaceQuota > 0 {
+			quota = strconv.Itoa(org.UserWorkspaceQuota)
+		}
+		row := organizationRow{
+			Name:               org.Name,
+			ID:                 org.ID.String(),
+			UserWorkspaceQuota: quota,
+		}
+		if org.ID == current.ID {
+			row.Current = "✓"
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func listOrganizations() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the organizations you are a member of",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			orgs, err := client.OrganizationsByUser(cmd.Context(), codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("get organizations: %w", err)
+			}
+			// The selected organization may no longer be available, which
+			// shouldn't prevent listing the others.
+			current, _ := CurrentOrganization(cmd, client)
+
+			out, err := cliui.DisplayTable(organizationRows(orgs, current), "", nil)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	return cmd
+}
+
+func showOrganization() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the current organization",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+
+			out, err := cliui.DisplayTable(organizationRows([]codersdk.Organization{org}, org), "", []string{"name", "id", "user_workspace_quota"})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	return cmd
+}
+
+func switchOrganization() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "switch <name>",
+		Short: "Select the organization used by other commands",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			orgs, err := client.OrganizationsByUser(cmd.Context(), codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("get organizations: %w", err)
+			}
+			org, ok := findOrganization(orgs, args[0])
+			if !ok {
+				return xerrors.Errorf("organization %q does not exist or you are not a member of it", args[0])
+			}
+
+			// The ID is stored, so renaming the organization doesn't
+			// change the selection.
+			err = createConfig(cmd).Organization().Write(org.ID.String())
+			if err != nil {
+				return xerrors.Errorf("write selected organization: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Switched to organization %s!\n", cliui.Styles.Keyword.Render(org.Name))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func createOrganization() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create an organization",
+		Long:  "You are added to the new organization as an organization admin.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := client.CreateOrganization(cmd.Context(), codersdk.CreateOrganizationRequest{
+				Name: args[0],
+			})
+			if err != nil {
+				return xerrors.Errorf("create organization: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Created organization %s! Run %s to use it.\n",
+				cliui.Styles.Keyword.Render(org.Name),
+				cliui.Styles.Code.Render("coder organizations switch "+org.Name))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func editOrganization() *cobra.Command {
+	var (
+		name               string
+		userWorkspaceQuota int
+	)
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the current organization",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+
+			req := codersdk.UpdateOrganizationRequest{
+				Name: name,
+			}
+			if cmd.Flags().Changed("user-workspace-quota") {
+				req.UserWorkspaceQuota = &userWorkspaceQuota
+			}
+			org, err = client.UpdateOrganization(cmd.Context(), org.ID, req)
+			if err != nil {
+				return xerrors.Errorf("update organization: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Updated organization %s!\n", cliui.Styles.Keyword.Render(org.Name))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "Rename the organization.")
+	cmd.Flags().IntVar(&userWorkspaceQuota, "user-workspace-quota", 0, "The maximum number of workspaces each member can own in the organization. 0 uses the deployment wide quota. Requires an enterprise license.")
+
+	return cmd
+}
@@ -0,0 +1,140 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/cli/config"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestOrganizations(t *testing.T) {
+	t.Parallel()
+
+	// run executes the command with a config directory shared between
+	// runs, so the selected organization is kept.
+	run := func(t *testing.T, root config.Root, args ...string) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		cmd, _ := clitest.New(t, append([]string{"--global-config", string(root)}, args...)...)
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		err := cmd.ExecuteContext(ctx)
+		return buf.String(), err
+	}
+
+	t.Run("Switch", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		root := config.Root(t.TempDir())
+		clitest.SetupConfig(t, client, root)
+		ctx, _ := testutil.Context(t)
+
+		first, err := client.Organization(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		out, err := run(t, root, "organizations", "create", "platform")
+		require.NoError(t, err)
+		require.Contains(t, out, "coder organizations switch platform")
+
+		// The first organization is used until another one is selected.
+		out, err = run(t, root, "organizations", "show")
+		require.NoError(t, err)
+		require.Contains(t, out, first.Name)
+
+		_, err = run(t, root, "organizations", "switch", "platform")
+		require.NoError(t, err)
+		out, err = run(t, root, "organizations", "show")
+		require.NoError(t, err)
+		require.Contains(t, out, "platform")
+		require.NotContains(t, out, first.Name)
+
+		// The flag takes precedence over the selected organization.
+		out, err = run(t, root, "organizations", "show", "--org", first.ID.String())
+		require.NoError(t, err)
+		require.Contains(t, out, first.Name)
+
+		out, err = run(t, root, "organizations", "list")
+		require.NoError(t, err)
+		require.Contains(t, out, first.Name)
+		require.Contains(t, out, "platform")
+
+		_, err = run(t, root, "organizations", "switch", "nothing")
+		require.ErrorContains(t, err, "does not exist")
+		_, err = run(t, root, "organizations", "show", "--org", "nothing")
+		require.ErrorContains(t, err, "does not exist")
+	})
+
+	t.Run("Edit", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		root := config.Root(t.TempDir())
+		clitest.SetupConfig(t, client, root)
+		ctx, _ := testutil.Context(t)
+
+		_, err := run(t, root, "organizations", "edit", "--name", "renamed", "--user-workspace-quota", "3")
+		require.NoError(t, err)
+		org, err := client.Organization(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		require.Equal(t, "renamed", org.Name)
+		require.Equal(t, 3, org.UserWorkspaceQuota)
+
+		// Omitting the quota leaves it unchanged.
+		_, err = run(t, root, "organizations", "edit", "--name", "again")
+		require.NoError(t, err)
+		org, err = client.Organization(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		require.Equal(t, "again", org.Name)
+		require.Equal(t, 3, org.UserWorkspaceQuota)
+	})
+
+	t.Run("Members", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		root := config.Root(t.TempDir())
+		clitest.SetupConfig(t, client, root)
+		ctx, _ := testutil.Context(t)
+
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "platform",
+		})
+		require.NoError(t, err)
+		user, err := client.CreateUser(ctx, codersdk.CreateUserRequest{
+			Email:          "alice@coder.com",
+			Username:       "alice",
+			Password:       "SomeSecurePassword!",
+			OrganizationID: org.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "alice", user.Username)
+
+		out, err := run(t, root, "organizations", "members", "ls")
+		require.NoError(t, err)
+		require.NotContains(t, out, "alice")
+
+		_, err = run(t, root, "organizations", "members", "add", "alice")
+		require.NoError(t, err)
+		out, err = run(t, root, "organizations", "members", "ls")
+		require.NoError(t, err)
+		require.Contains(t, out, "alice@coder.com")
+
+		_, err = run(t, root, "organizations", "members", "rm", "alice", "--yes")
+		require.NoError(t, err)
+		out, err = run(t, root, "organizations", "members", "ls")
+		require.NoError(t, err)
+		require.NotContains(t, out, "alice")
+
+		// The member is still in the organization selected with the flag.
+		out, err = run(t, root, "organizations", "members", "ls", "--org", "platform")
+		require.NoError(t, err)
+		require.Contains(t, out, "alice")
+	})
+}
@@ -55,3 +55,25 @@ func getParameterValueFromMapOrInput(cmd *cobra.Command, parameterMap map[string
 	}
 	return parameterValue, nil
 }
+
+func getRichParameterValueFromMapOrInput(cmd *cobra.Command, parameterMap map[string]string, templateVersionParameter codersdk.TemplateVersionParameter) (string, error) {
+	var parameterValue string
+	if parameterMap != nil {
+		var ok bool
+		parameterValue, ok = parameterMap[templateVersionParameter.Name]
+		if !ok {
+			parameterValue = templateVersionParameter.DefaultValue
+		}
+		err := codersdk.ValidateWorkspaceBuildParameter(templateVersionParameter, parameterValue)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		var err error
+		parameterValue, err = cliui.RichParameter(cmd, templateVersionParameter)
+		if err != nil {
+			return "", err
+		}
+	}
+	return parameterValue, nil
+}
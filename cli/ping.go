@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/net/netcheck"
+	"tailscale.com/tailcfg"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/retry"
+)
+
+func ping() *cobra.Command {
+	var (
+		num          int
+		wait         time.Duration
+		timeout      time.Duration
+		diagnostics  bool
+		outputFormat string
+	)
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "ping <workspace>",
+		Args:        cobra.ExactArgs(1),
+		Short:       "Ping a workspace and show whether the connection is direct or relayed through DERP",
+		Example: formatExamples(
+			example{
+				Description: "Ping a workspace until the command is canceled",
+				Command:     "coder ping my-workspace -n 0",
+			},
+			example{
+				Description: "Explain why a connection is relayed through DERP",
+				Command:     "coder ping my-workspace --diagnostics",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			switch outputFormat {
+			case "table", "":
+			case "json":
+				// The JSON output is the diagnostics report.
+				diagnostics = true
+			default:
+				return xerrors.Errorf(`unknown output format %q, only "table" and "json" are supported`, outputFormat)
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, args[0], false)
+			if err != nil {
+				return err
+			}
+
+			err = cliui.Agent(ctx, cmd.ErrOrStderr(), cliui.AgentOptions{
+				WorkspaceName: workspace.Name,
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+				},
+			})
+			if err != nil {
+				return xerrors.Errorf("await agent: %w", err)
+			}
+			logger := slog.Make(sloghuman.Sink(cmd.ErrOrStderr()))
+			if cliflag.IsSetBool(cmd, varVerbose) {
+				logger = logger.Leveled(slog.LevelDebug)
+			}
+			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{
+				Logger: logger,
+			})
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			derpMap := conn.DERPMap()
+
+			// Pongs are only printed as they arrive for the table format,
+			// the JSON format prints everything at the end.
+			pongOut := cmd.OutOrStdout()
+			if outputFormat == "json" {
+				pongOut = io.Discard
+			}
+			var report pingDiagnostics
+			for i := 0; num <= 0 || i < num; i++ {
+				if i > 0 {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(wait):
+					}
+				}
+				pingCtx, pingCancel := context.WithTimeout(ctx, timeout)
+				pr, err := pingAgent(pingCtx, conn)
+				pingCancel()
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if err != nil {
+					report.Pings = append(report.Pings, pingPong{Error: err.Error()})
+					_, _ = fmt.Fprintf(pongOut, "ping to %q failed: %s\n", workspace.Name, err)
+					continue
+				}
+				pong := convertPingResult(pr, derpMap)
+				report.Pings = append(report.Pings, pong)
+				_, _ = fmt.Fprintf(pongOut, "pong from %s via %s in %s\n", workspace.Name, pong.path(), time.Duration(pong.LatencyMS*float64(time.Millisecond)).Round(time.Millisecond/10))
+			}
+
+			if !diagnostics {
+				if last := report.lastPong(); last != nil && last.Endpoint == "" {
+					_, _ = fmt.Fprintln(cmd.ErrOrStderr(), cliui.Styles.Placeholder.Render("The connection is relayed through DERP. Run with --diagnostics to find out why."))
+				}
+				return nil
+			}
+
+			clientReport, err := conn.Netcheck(ctx)
+			if err != nil {
+				return xerrors.Errorf("run netcheck: %w", err)
+			}
+			report.Client = convertNetcheckReport(clientReport, derpMap)
+			agentReport, err := conn.AgentNetcheck(ctx)
+			if err != nil {
+				// Older agents don't support netcheck, which shouldn't hide
+				// the results of this side.
+				report.AgentError = err.Error()
+			} else {
+				converted := convertNetcheckReport(agentReport, derpMap)
+				report.Agent = &converted
+			}
+			report.Notes = report.notes()
+
+			if outputFormat == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), "\n"+report.table())
+			return err
+		},
+	}
+	cmd.Flags().IntVarP(&num, "num", "n", 10, "Number of pings to send. 0 pings until the command is canceled.")
+	cmd.Flags().DurationVar(&wait, "wait", time.Second, "Duration to wait between pings.")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 5*time.Second, "Duration to wait for each pong.")
+	cliflag.BoolVarP(cmd.Flags(), &diagnostics, "diagnostics", "", "", false,
+		"Print a report of STUN results, NAT type, UDP blocking and DERP latency of this machine and the workspace after pinging.")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format. Available formats are: table, json. The JSON output includes the diagnostics report.")
+	return cmd
+}
+
+// pingDiagnostics explains the path a connection to a workspace takes.
+type pingDiagnostics struct {
+	Pings  []pingPong       `json:"pings"`
+	Client netcheckSummary  `json:"client"`
+	Agent  *netcheckSummary `json:"agent"`
+	// AgentError is set if the agent couldn't run a netcheck.
+	AgentError string `json:"agent_error,omitempty"`
+	// Notes are the likely reasons for a relayed connection.
+	Notes []string `json:"notes"`
+}
+
+type pingPong struct {
+	LatencyMS float64 `json:"latency_ms,omitempty"`
+	// Endpoint is the ip:port of the agent if the pong was received over
+	// a direct connection.
+	Endpoint string `json:"endpoint,omitempty"`
+	// DERPRegion is set if the pong was relayed through DERP.
+	DERPRegion string `json:"derp_region,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (p pingPong) path() string {
+	if p.Endpoint != "" {
+		return p.Endpoint
+	}
+	return fmt.Sprintf("DERP(%s)", p.DERPRegion)
+}
+
+// netcheckSummary is the part of a netcheck report that's relevant to
+// establishing direct connections.
+type netcheckSummary struct {
+	// UDP is false if UDP is blocked, which forces all connections
+	// through DERP.
+	UDP  bool `json:"udp"`
+	IPv4 bool `json:"ipv4"`
+	IPv6 bool `json:"ipv6"`
+	// GlobalV4 and GlobalV6 are the public endpoints discovered with STUN.
+	GlobalV4 string `json:"global_v4"`
+	GlobalV6 string `json:"global_v6"`
+	// NATType is "hard" if the NAT maps a different public port for every
+	// destination, which prevents direct connections with another hard
+	// NAT. It's "easy" otherwise, or "unknown" if it couldn't be
+	// determined.
+	NATType       string             `json:"nat_type"`
+	PreferredDERP string             `json:"preferred_derp"`
+	DERPLatencyMS map[string]float64 `json:"derp_latency_ms"`
+}
+
+const (
+	natTypeEasy    = "easy"
+	natTypeHard    = "hard"
+	natTypeUnknown = "unknown"
+)
+
+// pingAgent pings the agent, retrying while the agent's node hasn't been
+// received from the coordinator yet.
+func pingAgent(ctx context.Context, conn *codersdk.AgentConn) (*ipnstate.PingResult, error) {
+	var err error
+	for r := retry.New(10*time.Millisecond, 500*time.Millisecond); r.Wait(ctx); {
+		var pr *ipnstate.PingResult
+		pr, err = conn.PingResult(ctx)
+		if err == nil {
+			return pr, nil
+		}
+		if !strings.Contains(err.Error(), "no matching peer") {
+			return nil, err
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return nil, err
+}
+
+func convertPingResult(pr *ipnstate.PingResult, derpMap *tailcfg.DERPMap) pingPong {
+	pong := pingPong{
+		LatencyMS: pr.LatencySeconds * 1000,
+		Endpoint:  pr.Endpoint,
+	}
+	if pr.Endpoint == "" {
+		pong.DERPRegion = derpRegionName(derpMap, pr.DERPRegionID)
+	}
+	return pong
+}
+
+func convertNetcheckReport(report *netcheck.Report, derpMap *tailcfg.DERPMap) netcheckSummary {
+	summary := netcheckSummary{
+		UDP:           report.UDP,
+		IPv4:          report.IPv4,
+		IPv6:          report.IPv6,
+		GlobalV4:      report.GlobalV4,
+		GlobalV6:      report.GlobalV6,
+		NATType:       natTypeUnknown,
+		DERPLatencyMS: map[string]float64{},
+	}
+	if varies, ok := report.MappingVariesByDestIP.Get(); ok {
+		summary.NATType = natTypeEasy
+		if varies {
+			summary.NATType = natTypeHard
+		}
+	}
+	if report.PreferredDERP != 0 {
+		summary.PreferredDERP = derpRegionName(derpMap, report.PreferredDERP)
+	}
+	for regionID, latency := range report.RegionLatency {
+		summary.DERPLatencyMS[derpRegionName(derpMap, regionID)] = float64(latency) / float64(time.Millisecond)
+	}
+	return summary
+}
+
+func derpRegionName(derpMap *tailcfg.DERPMap, regionID int) string {
+	if derpMap != nil {
+		if region, ok := derpMap.Regions[regionID]; ok && region.RegionName != "" {
+			return region.RegionName
+		}
+	}
+	return fmt.Sprintf("region %d", regionID)
+}
+
+func (d pingDiagnostics) lastPong() *pingPong {
+	for i := len(d.Pings) - 1; i >= 0; i-- {
+		if d.Pings[i].Error == "" {
+			return &d.Pings[i]
+		}
+	}
+	return nil
+}
+
+func (d pingDiagnostics) notes() []string {
+	notes := []string{}
+	last := d.lastPong()
+	if last == nil {
+		notes = append(notes, "No pongs were received from the workspace.")
+	} else if last.Endpoint != "" {
+		return append(notes, "The connection is direct.")
+	}
+	if !d.Client.UDP {
+		notes = append(notes, "UDP is blocked on this machine, so connections must be relayed through DERP.")
+	}
+	if d.Agent != nil && !d.Agent.UDP {
+		notes = append(notes, "UDP is blocked in the workspace, so connections must be relayed through DERP.")
+	}
+	if d.Client.UDP && d.Agent != nil && d.Agent.UDP &&
+		d.Client.NATType == natTypeHard && d.Agent.NATType == natTypeHard {
+		notes = append(notes, "This machine and the workspace are both behind hard NATs, which prevents a direct connection.")
+	}
+	if len(notes) == 0 && last != nil {
+		notes = append(notes, "No reason for a relayed connection was found. A direct connection may still be negotiated.")
+	}
+	return notes
+}
+
+func (d pingDiagnostics) table() string {
+	agent := netcheckSummary{NATType: natTypeUnknown}
+	if d.Agent != nil {
+		agent = *d.Agent
+	}
+	tableWriter := cliui.Table()
+	tableWriter.AppendHeader(table.Row{"", "This machine", "Workspace"})
+	tableWriter.AppendRow(table.Row{"UDP", d.Client.UDP, agent.UDP})
+	tableWriter.AppendRow(table.Row{"IPv4", d.Client.IPv4, agent.IPv4})
+	tableWriter.AppendRow(table.Row{"IPv6", d.Client.IPv6, agent.IPv6})
+	tableWriter.AppendRow(table.Row{"Public IPv4 (STUN)", d.Client.GlobalV4, agent.GlobalV4})
+	tableWriter.AppendRow(table.Row{"Public IPv6 (STUN)", d.Client.GlobalV6, agent.GlobalV6})
+	tableWriter.AppendRow(table.Row{"NAT type", d.Client.NATType, agent.NATType})
+	tableWriter.AppendRow(table.Row{"Preferred DERP", d.Client.PreferredDERP, agent.PreferredDERP})
+
+	regions := make([]string, 0, len(d.Client.DERPLatencyMS))
+	seen := map[string]struct{}{}
+	for _, latencies := range []map[string]float64{d.Client.DERPLatencyMS, agent.DERPLatencyMS} {
+		for region := range latencies {
+			if _, ok := seen[region]; !ok {
+				seen[region] = struct{}{}
+				regions = append(regions, region)
+			}
+		}
+	}
+	sort.Strings(regions)
+	for _, region := range regions {
+		tableWriter.AppendRow(table.Row{
+			fmt.Sprintf("Latency to %s", region),
+			formatLatency(d.Client.DERPLatencyMS, region),
+			formatLatency(agent.DERPLatencyMS, region),
+		})
+	}
+
+	out := tableWriter.Render()
+	if d.AgentError != "" {
+		out += "\n" + cliui.Styles.Warn.Render(fmt.Sprintf("The workspace couldn't run a netcheck: %s", d.AgentError))
+	}
+	for _, note := range d.Notes {
+		out += "\n" + note
+	}
+	return out
+}
+
+func formatLatency(latencies map[string]float64, region string) string {
+	latency, ok := latencies[region]
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", latency)
+}
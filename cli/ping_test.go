@@ -0,0 +1,97 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Pongs", func(t *testing.T) {
+		t.Parallel()
+		client, workspace, agentToken := setupWorkspaceForAgent(t)
+		agentClient := codersdk.New(client.URL)
+		agentClient.SessionToken = agentToken
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent"),
+		})
+		defer agentCloser.Close()
+		coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+		cmd, root := clitest.New(t, "ping", workspace.Name, "-n", "2", "--wait", "10ms")
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetOut(pty.Output())
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		cmdDone := tGo(t, func() {
+			err := cmd.ExecuteContext(ctx)
+			assert.NoError(t, err)
+		})
+		pty.ExpectMatch("pong from " + workspace.Name)
+		<-cmdDone
+	})
+
+	t.Run("Diagnostics", func(t *testing.T) {
+		t.Parallel()
+		client, workspace, agentToken := setupWorkspaceForAgent(t)
+		agentClient := codersdk.New(client.URL)
+		agentClient.SessionToken = agentToken
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent"),
+		})
+		defer agentCloser.Close()
+		coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+		cmd, root := clitest.New(t, "ping", workspace.Name, "-n", "1", "-o", "json")
+		clitest.SetupConfig(t, client, root)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		err := cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+
+		var report struct {
+			Pings []struct {
+				LatencyMS float64 `json:"latency_ms"`
+				Error     string  `json:"error"`
+			} `json:"pings"`
+			Client struct {
+				UDP           bool   `json:"udp"`
+				PreferredDERP string `json:"preferred_derp"`
+			} `json:"client"`
+			Agent *struct {
+				UDP bool `json:"udp"`
+			} `json:"agent"`
+			Notes []string `json:"notes"`
+		}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &report), out.String())
+		require.Len(t, report.Pings, 1)
+		require.Empty(t, report.Pings[0].Error)
+		// The test DERP server runs a STUN server on localhost.
+		require.True(t, report.Client.UDP)
+		require.NotEmpty(t, report.Client.PreferredDERP)
+		require.NotNil(t, report.Agent)
+		require.True(t, report.Agent.UDP)
+		require.NotEmpty(t, report.Notes)
+	})
+}
@@ -28,10 +28,9 @@ func portForward() *cobra.Command {
 		udpForwards []string // <port>:<port>
 	)
 	cmd := &cobra.Command{
-		Use:     "port-forward <workspace>",
-		Short:   "Forward ports from machine to a workspace",
-		Aliases: []string{"tunnel"},
-		Args:    cobra.ExactArgs(1),
+		Use:   "port-forward <workspace>",
+		Short: "Forward ports from machine to a workspace",
+		Args:  cobra.ExactArgs(1),
 		Example: formatExamples(
 			example{
 				Description: "Port forward a single TCP port from 1234 in the workspace to port 5678 on your local machine",
@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func roles() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "roles",
+		Short: "Manage custom roles",
+		Long: "Roles are managed in the current organization, or site wide with --site. " +
+			"Custom roles grant permissions in addition to the builtin roles, and are assigned to users like them. " +
+			"Permissions are written as \"resource:action\", e.g. \"template:create\". Either can be \"*\", " +
+			"and a leading \"!\" denies the permission.",
+		Example: formatExamples(
+			example{
+				Description: "Create a site wide role that can push template versions, but not delete templates",
+				Command: "coder roles create template-operator --site --display-name \"Template Operator\" " +
+					"--site-permission template:read --site-permission template:create --site-permission template:update " +
+					"--user-permission file:*",
+			},
+			example{
+				Description: "Create a role in the organization selected with --org",
+				Command:     "coder roles create template-reader --org acme --display-name \"Template Reader\" --org-permission template:read",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		listRoles(),
+		createRole(),
+		editRole(),
+		deleteRole(),
+	)
+
+	return cmd
+}
+
+type roleRow struct {
+	Name            string `table:"Name"`
+	DisplayName     string `table:"Display Name"`
+	SitePermissions string `table:"Site Permissions"`
+	OrgPermissions  string `table:"Org Permissions"`
+	UserPermissions string `table:"User Permissions"`
+}
+
+func listRoles() *cobra.Command {
+	var siteWide bool
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List custom roles",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			organizationID, err := roleOrganization(cmd, client, siteWide)
+			if err != nil {
+				return err
+			}
+			roles, err := client.CustomRoles(cmd.Context(), organizationID)
+			if err != nil {
+				return xerrors.Errorf("get custom roles: %w", err)
+			}
+
+			if len(roles) == 0 {
+				cmd.Println(cliui.Styles.Wrap.Render(
+					"No custom roles found.",
+				))
+				return nil
+			}
+
+			rows := make([]roleRow, 0, len(roles))
+			for _, role := range roles {
+				rows = append(rows, roleRow{
+					Name:            role.Name,
+					DisplayName:     role.DisplayName,
+					SitePermissions: formatPermissions(role.SitePermissions),
+					OrgPermissions:  formatPermissions(role.OrgPermissions),
+					UserPermissions: formatPermissions(role.UserPermissions),
+				})
+			}
+
+			out, err := cliui.DisplayTable(rows, "", nil)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+	roleSiteFlag(cmd, &siteWide, "List the site wide roles instead of the roles of the organization.")
+
+	return cmd
+}
+
+func createRole() *cobra.Command {
+	var (
+		siteWide    bool
+		displayName string
+		permissions rolePermissionFlags
+	)
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a custom role",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			organizationID, err := roleOrganization(cmd, client, siteWide)
+			if err != nil {
+				return err
+			}
+			if displayName == "" {
+				displayName = args[0]
+			}
+			site, orgPerms, user, err := permissions.parse()
+			if err != nil {
+				return err
+			}
+
+			role, err := client.CreateCustomRole(cmd.Context(), organizationID, codersdk.CreateCustomRoleRequest{
+				Name:            args[0],
+				DisplayName:     displayName,
+				SitePermissions: site,
+				OrgPermissions:  orgPerms,
+				UserPermissions: user,
+			})
+			if err != nil {
+				return xerrors.Errorf("create custom role: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Created role %s!\n", cliui.Styles.Keyword.Render(role.Name))
+			return nil
+		},
+	}
+	roleSiteFlag(cmd, &siteWide, "Create a site wide role instead of a role in the organization.")
+	cmd.Flags().StringVar(&displayName, "display-name", "", "The name of the role shown in the dashboard. Defaults to the name.")
+	permissions.attach(cmd)
+
+	return cmd
+}
+
+func editRole() *cobra.Command {
+	var (
+		siteWide    bool
+		displayName string
+		permissions rolePermissionFlags
+	)
+	cmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Edit a custom role",
+		Long:  "Each permission flag that is passed replaces that set of permissions of the role, other sets are kept.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			organizationID, err := roleOrganization(cmd, client, siteWide)
+			if err != nil {
+				return err
+			}
+			roles, err := client.CustomRoles(cmd.Context(), organizationID)
+			if err != nil {
+				return xerrors.Errorf("get custom roles: %w", err)
+			}
+			var role *codersdk.CustomRole
+			for i := range roles {
+				if roles[i].Name == args[0] {
+					role = &roles[i]
+				}
+			}
+			if role == nil {
+				return xerrors.Errorf("custom role %q not found", args[0])
+			}
+
+			site, orgPerms, user, err := permissions.parse()
+			if err != nil {
+				return err
+			}
+			req := codersdk.UpdateCustomRoleRequest{
+				DisplayName:     role.DisplayName,
+				SitePermissions: role.SitePermissions,
+				OrgPermissions:  role.OrgPermissions,
+				UserPermissions: role.UserPermissions,
+			}
+			if cmd.Flags().Changed("display-name") {
+				req.DisplayName = displayName
+			}
+			if cmd.Flags().Changed("site-permission") {
+				req.SitePermissions = site
+			}
+			if cmd.Flags().Changed("org-permission") {
+				req.OrgPermissions = orgPerms
+			}
+			if cmd.Flags().Changed("user-permission") {
+				req.UserPermissions = user
+			}
+
+			_, err = client.UpdateCustomRole(cmd.Context(), organizationID, role.Name, req)
+			if err != nil {
+				return xerrors.Errorf("update custom role: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Updated role %s!\n", cliui.Styles.Keyword.Render(role.Name))
+			return nil
+		},
+	}
+	roleSiteFlag(cmd, &siteWide, "Edit a site wide role instead of a role of the organization.")
+	cmd.Flags().StringVar(&displayName, "display-name", "", "The name of the role shown in the dashboard.")
+	permissions.attach(cmd)
+
+	return cmd
+}
+
+func deleteRole() *cobra.Command {
+	var siteWide bool
+	cmd := &cobra.Command{
+		Use:     "delete <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a custom role and unassign it from all users",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			organizationID, err := roleOrganization(cmd, client, siteWide)
+			if err != nil {
+				return err
+			}
+			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+				Text:      fmt.Sprintf("Delete role %s? Users with the role lose its permissions.", cliui.Styles.Code.Render(args[0])),
+				IsConfirm: true,
+				Default:   cliui.ConfirmNo,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = client.DeleteCustomRole(cmd.Context(), organizationID, args[0])
+			if err != nil {
+				return xerrors.Errorf("delete custom role: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Deleted role %s!\n", cliui.Styles.Keyword.Render(args[0]))
+			return nil
+		},
+	}
+	roleSiteFlag(cmd, &siteWide, "Delete a site wide role instead of a role of the organization.")
+	cliui.AllowSkipPrompt(cmd)
+
+	return cmd
+}
+
+// roleSiteFlag adds --site to a roles command, which manages the site wide
+// roles instead of the roles of the organization selected with --org.
+func roleSiteFlag(cmd *cobra.Command, site *bool, usage string) {
+	cmd.Flags().BoolVar(site, "site", false, usage)
+}
+
+// roleOrganization returns the organization of the roles to manage, which is
+// uuid.Nil for the site wide roles.
+func roleOrganization(cmd *cobra.Command, client *codersdk.Client, site bool) (uuid.UUID, error) {
+	if site {
+		return uuid.Nil, nil
+	}
+	organization, err := CurrentOrganization(cmd, client)
+	if err != nil {
+		return uuid.Nil, xerrors.Errorf("get current organization: %w", err)
+	}
+	return organization.ID, nil
+}
+
+type rolePermissionFlags struct {
+	site []string
+	org  []string
+	user []string
+}
+
+func (f *rolePermissionFlags) attach(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.site, "site-permission", nil, "A permission the role grants everywhere, e.g. \"template:read\". Site wide roles only.")
+	cmd.Flags().StringArrayVar(&f.org, "org-permission", nil, "A permission the role grants in its organization, e.g. \"template:*\". Organization roles only.")
+	cmd.Flags().StringArrayVar(&f.user, "user-permission", nil, "A permission the role grants on resources owned by the user, e.g. \"workspace:*\". Site wide roles only.")
+}
+
+func (f *rolePermissionFlags) parse() (site, org, user []codersdk.Permission, err error) {
+	site, err = parsePermissions(f.site)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	org, err = parsePermissions(f.org)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	user, err = parsePermissions(f.user)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return site, org, user, nil
+}
+
+// parsePermissions parses permissions like "template:create" or
+// "!template:delete".
+func parsePermissions(values []string) ([]codersdk.Permission, error) {
+	permissions := make([]codersdk.Permission, 0, len(values))
+	for _, value := range values {
+		negate := strings.HasPrefix(value, "!")
+		resourceType, action, ok := strings.Cut(strings.TrimPrefix(value, "!"), ":")
+		if !ok || resourceType == "" || action == "" {
+			return nil, xerrors.Errorf("permission %q must be in the format \"resource:action\"", value)
+		}
+		permissions = append(permissions, codersdk.Permission{
+			Negate:       negate,
+			ResourceType: resourceType,
+			Action:       action,
+		})
+	}
+	return permissions, nil
+}
+
+func formatPermissions(permissions []codersdk.Permission) string {
+	formatted := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		value := permission.ResourceType + ":" + permission.Action
+		if permission.Negate {
+			value = "!" + value
+		}
+		formatted = append(formatted, value)
+	}
+	return strings.Join(formatted, ", ")
+}
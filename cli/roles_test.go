@@ -0,0 +1,124 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestRoles(t *testing.T) {
+	t.Parallel()
+
+	run := func(t *testing.T, client *codersdk.Client, args ...string) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		cmd, root := clitest.New(t, append([]string{"roles"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		err := cmd.ExecuteContext(ctx)
+		return buf.String(), err
+	}
+
+	t.Run("Site", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		out, err := run(t, client, "create", "template-operator", "--site",
+			"--display-name", "Template Operator",
+			"--site-permission", "template:read",
+			"--site-permission", "template:create",
+			"--user-permission", "file:*",
+		)
+		require.NoError(t, err)
+		require.Contains(t, out, "template-operator")
+
+		out, err = run(t, client, "ls", "--site")
+		require.NoError(t, err)
+		require.Contains(t, out, "Template Operator")
+		require.Contains(t, out, "template:read, template:create")
+
+		// Only the passed sets of permissions are replaced.
+		_, err = run(t, client, "edit", "template-operator", "--site",
+			"--site-permission", "template:*",
+			"--site-permission", "!template:delete",
+		)
+		require.NoError(t, err)
+		roles, err := client.CustomRoles(ctx, uuid.Nil)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.Equal(t, "Template Operator", roles[0].DisplayName)
+		require.Equal(t, []codersdk.Permission{
+			{ResourceType: "template", Action: "*"},
+			{Negate: true, ResourceType: "template", Action: "delete"},
+		}, roles[0].SitePermissions)
+		require.Equal(t, []codersdk.Permission{
+			{ResourceType: "file", Action: "*"},
+		}, roles[0].UserPermissions)
+
+		_, err = run(t, client, "delete", "-y", "--site", "template-operator")
+		require.NoError(t, err)
+		roles, err = client.CustomRoles(ctx, uuid.Nil)
+		require.NoError(t, err)
+		require.Len(t, roles, 0)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		// Without --org, the current organization is used.
+		_, err := run(t, client, "create", "template-reader", "--org-permission", "template:read")
+		require.NoError(t, err)
+		roles, err := client.CustomRoles(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.Equal(t, "template-reader", roles[0].DisplayName)
+
+		other, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "other",
+		})
+		require.NoError(t, err)
+		_, err = run(t, client, "create", "template-reader", "--org", "other", "--org-permission", "template:read")
+		require.NoError(t, err)
+		roles, err = client.CustomRoles(ctx, other.ID)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+
+		// The global flag works the same.
+		out, err := run(t, client, "--org", "other", "ls")
+		require.NoError(t, err)
+		require.Contains(t, out, "template-reader")
+		_, err = run(t, client, "--org", "other", "delete", "-y", "template-reader")
+		require.NoError(t, err)
+		roles, err = client.CustomRoles(ctx, other.ID)
+		require.NoError(t, err)
+		require.Empty(t, roles)
+
+		// Site wide roles are only managed with --site.
+		roles, err = client.CustomRoles(ctx, uuid.Nil)
+		require.NoError(t, err)
+		require.Empty(t, roles)
+	})
+
+	t.Run("InvalidPermission", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		_, err := run(t, client, "create", "invalid", "--site-permission", "template")
+		require.ErrorContains(t, err, "resource:action")
+	})
+}
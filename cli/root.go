@@ -51,6 +51,7 @@ const (
 	varForceTty         = "force-tty"
 	varVerbose          = "verbose"
 	varExperimental     = "experimental"
+	varOrganization     = "org"
 	notLoggedInMessage  = "You are not logged in. Try logging in using 'coder login <url>'."
 
 	envNoVersionCheck   = "CODER_NO_VERSION_WARNING"
@@ -58,6 +59,7 @@ const (
 	envExperimental     = "CODER_EXPERIMENTAL"
 	envSessionToken     = "CODER_SESSION_TOKEN"
 	envURL              = "CODER_URL"
+	envOrganization     = "CODER_ORGANIZATION"
 )
 
 var (
@@ -72,6 +74,7 @@ func init() {
 func Core() []*cobra.Command {
 	return []*cobra.Command{
 		configSSH(),
+		cp(),
 		create(),
 		deleteWorkspace(),
 		dotfiles(),
@@ -79,13 +82,17 @@ func Core() []*cobra.Command {
 		list(),
 		login(),
 		logout(),
+		organizations(),
 		parameters(),
 		portForward(),
 		publickey(),
 		resetPassword(),
+		roles(),
 		schedules(),
+		sessions(),
 		show(),
 		ssh(),
+		ping(),
 		speedtest(),
 		start(),
 		state(),
@@ -97,6 +104,7 @@ func Core() []*cobra.Command {
 		versionCmd(),
 		workspaceAgent(),
 		tokens(),
+		tunnel(),
 	}
 }
 
@@ -209,6 +217,7 @@ func Root(subcommands []*cobra.Command) *cobra.Command {
 	cliflag.String(cmd.PersistentFlags(), varAgentURL, "", "CODER_AGENT_URL", "", "URL for an agent to access your deployment.")
 	_ = cmd.PersistentFlags().MarkHidden(varAgentURL)
 	cliflag.String(cmd.PersistentFlags(), config.FlagName, "", "CODER_CONFIG_DIR", configdir.LocalConfig("coderv2"), "Path to the global `coder` config directory.")
+	cliflag.String(cmd.PersistentFlags(), varOrganization, "", envOrganization, "", "Name or ID of the organization to use. Defaults to the organization selected with \"coder organizations switch\", or your first organization.")
 	cliflag.StringArray(cmd.PersistentFlags(), varHeader, "", "CODER_HEADER", []string{}, "HTTP headers added to all requests. Provide as \"Key=Value\"")
 	cmd.PersistentFlags().Bool(varForceTty, false, "Force the `coder` command to run as if connected to a TTY.")
 	_ = cmd.PersistentFlags().MarkHidden(varForceTty)
@@ -277,18 +286,7 @@ func isTest() bool {
 // It reads from global configuration files if flags are not set.
 func CreateClient(cmd *cobra.Command) (*codersdk.Client, error) {
 	root := createConfig(cmd)
-	rawURL, err := cmd.Flags().GetString(varURL)
-	if err != nil || rawURL == "" {
-		rawURL, err = root.URL().Read()
-		if err != nil {
-			// If the configuration files are absent, the user is logged out
-			if os.IsNotExist(err) {
-				return nil, errUnauthenticated
-			}
-			return nil, err
-		}
-	}
-	serverURL, err := url.Parse(strings.TrimSpace(rawURL))
+	serverURL, err := readServerURL(cmd, root)
 	if err != nil {
 		return nil, err
 	}
@@ -311,6 +309,34 @@ func CreateClient(cmd *cobra.Command) (*codersdk.Client, error) {
 	return client, nil
 }
 
+// CreateUnauthenticatedClient returns a new client from the command context
+// without a session token. It's used by commands that authenticate by other
+// means, like external provisioner daemons using a pre-shared key.
+func CreateUnauthenticatedClient(cmd *cobra.Command) (*codersdk.Client, error) {
+	serverURL, err := readServerURL(cmd, createConfig(cmd))
+	if err != nil {
+		return nil, err
+	}
+	return createUnauthenticatedClient(cmd, serverURL)
+}
+
+// readServerURL reads the deployment URL from flags, falling back to
+// the global configuration files.
+func readServerURL(cmd *cobra.Command, root config.Root) (*url.URL, error) {
+	rawURL, err := cmd.Flags().GetString(varURL)
+	if err != nil || rawURL == "" {
+		rawURL, err = root.URL().Read()
+		if err != nil {
+			// If the configuration files are absent, the user is logged out
+			if os.IsNotExist(err) {
+				return nil, errUnauthenticated
+			}
+			return nil, err
+		}
+	}
+	return url.Parse(strings.TrimSpace(rawURL))
+}
+
 func createUnauthenticatedClient(cmd *cobra.Command, serverURL *url.URL) (*codersdk.Client, error) {
 	client := codersdk.New(serverURL)
 	headers, err := cmd.Flags().GetStringArray(varHeader)
@@ -353,14 +379,46 @@ func createAgentClient(cmd *cobra.Command) (*codersdk.Client, error) {
 }
 
 // CurrentOrganization returns the currently active organization for the authenticated user.
+// It is the organization passed with --org, the one selected with
+// "coder organizations switch", or the user's first organization.
 func CurrentOrganization(cmd *cobra.Command, client *codersdk.Client) (codersdk.Organization, error) {
+	selected, err := cmd.Flags().GetString(varOrganization)
+	if err != nil {
+		return codersdk.Organization{}, err
+	}
+	if selected == "" {
+		selected, err = createConfig(cmd).Organization().Read()
+		if err != nil && !os.IsNotExist(err) {
+			return codersdk.Organization{}, xerrors.Errorf("read selected organization: %w", err)
+		}
+	}
+
 	orgs, err := client.OrganizationsByUser(cmd.Context(), codersdk.Me)
 	if err != nil {
-		return codersdk.Organization{}, nil
+		return codersdk.Organization{}, xerrors.Errorf("get organizations: %w", err)
+	}
+	if selected == "" {
+		if len(orgs) == 0 {
+			return codersdk.Organization{}, xerrors.New("you are not a member of any organization")
+		}
+		return orgs[0], nil
+	}
+	org, ok := findOrganization(orgs, selected)
+	if !ok {
+		return codersdk.Organization{}, xerrors.Errorf("organization %q does not exist or you are not a member of it, run %q to list your organizations",
+			selected, "coder organizations list")
+	}
+	return org, nil
+}
+
+// findOrganization returns the organization with the given name or ID.
+func findOrganization(orgs []codersdk.Organization, nameOrID string) (codersdk.Organization, bool) {
+	for _, org := range orgs {
+		if strings.EqualFold(org.Name, nameOrID) || org.ID.String() == nameOrID {
+			return org, true
+		}
 	}
-	// For now, we won't use the config to set this.
-	// Eventually, we will support changing using "coder switch <org>"
-	return orgs[0], nil
+	return codersdk.Organization{}, false
 }
 
 // namedWorkspace fetches and returns a workspace by an identifier, which may be either
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -54,12 +55,14 @@ import (
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/databasefake"
 	"github.com/coder/coder/coderd/database/migrations"
+	"github.com/coder/coder/coderd/derpmap"
 	"github.com/coder/coder/coderd/devtunnel"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/prometheusmetrics"
+	"github.com/coder/coder/coderd/sshca"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/tracing"
 	"github.com/coder/coder/codersdk"
@@ -313,10 +316,20 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 			if !cfg.DERP.Server.Enable.Value {
 				defaultRegion = nil
 			}
-			derpMap, err := tailnet.NewDERPMap(ctx, defaultRegion, cfg.DERP.Server.STUNAddresses.Value, cfg.DERP.Config.URL.Value, cfg.DERP.Config.Path.Value)
+			derpMapRefresher, err := derpmap.New(ctx, derpmap.Options{
+				Logger: logger.Named("derpmap"),
+				Provider: func(ctx context.Context) (*tailcfg.DERPMap, error) {
+					// NewDERPMap adds STUN nodes to the region it's given,
+					// so every refresh starts from a copy.
+					return tailnet.NewDERPMap(ctx, defaultRegion.Clone(), cfg.DERP.Server.STUNAddresses.Value, cfg.DERP.Config.URL.Value, cfg.DERP.Config.Path.Value)
+				},
+				RefreshInterval: cfg.DERP.Config.RefreshInterval.Value,
+			})
 			if err != nil {
 				return xerrors.Errorf("create derp map: %w", err)
 			}
+			defer derpMapRefresher.Close()
+			derpMap := derpMapRefresher.DERPMap()
 
 			appHostname := strings.TrimSpace(cfg.WildcardAccessURL.Value)
 			var appHostnameRegex *regexp.Regexp
@@ -344,6 +357,7 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				Logger:                      logger.Named("coderd"),
 				Database:                    databasefake.New(),
 				DERPMap:                     derpMap,
+				DERPMapRefresher:            derpMapRefresher,
 				Pubsub:                      database.NewPubsubInMemory(),
 				CacheDir:                    cfg.CacheDirectory.Value,
 				GoogleTokenValidator:        googleTokenValidator,
@@ -351,6 +365,9 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				RealIPConfig:                realIPConfig,
 				SecureAuthCookie:            cfg.SecureAuthCookie.Value,
 				SSHKeygenAlgorithm:          sshKeygenAlgorithm,
+				SSHCertificateTTL:           cfg.SSHCertificateTTL.Value,
+				SSHRequireCertificates:      cfg.SSHRequireCertificates.Value,
+				MaxServiceTokenLifetime:     cfg.MaxServiceTokenLifetime.Value,
 				TracerProvider:              tracerProvider,
 				Telemetry:                   telemetry.NewNoop(),
 				AutoImportTemplates:         validatedAutoImportTemplates,
@@ -393,6 +410,20 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				if err != nil {
 					return xerrors.Errorf("parse oidc oauth callback url: %w", err)
 				}
+				var groupMapping map[string]string
+				if cfg.OIDC.GroupMapping.Value != "" {
+					err = json.Unmarshal([]byte(cfg.OIDC.GroupMapping.Value), &groupMapping)
+					if err != nil {
+						return xerrors.Errorf("parse oidc group mapping: %w", err)
+					}
+				}
+				var userRoleMapping map[string][]string
+				if cfg.OIDC.UserRoleMapping.Value != "" {
+					err = json.Unmarshal([]byte(cfg.OIDC.UserRoleMapping.Value), &userRoleMapping)
+					if err != nil {
+						return xerrors.Errorf("parse oidc user role mapping: %w", err)
+					}
+				}
 				options.OIDCConfig = &coderd.OIDCConfig{
 					OAuth2Config: &oauth2.Config{
 						ClientID:     cfg.OIDC.ClientID.Value,
@@ -404,8 +435,12 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 					Verifier: oidcProvider.Verifier(&oidc.Config{
 						ClientID: cfg.OIDC.ClientID.Value,
 					}),
-					EmailDomain:  cfg.OIDC.EmailDomain.Value,
-					AllowSignups: cfg.OIDC.AllowSignups.Value,
+					EmailDomain:     cfg.OIDC.EmailDomain.Value,
+					AllowSignups:    cfg.OIDC.AllowSignups.Value,
+					GroupField:      cfg.OIDC.GroupField.Value,
+					GroupMapping:    groupMapping,
+					UserRoleField:   cfg.OIDC.UserRoleField.Value,
+					UserRoleMapping: userRoleMapping,
 				}
 			}
 
@@ -468,6 +503,25 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				}
 			}
 
+			sshCAKey, err := options.Database.GetSSHCertificateAuthorityKey(ctx)
+			if err != nil {
+				if !errors.Is(err, sql.ErrNoRows) {
+					return xerrors.Errorf("get ssh certificate authority key: %w", err)
+				}
+				sshCAKey, err = sshca.GenerateKey()
+				if err != nil {
+					return xerrors.Errorf("generate ssh certificate authority key: %w", err)
+				}
+				err = options.Database.InsertSSHCertificateAuthorityKey(ctx, sshCAKey)
+				if err != nil {
+					return xerrors.Errorf("insert ssh certificate authority key: %w", err)
+				}
+			}
+			options.SSHCertificateAuthority, err = sshca.ParseKey(sshCAKey)
+			if err != nil {
+				return xerrors.Errorf("parse ssh certificate authority key from database: %w", err)
+			}
+
 			// Parse the raw telemetry URL!
 			telemetryURL, err := parseURL(cfg.Telemetry.URL.Value)
 			if err != nil {
@@ -517,6 +571,12 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				}
 				defer closeWorkspacesFunc()
 
+				closeConnectionStatsFunc, err := prometheusmetrics.ConnectionStats(ctx, options.PrometheusRegistry, options.Database, options.DERPMap, 0)
+				if err != nil {
+					return xerrors.Errorf("register connection stats prometheus metric: %w", err)
+				}
+				defer closeConnectionStatsFunc()
+
 				//nolint:revive
 				defer serveHandler(ctx, logger, promhttp.InstrumentMetricHandler(
 					options.PrometheusRegistry, promhttp.HandlerFor(options.PrometheusRegistry, promhttp.HandlerOpts{}),
@@ -625,7 +685,7 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 
 			autobuildPoller := time.NewTicker(cfg.AutobuildPollInterval.Value)
 			defer autobuildPoller.Stop()
-			autobuildExecutor := executor.New(ctx, options.Database, logger, autobuildPoller.C)
+			autobuildExecutor := executor.New(ctx, options.Database, logger, autobuildPoller.C).WithAuditor(&coderAPI.Auditor)
 			autobuildExecutor.Run()
 
 			// This is helpful for tests, but can be silently ignored.
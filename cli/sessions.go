@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/cli/cliui"
+)
+
+func sessions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List and play recorded terminal sessions",
+		Long: "Terminal sessions are recorded on workspaces created from templates with session recording enabled. " +
+			"Recordings are visible to users who can read the audit log.",
+		Example: formatExamples(
+			example{
+				Description: "List the recorded sessions of a workspace",
+				Command:     "coder sessions ls kyle/dev",
+			},
+			example{
+				Description: "Play a recorded session at double speed",
+				Command:     "coder sessions play 3b7b8d3c-7a2e-4b8e-9a33-4a0d5a1a8a4e --speed 2",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		listSessions(),
+		playSession(),
+	)
+
+	return cmd
+}
+
+type sessionRow struct {
+	ID        string        `table:"ID"`
+	Type      string        `table:"Type"`
+	StartedAt time.Time     `table:"Started At"`
+	Duration  time.Duration `table:"Duration"`
+}
+
+func listSessions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list <workspace>",
+		Aliases: []string{"ls"},
+		Short:   "List the recorded sessions of a workspace",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			workspace, err := namedWorkspace(cmd, client, args[0])
+			if err != nil {
+				return xerrors.Errorf("get workspace: %w", err)
+			}
+			recordings, err := client.WorkspaceSessionRecordings(cmd.Context(), workspace.ID)
+			if err != nil {
+				return xerrors.Errorf("get session recordings: %w", err)
+			}
+
+			if len(recordings) == 0 {
+				cmd.Println(cliui.Styles.Wrap.Render(
+					"No recorded sessions found.",
+				))
+				return nil
+			}
+
+			rows := make([]sessionRow, 0, len(recordings))
+			for _, recording := range recordings {
+				rows = append(rows, sessionRow{
+					ID:        recording.ID.String(),
+					Type:      string(recording.Type),
+					StartedAt: recording.StartedAt,
+					Duration:  recording.EndedAt.Sub(recording.StartedAt).Round(time.Second),
+				})
+			}
+
+			out, err := cliui.DisplayTable(rows, "", nil)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	return cmd
+}
+
+func playSession() *cobra.Command {
+	var (
+		speed         float64
+		idleTimeLimit time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "play <id>",
+		Short: "Play a recorded session in the terminal",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if speed <= 0 {
+				return xerrors.Errorf("speed must be positive, got %v", speed)
+			}
+			id, err := uuid.Parse(args[0])
+			if err != nil {
+				return xerrors.Errorf("parse session recording id: %w", err)
+			}
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			data, err := client.SessionRecording(cmd.Context(), id)
+			if err != nil {
+				return xerrors.Errorf("get session recording: %w", err)
+			}
+			reader, err := asciicast.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return xerrors.Errorf("read session recording: %w", err)
+			}
+
+			// The recording is played as it happened, so the output is only
+			// correct in a terminal at least as large as the recorded one.
+			var last time.Duration
+			for {
+				event, err := reader.Next()
+				if xerrors.Is(err, io.EOF) {
+					return nil
+				}
+				if err != nil {
+					return xerrors.Errorf("read session recording: %w", err)
+				}
+				delay := event.Time - last
+				last = event.Time
+				if idleTimeLimit > 0 && delay > idleTimeLimit {
+					delay = idleTimeLimit
+				}
+				delay = time.Duration(float64(delay) / speed)
+				if delay > 0 {
+					timer := time.NewTimer(delay)
+					select {
+					case <-cmd.Context().Done():
+						timer.Stop()
+						return cmd.Context().Err()
+					case <-timer.C:
+					}
+				}
+				if event.Type != asciicast.EventTypeOutput {
+					continue
+				}
+				_, err = fmt.Fprint(cmd.OutOrStdout(), event.Data)
+				if err != nil {
+					return err
+				}
+			}
+		},
+	}
+	cmd.Flags().Float64VarP(&speed, "speed", "s", 1, "Play the recording faster or slower by this factor.")
+	cmd.Flags().DurationVarP(&idleTimeLimit, "idle-time-limit", "i", 2*time.Second, "Limit pauses in the recording to this long. Set to 0 to play pauses as recorded.")
+
+	return cmd
+}
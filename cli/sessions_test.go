@@ -0,0 +1,57 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+	client, workspace, agentToken := setupWorkspaceForAgent(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	cmd, root := clitest.New(t, "sessions", "ls", workspace.Name)
+	clitest.SetupConfig(t, client, root)
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err := cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "No recorded sessions found")
+
+	var cast bytes.Buffer
+	writer, err := asciicast.NewWriter(&cast, asciicast.Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("hello from the past\r\n"))
+	require.NoError(t, err)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = agentToken
+	recording, err := agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.SessionRecordingTypeSSH, uuid.Nil, &cast)
+	require.NoError(t, err)
+
+	cmd, root = clitest.New(t, "sessions", "ls", workspace.Name)
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), recording.ID.String())
+	require.Contains(t, buf.String(), "ssh")
+
+	cmd, root = clitest.New(t, "sessions", "play", recording.ID.String(), "--speed", "100")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "hello from the past\r\n", buf.String())
+}
@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
 )
 
 func show() *cobra.Command {
@@ -29,7 +34,31 @@ func show() *cobra.Command {
 			return cliui.WorkspaceResources(cmd.OutOrStdout(), workspace.LatestBuild.Resources, cliui.WorkspaceResourcesOptions{
 				WorkspaceName: workspace.Name,
 				ServerVersion: buildInfo.Version,
+				AgentMetadata: agentMetadata(cmd.Context(), client, workspace.LatestBuild.Resources),
 			})
 		},
 	}
 }
+
+// agentMetadata fetches the latest metadata of every connected agent.
+// Metadata is informational, so agents that fail to respond are omitted
+// rather than failing the command.
+func agentMetadata(ctx context.Context, client *codersdk.Client, resources []codersdk.WorkspaceResource) map[uuid.UUID][]codersdk.WorkspaceAgentMetadatum {
+	metadata := make(map[uuid.UUID][]codersdk.WorkspaceAgentMetadatum)
+	for _, resource := range resources {
+		for _, agent := range resource.Agents {
+			if agent.Status != codersdk.WorkspaceAgentConnected {
+				continue
+			}
+			watchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			updates, errs := client.WatchWorkspaceAgentMetadata(watchCtx, agent.ID)
+			select {
+			case update := <-updates:
+				metadata[agent.ID] = update
+			case <-errs:
+			}
+			cancel()
+		}
+	}
+	return metadata
+}
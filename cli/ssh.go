@@ -31,6 +31,7 @@ import (
 var (
 	workspacePollInterval   = time.Minute
 	autostopNotifyCountdown = []time.Duration{30 * time.Minute}
+	dormancyNotifyCountdown = []time.Duration{time.Hour, 15 * time.Minute}
 )
 
 func ssh() *cobra.Command {
@@ -40,6 +41,9 @@ func ssh() *cobra.Command {
 		forwardAgent   bool
 		identityAgent  string
 		wsPollInterval time.Duration
+		noWait         bool
+		session        string
+		renewCert      bool
 	)
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
@@ -67,11 +71,19 @@ func ssh() *cobra.Command {
 				}
 			}
 
+			if session != "" && (stdio || forwardAgent) {
+				return xerrors.New("--session cannot be used with --stdio or --forward-agent")
+			}
+
 			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, args[0], shuffle)
 			if err != nil {
 				return err
 			}
 
+			if renewCert {
+				return sshRenewCertificate(ctx, createConfig(cmd), client, workspaceAgent.ID, args[0])
+			}
+
 			// OpenSSH passes stderr directly to the calling TTY.
 			// This is required in "stdio" mode so a connecting indicator can be displayed.
 			err = cliui.Agent(ctx, cmd.ErrOrStderr(), cliui.AgentOptions{
@@ -79,20 +91,28 @@ func ssh() *cobra.Command {
 				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
 					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
 				},
+				FetchLogs: client.WorkspaceAgentStartupLogsAfter,
+				Wait:      !noWait && !workspaceAgent.LoginBeforeReady,
 			})
 			if err != nil {
 				return xerrors.Errorf("await agent: %w", err)
 			}
 
+			stopPolling := tryPollWorkspaceAutostop(ctx, client, workspace)
+			defer stopPolling()
+			stopDormancyPolling := tryPollWorkspaceDormancy(ctx, client, workspace)
+			defer stopDormancyPolling()
+
+			if session != "" {
+				return sshSession(ctx, cmd, client, workspaceAgent.ID, session)
+			}
+
 			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, nil)
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
 
-			stopPolling := tryPollWorkspaceAutostop(ctx, client, workspace)
-			defer stopPolling()
-
 			if stdio {
 				rawSSH, err := conn.SSH()
 				if err != nil {
@@ -107,7 +127,15 @@ func ssh() *cobra.Command {
 				return nil
 			}
 
-			sshClient, err := conn.SSHClient()
+			// Agents only ask for a certificate when the deployment requires
+			// them, so don't request one otherwise.
+			sshClient, err := conn.SSHClientWithSignerFunc(func() (gossh.Signer, error) {
+				signer, err := client.WorkspaceAgentSSHSigner(ctx, workspaceAgent.ID)
+				if err != nil {
+					return nil, xerrors.Errorf("get ssh certificate: %w", err)
+				}
+				return signer, nil
+			})
 			if err != nil {
 				return err
 			}
@@ -212,6 +240,10 @@ func ssh() *cobra.Command {
 	cliflag.BoolVarP(cmd.Flags(), &forwardAgent, "forward-agent", "A", "CODER_SSH_FORWARD_AGENT", false, "Specifies whether to forward the SSH agent specified in $SSH_AUTH_SOCK")
 	cliflag.StringVarP(cmd.Flags(), &identityAgent, "identity-agent", "", "CODER_SSH_IDENTITY_AGENT", "", "Specifies which identity agent to use (overrides $SSH_AUTH_SOCK), forward agent must also be enabled")
 	cliflag.DurationVarP(cmd.Flags(), &wsPollInterval, "workspace-poll-interval", "", "CODER_WORKSPACE_POLL_INTERVAL", workspacePollInterval, "Specifies how often to poll for workspace automated shutdown.")
+	cliflag.StringVarP(cmd.Flags(), &session, "session", "", "CODER_SSH_SESSION", "", "Attach to the named session, starting it if it doesn't exist. Sessions survive disconnects: the connection is re-established automatically and missed output is replayed. A disconnected session is kept for 5 minutes.")
+	cmd.Flags().BoolVarP(&renewCert, "renew-certificate", "", false, "Renews the SSH certificate written by config-ssh for the host and exits, without connecting.")
+	_ = cmd.Flags().MarkHidden("renew-certificate")
+	cliflag.BoolVarP(cmd.Flags(), &noWait, "no-wait", "", "CODER_SSH_NO_WAIT", false, "Specifies whether to skip waiting for the startup script to finish, if the template requires it.")
 	return cmd
 }
 
@@ -343,3 +375,42 @@ func notifyCondition(ctx context.Context, client *codersdk.Client, workspaceID u
 		return deadline.Truncate(time.Minute), callback
 	}
 }
+
+// Attempt to poll workspace dormancy. As with autostop, a per-workspace
+// lockfile avoids duplicate notifications from multiple CLI instances.
+func tryPollWorkspaceDormancy(ctx context.Context, client *codersdk.Client, workspace codersdk.Workspace) (stop func()) {
+	lock := flock.New(filepath.Join(os.TempDir(), "coder-dormancy-notify-"+workspace.ID.String()))
+	condition := dormancyNotifyCondition(ctx, client, workspace.ID, workspace.TemplateID, lock)
+	return notify.Notify(condition, workspacePollInterval, dormancyNotifyCountdown...)
+}
+
+// Notify the user if the workspace is due to be marked dormant because it
+// has not been used for the inactivity TTL of its template.
+func dormancyNotifyCondition(ctx context.Context, client *codersdk.Client, workspaceID, templateID uuid.UUID, lock *flock.Flock) notify.Condition {
+	return func(now time.Time) (deadline time.Time, callback func()) {
+		locked, err := lock.TryLockContext(ctx, workspacePollInterval)
+		if err != nil || !locked {
+			return time.Time{}, nil
+		}
+
+		template, err := client.Template(ctx, templateID)
+		if err != nil || template.InactivityTTLMillis <= 0 {
+			return time.Time{}, nil
+		}
+
+		ws, err := client.Workspace(ctx, workspaceID)
+		if err != nil || ws.DormantAt != nil {
+			return time.Time{}, nil
+		}
+
+		deadline = ws.LastUsedAt.Add(time.Duration(template.InactivityTTLMillis) * time.Millisecond)
+		callback = func() {
+			title := fmt.Sprintf("Workspace %s inactive", ws.Name)
+			body := fmt.Sprintf(
+				"Your Coder workspace %s will be stopped and marked dormant in %.0f mins unless it is used", ws.Name, deadline.Sub(now).Minutes())
+			// notify user with a native system notification (best effort)
+			_ = beeep.Notify(title, body, "")
+		}
+		return deadline.Truncate(time.Minute), callback
+	}
+}
@@ -8,8 +8,14 @@ import (
 	"errors"
 	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -100,6 +106,57 @@ func TestSSH(t *testing.T) {
 		pty.WriteLine("exit")
 		<-cmdDone
 	})
+	t.Run("CertificateNotRequired", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspace, agentToken := setupWorkspaceForAgent(t)
+		// Act like a deployment that doesn't issue certificates, and make
+		// sure the CLI doesn't ask for one when the agent doesn't need it.
+		var certificateRequests atomic.Int64
+		proxy := httputil.NewSingleHostReverseProxy(client.URL)
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/ssh-certificate") {
+				certificateRequests.Add(1)
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			proxy.ServeHTTP(rw, r)
+		}))
+		defer srv.Close()
+		srvURL, err := url.Parse(srv.URL)
+		require.NoError(t, err)
+		proxyClient := codersdk.New(srvURL)
+		proxyClient.SessionToken = client.SessionToken
+
+		agentClient := codersdk.New(client.URL)
+		agentClient.SessionToken = agentToken
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent"),
+		})
+		defer func() {
+			_ = agentCloser.Close()
+		}()
+		coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+		cmd, root := clitest.New(t, "ssh", workspace.Name)
+		clitest.SetupConfig(t, proxyClient, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetErr(pty.Output())
+		cmd.SetOut(pty.Output())
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmdDone := tGo(t, func() {
+			err := cmd.ExecuteContext(ctx)
+			assert.NoError(t, err)
+		})
+		pty.WriteLine("exit")
+		<-cmdDone
+		require.Zero(t, certificateRequests.Load())
+	})
 	t.Run("Stdio", func(t *testing.T) {
 		t.Parallel()
 		client, workspace, agentToken := setupWorkspaceForAgent(t)
@@ -164,6 +221,63 @@ func TestSSH(t *testing.T) {
 
 		<-cmdDone
 	})
+	t.Run("Session", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("ConPTY appears to be inconsistent on Windows.")
+		}
+		t.Parallel()
+
+		client, workspace, agentToken := setupWorkspaceForAgent(t)
+		agentClient := codersdk.New(client.URL)
+		agentClient.SessionToken = agentToken
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent"),
+		})
+		defer func() {
+			_ = agentCloser.Close()
+		}()
+		coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// The first invocation starts the session and is then killed,
+		// e.g. because the laptop went to sleep.
+		cmd, root := clitest.New(t, "ssh", workspace.Name, "--session", "build")
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetErr(pty.Output())
+		cmd.SetOut(pty.Output())
+		firstCtx, firstCancel := context.WithCancel(ctx)
+		firstDone := tGo(t, func() {
+			err := cmd.ExecuteContext(firstCtx)
+			assert.ErrorIs(t, err, context.Canceled)
+		})
+		pty.WriteLine("export SESSION_VALUE=persisted")
+		pty.WriteLine("echo value=$SESSION_VALUE")
+		pty.ExpectMatch("value=persisted")
+		firstCancel()
+		<-firstDone
+
+		// The second invocation attaches to the same shell.
+		cmd, root = clitest.New(t, "ssh", workspace.Name, "--session", "build")
+		clitest.SetupConfig(t, client, root)
+		pty = ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetErr(pty.Output())
+		cmd.SetOut(pty.Output())
+		secondDone := tGo(t, func() {
+			err := cmd.ExecuteContext(ctx)
+			assert.NoError(t, err)
+		})
+		pty.WriteLine("echo value=$SESSION_VALUE")
+		pty.ExpectMatch("value=persisted")
+		// Exiting the shell ends the session instead of reconnecting.
+		pty.WriteLine("exit")
+		<-secondDone
+	})
 	t.Run("ForwardAgent", func(t *testing.T) {
 		if runtime.GOOS == "windows" {
 			t.Skip("Test not supported on windows")
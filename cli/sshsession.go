@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/retry"
+)
+
+const (
+	// sessionPingInterval is how often the agent is pinged to detect a
+	// dead connection. TCP over tailnet can take minutes to notice that
+	// the network went away, e.g. after a laptop wakes from sleep.
+	sessionPingInterval = 5 * time.Second
+	// sessionPingFailures is the number of consecutive failed pings after
+	// which the connection is considered dead.
+	sessionPingFailures = 3
+)
+
+// sshSession attaches the terminal to the agent's reconnecting PTY with the
+// given name. The PTY keeps running on the agent when the connection drops,
+// so the session redials the agent and replays any output that was missed
+// until the PTY's process exits or ctx is canceled.
+func sshSession(ctx context.Context, cmd *cobra.Command, client *codersdk.Client, agentID uuid.UUID, name string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		stdout = cmd.OutOrStdout()
+		stderr = cmd.ErrOrStderr()
+		width  = 80
+		height = 24
+	)
+	stdoutFile, validOut := stdout.(*os.File)
+	stdinFile, validIn := cmd.InOrStdin().(*os.File)
+	isTTY := validOut && validIn && isatty.IsTerminal(stdoutFile.Fd())
+	if isTTY {
+		state, err := term.MakeRaw(int(stdinFile.Fd()))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = term.Restore(int(stdinFile.Fd()), state)
+		}()
+		if w, h, err := term.GetSize(int(stdoutFile.Fd())); err == nil {
+			width, height = w, h
+		}
+	}
+
+	// Input is read for the lifetime of the command, independent of the
+	// current connection, so nothing typed while reconnecting is lost.
+	input := make(chan []byte)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := cmd.InOrStdin().Read(buf)
+			if n > 0 {
+				data := make([]byte, n)
+				copy(data, buf[:n])
+				select {
+				case input <- data:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	resize := make(chan [2]int, 1)
+	if isTTY {
+		windowChange := listenWindowSize(ctx)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-windowChange:
+				}
+				w, h, err := term.GetSize(int(stdoutFile.Fd()))
+				if err != nil {
+					continue
+				}
+				// Only the latest size matters.
+				select {
+				case <-resize:
+				default:
+				}
+				resize <- [2]int{w, h}
+			}
+		}()
+	}
+
+	s := &sessionAttacher{
+		stdout: stdout,
+		input:  input,
+		resize: resize,
+	}
+	attached := false
+	for {
+		conn, ptyConn, attach, err := dialSession(ctx, client, agentID, codersdk.ReconnectingPTYInit{
+			ID:     name,
+			Height: uint16(height),
+			Width:  uint16(width),
+			Offset: s.offset,
+			// Only the first attach may start a new PTY. Afterwards a
+			// missing PTY means its process exited.
+			AttachOnly: attached,
+		})
+		if err != nil {
+			return err
+		}
+		if attach.Exited {
+			_ = conn.Close()
+			return nil
+		}
+		if attached {
+			_, _ = fmt.Fprintf(stderr, "\r\n%s\r\n", cliui.Styles.Keyword.Render(fmt.Sprintf("Reconnected to session %q.", name)))
+			if attach.Offset > s.offset {
+				_, _ = fmt.Fprintf(stderr, "%s\r\n", cliui.Styles.Warn.Render(fmt.Sprintf("%d bytes of output were lost while disconnected.", attach.Offset-s.offset)))
+			}
+		}
+		attached = true
+		s.offset = attach.Offset
+
+		err = s.run(ctx, conn, ptyConn)
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if xerrors.Is(err, errSessionConnectionLost) {
+			_, _ = fmt.Fprintf(stderr, "\r\n%s\r\n", cliui.Styles.Warn.Render("Connection to the workspace was lost, reconnecting..."))
+		}
+	}
+}
+
+// dialSession dials the agent and attaches to the reconnecting PTY,
+// retrying until it succeeds or ctx is canceled.
+func dialSession(ctx context.Context, client *codersdk.Client, agentID uuid.UUID, init codersdk.ReconnectingPTYInit) (*codersdk.AgentConn, io.ReadWriteCloser, codersdk.ReconnectingPTYAttach, error) {
+	for r := retry.New(250*time.Millisecond, 10*time.Second); r.Wait(ctx); {
+		conn, err := client.DialWorkspaceAgent(ctx, agentID, nil)
+		if err != nil {
+			continue
+		}
+		// Dialing over tailnet blocks until the agent is reachable, which
+		// may be never if the connection went bad again.
+		attachCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		ptyConn, attach, err := conn.AttachReconnectingPTY(attachCtx, init)
+		cancel()
+		if err != nil {
+			_ = conn.Close()
+			continue
+		}
+		return conn, ptyConn, attach, nil
+	}
+	return nil, nil, codersdk.ReconnectingPTYAttach{}, ctx.Err()
+}
+
+var errSessionConnectionLost = xerrors.New("connection lost")
+
+// sessionAttacher pipes the terminal to one connection of a reconnecting
+// PTY at a time and tracks how much output has been received across
+// connections.
+type sessionAttacher struct {
+	stdout io.Writer
+	input  <-chan []byte
+	resize <-chan [2]int
+
+	// offset is the number of bytes of PTY output written to stdout.
+	offset int64
+	// pending is input that couldn't be sent before the connection
+	// dropped. It's sent first on the next connection.
+	pending []byte
+}
+
+// run returns when the connection ends. Output is copied to stdout until
+// then.
+func (s *sessionAttacher) run(ctx context.Context, conn *codersdk.AgentConn, ptyConn io.ReadWriteCloser) error {
+	readErr := make(chan error, 1)
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := ptyConn.Read(buf)
+			if n > 0 {
+				_, _ = s.stdout.Write(buf[:n])
+				s.offset += int64(n)
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+	defer func() {
+		_ = ptyConn.Close()
+		// Wait for the reader so offset is safe to read.
+		<-readDone
+	}()
+
+	encoder := json.NewEncoder(ptyConn)
+	if len(s.pending) > 0 {
+		err := encoder.Encode(codersdk.ReconnectingPTYRequest{Data: string(s.pending)})
+		if err != nil {
+			return err
+		}
+		s.pending = nil
+	}
+
+	ping := time.NewTicker(sessionPingInterval)
+	defer ping.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case data := <-s.input:
+			err := encoder.Encode(codersdk.ReconnectingPTYRequest{Data: string(data)})
+			if err != nil {
+				s.pending = data
+				return err
+			}
+		case size := <-s.resize:
+			err := encoder.Encode(codersdk.ReconnectingPTYRequest{
+				Width:  uint16(size[0]),
+				Height: uint16(size[1]),
+			})
+			if err != nil {
+				return err
+			}
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, sessionPingInterval)
+			_, perr := conn.Ping(pingCtx)
+			cancel()
+			if perr == nil {
+				failures = 0
+				continue
+			}
+			failures++
+			if failures >= sessionPingFailures {
+				return errSessionConnectionLost
+			}
+		}
+	}
+}
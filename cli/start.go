@@ -5,12 +5,14 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/codersdk"
 )
 
 func start() *cobra.Command {
+	var activate bool
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
 		Use:         "start <workspace>",
@@ -25,6 +27,18 @@ func start() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if workspace.DormantAt != nil {
+				if !activate {
+					return xerrors.Errorf("workspace %q is dormant because it has not been used in a while, pass --activate to start it", workspace.Name)
+				}
+				err = client.UpdateWorkspaceDormancy(cmd.Context(), workspace.ID, codersdk.UpdateWorkspaceDormancy{
+					Dormant: false,
+				})
+				if err != nil {
+					return xerrors.Errorf("activate workspace: %w", err)
+				}
+			}
+
 			before := time.Now()
 			build, err := client.CreateWorkspaceBuild(cmd.Context(), workspace.ID, codersdk.CreateWorkspaceBuildRequest{
 				Transition: codersdk.WorkspaceTransitionStart,
@@ -42,6 +56,7 @@ func start() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&activate, "activate", false, "Activate the workspace if it is dormant.")
 	cliui.AllowSkipPrompt(cmd)
 	return cmd
 }
@@ -256,6 +256,17 @@ func TestTemplateCreate(t *testing.T) {
 
 		require.EqualError(t, <-execDone, "Template name must be less than 32 characters")
 	})
+
+	t.Run("InvalidProvisionerTag", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		coderdtest.CreateFirstUser(t, client)
+		cmd, root := clitest.New(t, "templates", "create", "my-template", "--provisioner-tag", "region", "--test.provisioner", string(database.ProvisionerTypeEcho))
+		clitest.SetupConfig(t, client, root)
+
+		err := cmd.Execute()
+		require.ErrorContains(t, err, "invalid tag format")
+	})
 }
 
 func createTestParseResponse() []*proto.Parse_Response {
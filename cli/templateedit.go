@@ -18,6 +18,11 @@ func templateEdit() *cobra.Command {
 		icon                 string
 		maxTTL               time.Duration
 		minAutostartInterval time.Duration
+		autostopRequirement  int64
+		inactivityTTL        time.Duration
+		dormantDeleteTTL     time.Duration
+		portForwarding       []string
+		recordSessions       bool
 	)
 
 	cmd := &cobra.Command{
@@ -38,6 +43,24 @@ func templateEdit() *cobra.Command {
 				return xerrors.Errorf("get workspace template: %w", err)
 			}
 
+			// These settings are reset when omitted, so keep the current
+			// values unless the flags were given.
+			if !cmd.Flags().Changed("autostop-requirement-days") {
+				autostopRequirement = template.AutostopRequirementDays
+			}
+			if !cmd.Flags().Changed("inactivity-ttl") {
+				inactivityTTL = time.Duration(template.InactivityTTLMillis) * time.Millisecond
+			}
+			if !cmd.Flags().Changed("dormant-delete-ttl") {
+				dormantDeleteTTL = time.Duration(template.DormantDeleteTTLMillis) * time.Millisecond
+			}
+			if !cmd.Flags().Changed("port-forwarding-allowlist") {
+				portForwarding = template.PortForwardingAllowlist
+			}
+			if !cmd.Flags().Changed("record-sessions") {
+				recordSessions = template.RecordSessions
+			}
+
 			// NOTE: coderd will ignore empty fields.
 			req := codersdk.UpdateTemplateMeta{
 				Name:                       name,
@@ -45,6 +68,11 @@ func templateEdit() *cobra.Command {
 				Icon:                       icon,
 				MaxTTLMillis:               maxTTL.Milliseconds(),
 				MinAutostartIntervalMillis: minAutostartInterval.Milliseconds(),
+				AutostopRequirementDays:    autostopRequirement,
+				InactivityTTLMillis:        inactivityTTL.Milliseconds(),
+				DormantDeleteTTLMillis:     dormantDeleteTTL.Milliseconds(),
+				PortForwardingAllowlist:    portForwarding,
+				RecordSessions:             recordSessions,
 			}
 
 			_, err = client.UpdateTemplateMeta(cmd.Context(), template.ID, req)
@@ -61,6 +89,11 @@ func templateEdit() *cobra.Command {
 	cmd.Flags().StringVarP(&icon, "icon", "", "", "Edit the template icon path")
 	cmd.Flags().DurationVarP(&maxTTL, "max-ttl", "", 0, "Edit the template maximum time before shutdown - workspaces created from this template cannot stay running longer than this.")
 	cmd.Flags().DurationVarP(&minAutostartInterval, "min-autostart-interval", "", 0, "Edit the template minimum autostart interval - workspaces created from this template must wait at least this long between autostarts.")
+	cmd.Flags().Int64VarP(&autostopRequirement, "autostop-requirement-days", "", 0, "Edit the template autostop requirement - workspaces created from this template must be stopped during their owner's quiet hours at least once every this many days. Set to 0 to disable.")
+	cmd.Flags().DurationVarP(&inactivityTTL, "inactivity-ttl", "", 0, "Edit the template inactivity TTL - workspaces created from this template that are not used for this long are stopped and marked dormant. Set to 0 to disable.")
+	cmd.Flags().DurationVarP(&dormantDeleteTTL, "dormant-delete-ttl", "", 0, "Edit the template dormant delete TTL - workspaces created from this template that are dormant for this long are deleted. Set to 0 to disable.")
+	cmd.Flags().StringSliceVarP(&portForwarding, "port-forwarding-allowlist", "", nil, `Edit the ports and port ranges, e.g. "5432,8000-8100", that may be forwarded to and from workspaces created from this template, with SSH, "coder port-forward" or apps. Set to "" to allow all ports.`)
+	cmd.Flags().BoolVarP(&recordSessions, "record-sessions", "", false, "Edit whether terminal sessions on workspaces created from this template are recorded for audit. Recordings are played with \"coder sessions play\".")
 	cliui.AllowSkipPrompt(cmd)
 
 	return cmd
@@ -2,6 +2,8 @@ package cli_test
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,6 +38,10 @@ func TestTemplateEdit(t *testing.T) {
 		icon := "/icons/new-icon.png"
 		maxTTL := 12 * time.Hour
 		minAutostartInterval := time.Minute
+		autostopRequirementDays := int64(7)
+		inactivityTTL := 72 * time.Hour
+		dormantDeleteTTL := 24 * time.Hour
+		portForwardingAllowlist := []string{"5432", "8000-8100"}
 		cmdArgs := []string{
 			"templates",
 			"edit",
@@ -45,6 +51,11 @@ func TestTemplateEdit(t *testing.T) {
 			"--icon", icon,
 			"--max-ttl", maxTTL.String(),
 			"--min-autostart-interval", minAutostartInterval.String(),
+			"--autostop-requirement-days", strconv.FormatInt(autostopRequirementDays, 10),
+			"--inactivity-ttl", inactivityTTL.String(),
+			"--dormant-delete-ttl", dormantDeleteTTL.String(),
+			"--port-forwarding-allowlist", strings.Join(portForwardingAllowlist, ","),
+			"--record-sessions",
 		}
 		cmd, root := clitest.New(t, cmdArgs...)
 		clitest.SetupConfig(t, client, root)
@@ -61,6 +72,11 @@ func TestTemplateEdit(t *testing.T) {
 		assert.Equal(t, icon, updated.Icon)
 		assert.Equal(t, maxTTL.Milliseconds(), updated.MaxTTLMillis)
 		assert.Equal(t, minAutostartInterval.Milliseconds(), updated.MinAutostartIntervalMillis)
+		assert.Equal(t, autostopRequirementDays, updated.AutostopRequirementDays)
+		assert.Equal(t, inactivityTTL.Milliseconds(), updated.InactivityTTLMillis)
+		assert.Equal(t, dormantDeleteTTL.Milliseconds(), updated.DormantDeleteTTLMillis)
+		assert.Equal(t, portForwardingAllowlist, updated.PortForwardingAllowlist)
+		assert.True(t, updated.RecordSessions)
 	})
 
 	t.Run("NotModified", func(t *testing.T) {
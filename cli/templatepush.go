@@ -18,11 +18,12 @@ import (
 
 func templatePush() *cobra.Command {
 	var (
-		directory     string
-		versionName   string
-		provisioner   string
-		parameterFile string
-		alwaysPrompt  bool
+		directory       string
+		versionName     string
+		provisioner     string
+		parameterFile   string
+		alwaysPrompt    bool
+		provisionerTags []string
 	)
 
 	cmd := &cobra.Command{
@@ -49,6 +50,20 @@ func templatePush() *cobra.Command {
 				return err
 			}
 
+			tags, err := ParseProvisionerTags(provisionerTags)
+			if err != nil {
+				return err
+			}
+			// Keep the tags of the active version unless new ones are
+			// provided, so templates stay on the same daemons.
+			if len(tags) == 0 {
+				activeVersion, err := client.TemplateVersion(cmd.Context(), template.ActiveVersionID)
+				if err != nil {
+					return xerrors.Errorf("get active template version: %w", err)
+				}
+				tags = activeVersion.Job.Tags
+			}
+
 			// Confirm upload of the directory.
 			prettyDir := prettyDirectoryPath(directory)
 			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
@@ -84,6 +99,7 @@ func templatePush() *cobra.Command {
 				ParameterFile:   parameterFile,
 				Template:        &template,
 				ReuseParameters: !alwaysPrompt,
+				ProvisionerTags: tags,
 			})
 			if err != nil {
 				return err
@@ -111,6 +127,7 @@ func templatePush() *cobra.Command {
 	cmd.Flags().StringVarP(&parameterFile, "parameter-file", "", "", "Specify a file path with parameter values.")
 	cmd.Flags().StringVarP(&versionName, "name", "", "", "Specify a name for the new template version. It will be automatically generated if not provided.")
 	cmd.Flags().BoolVar(&alwaysPrompt, "always-prompt", false, "Always prompt all parameters. Does not pull parameter values from active template version")
+	cmd.Flags().StringArrayVarP(&provisionerTags, "provisioner-tag", "", []string{}, "Specify a set of tags to target provisioner daemons, in the format key=value. Defaults to the tags of the active template version.")
 	cliui.AllowSkipPrompt(cmd)
 	// This is for testing!
 	err := cmd.Flags().MarkHidden("test.provisioner")
@@ -23,6 +23,14 @@ func tokens() *cobra.Command {
 				Description: "Create a token for automation",
 				Command:     "coder tokens create",
 			},
+			example{
+				Description: "Create a token that can only push versions of a single template",
+				Command:     "coder tokens create --scope template:push:<template id>",
+			},
+			example{
+				Description: "Create a token for a service account that expires in 30 days",
+				Command:     "coder tokens create --user ci-bot --lifetime 720h",
+			},
 			example{
 				Description: "List your tokens",
 				Command:     "coder tokens ls",
@@ -46,6 +54,11 @@ func tokens() *cobra.Command {
 }
 
 func createToken() *cobra.Command {
+	var (
+		scopes   []string
+		lifetime time.Duration
+		user     string
+	)
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a tokens",
@@ -55,7 +68,10 @@ func createToken() *cobra.Command {
 				return xerrors.Errorf("create codersdk client: %w", err)
 			}
 
-			res, err := client.CreateToken(cmd.Context(), codersdk.Me, codersdk.CreateTokenRequest{})
+			res, err := client.CreateToken(cmd.Context(), user, codersdk.CreateTokenRequest{
+				Scopes:   scopes,
+				Lifetime: lifetime,
+			})
 			if err != nil {
 				return xerrors.Errorf("create tokens: %w", err)
 			}
@@ -73,12 +89,17 @@ func createToken() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().StringArrayVar(&scopes, "scope", nil, "Restrict the token to a scope, e.g. \"workspace:read\", \"workspace:start-stop\", \"template:read\", \"template:push\" or \"audit:read\". "+
+		"Append \":<id>\" to pin the scope to a single workspace or template. Repeat to combine scopes. By default the token has all permissions of your user.")
+	cmd.Flags().DurationVar(&lifetime, "lifetime", 0, "How long the token is valid for, e.g. \"720h\". Required for service accounts, other tokens don't expire by default.")
+	cmd.Flags().StringVar(&user, "user", codersdk.Me, "Create the token for another user, such as a service account. Requires permission to manage the user's tokens.")
 
 	return cmd
 }
 
 type tokenRow struct {
 	ID        string    `table:"ID"`
+	Scopes    string    `table:"Scopes"`
 	LastUsed  time.Time `table:"Last Used"`
 	ExpiresAt time.Time `table:"Expires At"`
 	CreatedAt time.Time `table:"Created At"`
@@ -108,8 +129,13 @@ func listTokens() *cobra.Command {
 
 			var rows []tokenRow
 			for _, key := range keys {
+				scopes := string(key.Scope)
+				if len(key.Scopes) > 0 {
+					scopes = strings.Join(key.Scopes, ", ")
+				}
 				rows = append(rows, tokenRow{
 					ID:        key.ID,
+					Scopes:    scopes,
 					LastUsed:  key.LastUsed,
 					ExpiresAt: key.ExpiresAt,
 					CreatedAt: key.CreatedAt,
@@ -2,6 +2,7 @@ package cli_test
 
 import (
 	"bytes"
+	"context"
 	"regexp"
 	"testing"
 
@@ -9,6 +10,9 @@ import (
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
 )
 
 func TestTokens(t *testing.T) {
@@ -64,3 +68,46 @@ func TestTokens(t *testing.T) {
 	require.NotEmpty(t, res)
 	require.Contains(t, res, "deleted")
 }
+
+func TestTokensScope(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+	_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+	cmd, root := clitest.New(t, "tokens", "create", "--scope", "template:push:"+template.ID.String())
+	clitest.SetupConfig(t, client, root)
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err := cmd.Execute()
+	require.NoError(t, err)
+	key := regexp.MustCompile("[a-zA-Z0-9]{10}-[a-zA-Z0-9]{22}").FindString(buf.String())
+	require.NotEmpty(t, key)
+	scoped := codersdk.New(client.URL)
+	scoped.SessionToken = key
+
+	// The token can push new versions of the template...
+	source := clitest.CreateTemplateVersionSource(t, &echo.Responses{
+		Parse:     echo.ParseComplete,
+		Provision: echo.ProvisionComplete,
+	})
+	cmd, root = clitest.New(t, "templates", "push", template.Name, "-y", "--directory", source, "--test.provisioner", string(database.ProvisionerTypeEcho))
+	clitest.SetupConfig(t, scoped, root)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	latest, _ := latestTemplateVersion(t, client, template.ID)
+	require.NotEqual(t, version.ID, latest.ID)
+
+	// ...but nothing else.
+	err = scoped.DeleteTemplate(context.Background(), template.ID)
+	require.Error(t, err)
+	_, err = scoped.CreateToken(context.Background(), codersdk.Me, codersdk.CreateTokenRequest{})
+	require.Error(t, err)
+
+	cmd, root = clitest.New(t, "tokens", "create", "--scope", "template:delete")
+	clitest.SetupConfig(t, client, root)
+	err = cmd.Execute()
+	require.ErrorContains(t, err, "no scope named")
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+func tunnel() *cobra.Command {
+	var listenAddress string
+	cmd := &cobra.Command{
+		Use:   "tunnel <workspace> <app>",
+		Short: "Expose a TCP app of a workspace on a local port",
+		Long: "Expose a TCP app of a workspace on a local port. TCP apps are defined in the template " +
+			"with a tcp:// URL and are reached through the deployment's subdomain app proxy, so " +
+			"SSH access to the workspace isn't required.",
+		Args: cobra.ExactArgs(2),
+		Example: formatExamples(
+			example{
+				Description: "Connect to the \"postgres\" app of a workspace on localhost:5432",
+				Command:     "coder tunnel <workspace> postgres --listen 127.0.0.1:5432",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), interruptSignals...)
+			defer stop()
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			appHost, err := client.GetAppHost(ctx)
+			if err != nil {
+				return xerrors.Errorf("get app host: %w", err)
+			}
+			if appHost.Host == "" {
+				return xerrors.New("this deployment doesn't have a wildcard access URL, which is required for TCP apps")
+			}
+
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, args[0], false)
+			if err != nil {
+				return err
+			}
+			var app *codersdk.WorkspaceApp
+			for i := range workspaceAgent.Apps {
+				if workspaceAgent.Apps[i].Slug == args[1] {
+					app = &workspaceAgent.Apps[i]
+					break
+				}
+			}
+			if app == nil {
+				return xerrors.Errorf("agent %q has no app %q", workspaceAgent.Name, args[1])
+			}
+			if !app.TCP || !app.Subdomain {
+				return xerrors.Errorf("app %q must have a tcp:// URL and be served on a subdomain to be tunneled", app.Slug)
+			}
+			subdomain := httpapi.ApplicationURL{
+				AppSlug:       app.Slug,
+				AgentName:     workspaceAgent.Name,
+				WorkspaceName: workspace.Name,
+				Username:      workspace.OwnerName,
+			}.String()
+			host := strings.Replace(appHost.Host, "*", subdomain, 1)
+
+			listener, err := net.Listen("tcp", listenAddress)
+			if err != nil {
+				return xerrors.Errorf("listen: %w", err)
+			}
+			defer listener.Close()
+			go func() {
+				<-ctx.Done()
+				_ = listener.Close()
+			}()
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Forwarding %s to app %s of %s\n", listener.Addr(), cliui.Styles.Keyword.Render(app.Slug), workspace.Name)
+
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			for {
+				local, err := listener.Accept()
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return xerrors.Errorf("accept: %w", err)
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					remote, err := client.DialWorkspaceAppTCP(ctx, host)
+					if err != nil {
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Failed to connect to app: %s\n", err)
+						_ = local.Close()
+						return
+					}
+					agent.Bicopy(ctx, local, remote)
+				}()
+			}
+		},
+	}
+	cliflag.StringVarP(cmd.Flags(), &listenAddress, "listen", "l", "CODER_TUNNEL_LISTEN", "127.0.0.1:0", "The local address to listen on. By default a random port is picked.")
+	return cmd
+}
@@ -14,6 +14,7 @@ func update() *cobra.Command {
 	var (
 		parameterFile string
 		alwaysPrompt  bool
+		dryRun        bool
 	)
 
 	cmd := &cobra.Command{
@@ -46,22 +47,33 @@ func update() *cobra.Command {
 					return nil
 				}
 			}
+			existingRichParams, err := client.WorkspaceBuildParameters(cmd.Context(), workspace.LatestBuild.ID)
+			if err != nil {
+				return err
+			}
 
-			parameters, err := prepWorkspaceBuild(cmd, client, prepWorkspaceBuildArgs{
-				Template:         template,
-				ExistingParams:   existingParams,
-				ParameterFile:    parameterFile,
-				NewWorkspaceName: workspace.Name,
+			buildParams, err := prepWorkspaceBuild(cmd, client, prepWorkspaceBuildArgs{
+				Template:           template,
+				ExistingParams:     existingParams,
+				ExistingRichParams: existingRichParams,
+				ParameterFile:      parameterFile,
+				NewWorkspaceName:   workspace.Name,
+				WorkspaceID:        workspace.ID,
+				AlwaysPrompt:       alwaysPrompt,
 			})
 			if err != nil {
 				return nil
 			}
+			if dryRun {
+				return nil
+			}
 
 			before := time.Now()
 			build, err := client.CreateWorkspaceBuild(cmd.Context(), workspace.ID, codersdk.CreateWorkspaceBuildRequest{
-				TemplateVersionID: template.ActiveVersionID,
-				Transition:        workspace.LatestBuild.Transition,
-				ParameterValues:   parameters,
+				TemplateVersionID:   template.ActiveVersionID,
+				Transition:          workspace.LatestBuild.Transition,
+				ParameterValues:     buildParams.parameters,
+				RichParameterValues: buildParams.richParameters,
 			})
 			if err != nil {
 				return err
@@ -82,6 +94,7 @@ func update() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the resources and parameters that would change without updating the workspace")
 	cmd.Flags().BoolVar(&alwaysPrompt, "always-prompt", false, "Always prompt all parameters. Does not pull parameter values from existing workspace")
 	cliflag.StringVarP(cmd.Flags(), &parameterFile, "parameter-file", "", "CODER_PARAMETER_FILE", "", "Specify a file path with parameter values.")
 	return cmd
@@ -3,6 +3,7 @@ package cli_test
 import (
 	"context"
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,7 +13,9 @@ import (
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
 	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
 )
 
 func TestUpdate(t *testing.T) {
@@ -74,6 +77,53 @@ func TestUpdate(t *testing.T) {
 		require.Equal(t, version2.ID.String(), ws.LatestBuild.TemplateVersionID.String())
 	})
 
+	t.Run("DryRun", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version1 := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version1.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version1.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		version2 := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse: echo.ParseComplete,
+			Provision: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{Type: "docker_volume", Name: "home"}},
+					},
+				},
+			}},
+		}, template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, version2.ID)
+		err := client.UpdateActiveTemplateVersion(context.Background(), template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: version2.ID,
+		})
+		require.NoError(t, err)
+
+		cmd, root := clitest.New(t, "update", workspace.Name, "--dry-run")
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetOut(pty.Output())
+		doneChan := make(chan struct{})
+		go func() {
+			defer close(doneChan)
+			err := cmd.Execute()
+			assert.NoError(t, err)
+		}()
+		pty.ExpectMatch("Update Preview")
+		pty.ExpectMatch("docker_volume.home")
+		<-doneChan
+
+		workspace, err = client.Workspace(context.Background(), workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, version1.ID, workspace.LatestBuild.TemplateVersionID)
+	})
+
 	t.Run("WithParameter", func(t *testing.T) {
 		t.Parallel()
 
@@ -139,3 +189,91 @@ func TestUpdate(t *testing.T) {
 		<-doneChan
 	})
 }
+
+func TestUpdateWithRichParameters(t *testing.T) {
+	t.Parallel()
+
+	const (
+		firstParameterName        = "first_parameter"
+		firstParameterDescription = "This is first parameter"
+		firstParameterValue       = "1"
+
+		secondParameterName        = "second_parameter"
+		secondParameterDescription = "This is second parameter"
+		secondParameterValue       = "2"
+	)
+
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version1 := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: []*proto.Parse_Response{{
+			Type: &proto.Parse_Response_Complete{
+				Complete: &proto.Parse_Complete{
+					RichParameters: []*proto.RichParameter{
+						{Name: firstParameterName, Description: firstParameterDescription, Mutable: true},
+					},
+				},
+			},
+		}},
+		Provision:       echo.ProvisionComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version1.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version1.ID)
+
+	tempDir := t.TempDir()
+	removeTmpDirUntilSuccessAfterTest(t, tempDir)
+	parameterFile, _ := os.CreateTemp(tempDir, "testParameterFile*.yaml")
+	_, _ = parameterFile.WriteString(firstParameterName + ": " + firstParameterValue)
+	cmd, root := clitest.New(t, "create", "my-workspace", "--template", template.Name, "--parameter-file", parameterFile.Name(), "-y")
+	clitest.SetupConfig(t, client, root)
+	err := cmd.Execute()
+	require.NoError(t, err)
+
+	version2 := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: []*proto.Parse_Response{{
+			Type: &proto.Parse_Response_Complete{
+				Complete: &proto.Parse_Complete{
+					RichParameters: []*proto.RichParameter{
+						{Name: firstParameterName, Description: firstParameterDescription, Mutable: true},
+						{Name: secondParameterName, Description: secondParameterDescription, Mutable: true},
+					},
+				},
+			},
+		}},
+		Provision:       echo.ProvisionComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+	}, template.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version2.ID)
+	err = client.UpdateActiveTemplateVersion(context.Background(), template.ID, codersdk.UpdateActiveTemplateVersion{
+		ID: version2.ID,
+	})
+	require.NoError(t, err)
+
+	// Only the parameter added by the new version should be prompted.
+	cmd, root = clitest.New(t, "update", "my-workspace")
+	clitest.SetupConfig(t, client, root)
+	pty := ptytest.New(t)
+	cmd.SetIn(pty.Input())
+	cmd.SetOut(pty.Output())
+	doneChan := make(chan struct{})
+	go func() {
+		defer close(doneChan)
+		err := cmd.Execute()
+		assert.NoError(t, err)
+	}()
+	pty.ExpectMatch(secondParameterDescription)
+	pty.WriteLine(secondParameterValue)
+	<-doneChan
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	workspace, err := client.WorkspaceByOwnerAndName(ctx, codersdk.Me, "my-workspace", codersdk.WorkspaceOptions{})
+	require.NoError(t, err)
+	buildParameters, err := client.WorkspaceBuildParameters(ctx, workspace.LatestBuild.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []codersdk.WorkspaceBuildParameter{
+		{Name: firstParameterName, Value: firstParameterValue},
+		{Name: secondParameterName, Value: secondParameterValue},
+	}, buildParameters)
+}
@@ -14,9 +14,11 @@ import (
 
 func userCreate() *cobra.Command {
 	var (
-		email    string
-		username string
-		password string
+		email          string
+		username       string
+		password       string
+		serviceAccount bool
+		contact        string
 	)
 	cmd := &cobra.Command{
 		Use: "create",
@@ -52,7 +54,19 @@ func userCreate() *cobra.Command {
 					return err
 				}
 			}
-			if password == "" {
+			if serviceAccount {
+				if password != "" {
+					return xerrors.New("Service accounts cannot have a password, they authenticate with tokens.")
+				}
+				if contact == "" {
+					contact, err = cliui.Prompt(cmd, cliui.PromptOptions{
+						Text: "Contact (the person or team responsible for the service account):",
+					})
+					if err != nil {
+						return err
+					}
+				}
+			} else if password == "" {
 				password, err = cryptorand.StringCharset(cryptorand.Human, 12)
 				if err != nil {
 					return err
@@ -64,10 +78,19 @@ func userCreate() *cobra.Command {
 				Username:       username,
 				Password:       password,
 				OrganizationID: organization.ID,
+				ServiceAccount: serviceAccount,
+				Contact:        contact,
 			})
 			if err != nil {
 				return err
 			}
+			if serviceAccount {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), `A new service account has been created!
+It cannot log in. Create an expiring token for your automation with:
+
+`+cliui.Styles.Code.Render("coder tokens create --user "+username+" --lifetime 720h"))
+				return nil
+			}
 			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), `A new user has been created!
 Share the instructions below to get them started.
 `+cliui.Styles.Placeholder.Render("—————————————————————————————————————————————————")+`
@@ -86,5 +109,7 @@ Create a workspace  `+cliui.Styles.Code.Render("coder create")+`!`)
 	cmd.Flags().StringVarP(&email, "email", "e", "", "Specifies an email address for the new user.")
 	cmd.Flags().StringVarP(&username, "username", "u", "", "Specifies a username for the new user.")
 	cmd.Flags().StringVarP(&password, "password", "p", "", "Specifies a password for the new user.")
+	cmd.Flags().BoolVar(&serviceAccount, "service-account", false, "Create a service account for automation. Service accounts cannot log in, they only authenticate with expiring tokens and don't count as active users.")
+	cmd.Flags().StringVar(&contact, "contact", "", "The person or team responsible for the service account.")
 	return cmd
 }
@@ -1,9 +1,11 @@
 package cli_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
@@ -39,4 +41,31 @@ func TestUserCreate(t *testing.T) {
 		}
 		<-doneChan
 	})
+
+	t.Run("ServiceAccount", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		coderdtest.CreateFirstUser(t, client)
+		cmd, root := clitest.New(t, "users", "create", "--service-account",
+			"--username", "ci-bot", "--email", "ci-bot@coder.com", "--contact", "platform-team@coder.com")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.NoError(t, err)
+
+		user, err := client.User(context.Background(), "ci-bot")
+		require.NoError(t, err)
+		require.True(t, user.ServiceAccount)
+		require.Equal(t, "platform-team@coder.com", user.Contact)
+
+		// Tokens of service accounts must expire.
+		cmd, root = clitest.New(t, "tokens", "create", "--user", "ci-bot")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.ErrorContains(t, err, "must expire")
+
+		cmd, root = clitest.New(t, "tokens", "create", "--user", "ci-bot", "--lifetime", "1h")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.NoError(t, err)
+	})
 }
@@ -59,7 +59,7 @@ func userList() *cobra.Command {
 	}
 
 	cmd.Flags().StringArrayVarP(&columns, "column", "c", []string{"username", "email", "created_at", "status"},
-		"Specify a column to filter in the table. Available columns are: id, username, email, created_at, status.")
+		"Specify a column to filter in the table. Available columns are: id, username, email, created_at, status, service_account, contact.")
 	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format. Available formats are: table, json.")
 	return cmd
 }
@@ -53,12 +53,20 @@ func activityBumpWorkspace(log slog.Logger, db database.Store, workspace databas
 		}
 
 		newDeadline := database.Now().Add(bumpAmount)
+		// Activity can never push the deadline past the autostop requirement.
+		if !build.MaxDeadline.IsZero() && newDeadline.After(build.MaxDeadline) {
+			newDeadline = build.MaxDeadline
+		}
+		if !newDeadline.After(build.Deadline) {
+			return nil
+		}
 
 		if err := s.UpdateWorkspaceBuildByID(ctx, database.UpdateWorkspaceBuildByIDParams{
 			ID:               build.ID,
 			UpdatedAt:        database.Now(),
 			ProvisionerState: build.ProvisionerState,
 			Deadline:         newDeadline,
+			MaxDeadline:      build.MaxDeadline,
 		}); err != nil {
 			return xerrors.Errorf("update workspace build: %w", err)
 		}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -43,14 +44,65 @@ func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
 	if scope != "" {
 		scope = database.APIKeyScope(createToken.Scope)
 	}
+	if len(createToken.Scopes) > 0 {
+		if scope != "" && scope != database.APIKeyScopeAll {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Fine-grained scopes cannot be combined with a scope other than \"all\".",
+			})
+			return
+		}
+		_, err := rbac.ExpandScope(rbac.ScopeList(createToken.Scopes))
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid scopes.",
+				Detail:  err.Error(),
+				Validations: []codersdk.ValidationError{{
+					Field:  "scopes",
+					Detail: fmt.Sprintf("%s. Valid scopes are %s, optionally pinned to a resource by appending \":<id>\".", err.Error(), strings.Join(rbac.FineGrainedScopes(), ", ")),
+				}},
+			})
+			return
+		}
+	}
 
 	// tokens last 100 years
 	lifeTime := time.Hour * 876000
+	if createToken.Lifetime < 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Lifetime must be positive.",
+		})
+		return
+	}
+	if createToken.Lifetime > 0 {
+		lifeTime = createToken.Lifetime
+	}
+	if user.ServiceAccount && createToken.Lifetime == 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Tokens of service accounts must expire.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "lifetime",
+				Detail: "A lifetime is required for tokens of service accounts.",
+			}},
+		})
+		return
+	}
+	if user.ServiceAccount && lifeTime > api.MaxServiceTokenLifetime {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Lifetime is too long for a token of a service account.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "lifetime",
+				Detail: fmt.Sprintf("Tokens of service accounts can't last longer than %s.", api.MaxServiceTokenLifetime),
+			}},
+		})
+		return
+	}
+
 	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
 		UserID:          user.ID,
 		LoginType:       database.LoginTypeToken,
 		ExpiresAt:       database.Now().Add(lifeTime),
 		Scope:           scope,
+		Scopes:          createToken.Scopes,
 		LifetimeSeconds: int64(lifeTime.Seconds()),
 	})
 	if err != nil {
@@ -75,6 +127,13 @@ func (api *API) postAPIKey(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.ServiceAccount {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Service accounts can only authenticate with tokens.",
+		})
+		return
+	}
+
 	lifeTime := time.Hour * 24 * 7
 	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
 		UserID:     user.ID,
@@ -212,6 +271,7 @@ type createAPIKeyParams struct {
 	ExpiresAt       time.Time
 	LifetimeSeconds int64
 	Scope           database.APIKeyScope
+	Scopes          []string
 }
 
 func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*http.Cookie, error) {
@@ -264,6 +324,7 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 		HashedSecret: hashed[:],
 		LoginType:    params.LoginType,
 		Scope:        scope,
+		Scopes:       params.Scopes,
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("insert API key: %w", err)
@@ -2,9 +2,11 @@ package coderd_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/coderd/coderdtest"
@@ -69,6 +71,87 @@ func TestTokens(t *testing.T) {
 		require.Greater(t, keys[0].ExpiresAt, time.Now().Add(time.Hour*438300))
 		require.Equal(t, keys[0].Scope, codersdk.APIKeyScopeApplicationConnect)
 	})
+
+	t.Run("FineGrainedScopes", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		pinned := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		other := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, pinned.LatestBuild.ID)
+
+		scopes := []string{"workspace:read:" + pinned.ID.String(), "audit:read"}
+		res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Scopes: scopes,
+		})
+		require.NoError(t, err)
+		keys, err := client.GetTokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, scopes, keys[0].Scopes)
+
+		scoped := codersdk.New(client.URL)
+		scoped.SessionToken = res.Key
+
+		// Only the pinned workspace can be read.
+		workspaces, err := scoped.Workspaces(ctx, codersdk.WorkspaceFilter{})
+		require.NoError(t, err)
+		require.Len(t, workspaces, 1)
+		require.Equal(t, pinned.ID, workspaces[0].ID)
+		_, err = scoped.Workspace(ctx, pinned.ID)
+		require.NoError(t, err)
+		_, err = scoped.Workspace(ctx, other.ID)
+		require.Error(t, err)
+
+		// Reading is not starting or stopping.
+		_, err = scoped.CreateWorkspaceBuild(ctx, pinned.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStop,
+		})
+		require.Error(t, err)
+		_, err = scoped.Template(ctx, template.ID)
+		require.Error(t, err)
+		_, err = scoped.AuditLogs(ctx, codersdk.AuditLogsRequest{
+			Pagination: codersdk.Pagination{Limit: 1},
+		})
+		require.NoError(t, err)
+
+		res, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Scopes: []string{"workspace:start-stop:" + pinned.ID.String()},
+		})
+		require.NoError(t, err)
+		scoped.SessionToken = res.Key
+		build, err := scoped.CreateWorkspaceBuild(ctx, pinned.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStop,
+		})
+		require.NoError(t, err)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+	})
+
+	t.Run("InvalidScopes", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		for _, req := range []codersdk.CreateTokenRequest{
+			{Scopes: []string{"workspace:launch"}},
+			{Scopes: []string{"audit:read:" + uuid.NewString()}},
+			{Scopes: []string{"workspace:read"}, Scope: codersdk.APIKeyScopeApplicationConnect},
+		} {
+			_, err := client.CreateToken(ctx, codersdk.Me, req)
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		}
+	})
 }
 
 func TestAPIKey(t *testing.T) {
@@ -1,7 +1,9 @@
 package coderd
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -22,8 +24,8 @@ import (
 
 func (api *API) auditLogs(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAuditLog) {
-		httpapi.Forbidden(rw)
+	filter, ok := api.auditLogFilter(rw, r)
+	if !ok {
 		return
 	}
 
@@ -32,24 +34,15 @@ func (api *API) auditLogs(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	queryStr := r.URL.Query().Get("q")
-	filter, errs := auditSearchQuery(queryStr)
-	if len(errs) > 0 {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message:     "Invalid audit search query.",
-			Validations: errs,
-		})
-		return
-	}
-
 	dblogs, err := api.Database.GetAuditLogsOffset(ctx, database.GetAuditLogsOffsetParams{
-		Offset:       int32(page.Offset),
-		Limit:        int32(page.Limit),
-		ResourceType: filter.ResourceType,
-		ResourceID:   filter.ResourceID,
-		Action:       filter.Action,
-		Username:     filter.Username,
-		Email:        filter.Email,
+		Offset:         int32(page.Offset),
+		Limit:          int32(page.Limit),
+		ResourceType:   filter.ResourceType,
+		ResourceID:     filter.ResourceID,
+		Action:         filter.Action,
+		Username:       filter.Username,
+		Email:          filter.Email,
+		OrganizationID: filter.OrganizationID,
 	})
 	if err != nil {
 		httpapi.InternalServerError(rw, err)
@@ -57,33 +50,24 @@ func (api *API) auditLogs(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.AuditLogResponse{
-		AuditLogs: convertAuditLogs(dblogs),
+		AuditLogs: convertAuditLogs(api.CustomRoles, dblogs),
 	})
 }
 
 func (api *API) auditLogCount(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAuditLog) {
-		httpapi.Forbidden(rw)
-		return
-	}
-
-	queryStr := r.URL.Query().Get("q")
-	filter, errs := auditSearchQuery(queryStr)
-	if len(errs) > 0 {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message:     "Invalid audit search query.",
-			Validations: errs,
-		})
+	filter, ok := api.auditLogFilter(rw, r)
+	if !ok {
 		return
 	}
 
 	count, err := api.Database.GetAuditLogCount(ctx, database.GetAuditLogCountParams{
-		ResourceType: filter.ResourceType,
-		ResourceID:   filter.ResourceID,
-		Action:       filter.Action,
-		Username:     filter.Username,
-		Email:        filter.Email,
+		ResourceType:   filter.ResourceType,
+		ResourceID:     filter.ResourceID,
+		Action:         filter.Action,
+		Username:       filter.Username,
+		Email:          filter.Email,
+		OrganizationID: filter.OrganizationID,
 	})
 	if err != nil {
 		httpapi.InternalServerError(rw, err)
@@ -95,6 +79,48 @@ func (api *API) auditLogCount(rw http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// auditLogFilter parses the search query of an audit log request and
+// authorizes reading the matching logs. Reading the logs of a single
+// organization only requires permission in that organization. It writes
+// the error response if it returns false.
+func (api *API) auditLogFilter(rw http.ResponseWriter, r *http.Request) (database.GetAuditLogsOffsetParams, bool) {
+	ctx := r.Context()
+	queryStr := r.URL.Query().Get("q")
+	filter, organization, errs := auditSearchQuery(queryStr)
+	if len(errs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid audit search query.",
+			Validations: errs,
+		})
+		return database.GetAuditLogsOffsetParams{}, false
+	}
+
+	object := rbac.ResourceAuditLog
+	if organization != "" {
+		org, err := api.OrganizationByNameOrID(ctx, organization)
+		if errors.Is(err, sql.ErrNoRows) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid audit search query.",
+				Validations: []codersdk.ValidationError{
+					{Field: "q", Detail: fmt.Sprintf("Organization %q does not exist.", organization)},
+				},
+			})
+			return database.GetAuditLogsOffsetParams{}, false
+		}
+		if err != nil {
+			httpapi.InternalServerError(rw, err)
+			return database.GetAuditLogsOffsetParams{}, false
+		}
+		filter.OrganizationID = org.ID
+		object = object.InOrg(org.ID)
+	}
+	if !api.Authorize(r, rbac.ActionRead, object) {
+		httpapi.Forbidden(rw)
+		return database.GetAuditLogsOffsetParams{}, false
+	}
+	return filter, true
+}
+
 func (api *API) generateFakeAuditLog(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAuditLog) {
@@ -152,6 +178,7 @@ func (api *API) generateFakeAuditLog(rw http.ResponseWriter, r *http.Request) {
 		ResourceType:     database.ResourceType(params.ResourceType),
 		ResourceID:       params.ResourceID,
 		ResourceTarget:   user.Username,
+		OrganizationID:   params.OrganizationID,
 		Action:           database.AuditAction(params.Action),
 		Diff:             diff,
 		StatusCode:       http.StatusOK,
@@ -165,17 +192,17 @@ func (api *API) generateFakeAuditLog(rw http.ResponseWriter, r *http.Request) {
 	rw.WriteHeader(http.StatusNoContent)
 }
 
-func convertAuditLogs(dblogs []database.GetAuditLogsOffsetRow) []codersdk.AuditLog {
+func convertAuditLogs(customRoles *rbac.CustomRoles, dblogs []database.GetAuditLogsOffsetRow) []codersdk.AuditLog {
 	alogs := make([]codersdk.AuditLog, 0, len(dblogs))
 
 	for _, dblog := range dblogs {
-		alogs = append(alogs, convertAuditLog(dblog))
+		alogs = append(alogs, convertAuditLog(customRoles, dblog))
 	}
 
 	return alogs
 }
 
-func convertAuditLog(dblog database.GetAuditLogsOffsetRow) codersdk.AuditLog {
+func convertAuditLog(customRoles *rbac.CustomRoles, dblog database.GetAuditLogsOffsetRow) codersdk.AuditLog {
 	ip, _ := netip.AddrFromSlice(dblog.Ip.IPNet.IP)
 
 	diff := codersdk.AuditDiff{}
@@ -194,8 +221,7 @@ func convertAuditLog(dblog database.GetAuditLogsOffsetRow) codersdk.AuditLog {
 		}
 
 		for _, roleName := range dblog.UserRoles {
-			rbacRole, _ := rbac.RoleByName(roleName)
-			user.Roles = append(user.Roles, convertRole(rbacRole))
+			user.Roles = append(user.Roles, convertRoleName(customRoles, roleName))
 		}
 	}
 
@@ -242,13 +268,15 @@ func auditLogDescription(alog database.GetAuditLogsOffsetRow) string {
 	return str
 }
 
-// auditSearchQuery takes a query string and returns the auditLog filter.
-// It also can return the list of validation errors to return to the api.
-func auditSearchQuery(query string) (database.GetAuditLogsOffsetParams, []codersdk.ValidationError) {
+// auditSearchQuery takes a query string and returns the auditLog filter, and
+// the name or ID of the organization to filter by, which has to be resolved
+// by the caller. It also can return the list of validation errors to return
+// to the api.
+func auditSearchQuery(query string) (database.GetAuditLogsOffsetParams, string, []codersdk.ValidationError) {
 	searchParams := make(url.Values)
 	if query == "" {
 		// No filter
-		return database.GetAuditLogsOffsetParams{}, nil
+		return database.GetAuditLogsOffsetParams{}, "", nil
 	}
 	query = strings.ToLower(query)
 	// Because we do this in 2 passes, we want to maintain quotes on the first
@@ -264,7 +292,7 @@ func auditSearchQuery(query string) (database.GetAuditLogsOffsetParams, []coders
 		case 2:
 			searchParams.Set(parts[0], parts[1])
 		default:
-			return database.GetAuditLogsOffsetParams{}, []codersdk.ValidationError{
+			return database.GetAuditLogsOffsetParams{}, "", []codersdk.ValidationError{
 				{Field: "q", Detail: fmt.Sprintf("Query element %q can only contain 1 ':'", element)},
 			}
 		}
@@ -281,7 +309,7 @@ func auditSearchQuery(query string) (database.GetAuditLogsOffsetParams, []coders
 		Email:        parser.String(searchParams, "", "email"),
 	}
 
-	return filter, parser.Errors
+	return filter, parser.String(searchParams, "", "organization"), parser.Errors
 }
 
 func resourceTypeFromString(resourceTypeString string) string {
@@ -302,6 +330,8 @@ func resourceTypeFromString(resourceTypeString string) string {
 		return resourceTypeString
 	case codersdk.ResourceTypeAPIKey:
 		return resourceTypeString
+	case codersdk.ResourceTypeOrganizationMember:
+		return resourceTypeString
 	}
 	return ""
 }
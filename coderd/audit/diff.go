@@ -17,7 +17,12 @@ type Auditable interface {
 		database.Workspace |
 		database.GitSSHKey |
 		database.Group |
-		database.WorkspaceBuild
+		database.WorkspaceBuild |
+		database.WorkspaceProxy |
+		database.RateLimitPolicy |
+		database.WorkspaceSessionRecording |
+		database.CustomRole |
+		database.AuditableOrganizationMember
 }
 
 // Map is a map of changed fields in an audited resource. It maps field names to
@@ -51,6 +51,17 @@ func ResourceTarget[T Auditable](tgt T) string {
 		return typed.PublicKey
 	case database.Group:
 		return typed.Name
+	case database.WorkspaceProxy:
+		return typed.Name
+	case database.RateLimitPolicy:
+		return fmt.Sprintf("%s:%s", typed.SubjectType, typed.Subject)
+	case database.WorkspaceSessionRecording:
+		// The ID is what "coder sessions play" takes.
+		return typed.ID.String()
+	case database.CustomRole:
+		return typed.RoleName()
+	case database.AuditableOrganizationMember:
+		return typed.Username
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -74,6 +85,16 @@ func ResourceID[T Auditable](tgt T) uuid.UUID {
 		return typed.UserID
 	case database.Group:
 		return typed.ID
+	case database.WorkspaceProxy:
+		return typed.ID
+	case database.RateLimitPolicy:
+		return typed.ID
+	case database.WorkspaceSessionRecording:
+		return typed.ID
+	case database.CustomRole:
+		return typed.ID
+	case database.AuditableOrganizationMember:
+		return typed.UserID
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -97,11 +118,44 @@ func ResourceType[T Auditable](tgt T) database.ResourceType {
 		return database.ResourceTypeGitSshKey
 	case database.Group:
 		return database.ResourceTypeGroup
+	case database.WorkspaceProxy:
+		return database.ResourceTypeWorkspaceProxy
+	case database.RateLimitPolicy:
+		return database.ResourceTypeRateLimitPolicy
+	case database.WorkspaceSessionRecording:
+		return database.ResourceTypeSessionRecording
+	case database.CustomRole:
+		return database.ResourceTypeCustomRole
+	case database.AuditableOrganizationMember:
+		return database.ResourceTypeOrganizationMember
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
 }
 
+// ResourceOrganizationID returns the organization of a resource, or uuid.Nil
+// for resources that don't belong to an organization.
+func ResourceOrganizationID[T Auditable](tgt T) uuid.UUID {
+	switch typed := any(tgt).(type) {
+	case database.Organization:
+		return typed.ID
+	case database.Template:
+		return typed.OrganizationID
+	case database.TemplateVersion:
+		return typed.OrganizationID
+	case database.Workspace:
+		return typed.OrganizationID
+	case database.Group:
+		return typed.OrganizationID
+	case database.CustomRole:
+		return typed.OrganizationID.UUID
+	case database.AuditableOrganizationMember:
+		return typed.OrganizationID
+	default:
+		return uuid.Nil
+	}
+}
+
 // InitRequest initializes an audit log for a request. It returns a function
 // that should be deferred, causing the audit log to be committed when the
 // handler returns.
@@ -146,6 +200,7 @@ func InitRequest[T Auditable](w http.ResponseWriter, p *RequestParams) (*Request
 			ID:               uuid.New(),
 			Time:             database.Now(),
 			UserID:           httpmw.APIKey(p.Request).UserID,
+			OrganizationID:   either(req.Old, req.New, ResourceOrganizationID[T]),
 			Ip:               ip,
 			UserAgent:        p.Request.UserAgent(),
 			ResourceType:     either(req.Old, req.New, ResourceType[T]),
@@ -164,6 +219,55 @@ func InitRequest[T Auditable](w http.ResponseWriter, p *RequestParams) (*Request
 	}
 }
 
+// BackgroundAuditParams are the parameters of an audit log for an action
+// that was not caused by an HTTP request, e.g. by the autobuild executor.
+type BackgroundAuditParams[T Auditable] struct {
+	Audit Auditor
+	Log   slog.Logger
+
+	// UserID is the user the action is attributed to, if any.
+	UserID           uuid.UUID
+	Action           database.AuditAction
+	AdditionalFields json.RawMessage
+
+	Old T
+	New T
+}
+
+// BackgroundAudit commits an audit log for an action performed by the system
+// in the background.
+func BackgroundAudit[T Auditable](ctx context.Context, p *BackgroundAuditParams[T]) {
+	diffRaw, err := json.Marshal(Diff(p.Audit, p.Old, p.New))
+	if err != nil {
+		p.Log.Warn(ctx, "marshal diff", slog.Error(err))
+		diffRaw = []byte("{}")
+	}
+
+	if p.AdditionalFields == nil {
+		p.AdditionalFields = json.RawMessage("{}")
+	}
+
+	err = p.Audit.Export(ctx, database.AuditLog{
+		ID:               uuid.New(),
+		Time:             database.Now(),
+		UserID:           p.UserID,
+		OrganizationID:   either(p.Old, p.New, ResourceOrganizationID[T]),
+		Ip:               parseIP(""),
+		UserAgent:        "",
+		ResourceType:     either(p.Old, p.New, ResourceType[T]),
+		ResourceID:       either(p.Old, p.New, ResourceID[T]),
+		ResourceTarget:   either(p.Old, p.New, ResourceTarget[T]),
+		Action:           p.Action,
+		Diff:             diffRaw,
+		StatusCode:       http.StatusOK,
+		RequestID:        uuid.Nil,
+		AdditionalFields: p.AdditionalFields,
+	})
+	if err != nil {
+		p.Log.Error(ctx, "export audit log", slog.Error(err))
+	}
+}
+
 func either[T Auditable, R any](old, new T, fn func(T) R) R {
 	if ResourceID(new) != uuid.Nil {
 		return fn(new)
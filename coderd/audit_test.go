@@ -2,12 +2,14 @@ package coderd_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -156,3 +158,76 @@ func TestAuditLogsFilter(t *testing.T) {
 		}
 	})
 }
+
+func TestAuditLogsOrganizationFilter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := coderdtest.New(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+	org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+		Name: "other",
+	})
+	require.NoError(t, err)
+
+	err = client.CreateTestAuditLog(ctx, codersdk.CreateTestAuditLogRequest{
+		OrganizationID: user.OrganizationID,
+	})
+	require.NoError(t, err)
+	err = client.CreateTestAuditLog(ctx, codersdk.CreateTestAuditLogRequest{
+		OrganizationID: org.ID,
+	})
+	require.NoError(t, err)
+	err = client.CreateTestAuditLog(ctx, codersdk.CreateTestAuditLogRequest{})
+	require.NoError(t, err)
+
+	t.Run("Filter", func(t *testing.T) {
+		t.Parallel()
+		for _, query := range []string{"organization:other", "organization:" + org.ID.String()} {
+			logs, err := client.AuditLogs(ctx, codersdk.AuditLogsRequest{
+				SearchQuery: query,
+				Pagination:  codersdk.Pagination{Limit: 25},
+			})
+			require.NoError(t, err)
+			require.Len(t, logs.AuditLogs, 1)
+			count, err := client.AuditLogCount(ctx, codersdk.AuditLogCountRequest{
+				SearchQuery: query,
+			})
+			require.NoError(t, err)
+			require.EqualValues(t, 1, count.Count)
+		}
+	})
+
+	t.Run("UnknownOrganization", func(t *testing.T) {
+		t.Parallel()
+		_, err := client.AuditLogs(ctx, codersdk.AuditLogsRequest{
+			SearchQuery: "organization:nothing",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("OrganizationAdmin", func(t *testing.T) {
+		t.Parallel()
+		admin := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleOrgAdmin(user.OrganizationID))
+
+		// Organization admins can only read the logs of their organization.
+		logs, err := admin.AuditLogs(ctx, codersdk.AuditLogsRequest{
+			SearchQuery: "organization:" + user.OrganizationID.String(),
+			Pagination:  codersdk.Pagination{Limit: 25},
+		})
+		require.NoError(t, err)
+		require.Len(t, logs.AuditLogs, 1)
+
+		var apiErr *codersdk.Error
+		_, err = admin.AuditLogs(ctx, codersdk.AuditLogsRequest{})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+		_, err = admin.AuditLogs(ctx, codersdk.AuditLogsRequest{
+			SearchQuery: "organization:other",
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+}
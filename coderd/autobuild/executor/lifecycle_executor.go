@@ -2,7 +2,9 @@ package executor
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +12,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
 )
@@ -21,6 +24,7 @@ type Executor struct {
 	log     slog.Logger
 	tick    <-chan time.Time
 	statsCh chan<- Stats
+	auditor *atomic.Pointer[audit.Auditor]
 }
 
 // Stats contains information about one run of Executor.
@@ -32,15 +36,26 @@ type Stats struct {
 
 // New returns a new autobuild executor.
 func New(ctx context.Context, db database.Store, log slog.Logger, tick <-chan time.Time) *Executor {
+	nop := audit.NewNop()
+	auditor := &atomic.Pointer[audit.Auditor]{}
+	auditor.Store(&nop)
 	le := &Executor{
-		ctx:  ctx,
-		db:   db,
-		tick: tick,
-		log:  log,
+		ctx:     ctx,
+		db:      db,
+		tick:    tick,
+		log:     log,
+		auditor: auditor,
 	}
 	return le
 }
 
+// WithAuditor will cause Executor to audit the workspaces it marks as
+// dormant.
+func (e *Executor) WithAuditor(auditor *atomic.Pointer[audit.Auditor]) *Executor {
+	e.auditor = auditor
+	return e
+}
+
 // WithStatsChannel will cause Executor to push a RunStats to ch after
 // every tick.
 func (e *Executor) WithStatsChannel(ch chan<- Stats) *Executor {
@@ -107,9 +122,22 @@ func (e *Executor) runOnce(t time.Time) Stats {
 		return stats
 	}
 
+	// Workspaces created from templates with an autostop requirement or
+	// dormancy settings must be considered even if they have no autostart
+	// schedule or TTL.
+	templates, err := e.db.GetTemplates(e.ctx)
+	if err != nil {
+		e.log.Error(e.ctx, "get templates for workspace scheduling", slog.Error(err))
+		return stats
+	}
+	templatesByID := make(map[uuid.UUID]database.Template)
+	for _, template := range templates {
+		templatesByID[template.ID] = template
+	}
+
 	var eligibleWorkspaceIDs []uuid.UUID
 	for _, ws := range workspaces {
-		if isEligibleForAutoStartStop(ws) {
+		if isEligibleForAutoStartStop(ws, templatesByID[ws.TemplateID]) {
 			eligibleWorkspaceIDs = append(eligibleWorkspaceIDs, ws.ID)
 		}
 	}
@@ -133,7 +161,12 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					log.Error(e.ctx, "get workspace autostart failed", slog.Error(err))
 					return nil
 				}
-				if !isEligibleForAutoStartStop(ws) {
+				template, err := db.GetTemplateByID(e.ctx, ws.TemplateID)
+				if err != nil {
+					log.Error(e.ctx, "get workspace template failed", slog.Error(err))
+					return nil
+				}
+				if !isEligibleForAutoStartStop(ws, template) {
 					return nil
 				}
 
@@ -150,6 +183,58 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					return nil
 				}
 
+				// Dormant workspaces are never started or stopped on a
+				// schedule, but may be deleted once the grace period passes.
+				if ws.DormantAt.Valid {
+					if !isDueForDeletion(ws, template, currentTick) {
+						return nil
+					}
+					if priorHistory.Transition == database.WorkspaceTransitionDelete || !priorJob.CompletedAt.Valid {
+						return nil
+					}
+					log.Info(e.ctx, "deleting dormant workspace", slog.F("dormant_at", ws.DormantAt.Time))
+					stats.Transitions[ws.ID] = database.WorkspaceTransitionDelete
+					if err := build(e.ctx, db, ws, database.WorkspaceTransitionDelete, database.BuildReasonAutodelete, priorHistory, priorJob); err != nil {
+						log.Error(e.ctx, "unable to delete dormant workspace", slog.Error(err))
+						return nil
+					}
+					audit.BackgroundAudit(e.ctx, &audit.BackgroundAuditParams[database.Workspace]{
+						Audit:  *e.auditor.Load(),
+						Log:    log,
+						Action: database.AuditActionDelete,
+						Old:    ws,
+					})
+					return nil
+				}
+
+				if isDueForDormancy(ws, template, currentTick) {
+					dormant, err := db.UpdateWorkspaceDormantAt(e.ctx, database.UpdateWorkspaceDormantAtParams{
+						ID:        ws.ID,
+						DormantAt: sql.NullTime{Time: database.Now(), Valid: true},
+					})
+					if err != nil {
+						log.Error(e.ctx, "unable to mark workspace dormant", slog.Error(err))
+						return nil
+					}
+					log.Info(e.ctx, "marked workspace dormant", slog.F("last_used_at", ws.LastUsedAt))
+					audit.BackgroundAudit(e.ctx, &audit.BackgroundAuditParams[database.Workspace]{
+						Audit:  *e.auditor.Load(),
+						Log:    log,
+						Action: database.AuditActionWrite,
+						Old:    ws,
+						New:    dormant,
+					})
+
+					if priorHistory.Transition != database.WorkspaceTransitionStart || !priorJob.CompletedAt.Valid || priorJob.Error.String != "" {
+						return nil
+					}
+					stats.Transitions[ws.ID] = database.WorkspaceTransitionStop
+					if err := build(e.ctx, db, dormant, database.WorkspaceTransitionStop, database.BuildReasonDormancy, priorHistory, priorJob); err != nil {
+						log.Error(e.ctx, "unable to stop dormant workspace", slog.Error(err))
+					}
+					return nil
+				}
+
 				validTransition, nextTransition, err := getNextTransition(ws, priorHistory, priorJob)
 				if err != nil {
 					log.Debug(e.ctx, "skipping workspace", slog.Error(err))
@@ -167,8 +252,13 @@ func (e *Executor) runOnce(t time.Time) Stats {
 
 				log.Info(e.ctx, "scheduling workspace transition", slog.F("transition", validTransition))
 
+				buildReason := database.BuildReasonAutostart
+				if validTransition == database.WorkspaceTransitionStop {
+					buildReason = database.BuildReasonAutostop
+				}
+
 				stats.Transitions[ws.ID] = validTransition
-				if err := build(e.ctx, db, ws, validTransition, priorHistory, priorJob); err != nil {
+				if err := build(e.ctx, db, ws, validTransition, buildReason, priorHistory, priorJob); err != nil {
 					log.Error(e.ctx, "unable to transition workspace",
 						slog.F("transition", validTransition),
 						slog.Error(err),
@@ -194,8 +284,38 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	return stats
 }
 
-func isEligibleForAutoStartStop(ws database.Workspace) bool {
-	return !ws.Deleted && (ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0)
+func isEligibleForAutoStartStop(ws database.Workspace, template database.Template) bool {
+	if ws.Deleted {
+		return false
+	}
+	return ws.AutostartSchedule.String != "" ||
+		ws.Ttl.Int64 > 0 ||
+		template.AutostopRequirementDays > 0 ||
+		template.InactivityTtl > 0 ||
+		(ws.DormantAt.Valid && template.DormantDeleteTtl > 0)
+}
+
+// isDueForDormancy returns true if the workspace has not been used for longer
+// than the inactivity TTL of its template. Workspaces that were never used
+// are considered last used when they were created.
+func isDueForDormancy(ws database.Workspace, template database.Template, now time.Time) bool {
+	if ws.DormantAt.Valid || template.InactivityTtl <= 0 {
+		return false
+	}
+	lastUsedAt := ws.LastUsedAt
+	if lastUsedAt.Before(ws.CreatedAt) {
+		lastUsedAt = ws.CreatedAt
+	}
+	return !now.Before(lastUsedAt.Add(time.Duration(template.InactivityTtl)))
+}
+
+// isDueForDeletion returns true if the workspace has been dormant for longer
+// than the dormant delete TTL of its template.
+func isDueForDeletion(ws database.Workspace, template database.Template, now time.Time) bool {
+	if !ws.DormantAt.Valid || template.DormantDeleteTtl <= 0 {
+		return false
+	}
+	return !now.Before(ws.DormantAt.Time.Add(time.Duration(template.DormantDeleteTtl)))
 }
 
 func getNextTransition(
@@ -213,12 +333,18 @@ func getNextTransition(
 
 	switch priorHistory.Transition {
 	case database.WorkspaceTransitionStart:
-		if priorHistory.Deadline.IsZero() {
+		deadline := priorHistory.Deadline
+		// The autostop requirement of the template takes precedence over
+		// the workspace deadline.
+		if !priorHistory.MaxDeadline.IsZero() && (deadline.IsZero() || deadline.After(priorHistory.MaxDeadline)) {
+			deadline = priorHistory.MaxDeadline
+		}
+		if deadline.IsZero() {
 			return "", time.Time{}, xerrors.Errorf("latest workspace build has zero deadline")
 		}
 		// For stopping, do not truncate. This is inconsistent with autostart, but
 		// it ensures we will not stop too early.
-		return database.WorkspaceTransitionStop, priorHistory.Deadline, nil
+		return database.WorkspaceTransitionStop, deadline, nil
 	case database.WorkspaceTransitionStop:
 		sched, err := schedule.Weekly(ws.AutostartSchedule.String)
 		if err != nil {
@@ -235,7 +361,7 @@ func getNextTransition(
 
 // TODO(cian): this function duplicates most of api.postWorkspaceBuilds. Refactor.
 // See: https://github.com/coder/coder/issues/1401
-func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, priorHistory database.WorkspaceBuild, priorJob database.ProvisionerJob) error {
+func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, buildReason database.BuildReason, priorHistory database.WorkspaceBuild, priorJob database.ProvisionerJob) error {
 	template, err := store.GetTemplateByID(ctx, workspace.TemplateID)
 	if err != nil {
 		return xerrors.Errorf("get workspace template: %w", err)
@@ -257,16 +383,6 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 	provisionerJobID := uuid.New()
 	now := database.Now()
 
-	var buildReason database.BuildReason
-	switch trans {
-	case database.WorkspaceTransitionStart:
-		buildReason = database.BuildReasonAutostart
-	case database.WorkspaceTransitionStop:
-		buildReason = database.BuildReasonAutostop
-	default:
-		return xerrors.Errorf("Unsupported transition: %q", trans)
-	}
-
 	newProvisionerJob, err := store.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
 		ID:             provisionerJobID,
 		CreatedAt:      now,
@@ -278,6 +394,7 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 		StorageMethod:  priorJob.StorageMethod,
 		FileID:         priorJob.FileID,
 		Input:          input,
+		Tags:           priorJob.Tags,
 	})
 	if err != nil {
 		return xerrors.Errorf("insert provisioner job: %w", err)
@@ -298,5 +415,28 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 	if err != nil {
 		return xerrors.Errorf("insert workspace build: %w", err)
 	}
+
+	// The build uses the same template version, so carry over the rich
+	// parameter values of the prior build.
+	priorParameters, err := store.GetWorkspaceBuildParameters(ctx, priorHistory.ID)
+	if err != nil {
+		return xerrors.Errorf("get prior workspace build parameters: %w", err)
+	}
+	if len(priorParameters) > 0 {
+		names := make([]string, 0, len(priorParameters))
+		values := make([]string, 0, len(priorParameters))
+		for _, param := range priorParameters {
+			names = append(names, param.Name)
+			values = append(values, param.Value)
+		}
+		err = store.InsertWorkspaceBuildParameters(ctx, database.InsertWorkspaceBuildParametersParams{
+			WorkspaceBuildID: workspaceBuildID,
+			Name:             names,
+			Value:            values,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert workspace build parameters: %w", err)
+		}
+	}
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 
 	"go.uber.org/goleak"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/autobuild/executor"
 	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/coderdtest"
@@ -540,6 +541,139 @@ func TestExecutorAutostartMultipleOK(t *testing.T) {
 	assert.Len(t, stats2.Transitions, 0)
 }
 
+func TestExecutorAutostopRequirement(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		user    = coderdtest.CreateFirstUser(t, client)
+		version = coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_       = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		// Given: a template that must be stopped every day
+		template = coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	)
+	_, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+		AutostopRequirementDays: 1,
+	})
+	require.NoError(t, err)
+	// Given: the owner has quiet hours set
+	_, err = client.UpdateUserQuietHoursSchedule(ctx, codersdk.Me, codersdk.UpdateUserQuietHoursScheduleRequest{
+		Schedule: "CRON_TZ=UTC 0 3 * * *",
+	})
+	require.NoError(t, err)
+
+	// Given: a running workspace with manual shutdown
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+		cwr.TTLMillis = nil
+	})
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+
+	// Then: the build has a max deadline during the quiet hours and the
+	// deadline is capped to it.
+	require.True(t, workspace.LatestBuild.MaxDeadline.Valid)
+	maxDeadline := workspace.LatestBuild.MaxDeadline.Time
+	require.Equal(t, 3, maxDeadline.UTC().Hour())
+	require.Equal(t, 0, maxDeadline.UTC().Minute())
+	require.WithinDuration(t, maxDeadline, workspace.LatestBuild.Deadline.Time, 0)
+
+	// When: the autobuild executor ticks before the max deadline and after it
+	go func() {
+		tickCh <- maxDeadline.Add(-time.Minute)
+		tickCh <- maxDeadline.Add(time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace is not stopped before the max deadline
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	// Then: the workspace is stopped after the max deadline
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonAutostop, workspace.LatestBuild.Reason)
+}
+
+func TestExecutorDormancy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		auditor = audit.NewMock()
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			Auditor:                  auditor,
+		})
+		// Given: we have a user with a workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	// Given: the template marks workspaces dormant after an hour of
+	// inactivity, and deletes them a day later.
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis:    time.Hour.Milliseconds(),
+		DormantDeleteTTLMillis: (24 * time.Hour).Milliseconds(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	require.Nil(t, workspace.DormantAt)
+
+	// When: the autobuild executor ticks after the inactivity TTL
+	tickCh <- workspace.CreatedAt.Add(2 * time.Hour)
+
+	// Then: the workspace is marked dormant and stopped
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.NotNil(t, workspace.DormantAt)
+	require.NotNil(t, workspace.DeletingAt)
+	assert.Equal(t, codersdk.BuildReasonDormancy, workspace.LatestBuild.Reason)
+	require.NotEmpty(t, auditor.AuditLogs)
+	dormancyLog := auditor.AuditLogs[len(auditor.AuditLogs)-1]
+	assert.Equal(t, database.ResourceTypeWorkspace, dormancyLog.ResourceType)
+	assert.Equal(t, workspace.ID, dormancyLog.ResourceID)
+	assert.Equal(t, database.AuditActionWrite, dormancyLog.Action)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	// Then: the workspace cannot be started
+	_, err = client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransitionStart,
+	})
+	require.Error(t, err)
+
+	// When: the autobuild executor ticks after the dormant delete TTL
+	tickCh <- workspace.DeletingAt.Add(time.Minute)
+	close(tickCh)
+
+	// Then: the workspace is deleted
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionDelete, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonAutodelete, workspace.LatestBuild.Reason)
+}
+
 func mustProvisionWorkspace(t *testing.T, client *codersdk.Client, mut ...func(*codersdk.CreateWorkspaceRequest)) codersdk.Workspace {
 	t.Helper()
 	user := coderdtest.CreateFirstUser(t, client)
@@ -41,6 +41,32 @@ func Weekly(raw string) (*Schedule, error) {
 		return nil, xerrors.Errorf("validate weekly schedule: %w", err)
 	}
 
+	return parse(raw)
+}
+
+// Daily parses a Schedule from spec scoped to a recurring daily event.
+// Spec consists of the following space-delimited fields, in the following order:
+// - timezone e.g. CRON_TZ=US/Central (optional)
+// - minutes of hour e.g. 30 (required)
+// - hour of day e.g. 9 (required)
+// - day of month (must be *)
+// - month (must be *)
+// - day of week (must be *)
+//
+// Example Usage:
+//
+//	sched, _ := schedule.Daily("CRON_TZ=Europe/Dublin 0 2 * * *")
+//	fmt.Println(sched.Next(time.Now()).Format(time.RFC3339))
+//	// Output: 2022-04-05T01:00:00Z
+func Daily(raw string) (*Schedule, error) {
+	if err := validateDailySpec(raw); err != nil {
+		return nil, xerrors.Errorf("validate daily schedule: %w", err)
+	}
+
+	return parse(raw)
+}
+
+func parse(raw string) (*Schedule, error) {
 	// If schedule does not specify a timezone, default to UTC. Otherwise,
 	// the library will default to time.Local which we want to avoid.
 	if !strings.HasPrefix(raw, "CRON_TZ=") {
@@ -188,3 +214,37 @@ func validateWeeklySpec(spec string) error {
 	}
 	return nil
 }
+
+// validateDailySpec ensures that the day-of-month, month and day-of-week
+// options of spec are all set to *
+func validateDailySpec(spec string) error {
+	if err := validateWeeklySpec(spec); err != nil {
+		return err
+	}
+	parts := strings.Fields(spec)
+	if parts[len(parts)-1] != "*" {
+		return xerrors.Errorf("expected dow to be *")
+	}
+	return nil
+}
+
+// DefaultQuietHoursSchedule is the quiet hours schedule used for users that
+// have not configured their own.
+const DefaultQuietHoursSchedule = "CRON_TZ=UTC 0 0 * * *"
+
+// autostopRequirementBuffer is the minimum amount of time a workspace is
+// guaranteed to run before it can be stopped by an autostop requirement.
+// This avoids stopping workspaces started right before the quiet hours.
+const autostopRequirementBuffer = time.Hour
+
+// AutostopRequirementDeadline returns the time by which a workspace started at
+// startedAt must be stopped in order to satisfy a template autostop
+// requirement of days. The deadline always falls on the start of the owner's
+// quiet hours. A zero time is returned if days is not positive.
+func AutostopRequirementDeadline(quietHours *Schedule, startedAt time.Time, days int64) time.Time {
+	if days <= 0 {
+		return time.Time{}
+	}
+	earliest := startedAt.Add(time.Duration(days-1)*24*time.Hour + autostopRequirementBuffer)
+	return quietHours.Next(earliest)
+}
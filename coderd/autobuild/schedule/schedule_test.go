@@ -169,3 +169,76 @@ func mustLocation(t *testing.T, s string) *time.Location {
 	require.NoError(t, err)
 	return loc
 }
+
+func Test_Daily(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		sched, err := schedule.Daily("CRON_TZ=US/Central 0 2 * * *")
+		require.NoError(t, err)
+		require.Equal(t, "CRON_TZ=US/Central 0 2 * * *", sched.String())
+		require.Equal(t, "daily", sched.DaysOfWeek())
+		require.Equal(t, 24*time.Hour, sched.Min())
+	})
+
+	t.Run("DayOfWeek", func(t *testing.T) {
+		t.Parallel()
+		_, err := schedule.Daily("CRON_TZ=US/Central 0 2 * * 1-5")
+		require.EqualError(t, err, "validate daily schedule: expected dow to be *")
+	})
+
+	t.Run("DayOfMonth", func(t *testing.T) {
+		t.Parallel()
+		_, err := schedule.Daily("0 2 1 * *")
+		require.EqualError(t, err, "validate daily schedule: expected month and dom to be *")
+	})
+}
+
+func Test_AutostopRequirementDeadline(t *testing.T) {
+	t.Parallel()
+
+	quietHours, err := schedule.Daily(schedule.DefaultQuietHoursSchedule)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		startedAt time.Time
+		days      int64
+		expected  time.Time
+	}{
+		{
+			name:      "Disabled",
+			startedAt: time.Date(2022, 4, 1, 9, 0, 0, 0, time.UTC),
+			days:      0,
+			expected:  time.Time{},
+		},
+		{
+			name:      "OneDay",
+			startedAt: time.Date(2022, 4, 1, 9, 0, 0, 0, time.UTC),
+			days:      1,
+			expected:  time.Date(2022, 4, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "OneDayRightBeforeQuietHours",
+			startedAt: time.Date(2022, 4, 1, 23, 30, 0, 0, time.UTC),
+			days:      1,
+			expected:  time.Date(2022, 4, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "SevenDays",
+			startedAt: time.Date(2022, 4, 1, 9, 0, 0, 0, time.UTC),
+			days:      7,
+			expected:  time.Date(2022, 4, 8, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			actual := schedule.AutostopRequirementDeadline(quietHours, testCase.startedAt, testCase.days)
+			require.True(t, testCase.expected.Equal(actual), "expected %s, got %s", testCase.expected, actual)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package coderd
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -19,6 +20,7 @@ import (
 	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 	"google.golang.org/api/idtoken"
 	"tailscale.com/derp"
@@ -31,14 +33,17 @@ import (
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/awsidentity"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/derpmap"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/metricscache"
+	"github.com/coder/coder/coderd/ratelimit"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/coderd/workspaceapps"
 	"github.com/coder/coder/coderd/workspacequota"
 	"github.com/coder/coder/coderd/wsconncache"
 	"github.com/coder/coder/codersdk"
@@ -92,6 +97,25 @@ type Options struct {
 	TailnetCoordinator tailnet.Coordinator
 	DERPServer         *derp.Server
 	DERPMap            *tailcfg.DERPMap
+	// DERPMapRefresher replaces DERPMap with a map that's refreshed and
+	// health checked periodically, if set.
+	DERPMapRefresher *derpmap.Refresher
+	// DERPMapUpdateFrequency is how often connected agents and clients are
+	// checked for DERP map changes.
+	DERPMapUpdateFrequency time.Duration
+	// AppSecurityKey signs workspace app tokens. Workspace proxies use the
+	// same key to verify tokens without contacting coderd.
+	AppSecurityKey workspaceapps.SecurityKey
+	// SSHCertificateAuthority signs SSH certificates that workspace agents
+	// accept. Certificates can't be issued if it's nil.
+	SSHCertificateAuthority ssh.Signer
+	SSHCertificateTTL       time.Duration
+	// SSHRequireCertificates makes agents reject SSH connections that don't
+	// authenticate with a certificate.
+	SSHRequireCertificates bool
+	// MaxServiceTokenLifetime is the longest lifetime tokens of
+	// service accounts can be created with.
+	MaxServiceTokenLifetime time.Duration
 
 	MetricsCacheRefreshInterval time.Duration
 	AgentStatsRefreshInterval   time.Duration
@@ -120,6 +144,15 @@ func New(options *Options) *API {
 	if options.MetricsCacheRefreshInterval == 0 {
 		options.MetricsCacheRefreshInterval = time.Hour
 	}
+	if options.DERPMapUpdateFrequency == 0 {
+		options.DERPMapUpdateFrequency = 5 * time.Second
+	}
+	if options.SSHCertificateTTL == 0 {
+		options.SSHCertificateTTL = time.Hour
+	}
+	if options.MaxServiceTokenLifetime == 0 {
+		options.MaxServiceTokenLifetime = 30 * 24 * time.Hour
+	}
 	if options.APIRateLimit == 0 {
 		options.APIRateLimit = 512
 	}
@@ -129,8 +162,9 @@ func New(options *Options) *API {
 	if options.MetricsCacheRefreshInterval == 0 {
 		options.MetricsCacheRefreshInterval = time.Hour
 	}
+	customRoles := rbac.NewCustomRoles()
 	if options.Authorizer == nil {
-		options.Authorizer = rbac.NewAuthorizer()
+		options.Authorizer = rbac.NewAuthorizer().WithCustomRoles(customRoles)
 	}
 	if options.PrometheusRegistry == nil {
 		options.PrometheusRegistry = prometheus.NewRegistry()
@@ -163,6 +197,13 @@ func New(options *Options) *API {
 		options.MetricsCacheRefreshInterval,
 	)
 
+	rateLimiter := ratelimit.New(ratelimit.Options{
+		Logger:       options.Logger.Named("ratelimit"),
+		Database:     options.Database,
+		Pubsub:       options.Pubsub,
+		DefaultCount: options.APIRateLimit,
+	})
+
 	r := chi.NewRouter()
 	api := &API{
 		Options:     options,
@@ -173,6 +214,8 @@ func New(options *Options) *API {
 			Logger:     options.Logger,
 		},
 		metricsCache:           metricsCache,
+		RateLimiter:            rateLimiter,
+		CustomRoles:            customRoles,
 		Auditor:                atomic.Pointer[audit.Auditor]{},
 		WorkspaceQuotaEnforcer: atomic.Pointer[workspacequota.Enforcer]{},
 	}
@@ -180,6 +223,19 @@ func New(options *Options) *API {
 	api.WorkspaceQuotaEnforcer.Store(&options.WorkspaceQuotaEnforcer)
 	api.workspaceAgentCache = wsconncache.New(api.dialWorkspaceAgentTailnet, 0)
 	api.TailnetCoordinator.Store(&options.TailnetCoordinator)
+	if err := api.reloadCustomRoles(context.Background()); err != nil {
+		options.Logger.Error(context.Background(), "load custom roles", slog.Error(err))
+	}
+	cancelCustomRoles, err := options.Pubsub.Subscribe(eventCustomRoles, func(ctx context.Context, _ []byte) {
+		if err := api.reloadCustomRoles(ctx); err != nil {
+			options.Logger.Error(ctx, "reload custom roles", slog.Error(err))
+		}
+	})
+	if err != nil {
+		options.Logger.Error(context.Background(), "subscribe to custom role changes", slog.Error(err))
+	} else {
+		api.cancelCustomRoles = cancelCustomRoles
+	}
 	oauthConfigs := &httpmw.OAuth2Configs{
 		Github: options.GithubOAuth2Config,
 		OIDC:   options.OIDCConfig,
@@ -209,7 +265,7 @@ func New(options *Options) *API {
 		// app URL. If it is, it will serve that application.
 		api.handleSubdomainApplications(
 			// Middleware to impose on the served application.
-			httpmw.RateLimit(options.APIRateLimit, time.Minute),
+			api.RateLimiter.Handler,
 			httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
 				DB:            options.Database,
 				OAuth2Configs: oauthConfigs,
@@ -234,7 +290,7 @@ func New(options *Options) *API {
 	apps := func(r chi.Router) {
 		r.Use(
 			tracing.Middleware(api.TracerProvider),
-			httpmw.RateLimit(options.APIRateLimit, time.Minute),
+			api.RateLimiter.Handler,
 			httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
 				DB:            options.Database,
 				OAuth2Configs: oauthConfigs,
@@ -283,7 +339,7 @@ func New(options *Options) *API {
 		r.Use(
 			tracing.Middleware(api.TracerProvider),
 			// Specific routes can specify smaller limits.
-			httpmw.RateLimit(options.APIRateLimit, time.Minute),
+			api.RateLimiter.Handler,
 		)
 		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 			httpapi.Write(r.Context(), w, http.StatusOK, codersdk.Response{
@@ -342,21 +398,35 @@ func New(options *Options) *API {
 					httpmw.ExtractOrganizationParam(options.Database),
 				)
 				r.Get("/", api.organization)
+				r.Patch("/", api.patchOrganization)
 				r.Post("/templateversions", api.postTemplateVersionsByOrganization)
 				r.Route("/templates", func(r chi.Router) {
 					r.Post("/", api.postTemplateByOrganization)
 					r.Get("/", api.templatesByOrganization)
 					r.Get("/{templatename}", api.templateByOrganizationAndName)
 				})
+				r.Route("/roles", func(r chi.Router) {
+					r.Get("/", api.customRoles)
+					r.Post("/", api.postCustomRole)
+					r.Put("/{role}", api.putCustomRole)
+					r.Delete("/{role}", api.deleteCustomRole)
+				})
 				r.Route("/members", func(r chi.Router) {
+					r.Get("/", api.organizationMembers)
 					r.Get("/roles", api.assignableOrgRoles)
 					r.Route("/{user}", func(r chi.Router) {
 						r.Use(
 							httpmw.ExtractUserParam(options.Database, false),
-							httpmw.ExtractOrganizationMemberParam(options.Database),
 						)
-						r.Put("/roles", api.putMemberRoles)
-						r.Post("/workspaces", api.postWorkspacesByOrganization)
+						r.Post("/", api.postOrganizationMember)
+						r.Group(func(r chi.Router) {
+							r.Use(
+								httpmw.ExtractOrganizationMemberParam(options.Database),
+							)
+							r.Delete("/", api.deleteOrganizationMember)
+							r.Put("/roles", api.putMemberRoles)
+							r.Post("/workspaces", api.postWorkspacesByOrganization)
+						})
 					})
 				})
 			})
@@ -394,6 +464,7 @@ func New(options *Options) *API {
 			r.Patch("/cancel", api.patchCancelTemplateVersion)
 			r.Get("/schema", api.templateVersionSchema)
 			r.Get("/parameters", api.templateVersionParameters)
+			r.Get("/rich-parameters", api.templateVersionRichParameters)
 			r.Get("/resources", api.templateVersionResources)
 			r.Get("/logs", api.templateVersionLogs)
 			r.Route("/dry-run", func(r chi.Router) {
@@ -401,6 +472,7 @@ func New(options *Options) *API {
 				r.Get("/{jobID}", api.templateVersionDryRun)
 				r.Get("/{jobID}/resources", api.templateVersionDryRunResources)
 				r.Get("/{jobID}/logs", api.templateVersionDryRunLogs)
+				r.Get("/{jobID}/diff", api.templateVersionDryRunDiff)
 				r.Patch("/{jobID}/cancel", api.patchTemplateVersionDryRunCancel)
 			})
 		})
@@ -443,6 +515,10 @@ func New(options *Options) *API {
 					r.Delete("/", api.deleteUser)
 					r.Get("/", api.userByName)
 					r.Put("/profile", api.putUserProfile)
+					r.Route("/quiet-hours", func(r chi.Router) {
+						r.Get("/", api.userQuietHoursSchedule)
+						r.Put("/", api.putUserQuietHoursSchedule)
+					})
 					r.Route("/status", func(r chi.Router) {
 						r.Put("/suspend", api.putUserStatus(database.UserStatusSuspended))
 						r.Put("/activate", api.putUserStatus(database.UserStatusActive))
@@ -486,12 +562,18 @@ func New(options *Options) *API {
 			r.Route("/me", func(r chi.Router) {
 				r.Use(httpmw.ExtractWorkspaceAgent(options.Database))
 				r.Get("/metadata", api.workspaceAgentMetadata)
+				r.Post("/metadata/{key}", api.workspaceAgentPostMetadata)
 				r.Post("/version", api.postWorkspaceAgentVersion)
+				r.Patch("/startup-logs", api.patchWorkspaceAgentStartupLogs)
 				r.Post("/app-health", api.postWorkspaceAppHealth)
 				r.Get("/gitauth", api.workspaceAgentsGitAuth)
 				r.Get("/gitsshkey", api.agentGitSSHKey)
 				r.Get("/coordinate", api.workspaceAgentCoordinate)
+				r.Get("/derp-map", api.derpMapUpdates)
 				r.Get("/report-stats", api.workspaceAgentReportStats)
+				r.Post("/report-lifecycle", api.workspaceAgentReportLifecycle)
+				r.Get("/shutdown", api.workspaceAgentShutdown)
+				r.Post("/session-recordings", api.postWorkspaceAgentSessionRecording)
 			})
 			r.Route("/{workspaceagent}", func(r chi.Router) {
 				r.Use(
@@ -502,8 +584,15 @@ func New(options *Options) *API {
 				r.Get("/", api.workspaceAgent)
 				r.Get("/pty", api.workspaceAgentPTY)
 				r.Get("/listening-ports", api.workspaceAgentListeningPorts)
+				r.Get("/files", api.workspaceAgentFile)
+				r.Put("/files", api.putWorkspaceAgentFile)
+				r.Get("/connection-stats", api.workspaceAgentConnectionStats)
+				r.Post("/connection-stats", api.postWorkspaceAgentConnectionStats)
+				r.Get("/startup-logs", api.workspaceAgentStartupLogs)
+				r.Get("/watch-metadata", api.watchWorkspaceAgentMetadata)
 				r.Get("/connection", api.workspaceAgentConnection)
 				r.Get("/coordinate", api.workspaceAgentClientCoordinate)
+				r.Post("/ssh-certificate", api.postWorkspaceAgentSSHCertificate)
 				// TODO: This can be removed in October. It allows for a friendly
 				// error message when transitioning from WebRTC to Tailscale. See:
 				// https://github.com/coder/coder/issues/4126
@@ -538,6 +627,8 @@ func New(options *Options) *API {
 				})
 				r.Get("/watch", api.watchWorkspace)
 				r.Put("/extend", api.putExtendWorkspace)
+				r.Put("/dormant", api.putWorkspaceDormant)
+				r.Get("/session-recordings", api.workspaceSessionRecordings)
 			})
 		})
 		r.Route("/workspacebuilds/{workspacebuild}", func(r chi.Router) {
@@ -550,8 +641,35 @@ func New(options *Options) *API {
 			r.Patch("/cancel", api.patchCancelWorkspaceBuild)
 			r.Get("/logs", api.workspaceBuildLogs)
 			r.Get("/resources", api.workspaceBuildResources)
+			r.Get("/parameters", api.workspaceBuildParameters)
 			r.Get("/state", api.workspaceBuildState)
 		})
+		r.Route("/ratelimits", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.rateLimitPolicies)
+			r.Put("/", api.putRateLimitPolicy)
+			r.Delete("/{ratelimit}", api.deleteRateLimitPolicy)
+		})
+		r.Route("/roles", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.customRoles)
+			r.Post("/", api.postCustomRole)
+			r.Put("/{role}", api.putCustomRole)
+			r.Delete("/{role}", api.deleteCustomRole)
+		})
+		r.Route("/derp-map", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.derpMapUpdates)
+			r.Get("/health", api.derpMapHealth)
+		})
+		r.Route("/connectionstats", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/regions", api.connectionStatsByRegion)
+		})
+		r.Route("/sessionrecordings", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/{sessionrecording}", api.sessionRecording)
+		})
 		r.Route("/authcheck", func(r chi.Router) {
 			r.Use(apiKeyMiddleware)
 			r.Post("/", api.checkAuthorization)
@@ -583,27 +701,63 @@ type API struct {
 	WorkspaceClientCoordinateOverride atomic.Pointer[func(rw http.ResponseWriter) bool]
 	WorkspaceQuotaEnforcer            atomic.Pointer[workspacequota.Enforcer]
 	TailnetCoordinator                atomic.Pointer[tailnet.Coordinator]
-	HTTPAuth                          *HTTPAuthorizer
+	// OIDCGroupSyncer syncs the groups of a user that logs in with OIDC. It's
+	// set by Enterprise code.
+	OIDCGroupSyncer atomic.Pointer[func(ctx context.Context, tx database.Store, user database.User, groups []string) error]
+	// DERPMapper mutates the DERP map handed to agents and clients, e.g. to
+	// add the regions served by workspace proxies. It's set by Enterprise
+	// code.
+	DERPMapper atomic.Pointer[func(derpMap *tailcfg.DERPMap) *tailcfg.DERPMap]
+	HTTPAuth   *HTTPAuthorizer
+	// RateLimiter applies the deployment-wide API rate limit and any rate
+	// limit policies stored in the database.
+	RateLimiter *ratelimit.Limiter
+	// CustomRoles are the roles defined by administrators, loaded from the
+	// database. They're assignable like the builtin roles.
+	CustomRoles *rbac.CustomRoles
 
 	// APIHandler serves "/api/v2"
 	APIHandler chi.Router
 	// RootHandler serves "/"
 	RootHandler chi.Router
 
+	// WebsocketWaitGroup tracks open WebSocket connections so Close
+	// can drain them. It's exported for handlers layered on top of
+	// the API, like those in Enterprise.
+	WebsocketWaitMutex sync.Mutex
+	WebsocketWaitGroup sync.WaitGroup
+
 	metricsCache        *metricscache.Cache
 	siteHandler         http.Handler
-	websocketWaitMutex  sync.Mutex
-	websocketWaitGroup  sync.WaitGroup
 	workspaceAgentCache *wsconncache.Cache
+	cancelCustomRoles   func()
+}
+
+// CurrentDERPMap returns the DERP map handed to agents, clients and
+// workspace proxies, with health checks and any Enterprise additions applied.
+func (api *API) CurrentDERPMap() *tailcfg.DERPMap {
+	derpMap := api.DERPMap
+	if api.DERPMapRefresher != nil {
+		derpMap = api.DERPMapRefresher.DERPMap()
+	}
+	mapper := api.DERPMapper.Load()
+	if mapper == nil || *mapper == nil {
+		return derpMap
+	}
+	return (*mapper)(derpMap.Clone())
 }
 
 // Close waits for all WebSocket connections to drain before returning.
 func (api *API) Close() error {
-	api.websocketWaitMutex.Lock()
-	api.websocketWaitGroup.Wait()
-	api.websocketWaitMutex.Unlock()
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Wait()
+	api.WebsocketWaitMutex.Unlock()
 
 	api.metricsCache.Close()
+	_ = api.RateLimiter.Close()
+	if api.cancelCustomRoles != nil {
+		api.cancelCustomRoles()
+	}
 	coordinator := api.TailnetCoordinator.Load()
 	if coordinator != nil {
 		_ = (*coordinator).Close()
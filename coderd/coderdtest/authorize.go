@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/xerrors"
@@ -33,6 +34,7 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		"POST:/api/v2/users/logout": "Logging out deletes the API Key for other routes",
 		"GET:/derp":                 "This requires a WebSocket upgrade!",
 		"GET:/derp/latency-check":   "This always returns a 200!",
+		"GET:/api/v2/derp-map":      "This streams the DERP map until the client disconnects!",
 	}
 
 	assertRoute := map[string]RouteCheck{
@@ -60,10 +62,16 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		"GET:/api/v2/workspaceagents/me/gitauth":                {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/me/gitsshkey":              {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/me/metadata":               {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/metadata/{key}":        {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/me/coordinate":             {NoAuthorize: true},
+		"GET:/api/v2/workspaceagents/me/derp-map":               {NoAuthorize: true},
 		"POST:/api/v2/workspaceagents/me/version":               {NoAuthorize: true},
 		"POST:/api/v2/workspaceagents/me/app-health":            {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/me/report-stats":           {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/report-lifecycle":      {NoAuthorize: true},
+		"GET:/api/v2/workspaceagents/me/shutdown":               {NoAuthorize: true},
+		"PATCH:/api/v2/workspaceagents/me/startup-logs":         {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/session-recordings":    {NoAuthorize: true},
 
 		// These endpoints have more assertions. This is good, add more endpoints to assert if you can!
 		"GET:/api/v2/organizations/{organization}": {AssertObject: rbac.ResourceOrganization.InOrg(a.Admin.OrganizationID)},
@@ -108,6 +116,10 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
+		"GET:/api/v2/workspacebuilds/{workspacebuild}/parameters": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
 		"GET:/api/v2/workspacebuilds/{workspacebuild}/state": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
@@ -116,6 +128,30 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/files": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: workspaceExecObj,
+		},
+		"PUT:/api/v2/workspaceagents/{workspaceagent}/files": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: workspaceExecObj,
+		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/startup-logs": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/watch-metadata": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/connection-stats": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"POST:/api/v2/workspaceagents/{workspaceagent}/connection-stats": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: workspaceExecObj,
+		},
 		"GET:/api/v2/workspaceagents/{workspaceagent}/pty": {
 			AssertAction: rbac.ActionCreate,
 			AssertObject: workspaceExecObj,
@@ -124,6 +160,10 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionCreate,
 			AssertObject: workspaceExecObj,
 		},
+		"POST:/api/v2/workspaceagents/{workspaceagent}/ssh-certificate": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: workspaceExecObj,
+		},
 		"GET:/api/v2/organizations/{organization}/templates": {
 			StatusCode:   http.StatusOK,
 			AssertAction: rbac.ActionRead,
@@ -158,6 +198,58 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
 		},
+		"GET:/api/v2/ratelimits": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceRateLimitPolicy,
+		},
+		"PUT:/api/v2/ratelimits": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceRateLimitPolicy,
+		},
+		"DELETE:/api/v2/ratelimits/{ratelimit}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceRateLimitPolicy,
+		},
+		"GET:/api/v2/roles": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceCustomRole,
+		},
+		"POST:/api/v2/roles": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: rbac.ResourceCustomRole,
+		},
+		"PUT:/api/v2/roles/{role}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceCustomRole,
+		},
+		"DELETE:/api/v2/roles/{role}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceCustomRole,
+		},
+		"GET:/api/v2/organizations/{organization}/roles": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceCustomRole.InOrg(a.Admin.OrganizationID),
+		},
+		"POST:/api/v2/organizations/{organization}/roles": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: rbac.ResourceCustomRole.InOrg(a.Admin.OrganizationID),
+		},
+		"PUT:/api/v2/organizations/{organization}/roles/{role}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceCustomRole.InOrg(a.Admin.OrganizationID),
+		},
+		"DELETE:/api/v2/organizations/{organization}/roles/{role}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceCustomRole.InOrg(a.Admin.OrganizationID),
+		},
+		"GET:/api/v2/derp-map/health": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceDeploymentConfig,
+		},
+		"GET:/api/v2/connectionstats/regions": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceConnectionStat,
+		},
 		"GET:/api/v2/templateversions/{templateversion}": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
@@ -174,6 +266,10 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
 		},
+		"GET:/api/v2/templateversions/{templateversion}/rich-parameters": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
+		},
 		"GET:/api/v2/templateversions/{templateversion}/resources": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
@@ -199,6 +295,10 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Version.OrganizationID),
 		},
+		"GET:/api/v2/templateversions/{templateversion}/dry-run/{jobID}/diff": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceTemplate.InOrg(a.Version.OrganizationID),
+		},
 		"PATCH:/api/v2/templateversions/{templateversion}/dry-run/{jobID}/cancel": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Version.OrganizationID),
@@ -220,6 +320,22 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionUpdate,
 			AssertObject: rbac.ResourceTemplate,
 		},
+		"PATCH:/api/v2/organizations/{organization}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceOrganization.InOrg(a.Admin.OrganizationID),
+		},
+		"GET:/api/v2/organizations/{organization}/members": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceOrganizationMember.InOrg(a.Admin.OrganizationID),
+		},
+		"POST:/api/v2/organizations/{organization}/members/{user}": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: rbac.ResourceOrganizationMember.InOrg(a.Admin.OrganizationID),
+		},
+		"DELETE:/api/v2/organizations/{organization}/members/{user}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceOrganizationMember.InOrg(a.Admin.OrganizationID),
+		},
 		"GET:/api/v2/organizations/{organization}/templates/{templatename}": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
@@ -233,6 +349,14 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
+		"GET:/api/v2/workspaces/{workspace}/session-recordings": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceAuditLog,
+		},
+		"GET:/api/v2/sessionrecordings/{sessionrecording}": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceAuditLog,
+		},
 		"GET:/api/v2/users":                      {StatusCode: http.StatusOK, AssertObject: rbac.ResourceUser},
 		"GET:/api/v2/applications/auth-redirect": {AssertAction: rbac.ActionCreate, AssertObject: rbac.ResourceAPIKey},
 
@@ -361,6 +485,13 @@ func NewAuthTester(ctx context.Context, t *testing.T, client *codersdk.Client, a
 		DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
 	})
 	require.NoError(t, err, "create template param")
+	rateLimitPolicy, err := client.UpsertRateLimitPolicy(ctx, codersdk.UpsertRateLimitPolicyRequest{
+		SubjectType:   codersdk.RateLimitSubjectTypeRole,
+		Subject:       rbac.RoleTemplateAdmin(),
+		Count:         100,
+		WindowSeconds: 60,
+	})
+	require.NoError(t, err, "create rate limit policy")
 	urlParameters := map[string]string{
 		"{organization}":        admin.OrganizationID.String(),
 		"{user}":                admin.UserID.String(),
@@ -378,6 +509,9 @@ func NewAuthTester(ctx context.Context, t *testing.T, client *codersdk.Client, a
 		"{jobID}":               templateVersionDryRun.ID.String(),
 		"{templatename}":        template.Name,
 		"{workspace_and_agent}": workspace.Name + "." + workspace.LatestBuild.Resources[0].Agents[0].Name,
+		"{ratelimit}":           rateLimitPolicy.ID.String(),
+		"{sessionrecording}":    uuid.NewString(),
+		"{role}":                "template-operator",
 		// Only checking template scoped params here
 		"parameters/{scope}/{id}": fmt.Sprintf("parameters/%s/%s",
 			string(templateParam.Scope), templateParam.ScopeID.String()),
@@ -25,6 +25,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -55,13 +56,16 @@ import (
 	"github.com/coder/coder/coderd/awsidentity"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/coderd/derpmap"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/sshca"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/util/ptr"
+	"github.com/coder/coder/coderd/workspaceapps"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/cryptorand"
 	"github.com/coder/coder/provisioner/echo"
@@ -96,6 +100,9 @@ type Options struct {
 	MetricsCacheRefreshInterval time.Duration
 	AgentStatsRefreshInterval   time.Duration
 	DeploymentConfig            *codersdk.DeploymentConfig
+	DERPMapRefresher            *derpmap.Refresher
+	DERPMapUpdateFrequency      time.Duration
+	SSHRequireCertificates      bool
 
 	// Overriding the database is heavily discouraged.
 	// It should only be used in cases where multiple Coder
@@ -160,13 +167,19 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 		options.Database, options.Pubsub = dbtestutil.NewDB(t)
 	}
 
+	if options.Auditor == nil {
+		options.Auditor = audit.NewNop()
+	}
+	auditor := &atomic.Pointer[audit.Auditor]{}
+	auditor.Store(&options.Auditor)
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	lifecycleExecutor := executor.New(
 		ctx,
 		options.Database,
 		slogtest.Make(t, nil).Named("autobuild.executor").Leveled(slog.LevelDebug),
 		options.AutobuildTicker,
-	).WithStatsChannel(options.AutobuildStats)
+	).WithStatsChannel(options.AutobuildStats).WithAuditor(auditor)
 	lifecycleExecutor.Run()
 
 	var mutex sync.RWMutex
@@ -220,6 +233,13 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 		require.NoError(t, err)
 	}
 
+	appSecurityKey, err := workspaceapps.GenerateSecurityKey()
+	require.NoError(t, err)
+	sshCAKey, err := sshca.GenerateKey()
+	require.NoError(t, err)
+	sshCertificateAuthority, err := sshca.ParseKey(sshCAKey)
+	require.NoError(t, err)
+
 	return func(h http.Handler) {
 			mutex.Lock()
 			defer mutex.Unlock()
@@ -252,6 +272,7 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 			Authorizer:           options.Authorizer,
 			Telemetry:            telemetry.NewNoop(),
 			TLSCertificates:      options.TLSCertificates,
+			AppSecurityKey:       appSecurityKey,
 			DERPMap: &tailcfg.DERPMap{
 				Regions: map[int]*tailcfg.DERPRegion{
 					1: {
@@ -274,6 +295,10 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 			AutoImportTemplates:         options.AutoImportTemplates,
 			MetricsCacheRefreshInterval: options.MetricsCacheRefreshInterval,
 			AgentStatsRefreshInterval:   options.AgentStatsRefreshInterval,
+			DERPMapRefresher:            options.DERPMapRefresher,
+			DERPMapUpdateFrequency:      options.DERPMapUpdateFrequency,
+			SSHCertificateAuthority:     sshCertificateAuthority,
+			SSHRequireCertificates:      options.SSHRequireCertificates,
 			DeploymentConfig:            options.DeploymentConfig,
 		}
 }
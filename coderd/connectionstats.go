@@ -0,0 +1,151 @@
+package coderd
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"tailscale.com/tailcfg"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// connectionStatsWindow is how far back connection records are returned.
+const connectionStatsWindow = 24 * time.Hour
+
+func (api *API) postWorkspaceAgentConnectionStats(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	apiKey := httpmw.APIKey(r)
+	// Only users that can connect to the workspace have connections to
+	// report.
+	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.PostWorkspaceAgentConnectionStatsRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	err := insertConnectionStats(ctx, api.Database, database.ConnectionStatSourceClient, workspaceAgent.ID, workspace.ID, apiKey.UserID, req.Connections)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+func (api *API) workspaceAgentConnectionStats(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	stats, err := api.Database.GetConnectionStatsByAgentID(ctx, database.GetConnectionStatsByAgentIDParams{
+		AgentID:   workspaceAgent.ID,
+		CreatedAt: database.Now().Add(-connectionStatsWindow),
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	derpMap := api.CurrentDERPMap()
+	apiStats := make([]codersdk.WorkspaceAgentConnectionStat, 0, len(stats))
+	for _, stat := range stats {
+		apiStats = append(apiStats, codersdk.WorkspaceAgentConnectionStat{
+			ID:             stat.ID,
+			CreatedAt:      stat.CreatedAt,
+			Source:         codersdk.ConnectionStatSource(stat.Source),
+			AgentID:        stat.AgentID,
+			WorkspaceID:    stat.WorkspaceID,
+			UserID:         stat.UserID,
+			PeerIP:         stat.PeerIp,
+			Direct:         stat.Direct,
+			Endpoint:       stat.Endpoint,
+			DERPRegionID:   int(stat.DerpRegionID),
+			DERPRegionName: derpRegionName(derpMap, int(stat.DerpRegionID)),
+			LatencyMS:      stat.LatencyMs,
+			RxBytes:        stat.RxBytes,
+			TxBytes:        stat.TxBytes,
+		})
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiStats)
+}
+
+func (api *API) connectionStatsByRegion(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceConnectionStat) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	rows, err := api.Database.GetConnectionStatsByRegion(ctx, database.Now().Add(-connectionStatsWindow))
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	derpMap := api.CurrentDERPMap()
+	regions := make([]codersdk.ConnectionStatsRegion, 0, len(rows))
+	for _, row := range rows {
+		regions = append(regions, codersdk.ConnectionStatsRegion{
+			DERPRegionID:      int(row.DerpRegionID),
+			DERPRegionName:    derpRegionName(derpMap, int(row.DerpRegionID)),
+			Source:            codersdk.ConnectionStatSource(row.Source),
+			Connections:       row.Connections,
+			DirectConnections: row.DirectConnections,
+			AverageLatencyMS:  row.AverageLatencyMs,
+		})
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, regions)
+}
+
+// insertConnectionStats stores the connection records of one report.
+func insertConnectionStats(ctx context.Context, db database.Store, source database.ConnectionStatSource, agentID, workspaceID, userID uuid.UUID, stats []codersdk.ConnectionStat) error {
+	now := database.Now()
+	for _, stat := range stats {
+		_, err := db.InsertConnectionStat(ctx, database.InsertConnectionStatParams{
+			ID:           uuid.New(),
+			CreatedAt:    now,
+			Source:       source,
+			AgentID:      agentID,
+			WorkspaceID:  workspaceID,
+			UserID:       userID,
+			PeerIp:       stat.PeerIP,
+			Direct:       stat.Direct,
+			Endpoint:     stat.Endpoint,
+			DerpRegionID: int32(stat.DERPRegionID),
+			LatencyMs:    stat.LatencyMS,
+			RxBytes:      stat.RxBytes,
+			TxBytes:      stat.TxBytes,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert connection stat: %w", err)
+		}
+	}
+	return nil
+}
+
+// derpRegionName returns the name of a region in the DERP map. It's empty for
+// regions that were removed from the map after connections were reported.
+func derpRegionName(derpMap *tailcfg.DERPMap, regionID int) string {
+	if derpMap != nil {
+		if region, ok := derpMap.Regions[regionID]; ok {
+			return region.RegionName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestConnectionStats(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon:  true,
+		AgentStatsRefreshInterval: time.Millisecond * 100,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		Logger: slogtest.Make(t, nil),
+		Client: agentClient,
+	})
+	defer func() {
+		_ = agentCloser.Close()
+	}()
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	agentID := resources[0].Agents[0].ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	conn, err := client.DialWorkspaceAgent(ctx, agentID, &codersdk.DialWorkspaceAgentOptions{
+		Logger:                  slogtest.Make(t, nil).Named("tailnet"),
+		ConnectionStatsInterval: time.Millisecond * 100,
+	})
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	sshClient, err := conn.SSHClient()
+	require.NoError(t, err)
+	defer sshClient.Close()
+
+	// Both the agent and the client report the connection.
+	require.Eventually(t, func() bool {
+		stats, err := client.WorkspaceAgentConnectionStats(ctx, agentID)
+		if !assert.NoError(t, err) {
+			return false
+		}
+		sources := map[codersdk.ConnectionStatSource]bool{}
+		for _, stat := range stats {
+			assert.Equal(t, agentID, stat.AgentID)
+			assert.Equal(t, workspace.ID, stat.WorkspaceID)
+			assert.NotEmpty(t, stat.PeerIP)
+			sources[stat.Source] = true
+		}
+		return sources[codersdk.ConnectionStatSourceAgent] && sources[codersdk.ConnectionStatSourceClient]
+	}, testutil.WaitLong, testutil.IntervalMedium)
+
+	regions, err := client.ConnectionStatsByRegion(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, regions)
+	for _, region := range regions {
+		require.EqualValues(t, 1, region.Connections)
+	}
+
+	// Connection stats of the whole deployment are only visible to admins.
+	member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	_, err = member.ConnectionStatsByRegion(ctx)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
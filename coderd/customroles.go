@@ -0,0 +1,382 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// eventCustomRoles is published whenever a custom role is created, updated
+// or deleted, so every replica reloads the roles.
+const eventCustomRoles = "custom_roles"
+
+// reloadCustomRoles reads the custom roles from the database.
+func (api *API) reloadCustomRoles(ctx context.Context) error {
+	roles, err := api.Database.GetCustomRoles(ctx)
+	if err != nil {
+		return xerrors.Errorf("get custom roles: %w", err)
+	}
+	rbacRoles := make([]rbac.Role, 0, len(roles))
+	for _, role := range roles {
+		rbacRoles = append(rbacRoles, role.ToRBAC())
+	}
+	api.CustomRoles.Set(rbacRoles)
+	return nil
+}
+
+// customRolesChanged reloads the custom roles on this replica and notifies
+// the others to do the same.
+func (api *API) customRolesChanged(ctx context.Context) error {
+	err := api.reloadCustomRoles(ctx)
+	if err != nil {
+		return err
+	}
+	err = api.Pubsub.Publish(eventCustomRoles, []byte{})
+	if err != nil {
+		return xerrors.Errorf("publish custom role change: %w", err)
+	}
+	return nil
+}
+
+// customRoleOrganization returns the organization of the custom roles route,
+// which is not valid for the site wide roles.
+func customRoleOrganization(r *http.Request) uuid.NullUUID {
+	if chi.URLParam(r, "organization") == "" {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{
+		UUID:  httpmw.OrganizationParam(r).ID,
+		Valid: true,
+	}
+}
+
+func customRoleObject(organizationID uuid.NullUUID) rbac.Object {
+	return database.CustomRole{OrganizationID: organizationID}.RBACObject()
+}
+
+// Lists the custom roles of the site or an organization.
+func (api *API) customRoles(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	organizationID := customRoleOrganization(r)
+	if !api.Authorize(r, rbac.ActionRead, customRoleObject(organizationID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	roles, err := api.Database.GetCustomRoles(ctx)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	apiRoles := make([]codersdk.CustomRole, 0, len(roles))
+	for _, role := range roles {
+		if role.OrganizationID != organizationID {
+			continue
+		}
+		apiRoles = append(apiRoles, convertCustomRole(role))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiRoles)
+}
+
+// Creates a custom role on the site or in an organization.
+func (api *API) postCustomRole(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		organizationID    = customRoleOrganization(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.CustomRole](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionCreate, customRoleObject(organizationID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.CreateCustomRoleRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	validErrs := validateCustomRolePermissions(organizationID, req.SitePermissions, req.OrgPermissions, req.UserPermissions)
+	if rbac.IsBuiltInRole(req.Name) {
+		validErrs = append(validErrs, codersdk.ValidationError{
+			Field:  "name",
+			Detail: fmt.Sprintf("%q is the name of a builtin role.", req.Name),
+		})
+	}
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid custom role.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	now := database.Now()
+	role, err := api.Database.InsertCustomRole(ctx, database.InsertCustomRoleParams{
+		ID:              uuid.New(),
+		Name:            req.Name,
+		DisplayName:     req.DisplayName,
+		OrganizationID:  organizationID,
+		SitePermissions: convertToRBACPermissions(req.SitePermissions),
+		OrgPermissions:  convertToRBACPermissions(req.OrgPermissions),
+		UserPermissions: convertToRBACPermissions(req.UserPermissions),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	})
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Role with name %q already exists.", req.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	aReq.New = role
+
+	err = api.customRolesChanged(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "reload custom roles", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertCustomRole(role))
+}
+
+// Replaces the permissions of a custom role.
+func (api *API) putCustomRole(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		organizationID    = customRoleOrganization(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.CustomRole](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionUpdate, customRoleObject(organizationID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateCustomRoleRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	old, ok := api.customRoleParam(rw, r, organizationID)
+	if !ok {
+		return
+	}
+	aReq.Old = old
+
+	validErrs := validateCustomRolePermissions(organizationID, req.SitePermissions, req.OrgPermissions, req.UserPermissions)
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid custom role.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	role, err := api.Database.UpdateCustomRoleByID(ctx, database.UpdateCustomRoleByIDParams{
+		ID:              old.ID,
+		DisplayName:     req.DisplayName,
+		SitePermissions: convertToRBACPermissions(req.SitePermissions),
+		OrgPermissions:  convertToRBACPermissions(req.OrgPermissions),
+		UserPermissions: convertToRBACPermissions(req.UserPermissions),
+		UpdatedAt:       database.Now(),
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	aReq.New = role
+
+	err = api.customRolesChanged(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "reload custom roles", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertCustomRole(role))
+}
+
+// Deletes a custom role and unassigns it from everyone.
+func (api *API) deleteCustomRole(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		organizationID    = customRoleOrganization(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.CustomRole](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionDelete, customRoleObject(organizationID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	role, ok := api.customRoleParam(rw, r, organizationID)
+	if !ok {
+		return
+	}
+	aReq.Old = role
+
+	err := api.Database.InTx(func(tx database.Store) error {
+		err := tx.DeleteCustomRoleByID(ctx, role.ID)
+		if err != nil {
+			return xerrors.Errorf("delete custom role: %w", err)
+		}
+		if role.OrganizationID.Valid {
+			err = tx.RemoveRoleFromOrganizationMembers(ctx, database.RemoveRoleFromOrganizationMembersParams{
+				RoleName:       role.RoleName(),
+				OrganizationID: role.OrganizationID.UUID,
+			})
+		} else {
+			err = tx.RemoveRoleFromUsers(ctx, role.RoleName())
+		}
+		if err != nil {
+			return xerrors.Errorf("unassign custom role: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	err = api.customRolesChanged(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "reload custom roles", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// customRoleParam fetches the custom role named in the URL.
+func (api *API) customRoleParam(rw http.ResponseWriter, r *http.Request, organizationID uuid.NullUUID) (database.CustomRole, bool) {
+	ctx := r.Context()
+	role, err := api.Database.GetCustomRoleByName(ctx, database.GetCustomRoleByNameParams{
+		Name:           chi.URLParam(r, "role"),
+		OrganizationID: organizationID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return database.CustomRole{}, false
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return database.CustomRole{}, false
+	}
+	return role, true
+}
+
+// validateCustomRolePermissions ensures the permissions refer to existing
+// resources and actions. Site roles can't have org permissions, and org
+// roles can only have org permissions.
+func validateCustomRolePermissions(organizationID uuid.NullUUID, site, org, user []codersdk.Permission) []codersdk.ValidationError {
+	var validErrs []codersdk.ValidationError
+	validate := func(field string, permissions []codersdk.Permission, allowed bool) {
+		if len(permissions) > 0 && !allowed {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  field,
+				Detail: "Organization roles can only have org permissions, and site roles can't have any.",
+			})
+			return
+		}
+		for _, permission := range permissions {
+			if !slices.Contains(rbac.ResourceTypes(), permission.ResourceType) {
+				validErrs = append(validErrs, codersdk.ValidationError{
+					Field:  field,
+					Detail: fmt.Sprintf("%q is not a resource type.", permission.ResourceType),
+				})
+			}
+			switch permission.Action {
+			case rbac.ActionCreate, rbac.ActionRead, rbac.ActionUpdate, rbac.ActionDelete, rbac.WildcardSymbol:
+			default:
+				validErrs = append(validErrs, codersdk.ValidationError{
+					Field:  field,
+					Detail: fmt.Sprintf("%q is not an action.", permission.Action),
+				})
+			}
+		}
+	}
+	validate("site_permissions", site, !organizationID.Valid)
+	validate("org_permissions", org, organizationID.Valid)
+	validate("user_permissions", user, !organizationID.Valid)
+	return validErrs
+}
+
+func convertToRBACPermissions(permissions []codersdk.Permission) database.CustomRolePermissions {
+	converted := make(database.CustomRolePermissions, 0, len(permissions))
+	for _, permission := range permissions {
+		converted = append(converted, rbac.Permission{
+			Negate:       permission.Negate,
+			ResourceType: permission.ResourceType,
+			Action:       rbac.Action(permission.Action),
+		})
+	}
+	return converted
+}
+
+func convertPermissions(permissions []rbac.Permission) []codersdk.Permission {
+	converted := make([]codersdk.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		converted = append(converted, codersdk.Permission{
+			Negate:       permission.Negate,
+			ResourceType: permission.ResourceType,
+			Action:       string(permission.Action),
+		})
+	}
+	return converted
+}
+
+func convertCustomRole(role database.CustomRole) codersdk.CustomRole {
+	converted := codersdk.CustomRole{
+		ID:              role.ID,
+		Name:            role.Name,
+		DisplayName:     role.DisplayName,
+		SitePermissions: convertPermissions(role.SitePermissions),
+		OrgPermissions:  convertPermissions(role.OrgPermissions),
+		UserPermissions: convertPermissions(role.UserPermissions),
+		CreatedAt:       role.CreatedAt,
+		UpdatedAt:       role.UpdatedAt,
+	}
+	if role.OrganizationID.Valid {
+		converted.OrganizationID = &role.OrganizationID.UUID
+	}
+	return converted
+}
@@ -0,0 +1,223 @@
+package coderd_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/testutil"
+)
+
+func TestCustomRoles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("TemplateOperator", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		role, err := client.CreateCustomRole(ctx, uuid.Nil, codersdk.CreateCustomRoleRequest{
+			Name:        "template-operator",
+			DisplayName: "Template Operator",
+			SitePermissions: []codersdk.Permission{
+				{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionRead},
+				{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionCreate},
+				{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionUpdate},
+			},
+			UserPermissions: []codersdk.Permission{
+				{ResourceType: rbac.ResourceFile.Type, Action: rbac.WildcardSymbol},
+			},
+		})
+		require.NoError(t, err)
+		require.Nil(t, role.OrganizationID)
+
+		roles, err := client.ListSiteRoles(ctx)
+		require.NoError(t, err)
+		require.Contains(t, roles, codersdk.AssignableRoles{
+			Role: codersdk.Role{
+				Name:        "template-operator",
+				DisplayName: "Template Operator",
+			},
+			Assignable: true,
+		})
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		// Operators can push new versions of templates...
+		operator := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, "template-operator")
+		operatorUser, err := operator.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Contains(t, operatorUser.Roles, codersdk.Role{
+			Name:        "template-operator",
+			DisplayName: "Template Operator",
+		})
+		data, err := echo.Tar(nil)
+		require.NoError(t, err)
+		file, err := operator.Upload(ctx, codersdk.ContentTypeTar, data)
+		require.NoError(t, err)
+		newVersion, err := operator.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			TemplateID:    template.ID,
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			FileID:        file.ID,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+		})
+		require.NoError(t, err)
+		coderdtest.AwaitTemplateVersionJob(t, operator, newVersion.ID)
+		err = operator.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: newVersion.ID,
+		})
+		require.NoError(t, err)
+
+		// ...but not delete them.
+		err = operator.DeleteTemplate(ctx, template.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		// Removing permissions applies immediately.
+		_, err = client.UpdateCustomRole(ctx, uuid.Nil, "template-operator", codersdk.UpdateCustomRoleRequest{
+			DisplayName: "Template Operator",
+			SitePermissions: []codersdk.Permission{
+				{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionRead},
+			},
+		})
+		require.NoError(t, err)
+		err = operator.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: version.ID,
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		// Deleting the role unassigns it.
+		err = client.DeleteCustomRole(ctx, uuid.Nil, "template-operator")
+		require.NoError(t, err)
+		operatorUser, err = operator.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.Role{}, operatorUser.Roles)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		for _, tc := range []struct {
+			name           string
+			organizationID bool
+			req            codersdk.CreateCustomRoleRequest
+		}{{
+			name: "BuiltinName",
+			req: codersdk.CreateCustomRoleRequest{
+				Name:        "template-admin",
+				DisplayName: "Template Admin",
+			},
+		}, {
+			name: "UnknownResource",
+			req: codersdk.CreateCustomRoleRequest{
+				Name:        "unknown",
+				DisplayName: "Unknown",
+				SitePermissions: []codersdk.Permission{
+					{ResourceType: "spaceship", Action: rbac.ActionRead},
+				},
+			},
+		}, {
+			name: "UnknownAction",
+			req: codersdk.CreateCustomRoleRequest{
+				Name:        "unknown",
+				DisplayName: "Unknown",
+				SitePermissions: []codersdk.Permission{
+					{ResourceType: rbac.ResourceTemplate.Type, Action: "launch"},
+				},
+			},
+		}, {
+			name: "SiteRoleWithOrgPermissions",
+			req: codersdk.CreateCustomRoleRequest{
+				Name:        "site",
+				DisplayName: "Site",
+				OrgPermissions: []codersdk.Permission{
+					{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionRead},
+				},
+			},
+		}, {
+			name:           "OrgRoleWithSitePermissions",
+			organizationID: true,
+			req: codersdk.CreateCustomRoleRequest{
+				Name:        "org",
+				DisplayName: "Org",
+				SitePermissions: []codersdk.Permission{
+					{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionRead},
+				},
+			},
+		}} {
+			organizationID := uuid.Nil
+			if tc.organizationID {
+				organizationID = user.OrganizationID
+			}
+			_, err := client.CreateCustomRole(ctx, organizationID, tc.req)
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr, tc.name)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode(), tc.name)
+		}
+	})
+
+	t.Run("OrganizationRole", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		// Org admins manage the custom roles of their organization.
+		orgAdmin := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleOrgAdmin(user.OrganizationID))
+		role, err := orgAdmin.CreateCustomRole(ctx, user.OrganizationID, codersdk.CreateCustomRoleRequest{
+			Name:        "template-reader",
+			DisplayName: "Template Reader",
+			OrgPermissions: []codersdk.Permission{
+				{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionRead},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, user.OrganizationID, *role.OrganizationID)
+		_, err = orgAdmin.CreateCustomRole(ctx, uuid.Nil, codersdk.CreateCustomRoleRequest{
+			Name:        "site",
+			DisplayName: "Site",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		roles, err := orgAdmin.CustomRoles(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		siteRoles, err := client.CustomRoles(ctx, uuid.Nil)
+		require.NoError(t, err)
+		require.Len(t, siteRoles, 0)
+
+		roleName := "template-reader:" + user.OrganizationID.String()
+		_, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		membership, err := orgAdmin.UpdateOrganizationMemberRoles(ctx, user.OrganizationID, member.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{roleName},
+		})
+		require.NoError(t, err)
+		require.Contains(t, membership.Roles, codersdk.Role{
+			Name:        roleName,
+			DisplayName: "Template Reader",
+		})
+
+		err = orgAdmin.DeleteCustomRole(ctx, user.OrganizationID, "template-reader")
+		require.NoError(t, err)
+		_, err = orgAdmin.UpdateOrganizationMemberRoles(ctx, user.OrganizationID, member.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{roleName},
+		})
+		require.Error(t, err)
+	})
+}
@@ -3,6 +3,7 @@ package databasefake
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"sort"
 	"strings"
 	"sync"
@@ -104,16 +105,29 @@ type data struct {
 	provisionerJobResourceMetadata []database.WorkspaceResourceMetadatum
 	provisionerJobs                []database.ProvisionerJob
 	templateVersions               []database.TemplateVersion
+	templateVersionParameters      []database.TemplateVersionParameter
 	templates                      []database.Template
 	workspaceBuilds                []database.WorkspaceBuild
+	workspaceBuildParameters       []database.WorkspaceBuildParameter
+	workspaceAgentStartupLogs      []database.WorkspaceAgentStartupLog
+	workspaceAgentMetadata         []database.WorkspaceAgentMetadatum
 	workspaceApps                  []database.WorkspaceApp
 	workspaces                     []database.Workspace
 	licenses                       []database.License
 	replicas                       []database.Replica
+	workspaceProxies               []database.WorkspaceProxy
+	rateLimitPolicies              []database.RateLimitPolicy
+	customRoles                    []database.CustomRole
+	connectionStats                []database.ConnectionStat
+	workspaceSessionRecordings     []database.WorkspaceSessionRecording
 
-	deploymentID  string
-	derpMeshKey   string
-	lastLicenseID int32
+	deploymentID                   string
+	derpMeshKey                    string
+	appSecurityKey                 string
+	sshCertificateAuthorityKey     string
+	lastWorkspaceProxyRegionID     int32
+	lastLicenseID                  int32
+	lastWorkspaceAgentStartupLogID int64
 }
 
 func (*fakeQuerier) Ping(_ context.Context) (time.Duration, error) {
@@ -131,6 +145,14 @@ func (q *fakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.Acqu
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
+	tags := map[string]string{}
+	if arg.Tags != nil {
+		err := json.Unmarshal(arg.Tags, &tags)
+		if err != nil {
+			return database.ProvisionerJob{}, xerrors.Errorf("unmarshal: %w", err)
+		}
+	}
+
 	for index, provisionerJob := range q.provisionerJobs {
 		if provisionerJob.StartedAt.Valid {
 			continue
@@ -146,6 +168,20 @@ func (q *fakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.Acqu
 		if !found {
 			continue
 		}
+		if arg.OrganizationID != uuid.Nil && provisionerJob.OrganizationID != arg.OrganizationID {
+			continue
+		}
+		missing := false
+		for key, value := range provisionerJob.Tags {
+			provided, ok := tags[key]
+			if !ok || provided != value {
+				missing = true
+				break
+			}
+		}
+		if missing {
+			continue
+		}
 		provisionerJob.StartedAt = arg.StartedAt
 		provisionerJob.UpdatedAt = arg.StartedAt.Time
 		provisionerJob.WorkerID = arg.WorkerID
@@ -176,6 +212,156 @@ func (q *fakeQuerier) InsertAgentStat(_ context.Context, p database.InsertAgentS
 	return stat, nil
 }
 
+func (*fakeQuerier) DeleteOldConnectionStats(_ context.Context) error {
+	// no-op
+	return nil
+}
+
+func (q *fakeQuerier) InsertConnectionStat(_ context.Context, arg database.InsertConnectionStatParams) (database.ConnectionStat, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	stat := database.ConnectionStat{
+		ID:           arg.ID,
+		CreatedAt:    arg.CreatedAt,
+		Source:       arg.Source,
+		AgentID:      arg.AgentID,
+		WorkspaceID:  arg.WorkspaceID,
+		UserID:       arg.UserID,
+		PeerIp:       arg.PeerIp,
+		Direct:       arg.Direct,
+		Endpoint:     arg.Endpoint,
+		DerpRegionID: arg.DerpRegionID,
+		LatencyMs:    arg.LatencyMs,
+		RxBytes:      arg.RxBytes,
+		TxBytes:      arg.TxBytes,
+	}
+	q.connectionStats = append(q.connectionStats, stat)
+	return stat, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceSessionRecording(_ context.Context, arg database.InsertWorkspaceSessionRecordingParams) (database.WorkspaceSessionRecording, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	recording := database.WorkspaceSessionRecording{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		WorkspaceID: arg.WorkspaceID,
+		AgentID:     arg.AgentID,
+		FileID:      arg.FileID,
+		Type:        arg.Type,
+		StartedAt:   arg.StartedAt,
+		EndedAt:     arg.EndedAt,
+		UserID:      arg.UserID,
+	}
+	q.workspaceSessionRecordings = append(q.workspaceSessionRecordings, recording)
+	return recording, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceSessionRecordingByID(_ context.Context, id uuid.UUID) (database.WorkspaceSessionRecording, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, recording := range q.workspaceSessionRecordings {
+		if recording.ID == id {
+			return recording, nil
+		}
+	}
+	return database.WorkspaceSessionRecording{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceSessionRecordingsByWorkspaceID(_ context.Context, workspaceID uuid.UUID) ([]database.WorkspaceSessionRecording, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	recordings := make([]database.WorkspaceSessionRecording, 0)
+	for _, recording := range q.workspaceSessionRecordings {
+		if recording.WorkspaceID == workspaceID {
+			recordings = append(recordings, recording)
+		}
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, nil
+}
+
+func (q *fakeQuerier) GetConnectionStatsByAgentID(_ context.Context, arg database.GetConnectionStatsByAgentIDParams) ([]database.ConnectionStat, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	stats := make([]database.ConnectionStat, 0)
+	for _, stat := range q.connectionStats {
+		if stat.AgentID != arg.AgentID || !stat.CreatedAt.After(arg.CreatedAt) {
+			continue
+		}
+		stats = append(stats, stat)
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].CreatedAt.After(stats[j].CreatedAt)
+	})
+	return stats, nil
+}
+
+func (q *fakeQuerier) GetConnectionStatsByRegion(_ context.Context, createdAt time.Time) ([]database.GetConnectionStatsByRegionRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	type groupKey struct {
+		regionID int32
+		source   database.ConnectionStatSource
+	}
+	type group struct {
+		connections map[string]struct{}
+		direct      map[string]struct{}
+		latencySum  float64
+		latencies   int
+	}
+	groups := map[groupKey]*group{}
+	for _, stat := range q.connectionStats {
+		if !stat.CreatedAt.After(createdAt) {
+			continue
+		}
+		key := groupKey{regionID: stat.DerpRegionID, source: stat.Source}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{connections: map[string]struct{}{}, direct: map[string]struct{}{}}
+			groups[key] = g
+		}
+		conn := stat.AgentID.String() + stat.PeerIp
+		g.connections[conn] = struct{}{}
+		if stat.Direct {
+			g.direct[conn] = struct{}{}
+		}
+		if stat.LatencyMs > 0 {
+			g.latencySum += stat.LatencyMs
+			g.latencies++
+		}
+	}
+
+	rows := make([]database.GetConnectionStatsByRegionRow, 0, len(groups))
+	for key, g := range groups {
+		row := database.GetConnectionStatsByRegionRow{
+			DerpRegionID:      key.regionID,
+			Source:            key.source,
+			Connections:       int64(len(g.connections)),
+			DirectConnections: int64(len(g.direct)),
+		}
+		if g.latencies > 0 {
+			row.AverageLatencyMs = g.latencySum / float64(g.latencies)
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].DerpRegionID != rows[j].DerpRegionID {
+			return rows[i].DerpRegionID < rows[j].DerpRegionID
+		}
+		return rows[i].Source < rows[j].Source
+	})
+	return rows, nil
+}
+
 func (q *fakeQuerier) GetLatestAgentStat(_ context.Context, agentID uuid.UUID) (database.AgentStat, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -450,7 +636,7 @@ func (q *fakeQuerier) GetActiveUserCount(_ context.Context) (int64, error) {
 
 	active := int64(0)
 	for _, u := range q.users {
-		if u.Status == database.UserStatusActive && !u.Deleted {
+		if u.Status == database.UserStatusActive && !u.Deleted && !u.ServiceAccount {
 			active++
 		}
 	}
@@ -618,11 +804,12 @@ func (q *fakeQuerier) GetAuthorizationUserRoles(_ context.Context, userID uuid.U
 	}
 
 	return database.GetAuthorizationUserRolesRow{
-		ID:       userID,
-		Username: user.Username,
-		Status:   user.Status,
-		Roles:    roles,
-		Groups:   groups,
+		ID:             userID,
+		Username:       user.Username,
+		Status:         user.Status,
+		ServiceAccount: user.ServiceAccount,
+		Roles:          roles,
+		Groups:         groups,
 	}, nil
 }
 
@@ -1060,6 +1247,20 @@ func (q *fakeQuerier) GetWorkspaceOwnerCountsByTemplateIDs(_ context.Context, te
 	return res, nil
 }
 
+func (q *fakeQuerier) GetWorkspaceBuildParameters(_ context.Context, workspaceBuildID uuid.UUID) ([]database.WorkspaceBuildParameter, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	params := make([]database.WorkspaceBuildParameter, 0)
+	for _, param := range q.workspaceBuildParameters {
+		if param.WorkspaceBuildID != workspaceBuildID {
+			continue
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
 func (q *fakeQuerier) GetWorkspaceBuildByID(_ context.Context, id uuid.UUID) (database.WorkspaceBuild, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1072,6 +1273,22 @@ func (q *fakeQuerier) GetWorkspaceBuildByID(_ context.Context, id uuid.UUID) (da
 	return database.WorkspaceBuild{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) GetWorkspaceCountByOrganizationAndOwner(_ context.Context, arg database.GetWorkspaceCountByOrganizationAndOwnerParams) (int64, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+	var count int64
+	for _, workspace := range q.workspaces {
+		if workspace.OrganizationID == arg.OrganizationID && workspace.OwnerID == arg.OwnerID {
+			if workspace.Deleted {
+				continue
+			}
+
+			count++
+		}
+	}
+	return count, nil
+}
+
 func (q *fakeQuerier) GetWorkspaceCountByUserID(_ context.Context, id uuid.UUID) (int64, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1390,6 +1607,11 @@ func (q *fakeQuerier) UpdateTemplateMetaByID(_ context.Context, arg database.Upd
 		tpl.Icon = arg.Icon
 		tpl.MaxTtl = arg.MaxTtl
 		tpl.MinAutostartInterval = arg.MinAutostartInterval
+		tpl.AutostopRequirementDays = arg.AutostopRequirementDays
+		tpl.InactivityTtl = arg.InactivityTtl
+		tpl.DormantDeleteTtl = arg.DormantDeleteTtl
+		tpl.PortForwardingAllowlist = arg.PortForwardingAllowlist
+		tpl.RecordSessions = arg.RecordSessions
 		q.templates[idx] = tpl
 		return tpl, nil
 	}
@@ -1529,6 +1751,26 @@ func (q *fakeQuerier) GetTemplateVersionByTemplateIDAndName(_ context.Context, a
 	return database.TemplateVersion{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) GetTemplateVersionParameters(_ context.Context, templateVersionID uuid.UUID) ([]database.TemplateVersionParameter, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	parameters := make([]database.TemplateVersionParameter, 0)
+	for _, param := range q.templateVersionParameters {
+		if param.TemplateVersionID != templateVersionID {
+			continue
+		}
+		parameters = append(parameters, param)
+	}
+	sort.SliceStable(parameters, func(i, j int) bool {
+		if parameters[i].DisplayOrder != parameters[j].DisplayOrder {
+			return parameters[i].DisplayOrder < parameters[j].DisplayOrder
+		}
+		return strings.ToLower(parameters[i].Name) < strings.ToLower(parameters[j].Name)
+	})
+	return parameters, nil
+}
+
 func (q *fakeQuerier) GetTemplateVersionByID(_ context.Context, templateVersionID uuid.UUID) (database.TemplateVersion, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -2073,6 +2315,9 @@ func (q *fakeQuerier) InsertAPIKey(_ context.Context, arg database.InsertAPIKeyP
 	if arg.LifetimeSeconds == 0 {
 		arg.LifetimeSeconds = 86400
 	}
+	if arg.Scopes == nil {
+		arg.Scopes = []string{}
+	}
 
 	//nolint:gosimple
 	key := database.APIKey{
@@ -2087,6 +2332,7 @@ func (q *fakeQuerier) InsertAPIKey(_ context.Context, arg database.InsertAPIKeyP
 		LastUsed:        arg.LastUsed,
 		LoginType:       arg.LoginType,
 		Scope:           arg.Scope,
+		Scopes:          arg.Scopes,
 	}
 	q.apiKeys = append(q.apiKeys, key)
 	return key, nil
@@ -2123,6 +2369,23 @@ func (q *fakeQuerier) InsertOrganization(_ context.Context, arg database.InsertO
 	return organization, nil
 }
 
+func (q *fakeQuerier) UpdateOrganization(_ context.Context, arg database.UpdateOrganizationParams) (database.Organization, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, organization := range q.organizations {
+		if organization.ID != arg.ID {
+			continue
+		}
+		organization.Name = arg.Name
+		organization.UserWorkspaceQuota = arg.UserWorkspaceQuota
+		organization.UpdatedAt = arg.UpdatedAt
+		q.organizations[i] = organization
+		return organization, nil
+	}
+	return database.Organization{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) InsertOrganizationMember(_ context.Context, arg database.InsertOrganizationMemberParams) (database.OrganizationMember, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2139,6 +2402,49 @@ func (q *fakeQuerier) InsertOrganizationMember(_ context.Context, arg database.I
 	return organizationMember, nil
 }
 
+func (q *fakeQuerier) DeleteOrganizationMember(_ context.Context, arg database.DeleteOrganizationMemberParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, member := range q.organizationMembers {
+		if member.OrganizationID == arg.OrganizationID && member.UserID == arg.UserID {
+			q.organizationMembers = append(q.organizationMembers[:i], q.organizationMembers[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *fakeQuerier) GetOrganizationMembers(_ context.Context, organizationID uuid.UUID) ([]database.GetOrganizationMembersRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	members := make([]database.GetOrganizationMembersRow, 0)
+	for _, member := range q.organizationMembers {
+		if member.OrganizationID != organizationID {
+			continue
+		}
+		for _, user := range q.users {
+			if user.ID != member.UserID || user.Deleted {
+				continue
+			}
+			members = append(members, database.GetOrganizationMembersRow{
+				UserID:         member.UserID,
+				OrganizationID: member.OrganizationID,
+				CreatedAt:      member.CreatedAt,
+				UpdatedAt:      member.UpdatedAt,
+				Roles:          member.Roles,
+				Username:       user.Username,
+				Email:          user.Email,
+			})
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Username < members[j].Username
+	})
+	return members, nil
+}
+
 func (q *fakeQuerier) InsertParameterValue(_ context.Context, arg database.InsertParameterValueParams) (database.ParameterValue, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2182,11 +2488,38 @@ func (q *fakeQuerier) InsertTemplate(_ context.Context, arg database.InsertTempl
 		CreatedBy:            arg.CreatedBy,
 		UserACL:              arg.UserACL,
 		GroupACL:             arg.GroupACL,
+
+		PortForwardingAllowlist: []string{},
 	}
 	q.templates = append(q.templates, template)
 	return template, nil
 }
 
+func (q *fakeQuerier) InsertTemplateVersionParameter(_ context.Context, arg database.InsertTemplateVersionParameterParams) (database.TemplateVersionParameter, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	param := database.TemplateVersionParameter{
+		TemplateVersionID: arg.TemplateVersionID,
+		Name:              arg.Name,
+		DisplayName:       arg.DisplayName,
+		Description:       arg.Description,
+		Type:              arg.Type,
+		Mutable:           arg.Mutable,
+		DefaultValue:      arg.DefaultValue,
+		Icon:              arg.Icon,
+		Options:           arg.Options,
+		ValidationRegex:   arg.ValidationRegex,
+		ValidationError:   arg.ValidationError,
+		ValidationMin:     arg.ValidationMin,
+		ValidationMax:     arg.ValidationMax,
+		DisplayOrder:      arg.DisplayOrder,
+	}
+	q.templateVersionParameters = append(q.templateVersionParameters, param)
+	return param, nil
+}
+
 func (q *fakeQuerier) InsertTemplateVersion(_ context.Context, arg database.InsertTemplateVersionParams) (database.TemplateVersion, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2260,10 +2593,12 @@ func (q *fakeQuerier) InsertProvisionerDaemon(_ context.Context, arg database.In
 	defer q.mutex.Unlock()
 
 	daemon := database.ProvisionerDaemon{
-		ID:           arg.ID,
-		CreatedAt:    arg.CreatedAt,
-		Name:         arg.Name,
-		Provisioners: arg.Provisioners,
+		ID:             arg.ID,
+		CreatedAt:      arg.CreatedAt,
+		Name:           arg.Name,
+		Provisioners:   arg.Provisioners,
+		Tags:           arg.Tags,
+		OrganizationID: arg.OrganizationID,
 	}
 	q.provisionerDaemons = append(q.provisionerDaemons, daemon)
 	return daemon, nil
@@ -2284,6 +2619,7 @@ func (q *fakeQuerier) InsertProvisionerJob(_ context.Context, arg database.Inser
 		FileID:         arg.FileID,
 		Type:           arg.Type,
 		Input:          arg.Input,
+		Tags:           arg.Tags,
 	}
 	q.provisionerJobs = append(q.provisionerJobs, job)
 	return job, nil
@@ -2294,20 +2630,25 @@ func (q *fakeQuerier) InsertWorkspaceAgent(_ context.Context, arg database.Inser
 	defer q.mutex.Unlock()
 
 	agent := database.WorkspaceAgent{
-		ID:                   arg.ID,
-		CreatedAt:            arg.CreatedAt,
-		UpdatedAt:            arg.UpdatedAt,
-		ResourceID:           arg.ResourceID,
-		AuthToken:            arg.AuthToken,
-		AuthInstanceID:       arg.AuthInstanceID,
-		EnvironmentVariables: arg.EnvironmentVariables,
-		Name:                 arg.Name,
-		Architecture:         arg.Architecture,
-		OperatingSystem:      arg.OperatingSystem,
-		Directory:            arg.Directory,
-		StartupScript:        arg.StartupScript,
-		InstanceMetadata:     arg.InstanceMetadata,
-		ResourceMetadata:     arg.ResourceMetadata,
+		ID:                           arg.ID,
+		CreatedAt:                    arg.CreatedAt,
+		UpdatedAt:                    arg.UpdatedAt,
+		ResourceID:                   arg.ResourceID,
+		AuthToken:                    arg.AuthToken,
+		AuthInstanceID:               arg.AuthInstanceID,
+		EnvironmentVariables:         arg.EnvironmentVariables,
+		Name:                         arg.Name,
+		Architecture:                 arg.Architecture,
+		OperatingSystem:              arg.OperatingSystem,
+		Directory:                    arg.Directory,
+		StartupScript:                arg.StartupScript,
+		InstanceMetadata:             arg.InstanceMetadata,
+		ResourceMetadata:             arg.ResourceMetadata,
+		LifecycleState:               database.WorkspaceAgentLifecycleStateCreated,
+		StartupScriptTimeoutSeconds:  arg.StartupScriptTimeoutSeconds,
+		LoginBeforeReady:             arg.LoginBeforeReady,
+		ShutdownScript:               arg.ShutdownScript,
+		ShutdownScriptTimeoutSeconds: arg.ShutdownScriptTimeoutSeconds,
 	}
 
 	q.provisionerJobAgents = append(q.provisionerJobAgents, agent)
@@ -2368,6 +2709,8 @@ func (q *fakeQuerier) InsertUser(_ context.Context, arg database.InsertUserParam
 		Status:         database.UserStatusActive,
 		RBACRoles:      arg.RBACRoles,
 		LoginType:      arg.LoginType,
+		ServiceAccount: arg.ServiceAccount,
+		Contact:        arg.Contact,
 	}
 	q.users = append(q.users, user)
 	return user, nil
@@ -2452,6 +2795,21 @@ func (q *fakeQuerier) UpdateUserLastSeenAt(_ context.Context, arg database.Updat
 	return database.User{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateUserQuietHoursSchedule(_ context.Context, arg database.UpdateUserQuietHoursScheduleParams) (database.User, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, user := range q.users {
+		if user.ID != arg.ID {
+			continue
+		}
+		user.QuietHoursSchedule = arg.QuietHoursSchedule
+		q.users[index] = user
+		return user, nil
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateUserHashedPassword(_ context.Context, arg database.UpdateUserHashedPasswordParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2487,6 +2845,20 @@ func (q *fakeQuerier) InsertWorkspace(_ context.Context, arg database.InsertWork
 	return workspace, nil
 }
 
+func (q *fakeQuerier) InsertWorkspaceBuildParameters(_ context.Context, arg database.InsertWorkspaceBuildParametersParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, name := range arg.Name {
+		q.workspaceBuildParameters = append(q.workspaceBuildParameters, database.WorkspaceBuildParameter{
+			WorkspaceBuildID: arg.WorkspaceBuildID,
+			Name:             name,
+			Value:            arg.Value[index],
+		})
+	}
+	return nil
+}
+
 func (q *fakeQuerier) InsertWorkspaceBuild(_ context.Context, arg database.InsertWorkspaceBuildParams) (database.WorkspaceBuild, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2701,6 +3073,20 @@ func (q *fakeQuerier) UpdateWorkspaceAgentVersionByID(_ context.Context, arg dat
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceAgentLifecycleStateByID(_ context.Context, arg database.UpdateWorkspaceAgentLifecycleStateByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, agent := range q.provisionerJobAgents {
+		if agent.ID == arg.ID {
+			agent.LifecycleState = arg.LifecycleState
+			q.provisionerJobAgents[i] = agent
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateProvisionerJobByID(_ context.Context, arg database.UpdateProvisionerJobByIDParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2823,6 +3209,22 @@ func (q *fakeQuerier) UpdateWorkspaceLastUsedAt(_ context.Context, arg database.
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceDormantAt(_ context.Context, arg database.UpdateWorkspaceDormantAtParams) (database.Workspace, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, workspace := range q.workspaces {
+		if workspace.ID != arg.ID {
+			continue
+		}
+		workspace.DormantAt = arg.DormantAt
+		q.workspaces[index] = workspace
+		return workspace, nil
+	}
+
+	return database.Workspace{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateWorkspaceBuildByID(_ context.Context, arg database.UpdateWorkspaceBuildByIDParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2834,6 +3236,7 @@ func (q *fakeQuerier) UpdateWorkspaceBuildByID(_ context.Context, arg database.U
 		workspaceBuild.UpdatedAt = arg.UpdatedAt
 		workspaceBuild.ProvisionerState = arg.ProvisionerState
 		workspaceBuild.Deadline = arg.Deadline
+		workspaceBuild.MaxDeadline = arg.MaxDeadline
 		q.workspaceBuilds[index] = workspaceBuild
 		return nil
 	}
@@ -2932,6 +3335,40 @@ func (q *fakeQuerier) DeleteGroupMember(_ context.Context, userID uuid.UUID) err
 	return nil
 }
 
+func (q *fakeQuerier) DeleteGroupMembersByOrgAndUser(_ context.Context, arg database.DeleteGroupMembersByOrgAndUserParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	groupIDs := map[uuid.UUID]bool{}
+	for _, group := range q.groups {
+		if group.OrganizationID == arg.OrganizationID {
+			groupIDs[group.ID] = true
+		}
+	}
+	members := make([]database.GroupMember, 0, len(q.groupMembers))
+	for _, member := range q.groupMembers {
+		if member.UserID == arg.UserID && groupIDs[member.GroupID] {
+			continue
+		}
+		members = append(members, member)
+	}
+	q.groupMembers = members
+	return nil
+}
+
+func (q *fakeQuerier) DeleteGroupMemberFromGroup(_ context.Context, arg database.DeleteGroupMemberFromGroupParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, member := range q.groupMembers {
+		if member.UserID == arg.UserID && member.GroupID == arg.GroupID {
+			q.groupMembers = append(q.groupMembers[:i], q.groupMembers[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 func (q *fakeQuerier) UpdateGroupByID(_ context.Context, arg database.UpdateGroupByIDParams) (database.Group, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2995,6 +3432,9 @@ func (q *fakeQuerier) GetAuditLogsOffset(ctx context.Context, arg database.GetAu
 				continue
 			}
 		}
+		if arg.OrganizationID != uuid.Nil && alog.OrganizationID != arg.OrganizationID {
+			continue
+		}
 
 		user, err := q.GetUserByID(ctx, alog.UserID)
 		userValid := err == nil
@@ -3057,6 +3497,9 @@ func (q *fakeQuerier) GetAuditLogCount(_ context.Context, arg database.GetAuditL
 				continue
 			}
 		}
+		if arg.OrganizationID != uuid.Nil && alog.OrganizationID != arg.OrganizationID {
+			continue
+		}
 
 		logs = append(logs, alog)
 	}
@@ -3108,6 +3551,42 @@ func (q *fakeQuerier) GetDERPMeshKey(_ context.Context) (string, error) {
 	return q.derpMeshKey, nil
 }
 
+func (q *fakeQuerier) InsertAppSecurityKey(_ context.Context, data string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.appSecurityKey = data
+	return nil
+}
+
+func (q *fakeQuerier) GetAppSecurityKey(_ context.Context) (string, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.appSecurityKey == "" {
+		return "", sql.ErrNoRows
+	}
+	return q.appSecurityKey, nil
+}
+
+func (q *fakeQuerier) InsertSSHCertificateAuthorityKey(_ context.Context, data string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.sshCertificateAuthorityKey = data
+	return nil
+}
+
+func (q *fakeQuerier) GetSSHCertificateAuthorityKey(_ context.Context) (string, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.sshCertificateAuthorityKey == "" {
+		return "", sql.ErrNoRows
+	}
+	return q.sshCertificateAuthorityKey, nil
+}
+
 func (q *fakeQuerier) InsertLicense(
 	_ context.Context, arg database.InsertLicenseParams,
 ) (database.License, error) {
@@ -3299,8 +3778,23 @@ func (q *fakeQuerier) InsertGroup(_ context.Context, arg database.InsertGroupPar
 	return group, nil
 }
 
-func (*fakeQuerier) GetUserGroups(_ context.Context, _ uuid.UUID) ([]database.Group, error) {
-	panic("not implemented")
+func (q *fakeQuerier) GetUserGroups(_ context.Context, userID uuid.UUID) ([]database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	groups := make([]database.Group, 0)
+	for _, member := range q.groupMembers {
+		if member.UserID != userID {
+			continue
+		}
+		for _, group := range q.groups {
+			if group.ID == member.GroupID {
+				groups = append(groups, group)
+				break
+			}
+		}
+	}
+	return groups, nil
 }
 
 func (q *fakeQuerier) GetGroupMembers(_ context.Context, groupID uuid.UUID) ([]database.User, error) {
@@ -3492,3 +3986,394 @@ func (q *fakeQuerier) UpdateGitAuthLink(_ context.Context, arg database.UpdateGi
 	}
 	return nil
 }
+
+func (q *fakeQuerier) UpdateWorkspaceAgentStartupLogOverflowByID(_ context.Context, arg database.UpdateWorkspaceAgentStartupLogOverflowByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, agent := range q.provisionerJobAgents {
+		if agent.ID != arg.ID {
+			continue
+		}
+		agent.StartupLogsOverflowed = arg.StartupLogsOverflowed
+		q.provisionerJobAgents[index] = agent
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentStartupLogsAfter(_ context.Context, arg database.GetWorkspaceAgentStartupLogsAfterParams) ([]database.WorkspaceAgentStartupLog, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	logs := []database.WorkspaceAgentStartupLog{}
+	for _, log := range q.workspaceAgentStartupLogs {
+		if log.AgentID != arg.AgentID {
+			continue
+		}
+		if log.ID <= arg.CreatedAfter {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceAgentStartupLogs(_ context.Context, arg database.InsertWorkspaceAgentStartupLogsParams) ([]database.WorkspaceAgentStartupLog, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	logs := []database.WorkspaceAgentStartupLog{}
+	id := q.lastWorkspaceAgentStartupLogID
+	for index, output := range arg.Output {
+		id++
+		logs = append(logs, database.WorkspaceAgentStartupLog{
+			ID:        id,
+			AgentID:   arg.AgentID,
+			CreatedAt: arg.CreatedAt[index],
+			Output:    output,
+			EOF:       arg.EOF[index],
+		})
+	}
+	for index, agent := range q.provisionerJobAgents {
+		if agent.ID != arg.AgentID {
+			continue
+		}
+		// Greater than 1MB, same as the PostgreSQL constraint!
+		if agent.StartupLogsLength+arg.OutputLength > (1 << 20) {
+			return nil, &pq.Error{
+				Constraint: "max_startup_logs_length",
+				Table:      "workspace_agents",
+			}
+		}
+		agent.StartupLogsLength += arg.OutputLength
+		q.provisionerJobAgents[index] = agent
+		break
+	}
+	q.workspaceAgentStartupLogs = append(q.workspaceAgentStartupLogs, logs...)
+	q.lastWorkspaceAgentStartupLogID = id
+	return logs, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceAgentMetadata(_ context.Context, arg database.InsertWorkspaceAgentMetadataParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	metadatum := database.WorkspaceAgentMetadatum{
+		WorkspaceAgentID: arg.WorkspaceAgentID,
+		Script:           arg.Script,
+		DisplayName:      arg.DisplayName,
+		Key:              arg.Key,
+		Timeout:          arg.Timeout,
+		Interval:         arg.Interval,
+	}
+
+	q.workspaceAgentMetadata = append(q.workspaceAgentMetadata, metadatum)
+	return nil
+}
+
+func (q *fakeQuerier) UpdateWorkspaceAgentMetadata(_ context.Context, arg database.UpdateWorkspaceAgentMetadataParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, m := range q.workspaceAgentMetadata {
+		if m.WorkspaceAgentID == arg.WorkspaceAgentID && m.Key == arg.Key {
+			q.workspaceAgentMetadata[i].Value = arg.Value
+			q.workspaceAgentMetadata[i].Error = arg.Error
+			q.workspaceAgentMetadata[i].CollectedAt = arg.CollectedAt
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentMetadata(_ context.Context, workspaceAgentID uuid.UUID) ([]database.WorkspaceAgentMetadatum, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	metadata := make([]database.WorkspaceAgentMetadatum, 0)
+	for _, m := range q.workspaceAgentMetadata {
+		if m.WorkspaceAgentID == workspaceAgentID {
+			metadata = append(metadata, m)
+		}
+	}
+	return metadata, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceProxy(_ context.Context, arg database.InsertWorkspaceProxyParams) (database.WorkspaceProxy, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, p := range q.workspaceProxies {
+		if !p.Deleted && strings.EqualFold(p.Name, arg.Name) {
+			return database.WorkspaceProxy{}, errDuplicateKey
+		}
+	}
+
+	q.lastWorkspaceProxyRegionID++
+	p := database.WorkspaceProxy{
+		ID:                arg.ID,
+		Name:              arg.Name,
+		DisplayName:       arg.DisplayName,
+		Icon:              arg.Icon,
+		RegionID:          q.lastWorkspaceProxyRegionID,
+		TokenHashedSecret: arg.TokenHashedSecret,
+		CreatedAt:         arg.CreatedAt,
+		UpdatedAt:         arg.UpdatedAt,
+	}
+	q.workspaceProxies = append(q.workspaceProxies, p)
+	return p, nil
+}
+
+func (q *fakeQuerier) RegisterWorkspaceProxy(_ context.Context, arg database.RegisterWorkspaceProxyParams) (database.WorkspaceProxy, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, p := range q.workspaceProxies {
+		if p.ID == arg.ID {
+			p.Url = arg.Url
+			p.WildcardHostname = arg.WildcardHostname
+			p.DerpEnabled = arg.DerpEnabled
+			p.UpdatedAt = database.Now()
+			q.workspaceProxies[i] = p
+			return p, nil
+		}
+	}
+	return database.WorkspaceProxy{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UpdateWorkspaceProxyDeleted(_ context.Context, arg database.UpdateWorkspaceProxyDeletedParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, p := range q.workspaceProxies {
+		if p.ID == arg.ID {
+			p.Deleted = arg.Deleted
+			p.UpdatedAt = database.Now()
+			q.workspaceProxies[i] = p
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceProxyByID(_ context.Context, id uuid.UUID) (database.WorkspaceProxy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, p := range q.workspaceProxies {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return database.WorkspaceProxy{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceProxyByName(_ context.Context, name string) (database.WorkspaceProxy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, p := range q.workspaceProxies {
+		if !p.Deleted && strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return database.WorkspaceProxy{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceProxies(_ context.Context) ([]database.WorkspaceProxy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	proxies := make([]database.WorkspaceProxy, 0)
+	for _, p := range q.workspaceProxies {
+		if !p.Deleted {
+			proxies = append(proxies, p)
+		}
+	}
+	slices.SortFunc(proxies, func(a, b database.WorkspaceProxy) bool {
+		return a.Name < b.Name
+	})
+	return proxies, nil
+}
+
+func (q *fakeQuerier) GetRateLimitPolicies(_ context.Context) ([]database.RateLimitPolicy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	policies := slices.Clone(q.rateLimitPolicies)
+	slices.SortFunc(policies, func(a, b database.RateLimitPolicy) bool {
+		if a.SubjectType != b.SubjectType {
+			return a.SubjectType < b.SubjectType
+		}
+		return a.Subject < b.Subject
+	})
+	return policies, nil
+}
+
+func (q *fakeQuerier) GetRateLimitPolicyByID(_ context.Context, id uuid.UUID) (database.RateLimitPolicy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, p := range q.rateLimitPolicies {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return database.RateLimitPolicy{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UpsertRateLimitPolicy(_ context.Context, arg database.UpsertRateLimitPolicyParams) (database.RateLimitPolicy, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, p := range q.rateLimitPolicies {
+		if p.SubjectType == arg.SubjectType && p.Subject == arg.Subject {
+			p.Count = arg.Count
+			p.WindowSeconds = arg.WindowSeconds
+			p.UpdatedAt = arg.UpdatedAt
+			q.rateLimitPolicies[i] = p
+			return p, nil
+		}
+	}
+
+	p := database.RateLimitPolicy{
+		ID:            arg.ID,
+		SubjectType:   arg.SubjectType,
+		Subject:       arg.Subject,
+		Count:         arg.Count,
+		WindowSeconds: arg.WindowSeconds,
+		CreatedAt:     arg.CreatedAt,
+		UpdatedAt:     arg.UpdatedAt,
+	}
+	q.rateLimitPolicies = append(q.rateLimitPolicies, p)
+	return p, nil
+}
+
+func (q *fakeQuerier) DeleteRateLimitPolicyByID(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, p := range q.rateLimitPolicies {
+		if p.ID == id {
+			q.rateLimitPolicies = append(q.rateLimitPolicies[:i], q.rateLimitPolicies[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetCustomRoles(_ context.Context) ([]database.CustomRole, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	roles := slices.Clone(q.customRoles)
+	slices.SortFunc(roles, func(a, b database.CustomRole) bool {
+		return a.Name < b.Name
+	})
+	return roles, nil
+}
+
+func (q *fakeQuerier) GetCustomRoleByName(_ context.Context, arg database.GetCustomRoleByNameParams) (database.CustomRole, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, role := range q.customRoles {
+		if role.Name == arg.Name && role.OrganizationID == arg.OrganizationID {
+			return role, nil
+		}
+	}
+	return database.CustomRole{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertCustomRole(_ context.Context, arg database.InsertCustomRoleParams) (database.CustomRole, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, role := range q.customRoles {
+		if role.Name == arg.Name && role.OrganizationID == arg.OrganizationID {
+			return database.CustomRole{}, errDuplicateKey
+		}
+	}
+	role := database.CustomRole{
+		ID:              arg.ID,
+		Name:            arg.Name,
+		DisplayName:     arg.DisplayName,
+		OrganizationID:  arg.OrganizationID,
+		SitePermissions: arg.SitePermissions,
+		OrgPermissions:  arg.OrgPermissions,
+		UserPermissions: arg.UserPermissions,
+		CreatedAt:       arg.CreatedAt,
+		UpdatedAt:       arg.UpdatedAt,
+	}
+	q.customRoles = append(q.customRoles, role)
+	return role, nil
+}
+
+func (q *fakeQuerier) UpdateCustomRoleByID(_ context.Context, arg database.UpdateCustomRoleByIDParams) (database.CustomRole, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, role := range q.customRoles {
+		if role.ID != arg.ID {
+			continue
+		}
+		role.DisplayName = arg.DisplayName
+		role.SitePermissions = arg.SitePermissions
+		role.OrgPermissions = arg.OrgPermissions
+		role.UserPermissions = arg.UserPermissions
+		role.UpdatedAt = arg.UpdatedAt
+		q.customRoles[i] = role
+		return role, nil
+	}
+	return database.CustomRole{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteCustomRoleByID(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, role := range q.customRoles {
+		if role.ID == id {
+			q.customRoles = append(q.customRoles[:i], q.customRoles[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) RemoveRoleFromUsers(_ context.Context, roleName string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, user := range q.users {
+		roles := make([]string, 0, len(user.RBACRoles))
+		for _, role := range user.RBACRoles {
+			if role != roleName {
+				roles = append(roles, role)
+			}
+		}
+		q.users[i].RBACRoles = roles
+	}
+	return nil
+}
+
+func (q *fakeQuerier) RemoveRoleFromOrganizationMembers(_ context.Context, arg database.RemoveRoleFromOrganizationMembersParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, member := range q.organizationMembers {
+		if member.OrganizationID != arg.OrganizationID {
+			continue
+		}
+		roles := make([]string, 0, len(member.Roles))
+		for _, role := range member.Roles {
+			if role != arg.RoleName {
+				roles = append(roles, role)
+			}
+		}
+		q.organizationMembers[i].Roles = roles
+	}
+	return nil
+}
@@ -43,3 +43,50 @@ func (t *TemplateACL) Scan(src interface{}) error {
 func (t TemplateACL) Value() (driver.Value, error) {
 	return json.Marshal(t)
 }
+
+// StringMap is a map of string keys to string values, stored as a JSON
+// object.
+type StringMap map[string]string
+
+func (m *StringMap) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	switch src := src.(type) {
+	case []byte:
+		return json.Unmarshal(src, m)
+	case string:
+		return json.Unmarshal([]byte(src), m)
+	}
+	return xerrors.Errorf("unsupported Scan, storing driver.Value type %T into type %T", src, m)
+}
+
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		// Store an empty object rather than null so containment
+		// checks behave as expected.
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
+
+// CustomRolePermissions is a list of permissions of a custom role, stored as
+// a JSON array.
+type CustomRolePermissions []rbac.Permission
+
+func (p *CustomRolePermissions) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return json.Unmarshal(src, p)
+	case string:
+		return json.Unmarshal([]byte(src), p)
+	}
+	return xerrors.Errorf("unsupported Scan, storing driver.Value type %T into type %T", src, p)
+}
+
+func (p CustomRolePermissions) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(p)
+}
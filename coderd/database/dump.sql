@@ -22,7 +22,14 @@ CREATE TYPE audit_action AS ENUM (
 CREATE TYPE build_reason AS ENUM (
     'initiator',
     'autostart',
-    'autostop'
+    'autostop',
+    'dormancy',
+    'autodelete'
+);
+
+CREATE TYPE connection_stat_source AS ENUM (
+    'agent',
+    'client'
 );
 
 CREATE TYPE log_level AS ENUM (
@@ -82,6 +89,12 @@ CREATE TYPE provisioner_type AS ENUM (
     'terraform'
 );
 
+CREATE TYPE rate_limit_subject_type AS ENUM (
+    'role',
+    'scope',
+    'user'
+);
+
 CREATE TYPE resource_type AS ENUM (
     'organization',
     'template',
@@ -91,7 +104,17 @@ CREATE TYPE resource_type AS ENUM (
     'git_ssh_key',
     'api_key',
     'group',
-    'workspace_build'
+    'workspace_build',
+    'workspace_proxy',
+    'rate_limit_policy',
+    'session_recording',
+    'custom_role',
+    'organization_member'
+);
+
+CREATE TYPE session_recording_type AS ENUM (
+    'ssh',
+    'reconnecting_pty'
 );
 
 CREATE TYPE user_status AS ENUM (
@@ -99,6 +122,18 @@ CREATE TYPE user_status AS ENUM (
     'suspended'
 );
 
+CREATE TYPE workspace_agent_lifecycle_state AS ENUM (
+    'created',
+    'starting',
+    'start_timeout',
+    'start_error',
+    'ready',
+    'shutting_down',
+    'shutdown_timeout',
+    'shutdown_error',
+    'off'
+);
+
 CREATE TYPE workspace_app_health AS ENUM (
     'disabled',
     'initializing',
@@ -133,11 +168,14 @@ CREATE TABLE api_keys (
     login_type login_type NOT NULL,
     lifetime_seconds bigint DEFAULT 86400 NOT NULL,
     ip_address inet DEFAULT '0.0.0.0'::inet NOT NULL,
-    scope api_key_scope DEFAULT 'all'::public.api_key_scope NOT NULL
+    scope api_key_scope DEFAULT 'all'::public.api_key_scope NOT NULL,
+    scopes text[] DEFAULT '{}'::text[] NOT NULL
 );
 
 COMMENT ON COLUMN api_keys.hashed_secret IS 'hashed_secret contains a SHA256 hash of the key secret. This is considered a secret and MUST NOT be returned from the API as it is used for API key encryption in app proxying code.';
 
+COMMENT ON COLUMN api_keys.scopes IS 'scopes restrict the key to fine-grained scopes like "template:push". If set, they are used instead of scope.';
+
 CREATE TABLE audit_logs (
     id uuid NOT NULL,
     "time" timestamp with time zone NOT NULL,
@@ -156,6 +194,48 @@ CREATE TABLE audit_logs (
     resource_icon text NOT NULL
 );
 
+CREATE TABLE connection_stats (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    source connection_stat_source NOT NULL,
+    agent_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    peer_ip text NOT NULL,
+    direct boolean NOT NULL,
+    endpoint text NOT NULL,
+    derp_region_id integer NOT NULL,
+    latency_ms double precision NOT NULL,
+    rx_bytes bigint NOT NULL,
+    tx_bytes bigint NOT NULL
+);
+
+COMMENT ON COLUMN connection_stats.source IS 'Whether the record was reported by the agent or by the client that dialed it.';
+
+COMMENT ON COLUMN connection_stats.user_id IS 'The reporting user for client records, or the workspace owner for agent records.';
+
+COMMENT ON COLUMN connection_stats.endpoint IS 'The ip:port of the peer for direct connections. Empty if the connection is relayed through DERP.';
+
+COMMENT ON COLUMN connection_stats.derp_region_id IS 'The DERP region traffic is relayed through, or the home region of the peer for direct connections. 0 if unknown.';
+
+COMMENT ON COLUMN connection_stats.latency_ms IS 'Round trip time of a ping to the peer. 0 if the ping did not complete.';
+
+CREATE TABLE custom_roles (
+    id uuid NOT NULL,
+    name text NOT NULL,
+    display_name text NOT NULL,
+    organization_id uuid,
+    site_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    org_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    user_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE custom_roles IS 'Roles defined by administrators in addition to the built-in roles.';
+
+COMMENT ON COLUMN custom_roles.organization_id IS 'Organization the role is scoped to. Site wide roles have no organization.';
+
 CREATE TABLE files (
     hash character varying(64) NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -227,9 +307,12 @@ CREATE TABLE organizations (
     name text NOT NULL,
     description text NOT NULL,
     created_at timestamp with time zone NOT NULL,
-    updated_at timestamp with time zone NOT NULL
+    updated_at timestamp with time zone NOT NULL,
+    user_workspace_quota integer DEFAULT 0 NOT NULL
 );
 
+COMMENT ON COLUMN organizations.user_workspace_quota IS 'user_workspace_quota is the maximum number of workspaces each member can own in the organization. 0 uses the deployment wide quota.';
+
 CREATE TABLE parameter_schemas (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -268,9 +351,13 @@ CREATE TABLE provisioner_daemons (
     updated_at timestamp with time zone,
     name character varying(64) NOT NULL,
     provisioners provisioner_type[] NOT NULL,
-    replica_id uuid
+    replica_id uuid,
+    tags jsonb DEFAULT '{}'::jsonb NOT NULL,
+    organization_id uuid
 );
 
+COMMENT ON COLUMN provisioner_daemons.organization_id IS 'organization_id restricts the daemon to jobs of the organization. Daemons without an organization acquire jobs of all organizations.';
+
 CREATE TABLE provisioner_job_logs (
     id uuid NOT NULL,
     job_id uuid NOT NULL,
@@ -296,9 +383,24 @@ CREATE TABLE provisioner_jobs (
     type provisioner_job_type NOT NULL,
     input jsonb NOT NULL,
     worker_id uuid,
-    file_id uuid NOT NULL
+    file_id uuid NOT NULL,
+    tags jsonb DEFAULT '{}'::jsonb NOT NULL
+);
+
+CREATE TABLE rate_limit_policies (
+    id uuid NOT NULL,
+    subject_type rate_limit_subject_type NOT NULL,
+    subject text NOT NULL,
+    count integer NOT NULL,
+    window_seconds integer NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
 );
 
+COMMENT ON COLUMN rate_limit_policies.subject IS 'Role name, API key scope or user ID the policy applies to, depending on subject_type.';
+
+COMMENT ON COLUMN rate_limit_policies.count IS 'Number of requests allowed per endpoint in each window. -1 disables rate limiting.';
+
 CREATE TABLE replicas (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -318,6 +420,49 @@ CREATE TABLE site_configs (
     value character varying(8192) NOT NULL
 );
 
+CREATE TABLE template_version_parameters (
+    template_version_id uuid NOT NULL,
+    name text NOT NULL,
+    display_name text DEFAULT ''::text NOT NULL,
+    description text NOT NULL,
+    type text NOT NULL,
+    mutable boolean NOT NULL,
+    default_value text NOT NULL,
+    icon text NOT NULL,
+    options jsonb DEFAULT '[]'::jsonb NOT NULL,
+    validation_regex text NOT NULL,
+    validation_error text DEFAULT ''::text NOT NULL,
+    validation_min integer,
+    validation_max integer,
+    display_order integer DEFAULT 0 NOT NULL
+);
+
+COMMENT ON COLUMN template_version_parameters.name IS 'Parameter name';
+
+COMMENT ON COLUMN template_version_parameters.display_name IS 'Display name of the rich parameter';
+
+COMMENT ON COLUMN template_version_parameters.description IS 'Parameter description';
+
+COMMENT ON COLUMN template_version_parameters.type IS 'Parameter type: string, number, bool or list(string)';
+
+COMMENT ON COLUMN template_version_parameters.mutable IS 'Whether the value can be changed after the workspace is created';
+
+COMMENT ON COLUMN template_version_parameters.default_value IS 'Default value';
+
+COMMENT ON COLUMN template_version_parameters.icon IS 'Icon';
+
+COMMENT ON COLUMN template_version_parameters.options IS 'Additional options';
+
+COMMENT ON COLUMN template_version_parameters.validation_regex IS 'Validation: regex pattern';
+
+COMMENT ON COLUMN template_version_parameters.validation_error IS 'Validation: error displayed when the regex does not match';
+
+COMMENT ON COLUMN template_version_parameters.validation_min IS 'Validation: minimum value of a number parameter';
+
+COMMENT ON COLUMN template_version_parameters.validation_max IS 'Validation: maximum value of a number parameter';
+
+COMMENT ON COLUMN template_version_parameters.display_order IS 'Specifies the order in which to display parameters in user interfaces';
+
 CREATE TABLE template_versions (
     id uuid NOT NULL,
     template_id uuid,
@@ -345,9 +490,24 @@ CREATE TABLE templates (
     created_by uuid NOT NULL,
     icon character varying(256) DEFAULT ''::character varying NOT NULL,
     user_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
-    group_acl jsonb DEFAULT '{}'::jsonb NOT NULL
+    group_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
+    autostop_requirement_days bigint DEFAULT 0 NOT NULL,
+    inactivity_ttl bigint DEFAULT 0 NOT NULL,
+    dormant_delete_ttl bigint DEFAULT 0 NOT NULL,
+    port_forwarding_allowlist text[] DEFAULT '{}'::text[] NOT NULL,
+    record_sessions boolean DEFAULT false NOT NULL
 );
 
+COMMENT ON COLUMN templates.autostop_requirement_days IS 'Workspaces must be stopped at least once every this many days, during the owner''s quiet hours. 0 disables the requirement.';
+
+COMMENT ON COLUMN templates.inactivity_ttl IS 'Workspaces that have not been used for this long are marked dormant. 0 disables dormancy.';
+
+COMMENT ON COLUMN templates.dormant_delete_ttl IS 'Workspaces that have been dormant for this long are deleted. 0 disables automatic deletion.';
+
+COMMENT ON COLUMN templates.port_forwarding_allowlist IS 'Ports or port ranges, e.g. 5432 or 8000-8100, that may be forwarded with SSH -L and -R or over tailnet, e.g. by coder port-forward and apps. Empty allows all ports.';
+
+COMMENT ON COLUMN templates.record_sessions IS 'Whether workspace agents record terminal sessions and upload them to coderd when they end.';
+
 CREATE TABLE user_links (
     user_id uuid NOT NULL,
     login_type login_type NOT NULL,
@@ -369,9 +529,53 @@ CREATE TABLE users (
     login_type login_type DEFAULT 'password'::public.login_type NOT NULL,
     avatar_url text,
     deleted boolean DEFAULT false NOT NULL,
-    last_seen_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+    last_seen_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    quiet_hours_schedule text DEFAULT ''::text NOT NULL,
+    service_account boolean DEFAULT false NOT NULL,
+    contact text DEFAULT ''::text NOT NULL
 );
 
+COMMENT ON COLUMN users.quiet_hours_schedule IS 'Daily (!) cron schedule (with optional CRON_TZ) signifying the start of the user''s quiet hours. If empty, the default quiet hours on the instance is used instead.';
+
+COMMENT ON COLUMN users.service_account IS 'service_account users are used by automation. They cannot log in and only authenticate with tokens that expire.';
+
+COMMENT ON COLUMN users.contact IS 'contact is the person or team responsible for a service account.';
+
+CREATE TABLE workspace_agent_metadata (
+    workspace_agent_id uuid NOT NULL,
+    display_name text NOT NULL,
+    key text NOT NULL,
+    script text NOT NULL,
+    value text DEFAULT ''::text NOT NULL,
+    error text DEFAULT ''::text NOT NULL,
+    timeout bigint NOT NULL,
+    "interval" bigint NOT NULL,
+    collected_at timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL
+);
+
+COMMENT ON COLUMN workspace_agent_metadata.timeout IS 'Timeout of the metadata script in seconds';
+
+COMMENT ON COLUMN workspace_agent_metadata."interval" IS 'Interval between metadata script runs in seconds';
+
+CREATE TABLE workspace_agent_startup_logs (
+    agent_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    output character varying(1024) NOT NULL,
+    id bigint NOT NULL,
+    eof boolean DEFAULT false NOT NULL
+);
+
+COMMENT ON COLUMN workspace_agent_startup_logs.eof IS 'End of file reached';
+
+CREATE SEQUENCE workspace_agent_startup_logs_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE workspace_agent_startup_logs_id_seq OWNED BY public.workspace_agent_startup_logs.id;
+
 CREATE TABLE workspace_agents (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -390,11 +594,33 @@ CREATE TABLE workspace_agents (
     instance_metadata jsonb,
     resource_metadata jsonb,
     directory character varying(4096) DEFAULT ''::character varying NOT NULL,
-    version text DEFAULT ''::text NOT NULL
+    version text DEFAULT ''::text NOT NULL,
+    startup_logs_length integer DEFAULT 0 NOT NULL,
+    startup_logs_overflowed boolean DEFAULT false NOT NULL,
+    lifecycle_state workspace_agent_lifecycle_state DEFAULT 'created'::workspace_agent_lifecycle_state NOT NULL,
+    startup_script_timeout_seconds integer DEFAULT 0 NOT NULL,
+    login_before_ready boolean DEFAULT true NOT NULL,
+    shutdown_script character varying(65534),
+    shutdown_script_timeout_seconds integer DEFAULT 0 NOT NULL,
+    CONSTRAINT max_startup_logs_length CHECK ((startup_logs_length <= 1048576))
 );
 
 COMMENT ON COLUMN workspace_agents.version IS 'Version tracks the version of the currently running workspace agent. Workspace agents register their version upon start.';
 
+COMMENT ON COLUMN workspace_agents.startup_logs_length IS 'Total length of startup logs';
+
+COMMENT ON COLUMN workspace_agents.startup_logs_overflowed IS 'Whether the startup logs overflowed in length';
+
+COMMENT ON COLUMN workspace_agents.lifecycle_state IS 'The current lifecycle state reported by the workspace agent.';
+
+COMMENT ON COLUMN workspace_agents.startup_script_timeout_seconds IS 'The number of seconds to wait for the startup script to complete. If the script does not complete within this time, the agent lifecycle will be marked as start_timeout.';
+
+COMMENT ON COLUMN workspace_agents.login_before_ready IS 'If true, the agent will not prevent login before it is ready (e.g. startup script is still executing).';
+
+COMMENT ON COLUMN workspace_agents.shutdown_script IS 'Script that is executed before the agent is stopped.';
+
+COMMENT ON COLUMN workspace_agents.shutdown_script_timeout_seconds IS 'The number of seconds to wait for the shutdown script to complete. If the script does not complete within this time, the agent lifecycle will be marked as shutdown_timeout.';
+
 CREATE TABLE workspace_apps (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -412,6 +638,16 @@ CREATE TABLE workspace_apps (
     slug text NOT NULL
 );
 
+CREATE TABLE workspace_build_parameters (
+    workspace_build_id uuid NOT NULL,
+    name text NOT NULL,
+    value text NOT NULL
+);
+
+COMMENT ON COLUMN workspace_build_parameters.name IS 'Parameter name';
+
+COMMENT ON COLUMN workspace_build_parameters.value IS 'Parameter value';
+
 CREATE TABLE workspace_builds (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -424,9 +660,45 @@ CREATE TABLE workspace_builds (
     provisioner_state bytea,
     job_id uuid NOT NULL,
     deadline timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL,
-    reason build_reason DEFAULT 'initiator'::public.build_reason NOT NULL
+    reason build_reason DEFAULT 'initiator'::public.build_reason NOT NULL,
+    max_deadline timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL
 );
 
+COMMENT ON COLUMN workspace_builds.max_deadline IS 'The latest the workspace can be stopped by, enforced by the template''s autostop requirement. The deadline can''t be extended past it.';
+
+CREATE TABLE workspace_proxies (
+    id uuid NOT NULL,
+    name text NOT NULL,
+    display_name text NOT NULL,
+    icon text NOT NULL,
+    url text DEFAULT ''::text NOT NULL,
+    wildcard_hostname text DEFAULT ''::text NOT NULL,
+    derp_enabled boolean DEFAULT false NOT NULL,
+    region_id integer NOT NULL,
+    token_hashed_secret bytea NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    deleted boolean DEFAULT false NOT NULL
+);
+
+COMMENT ON COLUMN workspace_proxies.url IS 'Full URL including scheme of the proxy api url: https://us.example.com. Empty until the proxy registers.';
+
+COMMENT ON COLUMN workspace_proxies.wildcard_hostname IS 'Hostname with the wildcard for subdomain based app hosting: *.us.example.com';
+
+COMMENT ON COLUMN workspace_proxies.region_id IS 'Used to derive the DERP region ID of the proxy, so it must not be reused after a proxy is deleted.';
+
+COMMENT ON COLUMN workspace_proxies.token_hashed_secret IS 'Hashed secret is used to authenticate the workspace proxy using a session token.';
+
+CREATE SEQUENCE workspace_proxies_region_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE workspace_proxies_region_id_seq OWNED BY public.workspace_proxies.region_id;
+
 CREATE TABLE workspace_resource_metadata (
     workspace_resource_id uuid NOT NULL,
     key character varying(1024) NOT NULL,
@@ -446,6 +718,22 @@ CREATE TABLE workspace_resources (
     instance_type character varying(256)
 );
 
+CREATE TABLE workspace_session_recordings (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    workspace_id uuid NOT NULL,
+    agent_id uuid NOT NULL,
+    file_id uuid NOT NULL,
+    type session_recording_type NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone NOT NULL,
+    user_id uuid NOT NULL
+);
+
+COMMENT ON COLUMN workspace_session_recordings.file_id IS 'The asciicast v2 recording of the session.';
+
+COMMENT ON COLUMN workspace_session_recordings.user_id IS 'The user who connected to the session, or the workspace owner if the agent could not tell.';
+
 CREATE TABLE workspaces (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -457,11 +745,18 @@ CREATE TABLE workspaces (
     name character varying(64) NOT NULL,
     autostart_schedule text,
     ttl bigint,
-    last_used_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+    last_used_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    dormant_at timestamp with time zone
 );
 
+COMMENT ON COLUMN workspaces.dormant_at IS 'The time the workspace was marked dormant. Dormant workspaces cannot be started until they are activated again.';
+
 ALTER TABLE ONLY licenses ALTER COLUMN id SET DEFAULT nextval('public.licenses_id_seq'::regclass);
 
+ALTER TABLE ONLY workspace_agent_startup_logs ALTER COLUMN id SET DEFAULT nextval('public.workspace_agent_startup_logs_id_seq'::regclass);
+
+ALTER TABLE ONLY workspace_proxies ALTER COLUMN region_id SET DEFAULT nextval('public.workspace_proxies_region_id_seq'::regclass);
+
 ALTER TABLE ONLY agent_stats
     ADD CONSTRAINT agent_stats_pkey PRIMARY KEY (id);
 
@@ -471,6 +766,12 @@ ALTER TABLE ONLY api_keys
 ALTER TABLE ONLY audit_logs
     ADD CONSTRAINT audit_logs_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY connection_stats
+    ADD CONSTRAINT connection_stats_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY custom_roles
+    ADD CONSTRAINT custom_roles_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY files
     ADD CONSTRAINT files_hash_created_by_key UNIQUE (hash, created_by);
 
@@ -528,9 +829,18 @@ ALTER TABLE ONLY provisioner_job_logs
 ALTER TABLE ONLY provisioner_jobs
     ADD CONSTRAINT provisioner_jobs_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY rate_limit_policies
+    ADD CONSTRAINT rate_limit_policies_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY rate_limit_policies
+    ADD CONSTRAINT rate_limit_policies_subject_type_subject_key UNIQUE (subject_type, subject);
+
 ALTER TABLE ONLY site_configs
     ADD CONSTRAINT site_configs_key_key UNIQUE (key);
 
+ALTER TABLE ONLY template_version_parameters
+    ADD CONSTRAINT template_version_parameters_template_version_id_name_key UNIQUE (template_version_id, name);
+
 ALTER TABLE ONLY template_versions
     ADD CONSTRAINT template_versions_pkey PRIMARY KEY (id);
 
@@ -546,6 +856,12 @@ ALTER TABLE ONLY user_links
 ALTER TABLE ONLY users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_agent_metadata
+    ADD CONSTRAINT workspace_agent_metadata_pkey PRIMARY KEY (workspace_agent_id, key);
+
+ALTER TABLE ONLY workspace_agent_startup_logs
+    ADD CONSTRAINT workspace_agent_startup_logs_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspace_agents
     ADD CONSTRAINT workspace_agents_pkey PRIMARY KEY (id);
 
@@ -555,6 +871,9 @@ ALTER TABLE ONLY workspace_apps
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_build_parameters
+    ADD CONSTRAINT workspace_build_parameters_workspace_build_id_name_key UNIQUE (workspace_build_id, name);
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_key UNIQUE (job_id);
 
@@ -564,15 +883,25 @@ ALTER TABLE ONLY workspace_builds
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_workspace_id_build_number_key UNIQUE (workspace_id, build_number);
 
+ALTER TABLE ONLY workspace_proxies
+    ADD CONSTRAINT workspace_proxies_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspace_resource_metadata
     ADD CONSTRAINT workspace_resource_metadata_pkey PRIMARY KEY (workspace_resource_id, key);
 
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_pkey PRIMARY KEY (id);
 
+CREATE UNIQUE INDEX custom_roles_name_idx ON custom_roles USING btree (name) WHERE (organization_id IS NULL);
+
+CREATE UNIQUE INDEX custom_roles_organization_id_name_idx ON custom_roles USING btree (organization_id, name) WHERE (organization_id IS NOT NULL);
+
 CREATE INDEX idx_agent_stats_created_at ON agent_stats USING btree (created_at);
 
 CREATE INDEX idx_agent_stats_user_id ON agent_stats USING btree (user_id);
@@ -587,6 +916,10 @@ CREATE INDEX idx_audit_log_user_id ON audit_logs USING btree (user_id);
 
 CREATE INDEX idx_audit_logs_time_desc ON audit_logs USING btree ("time" DESC);
 
+CREATE INDEX idx_connection_stats_agent_id ON connection_stats USING btree (agent_id);
+
+CREATE INDEX idx_connection_stats_created_at ON connection_stats USING btree (created_at);
+
 CREATE INDEX idx_organization_member_organization_id_uuid ON organization_members USING btree (organization_id);
 
 CREATE INDEX idx_organization_member_user_id_uuid ON organization_members USING btree (user_id);
@@ -599,17 +932,26 @@ CREATE UNIQUE INDEX idx_users_email ON users USING btree (email) WHERE (deleted
 
 CREATE UNIQUE INDEX idx_users_username ON users USING btree (username) WHERE (deleted = false);
 
+CREATE INDEX idx_workspace_session_recordings_workspace_id ON workspace_session_recordings USING btree (workspace_id);
+
 CREATE UNIQUE INDEX templates_organization_id_name_idx ON templates USING btree (organization_id, lower((name)::text)) WHERE (deleted = false);
 
 CREATE UNIQUE INDEX users_email_lower_idx ON users USING btree (lower(email)) WHERE (deleted = false);
 
 CREATE UNIQUE INDEX users_username_lower_idx ON users USING btree (lower(username)) WHERE (deleted = false);
 
+CREATE INDEX workspace_agent_startup_logs_id_agent_id_idx ON workspace_agent_startup_logs USING btree (agent_id, id);
+
+CREATE UNIQUE INDEX workspace_proxies_lower_name_idx ON workspace_proxies USING btree (lower(name)) WHERE (deleted = false);
+
 CREATE UNIQUE INDEX workspaces_owner_id_lower_idx ON workspaces USING btree (owner_id, lower((name)::text)) WHERE (deleted = false);
 
 ALTER TABLE ONLY api_keys
     ADD CONSTRAINT api_keys_user_id_uuid_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY custom_roles
+    ADD CONSTRAINT custom_roles_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY gitsshkeys
     ADD CONSTRAINT gitsshkeys_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
 
@@ -631,12 +973,18 @@ ALTER TABLE ONLY organization_members
 ALTER TABLE ONLY parameter_schemas
     ADD CONSTRAINT parameter_schemas_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY provisioner_daemons
+    ADD CONSTRAINT provisioner_daemons_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY provisioner_job_logs
     ADD CONSTRAINT provisioner_job_logs_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
 ALTER TABLE ONLY provisioner_jobs
     ADD CONSTRAINT provisioner_jobs_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY template_version_parameters
+    ADD CONSTRAINT template_version_parameters_template_version_id_fkey FOREIGN KEY (template_version_id) REFERENCES template_versions(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY template_versions
     ADD CONSTRAINT template_versions_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE RESTRICT;
 
@@ -655,12 +1003,21 @@ ALTER TABLE ONLY templates
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_agent_metadata
+    ADD CONSTRAINT workspace_agent_metadata_workspace_agent_id_fkey FOREIGN KEY (workspace_agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_agent_startup_logs
+    ADD CONSTRAINT workspace_agent_startup_logs_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_agents
     ADD CONSTRAINT workspace_agents_resource_id_fkey FOREIGN KEY (resource_id) REFERENCES workspace_resources(id) ON DELETE CASCADE;
 
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_build_parameters
+    ADD CONSTRAINT workspace_build_parameters_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
@@ -676,6 +1033,15 @@ ALTER TABLE ONLY workspace_resource_metadata
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_file_id_fkey FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
 
@@ -27,3 +27,14 @@ func IsUniqueViolation(err error, uniqueConstraints ...UniqueConstraint) bool {
 
 	return false
 }
+
+// IsStartupLogsLimitError returns true if the error is a check violation
+// caused by the total length of startup logs exceeding the limit.
+func IsStartupLogsLimitError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint == "max_startup_logs_length" && pqErr.Table == "workspace_agents"
+	}
+
+	return false
+}
@@ -0,0 +1,3 @@
+ALTER TABLE workspace_agents DROP COLUMN startup_logs_overflowed;
+ALTER TABLE workspace_agents DROP COLUMN startup_logs_length;
+DROP TABLE IF EXISTS workspace_agent_startup_logs;
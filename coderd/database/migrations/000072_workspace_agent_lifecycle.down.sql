@@ -0,0 +1,5 @@
+ALTER TABLE workspace_agents DROP COLUMN login_before_ready;
+ALTER TABLE workspace_agents DROP COLUMN startup_script_timeout_seconds;
+ALTER TABLE workspace_agents DROP COLUMN lifecycle_state;
+
+DROP TYPE workspace_agent_lifecycle_state;
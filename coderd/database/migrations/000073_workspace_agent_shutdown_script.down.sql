@@ -0,0 +1,12 @@
+ALTER TABLE workspace_agents DROP COLUMN shutdown_script_timeout_seconds;
+ALTER TABLE workspace_agents DROP COLUMN shutdown_script;
+
+-- Values can't be removed from an enum, so the type is recreated
+-- without the shutdown result states.
+UPDATE workspace_agents SET lifecycle_state = 'off' WHERE lifecycle_state IN ('shutdown_timeout', 'shutdown_error');
+ALTER TABLE workspace_agents ALTER COLUMN lifecycle_state DROP DEFAULT;
+ALTER TYPE workspace_agent_lifecycle_state RENAME TO old_workspace_agent_lifecycle_state;
+CREATE TYPE workspace_agent_lifecycle_state AS ENUM ('created', 'starting', 'start_timeout', 'start_error', 'ready', 'shutting_down', 'off');
+ALTER TABLE workspace_agents ALTER COLUMN lifecycle_state TYPE workspace_agent_lifecycle_state USING (lifecycle_state::text::workspace_agent_lifecycle_state);
+ALTER TABLE workspace_agents ALTER COLUMN lifecycle_state SET DEFAULT 'created';
+DROP TYPE old_workspace_agent_lifecycle_state;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS template_version_parameters (
+	template_version_id uuid NOT NULL REFERENCES template_versions (id) ON DELETE CASCADE,
+	name text NOT NULL,
+	display_name text NOT NULL DEFAULT '',
+	description text NOT NULL,
+	type text NOT NULL,
+	mutable boolean NOT NULL,
+	default_value text NOT NULL,
+	icon text NOT NULL,
+	options jsonb NOT NULL DEFAULT '[]'::jsonb,
+	validation_regex text NOT NULL,
+	validation_error text NOT NULL DEFAULT '',
+	validation_min integer,
+	validation_max integer,
+	display_order integer NOT NULL DEFAULT 0,
+	UNIQUE (template_version_id, name)
+);
+
+COMMENT ON COLUMN template_version_parameters.name IS 'Parameter name';
+COMMENT ON COLUMN template_version_parameters.display_name IS 'Display name of the rich parameter';
+COMMENT ON COLUMN template_version_parameters.description IS 'Parameter description';
+COMMENT ON COLUMN template_version_parameters.type IS 'Parameter type: string, number, bool or list(string)';
+COMMENT ON COLUMN template_version_parameters.mutable IS 'Whether the value can be changed after the workspace is created';
+COMMENT ON COLUMN template_version_parameters.default_value IS 'Default value';
+COMMENT ON COLUMN template_version_parameters.icon IS 'Icon';
+COMMENT ON COLUMN template_version_parameters.options IS 'Additional options';
+COMMENT ON COLUMN template_version_parameters.validation_regex IS 'Validation: regex pattern';
+COMMENT ON COLUMN template_version_parameters.validation_error IS 'Validation: error displayed when the regex does not match';
+COMMENT ON COLUMN template_version_parameters.validation_min IS 'Validation: minimum value of a number parameter';
+COMMENT ON COLUMN template_version_parameters.validation_max IS 'Validation: maximum value of a number parameter';
+COMMENT ON COLUMN template_version_parameters.display_order IS 'Specifies the order in which to display parameters in user interfaces';
+
+CREATE TABLE IF NOT EXISTS workspace_build_parameters (
+	workspace_build_id uuid NOT NULL REFERENCES workspace_builds (id) ON DELETE CASCADE,
+	name text NOT NULL,
+	value text NOT NULL,
+	UNIQUE (workspace_build_id, name)
+);
+
+COMMENT ON COLUMN workspace_build_parameters.name IS 'Parameter name';
+COMMENT ON COLUMN workspace_build_parameters.value IS 'Parameter value';
@@ -0,0 +1,18 @@
+-- A table to store metadata for each workspace agent. The script, interval
+-- and timeout are defined by the template, the value, error and collected_at
+-- are reported by the agent.
+CREATE TABLE workspace_agent_metadata (
+	workspace_agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+	display_name text NOT NULL,
+	key text NOT NULL,
+	script text NOT NULL,
+	value text NOT NULL DEFAULT '',
+	error text NOT NULL DEFAULT '',
+	timeout bigint NOT NULL,
+	interval bigint NOT NULL,
+	collected_at timestamp with time zone NOT NULL DEFAULT '0001-01-01 00:00:00+00',
+	PRIMARY KEY (workspace_agent_id, key)
+);
+
+COMMENT ON COLUMN workspace_agent_metadata.timeout IS 'Timeout of the metadata script in seconds';
+COMMENT ON COLUMN workspace_agent_metadata.interval IS 'Interval between metadata script runs in seconds';
@@ -0,0 +1,5 @@
+ALTER TABLE workspace_builds DROP COLUMN max_deadline;
+
+ALTER TABLE users DROP COLUMN quiet_hours_schedule;
+
+ALTER TABLE templates DROP COLUMN autostop_requirement_days;
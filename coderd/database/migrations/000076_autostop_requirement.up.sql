@@ -0,0 +1,11 @@
+ALTER TABLE templates ADD COLUMN autostop_requirement_days bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN templates.autostop_requirement_days IS 'Workspaces must be stopped at least once every this many days, during the owner''s quiet hours. 0 disables the requirement.';
+
+ALTER TABLE users ADD COLUMN quiet_hours_schedule text NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN users.quiet_hours_schedule IS 'Daily (!) cron schedule (with optional CRON_TZ) signifying the start of the user''s quiet hours. If empty, the default quiet hours on the instance is used instead.';
+
+ALTER TABLE workspace_builds ADD COLUMN max_deadline timestamp with time zone NOT NULL DEFAULT '0001-01-01 00:00:00+00';
+
+COMMENT ON COLUMN workspace_builds.max_deadline IS 'The latest the workspace can be stopped by, enforced by the template''s autostop requirement. The deadline can''t be extended past it.';
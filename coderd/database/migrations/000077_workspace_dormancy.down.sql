@@ -0,0 +1,7 @@
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
+
+ALTER TABLE workspaces DROP COLUMN dormant_at;
+
+ALTER TABLE templates DROP COLUMN dormant_delete_ttl;
+ALTER TABLE templates DROP COLUMN inactivity_ttl;
@@ -0,0 +1,12 @@
+ALTER TABLE templates ADD COLUMN inactivity_ttl bigint NOT NULL DEFAULT 0;
+ALTER TABLE templates ADD COLUMN dormant_delete_ttl bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN templates.inactivity_ttl IS 'Workspaces that have not been used for this long are marked dormant. 0 disables dormancy.';
+COMMENT ON COLUMN templates.dormant_delete_ttl IS 'Workspaces that have been dormant for this long are deleted. 0 disables automatic deletion.';
+
+ALTER TABLE workspaces ADD COLUMN dormant_at timestamp with time zone NULL;
+
+COMMENT ON COLUMN workspaces.dormant_at IS 'The time the workspace was marked dormant. Dormant workspaces cannot be started until they are activated again.';
+
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'dormancy';
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'autodelete';
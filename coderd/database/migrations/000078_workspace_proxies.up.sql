@@ -0,0 +1,24 @@
+CREATE TABLE workspace_proxies (
+    id uuid NOT NULL,
+    name text NOT NULL,
+    display_name text NOT NULL,
+    icon text NOT NULL,
+    url text DEFAULT ''::text NOT NULL,
+    wildcard_hostname text DEFAULT ''::text NOT NULL,
+    derp_enabled boolean DEFAULT false NOT NULL,
+    region_id serial NOT NULL,
+    token_hashed_secret bytea NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    deleted boolean DEFAULT false NOT NULL,
+    PRIMARY KEY (id)
+);
+
+COMMENT ON COLUMN workspace_proxies.url IS 'Full URL including scheme of the proxy api url: https://us.example.com. Empty until the proxy registers.';
+COMMENT ON COLUMN workspace_proxies.wildcard_hostname IS 'Hostname with the wildcard for subdomain based app hosting: *.us.example.com';
+COMMENT ON COLUMN workspace_proxies.region_id IS 'Used to derive the DERP region ID of the proxy, so it must not be reused after a proxy is deleted.';
+COMMENT ON COLUMN workspace_proxies.token_hashed_secret IS 'Hashed secret is used to authenticate the workspace proxy using a session token.';
+
+CREATE UNIQUE INDEX workspace_proxies_lower_name_idx ON workspace_proxies USING btree (lower(name)) WHERE (deleted = false);
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'workspace_proxy';
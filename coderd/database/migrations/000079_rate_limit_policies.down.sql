@@ -0,0 +1,6 @@
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
+
+DROP TABLE rate_limit_policies;
+
+DROP TYPE rate_limit_subject_type;
@@ -0,0 +1,22 @@
+CREATE TYPE rate_limit_subject_type AS ENUM (
+    'role',
+    'scope',
+    'user'
+);
+
+CREATE TABLE rate_limit_policies (
+    id uuid NOT NULL,
+    subject_type rate_limit_subject_type NOT NULL,
+    subject text NOT NULL,
+    count integer NOT NULL,
+    window_seconds integer NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE (subject_type, subject)
+);
+
+COMMENT ON COLUMN rate_limit_policies.subject IS 'Role name, API key scope or user ID the policy applies to, depending on subject_type.';
+COMMENT ON COLUMN rate_limit_policies.count IS 'Number of requests allowed per endpoint in each window. -1 disables rate limiting.';
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'rate_limit_policy';
@@ -0,0 +1,3 @@
+DROP TABLE connection_stats;
+
+DROP TYPE connection_stat_source;
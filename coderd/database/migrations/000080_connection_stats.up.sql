@@ -0,0 +1,31 @@
+CREATE TYPE connection_stat_source AS ENUM (
+    'agent',
+    'client'
+);
+
+CREATE TABLE connection_stats (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    source connection_stat_source NOT NULL,
+    agent_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    peer_ip text NOT NULL,
+    direct boolean NOT NULL,
+    endpoint text NOT NULL,
+    derp_region_id integer NOT NULL,
+    latency_ms double precision NOT NULL,
+    rx_bytes bigint NOT NULL,
+    tx_bytes bigint NOT NULL,
+    PRIMARY KEY (id)
+);
+
+COMMENT ON COLUMN connection_stats.source IS 'Whether the record was reported by the agent or by the client that dialed it.';
+COMMENT ON COLUMN connection_stats.user_id IS 'The reporting user for client records, or the workspace owner for agent records.';
+COMMENT ON COLUMN connection_stats.endpoint IS 'The ip:port of the peer for direct connections. Empty if the connection is relayed through DERP.';
+COMMENT ON COLUMN connection_stats.derp_region_id IS 'The DERP region traffic is relayed through, or the home region of the peer for direct connections. 0 if unknown.';
+COMMENT ON COLUMN connection_stats.latency_ms IS 'Round trip time of a ping to the peer. 0 if the ping did not complete.';
+
+CREATE INDEX idx_connection_stats_created_at ON connection_stats USING btree (created_at);
+
+CREATE INDEX idx_connection_stats_agent_id ON connection_stats USING btree (agent_id);
@@ -0,0 +1 @@
+ALTER TABLE templates DROP COLUMN port_forwarding_allowlist;
@@ -0,0 +1,8 @@
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
+
+DROP TABLE workspace_session_recordings;
+
+DROP TYPE session_recording_type;
+
+ALTER TABLE templates DROP COLUMN record_sessions;
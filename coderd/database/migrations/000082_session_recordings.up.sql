@@ -0,0 +1,26 @@
+ALTER TABLE templates ADD COLUMN record_sessions boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN templates.record_sessions IS 'Whether workspace agents record terminal sessions and upload them to coderd when they end.';
+
+CREATE TYPE session_recording_type AS ENUM (
+    'ssh',
+    'reconnecting_pty'
+);
+
+CREATE TABLE workspace_session_recordings (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    agent_id uuid NOT NULL,
+    file_id uuid NOT NULL REFERENCES files (id) ON DELETE CASCADE,
+    type session_recording_type NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (id)
+);
+
+COMMENT ON COLUMN workspace_session_recordings.file_id IS 'The asciicast v2 recording of the session.';
+
+CREATE INDEX idx_workspace_session_recordings_workspace_id ON workspace_session_recordings USING btree (workspace_id);
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'session_recording';
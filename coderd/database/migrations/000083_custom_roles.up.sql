@@ -0,0 +1,22 @@
+CREATE TABLE custom_roles (
+    id uuid NOT NULL,
+    name text NOT NULL,
+    display_name text NOT NULL,
+    organization_id uuid REFERENCES organizations (id) ON DELETE CASCADE,
+    site_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    org_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    user_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE custom_roles IS 'Roles defined by administrators in addition to the built-in roles.';
+
+COMMENT ON COLUMN custom_roles.organization_id IS 'Organization the role is scoped to. Site wide roles have no organization.';
+
+CREATE UNIQUE INDEX custom_roles_name_idx ON custom_roles USING btree (name) WHERE (organization_id IS NULL);
+
+CREATE UNIQUE INDEX custom_roles_organization_id_name_idx ON custom_roles USING btree (organization_id, name) WHERE (organization_id IS NOT NULL);
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'custom_role';
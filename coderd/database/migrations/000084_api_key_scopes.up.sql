@@ -0,0 +1,3 @@
+ALTER TABLE api_keys ADD COLUMN scopes text[] DEFAULT '{}'::text[] NOT NULL;
+
+COMMENT ON COLUMN api_keys.scopes IS 'scopes restrict the key to fine-grained scopes like "template:push". If set, they are used instead of scope.';
@@ -0,0 +1,3 @@
+ALTER TABLE users
+	DROP COLUMN service_account,
+	DROP COLUMN contact;
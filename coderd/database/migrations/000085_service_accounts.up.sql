@@ -0,0 +1,7 @@
+ALTER TABLE users
+	ADD COLUMN service_account boolean DEFAULT false NOT NULL,
+	ADD COLUMN contact text DEFAULT ''::text NOT NULL;
+
+COMMENT ON COLUMN users.service_account IS 'service_account users are used by automation. They cannot log in and only authenticate with tokens that expire.';
+
+COMMENT ON COLUMN users.contact IS 'contact is the person or team responsible for a service account.';
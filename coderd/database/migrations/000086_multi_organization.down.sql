@@ -0,0 +1,5 @@
+ALTER TABLE organizations
+	DROP COLUMN user_workspace_quota;
+
+ALTER TABLE provisioner_daemons
+	DROP COLUMN organization_id;
@@ -0,0 +1,9 @@
+ALTER TABLE provisioner_daemons
+	ADD COLUMN organization_id uuid REFERENCES organizations(id) ON DELETE CASCADE;
+
+COMMENT ON COLUMN provisioner_daemons.organization_id IS 'organization_id restricts the daemon to jobs of the organization. Daemons without an organization acquire jobs of all organizations.';
+
+ALTER TABLE organizations
+	ADD COLUMN user_workspace_quota integer DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN organizations.user_workspace_quota IS 'user_workspace_quota is the maximum number of workspaces each member can own in the organization. 0 uses the deployment wide quota.';
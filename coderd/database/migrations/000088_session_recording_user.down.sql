@@ -0,0 +1 @@
+ALTER TABLE workspace_session_recordings DROP COLUMN user_id;
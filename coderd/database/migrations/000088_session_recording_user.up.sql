@@ -0,0 +1,11 @@
+ALTER TABLE workspace_session_recordings ADD COLUMN user_id uuid REFERENCES users (id) ON DELETE CASCADE;
+
+-- Recordings were attributed to the workspace owner before.
+UPDATE workspace_session_recordings
+SET user_id = workspaces.owner_id
+FROM workspaces
+WHERE workspaces.id = workspace_session_recordings.workspace_id;
+
+ALTER TABLE workspace_session_recordings ALTER COLUMN user_id SET NOT NULL;
+
+COMMENT ON COLUMN workspace_session_recordings.user_id IS 'The user who connected to the session, or the workspace owner if the agent could not tell.';
@@ -0,0 +1 @@
+COMMENT ON COLUMN templates.port_forwarding_allowlist IS 'Ports or port ranges, e.g. 5432 or 8000-8100, that SSH clients may forward with -L and -R. Empty allows all ports.';
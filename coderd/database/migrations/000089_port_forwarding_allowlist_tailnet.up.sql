@@ -0,0 +1 @@
+COMMENT ON COLUMN templates.port_forwarding_allowlist IS 'Ports or port ranges, e.g. 5432 or 8000-8100, that may be forwarded with SSH -L and -R or over tailnet, e.g. by coder port-forward and apps. Empty allows all ports.';
@@ -1,7 +1,10 @@
 package database
 
 import (
+	"time"
+
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/google/uuid"
 )
 
 const AllUsersGroup = "Everyone"
@@ -17,9 +20,19 @@ func (s APIKeyScope) ToRBAC() rbac.Scope {
 	}
 }
 
+// RBACScope returns the scope the key is restricted to. Fine-grained scopes
+// take precedence over the scope enum.
+func (k APIKey) RBACScope() rbac.Scope {
+	if len(k.Scopes) > 0 {
+		return rbac.ScopeList(k.Scopes)
+	}
+	return k.Scope.ToRBAC()
+}
+
 func (t Template) RBACObject() rbac.Object {
 	obj := rbac.ResourceTemplate
-	return obj.InOrg(t.OrganizationID).
+	return obj.WithID(t.ID).
+		InOrg(t.OrganizationID).
 		WithACLUserList(t.UserACL).
 		WithGroupACL(t.GroupACL)
 }
@@ -34,7 +47,7 @@ func (g Group) RBACObject() rbac.Object {
 }
 
 func (w Workspace) RBACObject() rbac.Object {
-	return rbac.ResourceWorkspace.InOrg(w.OrganizationID).WithOwner(w.OwnerID.String())
+	return rbac.ResourceWorkspace.WithID(w.ID).InOrg(w.OrganizationID).WithOwner(w.OwnerID.String())
 }
 
 func (w Workspace) ExecutionRBAC() rbac.Object {
@@ -49,12 +62,39 @@ func (m OrganizationMember) RBACObject() rbac.Object {
 	return rbac.ResourceOrganizationMember.InOrg(m.OrganizationID)
 }
 
+// AuditableOrganizationMember is an organization member with the username of
+// the user, which audit logs show as the target.
+type AuditableOrganizationMember struct {
+	UserID         uuid.UUID `json:"user_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Username       string    `json:"username"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Roles          []string  `json:"roles"`
+}
+
+func (m OrganizationMember) Auditable(username string) AuditableOrganizationMember {
+	return AuditableOrganizationMember{
+		UserID:         m.UserID,
+		OrganizationID: m.OrganizationID,
+		Username:       username,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+		Roles:          m.Roles,
+	}
+}
+
 func (o Organization) RBACObject() rbac.Object {
 	return rbac.ResourceOrganization.InOrg(o.ID)
 }
 
-func (ProvisionerDaemon) RBACObject() rbac.Object {
-	return rbac.ResourceProvisionerDaemon
+func (d ProvisionerDaemon) RBACObject() rbac.Object {
+	obj := rbac.ResourceProvisionerDaemon.WithID(d.ID)
+	// Daemons of an organization are visible to its admins.
+	if d.OrganizationID.Valid {
+		return obj.InOrg(d.OrganizationID.UUID)
+	}
+	return obj
 }
 
 func (f File) RBACObject() rbac.Object {
@@ -71,3 +111,37 @@ func (User) RBACObject() rbac.Object {
 func (License) RBACObject() rbac.Object {
 	return rbac.ResourceLicense
 }
+
+func (r CustomRole) RBACObject() rbac.Object {
+	if r.OrganizationID.Valid {
+		return rbac.ResourceCustomRole.InOrg(r.OrganizationID.UUID)
+	}
+	return rbac.ResourceCustomRole
+}
+
+// RoleName is the name the role is assigned by. Org roles are suffixed with
+// the organization like the builtin org roles.
+func (r CustomRole) RoleName() string {
+	if r.OrganizationID.Valid {
+		return r.Name + ":" + r.OrganizationID.UUID.String()
+	}
+	return r.Name
+}
+
+// ToRBAC returns the role for the authorizer. Org roles only grant
+// permissions in their organization.
+func (r CustomRole) ToRBAC() rbac.Role {
+	role := rbac.Role{
+		Name:        r.RoleName(),
+		DisplayName: r.DisplayName,
+	}
+	if r.OrganizationID.Valid {
+		role.Org = map[string][]rbac.Permission{
+			r.OrganizationID.UUID.String(): r.OrgPermissions,
+		}
+		return role
+	}
+	role.Site = r.SitePermissions
+	role.User = r.UserPermissions
+	return role
+}
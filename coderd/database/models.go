@@ -79,9 +79,11 @@ func (e *AuditAction) Scan(src interface{}) error {
 type BuildReason string
 
 const (
-	BuildReasonInitiator BuildReason = "initiator"
-	BuildReasonAutostart BuildReason = "autostart"
-	BuildReasonAutostop  BuildReason = "autostop"
+	BuildReasonInitiator  BuildReason = "initiator"
+	BuildReasonAutostart  BuildReason = "autostart"
+	BuildReasonAutostop   BuildReason = "autostop"
+	BuildReasonDormancy   BuildReason = "dormancy"
+	BuildReasonAutodelete BuildReason = "autodelete"
 )
 
 func (e *BuildReason) Scan(src interface{}) error {
@@ -96,6 +98,25 @@ func (e *BuildReason) Scan(src interface{}) error {
 	return nil
 }
 
+type ConnectionStatSource string
+
+const (
+	ConnectionStatSourceAgent  ConnectionStatSource = "agent"
+	ConnectionStatSourceClient ConnectionStatSource = "client"
+)
+
+func (e *ConnectionStatSource) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ConnectionStatSource(s)
+	case string:
+		*e = ConnectionStatSource(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ConnectionStatSource: %T", src)
+	}
+	return nil
+}
+
 type LogLevel string
 
 const (
@@ -293,18 +314,43 @@ func (e *ProvisionerType) Scan(src interface{}) error {
 	return nil
 }
 
+type RateLimitSubjectType string
+
+const (
+	RateLimitSubjectTypeRole  RateLimitSubjectType = "role"
+	RateLimitSubjectTypeScope RateLimitSubjectType = "scope"
+	RateLimitSubjectTypeUser  RateLimitSubjectType = "user"
+)
+
+func (e *RateLimitSubjectType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RateLimitSubjectType(s)
+	case string:
+		*e = RateLimitSubjectType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RateLimitSubjectType: %T", src)
+	}
+	return nil
+}
+
 type ResourceType string
 
 const (
-	ResourceTypeOrganization    ResourceType = "organization"
-	ResourceTypeTemplate        ResourceType = "template"
-	ResourceTypeTemplateVersion ResourceType = "template_version"
-	ResourceTypeUser            ResourceType = "user"
-	ResourceTypeWorkspace       ResourceType = "workspace"
-	ResourceTypeGitSshKey       ResourceType = "git_ssh_key"
-	ResourceTypeApiKey          ResourceType = "api_key"
-	ResourceTypeGroup           ResourceType = "group"
-	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
+	ResourceTypeOrganization       ResourceType = "organization"
+	ResourceTypeTemplate           ResourceType = "template"
+	ResourceTypeTemplateVersion    ResourceType = "template_version"
+	ResourceTypeUser               ResourceType = "user"
+	ResourceTypeWorkspace          ResourceType = "workspace"
+	ResourceTypeGitSshKey          ResourceType = "git_ssh_key"
+	ResourceTypeApiKey             ResourceType = "api_key"
+	ResourceTypeGroup              ResourceType = "group"
+	ResourceTypeWorkspaceBuild     ResourceType = "workspace_build"
+	ResourceTypeWorkspaceProxy     ResourceType = "workspace_proxy"
+	ResourceTypeRateLimitPolicy    ResourceType = "rate_limit_policy"
+	ResourceTypeSessionRecording   ResourceType = "session_recording"
+	ResourceTypeCustomRole         ResourceType = "custom_role"
+	ResourceTypeOrganizationMember ResourceType = "organization_member"
 )
 
 func (e *ResourceType) Scan(src interface{}) error {
@@ -319,6 +365,25 @@ func (e *ResourceType) Scan(src interface{}) error {
 	return nil
 }
 
+type SessionRecordingType string
+
+const (
+	SessionRecordingTypeSsh             SessionRecordingType = "ssh"
+	SessionRecordingTypeReconnectingPty SessionRecordingType = "reconnecting_pty"
+)
+
+func (e *SessionRecordingType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SessionRecordingType(s)
+	case string:
+		*e = SessionRecordingType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SessionRecordingType: %T", src)
+	}
+	return nil
+}
+
 type UserStatus string
 
 const (
@@ -338,6 +403,32 @@ func (e *UserStatus) Scan(src interface{}) error {
 	return nil
 }
 
+type WorkspaceAgentLifecycleState string
+
+const (
+	WorkspaceAgentLifecycleStateCreated         WorkspaceAgentLifecycleState = "created"
+	WorkspaceAgentLifecycleStateStarting        WorkspaceAgentLifecycleState = "starting"
+	WorkspaceAgentLifecycleStateStartTimeout    WorkspaceAgentLifecycleState = "start_timeout"
+	WorkspaceAgentLifecycleStateStartError      WorkspaceAgentLifecycleState = "start_error"
+	WorkspaceAgentLifecycleStateReady           WorkspaceAgentLifecycleState = "ready"
+	WorkspaceAgentLifecycleStateShuttingDown    WorkspaceAgentLifecycleState = "shutting_down"
+	WorkspaceAgentLifecycleStateShutdownTimeout WorkspaceAgentLifecycleState = "shutdown_timeout"
+	WorkspaceAgentLifecycleStateShutdownError   WorkspaceAgentLifecycleState = "shutdown_error"
+	WorkspaceAgentLifecycleStateOff             WorkspaceAgentLifecycleState = "off"
+)
+
+func (e *WorkspaceAgentLifecycleState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceAgentLifecycleState(s)
+	case string:
+		*e = WorkspaceAgentLifecycleState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceAgentLifecycleState: %T", src)
+	}
+	return nil
+}
+
 type WorkspaceAppHealth string
 
 const (
@@ -392,6 +483,8 @@ type APIKey struct {
 	LifetimeSeconds int64       `db:"lifetime_seconds" json:"lifetime_seconds"`
 	IPAddress       pqtype.Inet `db:"ip_address" json:"ip_address"`
 	Scope           APIKeyScope `db:"scope" json:"scope"`
+	// scopes restrict the key to fine-grained scopes like "template:push". If set, they are used instead of scope.
+	Scopes []string `db:"scopes" json:"scopes"`
 }
 
 type AgentStat struct {
@@ -422,6 +515,41 @@ type AuditLog struct {
 	ResourceIcon     string          `db:"resource_icon" json:"resource_icon"`
 }
 
+type ConnectionStat struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	// Whether the record was reported by the agent or by the client that dialed it.
+	Source      ConnectionStatSource `db:"source" json:"source"`
+	AgentID     uuid.UUID            `db:"agent_id" json:"agent_id"`
+	WorkspaceID uuid.UUID            `db:"workspace_id" json:"workspace_id"`
+	// The reporting user for client records, or the workspace owner for agent records.
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	PeerIp string    `db:"peer_ip" json:"peer_ip"`
+	Direct bool      `db:"direct" json:"direct"`
+	// The ip:port of the peer for direct connections. Empty if the connection is relayed through DERP.
+	Endpoint string `db:"endpoint" json:"endpoint"`
+	// The DERP region traffic is relayed through, or the home region of the peer for direct connections. 0 if unknown.
+	DerpRegionID int32 `db:"derp_region_id" json:"derp_region_id"`
+	// Round trip time of a ping to the peer. 0 if the ping did not complete.
+	LatencyMs float64 `db:"latency_ms" json:"latency_ms"`
+	RxBytes   int64   `db:"rx_bytes" json:"rx_bytes"`
+	TxBytes   int64   `db:"tx_bytes" json:"tx_bytes"`
+}
+
+// Roles defined by administrators in addition to the built-in roles.
+type CustomRole struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	DisplayName string    `db:"display_name" json:"display_name"`
+	// Organization the role is scoped to. Site wide roles have no organization.
+	OrganizationID  uuid.NullUUID         `db:"organization_id" json:"organization_id"`
+	SitePermissions CustomRolePermissions `db:"site_permissions" json:"site_permissions"`
+	OrgPermissions  CustomRolePermissions `db:"org_permissions" json:"org_permissions"`
+	UserPermissions CustomRolePermissions `db:"user_permissions" json:"user_permissions"`
+	CreatedAt       time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
+}
+
 type File struct {
 	Hash      string    `db:"hash" json:"hash"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
@@ -475,6 +603,8 @@ type Organization struct {
 	Description string    `db:"description" json:"description"`
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	// user_workspace_quota is the maximum number of workspaces each member can own in the organization. 0 uses the deployment wide quota.
+	UserWorkspaceQuota int32 `db:"user_workspace_quota" json:"user_workspace_quota"`
 }
 
 type OrganizationMember struct {
@@ -524,6 +654,9 @@ type ProvisionerDaemon struct {
 	Name         string            `db:"name" json:"name"`
 	Provisioners []ProvisionerType `db:"provisioners" json:"provisioners"`
 	ReplicaID    uuid.NullUUID     `db:"replica_id" json:"replica_id"`
+	Tags         StringMap         `db:"tags" json:"tags"`
+	// organization_id restricts the daemon to jobs of the organization. Daemons without an organization acquire jobs of all organizations.
+	OrganizationID uuid.NullUUID `db:"organization_id" json:"organization_id"`
 }
 
 type ProvisionerJob struct {
@@ -542,6 +675,7 @@ type ProvisionerJob struct {
 	Input          json.RawMessage          `db:"input" json:"input"`
 	WorkerID       uuid.NullUUID            `db:"worker_id" json:"worker_id"`
 	FileID         uuid.UUID                `db:"file_id" json:"file_id"`
+	Tags           StringMap                `db:"tags" json:"tags"`
 }
 
 type ProvisionerJobLog struct {
@@ -554,6 +688,18 @@ type ProvisionerJobLog struct {
 	Output    string    `db:"output" json:"output"`
 }
 
+type RateLimitPolicy struct {
+	ID          uuid.UUID            `db:"id" json:"id"`
+	SubjectType RateLimitSubjectType `db:"subject_type" json:"subject_type"`
+	// Role name, API key scope or user ID the policy applies to, depending on subject_type.
+	Subject string `db:"subject" json:"subject"`
+	// Number of requests allowed per endpoint in each window. -1 disables rate limiting.
+	Count         int32     `db:"count" json:"count"`
+	WindowSeconds int32     `db:"window_seconds" json:"window_seconds"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
 type Replica struct {
 	ID              uuid.UUID    `db:"id" json:"id"`
 	CreatedAt       time.Time    `db:"created_at" json:"created_at"`
@@ -589,6 +735,16 @@ type Template struct {
 	Icon                 string          `db:"icon" json:"icon"`
 	UserACL              TemplateACL     `db:"user_acl" json:"user_acl"`
 	GroupACL             TemplateACL     `db:"group_acl" json:"group_acl"`
+	// Workspaces must be stopped at least once every this many days, during the owner's quiet hours. 0 disables the requirement.
+	AutostopRequirementDays int64 `db:"autostop_requirement_days" json:"autostop_requirement_days"`
+	// Workspaces that have not been used for this long are marked dormant. 0 disables dormancy.
+	InactivityTtl int64 `db:"inactivity_ttl" json:"inactivity_ttl"`
+	// Workspaces that have been dormant for this long are deleted. 0 disables automatic deletion.
+	DormantDeleteTtl int64 `db:"dormant_delete_ttl" json:"dormant_delete_ttl"`
+	// Ports or port ranges, e.g. 5432 or 8000-8100, that may be forwarded with SSH -L and -R or over tailnet, e.g. by coder port-forward and apps. Empty allows all ports.
+	PortForwardingAllowlist []string `db:"port_forwarding_allowlist" json:"port_forwarding_allowlist"`
+	// Whether workspace agents record terminal sessions and upload them to coderd when they end.
+	RecordSessions bool `db:"record_sessions" json:"record_sessions"`
 }
 
 type TemplateVersion struct {
@@ -603,6 +759,36 @@ type TemplateVersion struct {
 	CreatedBy      uuid.UUID     `db:"created_by" json:"created_by"`
 }
 
+type TemplateVersionParameter struct {
+	TemplateVersionID uuid.UUID `db:"template_version_id" json:"template_version_id"`
+	// Parameter name
+	Name string `db:"name" json:"name"`
+	// Display name of the rich parameter
+	DisplayName string `db:"display_name" json:"display_name"`
+	// Parameter description
+	Description string `db:"description" json:"description"`
+	// Parameter type: string, number, bool or list(string)
+	Type string `db:"type" json:"type"`
+	// Whether the value can be changed after the workspace is created
+	Mutable bool `db:"mutable" json:"mutable"`
+	// Default value
+	DefaultValue string `db:"default_value" json:"default_value"`
+	// Icon
+	Icon string `db:"icon" json:"icon"`
+	// Additional options
+	Options json.RawMessage `db:"options" json:"options"`
+	// Validation: regex pattern
+	ValidationRegex string `db:"validation_regex" json:"validation_regex"`
+	// Validation: error displayed when the regex does not match
+	ValidationError string `db:"validation_error" json:"validation_error"`
+	// Validation: minimum value of a number parameter
+	ValidationMin sql.NullInt32 `db:"validation_min" json:"validation_min"`
+	// Validation: maximum value of a number parameter
+	ValidationMax sql.NullInt32 `db:"validation_max" json:"validation_max"`
+	// Specifies the order in which to display parameters in user interfaces
+	DisplayOrder int32 `db:"display_order" json:"display_order"`
+}
+
 type User struct {
 	ID             uuid.UUID      `db:"id" json:"id"`
 	Email          string         `db:"email" json:"email"`
@@ -616,6 +802,12 @@ type User struct {
 	AvatarURL      sql.NullString `db:"avatar_url" json:"avatar_url"`
 	Deleted        bool           `db:"deleted" json:"deleted"`
 	LastSeenAt     time.Time      `db:"last_seen_at" json:"last_seen_at"`
+	// Daily (!) cron schedule (with optional CRON_TZ) signifying the start of the user's quiet hours. If empty, the default quiet hours on the instance is used instead.
+	QuietHoursSchedule string `db:"quiet_hours_schedule" json:"quiet_hours_schedule"`
+	// service_account users are used by automation. They cannot log in and only authenticate with tokens that expire.
+	ServiceAccount bool `db:"service_account" json:"service_account"`
+	// contact is the person or team responsible for a service account.
+	Contact string `db:"contact" json:"contact"`
 }
 
 type UserLink struct {
@@ -639,6 +831,8 @@ type Workspace struct {
 	AutostartSchedule sql.NullString `db:"autostart_schedule" json:"autostart_schedule"`
 	Ttl               sql.NullInt64  `db:"ttl" json:"ttl"`
 	LastUsedAt        time.Time      `db:"last_used_at" json:"last_used_at"`
+	// The time the workspace was marked dormant. Dormant workspaces cannot be started until they are activated again.
+	DormantAt sql.NullTime `db:"dormant_at" json:"dormant_at"`
 }
 
 type WorkspaceAgent struct {
@@ -661,6 +855,43 @@ type WorkspaceAgent struct {
 	Directory            string                `db:"directory" json:"directory"`
 	// Version tracks the version of the currently running workspace agent. Workspace agents register their version upon start.
 	Version string `db:"version" json:"version"`
+	// Total length of startup logs
+	StartupLogsLength int32 `db:"startup_logs_length" json:"startup_logs_length"`
+	// Whether the startup logs overflowed in length
+	StartupLogsOverflowed bool `db:"startup_logs_overflowed" json:"startup_logs_overflowed"`
+	// The current lifecycle state reported by the workspace agent.
+	LifecycleState WorkspaceAgentLifecycleState `db:"lifecycle_state" json:"lifecycle_state"`
+	// The number of seconds to wait for the startup script to complete. If the script does not complete within this time, the agent lifecycle will be marked as start_timeout.
+	StartupScriptTimeoutSeconds int32 `db:"startup_script_timeout_seconds" json:"startup_script_timeout_seconds"`
+	// If true, the agent will not prevent login before it is ready (e.g. startup script is still executing).
+	LoginBeforeReady bool `db:"login_before_ready" json:"login_before_ready"`
+	// Script that is executed before the agent is stopped.
+	ShutdownScript sql.NullString `db:"shutdown_script" json:"shutdown_script"`
+	// The number of seconds to wait for the shutdown script to complete. If the script does not complete within this time, the agent lifecycle will be marked as shutdown_timeout.
+	ShutdownScriptTimeoutSeconds int32 `db:"shutdown_script_timeout_seconds" json:"shutdown_script_timeout_seconds"`
+}
+
+type WorkspaceAgentMetadatum struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	DisplayName      string    `db:"display_name" json:"display_name"`
+	Key              string    `db:"key" json:"key"`
+	Script           string    `db:"script" json:"script"`
+	Value            string    `db:"value" json:"value"`
+	Error            string    `db:"error" json:"error"`
+	// Timeout of the metadata script in seconds
+	Timeout int64 `db:"timeout" json:"timeout"`
+	// Interval between metadata script runs in seconds
+	Interval    int64     `db:"interval" json:"interval"`
+	CollectedAt time.Time `db:"collected_at" json:"collected_at"`
+}
+
+type WorkspaceAgentStartupLog struct {
+	AgentID   uuid.UUID `db:"agent_id" json:"agent_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	Output    string    `db:"output" json:"output"`
+	ID        int64     `db:"id" json:"id"`
+	// End of file reached
+	EOF bool `db:"eof" json:"eof"`
 }
 
 type WorkspaceApp struct {
@@ -693,6 +924,35 @@ type WorkspaceBuild struct {
 	JobID             uuid.UUID           `db:"job_id" json:"job_id"`
 	Deadline          time.Time           `db:"deadline" json:"deadline"`
 	Reason            BuildReason         `db:"reason" json:"reason"`
+	// The latest the workspace can be stopped by, enforced by the template's autostop requirement. The deadline can't be extended past it.
+	MaxDeadline time.Time `db:"max_deadline" json:"max_deadline"`
+}
+
+type WorkspaceBuildParameter struct {
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	// Parameter name
+	Name string `db:"name" json:"name"`
+	// Parameter value
+	Value string `db:"value" json:"value"`
+}
+
+type WorkspaceProxy struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	DisplayName string    `db:"display_name" json:"display_name"`
+	Icon        string    `db:"icon" json:"icon"`
+	// Full URL including scheme of the proxy api url: https://us.example.com. Empty until the proxy registers.
+	Url string `db:"url" json:"url"`
+	// Hostname with the wildcard for subdomain based app hosting: *.us.example.com
+	WildcardHostname string `db:"wildcard_hostname" json:"wildcard_hostname"`
+	DerpEnabled      bool   `db:"derp_enabled" json:"derp_enabled"`
+	// Used to derive the DERP region ID of the proxy, so it must not be reused after a proxy is deleted.
+	RegionID int32 `db:"region_id" json:"region_id"`
+	// Hashed secret is used to authenticate the workspace proxy using a session token.
+	TokenHashedSecret []byte    `db:"token_hashed_secret" json:"token_hashed_secret"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+	Deleted           bool      `db:"deleted" json:"deleted"`
 }
 
 type WorkspaceResource struct {
@@ -713,3 +973,17 @@ type WorkspaceResourceMetadatum struct {
 	Value               sql.NullString `db:"value" json:"value"`
 	Sensitive           bool           `db:"sensitive" json:"sensitive"`
 }
+
+type WorkspaceSessionRecording struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID `db:"agent_id" json:"agent_id"`
+	// The asciicast v2 recording of the session.
+	FileID    uuid.UUID            `db:"file_id" json:"file_id"`
+	Type      SessionRecordingType `db:"type" json:"type"`
+	StartedAt time.Time            `db:"started_at" json:"started_at"`
+	EndedAt   time.Time            `db:"ended_at" json:"ended_at"`
+	// The user who connected to the session, or the workspace owner if the agent could not tell.
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+}
@@ -159,7 +159,7 @@ func (q *sqlQuerier) DeleteAPIKeysByUserID(ctx context.Context, userID uuid.UUID
 
 const getAPIKeyByID = `-- name: GetAPIKeyByID :one
 SELECT
-	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope
+	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, scopes
 FROM
 	api_keys
 WHERE
@@ -183,12 +183,13 @@ func (q *sqlQuerier) GetAPIKeyByID(ctx context.Context, id string) (APIKey, erro
 		&i.LifetimeSeconds,
 		&i.IPAddress,
 		&i.Scope,
+		pq.Array(&i.Scopes),
 	)
 	return i, err
 }
 
 const getAPIKeysByLoginType = `-- name: GetAPIKeysByLoginType :many
-SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope FROM api_keys WHERE login_type = $1
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, scopes FROM api_keys WHERE login_type = $1
 `
 
 func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginType) ([]APIKey, error) {
@@ -212,6 +213,7 @@ func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginT
 			&i.LifetimeSeconds,
 			&i.IPAddress,
 			&i.Scope,
+			pq.Array(&i.Scopes),
 		); err != nil {
 			return nil, err
 		}
@@ -227,7 +229,7 @@ func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginT
 }
 
 const getAPIKeysLastUsedAfter = `-- name: GetAPIKeysLastUsedAfter :many
-SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope FROM api_keys WHERE last_used > $1
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, scopes FROM api_keys WHERE last_used > $1
 `
 
 func (q *sqlQuerier) GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.Time) ([]APIKey, error) {
@@ -251,6 +253,7 @@ func (q *sqlQuerier) GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.
 			&i.LifetimeSeconds,
 			&i.IPAddress,
 			&i.Scope,
+			pq.Array(&i.Scopes),
 		); err != nil {
 			return nil, err
 		}
@@ -278,7 +281,8 @@ INSERT INTO
 		created_at,
 		updated_at,
 		login_type,
-		scope
+		scope,
+		scopes
 	)
 VALUES
 	($1,
@@ -287,7 +291,9 @@ VALUES
 	     WHEN 0 THEN 86400
 		 ELSE $2::bigint
 	 END
-	 , $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope
+	 , $3, $4, $5, $6, $7, $8, $9, $10, $11,
+	 -- Keys without fine-grained scopes store an empty list.
+	 COALESCE($12 :: text[], '{}' :: text[])) RETURNING id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, scopes
 `
 
 type InsertAPIKeyParams struct {
@@ -302,6 +308,7 @@ type InsertAPIKeyParams struct {
 	UpdatedAt       time.Time   `db:"updated_at" json:"updated_at"`
 	LoginType       LoginType   `db:"login_type" json:"login_type"`
 	Scope           APIKeyScope `db:"scope" json:"scope"`
+	Scopes          []string    `db:"scopes" json:"scopes"`
 }
 
 func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (APIKey, error) {
@@ -317,6 +324,7 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 		arg.UpdatedAt,
 		arg.LoginType,
 		arg.Scope,
+		pq.Array(arg.Scopes),
 	)
 	var i APIKey
 	err := row.Scan(
@@ -331,6 +339,7 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 		&i.LifetimeSeconds,
 		&i.IPAddress,
 		&i.Scope,
+		pq.Array(&i.Scopes),
 	)
 	return i, err
 }
@@ -405,6 +414,12 @@ WHERE
 			user_id = (SELECT id from users WHERE users.email = $6 )
 		ELSE true
 	END
+	-- Filter by organization_id
+	AND CASE
+		WHEN $7 :: uuid != '00000000-00000000-00000000-00000000' THEN
+			organization_id = $7
+		ELSE true
+	END
 `
 
 type GetAuditLogCountParams struct {
@@ -414,6 +429,7 @@ type GetAuditLogCountParams struct {
 	Action         string    `db:"action" json:"action"`
 	Username       string    `db:"username" json:"username"`
 	Email          string    `db:"email" json:"email"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
 }
 
 func (q *sqlQuerier) GetAuditLogCount(ctx context.Context, arg GetAuditLogCountParams) (int64, error) {
@@ -424,6 +440,7 @@ func (q *sqlQuerier) GetAuditLogCount(ctx context.Context, arg GetAuditLogCountP
 		arg.Action,
 		arg.Username,
 		arg.Email,
+		arg.OrganizationID,
 	)
 	var count int64
 	err := row.Scan(&count)
@@ -480,6 +497,12 @@ WHERE
 			users.email = $8
 		ELSE true
 	END
+	-- Filter by organization_id
+	AND CASE
+		WHEN $9 :: uuid != '00000000-00000000-00000000-00000000' THEN
+			audit_logs.organization_id = $9
+		ELSE true
+	END
 ORDER BY
     "time" DESC
 LIMIT
@@ -497,6 +520,7 @@ type GetAuditLogsOffsetParams struct {
 	Action         string    `db:"action" json:"action"`
 	Username       string    `db:"username" json:"username"`
 	Email          string    `db:"email" json:"email"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
 }
 
 type GetAuditLogsOffsetRow struct {
@@ -535,6 +559,7 @@ func (q *sqlQuerier) GetAuditLogsOffset(ctx context.Context, arg GetAuditLogsOff
 		arg.Action,
 		arg.Username,
 		arg.Email,
+		arg.OrganizationID,
 	)
 	if err != nil {
 		return nil, err
@@ -659,6 +684,420 @@ func (q *sqlQuerier) InsertAuditLog(ctx context.Context, arg InsertAuditLogParam
 	return i, err
 }
 
+const deleteOldConnectionStats = `-- name: DeleteOldConnectionStats :exec
+DELETE FROM connection_stats WHERE created_at < now() - interval '30 days'
+`
+
+func (q *sqlQuerier) DeleteOldConnectionStats(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteOldConnectionStats)
+	return err
+}
+
+const getConnectionStatsByAgentID = `-- name: GetConnectionStatsByAgentID :many
+SELECT
+	id, created_at, source, agent_id, workspace_id, user_id, peer_ip, direct, endpoint, derp_region_id, latency_ms, rx_bytes, tx_bytes
+FROM
+	connection_stats
+WHERE
+	agent_id = $1
+	AND created_at > $2
+ORDER BY
+	created_at DESC
+`
+
+type GetConnectionStatsByAgentIDParams struct {
+	AgentID   uuid.UUID `db:"agent_id" json:"agent_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) GetConnectionStatsByAgentID(ctx context.Context, arg GetConnectionStatsByAgentIDParams) ([]ConnectionStat, error) {
+	rows, err := q.db.QueryContext(ctx, getConnectionStatsByAgentID, arg.AgentID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ConnectionStat
+	for rows.Next() {
+		var i ConnectionStat
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Source,
+			&i.AgentID,
+			&i.WorkspaceID,
+			&i.UserID,
+			&i.PeerIp,
+			&i.Direct,
+			&i.Endpoint,
+			&i.DerpRegionID,
+			&i.LatencyMs,
+			&i.RxBytes,
+			&i.TxBytes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getConnectionStatsByRegion = `-- name: GetConnectionStatsByRegion :many
+SELECT
+	derp_region_id,
+	source,
+	count(DISTINCT agent_id::text || peer_ip) AS connections,
+	count(DISTINCT agent_id::text || peer_ip) FILTER (WHERE direct) AS direct_connections,
+	coalesce(avg(latency_ms) FILTER (WHERE latency_ms > 0), 0)::double precision AS average_latency_ms
+FROM
+	connection_stats
+WHERE
+	created_at > $1
+GROUP BY
+	derp_region_id, source
+ORDER BY
+	derp_region_id, source
+`
+
+type GetConnectionStatsByRegionRow struct {
+	DerpRegionID      int32                `db:"derp_region_id" json:"derp_region_id"`
+	Source            ConnectionStatSource `db:"source" json:"source"`
+	Connections       int64                `db:"connections" json:"connections"`
+	DirectConnections int64                `db:"direct_connections" json:"direct_connections"`
+	AverageLatencyMs  float64              `db:"average_latency_ms" json:"average_latency_ms"`
+}
+
+// Connections are identified by the agent and the peer's IP, since every
+// connection is reported repeatedly while it's open. A connection counts as
+// direct if it was direct in any of its reports.
+func (q *sqlQuerier) GetConnectionStatsByRegion(ctx context.Context, createdAt time.Time) ([]GetConnectionStatsByRegionRow, error) {
+	rows, err := q.db.QueryContext(ctx, getConnectionStatsByRegion, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetConnectionStatsByRegionRow
+	for rows.Next() {
+		var i GetConnectionStatsByRegionRow
+		if err := rows.Scan(
+			&i.DerpRegionID,
+			&i.Source,
+			&i.Connections,
+			&i.DirectConnections,
+			&i.AverageLatencyMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertConnectionStat = `-- name: InsertConnectionStat :one
+INSERT INTO
+	connection_stats (
+		id,
+		created_at,
+		source,
+		agent_id,
+		workspace_id,
+		user_id,
+		peer_ip,
+		direct,
+		endpoint,
+		derp_region_id,
+		latency_ms,
+		rx_bytes,
+		tx_bytes
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at, source, agent_id, workspace_id, user_id, peer_ip, direct, endpoint, derp_region_id, latency_ms, rx_bytes, tx_bytes
+`
+
+type InsertConnectionStatParams struct {
+	ID           uuid.UUID            `db:"id" json:"id"`
+	CreatedAt    time.Time            `db:"created_at" json:"created_at"`
+	Source       ConnectionStatSource `db:"source" json:"source"`
+	AgentID      uuid.UUID            `db:"agent_id" json:"agent_id"`
+	WorkspaceID  uuid.UUID            `db:"workspace_id" json:"workspace_id"`
+	UserID       uuid.UUID            `db:"user_id" json:"user_id"`
+	PeerIp       string               `db:"peer_ip" json:"peer_ip"`
+	Direct       bool                 `db:"direct" json:"direct"`
+	Endpoint     string               `db:"endpoint" json:"endpoint"`
+	DerpRegionID int32                `db:"derp_region_id" json:"derp_region_id"`
+	LatencyMs    float64              `db:"latency_ms" json:"latency_ms"`
+	RxBytes      int64                `db:"rx_bytes" json:"rx_bytes"`
+	TxBytes      int64                `db:"tx_bytes" json:"tx_bytes"`
+}
+
+func (q *sqlQuerier) InsertConnectionStat(ctx context.Context, arg InsertConnectionStatParams) (ConnectionStat, error) {
+	row := q.db.QueryRowContext(ctx, insertConnectionStat,
+		arg.ID,
+		arg.CreatedAt,
+		arg.Source,
+		arg.AgentID,
+		arg.WorkspaceID,
+		arg.UserID,
+		arg.PeerIp,
+		arg.Direct,
+		arg.Endpoint,
+		arg.DerpRegionID,
+		arg.LatencyMs,
+		arg.RxBytes,
+		arg.TxBytes,
+	)
+	var i ConnectionStat
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Source,
+		&i.AgentID,
+		&i.WorkspaceID,
+		&i.UserID,
+		&i.PeerIp,
+		&i.Direct,
+		&i.Endpoint,
+		&i.DerpRegionID,
+		&i.LatencyMs,
+		&i.RxBytes,
+		&i.TxBytes,
+	)
+	return i, err
+}
+
+const deleteCustomRoleByID = `-- name: DeleteCustomRoleByID :exec
+DELETE FROM
+	custom_roles
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) DeleteCustomRoleByID(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteCustomRoleByID, id)
+	return err
+}
+
+const getCustomRoleByName = `-- name: GetCustomRoleByName :one
+SELECT
+	id, name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
+FROM
+	custom_roles
+WHERE
+	name = $1
+	AND organization_id IS NOT DISTINCT FROM $2
+LIMIT
+	1
+`
+
+type GetCustomRoleByNameParams struct {
+	Name           string        `db:"name" json:"name"`
+	OrganizationID uuid.NullUUID `db:"organization_id" json:"organization_id"`
+}
+
+func (q *sqlQuerier) GetCustomRoleByName(ctx context.Context, arg GetCustomRoleByNameParams) (CustomRole, error) {
+	row := q.db.QueryRowContext(ctx, getCustomRoleByName, arg.Name, arg.OrganizationID)
+	var i CustomRole
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.OrganizationID,
+		&i.SitePermissions,
+		&i.OrgPermissions,
+		&i.UserPermissions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCustomRoles = `-- name: GetCustomRoles :many
+SELECT
+	id, name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
+FROM
+	custom_roles
+ORDER BY
+	name ASC
+`
+
+func (q *sqlQuerier) GetCustomRoles(ctx context.Context) ([]CustomRole, error) {
+	rows, err := q.db.QueryContext(ctx, getCustomRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomRole
+	for rows.Next() {
+		var i CustomRole
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.DisplayName,
+			&i.OrganizationID,
+			&i.SitePermissions,
+			&i.OrgPermissions,
+			&i.UserPermissions,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertCustomRole = `-- name: InsertCustomRole :one
+INSERT INTO
+	custom_roles (
+		id,
+		name,
+		display_name,
+		organization_id,
+		site_permissions,
+		org_permissions,
+		user_permissions,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
+`
+
+type InsertCustomRoleParams struct {
+	ID              uuid.UUID             `db:"id" json:"id"`
+	Name            string                `db:"name" json:"name"`
+	DisplayName     string                `db:"display_name" json:"display_name"`
+	OrganizationID  uuid.NullUUID         `db:"organization_id" json:"organization_id"`
+	SitePermissions CustomRolePermissions `db:"site_permissions" json:"site_permissions"`
+	OrgPermissions  CustomRolePermissions `db:"org_permissions" json:"org_permissions"`
+	UserPermissions CustomRolePermissions `db:"user_permissions" json:"user_permissions"`
+	CreatedAt       time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) InsertCustomRole(ctx context.Context, arg InsertCustomRoleParams) (CustomRole, error) {
+	row := q.db.QueryRowContext(ctx, insertCustomRole,
+		arg.ID,
+		arg.Name,
+		arg.DisplayName,
+		arg.OrganizationID,
+		arg.SitePermissions,
+		arg.OrgPermissions,
+		arg.UserPermissions,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i CustomRole
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.OrganizationID,
+		&i.SitePermissions,
+		&i.OrgPermissions,
+		&i.UserPermissions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const removeRoleFromOrganizationMembers = `-- name: RemoveRoleFromOrganizationMembers :exec
+UPDATE
+	organization_members
+SET
+	roles = array_remove(roles, $1 :: text)
+WHERE
+	organization_id = $2
+`
+
+type RemoveRoleFromOrganizationMembersParams struct {
+	RoleName       string    `db:"role_name" json:"role_name"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+}
+
+func (q *sqlQuerier) RemoveRoleFromOrganizationMembers(ctx context.Context, arg RemoveRoleFromOrganizationMembersParams) error {
+	_, err := q.db.ExecContext(ctx, removeRoleFromOrganizationMembers, arg.RoleName, arg.OrganizationID)
+	return err
+}
+
+const removeRoleFromUsers = `-- name: RemoveRoleFromUsers :exec
+UPDATE
+	users
+SET
+	rbac_roles = array_remove(rbac_roles, $1 :: text)
+`
+
+func (q *sqlQuerier) RemoveRoleFromUsers(ctx context.Context, roleName string) error {
+	_, err := q.db.ExecContext(ctx, removeRoleFromUsers, roleName)
+	return err
+}
+
+const updateCustomRoleByID = `-- name: UpdateCustomRoleByID :one
+UPDATE
+	custom_roles
+SET
+	display_name = $2,
+	site_permissions = $3,
+	org_permissions = $4,
+	user_permissions = $5,
+	updated_at = $6
+WHERE
+	id = $1
+RETURNING id, name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
+`
+
+type UpdateCustomRoleByIDParams struct {
+	ID              uuid.UUID             `db:"id" json:"id"`
+	DisplayName     string                `db:"display_name" json:"display_name"`
+	SitePermissions CustomRolePermissions `db:"site_permissions" json:"site_permissions"`
+	OrgPermissions  CustomRolePermissions `db:"org_permissions" json:"org_permissions"`
+	UserPermissions CustomRolePermissions `db:"user_permissions" json:"user_permissions"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateCustomRoleByID(ctx context.Context, arg UpdateCustomRoleByIDParams) (CustomRole, error) {
+	row := q.db.QueryRowContext(ctx, updateCustomRoleByID,
+		arg.ID,
+		arg.DisplayName,
+		arg.SitePermissions,
+		arg.OrgPermissions,
+		arg.UserPermissions,
+		arg.UpdatedAt,
+	)
+	var i CustomRole
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.OrganizationID,
+		&i.SitePermissions,
+		&i.OrgPermissions,
+		&i.UserPermissions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getFileByHashAndCreator = `-- name: GetFileByHashAndCreator :one
 SELECT
 	hash, created_at, created_by, mimetype, data, id
@@ -996,9 +1435,54 @@ func (q *sqlQuerier) DeleteGroupMember(ctx context.Context, userID uuid.UUID) er
 	return err
 }
 
+const deleteGroupMemberFromGroup = `-- name: DeleteGroupMemberFromGroup :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = $1
+AND
+	group_id = $2
+`
+
+type DeleteGroupMemberFromGroupParams struct {
+	UserID  uuid.UUID `db:"user_id" json:"user_id"`
+	GroupID uuid.UUID `db:"group_id" json:"group_id"`
+}
+
+func (q *sqlQuerier) DeleteGroupMemberFromGroup(ctx context.Context, arg DeleteGroupMemberFromGroupParams) error {
+	_, err := q.db.ExecContext(ctx, deleteGroupMemberFromGroup, arg.UserID, arg.GroupID)
+	return err
+}
+
+const deleteGroupMembersByOrgAndUser = `-- name: DeleteGroupMembersByOrgAndUser :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = $1
+AND
+	group_id IN (
+		SELECT
+			id
+		FROM
+			groups
+		WHERE
+			organization_id = $2
+	)
+`
+
+type DeleteGroupMembersByOrgAndUserParams struct {
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+}
+
+func (q *sqlQuerier) DeleteGroupMembersByOrgAndUser(ctx context.Context, arg DeleteGroupMembersByOrgAndUserParams) error {
+	_, err := q.db.ExecContext(ctx, deleteGroupMembersByOrgAndUser, arg.UserID, arg.OrganizationID)
+	return err
+}
+
 const getAllOrganizationMembers = `-- name: GetAllOrganizationMembers :many
 SELECT
-	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at
+	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at, users.quiet_hours_schedule, users.service_account, users.contact
 FROM
 	users
 JOIN
@@ -1031,6 +1515,9 @@ func (q *sqlQuerier) GetAllOrganizationMembers(ctx context.Context, organization
 			&i.AvatarURL,
 			&i.Deleted,
 			&i.LastSeenAt,
+			&i.QuietHoursSchedule,
+			&i.ServiceAccount,
+			&i.Contact,
 		); err != nil {
 			return nil, err
 		}
@@ -1100,7 +1587,7 @@ func (q *sqlQuerier) GetGroupByOrgAndName(ctx context.Context, arg GetGroupByOrg
 
 const getGroupMembers = `-- name: GetGroupMembers :many
 SELECT
-	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at
+	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at, users.quiet_hours_schedule, users.service_account, users.contact
 FROM
 	users
 JOIN
@@ -1137,6 +1624,9 @@ func (q *sqlQuerier) GetGroupMembers(ctx context.Context, groupID uuid.UUID) ([]
 			&i.AvatarURL,
 			&i.Deleted,
 			&i.LastSeenAt,
+			&i.QuietHoursSchedule,
+			&i.ServiceAccount,
+			&i.Contact,
 		); err != nil {
 			return nil, err
 		}
@@ -1449,6 +1939,24 @@ func (q *sqlQuerier) InsertLicense(ctx context.Context, arg InsertLicenseParams)
 	return i, err
 }
 
+const deleteOrganizationMember = `-- name: DeleteOrganizationMember :exec
+DELETE FROM
+	organization_members
+WHERE
+	organization_id = $1
+	AND user_id = $2
+`
+
+type DeleteOrganizationMemberParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) DeleteOrganizationMember(ctx context.Context, arg DeleteOrganizationMemberParams) error {
+	_, err := q.db.ExecContext(ctx, deleteOrganizationMember, arg.OrganizationID, arg.UserID)
+	return err
+}
+
 const getOrganizationIDsByMemberIDs = `-- name: GetOrganizationIDsByMemberIDs :many
 SELECT
     user_id, array_agg(organization_id) :: uuid [ ] AS "organization_IDs"
@@ -1518,6 +2026,65 @@ func (q *sqlQuerier) GetOrganizationMemberByUserID(ctx context.Context, arg GetO
 	return i, err
 }
 
+const getOrganizationMembers = `-- name: GetOrganizationMembers :many
+SELECT
+	organization_members.user_id, organization_members.organization_id, organization_members.created_at, organization_members.updated_at, organization_members.roles,
+	users.username,
+	users.email
+FROM
+	organization_members
+JOIN
+	users
+ON
+	users.id = organization_members.user_id
+WHERE
+	organization_members.organization_id = $1
+	AND users.deleted = false
+ORDER BY
+	users.username ASC
+`
+
+type GetOrganizationMembersRow struct {
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+	Roles          []string  `db:"roles" json:"roles"`
+	Username       string    `db:"username" json:"username"`
+	Email          string    `db:"email" json:"email"`
+}
+
+func (q *sqlQuerier) GetOrganizationMembers(ctx context.Context, organizationID uuid.UUID) ([]GetOrganizationMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationMembers, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOrganizationMembersRow
+	for rows.Next() {
+		var i GetOrganizationMembersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.OrganizationID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			pq.Array(&i.Roles),
+			&i.Username,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getOrganizationMembershipsByUserID = `-- name: GetOrganizationMembershipsByUserID :many
 SELECT
 	user_id, organization_id, created_at, updated_at, roles
@@ -1629,7 +2196,7 @@ func (q *sqlQuerier) UpdateMemberRoles(ctx context.Context, arg UpdateMemberRole
 
 const getOrganizationByID = `-- name: GetOrganizationByID :one
 SELECT
-	id, name, description, created_at, updated_at
+	id, name, description, created_at, updated_at, user_workspace_quota
 FROM
 	organizations
 WHERE
@@ -1645,13 +2212,14 @@ func (q *sqlQuerier) GetOrganizationByID(ctx context.Context, id uuid.UUID) (Org
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserWorkspaceQuota,
 	)
 	return i, err
 }
 
 const getOrganizationByName = `-- name: GetOrganizationByName :one
 SELECT
-	id, name, description, created_at, updated_at
+	id, name, description, created_at, updated_at, user_workspace_quota
 FROM
 	organizations
 WHERE
@@ -1669,13 +2237,14 @@ func (q *sqlQuerier) GetOrganizationByName(ctx context.Context, name string) (Or
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserWorkspaceQuota,
 	)
 	return i, err
 }
 
 const getOrganizations = `-- name: GetOrganizations :many
 SELECT
-	id, name, description, created_at, updated_at
+	id, name, description, created_at, updated_at, user_workspace_quota
 FROM
 	organizations
 `
@@ -1695,6 +2264,7 @@ func (q *sqlQuerier) GetOrganizations(ctx context.Context) ([]Organization, erro
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UserWorkspaceQuota,
 		); err != nil {
 			return nil, err
 		}
@@ -1711,11 +2281,11 @@ func (q *sqlQuerier) GetOrganizations(ctx context.Context) ([]Organization, erro
 
 const getOrganizationsByUserID = `-- name: GetOrganizationsByUserID :many
 SELECT
-	id, name, description, created_at, updated_at
+	id, name, description, created_at, updated_at, user_workspace_quota
 FROM
 	organizations
 WHERE
-	id = (
+	id = ANY(
 		SELECT
 			organization_id
 		FROM
@@ -1740,6 +2310,7 @@ func (q *sqlQuerier) GetOrganizationsByUserID(ctx context.Context, userID uuid.U
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UserWorkspaceQuota,
 		); err != nil {
 			return nil, err
 		}
@@ -1758,7 +2329,7 @@ const insertOrganization = `-- name: InsertOrganization :one
 INSERT INTO
 	organizations (id, "name", description, created_at, updated_at)
 VALUES
-	($1, $2, $3, $4, $5) RETURNING id, name, description, created_at, updated_at
+	($1, $2, $3, $4, $5) RETURNING id, name, description, created_at, updated_at, user_workspace_quota
 `
 
 type InsertOrganizationParams struct {
@@ -1784,6 +2355,45 @@ func (q *sqlQuerier) InsertOrganization(ctx context.Context, arg InsertOrganizat
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UserWorkspaceQuota,
+	)
+	return i, err
+}
+
+const updateOrganization = `-- name: UpdateOrganization :one
+UPDATE
+	organizations
+SET
+	"name" = $1,
+	user_workspace_quota = $2,
+	updated_at = $3
+WHERE
+	id = $4
+RETURNING id, name, description, created_at, updated_at, user_workspace_quota
+`
+
+type UpdateOrganizationParams struct {
+	Name               string    `db:"name" json:"name"`
+	UserWorkspaceQuota int32     `db:"user_workspace_quota" json:"user_workspace_quota"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+	ID                 uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateOrganization(ctx context.Context, arg UpdateOrganizationParams) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, updateOrganization,
+		arg.Name,
+		arg.UserWorkspaceQuota,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserWorkspaceQuota,
 	)
 	return i, err
 }
@@ -2194,7 +2804,7 @@ func (q *sqlQuerier) ParameterValues(ctx context.Context, arg ParameterValuesPar
 
 const getProvisionerDaemonByID = `-- name: GetProvisionerDaemonByID :one
 SELECT
-	id, created_at, updated_at, name, provisioners, replica_id
+	id, created_at, updated_at, name, provisioners, replica_id, tags, organization_id
 FROM
 	provisioner_daemons
 WHERE
@@ -2211,13 +2821,15 @@ func (q *sqlQuerier) GetProvisionerDaemonByID(ctx context.Context, id uuid.UUID)
 		&i.Name,
 		pq.Array(&i.Provisioners),
 		&i.ReplicaID,
+		&i.Tags,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
 const getProvisionerDaemons = `-- name: GetProvisionerDaemons :many
 SELECT
-	id, created_at, updated_at, name, provisioners, replica_id
+	id, created_at, updated_at, name, provisioners, replica_id, tags, organization_id
 FROM
 	provisioner_daemons
 `
@@ -2238,6 +2850,8 @@ func (q *sqlQuerier) GetProvisionerDaemons(ctx context.Context) ([]ProvisionerDa
 			&i.Name,
 			pq.Array(&i.Provisioners),
 			&i.ReplicaID,
+			&i.Tags,
+			&i.OrganizationID,
 		); err != nil {
 			return nil, err
 		}
@@ -2258,17 +2872,21 @@ INSERT INTO
 		id,
 		created_at,
 		"name",
-		provisioners
+		provisioners,
+		tags,
+		organization_id
 	)
 VALUES
-	($1, $2, $3, $4) RETURNING id, created_at, updated_at, name, provisioners, replica_id
+	($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at, name, provisioners, replica_id, tags, organization_id
 `
 
 type InsertProvisionerDaemonParams struct {
-	ID           uuid.UUID         `db:"id" json:"id"`
-	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
-	Name         string            `db:"name" json:"name"`
-	Provisioners []ProvisionerType `db:"provisioners" json:"provisioners"`
+	ID             uuid.UUID         `db:"id" json:"id"`
+	CreatedAt      time.Time         `db:"created_at" json:"created_at"`
+	Name           string            `db:"name" json:"name"`
+	Provisioners   []ProvisionerType `db:"provisioners" json:"provisioners"`
+	Tags           StringMap         `db:"tags" json:"tags"`
+	OrganizationID uuid.NullUUID     `db:"organization_id" json:"organization_id"`
 }
 
 func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProvisionerDaemonParams) (ProvisionerDaemon, error) {
@@ -2277,6 +2895,8 @@ func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProv
 		arg.CreatedAt,
 		arg.Name,
 		pq.Array(arg.Provisioners),
+		arg.Tags,
+		arg.OrganizationID,
 	)
 	var i ProvisionerDaemon
 	err := row.Scan(
@@ -2286,6 +2906,8 @@ func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProv
 		&i.Name,
 		pq.Array(&i.Provisioners),
 		&i.ReplicaID,
+		&i.Tags,
+		&i.OrganizationID,
 	)
 	return i, err
 }
@@ -2443,29 +3065,45 @@ WHERE
 			AND nested.canceled_at IS NULL
 			AND nested.completed_at IS NULL
 			AND nested.provisioner = ANY($3 :: provisioner_type [ ])
+			AND nested.tags <@ $4 :: jsonb
+			-- Daemons of an organization only acquire its jobs.
+			AND CASE
+				WHEN $5 :: uuid != '00000000-00000000-00000000-00000000' THEN
+					nested.organization_id = $5
+				ELSE true
+			END
 		ORDER BY
 			nested.created_at FOR
 		UPDATE
 			SKIP LOCKED
 		LIMIT
 			1
-	) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id
+	) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags
 `
 
 type AcquireProvisionerJobParams struct {
-	StartedAt sql.NullTime      `db:"started_at" json:"started_at"`
-	WorkerID  uuid.NullUUID     `db:"worker_id" json:"worker_id"`
-	Types     []ProvisionerType `db:"types" json:"types"`
+	StartedAt      sql.NullTime      `db:"started_at" json:"started_at"`
+	WorkerID       uuid.NullUUID     `db:"worker_id" json:"worker_id"`
+	Types          []ProvisionerType `db:"types" json:"types"`
+	Tags           json.RawMessage   `db:"tags" json:"tags"`
+	OrganizationID uuid.UUID         `db:"organization_id" json:"organization_id"`
 }
 
 // Acquires the lock for a single job that isn't started, completed,
-// canceled, and that matches an array of provisioner types.
+// canceled, and that matches an array of provisioner types. The job's
+// tags must be a subset of the tags of the acquiring daemon.
 //
 // SKIP LOCKED is used to jump over locked rows. This prevents
 // multiple provisioners from acquiring the same jobs. See:
 // https://www.postgresql.org/docs/9.5/sql-select.html#SQL-FOR-UPDATE-SHARE
 func (q *sqlQuerier) AcquireProvisionerJob(ctx context.Context, arg AcquireProvisionerJobParams) (ProvisionerJob, error) {
-	row := q.db.QueryRowContext(ctx, acquireProvisionerJob, arg.StartedAt, arg.WorkerID, pq.Array(arg.Types))
+	row := q.db.QueryRowContext(ctx, acquireProvisionerJob,
+		arg.StartedAt,
+		arg.WorkerID,
+		pq.Array(arg.Types),
+		arg.Tags,
+		arg.OrganizationID,
+	)
 	var i ProvisionerJob
 	err := row.Scan(
 		&i.ID,
@@ -2483,13 +3121,14 @@ func (q *sqlQuerier) AcquireProvisionerJob(ctx context.Context, arg AcquireProvi
 		&i.Input,
 		&i.WorkerID,
 		&i.FileID,
+		&i.Tags,
 	)
 	return i, err
 }
 
 const getProvisionerJobByID = `-- name: GetProvisionerJobByID :one
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags
 FROM
 	provisioner_jobs
 WHERE
@@ -2515,13 +3154,14 @@ func (q *sqlQuerier) GetProvisionerJobByID(ctx context.Context, id uuid.UUID) (P
 		&i.Input,
 		&i.WorkerID,
 		&i.FileID,
+		&i.Tags,
 	)
 	return i, err
 }
 
 const getProvisionerJobsByIDs = `-- name: GetProvisionerJobsByIDs :many
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags
 FROM
 	provisioner_jobs
 WHERE
@@ -2553,6 +3193,7 @@ func (q *sqlQuerier) GetProvisionerJobsByIDs(ctx context.Context, ids []uuid.UUI
 			&i.Input,
 			&i.WorkerID,
 			&i.FileID,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -2568,7 +3209,7 @@ func (q *sqlQuerier) GetProvisionerJobsByIDs(ctx context.Context, ids []uuid.UUI
 }
 
 const getProvisionerJobsCreatedAfter = `-- name: GetProvisionerJobsCreatedAfter :many
-SELECT id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id FROM provisioner_jobs WHERE created_at > $1
+SELECT id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags FROM provisioner_jobs WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, createdAt time.Time) ([]ProvisionerJob, error) {
@@ -2596,6 +3237,7 @@ func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, created
 			&i.Input,
 			&i.WorkerID,
 			&i.FileID,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -2622,10 +3264,11 @@ INSERT INTO
 		storage_method,
 		file_id,
 		"type",
-		"input"
+		"input",
+		tags
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags
 `
 
 type InsertProvisionerJobParams struct {
@@ -2639,6 +3282,7 @@ type InsertProvisionerJobParams struct {
 	FileID         uuid.UUID                `db:"file_id" json:"file_id"`
 	Type           ProvisionerJobType       `db:"type" json:"type"`
 	Input          json.RawMessage          `db:"input" json:"input"`
+	Tags           StringMap                `db:"tags" json:"tags"`
 }
 
 func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisionerJobParams) (ProvisionerJob, error) {
@@ -2653,6 +3297,7 @@ func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisi
 		arg.FileID,
 		arg.Type,
 		arg.Input,
+		arg.Tags,
 	)
 	var i ProvisionerJob
 	err := row.Scan(
@@ -2671,6 +3316,7 @@ func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisi
 		&i.Input,
 		&i.WorkerID,
 		&i.FileID,
+		&i.Tags,
 	)
 	return i, err
 }
@@ -2743,6 +3389,139 @@ func (q *sqlQuerier) UpdateProvisionerJobWithCompleteByID(ctx context.Context, a
 	return err
 }
 
+const deleteRateLimitPolicyByID = `-- name: DeleteRateLimitPolicyByID :exec
+DELETE FROM
+	rate_limit_policies
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) DeleteRateLimitPolicyByID(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRateLimitPolicyByID, id)
+	return err
+}
+
+const getRateLimitPolicies = `-- name: GetRateLimitPolicies :many
+SELECT
+	id, subject_type, subject, count, window_seconds, created_at, updated_at
+FROM
+	rate_limit_policies
+ORDER BY
+	subject_type ASC,
+	subject ASC
+`
+
+func (q *sqlQuerier) GetRateLimitPolicies(ctx context.Context) ([]RateLimitPolicy, error) {
+	rows, err := q.db.QueryContext(ctx, getRateLimitPolicies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RateLimitPolicy
+	for rows.Next() {
+		var i RateLimitPolicy
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubjectType,
+			&i.Subject,
+			&i.Count,
+			&i.WindowSeconds,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRateLimitPolicyByID = `-- name: GetRateLimitPolicyByID :one
+SELECT
+	id, subject_type, subject, count, window_seconds, created_at, updated_at
+FROM
+	rate_limit_policies
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetRateLimitPolicyByID(ctx context.Context, id uuid.UUID) (RateLimitPolicy, error) {
+	row := q.db.QueryRowContext(ctx, getRateLimitPolicyByID, id)
+	var i RateLimitPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectType,
+		&i.Subject,
+		&i.Count,
+		&i.WindowSeconds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertRateLimitPolicy = `-- name: UpsertRateLimitPolicy :one
+INSERT INTO
+	rate_limit_policies (
+		id,
+		subject_type,
+		subject,
+		count,
+		window_seconds,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (subject_type, subject) DO UPDATE
+SET
+	count = $4,
+	window_seconds = $5,
+	updated_at = $7
+RETURNING id, subject_type, subject, count, window_seconds, created_at, updated_at
+`
+
+type UpsertRateLimitPolicyParams struct {
+	ID            uuid.UUID            `db:"id" json:"id"`
+	SubjectType   RateLimitSubjectType `db:"subject_type" json:"subject_type"`
+	Subject       string               `db:"subject" json:"subject"`
+	Count         int32                `db:"count" json:"count"`
+	WindowSeconds int32                `db:"window_seconds" json:"window_seconds"`
+	CreatedAt     time.Time            `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time            `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpsertRateLimitPolicy(ctx context.Context, arg UpsertRateLimitPolicyParams) (RateLimitPolicy, error) {
+	row := q.db.QueryRowContext(ctx, upsertRateLimitPolicy,
+		arg.ID,
+		arg.SubjectType,
+		arg.Subject,
+		arg.Count,
+		arg.WindowSeconds,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i RateLimitPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectType,
+		&i.Subject,
+		&i.Count,
+		&i.WindowSeconds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const deleteReplicasUpdatedBefore = `-- name: DeleteReplicasUpdatedBefore :exec
 DELETE FROM replicas WHERE updated_at < $1
 `
@@ -2886,23 +3665,160 @@ func (q *sqlQuerier) UpdateReplica(ctx context.Context, arg UpdateReplicaParams)
 		arg.Error,
 		arg.DatabaseLatency,
 	)
-	var i Replica
+	var i Replica
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.StoppedAt,
+		&i.UpdatedAt,
+		&i.Hostname,
+		&i.RegionID,
+		&i.RelayAddress,
+		&i.DatabaseLatency,
+		&i.Version,
+		&i.Error,
+	)
+	return i, err
+}
+
+const getWorkspaceSessionRecordingByID = `-- name: GetWorkspaceSessionRecordingByID :one
+SELECT
+	id, created_at, workspace_id, agent_id, file_id, type, started_at, ended_at, user_id
+FROM
+	workspace_session_recordings
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceSessionRecordingByID(ctx context.Context, id uuid.UUID) (WorkspaceSessionRecording, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceSessionRecordingByID, id)
+	var i WorkspaceSessionRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.FileID,
+		&i.Type,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const getWorkspaceSessionRecordingsByWorkspaceID = `-- name: GetWorkspaceSessionRecordingsByWorkspaceID :many
+SELECT
+	id, created_at, workspace_id, agent_id, file_id, type, started_at, ended_at, user_id
+FROM
+	workspace_session_recordings
+WHERE
+	workspace_id = $1
+ORDER BY
+	started_at DESC
+`
+
+func (q *sqlQuerier) GetWorkspaceSessionRecordingsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceSessionRecording, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceSessionRecordingsByWorkspaceID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceSessionRecording
+	for rows.Next() {
+		var i WorkspaceSessionRecording
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.AgentID,
+			&i.FileID,
+			&i.Type,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceSessionRecording = `-- name: InsertWorkspaceSessionRecording :one
+INSERT INTO
+	workspace_session_recordings (
+		id,
+		created_at,
+		workspace_id,
+		agent_id,
+		file_id,
+		type,
+		started_at,
+		ended_at,
+		user_id
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, workspace_id, agent_id, file_id, type, started_at, ended_at, user_id
+`
+
+type InsertWorkspaceSessionRecordingParams struct {
+	ID          uuid.UUID            `db:"id" json:"id"`
+	CreatedAt   time.Time            `db:"created_at" json:"created_at"`
+	WorkspaceID uuid.UUID            `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID            `db:"agent_id" json:"agent_id"`
+	FileID      uuid.UUID            `db:"file_id" json:"file_id"`
+	Type        SessionRecordingType `db:"type" json:"type"`
+	StartedAt   time.Time            `db:"started_at" json:"started_at"`
+	EndedAt     time.Time            `db:"ended_at" json:"ended_at"`
+	UserID      uuid.UUID            `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceSessionRecording(ctx context.Context, arg InsertWorkspaceSessionRecordingParams) (WorkspaceSessionRecording, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceSessionRecording,
+		arg.ID,
+		arg.CreatedAt,
+		arg.WorkspaceID,
+		arg.AgentID,
+		arg.FileID,
+		arg.Type,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.UserID,
+	)
+	var i WorkspaceSessionRecording
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.FileID,
+		&i.Type,
 		&i.StartedAt,
-		&i.StoppedAt,
-		&i.UpdatedAt,
-		&i.Hostname,
-		&i.RegionID,
-		&i.RelayAddress,
-		&i.DatabaseLatency,
-		&i.Version,
-		&i.Error,
+		&i.EndedAt,
+		&i.UserID,
 	)
 	return i, err
 }
 
+const getAppSecurityKey = `-- name: GetAppSecurityKey :one
+SELECT value FROM site_configs WHERE key = 'app_signing_key'
+`
+
+func (q *sqlQuerier) GetAppSecurityKey(ctx context.Context) (string, error) {
+	row := q.db.QueryRowContext(ctx, getAppSecurityKey)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
 const getDERPMeshKey = `-- name: GetDERPMeshKey :one
 SELECT value FROM site_configs WHERE key = 'derp_mesh_key'
 `
@@ -2925,6 +3841,26 @@ func (q *sqlQuerier) GetDeploymentID(ctx context.Context) (string, error) {
 	return value, err
 }
 
+const getSSHCertificateAuthorityKey = `-- name: GetSSHCertificateAuthorityKey :one
+SELECT value FROM site_configs WHERE key = 'ssh_certificate_authority_key'
+`
+
+func (q *sqlQuerier) GetSSHCertificateAuthorityKey(ctx context.Context) (string, error) {
+	row := q.db.QueryRowContext(ctx, getSSHCertificateAuthorityKey)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
+const insertAppSecurityKey = `-- name: InsertAppSecurityKey :exec
+INSERT INTO site_configs (key, value) VALUES ('app_signing_key', $1)
+`
+
+func (q *sqlQuerier) InsertAppSecurityKey(ctx context.Context, value string) error {
+	_, err := q.db.ExecContext(ctx, insertAppSecurityKey, value)
+	return err
+}
+
 const insertDERPMeshKey = `-- name: InsertDERPMeshKey :exec
 INSERT INTO site_configs (key, value) VALUES ('derp_mesh_key', $1)
 `
@@ -2943,6 +3879,15 @@ func (q *sqlQuerier) InsertDeploymentID(ctx context.Context, value string) error
 	return err
 }
 
+const insertSSHCertificateAuthorityKey = `-- name: InsertSSHCertificateAuthorityKey :exec
+INSERT INTO site_configs (key, value) VALUES ('ssh_certificate_authority_key', $1)
+`
+
+func (q *sqlQuerier) InsertSSHCertificateAuthorityKey(ctx context.Context, value string) error {
+	_, err := q.db.ExecContext(ctx, insertSSHCertificateAuthorityKey, value)
+	return err
+}
+
 const getTemplateAverageBuildTime = `-- name: GetTemplateAverageBuildTime :one
 WITH build_times AS (
 SELECT
@@ -2992,7 +3937,7 @@ func (q *sqlQuerier) GetTemplateAverageBuildTime(ctx context.Context, arg GetTem
 
 const getTemplateByID = `-- name: GetTemplateByID :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl, autostop_requirement_days, inactivity_ttl, dormant_delete_ttl, port_forwarding_allowlist, record_sessions
 FROM
 	templates
 WHERE
@@ -3020,13 +3965,18 @@ func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Templat
 		&i.Icon,
 		&i.UserACL,
 		&i.GroupACL,
+		&i.AutostopRequirementDays,
+		&i.InactivityTtl,
+		&i.DormantDeleteTtl,
+		pq.Array(&i.PortForwardingAllowlist),
+		&i.RecordSessions,
 	)
 	return i, err
 }
 
 const getTemplateByOrganizationAndName = `-- name: GetTemplateByOrganizationAndName :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl, autostop_requirement_days, inactivity_ttl, dormant_delete_ttl, port_forwarding_allowlist, record_sessions
 FROM
 	templates
 WHERE
@@ -3062,12 +4012,17 @@ func (q *sqlQuerier) GetTemplateByOrganizationAndName(ctx context.Context, arg G
 		&i.Icon,
 		&i.UserACL,
 		&i.GroupACL,
+		&i.AutostopRequirementDays,
+		&i.InactivityTtl,
+		&i.DormantDeleteTtl,
+		pq.Array(&i.PortForwardingAllowlist),
+		&i.RecordSessions,
 	)
 	return i, err
 }
 
 const getTemplates = `-- name: GetTemplates :many
-SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl FROM templates
+SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl, autostop_requirement_days, inactivity_ttl, dormant_delete_ttl, port_forwarding_allowlist, record_sessions FROM templates
 ORDER BY (name, id) ASC
 `
 
@@ -3096,6 +4051,11 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 			&i.Icon,
 			&i.UserACL,
 			&i.GroupACL,
+			&i.AutostopRequirementDays,
+			&i.InactivityTtl,
+			&i.DormantDeleteTtl,
+			pq.Array(&i.PortForwardingAllowlist),
+			&i.RecordSessions,
 		); err != nil {
 			return nil, err
 		}
@@ -3112,7 +4072,7 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 
 const getTemplatesWithFilter = `-- name: GetTemplatesWithFilter :many
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl, autostop_requirement_days, inactivity_ttl, dormant_delete_ttl, port_forwarding_allowlist, record_sessions
 FROM
 	templates
 WHERE
@@ -3176,6 +4136,11 @@ func (q *sqlQuerier) GetTemplatesWithFilter(ctx context.Context, arg GetTemplate
 			&i.Icon,
 			&i.UserACL,
 			&i.GroupACL,
+			&i.AutostopRequirementDays,
+			&i.InactivityTtl,
+			&i.DormantDeleteTtl,
+			pq.Array(&i.PortForwardingAllowlist),
+			&i.RecordSessions,
 		); err != nil {
 			return nil, err
 		}
@@ -3209,7 +4174,7 @@ INSERT INTO
 		group_acl
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl, autostop_requirement_days, inactivity_ttl, dormant_delete_ttl, port_forwarding_allowlist, record_sessions
 `
 
 type InsertTemplateParams struct {
@@ -3263,6 +4228,11 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		&i.Icon,
 		&i.UserACL,
 		&i.GroupACL,
+		&i.AutostopRequirementDays,
+		&i.InactivityTtl,
+		&i.DormantDeleteTtl,
+		pq.Array(&i.PortForwardingAllowlist),
+		&i.RecordSessions,
 	)
 	return i, err
 }
@@ -3276,7 +4246,7 @@ SET
 WHERE
 	id = $3
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl, autostop_requirement_days, inactivity_ttl, dormant_delete_ttl, port_forwarding_allowlist, record_sessions
 `
 
 type UpdateTemplateACLByIDParams struct {
@@ -3304,6 +4274,11 @@ func (q *sqlQuerier) UpdateTemplateACLByID(ctx context.Context, arg UpdateTempla
 		&i.Icon,
 		&i.UserACL,
 		&i.GroupACL,
+		&i.AutostopRequirementDays,
+		&i.InactivityTtl,
+		&i.DormantDeleteTtl,
+		pq.Array(&i.PortForwardingAllowlist),
+		&i.RecordSessions,
 	)
 	return i, err
 }
@@ -3359,21 +4334,31 @@ SET
 	max_ttl = $4,
 	min_autostart_interval = $5,
 	name = $6,
-	icon = $7
+	icon = $7,
+	autostop_requirement_days = $8,
+	inactivity_ttl = $9,
+	dormant_delete_ttl = $10,
+	port_forwarding_allowlist = $11,
+	record_sessions = $12
 WHERE
 	id = $1
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, user_acl, group_acl, autostop_requirement_days, inactivity_ttl, dormant_delete_ttl, port_forwarding_allowlist, record_sessions
 `
 
 type UpdateTemplateMetaByIDParams struct {
-	ID                   uuid.UUID `db:"id" json:"id"`
-	UpdatedAt            time.Time `db:"updated_at" json:"updated_at"`
-	Description          string    `db:"description" json:"description"`
-	MaxTtl               int64     `db:"max_ttl" json:"max_ttl"`
-	MinAutostartInterval int64     `db:"min_autostart_interval" json:"min_autostart_interval"`
-	Name                 string    `db:"name" json:"name"`
-	Icon                 string    `db:"icon" json:"icon"`
+	ID                      uuid.UUID `db:"id" json:"id"`
+	UpdatedAt               time.Time `db:"updated_at" json:"updated_at"`
+	Description             string    `db:"description" json:"description"`
+	MaxTtl                  int64     `db:"max_ttl" json:"max_ttl"`
+	MinAutostartInterval    int64     `db:"min_autostart_interval" json:"min_autostart_interval"`
+	Name                    string    `db:"name" json:"name"`
+	Icon                    string    `db:"icon" json:"icon"`
+	AutostopRequirementDays int64     `db:"autostop_requirement_days" json:"autostop_requirement_days"`
+	InactivityTtl           int64     `db:"inactivity_ttl" json:"inactivity_ttl"`
+	DormantDeleteTtl        int64     `db:"dormant_delete_ttl" json:"dormant_delete_ttl"`
+	PortForwardingAllowlist []string  `db:"port_forwarding_allowlist" json:"port_forwarding_allowlist"`
+	RecordSessions          bool      `db:"record_sessions" json:"record_sessions"`
 }
 
 func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTemplateMetaByIDParams) (Template, error) {
@@ -3385,6 +4370,11 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		arg.MinAutostartInterval,
 		arg.Name,
 		arg.Icon,
+		arg.AutostopRequirementDays,
+		arg.InactivityTtl,
+		arg.DormantDeleteTtl,
+		pq.Array(arg.PortForwardingAllowlist),
+		arg.RecordSessions,
 	)
 	var i Template
 	err := row.Scan(
@@ -3403,6 +4393,144 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		&i.Icon,
 		&i.UserACL,
 		&i.GroupACL,
+		&i.AutostopRequirementDays,
+		&i.InactivityTtl,
+		&i.DormantDeleteTtl,
+		pq.Array(&i.PortForwardingAllowlist),
+		&i.RecordSessions,
+	)
+	return i, err
+}
+
+const getTemplateVersionParameters = `-- name: GetTemplateVersionParameters :many
+SELECT template_version_id, name, display_name, description, type, mutable, default_value, icon, options, validation_regex, validation_error, validation_min, validation_max, display_order FROM template_version_parameters WHERE template_version_id = $1 ORDER BY display_order ASC, LOWER(name) ASC
+`
+
+func (q *sqlQuerier) GetTemplateVersionParameters(ctx context.Context, templateVersionID uuid.UUID) ([]TemplateVersionParameter, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplateVersionParameters, templateVersionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TemplateVersionParameter
+	for rows.Next() {
+		var i TemplateVersionParameter
+		if err := rows.Scan(
+			&i.TemplateVersionID,
+			&i.Name,
+			&i.DisplayName,
+			&i.Description,
+			&i.Type,
+			&i.Mutable,
+			&i.DefaultValue,
+			&i.Icon,
+			&i.Options,
+			&i.ValidationRegex,
+			&i.ValidationError,
+			&i.ValidationMin,
+			&i.ValidationMax,
+			&i.DisplayOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertTemplateVersionParameter = `-- name: InsertTemplateVersionParameter :one
+INSERT INTO
+	template_version_parameters (
+		template_version_id,
+		name,
+		display_name,
+		description,
+		type,
+		mutable,
+		default_value,
+		icon,
+		options,
+		validation_regex,
+		validation_error,
+		validation_min,
+		validation_max,
+		display_order
+	)
+VALUES
+	(
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		$7,
+		$8,
+		$9,
+		$10,
+		$11,
+		$12,
+		$13,
+		$14
+	) RETURNING template_version_id, name, display_name, description, type, mutable, default_value, icon, options, validation_regex, validation_error, validation_min, validation_max, display_order
+`
+
+type InsertTemplateVersionParameterParams struct {
+	TemplateVersionID uuid.UUID       `db:"template_version_id" json:"template_version_id"`
+	Name              string          `db:"name" json:"name"`
+	DisplayName       string          `db:"display_name" json:"display_name"`
+	Description       string          `db:"description" json:"description"`
+	Type              string          `db:"type" json:"type"`
+	Mutable           bool            `db:"mutable" json:"mutable"`
+	DefaultValue      string          `db:"default_value" json:"default_value"`
+	Icon              string          `db:"icon" json:"icon"`
+	Options           json.RawMessage `db:"options" json:"options"`
+	ValidationRegex   string          `db:"validation_regex" json:"validation_regex"`
+	ValidationError   string          `db:"validation_error" json:"validation_error"`
+	ValidationMin     sql.NullInt32   `db:"validation_min" json:"validation_min"`
+	ValidationMax     sql.NullInt32   `db:"validation_max" json:"validation_max"`
+	DisplayOrder      int32           `db:"display_order" json:"display_order"`
+}
+
+func (q *sqlQuerier) InsertTemplateVersionParameter(ctx context.Context, arg InsertTemplateVersionParameterParams) (TemplateVersionParameter, error) {
+	row := q.db.QueryRowContext(ctx, insertTemplateVersionParameter,
+		arg.TemplateVersionID,
+		arg.Name,
+		arg.DisplayName,
+		arg.Description,
+		arg.Type,
+		arg.Mutable,
+		arg.DefaultValue,
+		arg.Icon,
+		arg.Options,
+		arg.ValidationRegex,
+		arg.ValidationError,
+		arg.ValidationMin,
+		arg.ValidationMax,
+		arg.DisplayOrder,
+	)
+	var i TemplateVersionParameter
+	err := row.Scan(
+		&i.TemplateVersionID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Description,
+		&i.Type,
+		&i.Mutable,
+		&i.DefaultValue,
+		&i.Icon,
+		&i.Options,
+		&i.ValidationRegex,
+		&i.ValidationError,
+		&i.ValidationMin,
+		&i.ValidationMax,
+		&i.DisplayOrder,
 	)
 	return i, err
 }
@@ -3876,6 +5004,8 @@ FROM
 	users
 WHERE
     status = 'active'::public.user_status AND deleted = false
+	-- Service accounts are not people, so they don't count as active users.
+	AND service_account = false
 `
 
 func (q *sqlQuerier) GetActiveUserCount(ctx context.Context) (int64, error) {
@@ -3890,7 +5020,9 @@ SELECT
 	-- username is returned just to help for logging purposes
 	-- status is used to enforce 'suspended' users, as all roles are ignored
 	--	when suspended.
-	id, username, status,
+	-- service_account is used to keep the tokens of service accounts from
+	--	being extended.
+	id, username, status, service_account,
 	-- All user roles, including their org roles.
 	array_cat(
 		-- All users are members
@@ -3926,11 +5058,12 @@ WHERE
 `
 
 type GetAuthorizationUserRolesRow struct {
-	ID       uuid.UUID  `db:"id" json:"id"`
-	Username string     `db:"username" json:"username"`
-	Status   UserStatus `db:"status" json:"status"`
-	Roles    []string   `db:"roles" json:"roles"`
-	Groups   []string   `db:"groups" json:"groups"`
+	ID             uuid.UUID  `db:"id" json:"id"`
+	Username       string     `db:"username" json:"username"`
+	Status         UserStatus `db:"status" json:"status"`
+	ServiceAccount bool       `db:"service_account" json:"service_account"`
+	Roles          []string   `db:"roles" json:"roles"`
+	Groups         []string   `db:"groups" json:"groups"`
 }
 
 // This function returns roles for authorization purposes. Implied member roles
@@ -3942,6 +5075,7 @@ func (q *sqlQuerier) GetAuthorizationUserRoles(ctx context.Context, userID uuid.
 		&i.ID,
 		&i.Username,
 		&i.Status,
+		&i.ServiceAccount,
 		pq.Array(&i.Roles),
 		pq.Array(&i.Groups),
 	)
@@ -3950,7 +5084,7 @@ func (q *sqlQuerier) GetAuthorizationUserRoles(ctx context.Context, userID uuid.
 
 const getUserByEmailOrUsername = `-- name: GetUserByEmailOrUsername :one
 SELECT
-	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
 FROM
 	users
 WHERE
@@ -3982,13 +5116,16 @@ func (q *sqlQuerier) GetUserByEmailOrUsername(ctx context.Context, arg GetUserBy
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.QuietHoursSchedule,
+		&i.ServiceAccount,
+		&i.Contact,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
 SELECT
-	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
 FROM
 	users
 WHERE
@@ -4013,6 +5150,9 @@ func (q *sqlQuerier) GetUserByID(ctx context.Context, id uuid.UUID) (User, error
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.QuietHoursSchedule,
+		&i.ServiceAccount,
+		&i.Contact,
 	)
 	return i, err
 }
@@ -4033,7 +5173,7 @@ func (q *sqlQuerier) GetUserCount(ctx context.Context) (int64, error) {
 
 const getUsers = `-- name: GetUsers :many
 SELECT
-	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
 FROM
 	users
 WHERE
@@ -4132,6 +5272,9 @@ func (q *sqlQuerier) GetUsers(ctx context.Context, arg GetUsersParams) ([]User,
 			&i.AvatarURL,
 			&i.Deleted,
 			&i.LastSeenAt,
+			&i.QuietHoursSchedule,
+			&i.ServiceAccount,
+			&i.Contact,
 		); err != nil {
 			return nil, err
 		}
@@ -4147,7 +5290,7 @@ func (q *sqlQuerier) GetUsers(ctx context.Context, arg GetUsersParams) ([]User,
 }
 
 const getUsersByIDs = `-- name: GetUsersByIDs :many
-SELECT id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at FROM users WHERE id = ANY($1 :: uuid [ ])
+SELECT id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact FROM users WHERE id = ANY($1 :: uuid [ ])
 `
 
 // This shouldn't check for deleted, because it's frequently used
@@ -4175,6 +5318,9 @@ func (q *sqlQuerier) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]User
 			&i.AvatarURL,
 			&i.Deleted,
 			&i.LastSeenAt,
+			&i.QuietHoursSchedule,
+			&i.ServiceAccount,
+			&i.Contact,
 		); err != nil {
 			return nil, err
 		}
@@ -4199,10 +5345,12 @@ INSERT INTO
 		created_at,
 		updated_at,
 		rbac_roles,
-		login_type
+		login_type,
+		service_account,
+		contact
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
 `
 
 type InsertUserParams struct {
@@ -4214,6 +5362,8 @@ type InsertUserParams struct {
 	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
 	RBACRoles      pq.StringArray `db:"rbac_roles" json:"rbac_roles"`
 	LoginType      LoginType      `db:"login_type" json:"login_type"`
+	ServiceAccount bool           `db:"service_account" json:"service_account"`
+	Contact        string         `db:"contact" json:"contact"`
 }
 
 func (q *sqlQuerier) InsertUser(ctx context.Context, arg InsertUserParams) (User, error) {
@@ -4226,6 +5376,8 @@ func (q *sqlQuerier) InsertUser(ctx context.Context, arg InsertUserParams) (User
 		arg.UpdatedAt,
 		arg.RBACRoles,
 		arg.LoginType,
+		arg.ServiceAccount,
+		arg.Contact,
 	)
 	var i User
 	err := row.Scan(
@@ -4241,6 +5393,9 @@ func (q *sqlQuerier) InsertUser(ctx context.Context, arg InsertUserParams) (User
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.QuietHoursSchedule,
+		&i.ServiceAccount,
+		&i.Contact,
 	)
 	return i, err
 }
@@ -4290,7 +5445,7 @@ SET
 	last_seen_at = $2,
 	updated_at = $3
 WHERE
-	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
 `
 
 type UpdateUserLastSeenAtParams struct {
@@ -4315,6 +5470,9 @@ func (q *sqlQuerier) UpdateUserLastSeenAt(ctx context.Context, arg UpdateUserLas
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.QuietHoursSchedule,
+		&i.ServiceAccount,
+		&i.Contact,
 	)
 	return i, err
 }
@@ -4328,7 +5486,7 @@ SET
 	avatar_url = $4,
 	updated_at = $5
 WHERE
-	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
 `
 
 type UpdateUserProfileParams struct {
@@ -4361,6 +5519,47 @@ func (q *sqlQuerier) UpdateUserProfile(ctx context.Context, arg UpdateUserProfil
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.QuietHoursSchedule,
+		&i.ServiceAccount,
+		&i.Contact,
+	)
+	return i, err
+}
+
+const updateUserQuietHoursSchedule = `-- name: UpdateUserQuietHoursSchedule :one
+UPDATE
+	users
+SET
+	quiet_hours_schedule = $2
+WHERE
+	id = $1
+RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
+`
+
+type UpdateUserQuietHoursScheduleParams struct {
+	ID                 uuid.UUID `db:"id" json:"id"`
+	QuietHoursSchedule string    `db:"quiet_hours_schedule" json:"quiet_hours_schedule"`
+}
+
+func (q *sqlQuerier) UpdateUserQuietHoursSchedule(ctx context.Context, arg UpdateUserQuietHoursScheduleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserQuietHoursSchedule, arg.ID, arg.QuietHoursSchedule)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.RBACRoles,
+		&i.LoginType,
+		&i.AvatarURL,
+		&i.Deleted,
+		&i.LastSeenAt,
+		&i.QuietHoursSchedule,
+		&i.ServiceAccount,
+		&i.Contact,
 	)
 	return i, err
 }
@@ -4373,7 +5572,7 @@ SET
 	rbac_roles = ARRAY(SELECT DISTINCT UNNEST($1 :: text[]))
 WHERE
 	id = $2
-RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
 `
 
 type UpdateUserRolesParams struct {
@@ -4397,6 +5596,9 @@ func (q *sqlQuerier) UpdateUserRoles(ctx context.Context, arg UpdateUserRolesPar
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.QuietHoursSchedule,
+		&i.ServiceAccount,
+		&i.Contact,
 	)
 	return i, err
 }
@@ -4408,7 +5610,7 @@ SET
 	status = $2,
 	updated_at = $3
 WHERE
-	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, quiet_hours_schedule, service_account, contact
 `
 
 type UpdateUserStatusParams struct {
@@ -4433,13 +5635,124 @@ func (q *sqlQuerier) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusP
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.QuietHoursSchedule,
+		&i.ServiceAccount,
+		&i.Contact,
 	)
 	return i, err
 }
 
+const getWorkspaceAgentMetadata = `-- name: GetWorkspaceAgentMetadata :many
+SELECT
+	workspace_agent_id, display_name, key, script, value, error, timeout, interval, collected_at
+FROM
+	workspace_agent_metadata
+WHERE
+	workspace_agent_id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceAgentMetadata(ctx context.Context, workspaceAgentID uuid.UUID) ([]WorkspaceAgentMetadatum, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentMetadata, workspaceAgentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentMetadatum
+	for rows.Next() {
+		var i WorkspaceAgentMetadatum
+		if err := rows.Scan(
+			&i.WorkspaceAgentID,
+			&i.DisplayName,
+			&i.Key,
+			&i.Script,
+			&i.Value,
+			&i.Error,
+			&i.Timeout,
+			&i.Interval,
+			&i.CollectedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceAgentMetadata = `-- name: InsertWorkspaceAgentMetadata :exec
+INSERT INTO
+	workspace_agent_metadata (
+		workspace_agent_id,
+		display_name,
+		key,
+		script,
+		timeout,
+		interval
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6)
+`
+
+type InsertWorkspaceAgentMetadataParams struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	DisplayName      string    `db:"display_name" json:"display_name"`
+	Key              string    `db:"key" json:"key"`
+	Script           string    `db:"script" json:"script"`
+	Timeout          int64     `db:"timeout" json:"timeout"`
+	Interval         int64     `db:"interval" json:"interval"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentMetadata(ctx context.Context, arg InsertWorkspaceAgentMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceAgentMetadata,
+		arg.WorkspaceAgentID,
+		arg.DisplayName,
+		arg.Key,
+		arg.Script,
+		arg.Timeout,
+		arg.Interval,
+	)
+	return err
+}
+
+const updateWorkspaceAgentMetadata = `-- name: UpdateWorkspaceAgentMetadata :exec
+UPDATE
+	workspace_agent_metadata
+SET
+	value = $3,
+	error = $4,
+	collected_at = $5
+WHERE
+	workspace_agent_id = $1
+	AND key = $2
+`
+
+type UpdateWorkspaceAgentMetadataParams struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	Key              string    `db:"key" json:"key"`
+	Value            string    `db:"value" json:"value"`
+	Error            string    `db:"error" json:"error"`
+	CollectedAt      time.Time `db:"collected_at" json:"collected_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentMetadata(ctx context.Context, arg UpdateWorkspaceAgentMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentMetadata,
+		arg.WorkspaceAgentID,
+		arg.Key,
+		arg.Value,
+		arg.Error,
+		arg.CollectedAt,
+	)
+	return err
+}
+
 const getWorkspaceAgentByAuthToken = `-- name: GetWorkspaceAgentByAuthToken :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, startup_logs_length, startup_logs_overflowed, lifecycle_state, startup_script_timeout_seconds, login_before_ready, shutdown_script, shutdown_script_timeout_seconds
 FROM
 	workspace_agents
 WHERE
@@ -4470,13 +5783,20 @@ func (q *sqlQuerier) GetWorkspaceAgentByAuthToken(ctx context.Context, authToken
 		&i.ResourceMetadata,
 		&i.Directory,
 		&i.Version,
+		&i.StartupLogsLength,
+		&i.StartupLogsOverflowed,
+		&i.LifecycleState,
+		&i.StartupScriptTimeoutSeconds,
+		&i.LoginBeforeReady,
+		&i.ShutdownScript,
+		&i.ShutdownScriptTimeoutSeconds,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByID = `-- name: GetWorkspaceAgentByID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, startup_logs_length, startup_logs_overflowed, lifecycle_state, startup_script_timeout_seconds, login_before_ready, shutdown_script, shutdown_script_timeout_seconds
 FROM
 	workspace_agents
 WHERE
@@ -4505,13 +5825,20 @@ func (q *sqlQuerier) GetWorkspaceAgentByID(ctx context.Context, id uuid.UUID) (W
 		&i.ResourceMetadata,
 		&i.Directory,
 		&i.Version,
+		&i.StartupLogsLength,
+		&i.StartupLogsOverflowed,
+		&i.LifecycleState,
+		&i.StartupScriptTimeoutSeconds,
+		&i.LoginBeforeReady,
+		&i.ShutdownScript,
+		&i.ShutdownScriptTimeoutSeconds,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByInstanceID = `-- name: GetWorkspaceAgentByInstanceID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, startup_logs_length, startup_logs_overflowed, lifecycle_state, startup_script_timeout_seconds, login_before_ready, shutdown_script, shutdown_script_timeout_seconds
 FROM
 	workspace_agents
 WHERE
@@ -4542,13 +5869,66 @@ func (q *sqlQuerier) GetWorkspaceAgentByInstanceID(ctx context.Context, authInst
 		&i.ResourceMetadata,
 		&i.Directory,
 		&i.Version,
+		&i.StartupLogsLength,
+		&i.StartupLogsOverflowed,
+		&i.LifecycleState,
+		&i.StartupScriptTimeoutSeconds,
+		&i.LoginBeforeReady,
+		&i.ShutdownScript,
+		&i.ShutdownScriptTimeoutSeconds,
 	)
 	return i, err
 }
 
+const getWorkspaceAgentStartupLogsAfter = `-- name: GetWorkspaceAgentStartupLogsAfter :many
+SELECT
+	agent_id, created_at, output, id, eof
+FROM
+	workspace_agent_startup_logs
+WHERE
+	agent_id = $1
+	AND (
+		id > $2
+	) ORDER BY id ASC
+`
+
+type GetWorkspaceAgentStartupLogsAfterParams struct {
+	AgentID      uuid.UUID `db:"agent_id" json:"agent_id"`
+	CreatedAfter int64     `db:"created_after" json:"created_after"`
+}
+
+func (q *sqlQuerier) GetWorkspaceAgentStartupLogsAfter(ctx context.Context, arg GetWorkspaceAgentStartupLogsAfterParams) ([]WorkspaceAgentStartupLog, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentStartupLogsAfter, arg.AgentID, arg.CreatedAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentStartupLog
+	for rows.Next() {
+		var i WorkspaceAgentStartupLog
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.CreatedAt,
+			&i.Output,
+			&i.ID,
+			&i.EOF,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspaceAgentsByResourceIDs = `-- name: GetWorkspaceAgentsByResourceIDs :many
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, startup_logs_length, startup_logs_overflowed, lifecycle_state, startup_script_timeout_seconds, login_before_ready, shutdown_script, shutdown_script_timeout_seconds
 FROM
 	workspace_agents
 WHERE
@@ -4583,6 +5963,13 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 			&i.ResourceMetadata,
 			&i.Directory,
 			&i.Version,
+			&i.StartupLogsLength,
+			&i.StartupLogsOverflowed,
+			&i.LifecycleState,
+			&i.StartupScriptTimeoutSeconds,
+			&i.LoginBeforeReady,
+			&i.ShutdownScript,
+			&i.ShutdownScriptTimeoutSeconds,
 		); err != nil {
 			return nil, err
 		}
@@ -4598,7 +5985,7 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 }
 
 const getWorkspaceAgentsCreatedAfter = `-- name: GetWorkspaceAgentsCreatedAfter :many
-SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version FROM workspace_agents WHERE created_at > $1
+SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, startup_logs_length, startup_logs_overflowed, lifecycle_state, startup_script_timeout_seconds, login_before_ready, shutdown_script, shutdown_script_timeout_seconds FROM workspace_agents WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceAgent, error) {
@@ -4629,6 +6016,13 @@ func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, created
 			&i.ResourceMetadata,
 			&i.Directory,
 			&i.Version,
+			&i.StartupLogsLength,
+			&i.StartupLogsOverflowed,
+			&i.LifecycleState,
+			&i.StartupScriptTimeoutSeconds,
+			&i.LoginBeforeReady,
+			&i.ShutdownScript,
+			&i.ShutdownScriptTimeoutSeconds,
 		); err != nil {
 			return nil, err
 		}
@@ -4659,27 +6053,35 @@ INSERT INTO
 		startup_script,
 		directory,
 		instance_metadata,
-		resource_metadata
+		resource_metadata,
+		startup_script_timeout_seconds,
+		login_before_ready,
+		shutdown_script,
+		shutdown_script_timeout_seconds
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, startup_logs_length, startup_logs_overflowed, lifecycle_state, startup_script_timeout_seconds, login_before_ready, shutdown_script, shutdown_script_timeout_seconds
 `
 
 type InsertWorkspaceAgentParams struct {
-	ID                   uuid.UUID             `db:"id" json:"id"`
-	CreatedAt            time.Time             `db:"created_at" json:"created_at"`
-	UpdatedAt            time.Time             `db:"updated_at" json:"updated_at"`
-	Name                 string                `db:"name" json:"name"`
-	ResourceID           uuid.UUID             `db:"resource_id" json:"resource_id"`
-	AuthToken            uuid.UUID             `db:"auth_token" json:"auth_token"`
-	AuthInstanceID       sql.NullString        `db:"auth_instance_id" json:"auth_instance_id"`
-	Architecture         string                `db:"architecture" json:"architecture"`
-	EnvironmentVariables pqtype.NullRawMessage `db:"environment_variables" json:"environment_variables"`
-	OperatingSystem      string                `db:"operating_system" json:"operating_system"`
-	StartupScript        sql.NullString        `db:"startup_script" json:"startup_script"`
-	Directory            string                `db:"directory" json:"directory"`
-	InstanceMetadata     pqtype.NullRawMessage `db:"instance_metadata" json:"instance_metadata"`
-	ResourceMetadata     pqtype.NullRawMessage `db:"resource_metadata" json:"resource_metadata"`
+	ID                           uuid.UUID             `db:"id" json:"id"`
+	CreatedAt                    time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt                    time.Time             `db:"updated_at" json:"updated_at"`
+	Name                         string                `db:"name" json:"name"`
+	ResourceID                   uuid.UUID             `db:"resource_id" json:"resource_id"`
+	AuthToken                    uuid.UUID             `db:"auth_token" json:"auth_token"`
+	AuthInstanceID               sql.NullString        `db:"auth_instance_id" json:"auth_instance_id"`
+	Architecture                 string                `db:"architecture" json:"architecture"`
+	EnvironmentVariables         pqtype.NullRawMessage `db:"environment_variables" json:"environment_variables"`
+	OperatingSystem              string                `db:"operating_system" json:"operating_system"`
+	StartupScript                sql.NullString        `db:"startup_script" json:"startup_script"`
+	Directory                    string                `db:"directory" json:"directory"`
+	InstanceMetadata             pqtype.NullRawMessage `db:"instance_metadata" json:"instance_metadata"`
+	ResourceMetadata             pqtype.NullRawMessage `db:"resource_metadata" json:"resource_metadata"`
+	StartupScriptTimeoutSeconds  int32                 `db:"startup_script_timeout_seconds" json:"startup_script_timeout_seconds"`
+	LoginBeforeReady             bool                  `db:"login_before_ready" json:"login_before_ready"`
+	ShutdownScript               sql.NullString        `db:"shutdown_script" json:"shutdown_script"`
+	ShutdownScriptTimeoutSeconds int32                 `db:"shutdown_script_timeout_seconds" json:"shutdown_script_timeout_seconds"`
 }
 
 func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspaceAgentParams) (WorkspaceAgent, error) {
@@ -4698,6 +6100,10 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		arg.Directory,
 		arg.InstanceMetadata,
 		arg.ResourceMetadata,
+		arg.StartupScriptTimeoutSeconds,
+		arg.LoginBeforeReady,
+		arg.ShutdownScript,
+		arg.ShutdownScriptTimeoutSeconds,
 	)
 	var i WorkspaceAgent
 	err := row.Scan(
@@ -4719,10 +6125,75 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		&i.ResourceMetadata,
 		&i.Directory,
 		&i.Version,
+		&i.StartupLogsLength,
+		&i.StartupLogsOverflowed,
+		&i.LifecycleState,
+		&i.StartupScriptTimeoutSeconds,
+		&i.LoginBeforeReady,
+		&i.ShutdownScript,
+		&i.ShutdownScriptTimeoutSeconds,
 	)
 	return i, err
 }
 
+const insertWorkspaceAgentStartupLogs = `-- name: InsertWorkspaceAgentStartupLogs :many
+WITH new_length AS (
+	UPDATE workspace_agents SET
+	startup_logs_length = startup_logs_length + $5 WHERE workspace_agents.id = $1
+)
+INSERT INTO
+	workspace_agent_startup_logs (agent_id, created_at, output, eof)
+SELECT
+	$1 :: uuid,
+	unnest($2 :: timestamptz [ ]) AS created_at,
+	unnest($3 :: VARCHAR(1024) [ ]) AS output,
+	unnest($4 :: boolean [ ]) AS eof
+	RETURNING workspace_agent_startup_logs.agent_id, workspace_agent_startup_logs.created_at, workspace_agent_startup_logs.output, workspace_agent_startup_logs.id, workspace_agent_startup_logs.eof
+`
+
+type InsertWorkspaceAgentStartupLogsParams struct {
+	AgentID      uuid.UUID   `db:"agent_id" json:"agent_id"`
+	CreatedAt    []time.Time `db:"created_at" json:"created_at"`
+	Output       []string    `db:"output" json:"output"`
+	EOF          []bool      `db:"eof" json:"eof"`
+	OutputLength int32       `db:"output_length" json:"output_length"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentStartupLogs(ctx context.Context, arg InsertWorkspaceAgentStartupLogsParams) ([]WorkspaceAgentStartupLog, error) {
+	rows, err := q.db.QueryContext(ctx, insertWorkspaceAgentStartupLogs,
+		arg.AgentID,
+		pq.Array(arg.CreatedAt),
+		pq.Array(arg.Output),
+		pq.Array(arg.EOF),
+		arg.OutputLength,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentStartupLog
+	for rows.Next() {
+		var i WorkspaceAgentStartupLog
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.CreatedAt,
+			&i.Output,
+			&i.ID,
+			&i.EOF,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateWorkspaceAgentConnectionByID = `-- name: UpdateWorkspaceAgentConnectionByID :exec
 UPDATE
 	workspace_agents
@@ -4754,6 +6225,44 @@ func (q *sqlQuerier) UpdateWorkspaceAgentConnectionByID(ctx context.Context, arg
 	return err
 }
 
+const updateWorkspaceAgentLifecycleStateByID = `-- name: UpdateWorkspaceAgentLifecycleStateByID :exec
+UPDATE
+	workspace_agents
+SET
+	lifecycle_state = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAgentLifecycleStateByIDParams struct {
+	ID             uuid.UUID                    `db:"id" json:"id"`
+	LifecycleState WorkspaceAgentLifecycleState `db:"lifecycle_state" json:"lifecycle_state"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentLifecycleStateByID(ctx context.Context, arg UpdateWorkspaceAgentLifecycleStateByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentLifecycleStateByID, arg.ID, arg.LifecycleState)
+	return err
+}
+
+const updateWorkspaceAgentStartupLogOverflowByID = `-- name: UpdateWorkspaceAgentStartupLogOverflowByID :exec
+UPDATE
+	workspace_agents
+SET
+	startup_logs_overflowed = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAgentStartupLogOverflowByIDParams struct {
+	ID                    uuid.UUID `db:"id" json:"id"`
+	StartupLogsOverflowed bool      `db:"startup_logs_overflowed" json:"startup_logs_overflowed"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentStartupLogOverflowByID(ctx context.Context, arg UpdateWorkspaceAgentStartupLogOverflowByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentStartupLogOverflowByID, arg.ID, arg.StartupLogsOverflowed)
+	return err
+}
+
 const updateWorkspaceAgentVersionByID = `-- name: UpdateWorkspaceAgentVersionByID :exec
 UPDATE
 	workspace_agents
@@ -5025,9 +6534,61 @@ func (q *sqlQuerier) UpdateWorkspaceAppHealthByID(ctx context.Context, arg Updat
 	return err
 }
 
+const getWorkspaceBuildParameters = `-- name: GetWorkspaceBuildParameters :many
+SELECT
+	workspace_build_id, name, value
+FROM
+	workspace_build_parameters
+WHERE
+	workspace_build_id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceBuildParameters(ctx context.Context, workspaceBuildID uuid.UUID) ([]WorkspaceBuildParameter, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildParameters, workspaceBuildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceBuildParameter
+	for rows.Next() {
+		var i WorkspaceBuildParameter
+		if err := rows.Scan(&i.WorkspaceBuildID, &i.Name, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceBuildParameters = `-- name: InsertWorkspaceBuildParameters :exec
+INSERT INTO
+	workspace_build_parameters (workspace_build_id, name, value)
+SELECT
+	$1 :: uuid AS workspace_build_id,
+	unnest($2 :: text[]) AS name,
+	unnest($3 :: text[]) AS value
+`
+
+type InsertWorkspaceBuildParametersParams struct {
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	Name             []string  `db:"name" json:"name"`
+	Value            []string  `db:"value" json:"value"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceBuildParameters(ctx context.Context, arg InsertWorkspaceBuildParametersParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceBuildParameters, arg.WorkspaceBuildID, pq.Array(arg.Name), pq.Array(arg.Value))
+	return err
+}
+
 const getLatestWorkspaceBuildByWorkspaceID = `-- name: GetLatestWorkspaceBuildByWorkspaceID :one
 SELECT
-	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason
+	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, max_deadline
 FROM
 	workspace_builds
 WHERE
@@ -5054,12 +6615,13 @@ func (q *sqlQuerier) GetLatestWorkspaceBuildByWorkspaceID(ctx context.Context, w
 		&i.JobID,
 		&i.Deadline,
 		&i.Reason,
+		&i.MaxDeadline,
 	)
 	return i, err
 }
 
 const getLatestWorkspaceBuilds = `-- name: GetLatestWorkspaceBuilds :many
-SELECT wb.id, wb.created_at, wb.updated_at, wb.workspace_id, wb.template_version_id, wb.build_number, wb.transition, wb.initiator_id, wb.provisioner_state, wb.job_id, wb.deadline, wb.reason
+SELECT wb.id, wb.created_at, wb.updated_at, wb.workspace_id, wb.template_version_id, wb.build_number, wb.transition, wb.initiator_id, wb.provisioner_state, wb.job_id, wb.deadline, wb.reason, wb.max_deadline
 FROM (
     SELECT
         workspace_id, MAX(build_number) as max_build_number
@@ -5095,6 +6657,7 @@ func (q *sqlQuerier) GetLatestWorkspaceBuilds(ctx context.Context) ([]WorkspaceB
 			&i.JobID,
 			&i.Deadline,
 			&i.Reason,
+			&i.MaxDeadline,
 		); err != nil {
 			return nil, err
 		}
@@ -5110,7 +6673,7 @@ func (q *sqlQuerier) GetLatestWorkspaceBuilds(ctx context.Context) ([]WorkspaceB
 }
 
 const getLatestWorkspaceBuildsByWorkspaceIDs = `-- name: GetLatestWorkspaceBuildsByWorkspaceIDs :many
-SELECT wb.id, wb.created_at, wb.updated_at, wb.workspace_id, wb.template_version_id, wb.build_number, wb.transition, wb.initiator_id, wb.provisioner_state, wb.job_id, wb.deadline, wb.reason
+SELECT wb.id, wb.created_at, wb.updated_at, wb.workspace_id, wb.template_version_id, wb.build_number, wb.transition, wb.initiator_id, wb.provisioner_state, wb.job_id, wb.deadline, wb.reason, wb.max_deadline
 FROM (
     SELECT
         workspace_id, MAX(build_number) as max_build_number
@@ -5148,6 +6711,7 @@ func (q *sqlQuerier) GetLatestWorkspaceBuildsByWorkspaceIDs(ctx context.Context,
 			&i.JobID,
 			&i.Deadline,
 			&i.Reason,
+			&i.MaxDeadline,
 		); err != nil {
 			return nil, err
 		}
@@ -5164,7 +6728,7 @@ func (q *sqlQuerier) GetLatestWorkspaceBuildsByWorkspaceIDs(ctx context.Context,
 
 const getWorkspaceBuildByID = `-- name: GetWorkspaceBuildByID :one
 SELECT
-	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason
+	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, max_deadline
 FROM
 	workspace_builds
 WHERE
@@ -5189,13 +6753,14 @@ func (q *sqlQuerier) GetWorkspaceBuildByID(ctx context.Context, id uuid.UUID) (W
 		&i.JobID,
 		&i.Deadline,
 		&i.Reason,
+		&i.MaxDeadline,
 	)
 	return i, err
 }
 
 const getWorkspaceBuildByJobID = `-- name: GetWorkspaceBuildByJobID :one
 SELECT
-	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason
+	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, max_deadline
 FROM
 	workspace_builds
 WHERE
@@ -5220,13 +6785,14 @@ func (q *sqlQuerier) GetWorkspaceBuildByJobID(ctx context.Context, jobID uuid.UU
 		&i.JobID,
 		&i.Deadline,
 		&i.Reason,
+		&i.MaxDeadline,
 	)
 	return i, err
 }
 
 const getWorkspaceBuildByWorkspaceIDAndBuildNumber = `-- name: GetWorkspaceBuildByWorkspaceIDAndBuildNumber :one
 SELECT
-	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason
+	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, max_deadline
 FROM
 	workspace_builds
 WHERE
@@ -5255,13 +6821,14 @@ func (q *sqlQuerier) GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx context.Co
 		&i.JobID,
 		&i.Deadline,
 		&i.Reason,
+		&i.MaxDeadline,
 	)
 	return i, err
 }
 
 const getWorkspaceBuildsByWorkspaceID = `-- name: GetWorkspaceBuildsByWorkspaceID :many
 SELECT
-	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason
+	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, max_deadline
 FROM
 	workspace_builds
 WHERE
@@ -5329,6 +6896,7 @@ func (q *sqlQuerier) GetWorkspaceBuildsByWorkspaceID(ctx context.Context, arg Ge
 			&i.JobID,
 			&i.Deadline,
 			&i.Reason,
+			&i.MaxDeadline,
 		); err != nil {
 			return nil, err
 		}
@@ -5344,7 +6912,7 @@ func (q *sqlQuerier) GetWorkspaceBuildsByWorkspaceID(ctx context.Context, arg Ge
 }
 
 const getWorkspaceBuildsCreatedAfter = `-- name: GetWorkspaceBuildsCreatedAfter :many
-SELECT id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason FROM workspace_builds WHERE created_at > $1
+SELECT id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, max_deadline FROM workspace_builds WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetWorkspaceBuildsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceBuild, error) {
@@ -5369,6 +6937,7 @@ func (q *sqlQuerier) GetWorkspaceBuildsCreatedAfter(ctx context.Context, created
 			&i.JobID,
 			&i.Deadline,
 			&i.Reason,
+			&i.MaxDeadline,
 		); err != nil {
 			return nil, err
 		}
@@ -5400,7 +6969,7 @@ INSERT INTO
 		reason
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, max_deadline
 `
 
 type InsertWorkspaceBuildParams struct {
@@ -5447,6 +7016,7 @@ func (q *sqlQuerier) InsertWorkspaceBuild(ctx context.Context, arg InsertWorkspa
 		&i.JobID,
 		&i.Deadline,
 		&i.Reason,
+		&i.MaxDeadline,
 	)
 	return i, err
 }
@@ -5457,7 +7027,8 @@ UPDATE
 SET
 	updated_at = $2,
 	provisioner_state = $3,
-	deadline = $4
+	deadline = $4,
+	max_deadline = $5
 WHERE
 	id = $1
 `
@@ -5467,6 +7038,7 @@ type UpdateWorkspaceBuildByIDParams struct {
 	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
 	ProvisionerState []byte    `db:"provisioner_state" json:"provisioner_state"`
 	Deadline         time.Time `db:"deadline" json:"deadline"`
+	MaxDeadline      time.Time `db:"max_deadline" json:"max_deadline"`
 }
 
 func (q *sqlQuerier) UpdateWorkspaceBuildByID(ctx context.Context, arg UpdateWorkspaceBuildByIDParams) error {
@@ -5475,7 +7047,237 @@ func (q *sqlQuerier) UpdateWorkspaceBuildByID(ctx context.Context, arg UpdateWor
 		arg.UpdatedAt,
 		arg.ProvisionerState,
 		arg.Deadline,
+		arg.MaxDeadline,
+	)
+	return err
+}
+
+const getWorkspaceProxies = `-- name: GetWorkspaceProxies :many
+SELECT
+	id, name, display_name, icon, url, wildcard_hostname, derp_enabled, region_id, token_hashed_secret, created_at, updated_at, deleted
+FROM
+	workspace_proxies
+WHERE
+	deleted = false
+ORDER BY
+	name ASC
+`
+
+func (q *sqlQuerier) GetWorkspaceProxies(ctx context.Context) ([]WorkspaceProxy, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceProxies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceProxy
+	for rows.Next() {
+		var i WorkspaceProxy
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.DisplayName,
+			&i.Icon,
+			&i.Url,
+			&i.WildcardHostname,
+			&i.DerpEnabled,
+			&i.RegionID,
+			&i.TokenHashedSecret,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Deleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceProxyByID = `-- name: GetWorkspaceProxyByID :one
+SELECT
+	id, name, display_name, icon, url, wildcard_hostname, derp_enabled, region_id, token_hashed_secret, created_at, updated_at, deleted
+FROM
+	workspace_proxies
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetWorkspaceProxyByID(ctx context.Context, id uuid.UUID) (WorkspaceProxy, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceProxyByID, id)
+	var i WorkspaceProxy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Icon,
+		&i.Url,
+		&i.WildcardHostname,
+		&i.DerpEnabled,
+		&i.RegionID,
+		&i.TokenHashedSecret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+	)
+	return i, err
+}
+
+const getWorkspaceProxyByName = `-- name: GetWorkspaceProxyByName :one
+SELECT
+	id, name, display_name, icon, url, wildcard_hostname, derp_enabled, region_id, token_hashed_secret, created_at, updated_at, deleted
+FROM
+	workspace_proxies
+WHERE
+	lower(name) = lower($1)
+	AND deleted = false
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetWorkspaceProxyByName(ctx context.Context, name string) (WorkspaceProxy, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceProxyByName, name)
+	var i WorkspaceProxy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Icon,
+		&i.Url,
+		&i.WildcardHostname,
+		&i.DerpEnabled,
+		&i.RegionID,
+		&i.TokenHashedSecret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+	)
+	return i, err
+}
+
+const insertWorkspaceProxy = `-- name: InsertWorkspaceProxy :one
+INSERT INTO
+	workspace_proxies (
+		id,
+		name,
+		display_name,
+		icon,
+		token_hashed_secret,
+		created_at,
+		updated_at,
+		deleted
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, false) RETURNING id, name, display_name, icon, url, wildcard_hostname, derp_enabled, region_id, token_hashed_secret, created_at, updated_at, deleted
+`
+
+type InsertWorkspaceProxyParams struct {
+	ID                uuid.UUID `db:"id" json:"id"`
+	Name              string    `db:"name" json:"name"`
+	DisplayName       string    `db:"display_name" json:"display_name"`
+	Icon              string    `db:"icon" json:"icon"`
+	TokenHashedSecret []byte    `db:"token_hashed_secret" json:"token_hashed_secret"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceProxy(ctx context.Context, arg InsertWorkspaceProxyParams) (WorkspaceProxy, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceProxy,
+		arg.ID,
+		arg.Name,
+		arg.DisplayName,
+		arg.Icon,
+		arg.TokenHashedSecret,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i WorkspaceProxy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Icon,
+		&i.Url,
+		&i.WildcardHostname,
+		&i.DerpEnabled,
+		&i.RegionID,
+		&i.TokenHashedSecret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+	)
+	return i, err
+}
+
+const registerWorkspaceProxy = `-- name: RegisterWorkspaceProxy :one
+UPDATE
+	workspace_proxies
+SET
+	url = $1,
+	wildcard_hostname = $2,
+	derp_enabled = $3,
+	updated_at = Now()
+WHERE
+	id = $4
+RETURNING id, name, display_name, icon, url, wildcard_hostname, derp_enabled, region_id, token_hashed_secret, created_at, updated_at, deleted
+`
+
+type RegisterWorkspaceProxyParams struct {
+	Url              string    `db:"url" json:"url"`
+	WildcardHostname string    `db:"wildcard_hostname" json:"wildcard_hostname"`
+	DerpEnabled      bool      `db:"derp_enabled" json:"derp_enabled"`
+	ID               uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) RegisterWorkspaceProxy(ctx context.Context, arg RegisterWorkspaceProxyParams) (WorkspaceProxy, error) {
+	row := q.db.QueryRowContext(ctx, registerWorkspaceProxy,
+		arg.Url,
+		arg.WildcardHostname,
+		arg.DerpEnabled,
+		arg.ID,
+	)
+	var i WorkspaceProxy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Icon,
+		&i.Url,
+		&i.WildcardHostname,
+		&i.DerpEnabled,
+		&i.RegionID,
+		&i.TokenHashedSecret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
 	)
+	return i, err
+}
+
+const updateWorkspaceProxyDeleted = `-- name: UpdateWorkspaceProxyDeleted :exec
+UPDATE
+	workspace_proxies
+SET
+	updated_at = Now(),
+	deleted = $1
+WHERE
+	id = $2
+`
+
+type UpdateWorkspaceProxyDeletedParams struct {
+	Deleted bool      `db:"deleted" json:"deleted"`
+	ID      uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceProxyDeleted(ctx context.Context, arg UpdateWorkspaceProxyDeletedParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceProxyDeleted, arg.Deleted, arg.ID)
 	return err
 }
 
@@ -5813,7 +7615,7 @@ func (q *sqlQuerier) InsertWorkspaceResourceMetadata(ctx context.Context, arg In
 
 const getWorkspaceByID = `-- name: GetWorkspaceByID :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at
 FROM
 	workspaces
 WHERE
@@ -5837,13 +7639,14 @@ func (q *sqlQuerier) GetWorkspaceByID(ctx context.Context, id uuid.UUID) (Worksp
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DormantAt,
 	)
 	return i, err
 }
 
 const getWorkspaceByOwnerIDAndName = `-- name: GetWorkspaceByOwnerIDAndName :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at
 FROM
 	workspaces
 WHERE
@@ -5874,6 +7677,7 @@ func (q *sqlQuerier) GetWorkspaceByOwnerIDAndName(ctx context.Context, arg GetWo
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DormantAt,
 	)
 	return i, err
 }
@@ -6032,6 +7836,30 @@ func (q *sqlQuerier) GetWorkspaceCount(ctx context.Context, arg GetWorkspaceCoun
 	return count, err
 }
 
+const getWorkspaceCountByOrganizationAndOwner = `-- name: GetWorkspaceCountByOrganizationAndOwner :one
+SELECT
+	COUNT(id)
+FROM
+	workspaces
+WHERE
+	organization_id = $1
+	AND owner_id = $2
+	-- Ignore deleted workspaces
+	AND deleted != true
+`
+
+type GetWorkspaceCountByOrganizationAndOwnerParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	OwnerID        uuid.UUID `db:"owner_id" json:"owner_id"`
+}
+
+func (q *sqlQuerier) GetWorkspaceCountByOrganizationAndOwner(ctx context.Context, arg GetWorkspaceCountByOrganizationAndOwnerParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceCountByOrganizationAndOwner, arg.OrganizationID, arg.OwnerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getWorkspaceCountByUserID = `-- name: GetWorkspaceCountByUserID :one
 SELECT
 	COUNT(id)
@@ -6094,7 +7922,7 @@ func (q *sqlQuerier) GetWorkspaceOwnerCountsByTemplateIDs(ctx context.Context, i
 
 const getWorkspaces = `-- name: GetWorkspaces :many
 SELECT
-	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at
+	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at, workspaces.dormant_at
 FROM
 	workspaces
 LEFT JOIN LATERAL (
@@ -6272,6 +8100,7 @@ func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams)
 			&i.AutostartSchedule,
 			&i.Ttl,
 			&i.LastUsedAt,
+			&i.DormantAt,
 		); err != nil {
 			return nil, err
 		}
@@ -6300,7 +8129,7 @@ INSERT INTO
 		ttl
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at
 `
 
 type InsertWorkspaceParams struct {
@@ -6340,6 +8169,7 @@ func (q *sqlQuerier) InsertWorkspace(ctx context.Context, arg InsertWorkspacePar
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DormantAt,
 	)
 	return i, err
 }
@@ -6352,7 +8182,7 @@ SET
 WHERE
 	id = $1
 	AND deleted = false
-RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at
 `
 
 type UpdateWorkspaceParams struct {
@@ -6375,6 +8205,7 @@ func (q *sqlQuerier) UpdateWorkspace(ctx context.Context, arg UpdateWorkspacePar
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DormantAt,
 	)
 	return i, err
 }
@@ -6417,6 +8248,41 @@ func (q *sqlQuerier) UpdateWorkspaceDeletedByID(ctx context.Context, arg UpdateW
 	return err
 }
 
+const updateWorkspaceDormantAt = `-- name: UpdateWorkspaceDormantAt :one
+UPDATE
+	workspaces
+SET
+	dormant_at = $2
+WHERE
+	id = $1
+RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at
+`
+
+type UpdateWorkspaceDormantAtParams struct {
+	ID        uuid.UUID    `db:"id" json:"id"`
+	DormantAt sql.NullTime `db:"dormant_at" json:"dormant_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceDormantAt(ctx context.Context, arg UpdateWorkspaceDormantAtParams) (Workspace, error) {
+	row := q.db.QueryRowContext(ctx, updateWorkspaceDormantAt, arg.ID, arg.DormantAt)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OwnerID,
+		&i.OrganizationID,
+		&i.TemplateID,
+		&i.Deleted,
+		&i.Name,
+		&i.AutostartSchedule,
+		&i.Ttl,
+		&i.LastUsedAt,
+		&i.DormantAt,
+	)
+	return i, err
+}
+
 const updateWorkspaceLastUsedAt = `-- name: UpdateWorkspaceLastUsedAt :exec
 UPDATE
 	workspaces
@@ -27,7 +27,8 @@ INSERT INTO
 		created_at,
 		updated_at,
 		login_type,
-		scope
+		scope,
+		scopes
 	)
 VALUES
 	(@id,
@@ -36,7 +37,9 @@ VALUES
 	     WHEN 0 THEN 86400
 		 ELSE @lifetime_seconds::bigint
 	 END
-	 , @hashed_secret, @ip_address, @user_id, @last_used, @expires_at, @created_at, @updated_at, @login_type, @scope) RETURNING *;
+	 , @hashed_secret, @ip_address, @user_id, @last_used, @expires_at, @created_at, @updated_at, @login_type, @scope,
+	 -- Keys without fine-grained scopes store an empty list.
+	 COALESCE(@scopes :: text[], '{}' :: text[])) RETURNING *;
 
 -- name: UpdateAPIKeyByID :exec
 UPDATE
@@ -50,6 +50,12 @@ WHERE
 			users.email = @email
 		ELSE true
 	END
+	-- Filter by organization_id
+	AND CASE
+		WHEN @organization_id :: uuid != '00000000-00000000-00000000-00000000' THEN
+			audit_logs.organization_id = @organization_id
+		ELSE true
+	END
 ORDER BY
     "time" DESC
 LIMIT
@@ -98,6 +104,12 @@ WHERE
 		WHEN @email :: text != '' THEN
 			user_id = (SELECT id from users WHERE users.email = @email )
 		ELSE true
+	END
+	-- Filter by organization_id
+	AND CASE
+		WHEN @organization_id :: uuid != '00000000-00000000-00000000-00000000' THEN
+			organization_id = @organization_id
+		ELSE true
 	END;
 
 -- name: InsertAuditLog :one
@@ -0,0 +1,52 @@
+-- name: InsertConnectionStat :one
+INSERT INTO
+	connection_stats (
+		id,
+		created_at,
+		source,
+		agent_id,
+		workspace_id,
+		user_id,
+		peer_ip,
+		direct,
+		endpoint,
+		derp_region_id,
+		latency_ms,
+		rx_bytes,
+		tx_bytes
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING *;
+
+-- name: GetConnectionStatsByAgentID :many
+SELECT
+	*
+FROM
+	connection_stats
+WHERE
+	agent_id = $1
+	AND created_at > $2
+ORDER BY
+	created_at DESC;
+
+-- name: GetConnectionStatsByRegion :many
+-- Connections are identified by the agent and the peer's IP, since every
+-- connection is reported repeatedly while it's open. A connection counts as
+-- direct if it was direct in any of its reports.
+SELECT
+	derp_region_id,
+	source,
+	count(DISTINCT agent_id::text || peer_ip) AS connections,
+	count(DISTINCT agent_id::text || peer_ip) FILTER (WHERE direct) AS direct_connections,
+	coalesce(avg(latency_ms) FILTER (WHERE latency_ms > 0), 0)::double precision AS average_latency_ms
+FROM
+	connection_stats
+WHERE
+	created_at > $1
+GROUP BY
+	derp_region_id, source
+ORDER BY
+	derp_region_id, source;
+
+-- name: DeleteOldConnectionStats :exec
+DELETE FROM connection_stats WHERE created_at < now() - interval '30 days';
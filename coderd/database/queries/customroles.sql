@@ -0,0 +1,67 @@
+-- name: GetCustomRoles :many
+SELECT
+	*
+FROM
+	custom_roles
+ORDER BY
+	name ASC;
+
+-- name: GetCustomRoleByName :one
+SELECT
+	*
+FROM
+	custom_roles
+WHERE
+	name = @name
+	AND organization_id IS NOT DISTINCT FROM @organization_id
+LIMIT
+	1;
+
+-- name: InsertCustomRole :one
+INSERT INTO
+	custom_roles (
+		id,
+		name,
+		display_name,
+		organization_id,
+		site_permissions,
+		org_permissions,
+		user_permissions,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
+
+-- name: UpdateCustomRoleByID :one
+UPDATE
+	custom_roles
+SET
+	display_name = $2,
+	site_permissions = $3,
+	org_permissions = $4,
+	user_permissions = $5,
+	updated_at = $6
+WHERE
+	id = $1
+RETURNING *;
+
+-- name: DeleteCustomRoleByID :exec
+DELETE FROM
+	custom_roles
+WHERE
+	id = $1;
+
+-- name: RemoveRoleFromUsers :exec
+UPDATE
+	users
+SET
+	rbac_roles = array_remove(rbac_roles, @role_name :: text);
+
+-- name: RemoveRoleFromOrganizationMembers :exec
+UPDATE
+	organization_members
+SET
+	roles = array_remove(roles, @role_name :: text)
+WHERE
+	organization_id = @organization_id;
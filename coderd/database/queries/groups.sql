@@ -115,6 +115,29 @@ DELETE FROM
 WHERE
 	user_id = $1;
 
+-- name: DeleteGroupMemberFromGroup :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = $1
+AND
+	group_id = $2;
+
+-- name: DeleteGroupMembersByOrgAndUser :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = @user_id
+AND
+	group_id IN (
+		SELECT
+			id
+		FROM
+			groups
+		WHERE
+			organization_id = @organization_id
+	);
+
 -- name: DeleteGroupByID :exec
 DELETE FROM
 	groups
@@ -9,6 +9,23 @@ WHERE
 LIMIT
 	1;
 
+-- name: GetOrganizationMembers :many
+SELECT
+	organization_members.*,
+	users.username,
+	users.email
+FROM
+	organization_members
+JOIN
+	users
+ON
+	users.id = organization_members.user_id
+WHERE
+	organization_members.organization_id = @organization_id
+	AND users.deleted = false
+ORDER BY
+	users.username ASC;
+
 -- name: InsertOrganizationMember :one
 INSERT INTO
 	organization_members (
@@ -21,6 +38,13 @@ INSERT INTO
 VALUES
 	($1, $2, $3, $4, $5) RETURNING *;
 
+-- name: DeleteOrganizationMember :exec
+DELETE FROM
+	organization_members
+WHERE
+	organization_id = @organization_id
+	AND user_id = @user_id;
+
 
 -- name: GetOrganizationMembershipsByUserID :many
 SELECT
@@ -28,7 +28,7 @@ SELECT
 FROM
 	organizations
 WHERE
-	id = (
+	id = ANY(
 		SELECT
 			organization_id
 		FROM
@@ -42,3 +42,14 @@ INSERT INTO
 	organizations (id, "name", description, created_at, updated_at)
 VALUES
 	($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: UpdateOrganization :one
+UPDATE
+	organizations
+SET
+	"name" = @name,
+	user_workspace_quota = @user_workspace_quota,
+	updated_at = @updated_at
+WHERE
+	id = @id
+RETURNING *;
@@ -18,10 +18,12 @@ INSERT INTO
 		id,
 		created_at,
 		"name",
-		provisioners
+		provisioners,
+		tags,
+		organization_id
 	)
 VALUES
-	($1, $2, $3, $4) RETURNING *;
+	($1, $2, $3, $4, $5, $6) RETURNING *;
 
 -- name: UpdateProvisionerDaemonByID :exec
 UPDATE
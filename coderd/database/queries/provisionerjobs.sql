@@ -1,5 +1,6 @@
 -- Acquires the lock for a single job that isn't started, completed,
--- canceled, and that matches an array of provisioner types.
+-- canceled, and that matches an array of provisioner types. The job's
+-- tags must be a subset of the tags of the acquiring daemon.
 --
 -- SKIP LOCKED is used to jump over locked rows. This prevents
 -- multiple provisioners from acquiring the same jobs. See:
@@ -22,6 +23,13 @@ WHERE
 			AND nested.canceled_at IS NULL
 			AND nested.completed_at IS NULL
 			AND nested.provisioner = ANY(@types :: provisioner_type [ ])
+			AND nested.tags <@ @tags :: jsonb
+			-- Daemons of an organization only acquire its jobs.
+			AND CASE
+				WHEN @organization_id :: uuid != '00000000-00000000-00000000-00000000' THEN
+					nested.organization_id = @organization_id
+				ELSE true
+			END
 		ORDER BY
 			nested.created_at FOR
 		UPDATE
@@ -61,10 +69,11 @@ INSERT INTO
 		storage_method,
 		file_id,
 		"type",
-		"input"
+		"input",
+		tags
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *;
 
 -- name: UpdateProvisionerJobByID :exec
 UPDATE
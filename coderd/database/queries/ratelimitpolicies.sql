@@ -0,0 +1,44 @@
+-- name: GetRateLimitPolicies :many
+SELECT
+	*
+FROM
+	rate_limit_policies
+ORDER BY
+	subject_type ASC,
+	subject ASC;
+
+-- name: GetRateLimitPolicyByID :one
+SELECT
+	*
+FROM
+	rate_limit_policies
+WHERE
+	id = $1
+LIMIT
+	1;
+
+-- name: UpsertRateLimitPolicy :one
+INSERT INTO
+	rate_limit_policies (
+		id,
+		subject_type,
+		subject,
+		count,
+		window_seconds,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (subject_type, subject) DO UPDATE
+SET
+	count = $4,
+	window_seconds = $5,
+	updated_at = $7
+RETURNING *;
+
+-- name: DeleteRateLimitPolicyByID :exec
+DELETE FROM
+	rate_limit_policies
+WHERE
+	id = $1;
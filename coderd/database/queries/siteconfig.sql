@@ -9,3 +9,15 @@ INSERT INTO site_configs (key, value) VALUES ('derp_mesh_key', $1);
 
 -- name: GetDERPMeshKey :one
 SELECT value FROM site_configs WHERE key = 'derp_mesh_key';
+
+-- name: InsertAppSecurityKey :exec
+INSERT INTO site_configs (key, value) VALUES ('app_signing_key', $1);
+
+-- name: GetAppSecurityKey :one
+SELECT value FROM site_configs WHERE key = 'app_signing_key';
+
+-- name: InsertSSHCertificateAuthorityKey :exec
+INSERT INTO site_configs (key, value) VALUES ('ssh_certificate_authority_key', $1);
+
+-- name: GetSSHCertificateAuthorityKey :one
+SELECT value FROM site_configs WHERE key = 'ssh_certificate_authority_key';
@@ -102,7 +102,12 @@ SET
 	max_ttl = $4,
 	min_autostart_interval = $5,
 	name = $6,
-	icon = $7
+	icon = $7,
+	autostop_requirement_days = $8,
+	inactivity_ttl = $9,
+	dormant_delete_ttl = $10,
+	port_forwarding_allowlist = $11,
+	record_sessions = $12
 WHERE
 	id = $1
 RETURNING
@@ -37,7 +37,9 @@ SELECT
 FROM
 	users
 WHERE
-    status = 'active'::public.user_status AND deleted = false;
+    status = 'active'::public.user_status AND deleted = false
+	-- Service accounts are not people, so they don't count as active users.
+	AND service_account = false;
 
 -- name: InsertUser :one
 INSERT INTO
@@ -49,10 +51,12 @@ INSERT INTO
 		created_at,
 		updated_at,
 		rbac_roles,
-		login_type
+		login_type,
+		service_account,
+		contact
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
 
 -- name: UpdateUserProfile :one
 UPDATE
@@ -169,6 +173,14 @@ SET
 WHERE
 	id = $1 RETURNING *;
 
+-- name: UpdateUserQuietHoursSchedule :one
+UPDATE
+	users
+SET
+	quiet_hours_schedule = $2
+WHERE
+	id = $1
+RETURNING *;
 
 -- name: GetAuthorizationUserRoles :one
 -- This function returns roles for authorization purposes. Implied member roles
@@ -177,7 +189,9 @@ SELECT
 	-- username is returned just to help for logging purposes
 	-- status is used to enforce 'suspended' users, as all roles are ignored
 	--	when suspended.
-	id, username, status,
+	-- service_account is used to keep the tokens of service accounts from
+	--	being extended.
+	id, username, status, service_account,
 	-- All user roles, including their org roles.
 	array_cat(
 		-- All users are members
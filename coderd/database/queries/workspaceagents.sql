@@ -53,10 +53,14 @@ INSERT INTO
 		startup_script,
 		directory,
 		instance_metadata,
-		resource_metadata
+		resource_metadata,
+		startup_script_timeout_seconds,
+		login_before_ready,
+		shutdown_script,
+		shutdown_script_timeout_seconds
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18) RETURNING *;
 
 -- name: UpdateWorkspaceAgentConnectionByID :exec
 UPDATE
@@ -76,3 +80,44 @@ SET
 	version = $2
 WHERE
 	id = $1;
+
+-- name: UpdateWorkspaceAgentStartupLogOverflowByID :exec
+UPDATE
+	workspace_agents
+SET
+	startup_logs_overflowed = $2
+WHERE
+	id = $1;
+
+-- name: GetWorkspaceAgentStartupLogsAfter :many
+SELECT
+	*
+FROM
+	workspace_agent_startup_logs
+WHERE
+	agent_id = $1
+	AND (
+		id > @created_after
+	) ORDER BY id ASC;
+
+-- name: InsertWorkspaceAgentStartupLogs :many
+WITH new_length AS (
+	UPDATE workspace_agents SET
+	startup_logs_length = startup_logs_length + @output_length WHERE workspace_agents.id = @agent_id
+)
+INSERT INTO
+	workspace_agent_startup_logs (agent_id, created_at, output, eof)
+SELECT
+	@agent_id :: uuid,
+	unnest(@created_at :: timestamptz [ ]) AS created_at,
+	unnest(@output :: VARCHAR(1024) [ ]) AS output,
+	unnest(@eof :: boolean [ ]) AS eof
+	RETURNING workspace_agent_startup_logs.*;
+
+-- name: UpdateWorkspaceAgentLifecycleStateByID :exec
+UPDATE
+	workspace_agents
+SET
+	lifecycle_state = $2
+WHERE
+	id = $1;
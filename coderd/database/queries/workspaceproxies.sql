@@ -0,0 +1,66 @@
+-- name: InsertWorkspaceProxy :one
+INSERT INTO
+	workspace_proxies (
+		id,
+		name,
+		display_name,
+		icon,
+		token_hashed_secret,
+		created_at,
+		updated_at,
+		deleted
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, false) RETURNING *;
+
+-- name: RegisterWorkspaceProxy :one
+UPDATE
+	workspace_proxies
+SET
+	url = @url,
+	wildcard_hostname = @wildcard_hostname,
+	derp_enabled = @derp_enabled,
+	updated_at = Now()
+WHERE
+	id = @id
+RETURNING *;
+
+-- name: UpdateWorkspaceProxyDeleted :exec
+UPDATE
+	workspace_proxies
+SET
+	updated_at = Now(),
+	deleted = @deleted
+WHERE
+	id = @id;
+
+-- name: GetWorkspaceProxyByID :one
+SELECT
+	*
+FROM
+	workspace_proxies
+WHERE
+	id = $1
+LIMIT
+	1;
+
+-- name: GetWorkspaceProxyByName :one
+SELECT
+	*
+FROM
+	workspace_proxies
+WHERE
+	lower(name) = lower(@name)
+	AND deleted = false
+LIMIT
+	1;
+
+-- name: GetWorkspaceProxies :many
+SELECT
+	*
+FROM
+	workspace_proxies
+WHERE
+	deleted = false
+ORDER BY
+	name ASC;
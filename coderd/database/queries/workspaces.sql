@@ -298,6 +298,17 @@ WHERE
 GROUP BY
 	template_id;
 
+-- name: GetWorkspaceCountByOrganizationAndOwner :one
+SELECT
+	COUNT(id)
+FROM
+	workspaces
+WHERE
+	organization_id = @organization_id
+	AND owner_id = @owner_id
+	-- Ignore deleted workspaces
+	AND deleted != true;
+
 -- name: GetWorkspaceCountByUserID :one
 SELECT
 	COUNT(id)
@@ -365,3 +376,12 @@ SET
 	last_used_at = $2
 WHERE
 	id = $1;
+
+-- name: UpdateWorkspaceDormantAt :one
+UPDATE
+	workspaces
+SET
+	dormant_at = $2
+WHERE
+	id = $1
+RETURNING *;
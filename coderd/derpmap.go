@@ -0,0 +1,74 @@
+package coderd
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"tailscale.com/tailcfg"
+
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/codersdk"
+)
+
+// derpMapUpdates streams the DERP map to agents and clients, so they move off
+// regions that become unhealthy and onto regions that are added.
+func (api *API) derpMapUpdates(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sendEvent, senderClosed, err := httpapi.ServerSentEventSender(rw, r)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error setting up server-sent events.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Prevent handler from returning until the sender is closed.
+	defer func() {
+		<-senderClosed
+	}()
+
+	// Ignore all trace spans after this, they're not too useful.
+	ctx = trace.ContextWithSpan(ctx, tracing.NoopSpan)
+
+	ticker := time.NewTicker(api.DERPMapUpdateFrequency)
+	defer ticker.Stop()
+	var lastDERPMap *tailcfg.DERPMap
+	for {
+		derpMap := api.CurrentDERPMap()
+		if lastDERPMap == nil || !reflect.DeepEqual(derpMap, lastDERPMap) {
+			err = sendEvent(ctx, codersdk.ServerSentEvent{
+				Type: codersdk.ServerSentEventTypeData,
+				Data: derpMap,
+			})
+			if err != nil {
+				return
+			}
+			lastDERPMap = derpMap
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-senderClosed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (api *API) derpMapHealth(rw http.ResponseWriter, r *http.Request) {
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceDeploymentConfig) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	health := []codersdk.DERPRegionHealth{}
+	if api.DERPMapRefresher != nil {
+		health = api.DERPMapRefresher.Health()
+	}
+	httpapi.Write(r.Context(), rw, http.StatusOK, health)
+}
@@ -0,0 +1,409 @@
+// Package derpmap keeps the DERP map handed to agents and clients up to date.
+// The map is fetched from a Provider periodically and every region is health
+// checked, so an outage of one relay region doesn't break connections until
+// coderd is restarted.
+package derpmap
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"golang.org/x/xerrors"
+	"tailscale.com/net/stun"
+	"tailscale.com/tailcfg"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk"
+)
+
+// Provider fetches the DERP map. It's called on every refresh, so providers
+// that read a remote URL or a file pick up changes without a restart.
+type Provider func(ctx context.Context) (*tailcfg.DERPMap, error)
+
+// StaticProvider returns a Provider that always returns derpMap.
+func StaticProvider(derpMap *tailcfg.DERPMap) Provider {
+	return func(context.Context) (*tailcfg.DERPMap, error) {
+		return derpMap.Clone(), nil
+	}
+}
+
+// HealthCheck checks the nodes of a single region.
+type HealthCheck func(ctx context.Context, region *tailcfg.DERPRegion) codersdk.DERPRegionHealth
+
+type Options struct {
+	Logger   slog.Logger
+	Provider Provider
+	// RefreshInterval is how often the DERP map is fetched and its regions
+	// are checked. Defaults to a minute.
+	RefreshInterval time.Duration
+	// HealthCheck defaults to CheckRegion.
+	HealthCheck HealthCheck
+}
+
+// Refresher periodically fetches the DERP map from a Provider and removes or
+// deprioritizes regions that fail their health checks.
+type Refresher struct {
+	opts Options
+
+	derpMap atomic.Pointer[tailcfg.DERPMap]
+	health  atomic.Pointer[[]codersdk.DERPRegionHealth]
+
+	// latest is the last map returned by the provider, before health checks
+	// were applied. It's used when the provider fails.
+	latest *tailcfg.DERPMap
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New fetches and checks the DERP map once, then keeps refreshing it in the
+// background until Close is called. An error is returned if the first fetch
+// fails.
+func New(ctx context.Context, opts Options) (*Refresher, error) {
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = time.Minute
+	}
+	if opts.HealthCheck == nil {
+		opts.HealthCheck = CheckRegion
+	}
+	r := &Refresher{
+		opts: opts,
+		done: make(chan struct{}),
+	}
+	derpMap, err := opts.Provider(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("fetch derp map: %w", err)
+	}
+	r.latest = derpMap
+	r.check(ctx)
+
+	ctx, r.cancel = context.WithCancel(context.Background())
+	go r.run(ctx)
+	return r, nil
+}
+
+// DERPMap returns the DERP map with unhealthy regions removed. It must not be
+// modified.
+func (r *Refresher) DERPMap() *tailcfg.DERPMap {
+	return r.derpMap.Load()
+}
+
+// Health returns the result of the last health check of every region,
+// ordered by region ID.
+func (r *Refresher) Health() []codersdk.DERPRegionHealth {
+	return *r.health.Load()
+}
+
+// Close stops refreshing the DERP map.
+func (r *Refresher) Close() error {
+	r.cancel()
+	<-r.done
+	return nil
+}
+
+func (r *Refresher) run(ctx context.Context) {
+	defer close(r.done)
+	ticker := time.NewTicker(r.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		derpMap, err := r.opts.Provider(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Keep checking the last known map, so regions that recover
+			// are restored even while the provider is down.
+			r.opts.Logger.Warn(ctx, "fetch derp map", slog.Error(err))
+		} else {
+			r.latest = derpMap
+		}
+		r.check(ctx)
+	}
+}
+
+// check health checks every region of the latest map and publishes the
+// result.
+func (r *Refresher) check(ctx context.Context) {
+	derpMap := r.latest.Clone()
+	regionIDs := sortedRegionIDs(derpMap)
+
+	results := make([]codersdk.DERPRegionHealth, len(regionIDs))
+	var wg sync.WaitGroup
+	for i, regionID := range regionIDs {
+		i, region := i, derpMap.Regions[regionID]
+		if region.EmbeddedRelay {
+			// coderd serves embedded relays itself, so they're up whenever
+			// coderd is. Checking them through the access URL fails in
+			// deployments where coderd can't reach itself that way.
+			results[i] = codersdk.DERPRegionHealth{
+				RegionID:   region.RegionID,
+				RegionCode: region.RegionCode,
+				RegionName: region.RegionName,
+				Status:     codersdk.DERPRegionStatusHealthy,
+				Nodes:      []codersdk.DERPNodeHealth{},
+				CheckedAt:  time.Now(),
+			}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.opts.HealthCheck(ctx, region)
+		}()
+	}
+	wg.Wait()
+
+	previous := map[int]codersdk.DERPRegionStatus{}
+	if health := r.health.Load(); health != nil {
+		for _, region := range *health {
+			previous[region.RegionID] = region.Status
+		}
+	}
+	usable := 0
+	for _, result := range results {
+		if result.Status != codersdk.DERPRegionStatusUnhealthy {
+			usable++
+		}
+		if status, ok := previous[result.RegionID]; ok && status != result.Status {
+			r.opts.Logger.Warn(ctx, "derp region health changed",
+				slog.F("region_id", result.RegionID),
+				slog.F("region_code", result.RegionCode),
+				slog.F("previous", status),
+				slog.F("status", result.Status),
+			)
+		}
+	}
+
+	// If every region fails, the problem is more likely on our side, e.g.
+	// coderd lost its network. Handing out an empty map would break every
+	// connection, so leave the map alone.
+	if usable > 0 {
+		for _, result := range results {
+			switch result.Status {
+			case codersdk.DERPRegionStatusUnhealthy:
+				delete(derpMap.Regions, result.RegionID)
+			case codersdk.DERPRegionStatusDegraded:
+				derpMap.Regions[result.RegionID].Avoid = true
+			}
+		}
+	}
+	r.health.Store(&results)
+	r.derpMap.Store(derpMap)
+}
+
+// CheckRegion checks every node of a region. The region is healthy if every
+// node passes, degraded if some nodes fail, and unhealthy if none of its
+// relays work. Regions with only STUN nodes are unhealthy if none of them
+// respond.
+func CheckRegion(ctx context.Context, region *tailcfg.DERPRegion) codersdk.DERPRegionHealth {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	results := make([]nodeResult, len(region.Nodes))
+	var wg sync.WaitGroup
+	for i, node := range region.Nodes {
+		i, node := i, node
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = checkNode(ctx, node)
+		}()
+	}
+	wg.Wait()
+
+	var (
+		relays, workingRelays int
+		stuns, workingSTUNs   int
+		failed                bool
+		latency               float64
+		nodes                 = make([]codersdk.DERPNodeHealth, 0, len(results))
+	)
+	for i, result := range results {
+		nodes = append(nodes, result.health)
+		if result.health.Error != "" {
+			failed = true
+		}
+		if !region.Nodes[i].STUNOnly {
+			relays++
+			if result.derpOK {
+				workingRelays++
+				if latency == 0 || result.health.DERPLatencyMS < latency {
+					latency = result.health.DERPLatencyMS
+				}
+			}
+			continue
+		}
+		if region.Nodes[i].STUNPort >= 0 {
+			stuns++
+			if result.stunOK {
+				workingSTUNs++
+			}
+		}
+	}
+
+	status := codersdk.DERPRegionStatusHealthy
+	switch {
+	case relays > 0 && workingRelays == 0, relays == 0 && stuns > 0 && workingSTUNs == 0:
+		status = codersdk.DERPRegionStatusUnhealthy
+	case failed:
+		status = codersdk.DERPRegionStatusDegraded
+	}
+	return codersdk.DERPRegionHealth{
+		RegionID:   region.RegionID,
+		RegionCode: region.RegionCode,
+		RegionName: region.RegionName,
+		Status:     status,
+		LatencyMS:  latency,
+		Nodes:      nodes,
+		CheckedAt:  time.Now(),
+	}
+}
+
+type nodeResult struct {
+	health codersdk.DERPNodeHealth
+	derpOK bool
+	stunOK bool
+}
+
+func checkNode(ctx context.Context, node *tailcfg.DERPNode) nodeResult {
+	result := nodeResult{
+		health: codersdk.DERPNodeHealth{
+			Name:     node.Name,
+			HostName: node.HostName,
+			STUNOnly: node.STUNOnly,
+		},
+	}
+	var errs []string
+	if !node.STUNOnly {
+		latency, err := checkDERP(ctx, node)
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			result.derpOK = true
+			result.health.DERPLatencyMS = milliseconds(latency)
+		}
+	}
+	if node.STUNPort >= 0 {
+		latency, err := checkSTUN(ctx, node)
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			result.stunOK = true
+			result.health.STUNLatencyMS = milliseconds(latency)
+		}
+	}
+	result.health.Error = strings.Join(errs, "; ")
+	return result
+}
+
+// checkDERP makes an HTTP request to the latency check endpoint of a relay.
+// Any response proves the TCP connection and TLS handshake work.
+func checkDERP(ctx context.Context, node *tailcfg.DERPNode) (time.Duration, error) {
+	scheme, port := "https", 443
+	if node.ForceHTTP {
+		scheme, port = "http", 80
+	}
+	if node.DERPPort != 0 {
+		port = node.DERPPort
+	}
+	host := nodeHost(node)
+	transport := &http.Transport{
+		DisableKeepAlives: true,
+		TLSClientConfig: &tls.Config{
+			ServerName: node.HostName,
+			// #nosec G402 only enabled by tests.
+			InsecureSkipVerify: node.InsecureForTests,
+			MinVersion:         tls.VersionTLS12,
+		},
+	}
+	defer transport.CloseIdleConnections()
+	url := fmt.Sprintf("%s://%s/derp/latency-check", scheme, net.JoinHostPort(host, strconv.Itoa(port)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, xerrors.Errorf("create request: %w", err)
+	}
+	start := time.Now()
+	res, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return 0, xerrors.Errorf("derp: %w", err)
+	}
+	latency := time.Since(start)
+	_ = res.Body.Close()
+	if res.StatusCode >= http.StatusInternalServerError {
+		return 0, xerrors.Errorf("derp: unexpected status code %d", res.StatusCode)
+	}
+	return latency, nil
+}
+
+// checkSTUN sends a STUN binding request to a node and waits for the
+// response.
+func checkSTUN(ctx context.Context, node *tailcfg.DERPNode) (time.Duration, error) {
+	port := node.STUNPort
+	if port == 0 {
+		port = 3478
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(nodeHost(node), strconv.Itoa(port)))
+	if err != nil {
+		return 0, xerrors.Errorf("stun: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	txID := stun.NewTxID()
+	start := time.Now()
+	_, err = conn.Write(stun.Request(txID))
+	if err != nil {
+		return 0, xerrors.Errorf("stun: %w", err)
+	}
+	buf := make([]byte, 1024)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return 0, xerrors.Errorf("stun: %w", err)
+		}
+		responseTxID, _, err := stun.ParseResponse(buf[:n])
+		if err != nil || responseTxID != txID {
+			// Ignore stray packets.
+			continue
+		}
+		return time.Since(start), nil
+	}
+}
+
+// nodeHost returns the address to reach a node at. Like tailscale, the IPv4
+// address takes precedence over the hostname.
+func nodeHost(node *tailcfg.DERPNode) string {
+	if addr, err := netip.ParseAddr(node.IPv4); err == nil {
+		return addr.String()
+	}
+	return node.HostName
+}
+
+func sortedRegionIDs(derpMap *tailcfg.DERPMap) []int {
+	ids := maps.Keys(derpMap.Regions)
+	slices.Sort(ids)
+	return ids
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,174 @@
+package derpmap_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"tailscale.com/tailcfg"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/derpmap"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet/tailnettest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestCheckRegion(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Healthy", func(t *testing.T) {
+		t.Parallel()
+		region := tailnettest.RunDERPAndSTUN(t).Regions[1]
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		health := derpmap.CheckRegion(ctx, region)
+		require.Equal(t, codersdk.DERPRegionStatusHealthy, health.Status)
+		require.Len(t, health.Nodes, 1)
+		require.Empty(t, health.Nodes[0].Error)
+		require.NotZero(t, health.Nodes[0].DERPLatencyMS)
+		require.NotZero(t, health.Nodes[0].STUNLatencyMS)
+		require.NotZero(t, health.LatencyMS)
+	})
+
+	t.Run("Unhealthy", func(t *testing.T) {
+		t.Parallel()
+		region := &tailcfg.DERPRegion{
+			RegionID: 2,
+			Nodes:    []*tailcfg.DERPNode{unreachableNode(t, 2)},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		health := derpmap.CheckRegion(ctx, region)
+		require.Equal(t, codersdk.DERPRegionStatusUnhealthy, health.Status)
+		require.NotEmpty(t, health.Nodes[0].Error)
+	})
+
+	t.Run("Degraded", func(t *testing.T) {
+		t.Parallel()
+		region := tailnettest.RunDERPAndSTUN(t).Regions[1]
+		region.Nodes = append(region.Nodes, unreachableNode(t, region.RegionID))
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		health := derpmap.CheckRegion(ctx, region)
+		require.Equal(t, codersdk.DERPRegionStatusDegraded, health.Status)
+	})
+}
+
+func TestRefresher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AppliesHealth", func(t *testing.T) {
+		t.Parallel()
+		var current atomic.Pointer[tailcfg.DERPMap]
+		current.Store(&tailcfg.DERPMap{
+			Regions: map[int]*tailcfg.DERPRegion{
+				1: {RegionID: 1},
+				2: {RegionID: 2},
+				3: {RegionID: 3, EmbeddedRelay: true},
+			},
+		})
+		statuses := map[int]codersdk.DERPRegionStatus{
+			1: codersdk.DERPRegionStatusHealthy,
+			2: codersdk.DERPRegionStatusUnhealthy,
+			// Embedded relays are never checked.
+			3: codersdk.DERPRegionStatusUnhealthy,
+			4: codersdk.DERPRegionStatusDegraded,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		refresher, err := derpmap.New(ctx, derpmap.Options{
+			Logger: slogtest.Make(t, nil),
+			Provider: func(context.Context) (*tailcfg.DERPMap, error) {
+				return current.Load().Clone(), nil
+			},
+			RefreshInterval: 10 * time.Millisecond,
+			HealthCheck:     fakeHealthCheck(statuses),
+		})
+		require.NoError(t, err)
+		defer refresher.Close()
+
+		derpMap := refresher.DERPMap()
+		require.Contains(t, derpMap.Regions, 1)
+		require.NotContains(t, derpMap.Regions, 2)
+		require.Contains(t, derpMap.Regions, 3)
+		health := refresher.Health()
+		require.Len(t, health, 3)
+		require.Equal(t, codersdk.DERPRegionStatusUnhealthy, health[1].Status)
+		require.Equal(t, codersdk.DERPRegionStatusHealthy, health[2].Status)
+
+		// Changes to the provided map are picked up.
+		updated := current.Load().Clone()
+		updated.Regions[4] = &tailcfg.DERPRegion{RegionID: 4}
+		current.Store(updated)
+		require.Eventually(t, func() bool {
+			region, ok := refresher.DERPMap().Regions[4]
+			return ok && region.Avoid
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("AllUnhealthy", func(t *testing.T) {
+		t.Parallel()
+		derpMap := &tailcfg.DERPMap{
+			Regions: map[int]*tailcfg.DERPRegion{
+				1: {RegionID: 1},
+				2: {RegionID: 2},
+			},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		refresher, err := derpmap.New(ctx, derpmap.Options{
+			Logger:   slogtest.Make(t, nil),
+			Provider: derpmap.StaticProvider(derpMap),
+			HealthCheck: fakeHealthCheck(map[int]codersdk.DERPRegionStatus{
+				1: codersdk.DERPRegionStatusUnhealthy,
+				2: codersdk.DERPRegionStatusUnhealthy,
+			}),
+		})
+		require.NoError(t, err)
+		defer refresher.Close()
+
+		// Regions are kept when they'd all be removed.
+		require.Len(t, refresher.DERPMap().Regions, 2)
+	})
+}
+
+func fakeHealthCheck(statuses map[int]codersdk.DERPRegionStatus) derpmap.HealthCheck {
+	return func(_ context.Context, region *tailcfg.DERPRegion) codersdk.DERPRegionHealth {
+		return codersdk.DERPRegionHealth{
+			RegionID: region.RegionID,
+			Status:   statuses[region.RegionID],
+		}
+	}
+}
+
+// unreachableNode returns a node with a relay and STUN server on ports that
+// nothing listens on.
+func unreachableNode(t *testing.T, regionID int) *tailcfg.DERPNode {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+	return &tailcfg.DERPNode{
+		Name:             "unreachable",
+		RegionID:         regionID,
+		IPv4:             "127.0.0.1",
+		DERPPort:         port,
+		STUNPort:         port,
+		InsecureForTests: true,
+	}
+}
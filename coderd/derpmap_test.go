@@ -0,0 +1,140 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"tailscale.com/tailcfg"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/derpmap"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWatchDERPMap(t *testing.T) {
+	t.Parallel()
+
+	var current atomic.Pointer[tailcfg.DERPMap]
+	current.Store(&tailcfg.DERPMap{
+		Regions: map[int]*tailcfg.DERPRegion{
+			1: {RegionID: 1, RegionCode: "one"},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	refresher, err := derpmap.New(ctx, derpmap.Options{
+		Logger: slogtest.Make(t, nil),
+		Provider: func(context.Context) (*tailcfg.DERPMap, error) {
+			return current.Load().Clone(), nil
+		},
+		RefreshInterval: 10 * time.Millisecond,
+		HealthCheck: func(_ context.Context, region *tailcfg.DERPRegion) codersdk.DERPRegionHealth {
+			return codersdk.DERPRegionHealth{
+				RegionID: region.RegionID,
+				Status:   codersdk.DERPRegionStatusHealthy,
+			}
+		},
+	})
+	require.NoError(t, err)
+	defer refresher.Close()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		DERPMapRefresher:       refresher,
+		DERPMapUpdateFrequency: 10 * time.Millisecond,
+	})
+	_ = coderdtest.CreateFirstUser(t, client)
+
+	derpMaps, err := client.WatchDERPMap(ctx)
+	require.NoError(t, err)
+	derpMap := recvDERPMap(ctx, t, derpMaps)
+	require.Contains(t, derpMap.Regions, 1)
+	require.NotContains(t, derpMap.Regions, 2)
+
+	updated := current.Load().Clone()
+	updated.Regions[2] = &tailcfg.DERPRegion{RegionID: 2, RegionCode: "two"}
+	current.Store(updated)
+	derpMap = recvDERPMap(ctx, t, derpMaps)
+	require.Contains(t, derpMap.Regions, 2)
+}
+
+func TestDERPMapHealth(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		refresher, err := derpmap.New(ctx, derpmap.Options{
+			Logger: slogtest.Make(t, nil),
+			Provider: derpmap.StaticProvider(&tailcfg.DERPMap{
+				Regions: map[int]*tailcfg.DERPRegion{
+					1: {RegionID: 1, RegionCode: "one"},
+					2: {RegionID: 2, RegionCode: "two"},
+				},
+			}),
+			HealthCheck: func(_ context.Context, region *tailcfg.DERPRegion) codersdk.DERPRegionHealth {
+				status := codersdk.DERPRegionStatusHealthy
+				if region.RegionID == 2 {
+					status = codersdk.DERPRegionStatusUnhealthy
+				}
+				return codersdk.DERPRegionHealth{
+					RegionID:   region.RegionID,
+					RegionCode: region.RegionCode,
+					Status:     status,
+				}
+			},
+		})
+		require.NoError(t, err)
+		defer refresher.Close()
+
+		client := coderdtest.New(t, &coderdtest.Options{
+			DERPMapRefresher: refresher,
+		})
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		health, err := client.DERPMapHealth(ctx)
+		require.NoError(t, err)
+		require.Len(t, health, 2)
+		require.Equal(t, codersdk.DERPRegionStatusHealthy, health[0].Status)
+		require.Equal(t, codersdk.DERPRegionStatusUnhealthy, health[1].Status)
+
+		// Unhealthy regions aren't given to clients.
+		derpMaps, err := client.WatchDERPMap(ctx)
+		require.NoError(t, err)
+		derpMap := recvDERPMap(ctx, t, derpMaps)
+		require.Contains(t, derpMap.Regions, 1)
+		require.NotContains(t, derpMap.Regions, 2)
+	})
+
+	t.Run("Member", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := member.DERPMapHealth(ctx)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+}
+
+func recvDERPMap(ctx context.Context, t *testing.T, derpMaps <-chan *tailcfg.DERPMap) *tailcfg.DERPMap {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for derp map")
+		return nil
+	case derpMap, ok := <-derpMaps:
+		require.True(t, ok, "derp map stream closed")
+		return derpMap
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -55,7 +56,7 @@ type Authorization struct {
 	Username string
 	Roles    []string
 	Groups   []string
-	Scope    database.APIKeyScope
+	Scope    rbac.Scope
 }
 
 // UserAuthorizationOptional may return the roles and scope used for
@@ -140,7 +141,7 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 				write(code, response)
 			}
 
-			token := apiTokenFromRequest(r)
+			token := APITokenFromRequest(r)
 			if token == "" {
 				optionalWrite(http.StatusUnauthorized, codersdk.Response{
 					Message: SignedOutErrorMessage,
@@ -247,6 +248,27 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 				return
 			}
 
+			// If the key is valid, we also fetch the user roles and status.
+			// The roles are used for RBAC authorize checks, and the status
+			// is to block 'suspended' users from accessing the platform.
+			// Whether the user is a service account decides if the key is
+			// extended below.
+			roles, err := cfg.DB.GetAuthorizationUserRoles(r.Context(), key.UserID)
+			if err != nil {
+				write(http.StatusUnauthorized, codersdk.Response{
+					Message: internalErrorMessage,
+					Detail:  fmt.Sprintf("Internal error fetching user's roles. %s", err.Error()),
+				})
+				return
+			}
+
+			if roles.Status != database.UserStatusActive {
+				write(http.StatusUnauthorized, codersdk.Response{
+					Message: fmt.Sprintf("User is not active (status = %q). Contact an admin to reactivate your account.", roles.Status),
+				})
+				return
+			}
+
 			// Only update LastUsed once an hour to prevent database spam.
 			if now.Sub(key.LastUsed) > time.Hour {
 				key.LastUsed = now
@@ -268,8 +290,11 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 			// We extend the ExpiresAt to reduce re-authentication.
 			apiKeyLifetime := time.Duration(key.LifetimeSeconds) * time.Second
 			if key.ExpiresAt.Sub(now) <= apiKeyLifetime-time.Hour {
-				key.ExpiresAt = now.Add(apiKeyLifetime)
-				changed = true
+				// Tokens of service accounts expire when chosen at creation.
+				if key.LoginType != database.LoginTypeToken || !roles.ServiceAccount {
+					key.ExpiresAt = now.Add(apiKeyLifetime)
+					changed = true
+				}
 			}
 			if changed {
 				err := cfg.DB.UpdateAPIKeyByID(r.Context(), database.UpdateAPIKeyByIDParams{
@@ -321,31 +346,12 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 				}
 			}
 
-			// If the key is valid, we also fetch the user roles and status.
-			// The roles are used for RBAC authorize checks, and the status
-			// is to block 'suspended' users from accessing the platform.
-			roles, err := cfg.DB.GetAuthorizationUserRoles(r.Context(), key.UserID)
-			if err != nil {
-				write(http.StatusUnauthorized, codersdk.Response{
-					Message: internalErrorMessage,
-					Detail:  fmt.Sprintf("Internal error fetching user's roles. %s", err.Error()),
-				})
-				return
-			}
-
-			if roles.Status != database.UserStatusActive {
-				write(http.StatusUnauthorized, codersdk.Response{
-					Message: fmt.Sprintf("User is not active (status = %q). Contact an admin to reactivate your account.", roles.Status),
-				})
-				return
-			}
-
 			ctx = context.WithValue(ctx, apiKeyContextKey{}, key)
 			ctx = context.WithValue(ctx, userAuthKey{}, Authorization{
 				ID:       key.UserID,
 				Username: roles.Username,
 				Roles:    roles.Roles,
-				Scope:    key.Scope,
+				Scope:    key.RBACScope(),
 				Groups:   roles.Groups,
 			})
 
@@ -354,14 +360,14 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 	}
 }
 
-// apiTokenFromRequest returns the api token from the request.
+// APITokenFromRequest returns the api token from the request.
 // Find the session token from:
 // 1: The cookie
 // 1: The devurl cookie
 // 3: The old cookie
 // 4. The coder_session_token query parameter
 // 5. The custom auth header
-func apiTokenFromRequest(r *http.Request) string {
+func APITokenFromRequest(r *http.Request) string {
 	cookie, err := r.Cookie(codersdk.SessionTokenKey)
 	if err == nil && cookie.Value != "" {
 		return cookie.Value
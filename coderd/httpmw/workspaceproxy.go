@@ -0,0 +1,158 @@
+package httpmw
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+type workspaceProxyContextKey struct{}
+
+// WorkspaceProxy returns the workspace proxy authenticated by the
+// ExtractWorkspaceProxy middleware.
+func WorkspaceProxy(r *http.Request) database.WorkspaceProxy {
+	proxy, ok := r.Context().Value(workspaceProxyContextKey{}).(database.WorkspaceProxy)
+	if !ok {
+		panic("developer error: ExtractWorkspaceProxy middleware not provided")
+	}
+	return proxy
+}
+
+// ExtractWorkspaceProxy authenticates a workspace proxy using the token in the
+// codersdk.WorkspaceProxyAuthTokenHeader header. Tokens are in the form
+// "<proxy id>:<secret>".
+func ExtractWorkspaceProxy(db database.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			token := r.Header.Get(codersdk.WorkspaceProxyAuthTokenHeader)
+			if token == "" {
+				httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+					Message: "Missing required workspace proxy token",
+				})
+				return
+			}
+
+			proxyID, secret, err := SplitWorkspaceProxyToken(token)
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+					Message: "Invalid workspace proxy token",
+					Detail:  err.Error(),
+				})
+				return
+			}
+
+			proxy, err := db.GetWorkspaceProxyByID(ctx, proxyID)
+			if xerrors.Is(err, sql.ErrNoRows) {
+				httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+					Message: "Invalid workspace proxy token",
+					Detail:  "Proxy not found.",
+				})
+				return
+			}
+			if err != nil {
+				httpapi.InternalServerError(rw, err)
+				return
+			}
+			if proxy.Deleted {
+				httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+					Message: "Invalid workspace proxy token",
+					Detail:  "Proxy has been deleted.",
+				})
+				return
+			}
+
+			hashedSecret := sha256.Sum256([]byte(secret))
+			if subtle.ConstantTimeCompare(proxy.TokenHashedSecret, hashedSecret[:]) != 1 {
+				httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+					Message: "Invalid workspace proxy token",
+					Detail:  "Invalid proxy token secret.",
+				})
+				return
+			}
+
+			ctx = context.WithValue(ctx, workspaceProxyContextKey{}, proxy)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+// SplitWorkspaceProxyToken splits a proxy token into its ID and secret.
+func SplitWorkspaceProxyToken(token string) (uuid.UUID, string, error) {
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 {
+		return uuid.Nil, "", xerrors.New("token must be in the format <proxy id>:<secret>")
+	}
+	proxyID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, "", xerrors.Errorf("parse proxy ID: %w", err)
+	}
+	if parts[1] == "" {
+		return uuid.Nil, "", xerrors.New("token secret is empty")
+	}
+	return proxyID, parts[1], nil
+}
+
+type workspaceProxyParamContextKey struct{}
+
+// WorkspaceProxyParam returns the workspace proxy from the
+// ExtractWorkspaceProxyParam handler.
+func WorkspaceProxyParam(r *http.Request) database.WorkspaceProxy {
+	proxy, ok := r.Context().Value(workspaceProxyParamContextKey{}).(database.WorkspaceProxy)
+	if !ok {
+		panic("developer error: workspace proxy param middleware not provided")
+	}
+	return proxy
+}
+
+// ExtractWorkspaceProxyParam extracts a workspace proxy from the
+// "workspaceproxy" URL parameter, which may be a proxy ID or name.
+func ExtractWorkspaceProxyParam(db database.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			proxyQuery := chi.URLParam(r, "workspaceproxy")
+			if proxyQuery == "" {
+				httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+					Message: "\"workspaceproxy\" must be provided.",
+				})
+				return
+			}
+
+			var proxy database.WorkspaceProxy
+			var err error
+			if proxyID, uuidErr := uuid.Parse(proxyQuery); uuidErr == nil {
+				proxy, err = db.GetWorkspaceProxyByID(ctx, proxyID)
+				if err == nil && proxy.Deleted {
+					err = sql.ErrNoRows
+				}
+			} else {
+				proxy, err = db.GetWorkspaceProxyByName(ctx, proxyQuery)
+			}
+			if xerrors.Is(err, sql.ErrNoRows) {
+				httpapi.ResourceNotFound(rw)
+				return
+			}
+			if err != nil {
+				httpapi.InternalServerError(rw, err)
+				return
+			}
+
+			ctx = context.WithValue(ctx, workspaceProxyParamContextKey{}, proxy)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
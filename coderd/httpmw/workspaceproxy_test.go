@@ -0,0 +1,99 @@
+package httpmw_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/codersdk"
+)
+
+func TestExtractWorkspaceProxy(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, db database.Store) (database.WorkspaceProxy, string) {
+		secret := "supersecret"
+		hashedSecret := sha256.Sum256([]byte(secret))
+		proxy, err := db.InsertWorkspaceProxy(context.Background(), database.InsertWorkspaceProxyParams{
+			ID:                uuid.New(),
+			Name:              "proxy",
+			TokenHashedSecret: hashedSecret[:],
+			CreatedAt:         database.Now(),
+			UpdatedAt:         database.Now(),
+		})
+		require.NoError(t, err)
+		return proxy, fmt.Sprintf("%s:%s", proxy.ID, secret)
+	}
+
+	serve := func(db database.Store, token string) *http.Response {
+		rtr := chi.NewRouter()
+		rtr.Use(httpmw.ExtractWorkspaceProxy(db))
+		rtr.Get("/", func(rw http.ResponseWriter, r *http.Request) {
+			_ = httpmw.WorkspaceProxy(r)
+			rw.WriteHeader(http.StatusOK)
+		})
+		r := httptest.NewRequest("GET", "/", nil)
+		if token != "" {
+			r.Header.Set(codersdk.WorkspaceProxyAuthTokenHeader, token)
+		}
+		rw := httptest.NewRecorder()
+		rtr.ServeHTTP(rw, r)
+		return rw.Result()
+	}
+
+	t.Run("NoToken", func(t *testing.T) {
+		t.Parallel()
+		res := serve(databasefake.New(), "")
+		defer res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		t.Parallel()
+		res := serve(databasefake.New(), "notatoken")
+		defer res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		proxy, _ := setup(t, db)
+		res := serve(db, fmt.Sprintf("%s:wrong", proxy.ID))
+		defer res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		proxy, token := setup(t, db)
+		err := db.UpdateWorkspaceProxyDeleted(context.Background(), database.UpdateWorkspaceProxyDeletedParams{
+			ID:      proxy.ID,
+			Deleted: true,
+		})
+		require.NoError(t, err)
+		res := serve(db, token)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		_, token := setup(t, db)
+		res := serve(db, token)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+}
@@ -2,6 +2,9 @@ package coderd
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -10,12 +13,196 @@ import (
 
 	"github.com/coder/coder/coderd/rbac"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/codersdk"
 )
 
+func (api *API) organizationMembers(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx          = r.Context()
+		organization = httpmw.OrganizationParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceOrganizationMember.InOrg(organization.ID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	members, err := api.Database.GetOrganizationMembers(ctx, organization.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching organization members.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	converted := make([]codersdk.OrganizationMemberWithUser, 0, len(members))
+	for _, member := range members {
+		roles := make([]codersdk.Role, 0, len(member.Roles))
+		for _, roleName := range member.Roles {
+			roles = append(roles, convertRoleName(api.CustomRoles, roleName))
+		}
+		converted = append(converted, codersdk.OrganizationMemberWithUser{
+			UserID:         member.UserID,
+			OrganizationID: member.OrganizationID,
+			Username:       member.Username,
+			Email:          member.Email,
+			CreatedAt:      member.CreatedAt,
+			UpdatedAt:      member.UpdatedAt,
+			Roles:          roles,
+		})
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+func (api *API) postOrganizationMember(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		organization      = httpmw.OrganizationParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.AuditableOrganizationMember](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceOrganizationMember.InOrg(organization.ID)) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	_, err := api.Database.GetOrganizationMemberByUserID(ctx, database.GetOrganizationMemberByUserIDParams{
+		OrganizationID: organization.ID,
+		UserID:         user.ID,
+	})
+	if err == nil {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("User %q is already a member of organization %q.", user.Username, organization.Name),
+		})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching organization member.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	member, err := api.Database.InsertOrganizationMember(ctx, database.InsertOrganizationMemberParams{
+		OrganizationID: organization.ID,
+		UserID:         user.ID,
+		CreatedAt:      database.Now(),
+		UpdatedAt:      database.Now(),
+		// The org-member role is implied.
+		Roles: []string{},
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting organization member.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = member.Auditable(user.Username)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertOrganizationMember(api.CustomRoles, member))
+}
+
+func (api *API) deleteOrganizationMember(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		organization      = httpmw.OrganizationParam(r)
+		member            = httpmw.OrganizationMemberParam(r)
+		apiKey            = httpmw.APIKey(r)
+		actorRoles        = httpmw.UserAuthorization(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.AuditableOrganizationMember](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = member.Auditable(user.Username)
+
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceOrganizationMember.InOrg(organization.ID)) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	if apiKey.UserID == member.UserID {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "You cannot remove yourself from an organization.",
+		})
+		return
+	}
+
+	// Removing the member removes their roles, which requires being able
+	// to assign them.
+	for _, roleName := range member.Roles {
+		if !rbac.CanAssignRole(actorRoles.Roles, roleName) {
+			httpapi.Forbidden(rw)
+			return
+		}
+	}
+
+	workspaceCount, err := api.Database.GetWorkspaceCountByOrganizationAndOwner(ctx, database.GetWorkspaceCountByOrganizationAndOwnerParams{
+		OrganizationID: organization.ID,
+		OwnerID:        user.ID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace count.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if workspaceCount > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("User %q owns workspaces in organization %q. Delete them before removing the user.", user.Username, organization.Name),
+		})
+		return
+	}
+
+	err = api.Database.InTx(func(tx database.Store) error {
+		err := tx.DeleteGroupMembersByOrgAndUser(ctx, database.DeleteGroupMembersByOrgAndUserParams{
+			OrganizationID: organization.ID,
+			UserID:         user.ID,
+		})
+		if err != nil {
+			return xerrors.Errorf("delete group members: %w", err)
+		}
+		err = tx.DeleteOrganizationMember(ctx, database.DeleteOrganizationMemberParams{
+			OrganizationID: organization.ID,
+			UserID:         user.ID,
+		})
+		if err != nil {
+			return xerrors.Errorf("delete organization member: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error removing organization member.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
 func (api *API) putMemberRoles(rw http.ResponseWriter, r *http.Request) {
 	var (
 		ctx          = r.Context()
@@ -74,7 +261,7 @@ func (api *API) putMemberRoles(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertOrganizationMember(updatedUser))
+	httpapi.Write(ctx, rw, http.StatusOK, convertOrganizationMember(api.CustomRoles, updatedUser))
 }
 
 func (api *API) updateOrganizationMemberRoles(ctx context.Context, args database.UpdateMemberRolesParams) (database.OrganizationMember, error) {
@@ -95,7 +282,7 @@ func (api *API) updateOrganizationMemberRoles(ctx context.Context, args database
 			return database.OrganizationMember{}, xerrors.Errorf("Must only pass roles for org %q", args.OrgID.String())
 		}
 
-		if _, err := rbac.RoleByName(r); err != nil {
+		if _, err := api.CustomRoles.RoleByName(r); err != nil {
 			return database.OrganizationMember{}, xerrors.Errorf("%q is not a supported role", r)
 		}
 	}
@@ -107,7 +294,7 @@ func (api *API) updateOrganizationMemberRoles(ctx context.Context, args database
 	return updatedUser, nil
 }
 
-func convertOrganizationMember(mem database.OrganizationMember) codersdk.OrganizationMember {
+func convertOrganizationMember(customRoles *rbac.CustomRoles, mem database.OrganizationMember) codersdk.OrganizationMember {
 	convertedMember := codersdk.OrganizationMember{
 		UserID:         mem.UserID,
 		OrganizationID: mem.OrganizationID,
@@ -117,8 +304,7 @@ func convertOrganizationMember(mem database.OrganizationMember) codersdk.Organiz
 	}
 
 	for _, roleName := range mem.Roles {
-		rbacRole, _ := rbac.RoleByName(roleName)
-		convertedMember.Roles = append(convertedMember.Roles, convertRole(rbacRole))
+		convertedMember.Roles = append(convertedMember.Roles, convertRoleName(customRoles, roleName))
 	}
 	return convertedMember
 }
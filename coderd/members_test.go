@@ -0,0 +1,204 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestOrganizationMembers(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+	_, other := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID, rbac.RoleOrgAdmin(user.OrganizationID))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	members, err := client.OrganizationMembers(ctx, user.OrganizationID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	for _, member := range members {
+		if member.UserID != other.ID {
+			continue
+		}
+		require.Equal(t, other.Username, member.Username)
+		require.Equal(t, other.Email, member.Email)
+		require.Len(t, member.Roles, 1)
+		require.Equal(t, rbac.RoleOrgAdmin(user.OrganizationID), member.Roles[0].Name)
+	}
+
+	// Users can't list the members of organizations they aren't in.
+	org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+		Name: "another",
+	})
+	require.NoError(t, err)
+	member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	_, err = member.OrganizationMembers(ctx, org.ID)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
+
+func TestPostOrganizationMember(t *testing.T) {
+	t.Parallel()
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		memberClient, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "another",
+		})
+		require.NoError(t, err)
+		added, err := client.AddOrganizationMember(ctx, org.ID, member.Username)
+		require.NoError(t, err)
+		require.Equal(t, member.ID, added.UserID)
+		require.Equal(t, org.ID, added.OrganizationID)
+
+		orgs, err := memberClient.OrganizationsByUser(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, orgs, 2)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		_, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.AddOrganizationMember(ctx, user.OrganizationID, member.ID.String())
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+	})
+
+	t.Run("NoPermission", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		memberClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, other := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Members can't add users to their organization.
+		_, err := memberClient.AddOrganizationMember(ctx, user.OrganizationID, other.Username)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+}
+
+func TestDeleteOrganizationMember(t *testing.T) {
+	t.Parallel()
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		user := coderdtest.CreateFirstUser(t, client)
+		memberClient, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "another",
+		})
+		require.NoError(t, err)
+		_, err = client.AddOrganizationMember(ctx, org.ID, member.Username)
+		require.NoError(t, err)
+		err = client.RemoveOrganizationMember(ctx, org.ID, member.Username)
+		require.NoError(t, err)
+
+		// Both membership changes are audited in the organization.
+		var memberLogs []database.AuditLog
+		for _, log := range auditor.AuditLogs {
+			if log.ResourceType == database.ResourceTypeOrganizationMember {
+				memberLogs = append(memberLogs, log)
+			}
+		}
+		require.Len(t, memberLogs, 2)
+		require.Equal(t, database.AuditActionCreate, memberLogs[0].Action)
+		require.Equal(t, database.AuditActionDelete, memberLogs[1].Action)
+		for _, log := range memberLogs {
+			require.Equal(t, member.ID, log.ResourceID)
+			require.Equal(t, member.Username, log.ResourceTarget)
+			require.Equal(t, org.ID, log.OrganizationID)
+		}
+
+		orgs, err := memberClient.OrganizationsByUser(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, orgs, 1)
+		_, err = memberClient.Organization(ctx, org.ID)
+		require.Error(t, err)
+	})
+
+	t.Run("Self", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := client.RemoveOrganizationMember(ctx, user.OrganizationID, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("OwnsWorkspaces", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		memberClient, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		coderdtest.CreateWorkspace(t, memberClient, user.OrganizationID, template.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := client.RemoveOrganizationMember(ctx, user.OrganizationID, member.Username)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Contains(t, apiErr.Message, "owns workspaces")
+	})
+
+	t.Run("NotMember", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		_, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "another",
+		})
+		require.NoError(t, err)
+		err = client.RemoveOrganizationMember(ctx, org.ID, member.Username)
+		require.Error(t, err)
+	})
+}
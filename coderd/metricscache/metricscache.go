@@ -123,6 +123,10 @@ func (c *Cache) refresh(ctx context.Context) error {
 	if err != nil {
 		return xerrors.Errorf("delete old stats: %w", err)
 	}
+	err = c.database.DeleteOldConnectionStats(ctx)
+	if err != nil {
+		return xerrors.Errorf("delete old connection stats: %w", err)
+	}
 
 	templates, err := c.database.GetTemplates(ctx)
 	if err != nil {
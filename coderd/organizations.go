@@ -1,14 +1,17 @@
 package coderd
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 
 	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
@@ -100,12 +103,108 @@ func (api *API) postOrganizations(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, http.StatusCreated, convertOrganization(organization))
 }
 
+func (api *API) patchOrganization(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		organization      = httpmw.OrganizationParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Organization](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = organization
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceOrganization.InOrg(organization.ID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateOrganizationRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	params := database.UpdateOrganizationParams{
+		ID:                 organization.ID,
+		Name:               organization.Name,
+		UserWorkspaceQuota: organization.UserWorkspaceQuota,
+		UpdatedAt:          database.Now(),
+	}
+	if req.Name != "" && req.Name != organization.Name {
+		_, err := api.Database.GetOrganizationByName(ctx, req.Name)
+		if err == nil {
+			httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+				Message: fmt.Sprintf("Organization already exists with the name %q.", req.Name),
+				Validations: []codersdk.ValidationError{{
+					Field:  "name",
+					Detail: "This value is already in use and should be unique.",
+				}},
+			})
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: fmt.Sprintf("Internal error fetching organization %q.", req.Name),
+				Detail:  err.Error(),
+			})
+			return
+		}
+		params.Name = req.Name
+	}
+	if req.UserWorkspaceQuota != nil {
+		quota := *req.UserWorkspaceQuota
+		// Organizations can lower the deployment wide quota, but not raise it.
+		siteQuota := (*api.WorkspaceQuotaEnforcer.Load()).UserWorkspaceLimit(0)
+		if quota < 0 || quota > math.MaxInt32 || (siteQuota > 0 && quota > siteQuota) {
+			detail := "Must not be negative."
+			if siteQuota > 0 {
+				detail = fmt.Sprintf("Must be between 0 and the deployment wide quota of %d.", siteQuota)
+			}
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid user workspace quota.",
+				Validations: []codersdk.ValidationError{{
+					Field:  "user_workspace_quota",
+					Detail: detail,
+				}},
+			})
+			return
+		}
+		params.UserWorkspaceQuota = int32(quota)
+	}
+
+	updated, err := api.Database.UpdateOrganization(ctx, params)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating organization.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = updated
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertOrganization(updated))
+}
+
+// OrganizationByNameOrID returns the organization with the given ID, or
+// with the given name if it isn't a UUID.
+func (api *API) OrganizationByNameOrID(ctx context.Context, nameOrID string) (database.Organization, error) {
+	if id, err := uuid.Parse(nameOrID); err == nil {
+		return api.Database.GetOrganizationByID(ctx, id)
+	}
+	return api.Database.GetOrganizationByName(ctx, nameOrID)
+}
+
 // convertOrganization consumes the database representation and outputs an API friendly representation.
 func convertOrganization(organization database.Organization) codersdk.Organization {
 	return codersdk.Organization{
-		ID:        organization.ID,
-		Name:      organization.Name,
-		CreatedAt: organization.CreatedAt,
-		UpdatedAt: organization.UpdatedAt,
+		ID:                 organization.ID,
+		Name:               organization.Name,
+		CreatedAt:          organization.CreatedAt,
+		UpdatedAt:          organization.UpdatedAt,
+		UserWorkspaceQuota: int(organization.UserWorkspaceQuota),
 	}
 }
@@ -2,12 +2,14 @@ package coderd_test
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -24,6 +26,14 @@ func TestOrganizationsByUser(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, orgs)
 	require.Len(t, orgs, 1)
+
+	_, err = client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+		Name: "another",
+	})
+	require.NoError(t, err)
+	orgs, err = client.OrganizationsByUser(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.Len(t, orgs, 2)
 }
 
 func TestOrganizationByUserAndName(t *testing.T) {
@@ -110,3 +120,86 @@ func TestPostOrganizationsByUser(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestPatchOrganization(t *testing.T) {
+	t.Parallel()
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		org, err := client.UpdateOrganization(ctx, user.OrganizationID, codersdk.UpdateOrganizationRequest{
+			Name:               "renamed",
+			UserWorkspaceQuota: ptr.Ref(5),
+		})
+		require.NoError(t, err)
+		require.Equal(t, "renamed", org.Name)
+		require.Equal(t, 5, org.UserWorkspaceQuota)
+
+		// Omitted fields are left unchanged.
+		org, err = client.UpdateOrganization(ctx, user.OrganizationID, codersdk.UpdateOrganizationRequest{
+			UserWorkspaceQuota: ptr.Ref(0),
+		})
+		require.NoError(t, err)
+		require.Equal(t, "renamed", org.Name)
+		require.Equal(t, 0, org.UserWorkspaceQuota)
+	})
+
+	t.Run("InvalidQuota", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		for _, quota := range []int{-1, math.MaxInt32 + 1} {
+			_, err := client.UpdateOrganization(ctx, user.OrganizationID, codersdk.UpdateOrganizationRequest{
+				UserWorkspaceQuota: ptr.Ref(quota),
+			})
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "taken",
+		})
+		require.NoError(t, err)
+		_, err = client.UpdateOrganization(ctx, user.OrganizationID, codersdk.UpdateOrganizationRequest{
+			Name: "taken",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+	})
+
+	t.Run("NoPermission", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := member.UpdateOrganization(ctx, user.OrganizationID, codersdk.UpdateOrganizationRequest{
+			Name: "mine",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
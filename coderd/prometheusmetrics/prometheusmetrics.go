@@ -2,10 +2,12 @@ package prometheusmetrics
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
+	"tailscale.com/tailcfg"
 
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/database"
@@ -104,3 +106,84 @@ func Workspaces(ctx context.Context, registerer prometheus.Registerer, db databa
 	}()
 	return cancelFunc, nil
 }
+
+// ConnectionStats tracks the tailnet connections reported by agents and
+// clients within the past hour, by DERP region and the side that reported
+// them. Regions where direct connections never succeed stand out by having
+// no direct connections.
+func ConnectionStats(ctx context.Context, registerer prometheus.Registerer, db database.Store, derpMap *tailcfg.DERPMap, duration time.Duration) (context.CancelFunc, error) {
+	if duration == 0 {
+		duration = 5 * time.Minute
+	}
+
+	labels := []string{"derp_region", "source"}
+	connections := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "agents",
+		Name:      "connections",
+		Help:      "The number of tailnet connections to agents within the last hour.",
+	}, labels)
+	err := registerer.Register(connections)
+	if err != nil {
+		return nil, err
+	}
+	directConnections := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "agents",
+		Name:      "direct_connections",
+		Help:      "The number of tailnet connections to agents within the last hour that were direct instead of relayed through DERP.",
+	}, labels)
+	err = registerer.Register(directConnections)
+	if err != nil {
+		return nil, err
+	}
+	latency := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "coderd",
+		Subsystem: "agents",
+		Name:      "connection_latency_seconds",
+		Help:      "The average round trip time of tailnet connections to agents within the last hour.",
+	}, labels)
+	err = registerer.Register(latency)
+	if err != nil {
+		return nil, err
+	}
+
+	regionName := func(regionID int32) string {
+		if derpMap != nil {
+			if region, ok := derpMap.Regions[int(regionID)]; ok {
+				return region.RegionName
+			}
+		}
+		if regionID == 0 {
+			return "unknown"
+		}
+		return strconv.Itoa(int(regionID))
+	}
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	ticker := time.NewTicker(duration)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			rows, err := db.GetConnectionStatsByRegion(ctx, database.Now().Add(-1*time.Hour))
+			if err != nil {
+				continue
+			}
+			connections.Reset()
+			directConnections.Reset()
+			latency.Reset()
+			for _, row := range rows {
+				values := []string{regionName(row.DerpRegionID), string(row.Source)}
+				connections.WithLabelValues(values...).Set(float64(row.Connections))
+				directConnections.WithLabelValues(values...).Set(float64(row.DirectConnections))
+				latency.WithLabelValues(values...).Set(row.AverageLatencyMs / 1000)
+			}
+		}
+	}()
+	return cancelFunc, nil
+}
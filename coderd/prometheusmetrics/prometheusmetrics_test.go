@@ -10,6 +10,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"tailscale.com/tailcfg"
 
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/databasefake"
@@ -233,3 +234,55 @@ func TestWorkspaces(t *testing.T) {
 		})
 	}
 }
+
+func TestConnectionStats(t *testing.T) {
+	t.Parallel()
+	db := databasefake.New()
+	insert := func(agentID uuid.UUID, regionID int32, direct bool) {
+		_, err := db.InsertConnectionStat(context.Background(), database.InsertConnectionStatParams{
+			ID:           uuid.New(),
+			CreatedAt:    database.Now(),
+			Source:       database.ConnectionStatSourceAgent,
+			AgentID:      agentID,
+			WorkspaceID:  uuid.New(),
+			UserID:       uuid.New(),
+			PeerIp:       "fd7a:115c:a1e0::1",
+			Direct:       direct,
+			DerpRegionID: regionID,
+			LatencyMs:    20,
+		})
+		require.NoError(t, err)
+	}
+	// The same connection is reported twice and becomes direct.
+	agentID := uuid.New()
+	insert(agentID, 1, false)
+	insert(agentID, 1, true)
+	// A connection through a region that's not in the DERP map.
+	insert(uuid.New(), 999, false)
+
+	derpMap := &tailcfg.DERPMap{
+		Regions: map[int]*tailcfg.DERPRegion{
+			1: {RegionID: 1, RegionCode: "coder", RegionName: "Coder"},
+		},
+	}
+	registry := prometheus.NewRegistry()
+	cancel, err := prometheusmetrics.ConnectionStats(context.Background(), registry, db, derpMap, time.Millisecond)
+	require.NoError(t, err)
+	t.Cleanup(cancel)
+
+	require.Eventually(t, func() bool {
+		metrics, err := registry.Gather()
+		assert.NoError(t, err)
+		values := map[string]float64{}
+		for _, family := range metrics {
+			for _, metric := range family.Metric {
+				values[family.GetName()+"/"+metric.Label[0].GetValue()] = metric.Gauge.GetValue()
+			}
+		}
+		return values["coderd_agents_connections/Coder"] == 1 &&
+			values["coderd_agents_direct_connections/Coder"] == 1 &&
+			values["coderd_agents_connection_latency_seconds/Coder"] == 0.02 &&
+			values["coderd_agents_connections/999"] == 1 &&
+			values["coderd_agents_direct_connections/999"] == 0
+	}, testutil.WaitShort, testutil.IntervalFast)
+}
@@ -10,9 +10,12 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/yamux"
 	"github.com/moby/moby/pkg/namesgenerator"
 	"github.com/tabbed/pqtype"
 	"golang.org/x/xerrors"
@@ -22,6 +25,7 @@ import (
 
 	"cdr.dev/slog"
 
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/parameter"
@@ -34,6 +38,15 @@ import (
 	sdkproto "github.com/coder/coder/provisionersdk/proto"
 )
 
+const (
+	// agentShutdownReportGrace is how long to wait past an agent's shutdown
+	// script timeout for it to report its lifecycle.
+	agentShutdownReportGrace = 30 * time.Second
+	// agentShutdownPollInterval is how often the lifecycle of agents
+	// running shutdown scripts is checked.
+	agentShutdownPollInterval = time.Second
+)
+
 func (api *API) provisionerDaemons(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	daemons, err := api.Database.GetProvisionerDaemons(ctx)
@@ -59,7 +72,29 @@ func (api *API) provisionerDaemons(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, daemons)
+	converted := make([]codersdk.ProvisionerDaemon, 0, len(daemons))
+	for _, daemon := range daemons {
+		converted = append(converted, convertProvisionerDaemon(daemon))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+func convertProvisionerDaemon(daemon database.ProvisionerDaemon) codersdk.ProvisionerDaemon {
+	result := codersdk.ProvisionerDaemon{
+		ID:           daemon.ID,
+		CreatedAt:    daemon.CreatedAt,
+		UpdatedAt:    daemon.UpdatedAt,
+		Name:         daemon.Name,
+		Provisioners: make([]codersdk.ProvisionerType, 0, len(daemon.Provisioners)),
+		Tags:         daemon.Tags,
+	}
+	for _, provisioner := range daemon.Provisioners {
+		result.Provisioners = append(result.Provisioners, codersdk.ProvisionerType(provisioner))
+	}
+	if daemon.OrganizationID.Valid {
+		result.OrganizationID = &daemon.OrganizationID.UUID
+	}
+	return result
 }
 
 // ListenProvisionerDaemon is an in-memory connection to a provisionerd.  Useful when starting coderd and provisionerd
@@ -84,18 +119,44 @@ func (api *API) ListenProvisionerDaemon(ctx context.Context) (client proto.DRPCP
 		return nil, xerrors.Errorf("insert provisioner daemon %q: %w", name, err)
 	}
 
+	go func() {
+		err := api.ServeProvisionerDaemon(ctx, serverSession, daemon)
+		if err != nil && !xerrors.Is(err, io.EOF) {
+			api.Logger.Debug(ctx, "provisioner daemon disconnected", slog.Error(err))
+		}
+		// close the sessions so we don't leak goroutines serving them.
+		_ = clientSession.Close()
+		_ = serverSession.Close()
+	}()
+
+	return proto.NewDRPCProvisionerDaemonClient(provisionersdk.Conn(clientSession)), nil
+}
+
+// ServeProvisionerDaemon serves the provisioner daemon protobuf API for the
+// daemon provided over a multiplexed session. It blocks until the session
+// is closed or the context is canceled.
+func (api *API) ServeProvisionerDaemon(ctx context.Context, session *yamux.Session, daemon database.ProvisionerDaemon) error {
+	tags, err := json.Marshal(daemon.Tags)
+	if err != nil {
+		return xerrors.Errorf("marshal tags: %w", err)
+	}
+	if daemon.Tags == nil {
+		tags = []byte("{}")
+	}
 	mux := drpcmux.New()
 	err = proto.DRPCRegisterProvisionerDaemon(mux, &provisionerdServer{
-		AccessURL:    api.AccessURL,
-		ID:           daemon.ID,
-		Database:     api.Database,
-		Pubsub:       api.Pubsub,
-		Provisioners: daemon.Provisioners,
-		Telemetry:    api.Telemetry,
-		Logger:       api.Logger.Named(fmt.Sprintf("provisionerd-%s", daemon.Name)),
+		AccessURL:      api.AccessURL,
+		ID:             daemon.ID,
+		Database:       api.Database,
+		Pubsub:         api.Pubsub,
+		Provisioners:   daemon.Provisioners,
+		Tags:           tags,
+		OrganizationID: daemon.OrganizationID.UUID,
+		Telemetry:      api.Telemetry,
+		Logger:         api.Logger.Named(fmt.Sprintf("provisionerd-%s", daemon.Name)),
 	})
 	if err != nil {
-		return nil, err
+		return xerrors.Errorf("register provisioner daemon: %w", err)
 	}
 	server := drpcserver.NewWithOptions(mux, drpcserver.Options{
 		Log: func(err error) {
@@ -105,17 +166,7 @@ func (api *API) ListenProvisionerDaemon(ctx context.Context) (client proto.DRPCP
 			api.Logger.Debug(ctx, "drpc server error", slog.Error(err))
 		},
 	})
-	go func() {
-		err := server.Serve(ctx, serverSession)
-		if err != nil && !xerrors.Is(err, io.EOF) {
-			api.Logger.Debug(ctx, "provisioner daemon disconnected", slog.Error(err))
-		}
-		// close the sessions so we don't leak goroutines serving them.
-		_ = clientSession.Close()
-		_ = serverSession.Close()
-	}()
-
-	return proto.NewDRPCProvisionerDaemonClient(provisionersdk.Conn(clientSession)), nil
+	return server.Serve(ctx, session)
 }
 
 // The input for a "workspace_provision" job.
@@ -126,9 +177,13 @@ type workspaceProvisionJob struct {
 
 // The input for a "template_version_dry_run" job.
 type templateVersionDryRunJob struct {
-	TemplateVersionID uuid.UUID                 `json:"template_version_id"`
-	WorkspaceName     string                    `json:"workspace_name"`
-	ParameterValues   []database.ParameterValue `json:"parameter_values"`
+	TemplateVersionID   uuid.UUID                          `json:"template_version_id"`
+	WorkspaceName       string                             `json:"workspace_name"`
+	ParameterValues     []database.ParameterValue          `json:"parameter_values"`
+	RichParameterValues []codersdk.WorkspaceBuildParameter `json:"rich_parameter_values"`
+	// WorkspaceID is set when the dry-run previews an update of an existing
+	// workspace. The workspace's latest state is provided to the provisioner.
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty"`
 }
 
 // Implementation of the provisioner daemon protobuf server.
@@ -137,9 +192,15 @@ type provisionerdServer struct {
 	ID           uuid.UUID
 	Logger       slog.Logger
 	Provisioners []database.ProvisionerType
-	Database     database.Store
-	Pubsub       database.Pubsub
-	Telemetry    telemetry.Reporter
+	// Tags is the JSON encoded set of tags for the daemon. Only jobs
+	// with a subset of these tags are acquired.
+	Tags json.RawMessage
+	// OrganizationID restricts the daemon to jobs of the organization.
+	// uuid.Nil acquires jobs of all organizations.
+	OrganizationID uuid.UUID
+	Database       database.Store
+	Pubsub         database.Pubsub
+	Telemetry      telemetry.Reporter
 }
 
 // AcquireJob queries the database to lock a job.
@@ -154,7 +215,9 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 			UUID:  server.ID,
 			Valid: true,
 		},
-		Types: server.Provisioners,
+		Types:          server.Provisioners,
+		Tags:           server.Tags,
+		OrganizationID: server.OrganizationID,
 	})
 	if errors.Is(err, sql.ErrNoRows) {
 		// The provisioner daemon assumes no jobs are available if
@@ -249,13 +312,24 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 		if err != nil {
 			return nil, failJob(fmt.Sprintf("convert workspace transition: %s", err))
 		}
+		workspaceBuildParameters, err := server.Database.GetWorkspaceBuildParameters(ctx, workspaceBuild.ID)
+		if err != nil {
+			return nil, failJob(fmt.Sprintf("get workspace build parameters: %s", err))
+		}
+		if workspaceBuild.Transition != database.WorkspaceTransitionStart {
+			// Give agents a chance to run their shutdown scripts before
+			// the compute they run on is destroyed.
+			agents := server.signalAgentShutdown(ctx, job.ID, workspaceBuild)
+			server.waitForAgentShutdown(ctx, job.ID, agents)
+		}
 
 		protoJob.Type = &proto.AcquiredJob_WorkspaceBuild_{
 			WorkspaceBuild: &proto.AcquiredJob_WorkspaceBuild{
-				WorkspaceBuildId: workspaceBuild.ID.String(),
-				WorkspaceName:    workspace.Name,
-				State:            workspaceBuild.ProvisionerState,
-				ParameterValues:  protoParameters,
+				WorkspaceBuildId:    workspaceBuild.ID.String(),
+				WorkspaceName:       workspace.Name,
+				State:               workspaceBuild.ProvisionerState,
+				ParameterValues:     protoParameters,
+				RichParameterValues: convertRichParameterValues(convertWorkspaceBuildParameters(workspaceBuildParameters)),
 				Metadata: &sdkproto.Provision_Metadata{
 					CoderUrl:            server.AccessURL.String(),
 					WorkspaceTransition: transition,
@@ -279,11 +353,47 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 			return nil, failJob(fmt.Sprintf("get template version: %s", err))
 		}
 
+		metadata := &sdkproto.Provision_Metadata{
+			CoderUrl:      server.AccessURL.String(),
+			WorkspaceName: input.WorkspaceName,
+		}
+		var state []byte
+		if input.WorkspaceID != uuid.Nil {
+			// Dry-run against the current state of the workspace so the
+			// provisioner plans an update instead of a fresh workspace.
+			workspace, err := server.Database.GetWorkspaceByID(ctx, input.WorkspaceID)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("get workspace: %s", err))
+			}
+			workspaceBuild, err := server.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("get latest workspace build: %s", err))
+			}
+			owner, err := server.Database.GetUserByID(ctx, workspace.OwnerID)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("get owner: %s", err))
+			}
+			transition, err := convertWorkspaceTransition(workspaceBuild.Transition)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("convert workspace transition: %s", err))
+			}
+			state = workspaceBuild.ProvisionerState
+			metadata.WorkspaceTransition = transition
+			metadata.WorkspaceName = workspace.Name
+			metadata.WorkspaceId = workspace.ID.String()
+			metadata.WorkspaceOwner = owner.Username
+			metadata.WorkspaceOwnerEmail = owner.Email
+			metadata.WorkspaceOwnerId = owner.ID.String()
+		}
+
 		// Compute parameters for the dry-run to consume.
 		parameters, err := parameter.Compute(ctx, server.Database, parameter.ComputeScope{
-			TemplateImportJobID:       templateVersion.JobID,
-			TemplateID:                templateVersion.TemplateID,
-			WorkspaceID:               uuid.NullUUID{},
+			TemplateImportJobID: templateVersion.JobID,
+			TemplateID:          templateVersion.TemplateID,
+			WorkspaceID: uuid.NullUUID{
+				UUID:  input.WorkspaceID,
+				Valid: input.WorkspaceID != uuid.Nil,
+			},
 			AdditionalParameterValues: input.ParameterValues,
 		}, nil)
 		if err != nil {
@@ -298,11 +408,10 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 
 		protoJob.Type = &proto.AcquiredJob_TemplateDryRun_{
 			TemplateDryRun: &proto.AcquiredJob_TemplateDryRun{
-				ParameterValues: protoParameters,
-				Metadata: &sdkproto.Provision_Metadata{
-					CoderUrl:      server.AccessURL.String(),
-					WorkspaceName: input.WorkspaceName,
-				},
+				ParameterValues:     protoParameters,
+				RichParameterValues: convertRichParameterValues(input.RichParameterValues),
+				State:               state,
+				Metadata:            metadata,
 			},
 		}
 	case database.ProvisionerJobTypeTemplateVersionImport:
@@ -408,6 +517,54 @@ func (server *provisionerdServer) UpdateJob(ctx context.Context, request *proto.
 		}
 	}
 
+	if len(request.RichParameters) > 0 {
+		templateVersion, err := server.Database.GetTemplateVersionByJobID(ctx, job.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("get template version by job id: %w", err)
+		}
+		for _, richParameter := range request.RichParameters {
+			options := make([]codersdk.TemplateVersionParameterOption, 0, len(richParameter.Options))
+			for _, option := range richParameter.Options {
+				options = append(options, codersdk.TemplateVersionParameterOption{
+					Name:        option.Name,
+					Description: option.Description,
+					Value:       option.Value,
+					Icon:        option.Icon,
+				})
+			}
+			optionsData, err := json.Marshal(options)
+			if err != nil {
+				return nil, xerrors.Errorf("marshal options of parameter %q: %w", richParameter.Name, err)
+			}
+			var validationMin, validationMax sql.NullInt32
+			if richParameter.ValidationMin != nil {
+				validationMin = sql.NullInt32{Int32: *richParameter.ValidationMin, Valid: true}
+			}
+			if richParameter.ValidationMax != nil {
+				validationMax = sql.NullInt32{Int32: *richParameter.ValidationMax, Valid: true}
+			}
+			_, err = server.Database.InsertTemplateVersionParameter(ctx, database.InsertTemplateVersionParameterParams{
+				TemplateVersionID: templateVersion.ID,
+				Name:              richParameter.Name,
+				DisplayName:       richParameter.DisplayName,
+				Description:       richParameter.Description,
+				Type:              richParameter.Type,
+				Mutable:           richParameter.Mutable,
+				DefaultValue:      richParameter.DefaultValue,
+				Icon:              richParameter.Icon,
+				Options:           optionsData,
+				ValidationRegex:   richParameter.ValidationRegex,
+				ValidationError:   richParameter.ValidationError,
+				ValidationMin:     validationMin,
+				ValidationMax:     validationMax,
+				DisplayOrder:      richParameter.Order,
+			})
+			if err != nil {
+				return nil, xerrors.Errorf("insert parameter %q: %w", richParameter.Name, err)
+			}
+		}
+	}
+
 	if len(request.ParameterSchemas) > 0 {
 		for index, protoParameter := range request.ParameterSchemas {
 			validationTypeSystem, err := convertValidationTypeSystem(protoParameter.ValidationTypeSystem)
@@ -635,12 +792,22 @@ func (server *provisionerdServer) CompleteJob(ctx context.Context, completed *pr
 
 		err = server.Database.InTx(func(db database.Store) error {
 			now := database.Now()
-			var workspaceDeadline time.Time
+			var workspaceDeadline, workspaceMaxDeadline time.Time
 			workspace, err := db.GetWorkspaceByID(ctx, workspaceBuild.WorkspaceID)
 			if err == nil {
 				if workspace.Ttl.Valid {
 					workspaceDeadline = now.Add(time.Duration(workspace.Ttl.Int64))
 				}
+				if workspaceBuild.Transition == database.WorkspaceTransitionStart {
+					workspaceMaxDeadline, err = autostopRequirementDeadline(ctx, db, workspace, now)
+					if err != nil {
+						return xerrors.Errorf("compute autostop requirement deadline: %w", err)
+					}
+					// The autostop requirement always wins over the workspace TTL.
+					if !workspaceMaxDeadline.IsZero() && (workspaceDeadline.IsZero() || workspaceDeadline.After(workspaceMaxDeadline)) {
+						workspaceDeadline = workspaceMaxDeadline
+					}
+				}
 			} else {
 				// Huh? Did the workspace get deleted?
 				// In any case, since this is just for the TTL, try and continue anyway.
@@ -660,6 +827,7 @@ func (server *provisionerdServer) CompleteJob(ctx context.Context, completed *pr
 			err = db.UpdateWorkspaceBuildByID(ctx, database.UpdateWorkspaceBuildByIDParams{
 				ID:               workspaceBuild.ID,
 				Deadline:         workspaceDeadline,
+				MaxDeadline:      workspaceMaxDeadline,
 				ProvisionerState: jobType.WorkspaceBuild.State,
 				UpdatedAt:        now,
 			})
@@ -740,6 +908,29 @@ func (server *provisionerdServer) CompleteJob(ctx context.Context, completed *pr
 	return &proto.Empty{}, nil
 }
 
+// autostopRequirementDeadline returns the latest time the workspace may keep
+// running when started at now, as required by the autostop requirement of its
+// template. The deadline is aligned to the start of the owner's quiet hours. A
+// zero time is returned if the template has no autostop requirement.
+func autostopRequirementDeadline(ctx context.Context, db database.Store, workspace database.Workspace, now time.Time) (time.Time, error) {
+	template, err := db.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("get template: %w", err)
+	}
+	if template.AutostopRequirementDays <= 0 {
+		return time.Time{}, nil
+	}
+	owner, err := db.GetUserByID(ctx, workspace.OwnerID)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("get workspace owner: %w", err)
+	}
+	quietHours, err := userQuietHoursSchedule(owner)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.AutostopRequirementDeadline(quietHours, now, template.AutostopRequirementDays), nil
+}
+
 func insertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.UUID, transition database.WorkspaceTransition, protoResource *sdkproto.Resource, snapshot *telemetry.Snapshot) error {
 	resource, err := db.InsertWorkspaceResource(ctx, database.InsertWorkspaceResourceParams{
 		ID:         uuid.New(),
@@ -805,12 +996,33 @@ func insertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 				String: prAgent.StartupScript,
 				Valid:  prAgent.StartupScript != "",
 			},
+			StartupScriptTimeoutSeconds: prAgent.GetStartupScriptTimeoutSeconds(),
+			LoginBeforeReady:            prAgent.GetLoginBeforeReady(),
+			ShutdownScript: sql.NullString{
+				String: prAgent.ShutdownScript,
+				Valid:  prAgent.ShutdownScript != "",
+			},
+			ShutdownScriptTimeoutSeconds: prAgent.GetShutdownScriptTimeoutSeconds(),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert agent: %w", err)
 		}
 		snapshot.WorkspaceAgents = append(snapshot.WorkspaceAgents, telemetry.ConvertWorkspaceAgent(dbAgent))
 
+		for _, md := range prAgent.Metadata {
+			err := db.InsertWorkspaceAgentMetadata(ctx, database.InsertWorkspaceAgentMetadataParams{
+				WorkspaceAgentID: agentID,
+				DisplayName:      md.DisplayName,
+				Script:           md.Script,
+				Key:              md.Key,
+				Timeout:          md.Timeout,
+				Interval:         md.Interval,
+			})
+			if err != nil {
+				return xerrors.Errorf("insert agent metadata: %w, params: %+v", err, md)
+			}
+		}
+
 		for _, app := range prAgent.Apps {
 			slug := app.Slug
 			if slug == "" {
@@ -949,6 +1161,17 @@ func convertLogSource(logSource proto.LogSource) (database.LogSource, error) {
 	}
 }
 
+func convertRichParameterValues(parameters []codersdk.WorkspaceBuildParameter) []*sdkproto.RichParameterValue {
+	protoParameters := make([]*sdkproto.RichParameterValue, 0, len(parameters))
+	for _, parameter := range parameters {
+		protoParameters = append(protoParameters, &sdkproto.RichParameterValue{
+			Name:  parameter.Name,
+			Value: parameter.Value,
+		})
+	}
+	return protoParameters
+}
+
 func convertComputedParameterValues(parameters []parameter.ComputedValue) ([]*sdkproto.ParameterValue, error) {
 	protoParameters := make([]*sdkproto.ParameterValue, len(parameters))
 	for i, computedParameter := range parameters {
@@ -980,6 +1203,154 @@ func convertComputedParameterValue(param parameter.ComputedValue) (*sdkproto.Par
 	}, nil
 }
 
+// signalAgentShutdown signals the agents of the build preceding the one
+// provided to run their shutdown scripts, and returns the agents that were
+// signaled. Errors are logged rather than returned, since a failed shutdown
+// script must not prevent the workspace from stopping.
+func (server *provisionerdServer) signalAgentShutdown(ctx context.Context, jobID uuid.UUID, workspaceBuild database.WorkspaceBuild) []database.WorkspaceAgent {
+	logger := server.Logger.With(slog.F("job_id", jobID), slog.F("workspace_build_id", workspaceBuild.ID))
+	priorBuild, err := server.Database.GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx, database.GetWorkspaceBuildByWorkspaceIDAndBuildNumberParams{
+		WorkspaceID: workspaceBuild.WorkspaceID,
+		BuildNumber: workspaceBuild.BuildNumber - 1,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		logger.Warn(ctx, "get prior workspace build", slog.Error(err))
+		return nil
+	}
+	resources, err := server.Database.GetWorkspaceResourcesByJobID(ctx, priorBuild.JobID)
+	if err != nil {
+		logger.Warn(ctx, "get prior workspace build resources", slog.Error(err))
+		return nil
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := server.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.Warn(ctx, "get prior workspace build agents", slog.Error(err))
+		return nil
+	}
+
+	var signaled []database.WorkspaceAgent
+	for _, agent := range agents {
+		// Agents that never reported their lifecycle can't run a
+		// shutdown script, and ones that already shut down don't need to.
+		if !agent.ShutdownScript.Valid || !agent.FirstConnectedAt.Valid ||
+			agent.LifecycleState == database.WorkspaceAgentLifecycleStateCreated ||
+			codersdk.WorkspaceAgentLifecycle(agent.LifecycleState).ShutDown() {
+			continue
+		}
+		err = server.Pubsub.Publish(workspaceAgentShutdownChannel(agent.ID), []byte{})
+		if err != nil {
+			logger.Warn(ctx, "publish agent shutdown", slog.F("agent_id", agent.ID), slog.Error(err))
+			continue
+		}
+		signaled = append(signaled, agent)
+	}
+	if len(signaled) == 0 {
+		return nil
+	}
+	server.insertJobLog(ctx, jobID, fmt.Sprintf("Signaled %s to run the shutdown script.", agentNames(signaled)))
+	return signaled
+}
+
+// waitForAgentShutdown blocks until every agent provided has shut down or
+// disconnected, so the provisioner doesn't destroy the compute while a
+// shutdown script is still running. It waits at most for the longest
+// shutdown script timeout of the agents, plus some time for the agents to
+// report their lifecycle.
+func (server *provisionerdServer) waitForAgentShutdown(ctx context.Context, jobID uuid.UUID, agents []database.WorkspaceAgent) {
+	if len(agents) == 0 {
+		return
+	}
+	var timeout time.Duration
+	for _, agent := range agents {
+		agentTimeout := time.Duration(agent.ShutdownScriptTimeoutSeconds) * time.Second
+		if agentTimeout <= 0 {
+			agentTimeout = codersdk.DefaultShutdownScriptTimeout
+		}
+		if agentTimeout > timeout {
+			timeout = agentTimeout
+		}
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout+agentShutdownReportGrace)
+	defer cancel()
+
+	ticker := time.NewTicker(agentShutdownPollInterval)
+	defer ticker.Stop()
+	pending := agents
+	for {
+		remaining := make([]database.WorkspaceAgent, 0, len(pending))
+		for _, agent := range pending {
+			current, err := server.Database.GetWorkspaceAgentByID(waitCtx, agent.ID)
+			if err != nil {
+				if waitCtx.Err() == nil {
+					server.Logger.Warn(ctx, "get workspace agent", slog.F("job_id", jobID), slog.F("agent_id", agent.ID), slog.Error(err))
+				}
+				remaining = append(remaining, agent)
+				continue
+			}
+			// An agent that disconnected won't report its shutdown.
+			disconnected := current.DisconnectedAt.Valid && current.DisconnectedAt.Time.After(current.LastConnectedAt.Time)
+			if disconnected || codersdk.WorkspaceAgentLifecycle(current.LifecycleState).ShutDown() {
+				continue
+			}
+			remaining = append(remaining, agent)
+		}
+		pending = remaining
+		if len(pending) == 0 {
+			server.insertJobLog(ctx, jobID, fmt.Sprintf("%s finished running the shutdown script.", agentNames(agents)))
+			return
+		}
+		select {
+		case <-waitCtx.Done():
+			server.insertJobLog(ctx, jobID, fmt.Sprintf("Timed out waiting for %s to run the shutdown script.", agentNames(pending)))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// agentNames returns the sorted, comma separated names of the agents.
+func agentNames(agents []database.WorkspaceAgent) string {
+	names := make([]string, 0, len(agents))
+	for _, agent := range agents {
+		names = append(names, agent.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// insertJobLog inserts a single log from coderd into the job's logs.
+func (server *provisionerdServer) insertJobLog(ctx context.Context, jobID uuid.UUID, output string) {
+	logs, err := server.Database.InsertProvisionerJobLogs(ctx, database.InsertProvisionerJobLogsParams{
+		JobID:     jobID,
+		ID:        []uuid.UUID{uuid.New()},
+		CreatedAt: []time.Time{database.Now()},
+		Source:    []database.LogSource{database.LogSourceProvisionerDaemon},
+		Level:     []database.LogLevel{database.LogLevelInfo},
+		Stage:     []string{"Running shutdown scripts"},
+		Output:    []string{output},
+	})
+	if err != nil {
+		server.Logger.Warn(ctx, "insert job log", slog.F("job_id", jobID), slog.Error(err))
+		return
+	}
+	data, err := json.Marshal(provisionerJobLogsMessage{Logs: logs})
+	if err != nil {
+		server.Logger.Warn(ctx, "marshal job log", slog.F("job_id", jobID), slog.Error(err))
+		return
+	}
+	err = server.Pubsub.Publish(provisionerJobLogsChannel(jobID), data)
+	if err != nil {
+		server.Logger.Warn(ctx, "publish job log", slog.F("job_id", jobID), slog.Error(err))
+	}
+}
+
 func convertWorkspaceTransition(transition database.WorkspaceTransition) (sdkproto.WorkspaceTransition, error) {
 	switch transition {
 	case database.WorkspaceTransitionStart:
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -269,7 +270,16 @@ type fakePubSub struct {
 	closed   bool
 }
 
-func (f *fakePubSub) Subscribe(_ string, listener database.Listener) (cancel func(), err error) {
+// isJobLogsEvent reports whether the fake should handle the event. Other
+// subsystems, like the rate limiter, share the pubsub but aren't under test.
+func isJobLogsEvent(event string) bool {
+	return strings.HasPrefix(event, "provisioner-log-logs:")
+}
+
+func (f *fakePubSub) Subscribe(event string, listener database.Listener) (cancel func(), err error) {
+	if !isJobLogsEvent(event) {
+		return func() {}, nil
+	}
 	f.cond.L.Lock()
 	defer f.cond.L.Unlock()
 	f.listener = listener
@@ -277,7 +287,10 @@ func (f *fakePubSub) Subscribe(_ string, listener database.Listener) (cancel fun
 	return f.cancel, nil
 }
 
-func (f *fakePubSub) Publish(_ string, _ []byte) error {
+func (f *fakePubSub) Publish(event string, _ []byte) error {
+	if !isJobLogsEvent(event) {
+		return nil
+	}
 	f.t.Fail()
 	return nil
 }
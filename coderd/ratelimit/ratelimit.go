@@ -0,0 +1,568 @@
+// Package ratelimit limits API requests according to policies stored in the
+// database. Policies can target a role, an API key scope or a single user,
+// and request counts are shared between replicas over pubsub so a limit
+// applies to the deployment as a whole.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	// EventPolicies is published whenever a policy is created, updated or
+	// deleted so every replica reloads them.
+	EventPolicies = "rate_limit_policies"
+	// EventCounts carries request counts between replicas.
+	EventCounts = "rate_limit_counts"
+)
+
+// maxCachedIdentities bounds the number of API keys cached at once.
+const maxCachedIdentities = 10000
+
+// Options configures a Limiter.
+type Options struct {
+	Logger   slog.Logger
+	Database database.Store
+	// Pubsub shares request counts and policy changes between replicas.
+	// If nil, counts are only tracked locally.
+	Pubsub database.Pubsub
+	// DefaultCount is the number of requests allowed per endpoint in each
+	// window when no policy matches. A value <= 0 disables the default
+	// limit.
+	DefaultCount int
+	// DefaultWindow defaults to a minute.
+	DefaultWindow time.Duration
+	// FlushInterval is how often request counts are published to other
+	// replicas. Defaults to a second.
+	FlushInterval time.Duration
+	// IdentityCacheTTL is how long an API key's owner and roles are cached
+	// before being looked up again. Defaults to a minute.
+	IdentityCacheTTL time.Duration
+}
+
+// Limiter enforces rate limit policies. A policy targeting a user takes
+// precedence over one targeting the API key scope, which takes precedence
+// over role policies. When several role policies match, the most permissive
+// one applies. Requests that match no policy use the default limit.
+type Limiter struct {
+	opts      Options
+	replicaID uuid.UUID
+
+	policies atomic.Pointer[policySet]
+
+	identityMutex sync.Mutex
+	identities    map[string]identity
+
+	counterMutex sync.Mutex
+	counters     map[string]*counter
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closed    chan struct{}
+	closeSubs []func()
+}
+
+// New creates a Limiter and loads the current policies. Close must be called
+// to stop sharing counts with other replicas.
+func New(opts Options) *Limiter {
+	if opts.DefaultWindow <= 0 {
+		opts.DefaultWindow = time.Minute
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.IdentityCacheTTL <= 0 {
+		opts.IdentityCacheTTL = time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Limiter{
+		opts:       opts,
+		replicaID:  uuid.New(),
+		identities: map[string]identity{},
+		counters:   map[string]*counter{},
+		ctx:        ctx,
+		cancel:     cancel,
+		closed:     make(chan struct{}),
+	}
+	l.policies.Store(&policySet{})
+	if err := l.ReloadPolicies(ctx); err != nil {
+		opts.Logger.Error(ctx, "load rate limit policies", slog.Error(err))
+	}
+
+	if opts.Pubsub != nil {
+		cancelPolicies, err := opts.Pubsub.Subscribe(EventPolicies, func(ctx context.Context, _ []byte) {
+			if err := l.ReloadPolicies(ctx); err != nil {
+				opts.Logger.Error(ctx, "reload rate limit policies", slog.Error(err))
+			}
+		})
+		if err != nil {
+			opts.Logger.Error(ctx, "subscribe to rate limit policy changes", slog.Error(err))
+		} else {
+			l.closeSubs = append(l.closeSubs, cancelPolicies)
+		}
+		cancelCounts, err := opts.Pubsub.Subscribe(EventCounts, l.handleCounts)
+		if err != nil {
+			opts.Logger.Error(ctx, "subscribe to rate limit counts", slog.Error(err))
+		} else {
+			l.closeSubs = append(l.closeSubs, cancelCounts)
+		}
+	}
+
+	go l.run()
+	return l
+}
+
+// Close stops sharing counts with other replicas.
+func (l *Limiter) Close() error {
+	for _, closeSub := range l.closeSubs {
+		closeSub()
+	}
+	l.cancel()
+	<-l.closed
+	return nil
+}
+
+// ReloadPolicies reads the policies from the database.
+func (l *Limiter) ReloadPolicies(ctx context.Context) error {
+	policies, err := l.opts.Database.GetRateLimitPolicies(ctx)
+	if err != nil {
+		return xerrors.Errorf("get rate limit policies: %w", err)
+	}
+	set := &policySet{
+		users:  map[string]policy{},
+		scopes: map[string]policy{},
+		roles:  map[string]policy{},
+	}
+	for _, p := range policies {
+		converted := policy{
+			count:  int(p.Count),
+			window: time.Duration(p.WindowSeconds) * time.Second,
+		}
+		switch p.SubjectType {
+		case database.RateLimitSubjectTypeUser:
+			set.users[p.Subject] = converted
+		case database.RateLimitSubjectTypeScope:
+			set.scopes[p.Subject] = converted
+		case database.RateLimitSubjectTypeRole:
+			set.roles[p.Subject] = converted
+		}
+	}
+	l.policies.Store(set)
+	return nil
+}
+
+// PoliciesChanged reloads the policies on this replica and notifies the
+// others to do the same.
+func (l *Limiter) PoliciesChanged(ctx context.Context) error {
+	err := l.ReloadPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	if l.opts.Pubsub == nil {
+		return nil
+	}
+	err = l.opts.Pubsub.Publish(EventPolicies, []byte{})
+	if err != nil {
+		return xerrors.Errorf("publish rate limit policy change: %w", err)
+	}
+	return nil
+}
+
+// Handler limits requests per endpoint. Authenticated requests are counted
+// per user, and unauthenticated requests per IP address.
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		defaultPolicy := policy{count: l.opts.DefaultCount, window: l.opts.DefaultWindow}
+		ident, known := l.identify(r)
+		if !known {
+			// The API key hasn't been seen recently. Count the request
+			// against the client's IP address before looking the key up,
+			// so requests with made up keys are limited without querying
+			// the database.
+			if !l.allow(rw, r, "ip:"+remoteIP(r), defaultPolicy) {
+				return
+			}
+			ident = l.lookupIdentity(r)
+			if !ident.valid {
+				next.ServeHTTP(rw, r)
+				return
+			}
+		}
+
+		if ident.valid {
+			if bypass, _ := strconv.ParseBool(r.Header.Get(codersdk.BypassRatelimitHeader)); bypass {
+				// Owners may bypass rate limiting for load tests and
+				// automation. We avoid using rbac.Authorizer since rego is
+				// CPU-intensive and undermines the DoS-prevention goal of
+				// the rate limiter.
+				if !ident.hasRole(rbac.RoleOwner()) {
+					httpapi.Write(r.Context(), rw, http.StatusPreconditionRequired, codersdk.Response{
+						Message: fmt.Sprintf("%q provided but user is not %v.", codersdk.BypassRatelimitHeader, rbac.RoleOwner()),
+					})
+					return
+				}
+				next.ServeHTTP(rw, r)
+				return
+			}
+		}
+
+		p := l.policies.Load().resolve(ident, defaultPolicy)
+		subject := "ip:" + remoteIP(r)
+		if ident.valid {
+			subject = "user:" + ident.userID.String()
+		}
+		if !l.allow(rw, r, subject, p) {
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// allow counts the request against subject and writes a response if the
+// policy doesn't allow it.
+func (l *Limiter) allow(rw http.ResponseWriter, r *http.Request, subject string, p policy) bool {
+	if p.unlimited() {
+		return true
+	}
+	allowed, reset := l.take(subject+":"+r.URL.Path, p, time.Now())
+	if allowed {
+		return true
+	}
+	retryAfter := int(time.Until(reset).Round(time.Second).Seconds())
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	rw.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	httpapi.Write(r.Context(), rw, http.StatusTooManyRequests, codersdk.Response{
+		Message: fmt.Sprintf("You've been rate limited for sending more than %v requests in %v.", p.count, p.window),
+	})
+	return false
+}
+
+// remoteIP returns the client's address. httpmw.ExtractRealIP has already
+// rewritten RemoteAddr when running behind a trusted proxy.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// take counts a request against key and reports whether it's allowed. If it
+// isn't, the time the current window ends is returned.
+func (l *Limiter) take(key string, p policy, now time.Time) (bool, time.Time) {
+	start := now.Truncate(p.window)
+
+	l.counterMutex.Lock()
+	defer l.counterMutex.Unlock()
+	c, ok := l.counters[key]
+	if !ok || c.start.Before(start) {
+		c = &counter{start: start, end: start.Add(p.window)}
+		l.counters[key] = c
+	}
+	if c.local+c.remote >= int64(p.count) {
+		return false, c.end
+	}
+	c.local++
+	c.unsent++
+	return true, c.end
+}
+
+func (l *Limiter) run() {
+	defer close(l.closed)
+	ticker := time.NewTicker(l.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.flush(now)
+		}
+	}
+}
+
+// countsMessage is published on EventCounts.
+type countsMessage struct {
+	ReplicaID uuid.UUID     `json:"replica_id"`
+	Counts    []countsEntry `json:"counts"`
+}
+
+type countsEntry struct {
+	Key         string    `json:"key"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	Count       int64     `json:"count"`
+}
+
+// flush publishes the requests counted since the last flush and forgets
+// counters whose window has ended.
+func (l *Limiter) flush(now time.Time) {
+	msg := countsMessage{ReplicaID: l.replicaID}
+	l.counterMutex.Lock()
+	for key, c := range l.counters {
+		if !c.end.After(now) {
+			delete(l.counters, key)
+			continue
+		}
+		if c.unsent == 0 {
+			continue
+		}
+		msg.Counts = append(msg.Counts, countsEntry{
+			Key:         key,
+			WindowStart: c.start,
+			WindowEnd:   c.end,
+			Count:       c.unsent,
+		})
+		c.unsent = 0
+	}
+	l.counterMutex.Unlock()
+
+	l.identityMutex.Lock()
+	for key, ident := range l.identities {
+		if now.Sub(ident.cachedAt) > l.opts.IdentityCacheTTL {
+			delete(l.identities, key)
+		}
+	}
+	l.identityMutex.Unlock()
+
+	if l.opts.Pubsub == nil || len(msg.Counts) == 0 {
+		return
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		l.opts.Logger.Error(l.ctx, "marshal rate limit counts", slog.Error(err))
+		return
+	}
+	err = l.opts.Pubsub.Publish(EventCounts, data)
+	if err != nil {
+		l.opts.Logger.Warn(l.ctx, "publish rate limit counts", slog.Error(err))
+	}
+}
+
+func (l *Limiter) handleCounts(ctx context.Context, data []byte) {
+	var msg countsMessage
+	err := json.Unmarshal(data, &msg)
+	if err != nil {
+		l.opts.Logger.Warn(ctx, "unmarshal rate limit counts", slog.Error(err))
+		return
+	}
+	if msg.ReplicaID == l.replicaID {
+		return
+	}
+
+	l.counterMutex.Lock()
+	defer l.counterMutex.Unlock()
+	for _, entry := range msg.Counts {
+		c, ok := l.counters[entry.Key]
+		if ok && entry.WindowStart.Before(c.start) {
+			// The count is for a window we've already moved past.
+			continue
+		}
+		if !ok || c.start.Before(entry.WindowStart) {
+			c = &counter{start: entry.WindowStart, end: entry.WindowEnd}
+			l.counters[entry.Key] = c
+		}
+		c.remote += entry.Count
+	}
+}
+
+// identify returns the owner of the API key sent with the request from the
+// cache. The key is verified the same way httpmw.ExtractAPIKey does, but
+// expired or invalid keys are treated as anonymous rather than rejected.
+// known is false if the key must be looked up with lookupIdentity.
+func (l *Limiter) identify(r *http.Request) (ident identity, known bool) {
+	keyID, keySecret, ok := splitToken(r)
+	if !ok {
+		return identity{}, true
+	}
+
+	l.identityMutex.Lock()
+	ident, ok = l.identities[keyID]
+	l.identityMutex.Unlock()
+	if !ok || time.Since(ident.cachedAt) > l.opts.IdentityCacheTTL {
+		return identity{}, false
+	}
+	return ident.verify(keySecret), true
+}
+
+// lookupIdentity reads the API key sent with the request from the database
+// and caches it. Only keys that exist are cached, so made up keys can't
+// fill the cache.
+func (l *Limiter) lookupIdentity(r *http.Request) identity {
+	ctx := r.Context()
+	keyID, keySecret, ok := splitToken(r)
+	if !ok {
+		return identity{}
+	}
+	key, err := l.opts.Database.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		if !xerrors.Is(err, sql.ErrNoRows) {
+			l.opts.Logger.Warn(ctx, "get api key for rate limiting", slog.Error(err))
+		}
+		return identity{}
+	}
+	roles, err := l.opts.Database.GetAuthorizationUserRoles(ctx, key.UserID)
+	if err != nil {
+		l.opts.Logger.Warn(ctx, "get user roles for rate limiting", slog.Error(err))
+		return identity{}
+	}
+	ident := identity{
+		valid:        true,
+		hashedSecret: key.HashedSecret,
+		expiresAt:    key.ExpiresAt,
+		userID:       key.UserID,
+		scope:        string(key.Scope),
+		roles:        roles.Roles,
+		cachedAt:     time.Now(),
+	}
+
+	l.identityMutex.Lock()
+	if _, ok := l.identities[keyID]; !ok && len(l.identities) >= maxCachedIdentities {
+		// Evict an arbitrary entry, expired ones are removed on flush.
+		for evict := range l.identities {
+			delete(l.identities, evict)
+			break
+		}
+	}
+	l.identities[keyID] = ident
+	l.identityMutex.Unlock()
+
+	return ident.verify(keySecret)
+}
+
+// splitToken returns the ID and secret of the API key sent with the request.
+func splitToken(r *http.Request) (keyID string, keySecret string, ok bool) {
+	token := httpmw.APITokenFromRequest(r)
+	if token == "" {
+		return "", "", false
+	}
+	keyID, keySecret, err := httpmw.SplitAPIToken(token)
+	if err != nil {
+		return "", "", false
+	}
+	return keyID, keySecret, true
+}
+
+type identity struct {
+	valid        bool
+	hashedSecret []byte
+	expiresAt    time.Time
+	userID       uuid.UUID
+	scope        string
+	roles        []string
+	cachedAt     time.Time
+}
+
+// verify returns the identity if the secret matches the cached key and it
+// hasn't expired, and an anonymous identity otherwise.
+func (i identity) verify(keySecret string) identity {
+	if !i.valid || time.Now().After(i.expiresAt) {
+		return identity{}
+	}
+	hashedSecret := sha256.Sum256([]byte(keySecret))
+	if subtle.ConstantTimeCompare(i.hashedSecret, hashedSecret[:]) != 1 {
+		return identity{}
+	}
+	return i
+}
+
+func (i identity) hasRole(role string) bool {
+	for _, r := range i.roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type policy struct {
+	// count <= 0 disables rate limiting.
+	count  int
+	window time.Duration
+}
+
+func (p policy) unlimited() bool {
+	return p.count <= 0 || p.window <= 0
+}
+
+// morePermissive reports whether p allows a higher request rate than other.
+func (p policy) morePermissive(other policy) bool {
+	if p.unlimited() {
+		return !other.unlimited()
+	}
+	if other.unlimited() {
+		return false
+	}
+	return float64(p.count)/p.window.Seconds() > float64(other.count)/other.window.Seconds()
+}
+
+type policySet struct {
+	users  map[string]policy
+	scopes map[string]policy
+	roles  map[string]policy
+}
+
+func (s *policySet) resolve(ident identity, fallback policy) policy {
+	if !ident.valid {
+		return fallback
+	}
+	if p, ok := s.users[ident.userID.String()]; ok {
+		return p
+	}
+	if p, ok := s.scopes[ident.scope]; ok {
+		return p
+	}
+	var (
+		best  policy
+		found bool
+	)
+	for _, role := range ident.roles {
+		p, ok := s.roles[role]
+		if !ok {
+			continue
+		}
+		if !found || p.morePermissive(best) {
+			best = p
+			found = true
+		}
+	}
+	if found {
+		return best
+	}
+	return fallback
+}
+
+type counter struct {
+	start time.Time
+	end   time.Time
+	// local is the number of requests this replica allowed in the window.
+	local int64
+	// remote is the number of requests other replicas reported.
+	remote int64
+	// unsent is the number of local requests not yet published.
+	unsent int64
+}
@@ -0,0 +1,270 @@
+package ratelimit_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/ratelimit"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/cryptorand"
+	"github.com/coder/coder/testutil"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("DefaultByIP", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		handler := newHandler(t, db, nil, 3)
+
+		for i := 0; i < 3; i++ {
+			require.Equal(t, http.StatusOK, serve(handler, "", "1.1.1.1:1234"))
+		}
+		require.Equal(t, http.StatusTooManyRequests, serve(handler, "", "1.1.1.1:1234"))
+		// Other addresses have their own count.
+		require.Equal(t, http.StatusOK, serve(handler, "", "2.2.2.2:1234"))
+	})
+
+	t.Run("InvalidTokenUsesIP", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		user := insertUser(t, db)
+		token := insertAPIKey(t, db, user.ID, database.APIKeyScopeAll)
+		_, err := db.UpsertRateLimitPolicy(context.Background(), database.UpsertRateLimitPolicyParams{
+			ID:            uuid.New(),
+			SubjectType:   database.RateLimitSubjectTypeUser,
+			Subject:       user.ID.String(),
+			Count:         -1,
+			WindowSeconds: 60,
+		})
+		require.NoError(t, err)
+		handler := newHandler(t, db, nil, 1)
+
+		// Guessing the key ID alone must not grant the user's limit.
+		forged := token[:11] + "aaaaaaaaaaaaaaaaaaaaaa"
+		require.Equal(t, http.StatusOK, serve(handler, forged, "1.1.1.1:1234"))
+		require.Equal(t, http.StatusTooManyRequests, serve(handler, forged, "1.1.1.1:1234"))
+	})
+
+	t.Run("PolicyPrecedence", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		ctx := context.Background()
+		user := insertUser(t, db, rbac.RoleTemplateAdmin(), rbac.RoleUserAdmin())
+		upsert := func(subjectType database.RateLimitSubjectType, subject string, count int32) {
+			_, err := db.UpsertRateLimitPolicy(ctx, database.UpsertRateLimitPolicyParams{
+				ID:            uuid.New(),
+				SubjectType:   subjectType,
+				Subject:       subject,
+				Count:         count,
+				WindowSeconds: 60,
+			})
+			require.NoError(t, err)
+		}
+		upsert(database.RateLimitSubjectTypeRole, rbac.RoleTemplateAdmin(), 2)
+		upsert(database.RateLimitSubjectTypeRole, rbac.RoleUserAdmin(), 4)
+		upsert(database.RateLimitSubjectTypeScope, string(database.APIKeyScopeApplicationConnect), 1)
+		handler := newHandler(t, db, nil, 100)
+
+		// The most permissive role policy applies.
+		token := insertAPIKey(t, db, user.ID, database.APIKeyScopeAll)
+		for i := 0; i < 4; i++ {
+			require.Equal(t, http.StatusOK, serve(handler, token, "1.1.1.1:1234"))
+		}
+		require.Equal(t, http.StatusTooManyRequests, serve(handler, token, "1.1.1.1:1234"))
+
+		// A scope policy takes precedence over role policies.
+		scoped := insertAPIKey(t, db, user.ID, database.APIKeyScopeApplicationConnect)
+		require.Equal(t, http.StatusTooManyRequests, serve(handler, scoped, "1.1.1.1:1234"))
+	})
+
+	t.Run("ReloadPolicies", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		ps := database.NewPubsubInMemory()
+		user := insertUser(t, db)
+		token := insertAPIKey(t, db, user.ID, database.APIKeyScopeAll)
+		limiter := ratelimit.New(ratelimit.Options{
+			Logger:       slogtest.Make(t, nil),
+			Database:     db,
+			Pubsub:       ps,
+			DefaultCount: 1,
+		})
+		t.Cleanup(func() {
+			_ = limiter.Close()
+		})
+		handler := limiter.Handler(okHandler())
+
+		require.Equal(t, http.StatusOK, serve(handler, token, "1.1.1.1:1234"))
+		require.Equal(t, http.StatusTooManyRequests, serve(handler, token, "1.1.1.1:1234"))
+
+		// A policy change on another replica is picked up over pubsub.
+		_, err := db.UpsertRateLimitPolicy(context.Background(), database.UpsertRateLimitPolicyParams{
+			ID:            uuid.New(),
+			SubjectType:   database.RateLimitSubjectTypeUser,
+			Subject:       user.ID.String(),
+			Count:         -1,
+			WindowSeconds: 60,
+		})
+		require.NoError(t, err)
+		require.NoError(t, ps.Publish(ratelimit.EventPolicies, []byte{}))
+		require.Eventually(t, func() bool {
+			return serve(handler, token, "1.1.1.1:1234") == http.StatusOK
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("SharedBetweenReplicas", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		ps := database.NewPubsubInMemory()
+		user := insertUser(t, db)
+		token := insertAPIKey(t, db, user.ID, database.APIKeyScopeAll)
+		first := newHandler(t, db, ps, 2)
+		second := newHandler(t, db, ps, 2)
+
+		require.Equal(t, http.StatusOK, serve(first, token, "1.1.1.1:1234"))
+		require.Equal(t, http.StatusOK, serve(first, token, "1.1.1.1:1234"))
+		require.Equal(t, http.StatusTooManyRequests, serve(first, token, "1.1.1.1:1234"))
+		// The second replica learns about the requests made to the first.
+		require.Eventually(t, func() bool {
+			return serve(second, token, "1.1.1.1:1234") == http.StatusTooManyRequests
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("OwnerBypass", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		owner := insertUser(t, db, rbac.RoleOwner())
+		ownerToken := insertAPIKey(t, db, owner.ID, database.APIKeyScopeAll)
+		member := insertUser(t, db)
+		memberToken := insertAPIKey(t, db, member.ID, database.APIKeyScopeAll)
+		handler := newHandler(t, db, nil, 1)
+
+		bypass := func(token, remoteAddr string) int {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set(codersdk.SessionCustomHeader, token)
+			req.Header.Set(codersdk.BypassRatelimitHeader, "true")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec.Code
+		}
+		for i := 0; i < 5; i++ {
+			require.Equal(t, http.StatusOK, bypass(ownerToken, "1.1.1.1:1234"))
+		}
+		// The first request with a key is counted against the address, so
+		// use another one.
+		require.Equal(t, http.StatusPreconditionRequired, bypass(memberToken, "2.2.2.2:1234"))
+	})
+
+	t.Run("UnknownKeysLimitedByIP", func(t *testing.T) {
+		t.Parallel()
+		db := &countingStore{Store: databasefake.New()}
+		user := insertUser(t, db)
+		token := insertAPIKey(t, db, user.ID, database.APIKeyScopeAll)
+		handler := newHandler(t, db, nil, 3)
+
+		// Made up keys are limited by address before they're looked up.
+		for i := 0; i < 10; i++ {
+			id, err := cryptorand.String(10)
+			require.NoError(t, err)
+			serve(handler, id+"-aaaaaaaaaaaaaaaaaaaaaa", "1.1.1.1:1234")
+		}
+		require.EqualValues(t, 3, db.apiKeyLookups.Load())
+
+		// Known keys are cached.
+		require.Equal(t, http.StatusOK, serve(handler, token, "2.2.2.2:1234"))
+		require.Equal(t, http.StatusOK, serve(handler, token, "2.2.2.2:1234"))
+		require.EqualValues(t, 4, db.apiKeyLookups.Load())
+	})
+}
+
+// countingStore counts the API keys looked up.
+type countingStore struct {
+	database.Store
+	apiKeyLookups atomic.Int64
+}
+
+func (s *countingStore) GetAPIKeyByID(ctx context.Context, id string) (database.APIKey, error) {
+	s.apiKeyLookups.Add(1)
+	return s.Store.GetAPIKeyByID(ctx, id)
+}
+
+func newHandler(t *testing.T, db database.Store, ps database.Pubsub, defaultCount int) http.Handler {
+	limiter := ratelimit.New(ratelimit.Options{
+		Logger:        slogtest.Make(t, nil),
+		Database:      db,
+		Pubsub:        ps,
+		DefaultCount:  defaultCount,
+		FlushInterval: testutil.IntervalFast,
+	})
+	t.Cleanup(func() {
+		_ = limiter.Close()
+	})
+	return limiter.Handler(okHandler())
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(handler http.Handler, token, remoteAddr string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if token != "" {
+		req.Header.Set(codersdk.SessionCustomHeader, token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func insertUser(t *testing.T, db database.Store, roles ...string) database.User {
+	user, err := db.InsertUser(context.Background(), database.InsertUserParams{
+		ID:             uuid.New(),
+		Email:          uuid.NewString() + "@coder.com",
+		Username:       uuid.NewString()[:8],
+		HashedPassword: []byte{},
+		CreatedAt:      database.Now(),
+		UpdatedAt:      database.Now(),
+		RBACRoles:      roles,
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func insertAPIKey(t *testing.T, db database.Store, userID uuid.UUID, scope database.APIKeyScope) string {
+	id, err := cryptorand.String(10)
+	require.NoError(t, err)
+	secret, err := cryptorand.String(22)
+	require.NoError(t, err)
+	hashed := sha256.Sum256([]byte(secret))
+	_, err = db.InsertAPIKey(context.Background(), database.InsertAPIKeyParams{
+		ID:           id,
+		HashedSecret: hashed[:],
+		LastUsed:     database.Now(),
+		ExpiresAt:    database.Now().Add(time.Hour),
+		UserID:       userID,
+		LoginType:    database.LoginTypePassword,
+		Scope:        scope,
+	})
+	require.NoError(t, err)
+	return fmt.Sprintf("%s-%s", id, secret)
+}
@@ -0,0 +1,204 @@
+package coderd
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+func (api *API) rateLimitPolicies(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceRateLimitPolicy) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	policies, err := api.Database.GetRateLimitPolicies(ctx)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	apiPolicies := make([]codersdk.RateLimitPolicy, 0, len(policies))
+	for _, policy := range policies {
+		apiPolicies = append(apiPolicies, convertRateLimitPolicy(policy))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiPolicies)
+}
+
+func (api *API) putRateLimitPolicy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.RateLimitPolicy](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceRateLimitPolicy) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpsertRateLimitPolicyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	var validErrs []codersdk.ValidationError
+	if req.Count < -1 {
+		validErrs = append(validErrs, codersdk.ValidationError{
+			Field:  "count",
+			Detail: "Count must be positive, or -1 to disable rate limiting.",
+		})
+	}
+	switch req.SubjectType {
+	case codersdk.RateLimitSubjectTypeRole:
+		if _, err := api.CustomRoles.RoleByName(req.Subject); err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  "subject",
+				Detail: err.Error(),
+			})
+		}
+	case codersdk.RateLimitSubjectTypeScope:
+		switch database.APIKeyScope(req.Subject) {
+		case database.APIKeyScopeAll, database.APIKeyScopeApplicationConnect:
+		default:
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  "subject",
+				Detail: "Subject must be a valid API key scope.",
+			})
+		}
+	case codersdk.RateLimitSubjectTypeUser:
+		userID, err := uuid.Parse(req.Subject)
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  "subject",
+				Detail: "Subject must be a user ID.",
+			})
+			break
+		}
+		_, err = api.Database.GetUserByID(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			validErrs = append(validErrs, codersdk.ValidationError{
+				Field:  "subject",
+				Detail: "User does not exist.",
+			})
+			break
+		}
+		if err != nil {
+			httpapi.InternalServerError(rw, err)
+			return
+		}
+		// Normalize the ID so it matches the key used by the limiter.
+		req.Subject = userID.String()
+	}
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid rate limit policy.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	now := database.Now()
+	policy, err := api.Database.UpsertRateLimitPolicy(ctx, database.UpsertRateLimitPolicyParams{
+		ID:            uuid.New(),
+		SubjectType:   database.RateLimitSubjectType(req.SubjectType),
+		Subject:       req.Subject,
+		Count:         req.Count,
+		WindowSeconds: req.WindowSeconds,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	aReq.New = policy
+
+	err = api.RateLimiter.PoliciesChanged(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "reload rate limit policies", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertRateLimitPolicy(policy))
+}
+
+func (api *API) deleteRateLimitPolicy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.RateLimitPolicy](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceRateLimitPolicy) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	policyID, err := uuid.Parse(chi.URLParam(r, "ratelimit"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid rate limit policy ID.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	policy, err := api.Database.GetRateLimitPolicyByID(ctx, policyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	aReq.Old = policy
+
+	err = api.Database.DeleteRateLimitPolicyByID(ctx, policy.ID)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	err = api.RateLimiter.PoliciesChanged(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "reload rate limit policies", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+func convertRateLimitPolicy(policy database.RateLimitPolicy) codersdk.RateLimitPolicy {
+	return codersdk.RateLimitPolicy{
+		ID:            policy.ID,
+		SubjectType:   codersdk.RateLimitSubjectType(policy.SubjectType),
+		Subject:       policy.Subject,
+		Count:         policy.Count,
+		WindowSeconds: policy.WindowSeconds,
+		CreatedAt:     policy.CreatedAt,
+		UpdatedAt:     policy.UpdatedAt,
+	}
+}
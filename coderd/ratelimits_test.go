@@ -0,0 +1,118 @@
+package coderd_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestRateLimitPolicies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CRUD", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		policy, err := client.UpsertRateLimitPolicy(ctx, codersdk.UpsertRateLimitPolicyRequest{
+			SubjectType:   codersdk.RateLimitSubjectTypeRole,
+			Subject:       rbac.RoleTemplateAdmin(),
+			Count:         1000,
+			WindowSeconds: 60,
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 1000, policy.Count)
+
+		// Upserting the same subject updates the existing policy.
+		updated, err := client.UpsertRateLimitPolicy(ctx, codersdk.UpsertRateLimitPolicyRequest{
+			SubjectType:   codersdk.RateLimitSubjectTypeRole,
+			Subject:       rbac.RoleTemplateAdmin(),
+			Count:         -1,
+			WindowSeconds: 60,
+		})
+		require.NoError(t, err)
+		require.Equal(t, policy.ID, updated.ID)
+		require.EqualValues(t, -1, updated.Count)
+
+		policies, err := client.RateLimitPolicies(ctx)
+		require.NoError(t, err)
+		require.Len(t, policies, 1)
+		require.Equal(t, updated.ID, policies[0].ID)
+
+		err = client.DeleteRateLimitPolicy(ctx, policy.ID)
+		require.NoError(t, err)
+		policies, err = client.RateLimitPolicies(ctx)
+		require.NoError(t, err)
+		require.Len(t, policies, 0)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		for _, req := range []codersdk.UpsertRateLimitPolicyRequest{
+			{SubjectType: codersdk.RateLimitSubjectTypeRole, Subject: "not-a-role", Count: 10, WindowSeconds: 60},
+			{SubjectType: codersdk.RateLimitSubjectTypeScope, Subject: "bad", Count: 10, WindowSeconds: 60},
+			{SubjectType: codersdk.RateLimitSubjectTypeUser, Subject: "bad", Count: 10, WindowSeconds: 60},
+			{SubjectType: codersdk.RateLimitSubjectTypeUser, Subject: uuid.NewString(), Count: 10, WindowSeconds: 60},
+			{SubjectType: codersdk.RateLimitSubjectTypeRole, Subject: rbac.RoleMember(), Count: -5, WindowSeconds: 60},
+			{SubjectType: codersdk.RateLimitSubjectTypeRole, Subject: rbac.RoleMember(), Count: 10, WindowSeconds: 0},
+		} {
+			_, err := client.UpsertRateLimitPolicy(ctx, req)
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr, "%+v", req)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode(), "%+v", req)
+		}
+	})
+
+	t.Run("MemberCannotUpsert", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		ctx, _ := testutil.Context(t)
+
+		_, err := member.UpsertRateLimitPolicy(ctx, codersdk.UpsertRateLimitPolicyRequest{
+			SubjectType:   codersdk.RateLimitSubjectTypeRole,
+			Subject:       rbac.RoleMember(),
+			Count:         -1,
+			WindowSeconds: 60,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("UserPolicyRaisesLimit", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{
+			APIRateLimit: 5,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		member, memberUser := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+		ctx, _ := testutil.Context(t)
+
+		_, err := client.UpsertRateLimitPolicy(ctx, codersdk.UpsertRateLimitPolicyRequest{
+			SubjectType:   codersdk.RateLimitSubjectTypeUser,
+			Subject:       memberUser.ID.String(),
+			Count:         -1,
+			WindowSeconds: 60,
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			_, err := member.User(ctx, codersdk.Me)
+			require.NoError(t, err)
+		}
+	})
+}
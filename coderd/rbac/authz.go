@@ -80,7 +80,8 @@ func Filter[O Objecter](ctx context.Context, auth Authorizer, subjID string, sub
 
 // RegoAuthorizer will use a prepared rego query for performing authorize()
 type RegoAuthorizer struct {
-	query rego.PreparedEvalQuery
+	query       rego.PreparedEvalQuery
+	customRoles *CustomRoles
 }
 
 var _ Authorizer = (*RegoAuthorizer)(nil)
@@ -108,28 +109,38 @@ func NewAuthorizer() *RegoAuthorizer {
 	return &RegoAuthorizer{query: query}
 }
 
+// WithCustomRoles returns a copy of the authorizer that also expands role
+// names into the given custom roles.
+func (a *RegoAuthorizer) WithCustomRoles(customRoles *CustomRoles) *RegoAuthorizer {
+	return &RegoAuthorizer{
+		query:       a.query,
+		customRoles: customRoles,
+	}
+}
+
 type authSubject struct {
-	ID     string   `json:"id"`
-	Roles  []Role   `json:"roles"`
-	Groups []string `json:"groups"`
-	Scope  Role     `json:"scope"`
+	ID     string        `json:"id"`
+	Roles  []Role        `json:"roles"`
+	Groups []string      `json:"groups"`
+	Scope  ExpandedScope `json:"scope"`
 }
 
 // ByRoleName will expand all roleNames into roles before calling Authorize().
 // This is the function intended to be used outside this package.
-// The role is fetched from the builtin map located in memory.
+// The role is fetched from the builtin map located in memory, or from the
+// custom roles of the authorizer.
 func (a RegoAuthorizer) ByRoleName(ctx context.Context, subjectID string, roleNames []string, scope Scope, groups []string, action Action, object Object) error {
-	roles, err := RolesByNames(roleNames)
+	roles, err := a.customRoles.RolesByNames(roleNames)
 	if err != nil {
 		return err
 	}
 
-	scopeRole, err := ScopeRole(scope)
+	expandedScope, err := ExpandScope(scope)
 	if err != nil {
 		return err
 	}
 
-	err = a.Authorize(ctx, subjectID, roles, scopeRole, groups, action, object)
+	err = a.authorize(ctx, subjectID, roles, expandedScope, groups, action, object)
 	if err != nil {
 		return err
 	}
@@ -140,6 +151,10 @@ func (a RegoAuthorizer) ByRoleName(ctx context.Context, subjectID string, roleNa
 // Authorize allows passing in custom Roles.
 // This is really helpful for unit testing, as we can create custom roles to exercise edge cases.
 func (a RegoAuthorizer) Authorize(ctx context.Context, subjectID string, roles []Role, scope Role, groups []string, action Action, object Object) error {
+	return a.authorize(ctx, subjectID, roles, ExpandedScope{Role: scope}, groups, action, object)
+}
+
+func (a RegoAuthorizer) authorize(ctx context.Context, subjectID string, roles []Role, scope ExpandedScope, groups []string, action Action, object Object) error {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
@@ -167,7 +182,11 @@ func (a RegoAuthorizer) Authorize(ctx context.Context, subjectID string, roles [
 
 // Prepare will partially execute the rego policy leaving the object fields unknown (except for the type).
 // This will vastly speed up performance if batch authorization on the same type of objects is needed.
-func (RegoAuthorizer) Prepare(ctx context.Context, subjectID string, roles []Role, scope Role, groups []string, action Action, objectType string) (*PartialAuthorizer, error) {
+func (a RegoAuthorizer) Prepare(ctx context.Context, subjectID string, roles []Role, scope Role, groups []string, action Action, objectType string) (*PartialAuthorizer, error) {
+	return a.prepare(ctx, subjectID, roles, ExpandedScope{Role: scope}, groups, action, objectType)
+}
+
+func (RegoAuthorizer) prepare(ctx context.Context, subjectID string, roles []Role, scope ExpandedScope, groups []string, action Action, objectType string) (*PartialAuthorizer, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
@@ -183,15 +202,15 @@ func (a RegoAuthorizer) PrepareByRoleName(ctx context.Context, subjectID string,
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
-	roles, err := RolesByNames(roleNames)
+	roles, err := a.customRoles.RolesByNames(roleNames)
 	if err != nil {
 		return nil, err
 	}
 
-	scopeRole, err := ScopeRole(scope)
+	expandedScope, err := ExpandScope(scope)
 	if err != nil {
 		return nil, err
 	}
 
-	return a.Prepare(ctx, subjectID, roles, scopeRole, groups, action, objectType)
+	return a.prepare(ctx, subjectID, roles, expandedScope, groups, action, objectType)
 }
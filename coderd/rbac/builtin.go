@@ -17,6 +17,11 @@ const (
 
 	orgAdmin  string = "organization-admin"
 	orgMember string = "organization-member"
+
+	// customSiteRole and customOrgRole stand in for all custom roles when
+	// checking which roles can be assigned.
+	customSiteRole string = "custom-site-role"
+	customOrgRole  string = "custom-organization-role"
 )
 
 // The functions below ONLY need to exist for roles that are "defaulted" in some way.
@@ -198,14 +203,19 @@ var (
 			orgMember:     true,
 			templateAdmin: true,
 			userAdmin:     true,
+			// Custom roles can grant anything, so only owners can assign
+			// site wide ones.
+			customSiteRole: true,
+			customOrgRole:  true,
 		},
 		userAdmin: {
 			member:    true,
 			orgMember: true,
 		},
 		orgAdmin: {
-			orgAdmin:  true,
-			orgMember: true,
+			orgAdmin:      true,
+			orgMember:     true,
+			customOrgRole: true,
 		},
 	}
 )
@@ -218,6 +228,13 @@ func CanAssignRole(roles []string, assignedRole string) bool {
 	if err != nil {
 		return false
 	}
+	if _, ok := builtInRoles[assigned]; !ok {
+		// Any role that is not builtin is a custom role.
+		assigned = customSiteRole
+		if assignedOrg != "" {
+			assigned = customOrgRole
+		}
+	}
 
 	for _, longRole := range roles {
 		role, orgID, err := roleSplit(longRole)
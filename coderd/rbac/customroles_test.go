@@ -0,0 +1,83 @@
+package rbac_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/rbac"
+)
+
+func TestCustomRoles(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.New()
+	operator := rbac.Role{
+		Name:        "template-operator",
+		DisplayName: "Template Operator",
+		Site: []rbac.Permission{
+			{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionCreate},
+			{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionUpdate},
+		},
+	}
+	reader := rbac.Role{
+		Name:        "template-reader:" + orgID.String(),
+		DisplayName: "Template Reader",
+		Org: map[string][]rbac.Permission{
+			orgID.String(): {{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionRead}},
+		},
+	}
+	customRoles := rbac.NewCustomRoles()
+	customRoles.Set([]rbac.Role{operator, reader})
+
+	t.Run("RoleByName", func(t *testing.T) {
+		t.Parallel()
+		role, err := customRoles.RoleByName("template-operator")
+		require.NoError(t, err)
+		require.Equal(t, operator, role)
+		_, err = customRoles.RoleByName("template-reader:" + uuid.NewString())
+		require.Error(t, err)
+		_, err = customRoles.RoleByName(rbac.RoleTemplateAdmin())
+		require.NoError(t, err)
+
+		var nilRoles *rbac.CustomRoles
+		_, err = nilRoles.RoleByName("template-operator")
+		require.Error(t, err)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+		require.Contains(t, customRoles.SiteRoles(), operator)
+		require.NotContains(t, customRoles.SiteRoles(), reader)
+		require.Contains(t, customRoles.OrganizationRoles(orgID), reader)
+		require.NotContains(t, customRoles.OrganizationRoles(uuid.New()), reader)
+	})
+
+	t.Run("Authorize", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		auth := rbac.NewAuthorizer().WithCustomRoles(customRoles)
+		roles := []string{rbac.RoleMember(), "template-operator"}
+		template := rbac.ResourceTemplate.InOrg(orgID)
+
+		err := auth.ByRoleName(ctx, uuid.NewString(), roles, rbac.ScopeAll, nil, rbac.ActionUpdate, template)
+		require.NoError(t, err)
+		err = auth.ByRoleName(ctx, uuid.NewString(), roles, rbac.ScopeAll, nil, rbac.ActionDelete, template)
+		require.Error(t, err)
+
+		// The authorizer without custom roles doesn't know them.
+		err = rbac.NewAuthorizer().ByRoleName(ctx, uuid.NewString(), roles, rbac.ScopeAll, nil, rbac.ActionUpdate, template)
+		require.Error(t, err)
+	})
+
+	t.Run("CanAssign", func(t *testing.T) {
+		t.Parallel()
+		require.True(t, rbac.CanAssignRole([]string{rbac.RoleOwner()}, "template-operator"))
+		require.False(t, rbac.CanAssignRole([]string{rbac.RoleUserAdmin()}, "template-operator"))
+		require.False(t, rbac.CanAssignRole([]string{rbac.RoleOrgAdmin(orgID)}, "template-operator"))
+		require.True(t, rbac.CanAssignRole([]string{rbac.RoleOrgAdmin(orgID)}, reader.Name))
+		require.False(t, rbac.CanAssignRole([]string{rbac.RoleOrgAdmin(uuid.New())}, reader.Name))
+	})
+}
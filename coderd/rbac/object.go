@@ -150,14 +150,75 @@ var (
 	ResourceReplicas = Object{
 		Type: "replicas",
 	}
+
+	// ResourceWorkspaceProxy is a site wide workspace proxy.
+	//	create/delete = make or delete proxies
+	//	read = read proxy urls
+	//	update = re-register a proxy
+	ResourceWorkspaceProxy = Object{
+		Type: "workspace_proxy",
+	}
+
+	// ResourceRateLimitPolicy is a site wide API rate limit policy.
+	//	create/update/delete = change the rate limit of a role, scope or user
+	//	read = view rate limit policies
+	ResourceRateLimitPolicy = Object{
+		Type: "rate_limit_policy",
+	}
+
+	// ResourceConnectionStat is the network connection records of all
+	// workspaces.
+	//	read = view connection stats of every workspace, e.g. by DERP region
+	ResourceConnectionStat = Object{
+		Type: "connection_stat",
+	}
+
+	// ResourceCustomRole is a role defined by an administrator. Site + Org
+	//	create/update/delete = change custom roles
+	//	read = view custom roles
+	ResourceCustomRole = Object{
+		Type: "custom_role",
+	}
 )
 
+// ResourceTypes returns the type of every resource. Permissions of custom
+// roles are limited to these types.
+func ResourceTypes() []string {
+	return []string{
+		ResourceWildcard.Type,
+		ResourceWorkspace.Type,
+		ResourceWorkspaceExecution.Type,
+		ResourceWorkspaceApplicationConnect.Type,
+		ResourceAuditLog.Type,
+		ResourceTemplate.Type,
+		ResourceGroup.Type,
+		ResourceFile.Type,
+		ResourceProvisionerDaemon.Type,
+		ResourceOrganization.Type,
+		ResourceRoleAssignment.Type,
+		ResourceOrgRoleAssignment.Type,
+		ResourceAPIKey.Type,
+		ResourceUser.Type,
+		ResourceUserData.Type,
+		ResourceOrganizationMember.Type,
+		ResourceLicense.Type,
+		ResourceDeploymentConfig.Type,
+		ResourceReplicas.Type,
+		ResourceWorkspaceProxy.Type,
+		ResourceRateLimitPolicy.Type,
+		ResourceConnectionStat.Type,
+		ResourceCustomRole.Type,
+	}
+}
+
 // Object is used to create objects for authz checks when you have none in
 // hand to run the check on.
 // An example is if you want to list all workspaces, you can create a Object
 // that represents the set of workspaces you are trying to get access too.
 // Do not export this type, as it can be created from a resource type constant.
 type Object struct {
+	// ID is the id of the resource, if the object is a single resource.
+	ID    string `json:"id"`
 	Owner string `json:"owner"`
 	// OrgID specifies which org the object is a part of.
 	OrgID string `json:"org_owner"`
@@ -176,6 +237,7 @@ func (z Object) RBACObject() Object {
 // All returns an object matching all resources of the same type.
 func (z Object) All() Object {
 	return Object{
+		ID:           "",
 		Owner:        "",
 		OrgID:        "",
 		Type:         z.Type,
@@ -187,6 +249,7 @@ func (z Object) All() Object {
 // InOrg adds an org OwnerID to the resource
 func (z Object) InOrg(orgID uuid.UUID) Object {
 	return Object{
+		ID:           z.ID,
 		Owner:        z.Owner,
 		OrgID:        orgID.String(),
 		Type:         z.Type,
@@ -198,6 +261,7 @@ func (z Object) InOrg(orgID uuid.UUID) Object {
 // WithOwner adds an OwnerID to the resource
 func (z Object) WithOwner(ownerID string) Object {
 	return Object{
+		ID:           z.ID,
 		Owner:        ownerID,
 		OrgID:        z.OrgID,
 		Type:         z.Type,
@@ -209,6 +273,7 @@ func (z Object) WithOwner(ownerID string) Object {
 // WithACLUserList adds an ACL list to a given object
 func (z Object) WithACLUserList(acl map[string][]Action) Object {
 	return Object{
+		ID:           z.ID,
 		Owner:        z.Owner,
 		OrgID:        z.OrgID,
 		Type:         z.Type,
@@ -219,6 +284,7 @@ func (z Object) WithACLUserList(acl map[string][]Action) Object {
 
 func (z Object) WithGroupACL(groups map[string][]Action) Object {
 	return Object{
+		ID:           z.ID,
 		Owner:        z.Owner,
 		OrgID:        z.OrgID,
 		Type:         z.Type,
@@ -226,3 +292,15 @@ func (z Object) WithGroupACL(groups map[string][]Action) Object {
 		ACLGroupList: groups,
 	}
 }
+
+// WithID adds the ID of a single resource, which scopes can be pinned to.
+func (z Object) WithID(id uuid.UUID) Object {
+	return Object{
+		ID:           id.String(),
+		Owner:        z.Owner,
+		OrgID:        z.OrgID,
+		Type:         z.Type,
+		ACLUserList:  z.ACLUserList,
+		ACLGroupList: z.ACLGroupList,
+	}
+}
@@ -196,6 +196,16 @@ scope_allow {
 	scope_user = 1
 }
 
+# Scopes can be pinned to specific resources of a type. Resource types that
+# are not in the allow list are not restricted.
+scope_allow_list {
+	not input.subject.scope.allow_list[input.object.type]
+}
+
+scope_allow_list {
+	input.object.id in input.subject.scope.allow_list[input.object.type]
+}
+
 # ACL for users
 acl_allow {
 	# Should you have to be a member of the org too?
@@ -225,15 +235,17 @@ acl_allow {
 ###############
 # Final Allow
 # The role or the ACL must allow the action. Scopes can be used to limit,
-# so scope_allow must always be true.
+# so scope_allow and scope_allow_list must always be true.
 
 allow {
 	role_allow
 	scope_allow
+	scope_allow_list
 }
 
 # ACL list must also have the scope_allow to pass
 allow {
 	acl_allow
 	scope_allow
+	scope_allow_list
 }
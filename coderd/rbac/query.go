@@ -68,6 +68,11 @@ func DefaultConfig() SQLConfig {
 				ColumnSelect: "user_acl->$1",
 				Type:         VarTypeJsonbTextArray,
 			},
+			{
+				RegoMatch:    regexp.MustCompile(`^input\.object\.id$`),
+				ColumnSelect: "id :: text",
+				Type:         VarTypeText,
+			},
 			{
 				RegoMatch:    regexp.MustCompile(`^input\.object\.org_owner$`),
 				ColumnSelect: "organization_id :: text",
@@ -95,6 +100,11 @@ func NoACLConfig() SQLConfig {
 				ColumnSelect: "",
 				Type:         VarTypeSkip,
 			},
+			{
+				RegoMatch:    regexp.MustCompile(`^input\.object\.id$`),
+				ColumnSelect: "id :: text",
+				Type:         VarTypeText,
+			},
 			{
 				RegoMatch:    regexp.MustCompile(`^input\.object\.org_owner$`),
 				ColumnSelect: "organization_id :: text",
@@ -348,8 +358,16 @@ func processTerm(term *ast.Term) (Term, error) {
 			Value: trimQuotes(v.String()),
 			base:  termBase,
 		}, nil
-	case ast.Set:
-		slice := v.Slice()
+	case ast.Set, *ast.Array:
+		var slice []*ast.Term
+		switch v := v.(type) {
+		case ast.Set:
+			slice = v.Slice()
+		case *ast.Array:
+			for i := 0; i < v.Len(); i++ {
+				slice = append(slice, v.Elem(i))
+			}
+		}
 		set := make([]Term, 0, len(slice))
 		for _, elem := range slice {
 			processed, err := processTerm(elem)
@@ -2,10 +2,15 @@ package rbac
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 )
 
+// Scope is either one of the builtin scopes, or a comma separated list of
+// fine-grained scopes. See ScopeList.
 type Scope string
 
 const (
@@ -37,10 +42,155 @@ var builtinScopes map[Scope]Role = map[Scope]Role{
 	},
 }
 
+// fineGrainedScope restricts a token to a few actions on a resource type.
+type fineGrainedScope struct {
+	// pinnable is the resource type the scope can be pinned to by appending
+	// a resource ID, e.g. "template:push:<template id>". Empty if the scope
+	// cannot be pinned.
+	pinnable    string
+	permissions map[string][]Action
+}
+
+// fineGrainedScopes are the scopes that can be combined into a scope list.
+var fineGrainedScopes = map[string]fineGrainedScope{
+	"workspace:read": {
+		pinnable: ResourceWorkspace.Type,
+		permissions: map[string][]Action{
+			ResourceWorkspace.Type: {ActionRead},
+		},
+	},
+	// Builds are authorized as workspace updates, so starting and stopping
+	// also allows renaming a workspace, changing its schedule and building
+	// other template versions.
+	"workspace:start-stop": {
+		pinnable: ResourceWorkspace.Type,
+		permissions: map[string][]Action{
+			ResourceWorkspace.Type: {ActionRead, ActionUpdate},
+		},
+	},
+	"template:read": {
+		pinnable: ResourceTemplate.Type,
+		permissions: map[string][]Action{
+			ResourceTemplate.Type: {ActionRead},
+		},
+	},
+	"template:push": {
+		pinnable: ResourceTemplate.Type,
+		permissions: map[string][]Action{
+			ResourceTemplate.Type: {ActionRead, ActionCreate, ActionUpdate},
+			// Template versions are uploaded as files first.
+			ResourceFile.Type: {ActionRead, ActionCreate},
+		},
+	},
+	"audit:read": {
+		permissions: map[string][]Action{
+			ResourceAuditLog.Type: {ActionRead},
+		},
+	},
+}
+
+// scopeListPermissions are granted to every scope list, so clients can look
+// up the user and organization they act on behalf of.
+var scopeListPermissions = map[string][]Action{
+	ResourceUser.Type:         {ActionRead},
+	ResourceOrganization.Type: {ActionRead},
+}
+
+// ExpandedScope is a scope with the resources it is restricted to.
+type ExpandedScope struct {
+	Role
+	// AllowIDList maps resource types to the IDs of the resources the scope
+	// is pinned to. Resource types that are not in the map are not restricted
+	// to specific resources.
+	AllowIDList map[string][]string `json:"allow_list"`
+}
+
+// FineGrainedScopes returns the names of the scopes that can be combined into
+// a scope list.
+func FineGrainedScopes() []string {
+	names := make([]string, 0, len(fineGrainedScopes))
+	for name := range fineGrainedScopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScopeList combines fine-grained scopes like "workspace:read" or
+// "template:push:<template id>" into a single scope. The result is validated
+// by ExpandScope.
+func ScopeList(scopes []string) Scope {
+	return Scope(strings.Join(scopes, ","))
+}
+
+// ScopeRole returns the role of a scope, without the resources the scope is
+// pinned to. Use ExpandScope to authorize with a scope.
 func ScopeRole(scope Scope) (Role, error) {
-	role, ok := builtinScopes[scope]
-	if !ok {
-		return Role{}, xerrors.Errorf("no scope named %q", scope)
+	expanded, err := ExpandScope(scope)
+	if err != nil {
+		return Role{}, err
+	}
+	return expanded.Role, nil
+}
+
+// ExpandScope returns the role and the pinned resources of a builtin scope or
+// a scope list.
+func ExpandScope(scope Scope) (ExpandedScope, error) {
+	if role, ok := builtinScopes[scope]; ok {
+		return ExpandedScope{Role: role}, nil
 	}
-	return role, nil
+	if scope == "" {
+		return ExpandedScope{}, xerrors.Errorf("no scope named %q", scope)
+	}
+
+	actions := map[string][]Action{}
+	for resourceType, allowed := range scopeListPermissions {
+		actions[resourceType] = append(actions[resourceType], allowed...)
+	}
+	allowList := map[string][]string{}
+	// unpinned tracks the resource types that are granted without pinning,
+	// which cannot be mixed with pinned scopes of the same type.
+	unpinned := map[string]bool{}
+	for _, entry := range strings.Split(string(scope), ",") {
+		name, id := entry, ""
+		if parts := strings.Split(entry, ":"); len(parts) == 3 {
+			name, id = parts[0]+":"+parts[1], parts[2]
+		}
+		fineGrained, ok := fineGrainedScopes[name]
+		if !ok {
+			return ExpandedScope{}, xerrors.Errorf("no scope named %q", entry)
+		}
+		for resourceType, allowed := range fineGrained.permissions {
+			actions[resourceType] = append(actions[resourceType], allowed...)
+		}
+		if id == "" {
+			if fineGrained.pinnable != "" {
+				unpinned[fineGrained.pinnable] = true
+			}
+			continue
+		}
+		if fineGrained.pinnable == "" {
+			return ExpandedScope{}, xerrors.Errorf("scope %q cannot be pinned to a resource", name)
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return ExpandedScope{}, xerrors.Errorf("scope %q: invalid resource id %q", name, id)
+		}
+		allowList[fineGrained.pinnable] = append(allowList[fineGrained.pinnable], id)
+	}
+	for resourceType := range allowList {
+		if unpinned[resourceType] {
+			return ExpandedScope{}, xerrors.Errorf("scopes on %q must either all be pinned to resources or none", resourceType)
+		}
+	}
+
+	return ExpandedScope{
+		Role: Role{
+			Name:        fmt.Sprintf("Scope_%s", scope),
+			DisplayName: "Fine-grained scopes",
+			Site:        permissions(actions),
+			Org:         map[string][]Permission{},
+			User:        []Permission{},
+		},
+		AllowIDList: allowList,
+	}, nil
 }
@@ -0,0 +1,97 @@
+package rbac_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/rbac"
+)
+
+func TestExpandScope(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.NewString()
+	for _, tc := range []struct {
+		name  string
+		scope rbac.Scope
+		err   string
+	}{
+		{name: "Builtin", scope: rbac.ScopeApplicationConnect},
+		{name: "List", scope: rbac.ScopeList([]string{"workspace:read", "audit:read"})},
+		{name: "Pinned", scope: rbac.ScopeList([]string{"template:push:" + id, "template:read:" + uuid.NewString()})},
+		{name: "Empty", scope: "", err: "no scope named"},
+		{name: "Unknown", scope: rbac.ScopeList([]string{"workspace:read", "workspace:launch"}), err: "no scope named \"workspace:launch\""},
+		{name: "NotPinnable", scope: rbac.ScopeList([]string{"audit:read:" + id}), err: "cannot be pinned"},
+		{name: "InvalidID", scope: rbac.ScopeList([]string{"template:push:main"}), err: "invalid resource id"},
+		{name: "MixedPinning", scope: rbac.ScopeList([]string{"template:push:" + id, "template:read"}), err: "all be pinned"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := rbac.ExpandScope(tc.scope)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestScopeList(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	auth := rbac.NewAuthorizer()
+	userID := uuid.New()
+	orgID := uuid.New()
+	roles := []string{rbac.RoleMember(), rbac.RoleOrgMember(orgID), rbac.RoleOwner()}
+	pinned := uuid.New()
+	template := rbac.ResourceTemplate.InOrg(orgID)
+
+	t.Run("Actions", func(t *testing.T) {
+		t.Parallel()
+		scope := rbac.ScopeList([]string{"template:push"})
+		err := auth.ByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionUpdate, template.WithID(uuid.New()))
+		require.NoError(t, err)
+		err = auth.ByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionDelete, template.WithID(uuid.New()))
+		require.Error(t, err)
+		err = auth.ByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionRead, rbac.ResourceWorkspace.InOrg(orgID).WithOwner(userID.String()))
+		require.Error(t, err)
+		err = auth.ByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionRead, rbac.ResourceUser)
+		require.NoError(t, err)
+	})
+
+	t.Run("Pinned", func(t *testing.T) {
+		t.Parallel()
+		scope := rbac.ScopeList([]string{"template:push:" + pinned.String()})
+		err := auth.ByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionUpdate, template.WithID(pinned))
+		require.NoError(t, err)
+		err = auth.ByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionUpdate, template.WithID(uuid.New()))
+		require.Error(t, err)
+		// Creating templates is not allowed, as the new template isn't the
+		// pinned one.
+		err = auth.ByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionCreate, template)
+		require.Error(t, err)
+		// Other resource types are not pinned.
+		err = auth.ByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionCreate, rbac.ResourceFile.WithOwner(userID.String()))
+		require.NoError(t, err)
+	})
+
+	t.Run("Prepared", func(t *testing.T) {
+		t.Parallel()
+		scope := rbac.ScopeList([]string{"workspace:read:" + pinned.String()})
+		prepared, err := auth.PrepareByRoleName(ctx, userID.String(), roles, scope, nil, rbac.ActionRead, rbac.ResourceWorkspace.Type)
+		require.NoError(t, err)
+		workspace := rbac.ResourceWorkspace.InOrg(orgID).WithOwner(userID.String())
+		require.NoError(t, prepared.Authorize(ctx, workspace.WithID(pinned)))
+		require.Error(t, prepared.Authorize(ctx, workspace.WithID(uuid.New())))
+
+		filter, err := prepared.Compile()
+		require.NoError(t, err)
+		require.Contains(t, filter.SQLString(rbac.NoACLConfig()), "id :: text = ANY(ARRAY ['"+pinned.String()+"'])")
+	})
+}
@@ -19,7 +19,7 @@ func (api *API) assignableSiteRoles(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	roles := rbac.SiteRoles()
+	roles := api.CustomRoles.SiteRoles()
 	httpapi.Write(ctx, rw, http.StatusOK, assignableRoles(actorRoles.Roles, roles))
 }
 
@@ -34,7 +34,7 @@ func (api *API) assignableOrgRoles(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	roles := rbac.OrganizationRoles(organization.ID)
+	roles := api.CustomRoles.OrganizationRoles(organization.ID)
 	httpapi.Write(ctx, rw, http.StatusOK, assignableRoles(actorRoles.Roles, roles))
 }
 
@@ -45,6 +45,16 @@ func convertRole(role rbac.Role) codersdk.Role {
 	}
 }
 
+// convertRoleName converts a role assigned to a user, which can be a builtin
+// or custom role. Unknown roles are shown without a display name.
+func convertRoleName(customRoles *rbac.CustomRoles, name string) codersdk.Role {
+	role, err := customRoles.RoleByName(name)
+	if err != nil {
+		return codersdk.Role{Name: name}
+	}
+	return convertRole(role)
+}
+
 func assignableRoles(actorRoles []string, roles []rbac.Role) []codersdk.AssignableRoles {
 	assignable := make([]codersdk.AssignableRoles, 0)
 	for _, role := range roles {
@@ -0,0 +1,282 @@
+package coderd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+func (api *API) postWorkspaceAgentSessionRecording(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != asciicast.ContentType {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Unsupported content type header %q.", contentType),
+		})
+		return
+	}
+	recordingType := database.SessionRecordingType(r.URL.Query().Get("type"))
+	switch recordingType {
+	case database.SessionRecordingTypeSsh, database.SessionRecordingTypeReconnectingPty:
+	default:
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid session recording type %q.", recordingType),
+		})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, codersdk.SessionRecordingMaxSize)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to read session recording from request.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	startedAt, endedAt, err := sessionRecordingTimes(data)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid asciicast recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace resource.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	build, err := api.Database.GetWorkspaceBuildByJobID(ctx, resource.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspace, err := api.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// The agent passes the user who connected if it knows them, e.g. from
+	// their SSH certificate. Otherwise the session is attributed to the
+	// workspace owner.
+	userID := workspace.OwnerID
+	if rawUserID := r.URL.Query().Get("user_id"); rawUserID != "" {
+		userID, err = uuid.Parse(rawUserID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid user id %q.", rawUserID),
+				Detail:  err.Error(),
+			})
+			return
+		}
+		_, err = api.Database.GetUserByID(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("User %q does not exist.", userID),
+			})
+			return
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching user.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	var recording database.WorkspaceSessionRecording
+	err = api.Database.InTx(func(tx database.Store) error {
+		hashBytes := sha256.Sum256(data)
+		hash := hex.EncodeToString(hashBytes[:])
+		// Recordings are stored as files of the user who connected.
+		file, err := tx.GetFileByHashAndCreator(ctx, database.GetFileByHashAndCreatorParams{
+			Hash:      hash,
+			CreatedBy: userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			file, err = tx.InsertFile(ctx, database.InsertFileParams{
+				ID:        uuid.New(),
+				Hash:      hash,
+				CreatedBy: userID,
+				CreatedAt: database.Now(),
+				Mimetype:  asciicast.ContentType,
+				Data:      data,
+			})
+		}
+		if err != nil {
+			return xerrors.Errorf("insert file: %w", err)
+		}
+
+		recording, err = tx.InsertWorkspaceSessionRecording(ctx, database.InsertWorkspaceSessionRecordingParams{
+			ID:          uuid.New(),
+			CreatedAt:   database.Now(),
+			WorkspaceID: workspace.ID,
+			AgentID:     workspaceAgent.ID,
+			FileID:      file.ID,
+			Type:        recordingType,
+			StartedAt:   startedAt,
+			EndedAt:     endedAt,
+			UserID:      userID,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert session recording: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error saving session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	additionalFields, err := json.Marshal(map[string]string{
+		"workspaceName": workspace.Name,
+		"agentName":     workspaceAgent.Name,
+	})
+	if err != nil {
+		api.Logger.Error(ctx, "marshal session recording audit fields", slog.Error(err))
+	}
+	audit.BackgroundAudit(ctx, &audit.BackgroundAuditParams[database.WorkspaceSessionRecording]{
+		Audit:            *api.Auditor.Load(),
+		Log:              api.Logger,
+		UserID:           userID,
+		Action:           database.AuditActionCreate,
+		AdditionalFields: additionalFields,
+		New:              recording,
+	})
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertWorkspaceSessionRecording(recording))
+}
+
+func (api *API) workspaceSessionRecordings(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	// Recordings are audit records, so they're visible to the same users.
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAuditLog) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	recordings, err := api.Database.GetWorkspaceSessionRecordingsByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	apiRecordings := make([]codersdk.WorkspaceSessionRecording, 0, len(recordings))
+	for _, recording := range recordings {
+		apiRecordings = append(apiRecordings, convertWorkspaceSessionRecording(recording))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiRecordings)
+}
+
+func (api *API) sessionRecording(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAuditLog) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "sessionrecording"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Session recording id must be a valid UUID.",
+		})
+		return
+	}
+	recording, err := api.Database.GetWorkspaceSessionRecordingByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	file, err := api.Database.GetFileByID(ctx, recording.FileID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session recording file.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.Header().Set("Content-Type", file.Mimetype)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(file.Data)
+}
+
+// sessionRecordingTimes returns when the session in an asciicast recording
+// started and when its last event happened.
+func sessionRecordingTimes(data []byte) (startedAt time.Time, endedAt time.Time, err error) {
+	reader, err := asciicast.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if reader.Header().Timestamp == 0 {
+		return time.Time{}, time.Time{}, xerrors.New("header has no timestamp")
+	}
+	startedAt = time.Unix(reader.Header().Timestamp, 0)
+	endedAt = startedAt
+	for {
+		event, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		endedAt = startedAt.Add(event.Time)
+	}
+	return startedAt, endedAt, nil
+}
+
+func convertWorkspaceSessionRecording(recording database.WorkspaceSessionRecording) codersdk.WorkspaceSessionRecording {
+	return codersdk.WorkspaceSessionRecording{
+		ID:          recording.ID,
+		CreatedAt:   recording.CreatedAt,
+		WorkspaceID: recording.WorkspaceID,
+		AgentID:     recording.AgentID,
+		Type:        codersdk.SessionRecordingType(recording.Type),
+		StartedAt:   recording.StartedAt,
+		EndedAt:     recording.EndedAt,
+		UserID:      recording.UserID,
+	}
+}
@@ -0,0 +1,166 @@
+package coderd_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceSessionRecordings(t *testing.T) {
+	t.Parallel()
+	auditor := audit.NewMock()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+		Auditor:                  auditor,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: echo.ParseComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	t.Cleanup(cancel)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+
+	metadata, err := agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.False(t, metadata.RecordSessions)
+	_, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+		RecordSessions: true,
+	})
+	require.NoError(t, err)
+	metadata, err = agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.True(t, metadata.RecordSessions)
+
+	var buf bytes.Buffer
+	cast, err := asciicast.NewWriter(&buf, asciicast.Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+	_, err = cast.Write([]byte("hello\r\n"))
+	require.NoError(t, err)
+	recordingData := buf.Bytes()
+
+	recording, err := agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.SessionRecordingTypeSSH, uuid.Nil, bytes.NewReader(recordingData))
+	require.NoError(t, err)
+	require.Equal(t, workspace.ID, recording.WorkspaceID)
+	require.Equal(t, codersdk.SessionRecordingTypeSSH, recording.Type)
+	require.False(t, recording.EndedAt.Before(recording.StartedAt))
+	// The agent didn't know who connected.
+	require.Equal(t, user.UserID, recording.UserID)
+
+	// Sessions of users the agent knows, e.g. from their SSH certificate,
+	// are attributed to them.
+	_, member := coderdtest.CreateAnotherUserWithUser(t, client, user.OrganizationID)
+	memberRecording, err := agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.SessionRecordingTypeSSH, member.ID, bytes.NewReader(recordingData))
+	require.NoError(t, err)
+	require.Equal(t, member.ID, memberRecording.UserID)
+	var auditedUsers []uuid.UUID
+	for _, log := range auditor.AuditLogs {
+		if log.ResourceType == database.ResourceTypeSessionRecording {
+			auditedUsers = append(auditedUsers, log.UserID)
+		}
+	}
+	require.Equal(t, []uuid.UUID{user.UserID, member.ID}, auditedUsers)
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+		ctx, _ := testutil.Context(t)
+		recordings, err := client.WorkspaceSessionRecordings(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Len(t, recordings, 2)
+		ids := []uuid.UUID{recordings[0].ID, recordings[1].ID}
+		require.ElementsMatch(t, []uuid.UUID{recording.ID, memberRecording.ID}, ids)
+		require.Equal(t, recording.AgentID, recordings[0].AgentID)
+	})
+
+	t.Run("Play", func(t *testing.T) {
+		t.Parallel()
+		ctx, _ := testutil.Context(t)
+		data, err := client.SessionRecording(ctx, recording.ID)
+		require.NoError(t, err)
+		require.Equal(t, recordingData, data)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+		ctx, _ := testutil.Context(t)
+		_, err := client.SessionRecording(ctx, uuid.New())
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Member", func(t *testing.T) {
+		t.Parallel()
+		ctx, _ := testutil.Context(t)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err := member.SessionRecording(ctx, recording.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidRecording", func(t *testing.T) {
+		t.Parallel()
+		ctx, _ := testutil.Context(t)
+		_, err := agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.SessionRecordingTypeSSH, uuid.Nil, strings.NewReader("not a recording"))
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		t.Parallel()
+		ctx, _ := testutil.Context(t)
+		_, err := agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.SessionRecordingTypeSSH, uuid.New(), bytes.NewReader(recordingData))
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidType", func(t *testing.T) {
+		t.Parallel()
+		ctx, _ := testutil.Context(t)
+		_, err := agentClient.PostWorkspaceAgentSessionRecording(ctx, "telnet", uuid.Nil, bytes.NewReader(recordingData))
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
@@ -0,0 +1,95 @@
+// Package sshca issues short-lived SSH user certificates for workspace
+// agents. Agents trust the certificate authority's public key, so clients
+// that can't speak to coderd, like a stock OpenSSH client, can prove they
+// were authorized to connect to a specific agent.
+package sshca
+
+import (
+	"crypto/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/gitsshkey"
+)
+
+const (
+	// ExtensionUserID is the certificate extension holding the ID of the
+	// user the certificate was issued to.
+	ExtensionUserID = "user-id@coder.com"
+	// ExtensionWorkspaceID is the certificate extension holding the ID of the
+	// workspace the certificate is valid for.
+	ExtensionWorkspaceID = "workspace-id@coder.com"
+
+	// clockSkew is subtracted from the start of a certificate's validity in
+	// case the agent's clock is behind coderd's.
+	clockSkew = time.Minute
+)
+
+// GenerateKey returns a new certificate authority key in the OpenSSH PEM
+// format.
+func GenerateKey() (string, error) {
+	privateKey, _, err := gitsshkey.Generate(gitsshkey.AlgorithmEd25519)
+	if err != nil {
+		return "", xerrors.Errorf("generate key: %w", err)
+	}
+	return privateKey, nil
+}
+
+// ParseKey parses a certificate authority key returned by GenerateKey.
+func ParseKey(privateKey string) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, xerrors.Errorf("parse private key: %w", err)
+	}
+	return signer, nil
+}
+
+// Principal is the only principal certificates for an agent are valid for.
+func Principal(agentID uuid.UUID) string {
+	return agentID.String()
+}
+
+type IssueOptions struct {
+	PublicKey   ssh.PublicKey
+	Username    string
+	UserID      uuid.UUID
+	WorkspaceID uuid.UUID
+	AgentID     uuid.UUID
+	TTL         time.Duration
+}
+
+// Issue signs a user certificate for the public key that's valid for a
+// single agent until the TTL passes.
+func Issue(ca ssh.Signer, opts IssueOptions) (*ssh.Certificate, error) {
+	if opts.TTL <= 0 {
+		return nil, xerrors.New("ttl must be positive")
+	}
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             opts.PublicKey,
+		CertType:        ssh.UserCert,
+		KeyId:           opts.Username,
+		ValidPrincipals: []string{Principal(opts.AgentID)},
+		ValidAfter:      uint64(now.Add(-clockSkew).Unix()),
+		ValidBefore:     uint64(now.Add(opts.TTL).Unix()),
+		Permissions: ssh.Permissions{
+			Extensions: map[string]string{
+				"permit-agent-forwarding": "",
+				"permit-port-forwarding":  "",
+				"permit-pty":              "",
+				"permit-user-rc":          "",
+				"permit-X11-forwarding":   "",
+				ExtensionUserID:           opts.UserID.String(),
+				ExtensionWorkspaceID:      opts.WorkspaceID.String(),
+			},
+		},
+	}
+	err := cert.SignCert(rand.Reader, ca)
+	if err != nil {
+		return nil, xerrors.Errorf("sign certificate: %w", err)
+	}
+	return cert, nil
+}
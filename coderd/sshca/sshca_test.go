@@ -0,0 +1,63 @@
+package sshca_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/coder/coder/coderd/sshca"
+)
+
+func TestIssue(t *testing.T) {
+	t.Parallel()
+
+	key, err := sshca.GenerateKey()
+	require.NoError(t, err)
+	ca, err := sshca.ParseKey(key)
+	require.NoError(t, err)
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	require.NoError(t, err)
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		userID := uuid.New()
+		workspaceID := uuid.New()
+		agentID := uuid.New()
+		cert, err := sshca.Issue(ca, sshca.IssueOptions{
+			PublicKey:   sshPublicKey,
+			Username:    "kyle",
+			UserID:      userID,
+			WorkspaceID: workspaceID,
+			AgentID:     agentID,
+			TTL:         time.Hour,
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint32(ssh.UserCert), cert.CertType)
+		require.Equal(t, "kyle", cert.KeyId)
+		require.Equal(t, []string{sshca.Principal(agentID)}, cert.ValidPrincipals)
+		require.Equal(t, userID.String(), cert.Extensions[sshca.ExtensionUserID])
+		require.Equal(t, workspaceID.String(), cert.Extensions[sshca.ExtensionWorkspaceID])
+		require.Equal(t, ca.PublicKey().Marshal(), cert.SignatureKey.Marshal())
+		require.WithinDuration(t, time.Now().Add(time.Hour), time.Unix(int64(cert.ValidBefore), 0), time.Minute)
+
+		checker := &ssh.CertChecker{}
+		require.NoError(t, checker.CheckCert(sshca.Principal(agentID), cert))
+		require.Error(t, checker.CheckCert(sshca.Principal(uuid.New()), cert))
+	})
+
+	t.Run("NoTTL", func(t *testing.T) {
+		t.Parallel()
+		_, err := sshca.Issue(ca, sshca.IssueOptions{
+			PublicKey: sshPublicKey,
+			AgentID:   uuid.New(),
+		})
+		require.Error(t, err)
+	})
+}
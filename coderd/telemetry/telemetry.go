@@ -348,6 +348,14 @@ func (r *remoteReporter) createSnapshot() (*Snapshot, error) {
 		if err != nil {
 			return xerrors.Errorf("get users: %w", err)
 		}
+		// Service accounts are not people, so they are left out.
+		humans := make([]database.User, 0, len(users))
+		for _, dbUser := range users {
+			if !dbUser.ServiceAccount {
+				humans = append(humans, dbUser)
+			}
+		}
+		users = humans
 		var firstUser database.User
 		for _, dbUser := range users {
 			if dbUser.Status != database.UserStatusActive {
@@ -112,6 +112,21 @@ func TestTelemetry(t *testing.T) {
 		require.Len(t, snapshot.Users, 1)
 		require.Equal(t, snapshot.Users[0].EmailHashed, "bb44bf07cf9a2db0554bba63a03d822c927deae77df101874496df5a6a3e896d@coder.com")
 	})
+	t.Run("ServiceAccounts", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		_, err := db.InsertUser(context.Background(), database.InsertUserParams{
+			ID:             uuid.New(),
+			Email:          "ci-bot@coder.com",
+			Username:       "ci-bot",
+			CreatedAt:      database.Now(),
+			LoginType:      database.LoginTypeToken,
+			ServiceAccount: true,
+		})
+		require.NoError(t, err)
+		snapshot := collectSnapshot(t, db)
+		require.Len(t, snapshot.Users, 0)
+	})
 }
 
 func collectSnapshot(t *testing.T, db database.Store) *telemetry.Snapshot {
@@ -14,6 +14,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/moby/moby/pkg/namesgenerator"
+	"golang.org/x/exp/slices"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd/audit"
@@ -473,6 +474,21 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 	if req.MaxTTLMillis > maxTTLDefault.Milliseconds() {
 		validErrs = append(validErrs, codersdk.ValidationError{Field: "max_ttl_ms", Detail: "Cannot be greater than " + maxTTLDefault.String()})
 	}
+	if req.AutostopRequirementDays < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "autostop_requirement_days", Detail: "Must be a positive integer."})
+	}
+	if req.InactivityTTLMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if req.DormantDeleteTTLMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "dormant_delete_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	for _, portRange := range req.PortForwardingAllowlist {
+		_, _, err := codersdk.ParsePortRange(portRange)
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "port_forwarding_allowlist", Detail: err.Error()})
+		}
+	}
 
 	if len(validErrs) > 0 {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
@@ -502,7 +518,12 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			req.Description == template.Description &&
 			req.Icon == template.Icon &&
 			req.MaxTTLMillis == time.Duration(template.MaxTtl).Milliseconds() &&
-			req.MinAutostartIntervalMillis == time.Duration(template.MinAutostartInterval).Milliseconds() {
+			req.MinAutostartIntervalMillis == time.Duration(template.MinAutostartInterval).Milliseconds() &&
+			req.AutostopRequirementDays == template.AutostopRequirementDays &&
+			req.InactivityTTLMillis == time.Duration(template.InactivityTtl).Milliseconds() &&
+			req.DormantDeleteTTLMillis == time.Duration(template.DormantDeleteTtl).Milliseconds() &&
+			slices.Equal(req.PortForwardingAllowlist, template.PortForwardingAllowlist) &&
+			req.RecordSessions == template.RecordSessions {
 			return nil
 		}
 
@@ -522,15 +543,24 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		if minAutostartInterval == 0 {
 			minAutostartInterval = time.Duration(template.MinAutostartInterval)
 		}
+		portForwardingAllowlist := req.PortForwardingAllowlist
+		if portForwardingAllowlist == nil {
+			portForwardingAllowlist = []string{}
+		}
 
 		updated, err = tx.UpdateTemplateMetaByID(ctx, database.UpdateTemplateMetaByIDParams{
-			ID:                   template.ID,
-			UpdatedAt:            database.Now(),
-			Name:                 name,
-			Description:          desc,
-			Icon:                 icon,
-			MaxTtl:               int64(maxTTL),
-			MinAutostartInterval: int64(minAutostartInterval),
+			ID:                      template.ID,
+			UpdatedAt:               database.Now(),
+			Name:                    name,
+			Description:             desc,
+			Icon:                    icon,
+			MaxTtl:                  int64(maxTTL),
+			MinAutostartInterval:    int64(minAutostartInterval),
+			AutostopRequirementDays: req.AutostopRequirementDays,
+			InactivityTtl:           int64(time.Duration(req.InactivityTTLMillis) * time.Millisecond),
+			DormantDeleteTtl:        int64(time.Duration(req.DormantDeleteTTLMillis) * time.Millisecond),
+			PortForwardingAllowlist: portForwardingAllowlist,
+			RecordSessions:          req.RecordSessions,
 		})
 		if err != nil {
 			return err
@@ -764,6 +794,10 @@ func (api *API) convertTemplate(
 	template database.Template, workspaceOwnerCount uint32, createdByName string,
 ) codersdk.Template {
 	activeCount, _ := api.metricsCache.TemplateUniqueUsers(template.ID)
+	portForwardingAllowlist := template.PortForwardingAllowlist
+	if portForwardingAllowlist == nil {
+		portForwardingAllowlist = []string{}
+	}
 
 	buildTimeStats := api.metricsCache.TemplateBuildTimeStats(template.ID)
 
@@ -782,6 +816,11 @@ func (api *API) convertTemplate(
 		Icon:                       template.Icon,
 		MaxTTLMillis:               time.Duration(template.MaxTtl).Milliseconds(),
 		MinAutostartIntervalMillis: time.Duration(template.MinAutostartInterval).Milliseconds(),
+		AutostopRequirementDays:    template.AutostopRequirementDays,
+		InactivityTTLMillis:        time.Duration(template.InactivityTtl).Milliseconds(),
+		DormantDeleteTTLMillis:     time.Duration(template.DormantDeleteTtl).Milliseconds(),
+		PortForwardingAllowlist:    portForwardingAllowlist,
+		RecordSessions:             template.RecordSessions,
 		CreatedByID:                template.CreatedBy,
 		CreatedByName:              createdByName,
 	}
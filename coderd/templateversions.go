@@ -1,11 +1,14 @@
 package coderd
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -206,6 +209,51 @@ func (api *API) templateVersionParameters(rw http.ResponseWriter, r *http.Reques
 	httpapi.Write(ctx, rw, http.StatusOK, values)
 }
 
+func (api *API) templateVersionRichParameters(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var (
+		templateVersion = httpmw.TemplateVersionParam(r)
+		template        = httpmw.TemplateParam(r)
+	)
+	if !api.Authorize(r, rbac.ActionRead, templateVersion.RBACObject(template)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	job, err := api.Database.GetProvisionerJobByID(ctx, templateVersion.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if !job.CompletedAt.Valid {
+		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
+			Message: "Job hasn't completed!",
+		})
+		return
+	}
+	dbParameters, err := api.Database.GetTemplateVersionParameters(ctx, templateVersion.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	templateVersionParameters, err := convertTemplateVersionParameters(dbParameters)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting template version parameter.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, templateVersionParameters)
+}
+
 func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var (
@@ -259,12 +307,90 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	dbTemplateVersionParameters, err := api.Database.GetTemplateVersionParameters(ctx, templateVersion.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	templateVersionParameters, err := convertTemplateVersionParameters(dbTemplateVersionParameters)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting template version parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// When dry-running against an existing workspace, parameters that aren't
+	// provided are carried over from its latest build, the same as an update.
+	var lastBuildParameters []database.WorkspaceBuildParameter
+	if req.WorkspaceID != uuid.Nil {
+		workspace, err := api.Database.GetWorkspaceByID(ctx, req.WorkspaceID)
+		if xerrors.Is(err, sql.ErrNoRows) {
+			httpapi.ResourceNotFound(rw)
+			return
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+			httpapi.ResourceNotFound(rw)
+			return
+		}
+		if !templateVersion.TemplateID.Valid || workspace.TemplateID != templateVersion.TemplateID.UUID {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Template version must belong to the template of the workspace.",
+			})
+			return
+		}
+		latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching latest workspace build.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if latestBuild.Transition == database.WorkspaceTransitionDelete {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Cannot dry-run against a deleted workspace.",
+			})
+			return
+		}
+		lastBuildParameters, err = api.Database.GetWorkspaceBuildParameters(ctx, latestBuild.ID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace build parameters.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		req.WorkspaceName = workspace.Name
+	}
+
+	richParameterValues, err := resolveWorkspaceBuildParameters(templateVersionParameters, lastBuildParameters, req.RichParameterValues)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid rich parameter values.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
 	// Marshal template version dry-run job with the parameters from the
 	// request.
 	input, err := json.Marshal(templateVersionDryRunJob{
-		TemplateVersionID: templateVersion.ID,
-		WorkspaceName:     req.WorkspaceName,
-		ParameterValues:   parameterValues,
+		TemplateVersionID:   templateVersion.ID,
+		WorkspaceName:       req.WorkspaceName,
+		ParameterValues:     parameterValues,
+		RichParameterValues: richParameterValues,
+		WorkspaceID:         req.WorkspaceID,
 	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -287,6 +413,7 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 		FileID:         job.FileID,
 		Type:           database.ProvisionerJobTypeTemplateVersionDryRun,
 		Input:          input,
+		Tags:           job.Tags,
 	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -327,6 +454,208 @@ func (api *API) templateVersionDryRunLogs(rw http.ResponseWriter, r *http.Reques
 	api.provisionerJobLogs(rw, r, job)
 }
 
+func (api *API) templateVersionDryRunDiff(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	job, ok := api.fetchTemplateVersionDryRunJob(rw, r)
+	if !ok {
+		return
+	}
+	if !job.CompletedAt.Valid {
+		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
+			Message: "Job hasn't completed!",
+		})
+		return
+	}
+	if job.Error.Valid {
+		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
+			Message: "Job failed, so there is nothing to compare.",
+			Detail:  job.Error.String,
+		})
+		return
+	}
+
+	var input templateVersionDryRunJob
+	err := json.Unmarshal(job.Input, &input)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error unmarshaling job metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if input.WorkspaceID == uuid.Nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Dry-run was not run against a workspace.",
+		})
+		return
+	}
+
+	workspace, err := api.Database.GetWorkspaceByID(ctx, input.WorkspaceID)
+	if xerrors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching latest workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	lastBuildParameters, err := api.Database.GetWorkspaceBuildParameters(ctx, latestBuild.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	oldResources, err := api.resourceFingerprints(ctx, latestBuild.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace resources.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	newResources, err := api.resourceFingerprints(ctx, job.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching dry-run resources.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	oldParameters := make(map[string]string, len(lastBuildParameters))
+	for _, param := range lastBuildParameters {
+		oldParameters[param.Name] = param.Value
+	}
+	newParameters := make(map[string]string, len(input.RichParameterValues))
+	for _, param := range input.RichParameterValues {
+		newParameters[param.Name] = param.Value
+	}
+
+	diff := codersdk.WorkspaceUpdateDiff{
+		WorkspaceID: workspace.ID,
+		Resources:   make([]codersdk.WorkspaceResourceDiff, 0),
+		Parameters:  make([]codersdk.WorkspaceBuildParameterDiff, 0),
+	}
+	for key, change := range diffMaps(oldResources, newResources) {
+		diff.Resources = append(diff.Resources, codersdk.WorkspaceResourceDiff{
+			Type:   key.Type,
+			Name:   key.Name,
+			Change: change,
+		})
+	}
+	for name, change := range diffMaps(oldParameters, newParameters) {
+		diff.Parameters = append(diff.Parameters, codersdk.WorkspaceBuildParameterDiff{
+			Name:     name,
+			OldValue: oldParameters[name],
+			NewValue: newParameters[name],
+			Change:   change,
+		})
+	}
+	sort.Slice(diff.Resources, func(i, j int) bool {
+		if diff.Resources[i].Type != diff.Resources[j].Type {
+			return diff.Resources[i].Type < diff.Resources[j].Type
+		}
+		return diff.Resources[i].Name < diff.Resources[j].Name
+	})
+	sort.Slice(diff.Parameters, func(i, j int) bool {
+		return diff.Parameters[i].Name < diff.Parameters[j].Name
+	})
+
+	httpapi.Write(ctx, rw, http.StatusOK, diff)
+}
+
+type resourceKey struct {
+	Type string
+	Name string
+}
+
+// resourceFingerprints returns a comparable summary of every resource created
+// by a provisioner job. Two resources with the same fingerprint are considered
+// unchanged. Agent tokens and IDs are excluded since they're regenerated on
+// every build.
+func (api *API) resourceFingerprints(ctx context.Context, jobID uuid.UUID) (map[resourceKey]string, error) {
+	resources, err := api.Database.GetWorkspaceResourcesByJobID(ctx, jobID)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return nil, xerrors.Errorf("get resources: %w", err)
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := api.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return nil, xerrors.Errorf("get agents: %w", err)
+	}
+	metadata, err := api.Database.GetWorkspaceResourceMetadataByResourceIDs(ctx, resourceIDs)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return nil, xerrors.Errorf("get resource metadata: %w", err)
+	}
+
+	fingerprints := make(map[resourceKey]string, len(resources))
+	for _, resource := range resources {
+		parts := []string{
+			fmt.Sprintf("hide=%t", resource.Hide),
+			fmt.Sprintf("icon=%s", resource.Icon),
+			fmt.Sprintf("instance_type=%s", resource.InstanceType.String),
+		}
+		for _, agent := range agents {
+			if agent.ResourceID == resource.ID {
+				parts = append(parts, fmt.Sprintf("agent=%s/%s/%s", agent.Name, agent.OperatingSystem, agent.Architecture))
+			}
+		}
+		for _, field := range metadata {
+			if field.WorkspaceResourceID == resource.ID {
+				parts = append(parts, fmt.Sprintf("metadata=%s=%s", field.Key, field.Value.String))
+			}
+		}
+		sort.Strings(parts)
+		key := resourceKey{Type: resource.Type, Name: resource.Name}
+		// Resources created with count share a type and name, so they're
+		// folded into a single fingerprint.
+		fingerprints[key] += strings.Join(parts, "\n") + "\n"
+	}
+	return fingerprints, nil
+}
+
+// diffMaps returns the change for every key that differs between before and
+// after.
+func diffMaps[K comparable](before, after map[K]string) map[K]codersdk.WorkspaceUpdateDiffChange {
+	changes := make(map[K]codersdk.WorkspaceUpdateDiffChange)
+	for key, beforeValue := range before {
+		afterValue, ok := after[key]
+		switch {
+		case !ok:
+			changes[key] = codersdk.WorkspaceUpdateDiffChangeRemoved
+		case beforeValue != afterValue:
+			changes[key] = codersdk.WorkspaceUpdateDiffChangeChanged
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = codersdk.WorkspaceUpdateDiffChangeAdded
+		}
+	}
+	return changes
+}
+
 func (api *API) patchTemplateVersionDryRunCancel(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	templateVersion := httpmw.TemplateVersionParam(r)
@@ -814,6 +1143,7 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 			FileID:         file.ID,
 			Type:           database.ProvisionerJobTypeTemplateVersionImport,
 			Input:          []byte{'{', '}'},
+			Tags:           req.ProvisionerTags,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -945,3 +1275,45 @@ func convertTemplateVersion(version database.TemplateVersion, job codersdk.Provi
 		CreatedBy:      createdBy,
 	}
 }
+
+func convertTemplateVersionParameters(dbParams []database.TemplateVersionParameter) ([]codersdk.TemplateVersionParameter, error) {
+	params := make([]codersdk.TemplateVersionParameter, 0, len(dbParams))
+	for _, dbParam := range dbParams {
+		param, err := convertTemplateVersionParameter(dbParam)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+func convertTemplateVersionParameter(param database.TemplateVersionParameter) (codersdk.TemplateVersionParameter, error) {
+	options := make([]codersdk.TemplateVersionParameterOption, 0)
+	err := json.Unmarshal(param.Options, &options)
+	if err != nil {
+		return codersdk.TemplateVersionParameter{}, xerrors.Errorf("unmarshal options for %q: %w", param.Name, err)
+	}
+	var validationMin, validationMax *int32
+	if param.ValidationMin.Valid {
+		validationMin = &param.ValidationMin.Int32
+	}
+	if param.ValidationMax.Valid {
+		validationMax = &param.ValidationMax.Int32
+	}
+	return codersdk.TemplateVersionParameter{
+		Name:            param.Name,
+		DisplayName:     param.DisplayName,
+		Description:     param.Description,
+		Type:            param.Type,
+		Mutable:         param.Mutable,
+		DefaultValue:    param.DefaultValue,
+		Icon:            param.Icon,
+		Options:         options,
+		ValidationError: param.ValidationError,
+		ValidationRegex: param.ValidationRegex,
+		ValidationMin:   validationMin,
+		ValidationMax:   validationMax,
+		Required:        param.DefaultValue == "",
+	}, nil
+}
@@ -667,6 +667,99 @@ func TestTemplateVersionDryRun(t *testing.T) {
 		require.Equal(t, resource.Type, resources[0].Type)
 	})
 
+	t.Run("WorkspaceDiff", func(t *testing.T) {
+		t.Parallel()
+
+		responses := func(resources []*proto.Resource, parameters ...*proto.RichParameter) *echo.Responses {
+			return &echo.Responses{
+				Parse: []*proto.Parse_Response{{
+					Type: &proto.Parse_Response_Complete{
+						Complete: &proto.Parse_Complete{
+							RichParameters: parameters,
+						},
+					},
+				}},
+				Provision: []*proto.Provision_Response{{
+					Type: &proto.Provision_Response_Complete{
+						Complete: &proto.Provision_Complete{
+							Resources: resources,
+						},
+					},
+				}},
+			}
+		}
+		region := &proto.RichParameter{Name: "region", Type: "string", DefaultValue: "us", Mutable: true}
+		size := &proto.RichParameter{Name: "size", Type: "string", DefaultValue: "small", Mutable: true}
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, responses([]*proto.Resource{
+			{Type: "docker_container", Name: "dev", Agents: []*proto.Agent{{Name: "main", OperatingSystem: "linux", Architecture: "amd64", Auth: &proto.Agent_Token{}}}},
+			{Type: "docker_volume", Name: "home"},
+			{Type: "docker_image", Name: "base"},
+		}, region))
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		version = coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, responses([]*proto.Resource{
+			{Type: "docker_container", Name: "dev", Agents: []*proto.Agent{{Name: "main", OperatingSystem: "linux", Architecture: "arm64", Auth: &proto.Agent_Token{}}}},
+			{Type: "docker_volume", Name: "home"},
+			{Type: "docker_network", Name: "private"},
+		}, region, size), template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		job, err := client.CreateTemplateVersionDryRun(ctx, version.ID, codersdk.CreateTemplateVersionDryRunRequest{
+			WorkspaceID:         workspace.ID,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{{Name: "region", Value: "eu"}},
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			job, err := client.TemplateVersionDryRun(ctx, version.ID, job.ID)
+			return assert.NoError(t, err) && job.Status == codersdk.ProvisionerJobSucceeded
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		diff, err := client.TemplateVersionDryRunDiff(ctx, version.ID, job.ID)
+		require.NoError(t, err)
+		require.Equal(t, workspace.ID, diff.WorkspaceID)
+		require.Equal(t, []codersdk.WorkspaceResourceDiff{
+			{Type: "docker_container", Name: "dev", Change: codersdk.WorkspaceUpdateDiffChangeChanged},
+			{Type: "docker_image", Name: "base", Change: codersdk.WorkspaceUpdateDiffChangeRemoved},
+			{Type: "docker_network", Name: "private", Change: codersdk.WorkspaceUpdateDiffChangeAdded},
+		}, diff.Resources)
+		require.Equal(t, []codersdk.WorkspaceBuildParameterDiff{
+			{Name: "region", OldValue: "us", NewValue: "eu", Change: codersdk.WorkspaceUpdateDiffChangeChanged},
+			{Name: "size", NewValue: "small", Change: codersdk.WorkspaceUpdateDiffChangeAdded},
+		}, diff.Parameters)
+	})
+
+	t.Run("DiffWithoutWorkspace", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		job, err := client.CreateTemplateVersionDryRun(ctx, version.ID, codersdk.CreateTemplateVersionDryRunRequest{})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			job, err := client.TemplateVersionDryRun(ctx, version.ID, job.ID)
+			return assert.NoError(t, err) && job.Status == codersdk.ProvisionerJobSucceeded
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		_, err = client.TemplateVersionDryRunDiff(ctx, version.ID, job.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
 	t.Run("ImportNotFinished", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
@@ -17,9 +17,11 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/xerrors"
 
+	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -183,6 +185,19 @@ type OIDCConfig struct {
 	// EmailDomain is the domain to enforce when a user authenticates.
 	EmailDomain  string
 	AllowSignups bool
+	// GroupField selects the claim that lists the groups of the user. When
+	// set, group memberships are synced on every login. Syncing groups is
+	// an Enterprise feature.
+	GroupField string
+	// GroupMapping maps groups of the identity provider to Coder groups.
+	// Groups that aren't mapped keep their name.
+	GroupMapping map[string]string
+	// UserRoleField selects the claim that lists the roles of the user.
+	// When set, site and organization roles are synced on every login.
+	UserRoleField string
+	// UserRoleMapping maps roles of the identity provider to Coder roles.
+	// Roles that aren't mapped are used as Coder role names.
+	UserRoleMapping map[string][]string
 }
 
 func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
@@ -285,6 +300,56 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		picture, _ = pictureRaw.(string)
 	}
 
+	var groups []string
+	// Like roles below, a missing group claim leaves groups alone.
+	syncGroups := oidcClaimPresent(claims, api.OIDCConfig.GroupField)
+	if api.OIDCConfig.GroupField != "" && !syncGroups {
+		api.Logger.Debug(ctx, "oidc group claim is missing, not syncing groups",
+			slog.F("field", api.OIDCConfig.GroupField))
+	}
+	if syncGroups {
+		idpGroups, ok := oidcClaimStrings(claims, api.OIDCConfig.GroupField)
+		if !ok {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("The %q claim in the OIDC payload must be a string or a list of strings!", api.OIDCConfig.GroupField),
+			})
+			return
+		}
+		groups = make([]string, 0, len(idpGroups))
+		for _, group := range idpGroups {
+			if mapped, ok := api.OIDCConfig.GroupMapping[group]; ok {
+				group = mapped
+			}
+			groups = append(groups, group)
+		}
+	}
+
+	var roles []string
+	// A missing role claim leaves roles alone, so an identity provider that
+	// omits it, e.g. from some tokens, doesn't take everyone's roles away.
+	syncRoles := oidcClaimPresent(claims, api.OIDCConfig.UserRoleField)
+	if api.OIDCConfig.UserRoleField != "" && !syncRoles {
+		api.Logger.Debug(ctx, "oidc role claim is missing, not syncing roles",
+			slog.F("field", api.OIDCConfig.UserRoleField))
+	}
+	if syncRoles {
+		idpRoles, ok := oidcClaimStrings(claims, api.OIDCConfig.UserRoleField)
+		if !ok {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("The %q claim in the OIDC payload must be a string or a list of strings!", api.OIDCConfig.UserRoleField),
+			})
+			return
+		}
+		roles = make([]string, 0, len(idpRoles))
+		for _, role := range idpRoles {
+			if mapped, ok := api.OIDCConfig.UserRoleMapping[role]; ok {
+				roles = append(roles, mapped...)
+				continue
+			}
+			roles = append(roles, role)
+		}
+	}
+
 	cookie, err := api.oauthLogin(r, oauthLoginParams{
 		State:        state,
 		LinkedID:     oidcLinkedID(idToken),
@@ -293,6 +358,10 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		Email:        email,
 		Username:     username,
 		AvatarURL:    picture,
+		UsingGroups:  syncGroups,
+		Groups:       groups,
+		UsingRoles:   syncRoles,
+		Roles:        roles,
 	})
 	var httpErr httpError
 	if xerrors.As(err, &httpErr) {
@@ -330,6 +399,13 @@ type oauthLoginParams struct {
 	Email        string
 	Username     string
 	AvatarURL    string
+
+	// UsingGroups is true if the user's groups are synced with Groups.
+	UsingGroups bool
+	Groups      []string
+	// UsingRoles is true if the user's roles are synced with Roles.
+	UsingRoles bool
+	Roles      []string
 }
 
 type httpError struct {
@@ -519,6 +595,25 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 			}
 		}
 
+		if params.UsingRoles {
+			user, err = api.syncUserRoles(ctx, tx, user, params.Roles)
+			if err != nil {
+				return xerrors.Errorf("sync user roles: %w", err)
+			}
+		}
+
+		// Groups are an Enterprise feature, so syncing them is left to
+		// Enterprise code.
+		if params.UsingGroups {
+			syncGroups := api.OIDCGroupSyncer.Load()
+			if syncGroups != nil && *syncGroups != nil {
+				err = (*syncGroups)(ctx, tx, user, params.Groups)
+				if err != nil {
+					return xerrors.Errorf("sync user groups: %w", err)
+				}
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -548,6 +643,126 @@ func oidcLinkedID(tok *oidc.IDToken) string {
 	return strings.Join([]string{tok.Issuer, tok.Subject}, "||")
 }
 
+// oidcClaimPresent returns whether the claim is set to a value.
+func oidcClaimPresent(claims map[string]interface{}, field string) bool {
+	if field == "" {
+		return false
+	}
+	raw, ok := claims[field]
+	return ok && raw != nil
+}
+
+// oidcClaimStrings returns the values of a claim that is either a string
+// or a list of strings. A missing claim has no values.
+func oidcClaimStrings(claims map[string]interface{}, field string) ([]string, bool) {
+	raw, ok := claims[field]
+	if !ok || raw == nil {
+		return []string{}, true
+	}
+	switch value := raw.(type) {
+	case string:
+		return []string{value}, true
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, str)
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
+
+// syncUserRoles replaces the site and organization roles of the user with
+// the roles given by the identity provider. Organization roles may omit the
+// organization ID, in which case they apply to every organization the user
+// is a member of. Unknown roles are ignored.
+func (api *API) syncUserRoles(ctx context.Context, tx database.Store, user database.User, roles []string) (database.User, error) {
+	memberships, err := tx.GetOrganizationMembershipsByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return user, xerrors.Errorf("get organization memberships: %w", err)
+	}
+
+	siteRoles := []string{}
+	orgRoles := make(map[uuid.UUID][]string, len(memberships))
+	for _, membership := range memberships {
+		orgRoles[membership.OrganizationID] = []string{}
+	}
+	for _, role := range roles {
+		if orgID, ok := rbac.IsOrgRole(role); ok {
+			id, err := uuid.Parse(orgID)
+			if orgRole, roleErr := api.CustomRoles.RoleByName(role); err != nil || roleErr != nil || orgRole.Name != role {
+				api.Logger.Warn(ctx, "ignoring unknown role from OIDC claim", slog.F("role", role))
+				continue
+			}
+			if _, ok := orgRoles[id]; ok && role != rbac.RoleOrgMember(id) {
+				orgRoles[id] = append(orgRoles[id], role)
+			}
+			continue
+		}
+		if siteRole, err := api.CustomRoles.RoleByName(role); err == nil {
+			if role != rbac.RoleMember() {
+				siteRoles = append(siteRoles, siteRole.Name)
+			}
+			continue
+		}
+		known := false
+		for orgID := range orgRoles {
+			orgRole, err := api.CustomRoles.RoleByName(role + ":" + orgID.String())
+			if err != nil || orgRole.Name != role+":"+orgID.String() {
+				continue
+			}
+			known = true
+			if orgRole.Name != rbac.RoleOrgMember(orgID) {
+				orgRoles[orgID] = append(orgRoles[orgID], orgRole.Name)
+			}
+		}
+		if !known {
+			api.Logger.Warn(ctx, "ignoring unknown role from OIDC claim", slog.F("role", role))
+		}
+	}
+
+	added, removed := rbac.ChangeRoleSet(user.RBACRoles, siteRoles)
+	if len(added) > 0 || len(removed) > 0 {
+		user, err = tx.UpdateUserRoles(ctx, database.UpdateUserRolesParams{
+			GrantedRoles: siteRoles,
+			ID:           user.ID,
+		})
+		if err != nil {
+			return user, xerrors.Errorf("update site roles: %w", err)
+		}
+	}
+
+	for _, membership := range memberships {
+		granted := orgRoles[membership.OrganizationID]
+		// The organization member role is implied, so it doesn't count as
+		// a change.
+		current := make([]string, 0, len(membership.Roles))
+		for _, role := range membership.Roles {
+			if role != rbac.RoleOrgMember(membership.OrganizationID) {
+				current = append(current, role)
+			}
+		}
+		added, removed := rbac.ChangeRoleSet(current, granted)
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+		_, err = tx.UpdateMemberRoles(ctx, database.UpdateMemberRolesParams{
+			GrantedRoles: granted,
+			UserID:       user.ID,
+			OrgID:        membership.OrganizationID,
+		})
+		if err != nil {
+			return user, xerrors.Errorf("update organization roles: %w", err)
+		}
+	}
+	return user, nil
+}
+
 // findLinkedUser tries to find a user by their unique OAuth-linked ID.
 // If it doesn't not find it, it returns the user by their email.
 func findLinkedUser(ctx context.Context, db database.Store, linkedID string, emails ...string) (database.User, database.UserLink, error) {
@@ -22,6 +22,7 @@ import (
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -540,6 +541,74 @@ func TestUserOIDC(t *testing.T) {
 		require.True(t, strings.HasPrefix(user.Username, "jon-"), "username %q should have prefix %q", user.Username, "jon-")
 	})
 
+	t.Run("SyncRoles", func(t *testing.T) {
+		t.Parallel()
+
+		conf := coderdtest.NewOIDCConfig(t, "")
+
+		config := conf.OIDCConfig()
+		config.AllowSignups = true
+		config.UserRoleField = "roles"
+		config.UserRoleMapping = map[string][]string{
+			"platform": {rbac.RoleTemplateAdmin(), "organization-admin"},
+		}
+
+		client := coderdtest.New(t, &coderdtest.Options{
+			OIDCConfig: config,
+		})
+		first := coderdtest.CreateFirstUser(t, client)
+
+		code := conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "jon@coder.com",
+			"roles": []string{"platform", "auditor", "unknown"},
+		})
+		resp := oidcCallback(t, client, code)
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		ctx, _ := testutil.Context(t)
+
+		user := codersdk.New(client.URL)
+		user.SessionToken = authCookieValue(resp.Cookies())
+		roles, err := user.GetUserRoles(ctx, "me")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{rbac.RoleTemplateAdmin(), "auditor"}, roles.Roles)
+		require.ElementsMatch(t, []string{rbac.RoleOrgAdmin(first.OrganizationID)}, roles.OrganizationRoles[first.OrganizationID])
+
+		// Roles that are no longer in the claim are removed.
+		code = conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "jon@coder.com",
+			"roles": "auditor",
+		})
+		resp = oidcCallback(t, client, code)
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		user.SessionToken = authCookieValue(resp.Cookies())
+		roles, err = user.GetUserRoles(ctx, "me")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"auditor"}, roles.Roles)
+		require.Empty(t, roles.OrganizationRoles[first.OrganizationID])
+
+		// Roles are left alone when the claim is missing.
+		code = conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "jon@coder.com",
+		})
+		resp = oidcCallback(t, client, code)
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		user.SessionToken = authCookieValue(resp.Cookies())
+		roles, err = user.GetUserRoles(ctx, "me")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"auditor"}, roles.Roles)
+
+		// A claim of the wrong type is rejected.
+		code = conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "jon@coder.com",
+			"roles": 1,
+		})
+		resp = oidcCallback(t, client, code)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
 	t.Run("Disabled", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, nil)
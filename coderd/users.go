@@ -18,6 +18,7 @@ import (
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
@@ -248,7 +249,7 @@ func (api *API) users(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(rw, r, convertUsers(users, organizationIDsByUserID))
+	render.JSON(rw, r, convertUsers(api.CustomRoles, users, organizationIDsByUserID))
 }
 
 // Creates a new user.
@@ -316,9 +317,25 @@ func (api *API) postUser(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Service accounts only authenticate with tokens.
+	loginType := database.LoginTypePassword
+	if req.ServiceAccount {
+		if req.Password != "" {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Service accounts cannot have a password.",
+				Validations: []codersdk.ValidationError{{
+					Field:  "password",
+					Detail: "Service accounts only authenticate with tokens.",
+				}},
+			})
+			return
+		}
+		loginType = database.LoginTypeToken
+	}
+
 	user, _, err := api.CreateUser(ctx, api.Database, CreateUserRequest{
 		CreateUserRequest: req,
-		LoginType:         database.LoginTypePassword,
+		LoginType:         loginType,
 	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -330,12 +347,15 @@ func (api *API) postUser(rw http.ResponseWriter, r *http.Request) {
 
 	aReq.New = user
 
-	// Report when users are added!
-	api.Telemetry.Report(&telemetry.Snapshot{
-		Users: []telemetry.User{telemetry.ConvertUser(user)},
-	})
+	// Report when users are added! Service accounts are not people, so they
+	// are left out of telemetry.
+	if !user.ServiceAccount {
+		api.Telemetry.Report(&telemetry.Snapshot{
+			Users: []telemetry.User{telemetry.ConvertUser(user)},
+		})
+	}
 
-	httpapi.Write(ctx, rw, http.StatusCreated, convertUser(user, []uuid.UUID{req.OrganizationID}))
+	httpapi.Write(ctx, rw, http.StatusCreated, convertUser(api.CustomRoles, user, []uuid.UUID{req.OrganizationID}))
 }
 
 func (api *API) deleteUser(rw http.ResponseWriter, r *http.Request) {
@@ -411,7 +431,7 @@ func (api *API) userByName(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertUser(user, organizationIDs))
+	httpapi.Write(ctx, rw, http.StatusOK, convertUser(api.CustomRoles, user, organizationIDs))
 }
 
 func (api *API) putUserProfile(rw http.ResponseWriter, r *http.Request) {
@@ -491,7 +511,120 @@ func (api *API) putUserProfile(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertUser(updatedUserProfile, organizationIDs))
+	httpapi.Write(ctx, rw, http.StatusOK, convertUser(api.CustomRoles, updatedUserProfile, organizationIDs))
+}
+
+// Returns the quiet hours schedule of the user.
+func (api *API) userQuietHoursSchedule(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	quietHours, err := userQuietHoursSchedule(user)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error parsing quiet hours schedule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertUserQuietHoursSchedule(quietHours, user.QuietHoursSchedule != ""))
+}
+
+// Updates the quiet hours schedule of the user.
+func (api *API) putUserQuietHoursSchedule(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.User](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = user
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var params codersdk.UpdateUserQuietHoursScheduleRequest
+	if !httpapi.Read(ctx, rw, r, &params) {
+		return
+	}
+
+	if params.Schedule != "" {
+		sched, err := schedule.Daily(params.Schedule)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid quiet hours schedule.",
+				Validations: []codersdk.ValidationError{
+					{Field: "schedule", Detail: err.Error()},
+				},
+			})
+			return
+		}
+		params.Schedule = sched.String()
+	}
+
+	updatedUser, err := api.Database.UpdateUserQuietHoursSchedule(ctx, database.UpdateUserQuietHoursScheduleParams{
+		ID:                 user.ID,
+		QuietHoursSchedule: params.Schedule,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating quiet hours schedule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = updatedUser
+
+	quietHours, err := userQuietHoursSchedule(updatedUser)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error parsing quiet hours schedule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertUserQuietHoursSchedule(quietHours, updatedUser.QuietHoursSchedule != ""))
+}
+
+// userQuietHoursSchedule returns the quiet hours schedule of the user, falling
+// back to the default schedule if the user has not set one.
+func userQuietHoursSchedule(user database.User) (*schedule.Schedule, error) {
+	raw := user.QuietHoursSchedule
+	if raw == "" {
+		raw = schedule.DefaultQuietHoursSchedule
+	}
+	sched, err := schedule.Daily(raw)
+	if err != nil {
+		return nil, xerrors.Errorf("parse quiet hours schedule %q: %w", raw, err)
+	}
+	return sched, nil
+}
+
+func convertUserQuietHoursSchedule(sched *schedule.Schedule, userSet bool) codersdk.UserQuietHoursScheduleResponse {
+	return codersdk.UserQuietHoursScheduleResponse{
+		RawSchedule: sched.String(),
+		UserSet:     userSet,
+		Time:        sched.Time(),
+		Timezone:    sched.Location().String(),
+		Next:        sched.Next(database.Now()),
+	}
 }
 
 func (api *API) putUserStatus(status database.UserStatus) func(rw http.ResponseWriter, r *http.Request) {
@@ -559,7 +692,7 @@ func (api *API) putUserStatus(status database.UserStatus) func(rw http.ResponseW
 			return
 		}
 
-		httpapi.Write(ctx, rw, http.StatusOK, convertUser(suspendedUser, organizations))
+		httpapi.Write(ctx, rw, http.StatusOK, convertUser(api.CustomRoles, suspendedUser, organizations))
 	}
 }
 
@@ -588,6 +721,13 @@ func (api *API) putUserPassword(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.ServiceAccount {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Service accounts cannot have a password.",
+		})
+		return
+	}
+
 	err := userpassword.Validate(params.Password)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
@@ -803,7 +943,7 @@ func (api *API) putUserRoles(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertUser(updatedUser, organizationIDs))
+	httpapi.Write(ctx, rw, http.StatusOK, convertUser(api.CustomRoles, updatedUser, organizationIDs))
 }
 
 // updateSiteUserRoles will ensure only site wide roles are passed in as arguments.
@@ -815,7 +955,7 @@ func (api *API) updateSiteUserRoles(ctx context.Context, args database.UpdateUse
 			return database.User{}, xerrors.Errorf("Must only update site wide roles")
 		}
 
-		if _, err := rbac.RoleByName(r); err != nil {
+		if _, err := api.CustomRoles.RoleByName(r); err != nil {
 			return database.User{}, xerrors.Errorf("%q is not a supported role", r)
 		}
 	}
@@ -1067,8 +1207,10 @@ func (api *API) CreateUser(ctx context.Context, store database.Store, req Create
 			CreatedAt: database.Now(),
 			UpdatedAt: database.Now(),
 			// All new users are defaulted to members of the site.
-			RBACRoles: []string{},
-			LoginType: req.LoginType,
+			RBACRoles:      []string{},
+			LoginType:      req.LoginType,
+			ServiceAccount: req.ServiceAccount,
+			Contact:        req.Contact,
 		}
 		// If a user signs up with OAuth, they can have no password!
 		if req.Password != "" {
@@ -1114,7 +1256,7 @@ func (api *API) CreateUser(ctx context.Context, store database.Store, req Create
 	})
 }
 
-func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User {
+func convertUser(customRoles *rbac.CustomRoles, user database.User, organizationIDs []uuid.UUID) codersdk.User {
 	convertedUser := codersdk.User{
 		ID:              user.ID,
 		Email:           user.Email,
@@ -1125,21 +1267,22 @@ func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User
 		OrganizationIDs: organizationIDs,
 		Roles:           make([]codersdk.Role, 0, len(user.RBACRoles)),
 		AvatarURL:       user.AvatarURL.String,
+		ServiceAccount:  user.ServiceAccount,
+		Contact:         user.Contact,
 	}
 
 	for _, roleName := range user.RBACRoles {
-		rbacRole, _ := rbac.RoleByName(roleName)
-		convertedUser.Roles = append(convertedUser.Roles, convertRole(rbacRole))
+		convertedUser.Roles = append(convertedUser.Roles, convertRoleName(customRoles, roleName))
 	}
 
 	return convertedUser
 }
 
-func convertUsers(users []database.User, organizationIDsByUserID map[uuid.UUID][]uuid.UUID) []codersdk.User {
+func convertUsers(customRoles *rbac.CustomRoles, users []database.User, organizationIDsByUserID map[uuid.UUID][]uuid.UUID) []codersdk.User {
 	converted := make([]codersdk.User, 0, len(users))
 	for _, u := range users {
 		userOrganizationIDs := organizationIDsByUserID[u.ID]
-		converted = append(converted, convertUser(u, userOrganizationIDs))
+		converted = append(converted, convertUser(customRoles, u, userOrganizationIDs))
 	}
 	return converted
 }
@@ -1229,6 +1372,7 @@ func convertAPIKey(k database.APIKey) codersdk.APIKey {
 		UpdatedAt:       k.UpdatedAt,
 		LoginType:       codersdk.LoginType(k.LoginType),
 		Scope:           codersdk.APIKeyScope(k.Scope),
+		Scopes:          k.Scopes,
 		LifetimeSeconds: k.LifetimeSeconds,
 	}
 }
@@ -16,6 +16,7 @@ import (
 
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/rbac"
@@ -487,6 +488,86 @@ func TestPostUsers(t *testing.T) {
 		require.Len(t, auditor.AuditLogs, 1)
 		assert.Equal(t, database.AuditActionCreate, auditor.AuditLogs[0].Action)
 	})
+
+	t.Run("ServiceAccount", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Service accounts need a contact, and cannot have a password.
+		for _, req := range []codersdk.CreateUserRequest{{
+			OrganizationID: user.OrganizationID,
+			Email:          "ci-bot@coder.com",
+			Username:       "ci-bot",
+			ServiceAccount: true,
+		}, {
+			OrganizationID: user.OrganizationID,
+			Email:          "ci-bot@coder.com",
+			Username:       "ci-bot",
+			Password:       "testing",
+			ServiceAccount: true,
+			Contact:        "platform-team@coder.com",
+		}} {
+			_, err := client.CreateUser(ctx, req)
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		}
+
+		serviceAccount, err := client.CreateUser(ctx, codersdk.CreateUserRequest{
+			OrganizationID: user.OrganizationID,
+			Email:          "ci-bot@coder.com",
+			Username:       "ci-bot",
+			ServiceAccount: true,
+			Contact:        "platform-team@coder.com",
+		})
+		require.NoError(t, err)
+		require.True(t, serviceAccount.ServiceAccount)
+		require.Equal(t, "platform-team@coder.com", serviceAccount.Contact)
+
+		// Service accounts cannot log in...
+		_, err = client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
+			Email:    "ci-bot@coder.com",
+			Password: "",
+		})
+		require.Error(t, err)
+		err = client.UpdateUserPassword(ctx, serviceAccount.ID.String(), codersdk.UpdateUserPasswordRequest{
+			Password: "SomeSecurePassword!",
+		})
+		require.Error(t, err)
+
+		// ...and only use tokens that expire.
+		_, err = client.CreateToken(ctx, serviceAccount.ID.String(), codersdk.CreateTokenRequest{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		_, err = client.CreateAPIKey(ctx, serviceAccount.ID.String())
+		require.Error(t, err)
+		// The default maximum lifetime is 30 days.
+		_, err = client.CreateToken(ctx, serviceAccount.ID.String(), codersdk.CreateTokenRequest{
+			Lifetime: 31 * 24 * time.Hour,
+		})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+		res, err := client.CreateToken(ctx, serviceAccount.ID.String(), codersdk.CreateTokenRequest{
+			Lifetime: time.Hour,
+		})
+		require.NoError(t, err)
+		keys, err := client.GetTokens(ctx, serviceAccount.ID.String())
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.WithinDuration(t, time.Now().Add(time.Hour), keys[0].ExpiresAt, time.Minute)
+
+		botClient := codersdk.New(client.URL)
+		botClient.SessionToken = res.Key
+		me, err := botClient.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, serviceAccount.ID, me.ID)
+	})
 }
 
 func TestUpdateUserProfile(t *testing.T) {
@@ -552,6 +633,70 @@ func TestUpdateUserProfile(t *testing.T) {
 	})
 }
 
+func TestUserQuietHoursSchedule(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Default", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		sched, err := client.UserQuietHoursSchedule(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, schedule.DefaultQuietHoursSchedule, sched.RawSchedule)
+		require.False(t, sched.UserSet)
+		require.Equal(t, "UTC", sched.Timezone)
+		require.Equal(t, "12:00AM", sched.Time)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		sched, err := client.UpdateUserQuietHoursSchedule(ctx, codersdk.Me, codersdk.UpdateUserQuietHoursScheduleRequest{
+			Schedule: "CRON_TZ=Australia/Sydney 30 1 * * *",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "CRON_TZ=Australia/Sydney 30 1 * * *", sched.RawSchedule)
+		require.True(t, sched.UserSet)
+		require.Equal(t, "Australia/Sydney", sched.Timezone)
+		require.Equal(t, "1:30AM", sched.Time)
+
+		sched, err = client.UserQuietHoursSchedule(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, "CRON_TZ=Australia/Sydney 30 1 * * *", sched.RawSchedule)
+
+		// An empty schedule resets to the default.
+		sched, err = client.UpdateUserQuietHoursSchedule(ctx, codersdk.Me, codersdk.UpdateUserQuietHoursScheduleRequest{})
+		require.NoError(t, err)
+		require.Equal(t, schedule.DefaultQuietHoursSchedule, sched.RawSchedule)
+		require.False(t, sched.UserSet)
+	})
+
+	t.Run("NotDaily", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.UpdateUserQuietHoursSchedule(ctx, codersdk.Me, codersdk.UpdateUserQuietHoursScheduleRequest{
+			Schedule: "CRON_TZ=UTC 0 0 * * 1-5",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
+
 func TestUpdateUserPassword(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,19 @@
+package strings
+
+import "unicode/utf8"
+
+// Truncate returns s truncated to at most n bytes. It never splits a
+// multi-byte UTF-8 sequence, so the result may be shorter than n.
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	// Back up to the start of the rune that would be split.
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,37 @@
+package strings_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/util/strings"
+)
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		s        string
+		n        int
+		expected string
+	}{
+		{"foo", 4, "foo"},
+		{"foo", 3, "foo"},
+		{"foo", 2, "fo"},
+		{"foo", 0, ""},
+		{"foo", -1, ""},
+		// "é" is two bytes and "😀" four, neither is split.
+		{"héllo", 2, "h"},
+		{"héllo", 3, "hé"},
+		{"a😀", 4, "a"},
+		{"a😀", 5, "a😀"},
+	} {
+		tt := tt
+		t.Run(fmt.Sprintf("%s/%d", tt.s, tt.n), func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, strings.Truncate(tt.s, tt.n))
+		})
+	}
+}
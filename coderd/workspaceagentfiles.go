@@ -0,0 +1,126 @@
+package coderd
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// maxWorkspaceAgentUploadSize limits uploads through coderd. Larger files
+// can be copied with "coder cp", which connects to the agent directly.
+const maxWorkspaceAgentUploadSize = 100 << 20
+
+// workspaceAgentFile downloads a file from the workspace for the dashboard.
+func (api *API) workspaceAgentFile(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filePath := r.URL.Query().Get("path")
+	agentConn, release, ok := api.workspaceAgentFileConn(rw, r, filePath)
+	if !ok {
+		return
+	}
+	defer release()
+
+	content, size, err := agentConn.DownloadFile(ctx, filePath, 0)
+	if err != nil {
+		writeAgentFileError(rw, r, "Failed to download file from workspace agent.", err)
+		return
+	}
+	defer content.Close()
+
+	rw.Header().Set("Content-Type", "application/octet-stream")
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": path.Base(filePath),
+	}))
+	if size >= 0 {
+		rw.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	rw.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(rw, content)
+}
+
+// putWorkspaceAgentFile uploads a file to the workspace from the dashboard.
+func (api *API) putWorkspaceAgentFile(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filePath := r.URL.Query().Get("path")
+	agentConn, release, ok := api.workspaceAgentFileConn(rw, r, filePath)
+	if !ok {
+		return
+	}
+	defer release()
+
+	r.Body = http.MaxBytesReader(rw, r.Body, maxWorkspaceAgentUploadSize)
+	checksum, err := agentConn.UploadFile(ctx, filePath, r.Body)
+	if err != nil {
+		writeAgentFileError(rw, r, "Failed to upload file to workspace agent.", err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusCreated, checksum)
+}
+
+// workspaceAgentFileConn authorizes access to the files of the workspace
+// agent and connects to it. Reading and writing files is as powerful as
+// running commands, so it requires the same permission.
+func (api *API) workspaceAgentFileConn(rw http.ResponseWriter, r *http.Request, filePath string) (*codersdk.AgentConn, func(), bool) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+		httpapi.ResourceNotFound(rw)
+		return nil, nil, false
+	}
+	if filePath == "" {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "The path query parameter is required.",
+		})
+		return nil, nil, false
+	}
+
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error reading workspace agent.",
+			Detail:  err.Error(),
+		})
+		return nil, nil, false
+	}
+	if apiAgent.Status != codersdk.WorkspaceAgentConnected {
+		httpapi.Write(ctx, rw, http.StatusPreconditionRequired, codersdk.Response{
+			Message: fmt.Sprintf("Agent state is %q, it must be in the %q state.", apiAgent.Status, codersdk.WorkspaceAgentConnected),
+		})
+		return nil, nil, false
+	}
+
+	agentConn, release, err := api.workspaceAgentCache.Acquire(r, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error dialing workspace agent.",
+			Detail:  err.Error(),
+		})
+		return nil, nil, false
+	}
+	return agentConn.AgentConn, release, true
+}
+
+// writeAgentFileError passes on errors of the agent, like a missing file,
+// with their status.
+func writeAgentFileError(rw http.ResponseWriter, r *http.Request, message string, err error) {
+	status := http.StatusInternalServerError
+	var apiErr *codersdk.Error
+	if xerrors.As(err, &apiErr) && apiErr.StatusCode() < http.StatusInternalServerError {
+		status = apiErr.StatusCode()
+	}
+	httpapi.Write(r.Context(), rw, status, codersdk.Response{
+		Message: message,
+		Detail:  err.Error(),
+	})
+}
@@ -12,12 +12,17 @@ import (
 	"net/netip"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/exp/slices"
 	"golang.org/x/mod/semver"
 	"golang.org/x/oauth2"
 	"golang.org/x/xerrors"
@@ -31,7 +36,10 @@ import (
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/sshca"
 	"github.com/coder/coder/coderd/tracing"
+	utilstrings "github.com/coder/coder/coderd/util/strings"
+	"github.com/coder/coder/coderd/workspaceapps"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/tailnet"
 )
@@ -52,7 +60,7 @@ func (api *API) workspaceAgent(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, convertApps(dbApps), api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), *api.TailnetCoordinator.Load(), workspaceAgent, convertApps(dbApps), api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -67,7 +75,7 @@ func (api *API) workspaceAgent(rw http.ResponseWriter, r *http.Request) {
 func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspaceAgent := httpmw.WorkspaceAgent(r)
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -84,20 +92,77 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	metadata, err := api.Database.GetWorkspaceAgentMetadata(ctx, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace resource.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	build, err := api.Database.GetWorkspaceBuildByJobID(ctx, resource.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspace, err := api.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	sshCertificateAuthorities := []string{}
+	if api.SSHCertificateAuthority != nil {
+		sshCertificateAuthorities = append(sshCertificateAuthorities,
+			strings.TrimSpace(string(gossh.MarshalAuthorizedKey(api.SSHCertificateAuthority.PublicKey()))))
+	}
+
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceAgentMetadata{
-		Apps:                 convertApps(dbApps),
-		DERPMap:              api.DERPMap,
-		GitAuthConfigs:       len(api.GitAuthConfigs),
-		EnvironmentVariables: apiAgent.EnvironmentVariables,
-		StartupScript:        apiAgent.StartupScript,
-		Directory:            apiAgent.Directory,
+		Apps:                      convertApps(dbApps),
+		DERPMap:                   api.CurrentDERPMap(),
+		GitAuthConfigs:            len(api.GitAuthConfigs),
+		EnvironmentVariables:      apiAgent.EnvironmentVariables,
+		StartupScript:             apiAgent.StartupScript,
+		StartupScriptTimeout:      time.Duration(apiAgent.StartupScriptTimeoutSeconds) * time.Second,
+		ShutdownScript:            apiAgent.ShutdownScript,
+		ShutdownScriptTimeout:     time.Duration(apiAgent.ShutdownScriptTimeoutSeconds) * time.Second,
+		Directory:                 apiAgent.Directory,
+		Metadata:                  convertWorkspaceAgentMetadataDesc(metadata),
+		PortForwardingAllowlist:   template.PortForwardingAllowlist,
+		SSHCertificateAuthorities: sshCertificateAuthorities,
+		SSHCertificatePrincipal:   sshca.Principal(workspaceAgent.ID),
+		SSHRequireCertificate:     api.SSHRequireCertificates,
+		RecordSessions:            template.RecordSessions,
 	})
 }
 
 func (api *API) postWorkspaceAgentVersion(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspaceAgent := httpmw.WorkspaceAgent(r)
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -135,15 +200,414 @@ func (api *API) postWorkspaceAgentVersion(rw http.ResponseWriter, r *http.Reques
 	httpapi.Write(ctx, rw, http.StatusOK, nil)
 }
 
+func (api *API) workspaceAgentReportLifecycle(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	var req codersdk.PostWorkspaceAgentLifecycleRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if !slices.Contains(codersdk.WorkspaceAgentLifecycleOrder, req.State) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid lifecycle state.",
+			Detail:  fmt.Sprintf("invalid lifecycle state %q, must be be one of %q", req.State, codersdk.WorkspaceAgentLifecycleOrder),
+		})
+		return
+	}
+
+	err := api.Database.UpdateWorkspaceAgentLifecycleStateByID(ctx, database.UpdateWorkspaceAgentLifecycleStateByIDParams{
+		ID:             workspaceAgent.ID,
+		LifecycleState: database.WorkspaceAgentLifecycleState(req.State),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update lifecycle state.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// workspaceAgentShutdown accepts a WebSocket from the authenticated agent
+// and writes a single message once the workspace is being stopped, so the
+// agent can run its shutdown script before the compute is destroyed.
+func (api *API) workspaceAgentShutdown(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace resource.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	build, err := api.Database.GetWorkspaceBuildByJobID(ctx, resource.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Subscribe before checking the latest build, so a signal published in
+	// between isn't missed.
+	shutdown := make(chan struct{})
+	var shutdownOnce sync.Once
+	closeSubscribe, err := api.Pubsub.Subscribe(workspaceAgentShutdownChannel(workspaceAgent.ID), func(_ context.Context, _ []byte) {
+		shutdownOnce.Do(func() {
+			close(shutdown)
+		})
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error watching for shutdown.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer closeSubscribe()
+
+	// Any build after the one that created this agent replaces it.
+	latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, build.WorkspaceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching latest workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if latestBuild.ID != build.ID {
+		shutdownOnce.Do(func() {
+			close(shutdown)
+		})
+	}
+
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
+	conn, err := websocket.Accept(rw, r, &websocket.AcceptOptions{
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	go httpapi.Heartbeat(ctx, conn)
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-shutdown:
+	}
+	err = conn.Write(ctx, websocket.MessageText, []byte("shutdown"))
+	if err != nil {
+		api.Logger.Debug(ctx, "write shutdown signal", slog.F("agent_id", workspaceAgent.ID), slog.Error(err))
+	}
+}
+
+func workspaceAgentShutdownChannel(agentID uuid.UUID) string {
+	return fmt.Sprintf("workspace-agent-shutdown:%s", agentID)
+}
+
+// patchWorkspaceAgentStartupLogs appends logs from the startup script of
+// the authenticated agent.
+func (api *API) patchWorkspaceAgentStartupLogs(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	var req codersdk.PatchStartupLogs
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if len(req.Logs) == 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "No logs provided.",
+		})
+		return
+	}
+	createdAt := make([]time.Time, 0, len(req.Logs))
+	output := make([]string, 0, len(req.Logs))
+	eof := make([]bool, 0, len(req.Logs))
+	outputLength := 0
+	for _, log := range req.Logs {
+		createdAt = append(createdAt, log.CreatedAt)
+		output = append(output, log.Output)
+		eof = append(eof, log.EOF)
+		outputLength += len(log.Output)
+	}
+	logs, err := api.Database.InsertWorkspaceAgentStartupLogs(ctx, database.InsertWorkspaceAgentStartupLogsParams{
+		AgentID:      workspaceAgent.ID,
+		CreatedAt:    createdAt,
+		Output:       output,
+		EOF:          eof,
+		OutputLength: int32(outputLength),
+	})
+	if err != nil {
+		if database.IsStartupLogsLimitError(err) {
+			if !workspaceAgent.StartupLogsOverflowed {
+				err := api.Database.UpdateWorkspaceAgentStartupLogOverflowByID(ctx, database.UpdateWorkspaceAgentStartupLogOverflowByIDParams{
+					ID:                    workspaceAgent.ID,
+					StartupLogsOverflowed: true,
+				})
+				if err != nil {
+					// We don't want to return here, because the agent will retry
+					// on failure and this isn't a huge deal. The overflow state
+					// is just a hint to the user that the logs are incomplete.
+					api.Logger.Warn(ctx, "failed to update workspace agent startup log overflow", slog.Error(err))
+				}
+				api.publishWorkspaceAgentStartupLogs(ctx, workspaceAgent.ID, workspaceAgentStartupLogsMessage{
+					EndOfLogs: true,
+				})
+			}
+			httpapi.Write(ctx, rw, http.StatusRequestEntityTooLarge, codersdk.Response{
+				Message: "Startup logs limit exceeded.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to upload startup logs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if len(logs) > 0 {
+		api.publishWorkspaceAgentStartupLogs(ctx, workspaceAgent.ID, workspaceAgentStartupLogsMessage{
+			CreatedAfter: logs[0].ID - 1,
+		})
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, nil)
+}
+
+// workspaceAgentStartupLogs returns the startup script logs of an agent.
+// Logs with an ID greater than the "after" query parameter are returned.
+// With "follow", logs are streamed over a WebSocket until the agent sends
+// the final log or the client disconnects.
+func (api *API) workspaceAgentStartupLogs(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+		workspace      = httpmw.WorkspaceParam(r)
+		logger         = api.Logger.With(slog.F("workspace_agent_id", workspaceAgent.ID))
+		follow         = r.URL.Query().Has("follow")
+		afterRaw       = r.URL.Query().Get("after")
+	)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var after int64
+	if afterRaw != "" {
+		var err error
+		after, err = strconv.ParseInt(afterRaw, 10, 64)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query param \"after\" must be an integer.",
+				Validations: []codersdk.ValidationError{
+					{Field: "after", Detail: "Must be an integer"},
+				},
+			})
+			return
+		}
+	}
+
+	// Subscribe before querying the database, so that no logs are missed
+	// between the end of the query and the start of the subscription.
+	var (
+		notifications = make(chan workspaceAgentStartupLogsMessage, 1)
+		endOfLogs     = make(chan struct{})
+	)
+	if follow {
+		closeSubscribe, err := api.Pubsub.Subscribe(workspaceAgentStartupLogsChannel(workspaceAgent.ID), func(ctx context.Context, message []byte) {
+			var msg workspaceAgentStartupLogsMessage
+			err := json.Unmarshal(message, &msg)
+			if err != nil {
+				logger.Warn(ctx, "invalid workspace agent startup logs message", slog.Error(err))
+				return
+			}
+			if msg.EndOfLogs {
+				select {
+				case <-endOfLogs:
+				default:
+					close(endOfLogs)
+				}
+				return
+			}
+			select {
+			case notifications <- msg:
+			default:
+				// A notification is already pending, which will fetch
+				// every log after the last one sent.
+			}
+		})
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error watching startup logs.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		defer closeSubscribe()
+
+		// Query the agent again after subscribing to see whether the
+		// logs have overflowed and the agent stopped sending them.
+		workspaceAgent, err = api.Database.GetWorkspaceAgentByID(ctx, workspaceAgent.ID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace agent.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	logs, err := api.Database.GetWorkspaceAgentStartupLogsAfter(ctx, database.GetWorkspaceAgentStartupLogsAfterParams{
+		AgentID:      workspaceAgent.ID,
+		CreatedAfter: after,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching startup logs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if logs == nil {
+		logs = []database.WorkspaceAgentStartupLog{}
+	}
+
+	if !follow {
+		httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceAgentStartupLogs(logs))
+		return
+	}
+
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
+	conn, err := websocket.Accept(rw, r, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	go httpapi.Heartbeat(ctx, conn)
+
+	ctx, wsNetConn := websocketNetConn(ctx, conn, websocket.MessageText)
+	defer wsNetConn.Close() // Also closes conn.
+
+	// The Go stdlib JSON encoder appends a newline character after message write.
+	encoder := json.NewEncoder(wsNetConn)
+	lastSentID := after
+	// send writes logs to the WebSocket and returns true once the final
+	// log has been sent.
+	send := func(logs []database.WorkspaceAgentStartupLog) (bool, error) {
+		if len(logs) == 0 {
+			return false, nil
+		}
+		err := encoder.Encode(convertWorkspaceAgentStartupLogs(logs))
+		if err != nil {
+			return false, err
+		}
+		lastSentID = logs[len(logs)-1].ID
+		for _, log := range logs {
+			if log.EOF {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	done, err := send(logs)
+	if err != nil || done || workspaceAgent.StartupLogsOverflowed {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-endOfLogs:
+			return
+		case <-notifications:
+		}
+		logs, err := api.Database.GetWorkspaceAgentStartupLogsAfter(ctx, database.GetWorkspaceAgentStartupLogsAfterParams{
+			AgentID:      workspaceAgent.ID,
+			CreatedAfter: lastSentID,
+		})
+		if err != nil {
+			logger.Warn(ctx, "failed to get workspace agent startup logs after", slog.Error(err))
+			return
+		}
+		done, err := send(logs)
+		if err != nil || done {
+			return
+		}
+	}
+}
+
+func workspaceAgentStartupLogsChannel(agentID uuid.UUID) string {
+	return fmt.Sprintf("workspace-agent-startup-logs:%s", agentID)
+}
+
+// workspaceAgentStartupLogsMessage is published on the
+// workspaceAgentStartupLogsChannel() channel when new logs are inserted.
+type workspaceAgentStartupLogsMessage struct {
+	CreatedAfter int64 `json:"created_after,omitempty"`
+	EndOfLogs    bool  `json:"end_of_logs,omitempty"`
+}
+
+func (api *API) publishWorkspaceAgentStartupLogs(ctx context.Context, agentID uuid.UUID, msg workspaceAgentStartupLogsMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		api.Logger.Warn(ctx, "failed to marshal startup logs notify message", slog.F("agent_id", agentID), slog.Error(err))
+		return
+	}
+	err = api.Pubsub.Publish(workspaceAgentStartupLogsChannel(agentID), data)
+	if err != nil {
+		api.Logger.Warn(ctx, "failed to publish startup logs notify message", slog.F("agent_id", agentID), slog.Error(err))
+	}
+}
+
+func convertWorkspaceAgentStartupLogs(logs []database.WorkspaceAgentStartupLog) []codersdk.WorkspaceAgentStartupLog {
+	sdk := make([]codersdk.WorkspaceAgentStartupLog, 0, len(logs))
+	for _, log := range logs {
+		sdk = append(sdk, codersdk.WorkspaceAgentStartupLog{
+			ID:        log.ID,
+			CreatedAt: log.CreatedAt,
+			Output:    log.Output,
+			EOF:       log.EOF,
+		})
+	}
+	return sdk
+}
+
 // workspaceAgentPTY spawns a PTY and pipes it over a WebSocket.
 // This is used for the web terminal.
 func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	api.websocketWaitMutex.Lock()
-	api.websocketWaitGroup.Add(1)
-	api.websocketWaitMutex.Unlock()
-	defer api.websocketWaitGroup.Done()
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
 
 	workspaceAgent := httpmw.WorkspaceAgentParam(r)
 	workspace := httpmw.WorkspaceParam(r)
@@ -151,7 +615,7 @@ func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -206,7 +670,13 @@ func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer release()
-	ptNetConn, err := agentConn.ReconnectingPTY(reconnect.String(), uint16(height), uint16(width), r.URL.Query().Get("command"))
+	ptNetConn, err := agentConn.DialReconnectingPTY(ctx, codersdk.ReconnectingPTYInit{
+		ID:      reconnect.String(),
+		Height:  uint16(height),
+		Width:   uint16(width),
+		Command: r.URL.Query().Get("command"),
+		UserID:  httpmw.APIKey(r).UserID,
+	})
 	if err != nil {
 		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("dial: %s", err))
 		return
@@ -228,7 +698,7 @@ func (api *API) workspaceAgentListeningPorts(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -326,7 +796,7 @@ func (api *API) dialWorkspaceAgentTailnet(r *http.Request, agentID uuid.UUID) (*
 		_ = serverConn.Close()
 	}()
 
-	derpMap := api.DERPMap.Clone()
+	derpMap := api.CurrentDERPMap().Clone()
 	for _, region := range derpMap.Regions {
 		if !region.EmbeddedRelay {
 			continue
@@ -394,17 +864,82 @@ func (api *API) workspaceAgentConnection(rw http.ResponseWriter, r *http.Request
 		return
 	}
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceAgentConnectionInfo{
-		DERPMap: api.DERPMap,
+		DERPMap: api.CurrentDERPMap(),
+	})
+}
+
+func (api *API) postWorkspaceAgentSSHCertificate(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	apiKey := httpmw.APIKey(r)
+	workspace := httpmw.WorkspaceParam(r)
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if api.SSHCertificateAuthority == nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "SSH certificates aren't enabled on this deployment.",
+		})
+		return
+	}
+
+	var req codersdk.IssueWorkspaceAgentSSHCertificateRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	publicKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(req.PublicKey))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid public key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if _, ok := publicKey.(*gossh.Certificate); ok {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "A certificate can't be certified, provide a public key.",
+		})
+		return
+	}
+	user, err := api.Database.GetUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	cert, err := sshca.Issue(api.SSHCertificateAuthority, sshca.IssueOptions{
+		PublicKey:   publicKey,
+		Username:    user.Username,
+		UserID:      user.ID,
+		WorkspaceID: workspace.ID,
+		AgentID:     workspaceAgent.ID,
+		TTL:         api.SSHCertificateTTL,
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	api.Logger.Debug(ctx, "issued workspace agent ssh certificate",
+		slog.F("user_id", user.ID),
+		slog.F("workspace_id", workspace.ID),
+		slog.F("agent_id", workspaceAgent.ID),
+		slog.F("fingerprint", gossh.FingerprintSHA256(publicKey)),
+	)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.WorkspaceAgentSSHCertificate{
+		Certificate: string(gossh.MarshalAuthorizedKey(cert)),
+		ExpiresAt:   time.Unix(int64(cert.ValidBefore), 0),
 	})
 }
 
 func (api *API) workspaceAgentCoordinate(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	api.websocketWaitMutex.Lock()
-	api.websocketWaitGroup.Add(1)
-	api.websocketWaitMutex.Unlock()
-	defer api.websocketWaitGroup.Done()
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
 	workspaceAgent := httpmw.WorkspaceAgent(r)
 	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
 	if err != nil {
@@ -567,10 +1102,10 @@ func (api *API) workspaceAgentClientCoordinate(rw http.ResponseWriter, r *http.R
 		}
 	}
 
-	api.websocketWaitMutex.Lock()
-	api.websocketWaitGroup.Add(1)
-	api.websocketWaitMutex.Unlock()
-	defer api.websocketWaitGroup.Done()
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
 	workspaceAgent := httpmw.WorkspaceAgentParam(r)
 
 	conn, err := websocket.Accept(rw, r, nil)
@@ -594,6 +1129,10 @@ func (api *API) workspaceAgentClientCoordinate(rw http.ResponseWriter, r *http.R
 func convertApps(dbApps []database.WorkspaceApp) []codersdk.WorkspaceApp {
 	apps := make([]codersdk.WorkspaceApp, 0)
 	for _, dbApp := range dbApps {
+		var tcp bool
+		if appURL, err := url.Parse(dbApp.Url.String); err == nil {
+			tcp = workspaceapps.IsTCP(appURL)
+		}
 		apps = append(apps, codersdk.WorkspaceApp{
 			ID:           dbApp.ID,
 			Slug:         dbApp.Slug,
@@ -602,6 +1141,7 @@ func convertApps(dbApps []database.WorkspaceApp) []codersdk.WorkspaceApp {
 			Icon:         dbApp.Icon,
 			Subdomain:    dbApp.Subdomain,
 			SharingLevel: codersdk.WorkspaceAppSharingLevel(dbApp.SharingLevel),
+			TCP:          tcp,
 			Healthcheck: codersdk.Healthcheck{
 				URL:       dbApp.HealthcheckUrl,
 				Interval:  dbApp.HealthcheckInterval,
@@ -622,19 +1162,26 @@ func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordin
 		}
 	}
 	workspaceAgent := codersdk.WorkspaceAgent{
-		ID:                   dbAgent.ID,
-		CreatedAt:            dbAgent.CreatedAt,
-		UpdatedAt:            dbAgent.UpdatedAt,
-		ResourceID:           dbAgent.ResourceID,
-		InstanceID:           dbAgent.AuthInstanceID.String,
-		Name:                 dbAgent.Name,
-		Architecture:         dbAgent.Architecture,
-		OperatingSystem:      dbAgent.OperatingSystem,
-		StartupScript:        dbAgent.StartupScript.String,
-		Version:              dbAgent.Version,
-		EnvironmentVariables: envs,
-		Directory:            dbAgent.Directory,
-		Apps:                 apps,
+		ID:                           dbAgent.ID,
+		CreatedAt:                    dbAgent.CreatedAt,
+		UpdatedAt:                    dbAgent.UpdatedAt,
+		ResourceID:                   dbAgent.ResourceID,
+		InstanceID:                   dbAgent.AuthInstanceID.String,
+		Name:                         dbAgent.Name,
+		Architecture:                 dbAgent.Architecture,
+		OperatingSystem:              dbAgent.OperatingSystem,
+		StartupScript:                dbAgent.StartupScript.String,
+		Version:                      dbAgent.Version,
+		EnvironmentVariables:         envs,
+		Directory:                    dbAgent.Directory,
+		Apps:                         apps,
+		StartupLogsLength:            dbAgent.StartupLogsLength,
+		StartupLogsOverflowed:        dbAgent.StartupLogsOverflowed,
+		LifecycleState:               codersdk.WorkspaceAgentLifecycle(dbAgent.LifecycleState),
+		StartupScriptTimeoutSeconds:  dbAgent.StartupScriptTimeoutSeconds,
+		LoginBeforeReady:             dbAgent.LoginBeforeReady,
+		ShutdownScript:               dbAgent.ShutdownScript.String,
+		ShutdownScriptTimeoutSeconds: dbAgent.ShutdownScriptTimeoutSeconds,
 	}
 	node := coordinator.Node(dbAgent.ID)
 	if node != nil {
@@ -697,10 +1244,10 @@ func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordin
 func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	api.websocketWaitMutex.Lock()
-	api.websocketWaitGroup.Add(1)
-	api.websocketWaitMutex.Unlock()
-	defer api.websocketWaitGroup.Done()
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
 
 	workspaceAgent := httpmw.WorkspaceAgent(r)
 	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
@@ -784,6 +1331,17 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 			conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("read report response: %s", err))
 			return
 		}
+		// Connection records are stored in their own table. They change with
+		// every report while a connection is open, so they must not affect
+		// the duplicate check below.
+		connections := rep.Connections
+		rep.Connections = nil
+		err = insertConnectionStats(ctx, api.Database, database.ConnectionStatSourceAgent, workspaceAgent.ID, workspace.ID, workspace.OwnerID, connections)
+		if err != nil {
+			api.Logger.Debug(ctx, "insert connection stats", slog.Error(err))
+			conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("insert connection stats: %s", err))
+			return
+		}
 
 		repJSON, err := json.Marshal(rep)
 		if err != nil {
@@ -1236,3 +1794,180 @@ func websocketNetConn(ctx context.Context, conn *websocket.Conn, msgType websock
 		Conn:   nc,
 	}
 }
+
+// maxWorkspaceAgentMetadataLength is the maximum length of a metadata value
+// or error. Longer values are truncated so a chatty script can't fill the
+// database.
+const maxWorkspaceAgentMetadataLength = 10 << 10
+
+func (api *API) workspaceAgentPostMetadata(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req codersdk.PostWorkspaceAgentMetadataRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+	key := chi.URLParam(r, "key")
+
+	datum := database.UpdateWorkspaceAgentMetadataParams{
+		WorkspaceAgentID: workspaceAgent.ID,
+		Key:              key,
+		Value:            utilstrings.Truncate(req.Value, maxWorkspaceAgentMetadataLength),
+		Error:            utilstrings.Truncate(req.Error, maxWorkspaceAgentMetadataLength),
+		CollectedAt:      req.CollectedAt,
+	}
+	// The agent's clock may be skewed, so trust the server's time when
+	// the agent reports one from the future.
+	if now := database.Now(); datum.CollectedAt.After(now) {
+		datum.CollectedAt = now
+	}
+
+	err := api.Database.UpdateWorkspaceAgentMetadata(ctx, datum)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	err = api.Pubsub.Publish(workspaceAgentMetadataChannel(workspaceAgent.ID), []byte(key))
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+func (api *API) watchWorkspaceAgentMetadata(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+		workspace      = httpmw.WorkspaceParam(r)
+		logger         = api.Logger.With(slog.F("workspace_agent_id", workspaceAgent.ID))
+	)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	sendEvent, senderClosed, err := httpapi.ServerSentEventSender(rw, r)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error setting up server-sent events.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Prevent handler from returning until the sender is closed.
+	defer func() {
+		<-senderClosed
+	}()
+
+	// Ignore all trace spans after this, they're not too useful.
+	ctx = trace.ContextWithSpan(ctx, tracing.NoopSpan)
+
+	// Subscribe before the initial query so no updates are missed. Updates
+	// are coalesced: the full set of metadata is refetched and sent
+	// whenever any item changes.
+	refresh := make(chan struct{}, 1)
+	cancelSub, err := api.Pubsub.Subscribe(workspaceAgentMetadataChannel(workspaceAgent.ID), func(_ context.Context, _ []byte) {
+		select {
+		case refresh <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		_ = sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeError,
+			Data: codersdk.Response{
+				Message: "Internal error subscribing to workspace agent metadata.",
+				Detail:  err.Error(),
+			},
+		})
+		return
+	}
+	defer cancelSub()
+
+	sendMetadata := func() error {
+		md, err := api.Database.GetWorkspaceAgentMetadata(ctx, workspaceAgent.ID)
+		if err != nil {
+			_ = sendEvent(ctx, codersdk.ServerSentEvent{
+				Type: codersdk.ServerSentEventTypeError,
+				Data: codersdk.Response{
+					Message: "Internal error fetching workspace agent metadata.",
+					Detail:  err.Error(),
+				},
+			})
+			return err
+		}
+		return sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeData,
+			Data: convertWorkspaceAgentMetadata(md),
+		})
+	}
+
+	if err := sendMetadata(); err != nil {
+		logger.Debug(ctx, "send workspace agent metadata", slog.Error(err))
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-senderClosed:
+			return
+		case <-refresh:
+			if err := sendMetadata(); err != nil {
+				logger.Debug(ctx, "send workspace agent metadata", slog.Error(err))
+				return
+			}
+		}
+	}
+}
+
+func convertWorkspaceAgentMetadataDesc(mds []database.WorkspaceAgentMetadatum) []codersdk.WorkspaceAgentMetadataDescription {
+	metadata := make([]codersdk.WorkspaceAgentMetadataDescription, 0, len(mds))
+	for _, datum := range mds {
+		metadata = append(metadata, codersdk.WorkspaceAgentMetadataDescription{
+			DisplayName: datum.DisplayName,
+			Key:         datum.Key,
+			Script:      datum.Script,
+			Interval:    datum.Interval,
+			Timeout:     datum.Timeout,
+		})
+	}
+	return metadata
+}
+
+func convertWorkspaceAgentMetadata(db []database.WorkspaceAgentMetadatum) []codersdk.WorkspaceAgentMetadatum {
+	// An empty array is easier for clients to handle than a null.
+	result := make([]codersdk.WorkspaceAgentMetadatum, 0, len(db))
+	for _, datum := range db {
+		result = append(result, codersdk.WorkspaceAgentMetadatum{
+			Result: codersdk.WorkspaceAgentMetadataResult{
+				Value:       datum.Value,
+				Error:       datum.Error,
+				CollectedAt: datum.CollectedAt,
+				Age:         int64(time.Since(datum.CollectedAt).Seconds()),
+			},
+			Description: codersdk.WorkspaceAgentMetadataDescription{
+				DisplayName: datum.DisplayName,
+				Key:         datum.Key,
+				Script:      datum.Script,
+				Interval:    datum.Interval,
+				Timeout:     datum.Timeout,
+			},
+		})
+	}
+	// Sort by key for a stable display order.
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Description.Key < result[j].Description.Key
+	})
+	return result
+}
+
+func workspaceAgentMetadataChannel(agentID uuid.UUID) string {
+	return fmt.Sprintf("workspace-agent-metadata:%s", agentID)
+}
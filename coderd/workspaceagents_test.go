@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -22,6 +24,7 @@ import (
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/agent"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisioner/echo"
@@ -272,6 +275,100 @@ func TestWorkspaceAgentTailnet(t *testing.T) {
 	require.Equal(t, "test", strings.TrimSpace(string(output)))
 }
 
+func TestWorkspaceAgentSSHCertificate(t *testing.T) {
+	t.Parallel()
+	client, daemonCloser := coderdtest.NewWithProvisionerCloser(t, &coderdtest.Options{
+		SSHRequireCertificates: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	daemonCloser.Close()
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	agentID := resources[0].Agents[0].ID
+
+	t.Run("Connect", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		conn, err := client.DialWorkspaceAgent(ctx, agentID, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// The agent requires a certificate.
+		_, err = conn.SSHClient()
+		require.Error(t, err)
+
+		signer, err := client.WorkspaceAgentSSHSigner(ctx, agentID)
+		require.NoError(t, err)
+		sshClient, err := conn.SSHClientWithSigner(signer)
+		require.NoError(t, err)
+		defer sshClient.Close()
+		session, err := sshClient.NewSession()
+		require.NoError(t, err)
+		defer session.Close()
+		output, err := session.CombinedOutput("echo test")
+		require.NoError(t, err)
+		require.Equal(t, "test", strings.TrimSpace(string(output)))
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := client.IssueWorkspaceAgentSSHCertificate(ctx, agentID, codersdk.IssueWorkspaceAgentSSHCertificateRequest{
+			PublicKey: "ssh-ed25519 invalid",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("NotOwner", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err := member.WorkspaceAgentSSHSigner(ctx, agentID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
+
 func TestWorkspaceAgentPTY(t *testing.T) {
 	t.Parallel()
 	if runtime.GOOS == "windows" {
@@ -726,6 +823,301 @@ func TestWorkspaceAgentAppHealth(t *testing.T) {
 	require.EqualValues(t, codersdk.WorkspaceAppHealthUnhealthy, metadata.Apps[1].Health)
 }
 
+func TestWorkspaceAgentReportLifecycle(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: echo.ParseComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							StartupScriptTimeoutSeconds: 30,
+							LoginBeforeReady:            false,
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	workspace, err := client.Workspace(ctx, workspace.ID)
+	require.NoError(t, err)
+	agent := workspace.LatestBuild.Resources[0].Agents[0]
+	require.Equal(t, codersdk.WorkspaceAgentLifecycleCreated, agent.LifecycleState)
+	require.EqualValues(t, 30, agent.StartupScriptTimeoutSeconds)
+	require.False(t, agent.LoginBeforeReady)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+
+	metadata, err := agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, metadata.StartupScriptTimeout)
+
+	for _, state := range codersdk.WorkspaceAgentLifecycleOrder {
+		err := agentClient.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+			State: state,
+		})
+		require.NoError(t, err, "post lifecycle state %q", state)
+
+		agent, err := client.WorkspaceAgent(ctx, agent.ID)
+		require.NoError(t, err)
+		require.Equal(t, state, agent.LifecycleState)
+	}
+
+	err = agentClient.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+		State: "nonexistent",
+	})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+}
+
+func TestWorkspaceAgentShutdownScript(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	tempPath := filepath.Join(t.TempDir(), "shutdown.txt")
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: echo.ParseComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: "dev",
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							ShutdownScript:               "echo bye > " + tempPath,
+							ShutdownScriptTimeoutSeconds: 30,
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	defer agentCloser.Close()
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	agentID := resources[0].Agents[0].ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	require.Eventually(t, func() bool {
+		agent, err := client.WorkspaceAgent(ctx, agentID)
+		return err == nil && agent.LifecycleState == codersdk.WorkspaceAgentLifecycleReady
+	}, testutil.WaitLong, testutil.IntervalMedium)
+
+	// Stopped workspaces usually have no agents, and the agent token
+	// must not be claimed by a new agent while the old one shuts down.
+	stopVersion := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, stopVersion.ID)
+	stopBuild, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		TemplateVersionID: stopVersion.ID,
+		Transition:        codersdk.WorkspaceTransitionStop,
+	})
+	require.NoError(t, err)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, stopBuild.ID)
+	stopBuild, err = client.WorkspaceBuild(ctx, stopBuild.ID)
+	require.NoError(t, err)
+	require.Equal(t, codersdk.ProvisionerJobSucceeded, stopBuild.Job.Status)
+
+	// The build waits for the script before provisioning.
+	workspaceAgent, err := client.WorkspaceAgent(ctx, agentID)
+	require.NoError(t, err)
+	require.Equal(t, codersdk.WorkspaceAgentLifecycleOff, workspaceAgent.LifecycleState)
+	content, err := os.ReadFile(tempPath)
+	require.NoError(t, err)
+	require.Equal(t, "bye", strings.TrimSpace(string(content)))
+
+	logs, err := client.WorkspaceBuildLogsBefore(ctx, stopBuild.ID, time.Now())
+	require.NoError(t, err)
+	var outputs []string
+	for _, log := range logs {
+		outputs = append(outputs, log.Output)
+	}
+	require.Contains(t, outputs, "Signaled dev to run the shutdown script.")
+	require.Contains(t, outputs, "dev finished running the shutdown script.")
+}
+
+func TestWorkspaceAgentStartupLogs(t *testing.T) {
+	t.Parallel()
+	setup := func(t *testing.T) (*codersdk.Client, *codersdk.Client, codersdk.WorkspaceAgent) {
+		t.Helper()
+		client := coderdtest.New(t, &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		authToken := uuid.NewString()
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse: echo.ParseComplete,
+			Provision: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{
+							Name: "example",
+							Type: "aws_instance",
+							Agents: []*proto.Agent{{
+								Id: uuid.NewString(),
+								Auth: &proto.Agent_Token{
+									Token: authToken,
+								},
+							}},
+						}},
+					},
+				},
+			}},
+		})
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		workspace, err := client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+
+		agentClient := codersdk.New(client.URL)
+		agentClient.SessionToken = authToken
+		return client, agentClient, workspace.LatestBuild.Resources[0].Agents[0]
+	}
+
+	t.Run("PublishAndFollow", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client, agentClient, agent := setup(t)
+
+		err := agentClient.PatchStartupLogs(ctx, codersdk.PatchStartupLogs{
+			Logs: []codersdk.StartupLog{{
+				CreatedAt: database.Now(),
+				Output:    "testing",
+			}},
+		})
+		require.NoError(t, err)
+
+		logs, closer, err := client.WorkspaceAgentStartupLogsAfter(ctx, agent.ID, 0, true)
+		require.NoError(t, err)
+		defer closer.Close()
+
+		var logChunk []codersdk.WorkspaceAgentStartupLog
+		select {
+		case <-ctx.Done():
+		case logChunk = <-logs:
+		}
+		require.NoError(t, ctx.Err())
+		require.Len(t, logChunk, 1)
+		require.Equal(t, "testing", logChunk[0].Output)
+
+		err = agentClient.PatchStartupLogs(ctx, codersdk.PatchStartupLogs{
+			Logs: []codersdk.StartupLog{{
+				CreatedAt: database.Now(),
+				Output:    "testing2",
+			}, {
+				CreatedAt: database.Now(),
+				EOF:       true,
+			}},
+		})
+		require.NoError(t, err)
+
+		select {
+		case <-ctx.Done():
+		case logChunk = <-logs:
+		}
+		require.NoError(t, ctx.Err())
+		require.Len(t, logChunk, 2)
+		require.Equal(t, "testing2", logChunk[0].Output)
+		require.True(t, logChunk[1].EOF)
+
+		// The stream ends once the final log has been sent.
+		select {
+		case <-ctx.Done():
+		case _, ok := <-logs:
+			require.False(t, ok)
+		}
+		require.NoError(t, ctx.Err())
+
+		// Logs can also be fetched without following.
+		logs, closer, err = client.WorkspaceAgentStartupLogsAfter(ctx, agent.ID, logChunk[0].ID, false)
+		require.NoError(t, err)
+		defer closer.Close()
+		logChunk = <-logs
+		require.Len(t, logChunk, 1)
+		require.True(t, logChunk[0].EOF)
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client, agentClient, agent := setup(t)
+
+		logs, closer, err := client.WorkspaceAgentStartupLogsAfter(ctx, agent.ID, 0, true)
+		require.NoError(t, err)
+		defer closer.Close()
+
+		err = agentClient.PatchStartupLogs(ctx, codersdk.PatchStartupLogs{
+			Logs: []codersdk.StartupLog{{
+				CreatedAt: database.Now(),
+				Output:    strings.Repeat("a", (1<<20)+1),
+			}},
+		})
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusRequestEntityTooLarge, apiError.StatusCode())
+
+		// Followers are told there will be no more logs.
+		select {
+		case <-ctx.Done():
+		case _, ok := <-logs:
+			require.False(t, ok)
+		}
+		require.NoError(t, ctx.Err())
+
+		agent, err = client.WorkspaceAgent(ctx, agent.ID)
+		require.NoError(t, err)
+		require.True(t, agent.StartupLogsOverflowed)
+	})
+}
+
 // nolint:bodyclose
 func TestWorkspaceAgentsGitAuth(t *testing.T) {
 	t.Parallel()
@@ -938,3 +1330,109 @@ func gitAuthCallback(t *testing.T, id string, client *codersdk.Client) *http.Res
 	})
 	return res
 }
+
+func TestWorkspaceAgent_Metadata(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: echo.ParseComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Metadata: []*proto.Agent_Metadata{
+								{
+									DisplayName: "First Meta",
+									Key:         "foo1",
+									Script:      "echo hi",
+									Interval:    10,
+									Timeout:     3,
+								},
+								{
+									DisplayName: "Second Meta",
+									Key:         "foo2",
+									Script:      "echo howdy",
+									Interval:    10,
+									Timeout:     3,
+								},
+							},
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	workspace, err := client.Workspace(ctx, workspace.ID)
+	require.NoError(t, err)
+	agentID := workspace.LatestBuild.Resources[0].Agents[0].ID
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+
+	manifest, err := agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.Len(t, manifest.Metadata, 2)
+	require.ElementsMatch(t, []string{"foo1", "foo2"}, []string{manifest.Metadata[0].Key, manifest.Metadata[1].Key})
+
+	updates, errs := client.WatchWorkspaceAgentMetadata(ctx, agentID)
+	recvUpdate := func() []codersdk.WorkspaceAgentMetadatum {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("context done: %v", ctx.Err())
+		case err := <-errs:
+			t.Fatalf("error watching metadata: %v", err)
+		case update := <-updates:
+			return update
+		}
+		return nil
+	}
+
+	// The initial state is sent right away, before anything is collected.
+	update := recvUpdate()
+	require.Len(t, update, 2)
+	require.Equal(t, "foo1", update[0].Description.Key)
+	require.Equal(t, "First Meta", update[0].Description.DisplayName)
+	require.Empty(t, update[0].Result.Value)
+
+	err = agentClient.PostWorkspaceAgentMetadata(ctx, "foo1", codersdk.PostWorkspaceAgentMetadataRequest{
+		CollectedAt: time.Now(),
+		Value:       "bar",
+	})
+	require.NoError(t, err)
+
+	update = recvUpdate()
+	require.Len(t, update, 2)
+	require.Equal(t, "bar", update[0].Result.Value)
+	require.Empty(t, update[0].Result.Error)
+	require.Empty(t, update[1].Result.Value)
+
+	// Reported times from the future are clamped to the server's clock.
+	err = agentClient.PostWorkspaceAgentMetadata(ctx, "foo2", codersdk.PostWorkspaceAgentMetadataRequest{
+		CollectedAt: time.Now().Add(time.Hour),
+		Error:       "failed",
+	})
+	require.NoError(t, err)
+
+	update = recvUpdate()
+	require.Equal(t, "failed", update[1].Result.Error)
+	require.WithinDuration(t, time.Now(), update[1].Result.CollectedAt, time.Minute)
+}
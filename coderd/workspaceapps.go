@@ -27,18 +27,11 @@ import (
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/coderd/workspaceapps"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/site"
 )
 
-const (
-	// This needs to be a super unique query parameter because we don't want to
-	// conflict with query parameters that users may use.
-	//nolint:gosec
-	subdomainProxyAPIKeyParam = "coder_application_connect_api_key_35e783"
-	redirectURIQueryParam     = "redirect_uri"
-)
-
 func (api *API) appHost(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.GetAppHostResponse{
 		Host: api.AppHostname,
@@ -89,11 +82,12 @@ func (api *API) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request)
 	}
 
 	api.proxyWorkspaceApplication(proxyApplication{
-		Workspace: workspace,
-		Agent:     agent,
-		App:       &app,
-		Port:      0,
-		Path:      chiPath,
+		Workspace:    workspace,
+		Agent:        agent,
+		AccessMethod: workspaceapps.AccessMethodPath,
+		App:          &app,
+		Port:         0,
+		Path:         chiPath,
 	}, rw, r)
 }
 
@@ -200,11 +194,12 @@ func (api *API) handleSubdomainApplications(middlewares ...func(http.Handler) ht
 				}
 
 				api.proxyWorkspaceApplication(proxyApplication{
-					Workspace: workspace,
-					Agent:     agent,
-					App:       workspaceAppPtr,
-					Port:      app.Port,
-					Path:      r.URL.Path,
+					Workspace:    workspace,
+					Agent:        agent,
+					AccessMethod: workspaceapps.AccessMethodSubdomain,
+					App:          workspaceAppPtr,
+					Port:         app.Port,
+					Path:         r.URL.Path,
 				}, rw, r)
 			})).ServeHTTP(rw, r.WithContext(ctx))
 		})
@@ -295,7 +290,7 @@ func (api *API) authorizeWorkspaceApp(r *http.Request, sharingLevel database.App
 	// Regardless of share level or whether it's enabled or not, the owner of
 	// the workspace can always access applications (as long as their API key's
 	// scope allows it).
-	err := api.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope.ToRBAC(), []string{}, rbac.ActionCreate, workspace.ApplicationConnectRBAC())
+	err := api.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope, []string{}, rbac.ActionCreate, workspace.ApplicationConnectRBAC())
 	if err == nil {
 		return true, nil
 	}
@@ -311,7 +306,7 @@ func (api *API) authorizeWorkspaceApp(r *http.Request, sharingLevel database.App
 		// workspaces. This ensures that the key's scope has permission to
 		// connect to workspace apps.
 		object := rbac.ResourceWorkspaceApplicationConnect.WithOwner(roles.ID.String())
-		err := api.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope.ToRBAC(), []string{}, rbac.ActionCreate, object)
+		err := api.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope, []string{}, rbac.ActionCreate, object)
 		if err == nil {
 			return true, nil
 		}
@@ -326,6 +321,135 @@ func (api *API) authorizeWorkspaceApp(r *http.Request, sharingLevel database.App
 	return false, nil
 }
 
+// ResolveWorkspaceApp resolves the user, workspace, agent and app described by
+// appReq and checks that the user authenticated on r, if any, may access the
+// app. The request must have passed through httpmw.ExtractAPIKey with
+// Optional set.
+//
+// If the app cannot be accessed, workspaceapps.ErrUnauthenticated is returned
+// for anonymous requests and workspaceapps.ErrNotFound for authenticated ones,
+// so callers can decide whether to send the user to log in.
+func (api *API) ResolveWorkspaceApp(r *http.Request, appReq workspaceapps.Request) (workspaceapps.SignedToken, error) {
+	ctx := r.Context()
+	err := appReq.Validate()
+	if err != nil {
+		return workspaceapps.SignedToken{}, xerrors.Errorf("invalid app request: %w", err)
+	}
+
+	_, authenticated := httpmw.APIKeyOptional(r)
+	notFound := workspaceapps.ErrNotFound
+	if !authenticated {
+		notFound = workspaceapps.ErrUnauthenticated
+	}
+
+	var user database.User
+	if userID, err := uuid.Parse(appReq.UsernameOrID); err == nil {
+		user, err = api.Database.GetUserByID(ctx, userID)
+		if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+			return workspaceapps.SignedToken{}, xerrors.Errorf("get user by ID: %w", err)
+		}
+	} else {
+		user, err = api.Database.GetUserByEmailOrUsername(ctx, database.GetUserByEmailOrUsernameParams{
+			Username: appReq.UsernameOrID,
+		})
+		if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+			return workspaceapps.SignedToken{}, xerrors.Errorf("get user by username: %w", err)
+		}
+	}
+	if user.ID == uuid.Nil || user.Deleted {
+		return workspaceapps.SignedToken{}, notFound
+	}
+
+	workspace, err := api.Database.GetWorkspaceByOwnerIDAndName(ctx, database.GetWorkspaceByOwnerIDAndNameParams{
+		OwnerID: user.ID,
+		Name:    appReq.WorkspaceName(),
+	})
+	if xerrors.Is(err, sql.ErrNoRows) {
+		return workspaceapps.SignedToken{}, notFound
+	}
+	if err != nil {
+		return workspaceapps.SignedToken{}, xerrors.Errorf("get workspace: %w", err)
+	}
+
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return workspaceapps.SignedToken{}, xerrors.Errorf("get latest workspace build: %w", err)
+	}
+	resources, err := api.Database.GetWorkspaceResourcesByJobID(ctx, build.JobID)
+	if err != nil {
+		return workspaceapps.SignedToken{}, xerrors.Errorf("get workspace resources: %w", err)
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := api.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil {
+		return workspaceapps.SignedToken{}, xerrors.Errorf("get workspace agents: %w", err)
+	}
+	var agent database.WorkspaceAgent
+	agentName := appReq.AgentName()
+	switch {
+	case agentName == "" && len(agents) == 1:
+		agent = agents[0]
+	case agentName != "":
+		for _, a := range agents {
+			if a.Name == agentName {
+				agent = a
+				break
+			}
+		}
+	}
+	if agent.ID == uuid.Nil {
+		return workspaceapps.SignedToken{}, notFound
+	}
+
+	sharingLevel := database.AppSharingLevelOwner
+	appURL := fmt.Sprintf("http://127.0.0.1:%d", appReq.Port())
+	if appReq.Port() == 0 {
+		app, err := api.Database.GetWorkspaceAppByAgentIDAndSlug(ctx, database.GetWorkspaceAppByAgentIDAndSlugParams{
+			AgentID: agent.ID,
+			Slug:    appReq.AppSlugOrPort,
+		})
+		if xerrors.Is(err, sql.ErrNoRows) {
+			return workspaceapps.SignedToken{}, notFound
+		}
+		if err != nil {
+			return workspaceapps.SignedToken{}, xerrors.Errorf("get workspace app: %w", err)
+		}
+		if !app.Url.Valid {
+			return workspaceapps.SignedToken{}, xerrors.Errorf("application %q does not have a URL set", app.Slug)
+		}
+		if app.SharingLevel != "" {
+			sharingLevel = app.SharingLevel
+		}
+		appURL = app.Url.String
+	} else if int(appReq.Port()) < codersdk.MinimumListeningPort {
+		return workspaceapps.SignedToken{}, xerrors.Errorf("application port %d is not permitted, coder reserves ports less than %d for internal use", appReq.Port(), codersdk.MinimumListeningPort)
+	}
+
+	authed, err := api.authorizeWorkspaceApp(r, sharingLevel, workspace)
+	if err != nil {
+		return workspaceapps.SignedToken{}, xerrors.Errorf("authorize workspace app: %w", err)
+	}
+	if !authed {
+		return workspaceapps.SignedToken{}, notFound
+	}
+
+	var userID uuid.UUID
+	if apiKey, ok := httpmw.APIKeyOptional(r); ok {
+		userID = apiKey.UserID
+	}
+	return workspaceapps.SignedToken{
+		Request:     appReq,
+		Expiry:      database.Now().Add(workspaceapps.SignedTokenExpiry),
+		UserID:      userID,
+		WorkspaceID: workspace.ID,
+		AgentID:     agent.ID,
+		AppURL:      appURL,
+	}, nil
+}
+
 // fetchWorkspaceApplicationAuth authorizes the user using api.AppAuthorizer
 // for a given app share level in the given workspace. The user's authorization
 // status is returned. If a server error occurs, a HTML error page is rendered
@@ -386,7 +510,7 @@ func (api *API) verifyWorkspaceApplicationSubdomainAuth(rw http.ResponseWriter,
 
 	// If the request has the special query param then we need to set a cookie
 	// and strip that query parameter.
-	if encryptedAPIKey := r.URL.Query().Get(subdomainProxyAPIKeyParam); encryptedAPIKey != "" {
+	if encryptedAPIKey := r.URL.Query().Get(workspaceapps.SubdomainProxyAPIKeyParam); encryptedAPIKey != "" {
 		// Exchange the encoded API key for a real one.
 		_, apiKey, err := decryptAPIKey(r.Context(), api.Database, encryptedAPIKey)
 		if err != nil {
@@ -436,7 +560,7 @@ func (api *API) verifyWorkspaceApplicationSubdomainAuth(rw http.ResponseWriter,
 			path = "/"
 		}
 		q := r.URL.Query()
-		q.Del(subdomainProxyAPIKeyParam)
+		q.Del(workspaceapps.SubdomainProxyAPIKeyParam)
 		rawQuery := q.Encode()
 		if rawQuery != "" {
 			path += "?" + q.Encode()
@@ -455,7 +579,7 @@ func (api *API) verifyWorkspaceApplicationSubdomainAuth(rw http.ResponseWriter,
 	u := *api.AccessURL
 	u.Path = "/api/v2/applications/auth-redirect"
 	q := u.Query()
-	q.Add(redirectURIQueryParam, redirectURI.String())
+	q.Add(workspaceapps.RedirectURIQueryParam, redirectURI.String())
 	u.RawQuery = q.Encode()
 
 	http.Redirect(rw, r, u.String(), http.StatusTemporaryRedirect)
@@ -470,13 +594,6 @@ func (api *API) verifyWorkspaceApplicationSubdomainAuth(rw http.ResponseWriter,
 // in production unless the user messes with the URL.
 func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	if api.AppHostname == "" {
-		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
-			Message: "The server does not accept subdomain-based application requests.",
-		})
-		return
-	}
-
 	apiKey := httpmw.APIKey(r)
 	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceAPIKey.WithOwner(apiKey.UserID.String())) {
 		httpapi.ResourceNotFound(rw)
@@ -484,7 +601,7 @@ func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request
 	}
 
 	// Get the redirect URI from the query parameters and parse it.
-	redirectURI := r.URL.Query().Get(redirectURIQueryParam)
+	redirectURI := r.URL.Query().Get(workspaceapps.RedirectURIQueryParam)
 	if redirectURI == "" {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message: "Missing redirect_uri query parameter.",
@@ -499,27 +616,49 @@ func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request
 		})
 		return
 	}
-	// Force the redirect URI to use the same scheme as the access URL for
-	// security purposes.
-	u.Scheme = api.AccessURL.Scheme
 
-	// Ensure that the redirect URI is a subdomain of api.AppHostname and is a
-	// valid app subdomain.
-	subdomain, ok := httpapi.ExecuteHostnamePattern(api.AppHostnameRegex, u.Host)
-	if !ok {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "The redirect_uri query parameter must be a valid app subdomain.",
-		})
-		return
-	}
-	_, err = httpapi.ParseSubdomainAppURL(subdomain)
+	// Workspace proxies serve both path and subdomain apps on their own
+	// hostnames, so they need app keys smuggled to them too.
+	proxyScheme, err := api.workspaceProxyAppScheme(ctx, u)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "The redirect_uri query parameter must be a valid app subdomain.",
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error checking workspace proxies.",
 			Detail:  err.Error(),
 		})
 		return
 	}
+	if proxyScheme != "" {
+		u.Scheme = proxyScheme
+	} else {
+		if api.AppHostname == "" {
+			httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+				Message: "The server does not accept subdomain-based application requests.",
+			})
+			return
+		}
+
+		// Force the redirect URI to use the same scheme as the access URL for
+		// security purposes.
+		u.Scheme = api.AccessURL.Scheme
+
+		// Ensure that the redirect URI is a subdomain of api.AppHostname and is
+		// a valid app subdomain.
+		subdomain, ok := httpapi.ExecuteHostnamePattern(api.AppHostnameRegex, u.Host)
+		if !ok {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "The redirect_uri query parameter must be a valid app subdomain.",
+			})
+			return
+		}
+		_, err = httpapi.ParseSubdomainAppURL(subdomain)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "The redirect_uri query parameter must be a valid app subdomain.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
 
 	// Create the application_connect-scoped API key with the same lifetime as
 	// the current session (defaulting to 1 day, capped to 1 week).
@@ -564,15 +703,53 @@ func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request
 	// Redirect to the redirect URI with the encrypted API key in the query
 	// parameters.
 	q := u.Query()
-	q.Set(subdomainProxyAPIKeyParam, encryptedAPIKey)
+	q.Set(workspaceapps.SubdomainProxyAPIKeyParam, encryptedAPIKey)
 	u.RawQuery = q.Encode()
 	http.Redirect(rw, r, u.String(), http.StatusTemporaryRedirect)
 }
 
+// workspaceProxyAppScheme returns the scheme of the workspace proxy that serves
+// apps at u, or an empty string if u does not belong to a registered proxy.
+func (api *API) workspaceProxyAppScheme(ctx context.Context, u *url.URL) (string, error) {
+	proxies, err := api.Database.GetWorkspaceProxies(ctx)
+	if err != nil {
+		return "", xerrors.Errorf("get workspace proxies: %w", err)
+	}
+	for _, proxy := range proxies {
+		if proxy.Url == "" {
+			// The proxy has not registered yet.
+			continue
+		}
+		proxyURL, err := url.Parse(proxy.Url)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(proxyURL.Host, u.Host) {
+			return proxyURL.Scheme, nil
+		}
+		if proxy.WildcardHostname == "" {
+			continue
+		}
+		pattern, err := httpapi.CompileHostnamePattern(proxy.WildcardHostname)
+		if err != nil {
+			continue
+		}
+		subdomain, ok := httpapi.ExecuteHostnamePattern(pattern, u.Host)
+		if !ok {
+			continue
+		}
+		if _, err := httpapi.ParseSubdomainAppURL(subdomain); err == nil {
+			return proxyURL.Scheme, nil
+		}
+	}
+	return "", nil
+}
+
 // proxyApplication are the required fields to proxy a workspace application.
 type proxyApplication struct {
-	Workspace database.Workspace
-	Agent     database.WorkspaceAgent
+	Workspace    database.Workspace
+	Agent        database.WorkspaceAgent
+	AccessMethod workspaceapps.AccessMethod
 
 	// Either App or Port must be set, but not both.
 	App  *database.WorkspaceApp
@@ -658,6 +835,11 @@ func (api *API) proxyWorkspaceApplication(proxyApp proxyApplication, rw http.Res
 		}
 	}
 
+	if workspaceapps.IsTCP(appURL) {
+		api.proxyWorkspaceAppTCP(proxyApp, appURL, rw, r)
+		return
+	}
+
 	// Ensure path and query parameter correctness.
 	if proxyApp.Path == "" {
 		// Web applications typically request paths relative to the
@@ -718,6 +900,49 @@ func (api *API) proxyWorkspaceApplication(proxyApp proxyApplication, rw http.Res
 	proxy.ServeHTTP(rw, r)
 }
 
+// proxyWorkspaceAppTCP forwards a websocket to a TCP app. TCP apps are only
+// served on subdomains, so the dashboard origin never accepts raw sockets.
+func (api *API) proxyWorkspaceAppTCP(proxyApp proxyApplication, appURL *url.URL, rw http.ResponseWriter, r *http.Request) {
+	if proxyApp.AccessMethod != workspaceapps.AccessMethodSubdomain {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusBadRequest,
+			Title:        "Bad Request",
+			Description:  fmt.Sprintf("Application %q forwards TCP and must be accessed on a subdomain. Set subdomain = true on the coder_app.", proxyApp.App.Slug),
+			RetryEnabled: false,
+			DashboardURL: api.AccessURL.String(),
+		})
+		return
+	}
+	if appURL.Port() == "" {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusBadRequest,
+			Title:        "Bad Request",
+			Description:  fmt.Sprintf("Application URL %q must include a port.", appURL.String()),
+			RetryEnabled: false,
+			DashboardURL: api.AccessURL.String(),
+		})
+		return
+	}
+
+	conn, release, err := api.workspaceAgentCache.Acquire(r, proxyApp.Agent.ID)
+	if err != nil {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusBadGateway,
+			Title:        "Bad Gateway",
+			Description:  "Could not connect to workspace agent: " + err.Error(),
+			RetryEnabled: true,
+			DashboardURL: api.AccessURL.String(),
+		})
+		return
+	}
+	defer release()
+
+	// end span so we don't get long lived trace data
+	tracing.EndHTTPSpan(r, http.StatusOK, trace.SpanFromContext(r.Context()))
+
+	workspaceapps.ProxyTCP(rw, r, appURL, conn.DialContext)
+}
+
 type encryptedAPIKeyPayload struct {
 	APIKey    string    `json:"api_key"`
 	ExpiresAt time.Time `json:"expires_at"`
@@ -837,6 +1062,14 @@ func decryptAPIKey(ctx context.Context, db database.Store, encryptedAPIKey strin
 	return key, payload.APIKey, nil
 }
 
+// DecryptSmuggledAPIKey decrypts an API key created by the app auth-redirect
+// flow and returns the plaintext key. Workspace proxies cannot access the
+// database, so they exchange smuggled keys through coderd.
+func (api *API) DecryptSmuggledAPIKey(ctx context.Context, encryptedAPIKey string) (string, error) {
+	_, apiKey, err := decryptAPIKey(ctx, api.Database, encryptedAPIKey)
+	return apiKey, err
+}
+
 // renderApplicationNotFound should always be used when the app is not found or
 // the current user doesn't have permission to access it.
 func renderApplicationNotFound(rw http.ResponseWriter, r *http.Request, accessURL *url.URL) {
@@ -0,0 +1,121 @@
+package workspaceapps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// SubdomainProxyAPIKeyParam is the query parameter used to smuggle
+	// encrypted API keys to app hostnames. This needs to be a super unique
+	// query parameter because we don't want to conflict with query parameters
+	// that users may use.
+	//nolint:gosec
+	SubdomainProxyAPIKeyParam = "coder_application_connect_api_key_35e783"
+	// RedirectURIQueryParam is the query parameter containing the app URL to
+	// return to after app authentication.
+	RedirectURIQueryParam = "redirect_uri"
+)
+
+type AccessMethod string
+
+const (
+	AccessMethodPath      AccessMethod = "path"
+	AccessMethodSubdomain AccessMethod = "subdomain"
+)
+
+// Request describes a request to a workspace application, as parsed from the
+// path or subdomain of the incoming HTTP request.
+type Request struct {
+	AccessMethod AccessMethod `json:"access_method"`
+	// BasePath of the app. For path apps, this is the path prefix in the router
+	// for this particular app. For subdomain apps, this should be "/". This is
+	// used for setting the cookie path.
+	BasePath string `json:"base_path"`
+
+	UsernameOrID string `json:"username_or_id"`
+	// WorkspaceAndAgent is the workspace name and optional agent name in the
+	// form "workspace" or "workspace.agent".
+	WorkspaceAndAgent string `json:"workspace_and_agent"`
+	// AppSlugOrPort is the app slug, or a port number for subdomain apps.
+	AppSlugOrPort string `json:"app_slug_or_port"`
+}
+
+// Validate ensures the request is complete and well formed.
+func (r Request) Validate() error {
+	if r.AccessMethod != AccessMethodPath && r.AccessMethod != AccessMethodSubdomain {
+		return xerrors.Errorf("invalid access method: %q", r.AccessMethod)
+	}
+	if r.BasePath == "" {
+		return xerrors.New("base path is required")
+	}
+	if r.UsernameOrID == "" {
+		return xerrors.New("username or ID is required")
+	}
+	if r.UsernameOrID == "me" {
+		// We block "me" for workspace app auth to avoid any security issues
+		// caused by having an identical workspace name on yourself and a
+		// different user and potentially reusing a token.
+		return xerrors.New(`username cannot be "me" in app requests`)
+	}
+	if r.WorkspaceAndAgent == "" {
+		return xerrors.New("workspace and agent is required")
+	}
+	if strings.Count(r.WorkspaceAndAgent, ".") > 1 {
+		return xerrors.Errorf("invalid workspace and agent: %q", r.WorkspaceAndAgent)
+	}
+	if r.AppSlugOrPort == "" {
+		return xerrors.New("app slug or port is required")
+	}
+	if r.AccessMethod == AccessMethodPath {
+		if _, err := strconv.ParseUint(r.AppSlugOrPort, 10, 16); err == nil {
+			return xerrors.New("port-based apps are only supported on subdomains")
+		}
+	}
+	return nil
+}
+
+// WorkspaceName returns the workspace portion of WorkspaceAndAgent.
+func (r Request) WorkspaceName() string {
+	return strings.SplitN(r.WorkspaceAndAgent, ".", 2)[0]
+}
+
+// AgentName returns the agent portion of WorkspaceAndAgent, which may be
+// empty if the workspace only has one agent.
+func (r Request) AgentName() string {
+	parts := strings.SplitN(r.WorkspaceAndAgent, ".", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// Port returns the port of a port-based subdomain app, or 0 if the request is
+// for an app slug.
+func (r Request) Port() uint16 {
+	if r.AccessMethod != AccessMethodSubdomain {
+		return 0
+	}
+	port, err := strconv.ParseUint(r.AppSlugOrPort, 10, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(port)
+}
+
+func (r Request) String() string {
+	return fmt.Sprintf("%s:%s/%s/%s/%s", r.AccessMethod, r.BasePath, r.UsernameOrID, r.WorkspaceAndAgent, r.AppSlugOrPort)
+}
+
+var (
+	// ErrUnauthenticated is returned when resolving a request requires a
+	// session and none was provided.
+	ErrUnauthenticated = xerrors.New("authentication required")
+	// ErrNotFound is returned when the app does not exist or the user is not
+	// permitted to access it. The two cases are deliberately
+	// indistinguishable.
+	ErrNotFound = xerrors.New("application not found")
+)
@@ -0,0 +1,63 @@
+package workspaceapps
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"nhooyr.io/websocket"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+// TCPScheme is the URL scheme of apps that forward raw TCP to a port in the
+// workspace instead of proxying HTTP, e.g. "tcp://localhost:5432". They can
+// only be accessed with a websocket on a subdomain, which `coder tunnel`
+// exposes as a local port.
+const TCPScheme = "tcp"
+
+// IsTCP returns true if the app URL forwards raw TCP.
+func IsTCP(appURL *url.URL) bool {
+	return strings.EqualFold(appURL.Scheme, TCPScheme)
+}
+
+// DialFunc dials an address in the workspace, usually through the agent.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// ProxyTCP accepts a websocket and pipes its binary messages to and from the
+// port of appURL in the workspace until either side closes. The request must
+// already be authorized.
+func ProxyTCP(rw http.ResponseWriter, r *http.Request, appURL *url.URL, dial DialFunc) {
+	ctx := r.Context()
+	if !httpapi.IsWebsocketUpgrade(r) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "TCP applications can only be accessed with a websocket.",
+			Detail:  `Use "coder tunnel" to expose the application on a local port.`,
+		})
+		return
+	}
+
+	appConn, err := dial(ctx, "tcp", appURL.Host)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadGateway, codersdk.Response{
+			Message: "Failed to dial application.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer appConn.Close()
+
+	conn, err := websocket.Accept(rw, r, &websocket.AcceptOptions{
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		return
+	}
+	go httpapi.Heartbeat(ctx, conn)
+
+	agent.Bicopy(ctx, websocket.NetConn(ctx, conn, websocket.MessageBinary), appConn)
+}
@@ -0,0 +1,137 @@
+package workspaceapps
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// SignedTokenExpiry is how long a signed app token is valid for.
+	SignedTokenExpiry = time.Minute
+
+	tokenSigningAlgorithm = jose.HS512
+)
+
+// SignedToken is the struct data contained inside a workspace app JWS. It
+// records the outcome of resolving and authorizing a Request, so the holder
+// can proxy the app without talking to the database.
+type SignedToken struct {
+	// Request details.
+	Request `json:"request"`
+
+	// Trusted resolved details.
+	Expiry      time.Time `json:"expiry"`
+	UserID      uuid.UUID `json:"user_id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	AgentID     uuid.UUID `json:"agent_id"`
+	// AppURL is the URL of the app inside the workspace, relative to the
+	// agent.
+	AppURL string `json:"app_url"`
+}
+
+// MatchesRequest returns true if the token matches the request. Any token that
+// does not match the request should be considered invalid.
+func (t SignedToken) MatchesRequest(req Request) bool {
+	return t.AccessMethod == req.AccessMethod &&
+		t.BasePath == req.BasePath &&
+		t.UsernameOrID == req.UsernameOrID &&
+		t.WorkspaceAndAgent == req.WorkspaceAndAgent &&
+		t.AppSlugOrPort == req.AppSlugOrPort
+}
+
+// SecurityKey is used for signing and verifying app tokens. It is shared
+// between coderd and every workspace proxy.
+type SecurityKey [64]byte
+
+// GenerateSecurityKey returns a new random key.
+func GenerateSecurityKey() (SecurityKey, error) {
+	var key SecurityKey
+	_, err := rand.Read(key[:])
+	if err != nil {
+		return SecurityKey{}, xerrors.Errorf("generate random bytes: %w", err)
+	}
+	return key, nil
+}
+
+// KeyFromString decodes a hex encoded key.
+func KeyFromString(str string) (SecurityKey, error) {
+	var key SecurityKey
+	decoded, err := hex.DecodeString(str)
+	if err != nil {
+		return key, xerrors.Errorf("decode key: %w", err)
+	}
+	if len(decoded) != len(key) {
+		return key, xerrors.Errorf("expected key to be %d bytes, got %d", len(key), len(decoded))
+	}
+	copy(key[:], decoded)
+	return key, nil
+}
+
+// String returns the hex encoded key.
+func (k SecurityKey) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// SignToken generates a signed workspace app token with the given payload.
+func (k SecurityKey) SignToken(payload SignedToken) (string, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", xerrors.Errorf("marshal payload to JSON: %w", err)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: tokenSigningAlgorithm,
+		Key:       k[:],
+	}, nil)
+	if err != nil {
+		return "", xerrors.Errorf("create signer: %w", err)
+	}
+
+	signedObject, err := signer.Sign(payloadBytes)
+	if err != nil {
+		return "", xerrors.Errorf("sign payload: %w", err)
+	}
+
+	serialized, err := signedObject.CompactSerialize()
+	if err != nil {
+		return "", xerrors.Errorf("serialize JWS: %w", err)
+	}
+	return serialized, nil
+}
+
+// VerifySignedToken parses a signed workspace app token, verifies its
+// signature and expiry, and returns the payload.
+func (k SecurityKey) VerifySignedToken(str string) (SignedToken, error) {
+	object, err := jose.ParseSigned(str)
+	if err != nil {
+		return SignedToken{}, xerrors.Errorf("parse JWS: %w", err)
+	}
+	if len(object.Signatures) != 1 {
+		return SignedToken{}, xerrors.New("expected 1 signature")
+	}
+	if object.Signatures[0].Header.Algorithm != string(tokenSigningAlgorithm) {
+		return SignedToken{}, xerrors.Errorf("expected token signing algorithm to be %q, got %q", tokenSigningAlgorithm, object.Signatures[0].Header.Algorithm)
+	}
+
+	output, err := object.Verify(k[:])
+	if err != nil {
+		return SignedToken{}, xerrors.Errorf("verify JWS: %w", err)
+	}
+
+	var tok SignedToken
+	err = json.Unmarshal(output, &tok)
+	if err != nil {
+		return SignedToken{}, xerrors.Errorf("unmarshal payload: %w", err)
+	}
+	if tok.Expiry.Before(time.Now()) {
+		return SignedToken{}, xerrors.New("signed app token expired")
+	}
+
+	return tok, nil
+}
@@ -0,0 +1,135 @@
+package workspaceapps_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/workspaceapps"
+)
+
+func TestSignedToken(t *testing.T) {
+	t.Parallel()
+
+	key, err := workspaceapps.GenerateSecurityKey()
+	require.NoError(t, err)
+
+	req := workspaceapps.Request{
+		AccessMethod:      workspaceapps.AccessMethodPath,
+		BasePath:          "/@user/workspace/apps/app/",
+		UsernameOrID:      "user",
+		WorkspaceAndAgent: "workspace",
+		AppSlugOrPort:     "app",
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		token := workspaceapps.SignedToken{
+			Request:     req,
+			Expiry:      time.Now().Add(time.Minute).Truncate(time.Second),
+			UserID:      uuid.New(),
+			WorkspaceID: uuid.New(),
+			AgentID:     uuid.New(),
+			AppURL:      "http://127.0.0.1:8080",
+		}
+		str, err := key.SignToken(token)
+		require.NoError(t, err)
+
+		got, err := key.VerifySignedToken(str)
+		require.NoError(t, err)
+		require.True(t, token.Expiry.Equal(got.Expiry))
+		got.Expiry = token.Expiry
+		require.Equal(t, token, got)
+		require.True(t, got.MatchesRequest(req))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		t.Parallel()
+
+		str, err := key.SignToken(workspaceapps.SignedToken{
+			Request: req,
+			Expiry:  time.Now().Add(-time.Minute),
+		})
+		require.NoError(t, err)
+
+		_, err = key.VerifySignedToken(str)
+		require.ErrorContains(t, err, "expired")
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		t.Parallel()
+
+		otherKey, err := workspaceapps.GenerateSecurityKey()
+		require.NoError(t, err)
+		str, err := otherKey.SignToken(workspaceapps.SignedToken{
+			Request: req,
+			Expiry:  time.Now().Add(time.Minute),
+		})
+		require.NoError(t, err)
+
+		_, err = key.VerifySignedToken(str)
+		require.Error(t, err)
+	})
+
+	t.Run("MismatchedRequest", func(t *testing.T) {
+		t.Parallel()
+
+		token := workspaceapps.SignedToken{Request: req}
+		other := req
+		other.AppSlugOrPort = "other"
+		require.False(t, token.MatchesRequest(other))
+	})
+}
+
+func TestSecurityKeyFromString(t *testing.T) {
+	t.Parallel()
+
+	key, err := workspaceapps.GenerateSecurityKey()
+	require.NoError(t, err)
+
+	decoded, err := workspaceapps.KeyFromString(key.String())
+	require.NoError(t, err)
+	require.Equal(t, key, decoded)
+
+	_, err = workspaceapps.KeyFromString("deadbeef")
+	require.Error(t, err)
+}
+
+func TestRequestValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := workspaceapps.Request{
+		AccessMethod:      workspaceapps.AccessMethodSubdomain,
+		BasePath:          "/",
+		UsernameOrID:      "user",
+		WorkspaceAndAgent: "workspace.agent",
+		AppSlugOrPort:     "8080",
+	}
+	require.NoError(t, valid.Validate())
+	require.Equal(t, "workspace", valid.WorkspaceName())
+	require.Equal(t, "agent", valid.AgentName())
+	require.EqualValues(t, 8080, valid.Port())
+
+	cases := []struct {
+		Name   string
+		Mutate func(r *workspaceapps.Request)
+	}{
+		{"Me", func(r *workspaceapps.Request) { r.UsernameOrID = "me" }},
+		{"TooManyDots", func(r *workspaceapps.Request) { r.WorkspaceAndAgent = "a.b.c" }},
+		{"NoSlug", func(r *workspaceapps.Request) { r.AppSlugOrPort = "" }},
+		{"BadAccessMethod", func(r *workspaceapps.Request) { r.AccessMethod = "bad" }},
+		{"PortOnPath", func(r *workspaceapps.Request) { r.AccessMethod = workspaceapps.AccessMethodPath }},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			req := valid
+			c.Mutate(&req)
+			require.Error(t, req.Validate())
+		})
+	}
+}
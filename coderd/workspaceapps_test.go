@@ -832,6 +832,127 @@ func TestWorkspaceAppsProxySubdomain(t *testing.T) {
 	})
 }
 
+func TestWorkspaceAppsProxyTCP(t *testing.T) {
+	t.Parallel()
+
+	// An echo server stands in for a database in the workspace.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		AppHostname:              proxyTestSubdomainRaw,
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: proxyTestAgentName,
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							Apps: []*proto.App{{
+								Slug:         "db",
+								DisplayName:  "db",
+								SharingLevel: proto.AppSharingLevel_OWNER,
+								Subdomain:    true,
+								Url:          "tcp://" + ln.Addr().String(),
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	require.True(t, resources[0].Agents[0].Apps[0].TCP)
+
+	host := strings.Replace(proxyTestSubdomainRaw, "*", httpapi.ApplicationURL{
+		AppSlug:       "db",
+		AgentName:     proxyTestAgentName,
+		WorkspaceName: workspace.Name,
+		Username:      workspace.OwnerName,
+	}.String(), 1)
+
+	t.Run("Forwards", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		conn, err := client.DialWorkspaceAppTCP(ctx, host)
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Write([]byte(proxyTestAppBody))
+		require.NoError(t, err)
+		got := make([]byte, len(proxyTestAppBody))
+		_, err = io.ReadFull(conn, got)
+		require.NoError(t, err)
+		require.Equal(t, proxyTestAppBody, string(got))
+	})
+
+	t.Run("NoAccess", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		userClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err := userClient.DialWorkspaceAppTCP(ctx, host)
+		require.Error(t, err)
+	})
+
+	t.Run("PathNotAllowed", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		resp, err := client.Request(ctx, http.MethodGet, fmt.Sprintf("/@%s/%s.%s/apps/db/", workspace.OwnerName, workspace.Name, proxyTestAgentName), nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
 func TestAppSharing(t *testing.T) {
 	t.Parallel()
 
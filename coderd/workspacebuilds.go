@@ -279,6 +279,14 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if createBuild.Transition == codersdk.WorkspaceTransitionStart && workspace.DormantAt.Valid {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "Workspace is dormant.",
+			Detail:  "Dormant workspaces must be activated before they can be started.",
+		})
+		return
+	}
+
 	auditor := api.Auditor.Load()
 
 	// if user deletes a workspace, audit the workspace
@@ -452,6 +460,42 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		state = priorHistory.ProvisionerState
 	}
 
+	dbTemplateVersionParameters, err := api.Database.GetTemplateVersionParameters(ctx, templateVersion.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	templateVersionParameters, err := convertTemplateVersionParameters(dbTemplateVersionParameters)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting template version parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	var lastBuildParameters []database.WorkspaceBuildParameter
+	if priorBuildNum > 0 {
+		lastBuildParameters, err = api.Database.GetWorkspaceBuildParameters(ctx, priorHistory.ID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching prior workspace build parameters.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+	buildParameters, err := resolveWorkspaceBuildParameters(templateVersionParameters, lastBuildParameters, createBuild.RichParameterValues)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid rich parameter values.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
 	var workspaceBuild database.WorkspaceBuild
 	var provisionerJob database.ProvisionerJob
 	// This must happen in a transaction to ensure history can be inserted, and
@@ -512,6 +556,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 			StorageMethod:  templateVersionJob.StorageMethod,
 			FileID:         templateVersionJob.FileID,
 			Input:          input,
+			Tags:           templateVersionJob.Tags,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -534,7 +579,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 			return xerrors.Errorf("insert workspace build: %w", err)
 		}
 
-		return nil
+		return insertWorkspaceBuildParameters(ctx, db, workspaceBuild.ID, buildParameters)
 	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -712,6 +757,112 @@ func (api *API) workspaceBuildState(rw http.ResponseWriter, r *http.Request) {
 	_, _ = rw.Write(workspaceBuild.ProvisionerState)
 }
 
+func (api *API) workspaceBuildParameters(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceBuild := httpmw.WorkspaceBuildParam(r)
+	workspace, err := api.Database.GetWorkspaceByID(ctx, workspaceBuild.WorkspaceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "No workspace exists for this job.",
+		})
+		return
+	}
+
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	parameters, err := api.Database.GetWorkspaceBuildParameters(ctx, workspaceBuild.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceBuildParameters(parameters))
+}
+
+// resolveWorkspaceBuildParameters returns the rich parameter values for a new
+// build of a template version. Values that aren't requested are carried over
+// from the last build, or use the parameter's default if they're new.
+// Immutable parameters can't change once a workspace has a value for them.
+func resolveWorkspaceBuildParameters(templateVersionParameters []codersdk.TemplateVersionParameter, lastBuildParameters []database.WorkspaceBuildParameter, requested []codersdk.WorkspaceBuildParameter) ([]codersdk.WorkspaceBuildParameter, error) {
+	defined := make(map[string]struct{}, len(templateVersionParameters))
+	for _, param := range templateVersionParameters {
+		defined[param.Name] = struct{}{}
+	}
+	requestedValues := make(map[string]string, len(requested))
+	for _, param := range requested {
+		if _, ok := defined[param.Name]; !ok {
+			return nil, xerrors.Errorf("parameter %q is not defined by the template version", param.Name)
+		}
+		requestedValues[param.Name] = param.Value
+	}
+	lastValues := make(map[string]string, len(lastBuildParameters))
+	for _, param := range lastBuildParameters {
+		lastValues[param.Name] = param.Value
+	}
+
+	resolved := make([]codersdk.WorkspaceBuildParameter, 0, len(templateVersionParameters))
+	for _, templateVersionParameter := range templateVersionParameters {
+		name := templateVersionParameter.Name
+		lastValue, hasLastValue := lastValues[name]
+		value, isRequested := requestedValues[name]
+		switch {
+		case isRequested && hasLastValue && !templateVersionParameter.Mutable && value != lastValue:
+			return nil, xerrors.Errorf("parameter %q is not mutable, so it can't be updated after creating a workspace", name)
+		case !isRequested && hasLastValue:
+			value = lastValue
+		case !isRequested:
+			value = templateVersionParameter.DefaultValue
+		}
+		err := codersdk.ValidateWorkspaceBuildParameter(templateVersionParameter, value)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, codersdk.WorkspaceBuildParameter{
+			Name:  name,
+			Value: value,
+		})
+	}
+	return resolved, nil
+}
+
+// insertWorkspaceBuildParameters stores the rich parameter values of a build.
+func insertWorkspaceBuildParameters(ctx context.Context, db database.Store, workspaceBuildID uuid.UUID, parameters []codersdk.WorkspaceBuildParameter) error {
+	if len(parameters) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(parameters))
+	values := make([]string, 0, len(parameters))
+	for _, param := range parameters {
+		names = append(names, param.Name)
+		values = append(values, param.Value)
+	}
+	err := db.InsertWorkspaceBuildParameters(ctx, database.InsertWorkspaceBuildParametersParams{
+		WorkspaceBuildID: workspaceBuildID,
+		Name:             names,
+		Value:            values,
+	})
+	if err != nil {
+		return xerrors.Errorf("insert workspace build parameters: %w", err)
+	}
+	return nil
+}
+
+func convertWorkspaceBuildParameters(parameters []database.WorkspaceBuildParameter) []codersdk.WorkspaceBuildParameter {
+	apiParameters := make([]codersdk.WorkspaceBuildParameter, 0, len(parameters))
+	for _, parameter := range parameters {
+		apiParameters = append(apiParameters, codersdk.WorkspaceBuildParameter{
+			Name:  parameter.Name,
+			Value: parameter.Value,
+		})
+	}
+	return apiParameters
+}
+
 type workspaceBuildsData struct {
 	users     []database.User
 	jobs      []database.ProvisionerJob
@@ -896,7 +1047,7 @@ func (api *API) convertWorkspaceBuild(
 		apiAgents := make([]codersdk.WorkspaceAgent, 0)
 		for _, agent := range agents {
 			apps := appsByAgentID[agent.ID]
-			apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), agent, convertApps(apps), api.AgentInactiveDisconnectTimeout)
+			apiAgent, err := convertWorkspaceAgent(api.CurrentDERPMap(), *api.TailnetCoordinator.Load(), agent, convertApps(apps), api.AgentInactiveDisconnectTimeout)
 			if err != nil {
 				return codersdk.WorkspaceBuild{}, xerrors.Errorf("converting workspace agent: %w", err)
 			}
@@ -922,6 +1073,7 @@ func (api *API) convertWorkspaceBuild(
 		InitiatorUsername:  initiator.Username,
 		Job:                apiJob,
 		Deadline:           codersdk.NewNullTime(build.Deadline, !build.Deadline.IsZero()),
+		MaxDeadline:        codersdk.NewNullTime(build.MaxDeadline, !build.MaxDeadline.IsZero()),
 		Reason:             codersdk.BuildReason(build.Reason),
 		Resources:          apiResources,
 		Status:             convertWorkspaceStatus(apiJob.Status, transition),
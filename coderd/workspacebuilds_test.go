@@ -531,6 +531,108 @@ func TestWorkspaceBuildState(t *testing.T) {
 	require.Equal(t, wantState, gotState)
 }
 
+func TestWorkspaceBuildRichParameters(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	cpuMin, cpuMax := int32(1), int32(8)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: []*proto.Parse_Response{{
+			Type: &proto.Parse_Response_Complete{
+				Complete: &proto.Parse_Complete{
+					RichParameters: []*proto.RichParameter{{
+						Name:         "region",
+						DisplayName:  "Region",
+						Type:         "string",
+						DefaultValue: "eu",
+						Order:        1,
+						Options: []*proto.RichParameterOption{{
+							Name:  "Europe",
+							Value: "eu",
+						}, {
+							Name:  "United States",
+							Value: "us",
+						}},
+					}, {
+						Name:          "cpu",
+						Type:          "number",
+						Mutable:       true,
+						DefaultValue:  "2",
+						ValidationMin: &cpuMin,
+						ValidationMax: &cpuMax,
+						Order:         2,
+					}},
+				},
+			},
+		}},
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision:       echo.ProvisionComplete,
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	templateVersionParameters, err := client.TemplateVersionRichParameters(ctx, version.ID)
+	require.NoError(t, err)
+	require.Len(t, templateVersionParameters, 2)
+	require.Equal(t, "region", templateVersionParameters[0].Name)
+	require.Len(t, templateVersionParameters[0].Options, 2)
+	require.False(t, templateVersionParameters[0].Mutable)
+	require.Equal(t, "cpu", templateVersionParameters[1].Name)
+	require.Equal(t, &cpuMax, templateVersionParameters[1].ValidationMax)
+
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+		cwr.RichParameterValues = []codersdk.WorkspaceBuildParameter{{
+			Name:  "region",
+			Value: "us",
+		}}
+	})
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	buildParameters, err := client.WorkspaceBuildParameters(ctx, workspace.LatestBuild.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []codersdk.WorkspaceBuildParameter{
+		{Name: "region", Value: "us"},
+		{Name: "cpu", Value: "2"},
+	}, buildParameters)
+
+	// Values that aren't provided are carried over from the last build.
+	build, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransitionStart,
+		RichParameterValues: []codersdk.WorkspaceBuildParameter{{
+			Name:  "cpu",
+			Value: "4",
+		}},
+	})
+	require.NoError(t, err)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+	buildParameters, err = client.WorkspaceBuildParameters(ctx, build.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []codersdk.WorkspaceBuildParameter{
+		{Name: "region", Value: "us"},
+		{Name: "cpu", Value: "4"},
+	}, buildParameters)
+
+	for _, invalid := range []codersdk.WorkspaceBuildParameter{
+		// Immutable parameters can't change.
+		{Name: "region", Value: "eu"},
+		// Out of range.
+		{Name: "cpu", Value: "16"},
+		// Not defined by the template.
+		{Name: "memory", Value: "4"},
+	} {
+		_, err = client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition:          codersdk.WorkspaceTransitionStart,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{invalid},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr, "parameter %q", invalid.Name)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	}
+}
+
 func TestWorkspaceBuildStatus(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
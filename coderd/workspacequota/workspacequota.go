@@ -1,8 +1,11 @@
 package workspacequota
 
+// Enforcer limits the number of workspaces a user can own. organizationQuota
+// is the quota configured on the organization, or 0 for the deployment wide
+// quota, which applies to all workspaces of the user.
 type Enforcer interface {
-	UserWorkspaceLimit() int
-	CanCreateWorkspace(count int) bool
+	UserWorkspaceLimit(organizationQuota int) int
+	CanCreateWorkspace(organizationQuota int, count int) bool
 }
 
 type nop struct{}
@@ -11,9 +14,9 @@ func NewNop() Enforcer {
 	return &nop{}
 }
 
-func (*nop) UserWorkspaceLimit() int {
+func (*nop) UserWorkspaceLimit(_ int) int {
 	return 0
 }
-func (*nop) CanCreateWorkspace(_ int) bool {
+func (*nop) CanCreateWorkspace(_ int, _ int) bool {
 	return true
 }
@@ -381,13 +381,31 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		})
 		return
 	}
+	organizationWorkspaceCount, err := api.Database.GetWorkspaceCountByOrganizationAndOwner(ctx, database.GetWorkspaceCountByOrganizationAndOwnerParams{
+		OrganizationID: organization.ID,
+		OwnerID:        user.ID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace count.",
+			Detail:  err.Error(),
+		})
+		return
+	}
 
-	// make sure the user has not hit their quota limit
+	// make sure the user has not hit their quota limit, which applies to
+	// all of their workspaces, nor the lower quota of the organization.
 	e := *api.WorkspaceQuotaEnforcer.Load()
-	canCreate := e.CanCreateWorkspace(int(workspaceCount))
+	if !e.CanCreateWorkspace(0, int(workspaceCount)) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("User workspace limit of %d is already reached.", e.UserWorkspaceLimit(0)),
+		})
+		return
+	}
+	canCreate := e.CanCreateWorkspace(int(organization.UserWorkspaceQuota), int(organizationWorkspaceCount))
 	if !canCreate {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: fmt.Sprintf("User workspace limit of %d is already reached.", e.UserWorkspaceLimit()),
+			Message: fmt.Sprintf("User workspace limit of %d is already reached.", e.UserWorkspaceLimit(int(organization.UserWorkspaceQuota))),
 		})
 		return
 	}
@@ -427,6 +445,31 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
+	dbTemplateVersionParameters, err := api.Database.GetTemplateVersionParameters(ctx, templateVersion.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	templateVersionParameters, err := convertTemplateVersionParameters(dbTemplateVersionParameters)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting template version parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	buildParameters, err := resolveWorkspaceBuildParameters(templateVersionParameters, nil, createWorkspace.RichParameterValues)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid rich parameter values.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
 	var (
 		provisionerJob database.ProvisionerJob
 		workspaceBuild database.WorkspaceBuild
@@ -489,6 +532,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 			StorageMethod:  templateVersionJob.StorageMethod,
 			FileID:         templateVersionJob.FileID,
 			Input:          input,
+			Tags:           templateVersionJob.Tags,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -509,7 +553,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		if err != nil {
 			return xerrors.Errorf("insert workspace build: %w", err)
 		}
-		return nil
+		return insertWorkspaceBuildParameters(ctx, db, workspaceBuild.ID, buildParameters)
 	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -838,12 +882,18 @@ func (api *API) putExtendWorkspace(rw http.ResponseWriter, r *http.Request) {
 			resp.Message = "Cannot extend workspace: " + err.Error()
 			return err
 		}
+		if !build.MaxDeadline.IsZero() && newDeadline.After(build.MaxDeadline) {
+			code = http.StatusBadRequest
+			resp.Message = "Cannot extend workspace: new deadline is beyond the template autostop requirement of " + build.MaxDeadline.Format(time.RFC3339) + "."
+			return xerrors.Errorf("new deadline %v is after max deadline %v", newDeadline, build.MaxDeadline)
+		}
 
 		if err := s.UpdateWorkspaceBuildByID(ctx, database.UpdateWorkspaceBuildByIDParams{
 			ID:               build.ID,
 			UpdatedAt:        build.UpdatedAt,
 			ProvisionerState: build.ProvisionerState,
 			Deadline:         newDeadline,
+			MaxDeadline:      build.MaxDeadline,
 		}); err != nil {
 			code = http.StatusInternalServerError
 			resp.Message = "Failed to extend workspace deadline."
@@ -859,6 +909,77 @@ func (api *API) putExtendWorkspace(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, code, resp)
 }
 
+// putWorkspaceDormant marks a workspace dormant, or activates a dormant
+// workspace so it can be started again.
+func (api *API) putWorkspaceDormant(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		workspace         = httpmw.WorkspaceParam(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Workspace](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = workspace
+
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateWorkspaceDormancy
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	var updated database.Workspace
+	err := api.Database.InTx(func(s database.Store) error {
+		var dormantAt sql.NullTime
+		if req.Dormant {
+			dormantAt = workspace.DormantAt
+			if !dormantAt.Valid {
+				dormantAt = sql.NullTime{Time: database.Now(), Valid: true}
+			}
+		} else {
+			// Activating a workspace counts as using it, otherwise it would
+			// be marked dormant again right away.
+			err := s.UpdateWorkspaceLastUsedAt(ctx, database.UpdateWorkspaceLastUsedAtParams{
+				ID:         workspace.ID,
+				LastUsedAt: database.Now(),
+			})
+			if err != nil {
+				return xerrors.Errorf("update workspace last used at: %w", err)
+			}
+		}
+
+		var err error
+		updated, err = s.UpdateWorkspaceDormantAt(ctx, database.UpdateWorkspaceDormantAtParams{
+			ID:        workspace.ID,
+			DormantAt: dormantAt,
+		})
+		if err != nil {
+			return xerrors.Errorf("update workspace dormant at: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating workspace dormancy.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = updated
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: "Workspace dormancy updated.",
+	})
+}
+
 func (api *API) watchWorkspace(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspace := httpmw.WorkspaceParam(r)
@@ -1040,6 +1161,14 @@ func convertWorkspace(
 		autostartSchedule = &workspace.AutostartSchedule.String
 	}
 
+	var dormantAt, deletingAt *time.Time
+	if workspace.DormantAt.Valid {
+		dormantAt = &workspace.DormantAt.Time
+		if template.DormantDeleteTtl > 0 {
+			deletingAt = ptr.Ref(workspace.DormantAt.Time.Add(time.Duration(template.DormantDeleteTtl)))
+		}
+	}
+
 	ttlMillis := convertWorkspaceTTLMillis(workspace.Ttl)
 	return codersdk.Workspace{
 		ID:                workspace.ID,
@@ -1056,6 +1185,8 @@ func convertWorkspace(
 		AutostartSchedule: autostartSchedule,
 		TTLMillis:         ttlMillis,
 		LastUsedAt:        workspace.LastUsedAt,
+		DormantAt:         dormantAt,
+		DeletingAt:        deletingAt,
 	}
 }
 
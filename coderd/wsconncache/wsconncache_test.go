@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 	"go.uber.org/goleak"
+	"tailscale.com/tailcfg"
 
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
@@ -221,3 +222,33 @@ func (*client) PostWorkspaceAgentAppHealth(_ context.Context, _ codersdk.PostWor
 func (*client) PostWorkspaceAgentVersion(_ context.Context, _ string) error {
 	return nil
 }
+
+func (*client) PatchStartupLogs(_ context.Context, _ codersdk.PatchStartupLogs) error {
+	return nil
+}
+
+func (*client) PostWorkspaceAgentLifecycle(_ context.Context, _ codersdk.PostWorkspaceAgentLifecycleRequest) error {
+	return nil
+}
+
+func (*client) WaitForWorkspaceAgentShutdown(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (*client) PostWorkspaceAgentMetadata(_ context.Context, _ string, _ codersdk.PostWorkspaceAgentMetadataRequest) error {
+	return nil
+}
+
+func (*client) PostWorkspaceAgentSessionRecording(_ context.Context, typ codersdk.SessionRecordingType, _ uuid.UUID, _ io.Reader) (codersdk.WorkspaceSessionRecording, error) {
+	return codersdk.WorkspaceSessionRecording{Type: typ}, nil
+}
+
+func (*client) WatchWorkspaceAgentDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error) {
+	derpMaps := make(chan *tailcfg.DERPMap)
+	go func() {
+		<-ctx.Done()
+		close(derpMaps)
+	}()
+	return derpMaps, nil
+}
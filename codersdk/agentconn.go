@@ -2,6 +2,8 @@ package codersdk
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -14,9 +16,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 	"tailscale.com/ipn/ipnstate"
+	"tailscale.com/net/netcheck"
 	"tailscale.com/net/speedtest"
 	"tailscale.com/tailcfg"
 
@@ -34,10 +38,13 @@ var (
 	// TailnetStatisticsPort serves a HTTP server with endpoints for gathering
 	// agent statistics.
 	TailnetStatisticsPort = 4
+	// TailnetFilesPort serves a HTTP server with endpoints for transferring
+	// files.
+	TailnetFilesPort = 5
 
 	// MinimumListeningPort is the minimum port that the listening-ports
 	// endpoint will return to the client, and the minimum port that is accepted
-	// by the proxy applications endpoint. Coder consumes ports 1-4 at the
+	// by the proxy applications endpoint. Coder consumes ports 1-5 at the
 	// moment, and we reserve some extra ports for future use. Port 9 and up are
 	// available for the user.
 	//
@@ -133,22 +140,33 @@ type AgentConn struct {
 }
 
 func (c *AgentConn) Ping(ctx context.Context) (time.Duration, error) {
+	pr, err := c.PingResult(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(pr.LatencySeconds * float64(time.Second)), nil
+}
+
+// PingResult pings the agent and returns the full result. Endpoint is set
+// if the pong came over a direct connection, and DERPRegionID if it was
+// relayed through DERP.
+func (c *AgentConn) PingResult(ctx context.Context) (*ipnstate.PingResult, error) {
 	errCh := make(chan error, 1)
-	durCh := make(chan time.Duration, 1)
+	resCh := make(chan *ipnstate.PingResult, 1)
 	go c.Conn.Ping(TailnetIP, tailcfg.PingDisco, func(pr *ipnstate.PingResult) {
 		if pr.Err != "" {
 			errCh <- xerrors.New(pr.Err)
 			return
 		}
-		durCh <- time.Duration(pr.LatencySeconds * float64(time.Second))
+		resCh <- pr
 	})
 	select {
 	case err := <-errCh:
-		return 0, err
+		return nil, err
 	case <-ctx.Done():
-		return 0, ctx.Err()
-	case dur := <-durCh:
-		return dur, nil
+		return nil, ctx.Err()
+	case pr := <-resCh:
+		return pr, nil
 	}
 }
 
@@ -169,19 +187,91 @@ type ReconnectingPTYInit struct {
 	Height  uint16
 	Width   uint16
 	Command string
+	// Resume makes the agent send a ReconnectingPTYAttach header before any
+	// output, and replay buffered output starting at Offset instead of
+	// replaying the whole buffer.
+	Resume bool
+	// Offset is the number of bytes of output the client has already
+	// received. Only used if Resume is set.
+	Offset int64
+	// AttachOnly prevents the agent from starting a new PTY if none exists
+	// with the ID. Clients set it when re-attaching after a disconnect, so
+	// a PTY whose process exited isn't silently replaced.
+	AttachOnly bool
+	// UserID is the user the session is recorded for if the PTY is started.
+	// coderd sets it for the web terminal.
+	UserID uuid.UUID
 }
 
+// ReconnectingPTYAttach is sent by the agent when a client sets
+// ReconnectingPTYInit.Resume.
+// @typescript-ignore ReconnectingPTYAttach
+type ReconnectingPTYAttach struct {
+	// Offset is the position in the PTY's output of the first byte that
+	// follows. If it's greater than the requested offset, output was lost
+	// because it no longer fits in the agent's buffer.
+	Offset int64
+	// Exited is true if no PTY exists with the requested ID, e.g. because
+	// its process exited. The agent closes the connection afterwards.
+	Exited bool
+}
+
+// ReconnectingPTY connects to the reconnecting PTY with the given ID,
+// creating it if it doesn't exist. The buffered output of the PTY is
+// replayed first.
 func (c *AgentConn) ReconnectingPTY(id string, height, width uint16, command string) (net.Conn, error) {
-	conn, err := c.DialContextTCP(context.Background(), netip.AddrPortFrom(TailnetIP, uint16(TailnetReconnectingPTYPort)))
-	if err != nil {
-		return nil, err
-	}
-	data, err := json.Marshal(ReconnectingPTYInit{
+	return c.DialReconnectingPTY(context.Background(), ReconnectingPTYInit{
 		ID:      id,
 		Height:  height,
 		Width:   width,
 		Command: command,
 	})
+}
+
+// AttachReconnectingPTY connects to a reconnecting PTY and resumes its
+// output from init.Offset. Resume is always set.
+func (c *AgentConn) AttachReconnectingPTY(ctx context.Context, init ReconnectingPTYInit) (net.Conn, ReconnectingPTYAttach, error) {
+	init.Resume = true
+	conn, err := c.DialReconnectingPTY(ctx, init)
+	if err != nil {
+		return nil, ReconnectingPTYAttach{}, err
+	}
+	// The header uses the same framing as the init message: a
+	// little-endian uint16 length followed by JSON. A JSON decoder can't
+	// be used because it may consume PTY output.
+	rawLen := make([]byte, 2)
+	_, err = io.ReadFull(conn, rawLen)
+	if err != nil {
+		_ = conn.Close()
+		return nil, ReconnectingPTYAttach{}, xerrors.Errorf("read attach header length: %w", err)
+	}
+	data := make([]byte, binary.LittleEndian.Uint16(rawLen))
+	_, err = io.ReadFull(conn, data)
+	if err != nil {
+		_ = conn.Close()
+		return nil, ReconnectingPTYAttach{}, xerrors.Errorf("read attach header: %w", err)
+	}
+	var attach ReconnectingPTYAttach
+	err = json.Unmarshal(data, &attach)
+	if err != nil {
+		_ = conn.Close()
+		return nil, ReconnectingPTYAttach{}, xerrors.Errorf("decode attach header: %w", err)
+	}
+	if attach.Exited {
+		_ = conn.Close()
+		return nil, attach, nil
+	}
+	return conn, attach, nil
+}
+
+// DialReconnectingPTY connects to a reconnecting PTY with the given init
+// message.
+func (c *AgentConn) DialReconnectingPTY(ctx context.Context, init ReconnectingPTYInit) (net.Conn, error) {
+	conn, err := c.DialContextTCP(ctx, netip.AddrPortFrom(TailnetIP, uint16(TailnetReconnectingPTYPort)))
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(init)
 	if err != nil {
 		_ = conn.Close()
 		return nil, err
@@ -204,11 +294,35 @@ func (c *AgentConn) SSH() (net.Conn, error) {
 // SSHClient calls SSH to create a client that uses a weak cipher
 // for high throughput.
 func (c *AgentConn) SSHClient() (*ssh.Client, error) {
+	return c.sshClient(nil)
+}
+
+// SSHClientWithSigner is like SSHClient, but authenticates with the signer,
+// which agents that require certificates need. See WorkspaceAgentSSHSigner.
+func (c *AgentConn) SSHClientWithSigner(signer ssh.Signer) (*ssh.Client, error) {
+	return c.sshClient([]ssh.AuthMethod{ssh.PublicKeys(signer)})
+}
+
+// SSHClientWithSignerFunc is like SSHClientWithSigner, but only calls
+// getSigner if the agent rejects connecting without one. This avoids
+// fetching a certificate from agents that don't require them.
+func (c *AgentConn) SSHClientWithSignerFunc(getSigner func() (ssh.Signer, error)) (*ssh.Client, error) {
+	return c.sshClient([]ssh.AuthMethod{ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		signer, err := getSigner()
+		if err != nil {
+			return nil, err
+		}
+		return []ssh.Signer{signer}, nil
+	})})
+}
+
+func (c *AgentConn) sshClient(auth []ssh.AuthMethod) (*ssh.Client, error) {
 	netConn, err := c.SSH()
 	if err != nil {
 		return nil, xerrors.Errorf("ssh: %w", err)
 	}
 	sshConn, channels, requests, err := ssh.NewClientConn(netConn, "localhost:22", &ssh.ClientConfig{
+		Auth: auth,
 		// SSH host validation isn't helpful, because obtaining a peer
 		// connection already signifies user-intent to dial a workspace.
 		// #nosec
@@ -220,6 +334,44 @@ func (c *AgentConn) SSHClient() (*ssh.Client, error) {
 	return ssh.NewClient(sshConn, channels, requests), nil
 }
 
+// WorkspaceAgentSSHSigner generates a key and has it certified for
+// connecting to the agent, for clients that don't keep a key of their own.
+func (c *Client) WorkspaceAgentSSHSigner(ctx context.Context, agentID uuid.UUID) (ssh.Signer, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, xerrors.Errorf("generate key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, xerrors.Errorf("create signer: %w", err)
+	}
+	res, err := c.IssueWorkspaceAgentSSHCertificate(ctx, agentID, IssueWorkspaceAgentSSHCertificateRequest{
+		PublicKey: string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("issue certificate: %w", err)
+	}
+	cert, err := ParseSSHCertificate(res.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewCertSigner(cert, signer)
+}
+
+// ParseSSHCertificate parses an authorized_keys encoded certificate, like
+// the one in WorkspaceAgentSSHCertificate.
+func ParseSSHCertificate(raw string) (*ssh.Certificate, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(raw))
+	if err != nil {
+		return nil, xerrors.Errorf("parse certificate: %w", err)
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, xerrors.Errorf("expected a certificate, got a %s key", key.Type())
+	}
+	return cert, nil
+}
+
 func (c *AgentConn) Speedtest(direction speedtest.Direction, duration time.Duration) ([]speedtest.Result, error) {
 	speedConn, err := c.DialContextTCP(context.Background(), netip.AddrPortFrom(TailnetIP, uint16(TailnetSpeedtestPort)))
 	if err != nil {
@@ -246,6 +398,16 @@ func (c *AgentConn) DialContext(ctx context.Context, network string, addr string
 }
 
 func (c *AgentConn) statisticsClient() *http.Client {
+	return c.tailnetHTTPClient("statistics", TailnetStatisticsPort)
+}
+
+func (c *AgentConn) filesClient() *http.Client {
+	return c.tailnetHTTPClient("files", TailnetFilesPort)
+}
+
+// tailnetHTTPClient returns a client for the HTTP server the agent serves
+// on the port.
+func (c *AgentConn) tailnetHTTPClient(name string, port int) *http.Client {
 	return &http.Client{
 		Transport: &http.Transport{
 			// Disable keep alives as we're usually only making a single
@@ -255,19 +417,19 @@ func (c *AgentConn) statisticsClient() *http.Client {
 				if network != "tcp" {
 					return nil, xerrors.Errorf("network must be tcp")
 				}
-				host, port, err := net.SplitHostPort(addr)
+				host, portStr, err := net.SplitHostPort(addr)
 				if err != nil {
 					return nil, xerrors.Errorf("split host port %q: %w", addr, err)
 				}
-				// Verify that host is TailnetIP and port is
-				// TailnetStatisticsPort.
-				if host != TailnetIP.String() || port != strconv.Itoa(TailnetStatisticsPort) {
-					return nil, xerrors.Errorf("request %q does not appear to be for statistics server", addr)
+				// Verify that host is TailnetIP and port is the port of
+				// the server.
+				if host != TailnetIP.String() || portStr != strconv.Itoa(port) {
+					return nil, xerrors.Errorf("request %q does not appear to be for %s server", addr, name)
 				}
 
-				conn, err := c.DialContextTCP(context.Background(), netip.AddrPortFrom(TailnetIP, uint16(TailnetStatisticsPort)))
+				conn, err := c.DialContextTCP(context.Background(), netip.AddrPortFrom(TailnetIP, uint16(port)))
 				if err != nil {
-					return nil, xerrors.Errorf("dial statistics: %w", err)
+					return nil, xerrors.Errorf("dial %s: %w", name, err)
 				}
 
 				return conn, nil
@@ -277,15 +439,26 @@ func (c *AgentConn) statisticsClient() *http.Client {
 }
 
 func (c *AgentConn) doStatisticsRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
-	host := net.JoinHostPort(TailnetIP.String(), strconv.Itoa(TailnetStatisticsPort))
+	req, err := newStatisticsRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.statisticsClient().Do(req)
+}
+
+func newStatisticsRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	return newTailnetHTTPRequest(ctx, "statistics", TailnetStatisticsPort, method, path, body)
+}
+
+func newTailnetHTTPRequest(ctx context.Context, name string, port int, method, path string, body io.Reader) (*http.Request, error) {
+	host := net.JoinHostPort(TailnetIP.String(), strconv.Itoa(port))
 	url := fmt.Sprintf("http://%s%s", host, path)
 
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return nil, xerrors.Errorf("new statistics server request to %q: %w", url, err)
+		return nil, xerrors.Errorf("new %s server request to %q: %w", name, url, err)
 	}
-
-	return c.statisticsClient().Do(req)
+	return req, nil
 }
 
 type ListeningPortsResponse struct {
@@ -308,6 +481,22 @@ type ListeningPort struct {
 	Port        uint16               `json:"port"`
 }
 
+// AgentNetcheck runs a network check from the agent's side of the
+// connection. Use Conn.Netcheck for this side.
+func (c *AgentConn) AgentNetcheck(ctx context.Context) (*netcheck.Report, error) {
+	res, err := c.doStatisticsRequest(ctx, http.MethodGet, "/api/v0/netcheck", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+
+	var report netcheck.Report
+	return &report, json.NewDecoder(res.Body).Decode(&report)
+}
+
 func (c *AgentConn) ListeningPorts(ctx context.Context) (ListeningPortsResponse, error) {
 	res, err := c.doStatisticsRequest(ctx, http.MethodGet, "/api/v0/listening-ports", nil)
 	if err != nil {
@@ -13,14 +13,16 @@ import (
 type APIKey struct {
 	ID string `json:"id" validate:"required"`
 	// NOTE: do not ever return the HashedSecret
-	UserID          uuid.UUID   `json:"user_id" validate:"required"`
-	LastUsed        time.Time   `json:"last_used" validate:"required"`
-	ExpiresAt       time.Time   `json:"expires_at" validate:"required"`
-	CreatedAt       time.Time   `json:"created_at" validate:"required"`
-	UpdatedAt       time.Time   `json:"updated_at" validate:"required"`
-	LoginType       LoginType   `json:"login_type" validate:"required"`
-	Scope           APIKeyScope `json:"scope" validate:"required"`
-	LifetimeSeconds int64       `json:"lifetime_seconds" validate:"required"`
+	UserID    uuid.UUID   `json:"user_id" validate:"required"`
+	LastUsed  time.Time   `json:"last_used" validate:"required"`
+	ExpiresAt time.Time   `json:"expires_at" validate:"required"`
+	CreatedAt time.Time   `json:"created_at" validate:"required"`
+	UpdatedAt time.Time   `json:"updated_at" validate:"required"`
+	LoginType LoginType   `json:"login_type" validate:"required"`
+	Scope     APIKeyScope `json:"scope" validate:"required"`
+	// Scopes are the fine-grained scopes the key is restricted to, if any.
+	Scopes          []string `json:"scopes"`
+	LifetimeSeconds int64    `json:"lifetime_seconds" validate:"required"`
 }
 
 type LoginType string
@@ -41,6 +43,13 @@ const (
 
 type CreateTokenRequest struct {
 	Scope APIKeyScope `json:"scope"`
+	// Scopes restrict the token to fine-grained scopes like "workspace:read"
+	// or "template:push". A scope can be pinned to a single resource by
+	// appending its ID, e.g. "template:push:<template id>".
+	Scopes []string `json:"scopes,omitempty"`
+	// Lifetime is how long the token is valid for. Tokens don't expire if it
+	// is zero, except for tokens of service accounts which must expire.
+	Lifetime time.Duration `json:"lifetime,omitempty"`
 }
 
 // GenerateAPIKeyResponse contains an API key for a user.
@@ -14,15 +14,20 @@ import (
 type ResourceType string
 
 const (
-	ResourceTypeOrganization    ResourceType = "organization"
-	ResourceTypeTemplate        ResourceType = "template"
-	ResourceTypeTemplateVersion ResourceType = "template_version"
-	ResourceTypeUser            ResourceType = "user"
-	ResourceTypeWorkspace       ResourceType = "workspace"
-	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
-	ResourceTypeGitSSHKey       ResourceType = "git_ssh_key"
-	ResourceTypeAPIKey          ResourceType = "api_key"
-	ResourceTypeGroup           ResourceType = "group"
+	ResourceTypeOrganization       ResourceType = "organization"
+	ResourceTypeTemplate           ResourceType = "template"
+	ResourceTypeTemplateVersion    ResourceType = "template_version"
+	ResourceTypeUser               ResourceType = "user"
+	ResourceTypeWorkspace          ResourceType = "workspace"
+	ResourceTypeWorkspaceBuild     ResourceType = "workspace_build"
+	ResourceTypeGitSSHKey          ResourceType = "git_ssh_key"
+	ResourceTypeAPIKey             ResourceType = "api_key"
+	ResourceTypeGroup              ResourceType = "group"
+	ResourceTypeWorkspaceProxy     ResourceType = "workspace_proxy"
+	ResourceTypeRateLimitPolicy    ResourceType = "rate_limit_policy"
+	ResourceTypeSessionRecording   ResourceType = "session_recording"
+	ResourceTypeCustomRole         ResourceType = "custom_role"
+	ResourceTypeOrganizationMember ResourceType = "organization_member"
 )
 
 func (r ResourceType) FriendlyString() string {
@@ -45,6 +50,16 @@ func (r ResourceType) FriendlyString() string {
 		return "api key"
 	case ResourceTypeGroup:
 		return "group"
+	case ResourceTypeWorkspaceProxy:
+		return "workspace proxy"
+	case ResourceTypeRateLimitPolicy:
+		return "rate limit policy"
+	case ResourceTypeSessionRecording:
+		return "session recording"
+	case ResourceTypeCustomRole:
+		return "custom role"
+	case ResourceTypeOrganizationMember:
+		return "organization member"
 	default:
 		return "unknown"
 	}
@@ -127,6 +142,8 @@ type CreateTestAuditLogRequest struct {
 	Action       AuditAction  `json:"action,omitempty"`
 	ResourceType ResourceType `json:"resource_type,omitempty"`
 	ResourceID   uuid.UUID    `json:"resource_id,omitempty"`
+	// OrganizationID is the organization the log belongs to, none if unset.
+	OrganizationID uuid.UUID `json:"organization_id,omitempty"`
 }
 
 // AuditLogs retrieves audit logs from the given page.
@@ -25,6 +25,10 @@ const (
 	SessionCustomHeader = "Coder-Session-Token"
 	OAuth2StateKey      = "oauth_state"
 	OAuth2RedirectKey   = "oauth_redirect"
+	// SignedAppTokenCookie is the name of the cookie that stores a signed
+	// workspace app token on the domain serving the app. The token is scoped
+	// to a single app and expires quickly.
+	SignedAppTokenCookie = "coder_signed_app_token"
 
 	// nolint: gosec
 	BypassRatelimitHeader = "X-Coder-Bypass-Ratelimit"
@@ -67,22 +71,27 @@ func (c *Client) Request(ctx context.Context, method, path string, body interfac
 		return nil, xerrors.Errorf("parse url: %w", err)
 	}
 
-	var buf bytes.Buffer
+	var r io.Reader
 	if body != nil {
-		if data, ok := body.([]byte); ok {
-			buf = *bytes.NewBuffer(data)
-		} else {
-			// Assume JSON if not bytes.
+		switch data := body.(type) {
+		case io.Reader:
+			r = data
+		case []byte:
+			r = bytes.NewReader(data)
+		default:
+			// Assume JSON in any other case.
+			var buf bytes.Buffer
 			enc := json.NewEncoder(&buf)
 			enc.SetEscapeHTML(false)
 			err = enc.Encode(body)
 			if err != nil {
 				return nil, xerrors.Errorf("encode body: %w", err)
 			}
+			r = &buf
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, serverURL.String(), &buf)
+	req, err := http.NewRequestWithContext(ctx, method, serverURL.String(), r)
 	if err != nil {
 		return nil, xerrors.Errorf("create request: %w", err)
 	}
@@ -102,6 +111,13 @@ func (c *Client) Request(ctx context.Context, method, path string, body interfac
 	return resp, err
 }
 
+// ReadBodyAsError reads the response as a codersdk.Response, and wraps it in a
+// codersdk.Error type for easy marshaling. It is exported for clients of other
+// Coder APIs, such as the workspace proxy API.
+func ReadBodyAsError(res *http.Response) error {
+	return readBodyAsError(res)
+}
+
 // readBodyAsError reads the response as an .Message, and
 // wraps it in a codersdk.Error type for easy marshaling.
 func readBodyAsError(res *http.Response) error {
@@ -0,0 +1,144 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/tailnet"
+)
+
+type ConnectionStatSource string
+
+const (
+	// ConnectionStatSourceAgent records are reported by the workspace agent
+	// about the clients connected to it.
+	ConnectionStatSourceAgent ConnectionStatSource = "agent"
+	// ConnectionStatSourceClient records are reported by a client about its
+	// connection to the workspace agent.
+	ConnectionStatSourceClient ConnectionStatSource = "client"
+)
+
+// ConnectionStat describes a tailnet connection to a single peer at the time
+// it was reported.
+type ConnectionStat struct {
+	// PeerIP is the tailnet IP of the other side of the connection.
+	PeerIP string `json:"peer_ip"`
+	// Direct is false if traffic is relayed through DERP.
+	Direct bool `json:"direct"`
+	// Endpoint is the ip:port of the peer for direct connections.
+	Endpoint string `json:"endpoint,omitempty"`
+	// DERPRegionID is the region traffic is relayed through, or the peer's
+	// home region for direct connections. 0 if unknown.
+	DERPRegionID int `json:"derp_region_id"`
+	// LatencyMS is the round trip time of a ping to the peer. 0 if the ping
+	// didn't complete.
+	LatencyMS float64 `json:"latency_ms"`
+	RxBytes   int64   `json:"rx_bytes"`
+	TxBytes   int64   `json:"tx_bytes"`
+}
+
+// ConnectionStatsFromPeers converts the peer state of a tailnet connection
+// into connection records.
+func ConnectionStatsFromPeers(peers []tailnet.PeerStat) []ConnectionStat {
+	stats := make([]ConnectionStat, 0, len(peers))
+	for _, peer := range peers {
+		stats = append(stats, ConnectionStat{
+			PeerIP:       peer.IP.String(),
+			Direct:       peer.Endpoint != "",
+			Endpoint:     peer.Endpoint,
+			DERPRegionID: peer.DERPRegionID,
+			LatencyMS:    float64(peer.Latency) / float64(time.Millisecond),
+			RxBytes:      peer.RxBytes,
+			TxBytes:      peer.TxBytes,
+		})
+	}
+	return stats
+}
+
+// WorkspaceAgentConnectionStat is a connection record stored by coderd.
+type WorkspaceAgentConnectionStat struct {
+	ID             uuid.UUID            `json:"id"`
+	CreatedAt      time.Time            `json:"created_at"`
+	Source         ConnectionStatSource `json:"source"`
+	AgentID        uuid.UUID            `json:"agent_id"`
+	WorkspaceID    uuid.UUID            `json:"workspace_id"`
+	UserID         uuid.UUID            `json:"user_id"`
+	PeerIP         string               `json:"peer_ip"`
+	Direct         bool                 `json:"direct"`
+	Endpoint       string               `json:"endpoint,omitempty"`
+	DERPRegionID   int                  `json:"derp_region_id"`
+	DERPRegionName string               `json:"derp_region_name"`
+	LatencyMS      float64              `json:"latency_ms"`
+	RxBytes        int64                `json:"rx_bytes"`
+	TxBytes        int64                `json:"tx_bytes"`
+}
+
+// PostWorkspaceAgentConnectionStatsRequest reports the connections of a
+// client to a workspace agent.
+type PostWorkspaceAgentConnectionStatsRequest struct {
+	Connections []ConnectionStat `json:"connections"`
+}
+
+// ConnectionStatsRegion summarizes the connections seen in a DERP region.
+type ConnectionStatsRegion struct {
+	DERPRegionID   int                  `json:"derp_region_id"`
+	DERPRegionName string               `json:"derp_region_name"`
+	Source         ConnectionStatSource `json:"source"`
+	// Connections is the number of distinct connections reported.
+	Connections int64 `json:"connections"`
+	// DirectConnections is the number of connections that were direct in
+	// any of their reports. A region where this is always 0 likely has
+	// users behind firewalls that block UDP.
+	DirectConnections int64   `json:"direct_connections"`
+	AverageLatencyMS  float64 `json:"average_latency_ms"`
+}
+
+// PostWorkspaceAgentConnectionStats reports the connections of this client to
+// a workspace agent.
+func (c *Client) PostWorkspaceAgentConnectionStats(ctx context.Context, agentID uuid.UUID, req PostWorkspaceAgentConnectionStatsRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/%s/connection-stats", agentID), req)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WorkspaceAgentConnectionStats returns the connection records reported by
+// and about a workspace agent in the past day, newest first.
+func (c *Client) WorkspaceAgentConnectionStats(ctx context.Context, agentID uuid.UUID) ([]WorkspaceAgentConnectionStat, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/connection-stats", agentID), nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var stats []WorkspaceAgentConnectionStat
+	return stats, json.NewDecoder(res.Body).Decode(&stats)
+}
+
+// ConnectionStatsByRegion summarizes the connections of all workspaces in the
+// past day by DERP region.
+func (c *Client) ConnectionStatsByRegion(ctx context.Context) ([]ConnectionStatsRegion, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/connectionstats/regions", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var regions []ConnectionStatsRegion
+	return regions, json.NewDecoder(res.Body).Decode(&regions)
+}
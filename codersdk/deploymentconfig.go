@@ -32,6 +32,8 @@ type DeploymentConfig struct {
 	Trace                       *TraceConfig                            `json:"trace" typescript:",notnull"`
 	SecureAuthCookie            *DeploymentConfigField[bool]            `json:"secure_auth_cookie" typescript:",notnull"`
 	SSHKeygenAlgorithm          *DeploymentConfigField[string]          `json:"ssh_keygen_algorithm" typescript:",notnull"`
+	SSHCertificateTTL           *DeploymentConfigField[time.Duration]   `json:"ssh_certificate_ttl" typescript:",notnull"`
+	SSHRequireCertificates      *DeploymentConfigField[bool]            `json:"ssh_require_certificates" typescript:",notnull"`
 	AutoImportTemplates         *DeploymentConfigField[[]string]        `json:"auto_import_templates" typescript:",notnull"`
 	MetricsCacheRefreshInterval *DeploymentConfigField[time.Duration]   `json:"metrics_cache_refresh_interval" typescript:",notnull"`
 	AgentStatRefreshInterval    *DeploymentConfigField[time.Duration]   `json:"agent_stat_refresh_interval" typescript:",notnull"`
@@ -39,6 +41,8 @@ type DeploymentConfig struct {
 	BrowserOnly                 *DeploymentConfigField[bool]            `json:"browser_only" typescript:",notnull"`
 	SCIMAPIKey                  *DeploymentConfigField[string]          `json:"scim_api_key" typescript:",notnull"`
 	UserWorkspaceQuota          *DeploymentConfigField[int]             `json:"user_workspace_quota" typescript:",notnull"`
+	ProvisionerDaemonPSK        *DeploymentConfigField[string]          `json:"provisioner_daemon_psk" typescript:",notnull"`
+	MaxServiceTokenLifetime     *DeploymentConfigField[time.Duration]   `json:"max_service_account_token_lifetime" typescript:",notnull"`
 }
 
 type DERP struct {
@@ -56,8 +60,9 @@ type DERPServerConfig struct {
 }
 
 type DERPConfig struct {
-	URL  *DeploymentConfigField[string] `json:"url" typescript:",notnull"`
-	Path *DeploymentConfigField[string] `json:"path" typescript:",notnull"`
+	URL             *DeploymentConfigField[string]        `json:"url" typescript:",notnull"`
+	Path            *DeploymentConfigField[string]        `json:"path" typescript:",notnull"`
+	RefreshInterval *DeploymentConfigField[time.Duration] `json:"refresh_interval" typescript:",notnull"`
 }
 
 type PrometheusConfig struct {
@@ -84,12 +89,16 @@ type OAuth2GithubConfig struct {
 }
 
 type OIDCConfig struct {
-	AllowSignups *DeploymentConfigField[bool]     `json:"allow_signups" typescript:",notnull"`
-	ClientID     *DeploymentConfigField[string]   `json:"client_id" typescript:",notnull"`
-	ClientSecret *DeploymentConfigField[string]   `json:"client_secret" typescript:",notnull"`
-	EmailDomain  *DeploymentConfigField[string]   `json:"email_domain" typescript:",notnull"`
-	IssuerURL    *DeploymentConfigField[string]   `json:"issuer_url" typescript:",notnull"`
-	Scopes       *DeploymentConfigField[[]string] `json:"scopes" typescript:",notnull"`
+	AllowSignups    *DeploymentConfigField[bool]     `json:"allow_signups" typescript:",notnull"`
+	ClientID        *DeploymentConfigField[string]   `json:"client_id" typescript:",notnull"`
+	ClientSecret    *DeploymentConfigField[string]   `json:"client_secret" typescript:",notnull"`
+	EmailDomain     *DeploymentConfigField[string]   `json:"email_domain" typescript:",notnull"`
+	GroupField      *DeploymentConfigField[string]   `json:"group_field" typescript:",notnull"`
+	GroupMapping    *DeploymentConfigField[string]   `json:"group_mapping" typescript:",notnull"`
+	IssuerURL       *DeploymentConfigField[string]   `json:"issuer_url" typescript:",notnull"`
+	Scopes          *DeploymentConfigField[[]string] `json:"scopes" typescript:",notnull"`
+	UserRoleField   *DeploymentConfigField[string]   `json:"user_role_field" typescript:",notnull"`
+	UserRoleMapping *DeploymentConfigField[string]   `json:"user_role_mapping" typescript:",notnull"`
 }
 
 type TelemetryConfig struct {
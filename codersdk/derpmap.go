@@ -0,0 +1,126 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"golang.org/x/xerrors"
+	"tailscale.com/tailcfg"
+)
+
+type DERPRegionStatus string
+
+const (
+	// DERPRegionStatusHealthy regions passed all of their checks.
+	DERPRegionStatusHealthy DERPRegionStatus = "healthy"
+	// DERPRegionStatusDegraded regions have some failing nodes. They're kept
+	// in the DERP map, but clients avoid picking them as their home region.
+	DERPRegionStatusDegraded DERPRegionStatus = "degraded"
+	// DERPRegionStatusUnhealthy regions have no working relay. They're
+	// removed from the DERP map until they recover.
+	DERPRegionStatusUnhealthy DERPRegionStatus = "unhealthy"
+)
+
+// DERPRegionHealth is the result of the last health check of a DERP region.
+type DERPRegionHealth struct {
+	RegionID   int              `json:"region_id"`
+	RegionCode string           `json:"region_code"`
+	RegionName string           `json:"region_name"`
+	Status     DERPRegionStatus `json:"status"`
+	// LatencyMS is the lowest latency of the region's working relays as seen
+	// from coderd.
+	LatencyMS float64          `json:"latency_ms"`
+	Nodes     []DERPNodeHealth `json:"nodes"`
+	CheckedAt time.Time        `json:"checked_at" format:"date-time"`
+}
+
+// DERPNodeHealth is the result of the last health check of a DERP node.
+type DERPNodeHealth struct {
+	Name     string `json:"name"`
+	HostName string `json:"host_name"`
+	STUNOnly bool   `json:"stun_only"`
+	// DERPLatencyMS is the time an HTTP request to the relay took, including
+	// the TLS handshake. 0 for STUN-only nodes.
+	DERPLatencyMS float64 `json:"derp_latency_ms"`
+	// STUNLatencyMS is the round trip time of a STUN binding request. 0 if
+	// the node has STUN disabled.
+	STUNLatencyMS float64 `json:"stun_latency_ms"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// DERPMapHealth returns the health of each DERP region the deployment knows
+// about, including regions that were removed from the DERP map.
+func (c *Client) DERPMapHealth(ctx context.Context) ([]DERPRegionHealth, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/derp-map/health", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var health []DERPRegionHealth
+	return health, json.NewDecoder(res.Body).Decode(&health)
+}
+
+// WatchDERPMap streams the DERP map clients should use. The current map is
+// sent first, then the map is sent again whenever it changes. The channel is
+// closed when the stream ends.
+func (c *Client) WatchDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error) {
+	return c.watchDERPMap(ctx, "/api/v2/derp-map")
+}
+
+// WatchWorkspaceAgentDERPMap is like WatchDERPMap, but authenticates as a
+// workspace agent.
+func (c *Client) WatchWorkspaceAgentDERPMap(ctx context.Context) (<-chan *tailcfg.DERPMap, error) {
+	return c.watchDERPMap(ctx, "/api/v2/workspaceagents/me/derp-map")
+}
+
+func (c *Client) watchDERPMap(ctx context.Context, path string) (<-chan *tailcfg.DERPMap, error) {
+	//nolint:bodyclose
+	res, err := c.Request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, readBodyAsError(res)
+	}
+	nextEvent := ServerSentEventReader(res.Body)
+
+	derpMaps := make(chan *tailcfg.DERPMap, 1)
+	go func() {
+		defer close(derpMaps)
+		defer res.Body.Close()
+
+		for {
+			sse, err := nextEvent()
+			if err != nil {
+				return
+			}
+			if sse.Type == ServerSentEventTypeError {
+				return
+			}
+			if sse.Type != ServerSentEventTypeData {
+				continue
+			}
+			b, ok := sse.Data.([]byte)
+			if !ok {
+				return
+			}
+			var derpMap tailcfg.DERPMap
+			err = json.Unmarshal(b, &derpMap)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case derpMaps <- &derpMap:
+			}
+		}
+	}()
+	return derpMaps, nil
+}
@@ -15,14 +15,16 @@ const (
 )
 
 const (
-	FeatureUserLimit        = "user_limit"
-	FeatureAuditLog         = "audit_log"
-	FeatureBrowserOnly      = "browser_only"
-	FeatureSCIM             = "scim"
-	FeatureWorkspaceQuota   = "workspace_quota"
-	FeatureTemplateRBAC     = "template_rbac"
-	FeatureHighAvailability = "high_availability"
-	FeatureMultipleGitAuth  = "multiple_git_auth"
+	FeatureUserLimit                  = "user_limit"
+	FeatureAuditLog                   = "audit_log"
+	FeatureBrowserOnly                = "browser_only"
+	FeatureSCIM                       = "scim"
+	FeatureWorkspaceQuota             = "workspace_quota"
+	FeatureTemplateRBAC               = "template_rbac"
+	FeatureHighAvailability           = "high_availability"
+	FeatureMultipleGitAuth            = "multiple_git_auth"
+	FeatureExternalProvisionerDaemons = "external_provisioner_daemons"
+	FeatureWorkspaceProxy             = "workspace_proxy"
 )
 
 var FeatureNames = []string{
@@ -34,6 +36,8 @@ var FeatureNames = []string{
 	FeatureTemplateRBAC,
 	FeatureHighAvailability,
 	FeatureMultipleGitAuth,
+	FeatureExternalProvisionerDaemons,
+	FeatureWorkspaceProxy,
 }
 
 type Feature struct {
@@ -30,6 +30,16 @@ type Organization struct {
 	Name      string    `json:"name" validate:"required"`
 	CreatedAt time.Time `json:"created_at" validate:"required"`
 	UpdatedAt time.Time `json:"updated_at" validate:"required"`
+	// UserWorkspaceQuota is the maximum number of workspaces each member can
+	// own in the organization. 0 uses the deployment wide quota.
+	UserWorkspaceQuota int `json:"user_workspace_quota"`
+}
+
+// UpdateOrganizationRequest updates an organization. Omitted fields are
+// left unchanged.
+type UpdateOrganizationRequest struct {
+	Name               string `json:"name,omitempty" validate:"omitempty,username"`
+	UserWorkspaceQuota *int   `json:"user_workspace_quota,omitempty" validate:"omitempty,min=0"`
 }
 
 // CreateTemplateVersionRequest enables callers to create a new Template Version.
@@ -44,6 +54,10 @@ type CreateTemplateVersionRequest struct {
 	// ParameterValues allows for additional parameters to be provided
 	// during the dry-run provision stage.
 	ParameterValues []CreateParameterRequest `json:"parameter_values,omitempty"`
+	// ProvisionerTags restricts the jobs for this version, and for
+	// workspaces built from it, to provisioner daemons that have all
+	// of these tags.
+	ProvisionerTags map[string]string `json:"tags,omitempty"`
 }
 
 // CreateTemplateRequest provides options when creating a template.
@@ -85,6 +99,9 @@ type CreateWorkspaceRequest struct {
 	// ParameterValues allows for additional parameters to be provided
 	// during the initial provision.
 	ParameterValues []CreateParameterRequest `json:"parameter_values,omitempty"`
+	// RichParameterValues are values for the template's coder_parameter
+	// data sources. Parameters that are omitted use their default value.
+	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values,omitempty"`
 }
 
 func (c *Client) Organization(ctx context.Context, id uuid.UUID) (Organization, error) {
@@ -102,6 +119,22 @@ func (c *Client) Organization(ctx context.Context, id uuid.UUID) (Organization,
 	return organization, json.NewDecoder(res.Body).Decode(&organization)
 }
 
+// UpdateOrganization updates the name or workspace quota of an organization.
+func (c *Client) UpdateOrganization(ctx context.Context, id uuid.UUID, req UpdateOrganizationRequest) (Organization, error) {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/organizations/%s", id.String()), req)
+	if err != nil {
+		return Organization{}, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Organization{}, readBodyAsError(res)
+	}
+
+	var organization Organization
+	return organization, json.NewDecoder(res.Body).Decode(&organization)
+}
+
 // ProvisionerDaemonsByOrganization returns provisioner daemons available for an organization.
 func (c *Client) ProvisionerDaemons(ctx context.Context) ([]ProvisionerDaemon, error) {
 	res, err := c.Request(ctx, http.MethodGet,
@@ -13,10 +13,18 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/yamux"
 	"golang.org/x/xerrors"
 	"nhooyr.io/websocket"
+
+	"github.com/coder/coder/provisionerd/proto"
+	"github.com/coder/coder/provisionersdk"
 )
 
+// ProvisionerDaemonPSKHeader is the header external provisioner daemons
+// use to authenticate with the pre-shared key configured on coderd.
+const ProvisionerDaemonPSKHeader = "Coder-Provisioner-Daemon-PSK"
+
 type LogSource string
 
 const (
@@ -40,6 +48,10 @@ type ProvisionerDaemon struct {
 	UpdatedAt    sql.NullTime      `json:"updated_at"`
 	Name         string            `json:"name"`
 	Provisioners []ProvisionerType `json:"provisioners"`
+	Tags         map[string]string `json:"tags"`
+	// OrganizationID is set if the daemon only acquires jobs of the
+	// organization.
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
 }
 
 // ProvisionerJobStatus represents the at-time state of a job.
@@ -73,6 +85,7 @@ type ProvisionerJob struct {
 	Status      ProvisionerJobStatus `json:"status"`
 	WorkerID    *uuid.UUID           `json:"worker_id,omitempty"`
 	FileID      uuid.UUID            `json:"file_id"`
+	Tags        map[string]string    `json:"tags"`
 }
 
 type ProvisionerJobLog struct {
@@ -162,3 +175,57 @@ func (c *Client) provisionerJobLogsAfter(ctx context.Context, path string, after
 		return nil
 	}), nil
 }
+
+// ServeProvisionerDaemon returns the gRPC service for a provisioner daemon
+// implementation. The daemon authenticates with the pre-shared key provided,
+// or with the session token of the client if it's empty, and registers the
+// provisioner types it supports. If organization is the name or ID of an
+// organization, the daemon only acquires its jobs.
+func (c *Client) ServeProvisionerDaemon(ctx context.Context, provisioners []ProvisionerType, tags map[string]string, organization string, preSharedKey string) (proto.DRPCProvisionerDaemonClient, error) {
+	serverURL, err := c.URL.Parse("/api/v2/provisionerdaemons/serve")
+	if err != nil {
+		return nil, xerrors.Errorf("parse url: %w", err)
+	}
+	query := serverURL.Query()
+	for _, provisioner := range provisioners {
+		query.Add("provisioner", string(provisioner))
+	}
+	for key, value := range tags {
+		query.Add("tag", fmt.Sprintf("%s=%s", key, value))
+	}
+	if organization != "" {
+		query.Set("organization", organization)
+	}
+	serverURL.RawQuery = query.Encode()
+	httpClient := &http.Client{
+		Transport: c.HTTPClient.Transport,
+	}
+	headers := http.Header{}
+	if preSharedKey != "" {
+		headers.Set(ProvisionerDaemonPSKHeader, preSharedKey)
+	} else {
+		headers.Set(SessionCustomHeader, c.SessionToken)
+	}
+	conn, res, err := websocket.Dial(ctx, serverURL.String(), &websocket.DialOptions{
+		HTTPClient: httpClient,
+		HTTPHeader: headers,
+		// Need to disable compression to avoid a data-race.
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, err
+		}
+		return nil, readBodyAsError(res)
+	}
+	// Align with the frame size of yamux.
+	conn.SetReadLimit(256 * 1024)
+
+	config := yamux.DefaultConfig()
+	config.LogOutput = io.Discard
+	session, err := yamux.Client(websocket.NetConn(ctx, conn, websocket.MessageBinary), config)
+	if err != nil {
+		return nil, xerrors.Errorf("multiplex client: %w", err)
+	}
+	return proto.NewDRPCProvisionerDaemonClient(provisionersdk.Conn(session)), nil
+}
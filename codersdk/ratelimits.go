@@ -0,0 +1,97 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+type RateLimitSubjectType string
+
+const (
+	// RateLimitSubjectTypeRole applies the policy to every user with the
+	// named site role, e.g. "template-admin".
+	RateLimitSubjectTypeRole RateLimitSubjectType = "role"
+	// RateLimitSubjectTypeScope applies the policy to requests made with an
+	// API key of the named scope, e.g. "application_connect".
+	RateLimitSubjectTypeScope RateLimitSubjectType = "scope"
+	// RateLimitSubjectTypeUser applies the policy to a single user. The
+	// subject is the user's ID.
+	RateLimitSubjectTypeUser RateLimitSubjectType = "user"
+)
+
+// RateLimitPolicy overrides the deployment-wide API rate limit for a role,
+// API key scope or user. A user policy takes precedence over a scope policy,
+// which takes precedence over role policies. If a user has several roles
+// with policies, the most permissive one applies.
+type RateLimitPolicy struct {
+	ID          uuid.UUID            `json:"id"`
+	SubjectType RateLimitSubjectType `json:"subject_type"`
+	Subject     string               `json:"subject"`
+	// Count is the number of requests allowed per endpoint in each window.
+	// -1 disables rate limiting.
+	Count         int32     `json:"count"`
+	WindowSeconds int32     `json:"window_seconds"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// UpsertRateLimitPolicyRequest creates the policy for a subject, or replaces
+// it if one already exists.
+type UpsertRateLimitPolicyRequest struct {
+	SubjectType   RateLimitSubjectType `json:"subject_type" validate:"required,oneof=role scope user"`
+	Subject       string               `json:"subject" validate:"required"`
+	Count         int32                `json:"count" validate:"required"`
+	WindowSeconds int32                `json:"window_seconds" validate:"required,gt=0"`
+}
+
+// RateLimitPolicies lists all rate limit policies.
+func (c *Client) RateLimitPolicies(ctx context.Context) ([]RateLimitPolicy, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/ratelimits", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var policies []RateLimitPolicy
+	return policies, json.NewDecoder(res.Body).Decode(&policies)
+}
+
+// UpsertRateLimitPolicy creates or updates the rate limit policy for a
+// subject.
+func (c *Client) UpsertRateLimitPolicy(ctx context.Context, req UpsertRateLimitPolicyRequest) (RateLimitPolicy, error) {
+	res, err := c.Request(ctx, http.MethodPut, "/api/v2/ratelimits", req)
+	if err != nil {
+		return RateLimitPolicy{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return RateLimitPolicy{}, readBodyAsError(res)
+	}
+	var policy RateLimitPolicy
+	return policy, json.NewDecoder(res.Body).Decode(&policy)
+}
+
+// DeleteRateLimitPolicy deletes a rate limit policy. Its subject falls back
+// to the next matching policy, or the deployment-wide limit.
+func (c *Client) DeleteRateLimitPolicy(ctx context.Context, id uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/ratelimits/%s", id), nil)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package codersdk
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/xerrors"
+)
+
+// ValidateWorkspaceBuildParameter checks that value is acceptable for the
+// template version parameter. An empty value is only valid for parameters
+// that aren't required.
+func ValidateWorkspaceBuildParameter(param TemplateVersionParameter, value string) error {
+	if value == "" {
+		if param.Required {
+			return xerrors.Errorf("parameter %q is required", param.Name)
+		}
+		return nil
+	}
+
+	switch param.Type {
+	case "number":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return xerrors.Errorf("parameter %q must be a number: %q", param.Name, value)
+		}
+		if param.ValidationMin != nil && number < float64(*param.ValidationMin) {
+			return xerrors.Errorf("parameter %q must be at least %d", param.Name, *param.ValidationMin)
+		}
+		if param.ValidationMax != nil && number > float64(*param.ValidationMax) {
+			return xerrors.Errorf("parameter %q must be at most %d", param.Name, *param.ValidationMax)
+		}
+	case "bool":
+		if value != "true" && value != "false" {
+			return xerrors.Errorf("parameter %q must be \"true\" or \"false\": %q", param.Name, value)
+		}
+	case "list(string)":
+		var list []string
+		err := json.Unmarshal([]byte(value), &list)
+		if err != nil {
+			return xerrors.Errorf("parameter %q must be a JSON list of strings: %w", param.Name, err)
+		}
+	default:
+		if param.ValidationRegex != "" {
+			regex, err := regexp.Compile(param.ValidationRegex)
+			if err != nil {
+				return xerrors.Errorf("compile validation regex for %q: %w", param.Name, err)
+			}
+			if !regex.MatchString(value) {
+				if param.ValidationError != "" {
+					return xerrors.Errorf("parameter %q: %s", param.Name, param.ValidationError)
+				}
+				return xerrors.Errorf("parameter %q must match %q", param.Name, param.ValidationRegex)
+			}
+		}
+	}
+
+	if len(param.Options) > 0 {
+		for _, option := range param.Options {
+			if option.Value == value {
+				return nil
+			}
+		}
+		return xerrors.Errorf("parameter %q must be one of the options: %q", param.Name, value)
+	}
+	return nil
+}
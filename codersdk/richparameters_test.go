@@ -0,0 +1,109 @@
+package codersdk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func TestValidateWorkspaceBuildParameter(t *testing.T) {
+	t.Parallel()
+
+	minimum, maximum := int32(1), int32(8)
+	testCases := []struct {
+		Name      string
+		Parameter codersdk.TemplateVersionParameter
+		Value     string
+		Valid     bool
+	}{
+		{
+			Name:      "Required",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "string", Required: true},
+			Value:     "",
+		},
+		{
+			Name:      "Optional",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "string"},
+			Value:     "",
+			Valid:     true,
+		},
+		{
+			Name:      "NumberInRange",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "number", ValidationMin: &minimum, ValidationMax: &maximum},
+			Value:     "4",
+			Valid:     true,
+		},
+		{
+			Name:      "NumberOutOfRange",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "number", ValidationMin: &minimum, ValidationMax: &maximum},
+			Value:     "9",
+		},
+		{
+			Name:      "NotANumber",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "number"},
+			Value:     "four",
+		},
+		{
+			Name:      "Bool",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "bool"},
+			Value:     "true",
+			Valid:     true,
+		},
+		{
+			Name:      "NotABool",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "bool"},
+			Value:     "yes",
+		},
+		{
+			Name:      "List",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "list(string)"},
+			Value:     `["a","b"]`,
+			Valid:     true,
+		},
+		{
+			Name:      "NotAList",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "list(string)"},
+			Value:     "a,b",
+		},
+		{
+			Name:      "RegexMatch",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "string", ValidationRegex: "^[a-z]+$"},
+			Value:     "abc",
+			Valid:     true,
+		},
+		{
+			Name:      "RegexMismatch",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "string", ValidationRegex: "^[a-z]+$"},
+			Value:     "ABC",
+		},
+		{
+			Name: "Option",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "string", Options: []codersdk.TemplateVersionParameterOption{
+				{Name: "Europe", Value: "eu"},
+			}},
+			Value: "eu",
+			Valid: true,
+		},
+		{
+			Name: "NotAnOption",
+			Parameter: codersdk.TemplateVersionParameter{Name: "p", Type: "string", Options: []codersdk.TemplateVersionParameterOption{
+				{Name: "Europe", Value: "eu"},
+			}},
+			Value: "us",
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.Name, func(t *testing.T) {
+			t.Parallel()
+			err := codersdk.ValidateWorkspaceBuildParameter(testCase.Parameter, testCase.Value)
+			if testCase.Valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
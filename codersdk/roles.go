@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 )
 
 type Role struct {
@@ -46,3 +48,114 @@ func (c *Client) ListOrganizationRoles(ctx context.Context, org uuid.UUID) ([]As
 	var roles []AssignableRoles
 	return roles, json.NewDecoder(res.Body).Decode(&roles)
 }
+
+// Permission allows or, if negated, denies an action on a type of resource,
+// e.g. "create" on "template". Both can be "*" to match all.
+type Permission struct {
+	Negate       bool   `json:"negate"`
+	ResourceType string `json:"resource_type"`
+	Action       string `json:"action"`
+}
+
+// CustomRole is a role defined by an administrator. Site wide roles grant
+// site and user permissions. Organization roles only grant permissions in
+// their organization, and are assigned as "name:organization_id".
+type CustomRole struct {
+	ID              uuid.UUID    `json:"id"`
+	Name            string       `json:"name"`
+	DisplayName     string       `json:"display_name"`
+	OrganizationID  *uuid.UUID   `json:"organization_id,omitempty"`
+	SitePermissions []Permission `json:"site_permissions"`
+	OrgPermissions  []Permission `json:"org_permissions"`
+	// UserPermissions apply to resources owned by the user with the role.
+	UserPermissions []Permission `json:"user_permissions"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+type CreateCustomRoleRequest struct {
+	Name            string       `json:"name" validate:"required,username"`
+	DisplayName     string       `json:"display_name" validate:"required"`
+	SitePermissions []Permission `json:"site_permissions"`
+	OrgPermissions  []Permission `json:"org_permissions"`
+	UserPermissions []Permission `json:"user_permissions"`
+}
+
+// UpdateCustomRoleRequest replaces the display name and all permissions of
+// a custom role.
+type UpdateCustomRoleRequest struct {
+	DisplayName     string       `json:"display_name" validate:"required"`
+	SitePermissions []Permission `json:"site_permissions"`
+	OrgPermissions  []Permission `json:"org_permissions"`
+	UserPermissions []Permission `json:"user_permissions"`
+}
+
+// customRolesPath returns the path of the site wide custom roles, or those
+// of an organization if organizationID isn't uuid.Nil.
+func customRolesPath(organizationID uuid.UUID) string {
+	if organizationID == uuid.Nil {
+		return "/api/v2/roles"
+	}
+	return fmt.Sprintf("/api/v2/organizations/%s/roles", organizationID)
+}
+
+// CustomRoles lists the custom roles of the site, or of an organization if
+// organizationID isn't uuid.Nil.
+func (c *Client) CustomRoles(ctx context.Context, organizationID uuid.UUID) ([]CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodGet, customRolesPath(organizationID), nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var roles []CustomRole
+	return roles, json.NewDecoder(res.Body).Decode(&roles)
+}
+
+// CreateCustomRole creates a site wide custom role, or one in an
+// organization if organizationID isn't uuid.Nil.
+func (c *Client) CreateCustomRole(ctx context.Context, organizationID uuid.UUID, req CreateCustomRoleRequest) (CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodPost, customRolesPath(organizationID), req)
+	if err != nil {
+		return CustomRole{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return CustomRole{}, readBodyAsError(res)
+	}
+	var role CustomRole
+	return role, json.NewDecoder(res.Body).Decode(&role)
+}
+
+// UpdateCustomRole replaces the permissions of a custom role.
+func (c *Client) UpdateCustomRole(ctx context.Context, organizationID uuid.UUID, name string, req UpdateCustomRoleRequest) (CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("%s/%s", customRolesPath(organizationID), name), req)
+	if err != nil {
+		return CustomRole{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return CustomRole{}, readBodyAsError(res)
+	}
+	var role CustomRole
+	return role, json.NewDecoder(res.Body).Decode(&role)
+}
+
+// DeleteCustomRole deletes a custom role and unassigns it from all users.
+func (c *Client) DeleteCustomRole(ctx context.Context, organizationID uuid.UUID, name string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("%s/%s", customRolesPath(organizationID), name), nil)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
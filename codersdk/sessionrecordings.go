@@ -0,0 +1,99 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+type SessionRecordingType string
+
+const (
+	// SessionRecordingTypeSSH recordings are of SSH sessions with a PTY, e.g.
+	// from "coder ssh" or OpenSSH.
+	SessionRecordingTypeSSH SessionRecordingType = "ssh"
+	// SessionRecordingTypeReconnectingPTY recordings are of reconnecting
+	// PTYs, e.g. the web terminal or "coder ssh --session".
+	SessionRecordingTypeReconnectingPTY SessionRecordingType = "reconnecting_pty"
+)
+
+// SessionRecordingMaxSize is the largest recording coderd accepts, the
+// same as the limit of uploaded files. Agents split longer sessions into
+// several recordings.
+const SessionRecordingMaxSize = 10 * (10 << 20)
+
+// WorkspaceSessionRecording is a terminal session on a workspace agent that
+// was recorded because the template has session recording enabled.
+type WorkspaceSessionRecording struct {
+	ID          uuid.UUID            `json:"id"`
+	CreatedAt   time.Time            `json:"created_at"`
+	WorkspaceID uuid.UUID            `json:"workspace_id"`
+	AgentID     uuid.UUID            `json:"agent_id"`
+	Type        SessionRecordingType `json:"type"`
+	StartedAt   time.Time            `json:"started_at"`
+	EndedAt     time.Time            `json:"ended_at"`
+	// UserID is the user who connected to the session, or the workspace
+	// owner if the agent couldn't tell who it was.
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// WorkspaceSessionRecordings returns the recorded sessions of a workspace,
+// newest first.
+func (c *Client) WorkspaceSessionRecordings(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceSessionRecording, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/session-recordings", workspaceID), nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var recordings []WorkspaceSessionRecording
+	return recordings, json.NewDecoder(res.Body).Decode(&recordings)
+}
+
+// SessionRecording returns the asciicast v2 recording of a session.
+func (c *Client) SessionRecording(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/sessionrecordings/%s", id), nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, xerrors.Errorf("read body: %w", err)
+	}
+	return data, nil
+}
+
+// PostWorkspaceAgentSessionRecording uploads the asciicast v2 recording of a
+// terminal session that ended. The session is attributed to userID, or to
+// the workspace owner if it's uuid.Nil.
+func (c *Client) PostWorkspaceAgentSessionRecording(ctx context.Context, typ SessionRecordingType, userID uuid.UUID, recording io.Reader) (WorkspaceSessionRecording, error) {
+	query := url.Values{"type": []string{string(typ)}}
+	if userID != uuid.Nil {
+		query.Set("user_id", userID.String())
+	}
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/session-recordings?"+query.Encode(), recording, func(r *http.Request) {
+		r.Header.Set("Content-Type", "application/x-asciicast")
+	})
+	if err != nil {
+		return WorkspaceSessionRecording{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return WorkspaceSessionRecording{}, readBodyAsError(res)
+	}
+	var resp WorkspaceSessionRecording
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
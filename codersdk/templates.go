@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,8 +31,25 @@ type Template struct {
 	Icon                       string                 `json:"icon"`
 	MaxTTLMillis               int64                  `json:"max_ttl_ms"`
 	MinAutostartIntervalMillis int64                  `json:"min_autostart_interval_ms"`
-	CreatedByID                uuid.UUID              `json:"created_by_id"`
-	CreatedByName              string                 `json:"created_by_name"`
+	// AutostopRequirementDays is the maximum number of days workspaces
+	// created from this template may run before they are stopped during
+	// their owner's quiet hours. Zero means there is no requirement.
+	AutostopRequirementDays int64 `json:"autostop_requirement_days"`
+	// InactivityTTLMillis is how long workspaces created from this template
+	// may go unused before they are marked dormant. Zero disables dormancy.
+	InactivityTTLMillis int64 `json:"inactivity_ttl_ms"`
+	// DormantDeleteTTLMillis is how long workspaces may be dormant before
+	// they are deleted. Zero disables automatic deletion.
+	DormantDeleteTTLMillis int64 `json:"dormant_delete_ttl_ms"`
+	// PortForwardingAllowlist is the ports and port ranges, e.g. "5432" or
+	// "8000-8100", that may be forwarded with SSH -L and -R or over tailnet,
+	// e.g. by "coder port-forward" and apps. Empty allows all ports.
+	PortForwardingAllowlist []string `json:"port_forwarding_allowlist"`
+	// RecordSessions is whether terminal sessions on workspaces created from
+	// this template are recorded.
+	RecordSessions bool      `json:"record_sessions"`
+	CreatedByID    uuid.UUID `json:"created_by_id"`
+	CreatedByName  string    `json:"created_by_name"`
 }
 
 type TemplateBuildTimeStats struct {
@@ -77,6 +96,54 @@ type UpdateTemplateMeta struct {
 	Icon                       string `json:"icon,omitempty"`
 	MaxTTLMillis               int64  `json:"max_ttl_ms,omitempty"`
 	MinAutostartIntervalMillis int64  `json:"min_autostart_interval_ms,omitempty"`
+	AutostopRequirementDays    int64  `json:"autostop_requirement_days,omitempty"`
+	InactivityTTLMillis        int64  `json:"inactivity_ttl_ms,omitempty"`
+	DormantDeleteTTLMillis     int64  `json:"dormant_delete_ttl_ms,omitempty"`
+	// PortForwardingAllowlist replaces the allowlist of the template. It's
+	// cleared when omitted.
+	PortForwardingAllowlist []string `json:"port_forwarding_allowlist,omitempty"`
+	// RecordSessions replaces whether terminal sessions are recorded. It's
+	// disabled when omitted.
+	RecordSessions bool `json:"record_sessions,omitempty"`
+}
+
+// ParsePortRange parses a port, e.g. "5432", or an inclusive range of ports,
+// e.g. "8000-8100".
+func ParsePortRange(s string) (start uint16, end uint16, err error) {
+	rawStart, rawEnd, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	start64, err := strconv.ParseUint(rawStart, 10, 16)
+	if err != nil || start64 == 0 {
+		return 0, 0, xerrors.Errorf("invalid port %q", rawStart)
+	}
+	if !isRange {
+		return uint16(start64), uint16(start64), nil
+	}
+	end64, err := strconv.ParseUint(rawEnd, 10, 16)
+	if err != nil || end64 == 0 {
+		return 0, 0, xerrors.Errorf("invalid port %q", rawEnd)
+	}
+	if end64 < start64 {
+		return 0, 0, xerrors.Errorf("port range %q ends before it starts", s)
+	}
+	return uint16(start64), uint16(end64), nil
+}
+
+// PortForwardingAllowed returns true if port is in one of the port ranges of
+// allowlist, or if allowlist is empty. Invalid ranges never match.
+func PortForwardingAllowed(allowlist []string, port uint32) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	for _, portRange := range allowlist {
+		start, end, err := ParsePortRange(portRange)
+		if err != nil {
+			continue
+		}
+		if port >= uint32(start) && port <= uint32(end) {
+			return true
+		}
+	}
+	return false
 }
 
 // Template returns a single template.
@@ -234,4 +301,7 @@ type AgentStatsReportResponse struct {
 	RxBytes int64 `json:"rx_bytes"`
 	// TxBytes is the number of received bytes.
 	TxBytes int64 `json:"tx_bytes"`
+	// Connections has a record for every tailnet peer of the agent. They're
+	// stored separately from the other stats.
+	Connections []ConnectionStat `json:"connections,omitempty"`
 }
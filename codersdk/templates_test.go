@@ -0,0 +1,57 @@
+package codersdk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func TestParsePortRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		start uint16
+		end   uint16
+		err   bool
+	}{
+		{input: "5432", start: 5432, end: 5432},
+		{input: " 22 ", start: 22, end: 22},
+		{input: "8000-8100", start: 8000, end: 8100},
+		{input: "1-65535", start: 1, end: 65535},
+		{input: "", err: true},
+		{input: "0", err: true},
+		{input: "65536", err: true},
+		{input: "http", err: true},
+		{input: "8000-", err: true},
+		{input: "8100-8000", err: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			start, end, err := codersdk.ParsePortRange(tt.input)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.start, start)
+			require.Equal(t, tt.end, end)
+		})
+	}
+}
+
+func TestPortForwardingAllowed(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, codersdk.PortForwardingAllowed(nil, 22))
+	allowlist := []string{"5432", "8000-8100", "invalid"}
+	require.True(t, codersdk.PortForwardingAllowed(allowlist, 5432))
+	require.True(t, codersdk.PortForwardingAllowed(allowlist, 8000))
+	require.True(t, codersdk.PortForwardingAllowed(allowlist, 8100))
+	require.False(t, codersdk.PortForwardingAllowed(allowlist, 8101))
+	require.False(t, codersdk.PortForwardingAllowed(allowlist, 22))
+}
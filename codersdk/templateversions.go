@@ -24,6 +24,37 @@ type TemplateVersion struct {
 	CreatedBy      User           `json:"created_by"`
 }
 
+// TemplateVersionParameterOption represents a selectable option for a
+// template version parameter.
+type TemplateVersionParameterOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+	Icon        string `json:"icon"`
+}
+
+// TemplateVersionParameter represents a parameter declared by a
+// coder_parameter data source in a template version.
+type TemplateVersionParameter struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	Description string `json:"description"`
+	// Type is one of "string", "number", "bool" or "list(string)".
+	Type         string                           `json:"type"`
+	Mutable      bool                             `json:"mutable"`
+	DefaultValue string                           `json:"default_value"`
+	Icon         string                           `json:"icon"`
+	Options      []TemplateVersionParameterOption `json:"options"`
+	// ValidationError is displayed when the value doesn't match
+	// ValidationRegex.
+	ValidationError string `json:"validation_error,omitempty"`
+	ValidationRegex string `json:"validation_regex,omitempty"`
+	ValidationMin   *int32 `json:"validation_min,omitempty"`
+	ValidationMax   *int32 `json:"validation_max,omitempty"`
+	// Required is true when the parameter has no default value.
+	Required bool `json:"required"`
+}
+
 // TemplateVersion returns a template version by ID.
 func (c *Client) TemplateVersion(ctx context.Context, id uuid.UUID) (TemplateVersion, error) {
 	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s", id), nil)
@@ -65,6 +96,21 @@ func (c *Client) TemplateVersionSchema(ctx context.Context, version uuid.UUID) (
 	return params, json.NewDecoder(res.Body).Decode(&params)
 }
 
+// TemplateVersionRichParameters returns the coder_parameter data sources
+// declared by a template version, in display order.
+func (c *Client) TemplateVersionRichParameters(ctx context.Context, version uuid.UUID) ([]TemplateVersionParameter, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/rich-parameters", version), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var params []TemplateVersionParameter
+	return params, json.NewDecoder(res.Body).Decode(&params)
+}
+
 // TemplateVersionParameters returns computed parameters for a template version.
 func (c *Client) TemplateVersionParameters(ctx context.Context, version uuid.UUID) ([]ComputedParameter, error) {
 	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/parameters", version), nil)
@@ -106,8 +152,13 @@ func (c *Client) TemplateVersionLogsAfter(ctx context.Context, version uuid.UUID
 // CreateTemplateVersionDryRunRequest defines the request parameters for
 // CreateTemplateVersionDryRun.
 type CreateTemplateVersionDryRunRequest struct {
-	WorkspaceName   string                   `json:"workspace_name"`
-	ParameterValues []CreateParameterRequest `json:"parameter_values"`
+	WorkspaceName       string                    `json:"workspace_name"`
+	ParameterValues     []CreateParameterRequest  `json:"parameter_values"`
+	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values,omitempty"`
+	// WorkspaceID optionally dry-runs the template version against an
+	// existing workspace. The workspace's current state and build parameters
+	// are used, so the result can be diffed with TemplateVersionDryRunDiff.
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty"`
 }
 
 // CreateTemplateVersionDryRun begins a dry-run provisioner job against the
@@ -158,6 +209,56 @@ func (c *Client) TemplateVersionDryRunResources(ctx context.Context, version, jo
 	return resources, json.NewDecoder(res.Body).Decode(&resources)
 }
 
+type WorkspaceUpdateDiffChange string
+
+const (
+	WorkspaceUpdateDiffChangeAdded   WorkspaceUpdateDiffChange = "added"
+	WorkspaceUpdateDiffChangeRemoved WorkspaceUpdateDiffChange = "removed"
+	WorkspaceUpdateDiffChangeChanged WorkspaceUpdateDiffChange = "changed"
+)
+
+// WorkspaceResourceDiff describes how a resource of a workspace changes when
+// it's updated. Resources are identified by their type and name.
+type WorkspaceResourceDiff struct {
+	Type   string                    `json:"type"`
+	Name   string                    `json:"name"`
+	Change WorkspaceUpdateDiffChange `json:"change"`
+}
+
+// WorkspaceBuildParameterDiff describes how a build parameter of a workspace
+// changes when it's updated.
+type WorkspaceBuildParameterDiff struct {
+	Name     string                    `json:"name"`
+	OldValue string                    `json:"old_value"`
+	NewValue string                    `json:"new_value"`
+	Change   WorkspaceUpdateDiffChange `json:"change"`
+}
+
+// WorkspaceUpdateDiff is the set of changes between a workspace's latest build
+// and a dry-run of a template version against that workspace. Unchanged
+// resources and parameters are omitted.
+type WorkspaceUpdateDiff struct {
+	WorkspaceID uuid.UUID                     `json:"workspace_id"`
+	Resources   []WorkspaceResourceDiff       `json:"resources"`
+	Parameters  []WorkspaceBuildParameterDiff `json:"parameters"`
+}
+
+// TemplateVersionDryRunDiff returns the changes a finished template version
+// dry-run would make to the workspace it was run against.
+func (c *Client) TemplateVersionDryRunDiff(ctx context.Context, version, job uuid.UUID) (WorkspaceUpdateDiff, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/dry-run/%s/diff", version, job), nil)
+	if err != nil {
+		return WorkspaceUpdateDiff{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceUpdateDiff{}, readBodyAsError(res)
+	}
+
+	var diff WorkspaceUpdateDiff
+	return diff, json.NewDecoder(res.Body).Decode(&diff)
+}
+
 // TemplateVersionDryRunLogsBefore returns logs for a template version dry-run
 // that occurred before a specific time.
 func (c *Client) TemplateVersionDryRunLogsBefore(ctx context.Context, version, job uuid.UUID, before time.Time) ([]ProvisionerJobLog, error) {
@@ -45,6 +45,11 @@ type User struct {
 	OrganizationIDs []uuid.UUID `json:"organization_ids"`
 	Roles           []Role      `json:"roles"`
 	AvatarURL       string      `json:"avatar_url"`
+	// ServiceAccount users are used by automation. They cannot log in and
+	// only authenticate with tokens that expire.
+	ServiceAccount bool `json:"service_account" table:"service account"`
+	// Contact is the person or team responsible for a service account.
+	Contact string `json:"contact" table:"contact"`
 }
 
 type CreateFirstUserRequest struct {
@@ -61,16 +66,42 @@ type CreateFirstUserResponse struct {
 }
 
 type CreateUserRequest struct {
-	Email          string    `json:"email" validate:"required,email"`
-	Username       string    `json:"username" validate:"required,username"`
-	Password       string    `json:"password" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Username string `json:"username" validate:"required,username"`
+	// Password is required for users, and must be empty for service accounts.
+	Password       string    `json:"password" validate:"required_unless=ServiceAccount true"`
 	OrganizationID uuid.UUID `json:"organization_id" validate:"required"`
+	ServiceAccount bool      `json:"service_account"`
+	// Contact is required for service accounts.
+	Contact string `json:"contact" validate:"required_if=ServiceAccount true"`
 }
 
 type UpdateUserProfileRequest struct {
 	Username string `json:"username" validate:"required,username"`
 }
 
+// UserQuietHoursScheduleResponse describes the daily schedule during which
+// workspaces owned by the user may be stopped to satisfy template autostop
+// requirements.
+type UserQuietHoursScheduleResponse struct {
+	RawSchedule string `json:"raw_schedule"`
+	// UserSet is true if the user has set their own quiet hours schedule. If
+	// false, the user is using the default schedule.
+	UserSet bool `json:"user_set"`
+	// Time is the time of day that the quiet hours start in the given
+	// Timezone, e.g. "12:00AM".
+	Time     string    `json:"time"`
+	Timezone string    `json:"timezone"`
+	Next     time.Time `json:"next"`
+}
+
+type UpdateUserQuietHoursScheduleRequest struct {
+	// Schedule is a cron expression that defines when the user's quiet hours
+	// start. It must be a daily schedule, e.g. "CRON_TZ=US/Central 0 2 * * *".
+	// An empty schedule resets the user to the default schedule.
+	Schedule string `json:"schedule"`
+}
+
 type UpdateUserPasswordRequest struct {
 	OldPassword string `json:"old_password" validate:""`
 	Password    string `json:"password" validate:"required"`
@@ -179,6 +210,34 @@ func (c *Client) UpdateUserProfile(ctx context.Context, user string, req UpdateU
 	return resp, json.NewDecoder(res.Body).Decode(&resp)
 }
 
+// UserQuietHoursSchedule returns the quiet hours schedule of the user.
+func (c *Client) UserQuietHoursSchedule(ctx context.Context, user string) (UserQuietHoursScheduleResponse, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/quiet-hours", user), nil)
+	if err != nil {
+		return UserQuietHoursScheduleResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return UserQuietHoursScheduleResponse{}, readBodyAsError(res)
+	}
+	var resp UserQuietHoursScheduleResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// UpdateUserQuietHoursSchedule sets the quiet hours schedule of the user.
+func (c *Client) UpdateUserQuietHoursSchedule(ctx context.Context, user string, req UpdateUserQuietHoursScheduleRequest) (UserQuietHoursScheduleResponse, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/users/%s/quiet-hours", user), req)
+	if err != nil {
+		return UserQuietHoursScheduleResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return UserQuietHoursScheduleResponse{}, readBodyAsError(res)
+	}
+	var resp UserQuietHoursScheduleResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
 // UpdateUserStatus sets the user status to the given status
 func (c *Client) UpdateUserStatus(ctx context.Context, user string, status UserStatus) (User, error) {
 	path := fmt.Sprintf("/api/v2/users/%s/status/", user)
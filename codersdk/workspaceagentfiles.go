@@ -0,0 +1,121 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// AgentFileChecksum describes the contents of a file in a workspace.
+type AgentFileChecksum struct {
+	// Path is the absolute path of the file. Relative paths are relative to
+	// the home directory of the user running the agent.
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func agentFilePath(path string) string {
+	return "/api/v0/files?" + url.Values{"path": {path}}.Encode()
+}
+
+func (c *AgentConn) doFilesRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := newTailnetHTTPRequest(ctx, "files", TailnetFilesPort, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.filesClient().Do(req)
+}
+
+// DownloadFile downloads a file from the workspace, starting at offset. It
+// returns the contents and their length.
+func (c *AgentConn) DownloadFile(ctx context.Context, path string, offset int64) (io.ReadCloser, int64, error) {
+	req, err := newTailnetHTTPRequest(ctx, "files", TailnetFilesPort, http.MethodGet, agentFilePath(path), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	res, err := c.filesClient().Do(req)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("do request: %w", err)
+	}
+	switch {
+	case res.StatusCode == http.StatusOK && offset == 0, res.StatusCode == http.StatusPartialContent:
+		return res.Body, res.ContentLength, nil
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The file is no longer than the offset.
+		_ = res.Body.Close()
+		return http.NoBody, 0, nil
+	default:
+		defer res.Body.Close()
+		return nil, 0, readBodyAsError(res)
+	}
+}
+
+// UploadFile writes the contents of r to a file in the workspace, replacing
+// it if it exists. The parent directory must exist.
+func (c *AgentConn) UploadFile(ctx context.Context, path string, r io.Reader) (AgentFileChecksum, error) {
+	res, err := c.doFilesRequest(ctx, http.MethodPut, agentFilePath(path), r)
+	if err != nil {
+		return AgentFileChecksum{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return AgentFileChecksum{}, readBodyAsError(res)
+	}
+	var checksum AgentFileChecksum
+	return checksum, json.NewDecoder(res.Body).Decode(&checksum)
+}
+
+// FileChecksum returns the SHA-256 checksum of a file in the workspace.
+func (c *AgentConn) FileChecksum(ctx context.Context, path string) (AgentFileChecksum, error) {
+	res, err := c.doFilesRequest(ctx, http.MethodGet, "/api/v0/files/checksum?"+url.Values{"path": {path}}.Encode(), nil)
+	if err != nil {
+		return AgentFileChecksum{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AgentFileChecksum{}, readBodyAsError(res)
+	}
+	var checksum AgentFileChecksum
+	return checksum, json.NewDecoder(res.Body).Decode(&checksum)
+}
+
+// WorkspaceAgentDownloadFile downloads a file from a workspace through
+// coderd.
+func (c *Client) WorkspaceAgentDownloadFile(ctx context.Context, agentID uuid.UUID, path string) (io.ReadCloser, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/files?%s", agentID, url.Values{"path": {path}}.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, readBodyAsError(res)
+	}
+	return res.Body, nil
+}
+
+// WorkspaceAgentUploadFile writes a file to a workspace through coderd,
+// replacing it if it exists.
+func (c *Client) WorkspaceAgentUploadFile(ctx context.Context, agentID uuid.UUID, path string, content []byte) (AgentFileChecksum, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/workspaceagents/%s/files?%s", agentID, url.Values{"path": {path}}.Encode()), content, func(r *http.Request) {
+		r.Header.Set("Content-Type", "application/octet-stream")
+	})
+	if err != nil {
+		return AgentFileChecksum{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return AgentFileChecksum{}, readBodyAsError(res)
+	}
+	var checksum AgentFileChecksum
+	return checksum, json.NewDecoder(res.Body).Decode(&checksum)
+}
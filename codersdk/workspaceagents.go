@@ -34,6 +34,61 @@ const (
 	WorkspaceAgentDisconnected WorkspaceAgentStatus = "disconnected"
 )
 
+// WorkspaceAgentLifecycle represents the lifecycle state of a workspace agent.
+//
+// The agent lifecycle starts in the "created" state, and transitions to
+// "starting" when the agent reports it has begun preparing (e.g. started
+// executing the startup script).
+type WorkspaceAgentLifecycle string
+
+// WorkspaceAgentLifecycle enums.
+const (
+	WorkspaceAgentLifecycleCreated         WorkspaceAgentLifecycle = "created"
+	WorkspaceAgentLifecycleStarting        WorkspaceAgentLifecycle = "starting"
+	WorkspaceAgentLifecycleStartTimeout    WorkspaceAgentLifecycle = "start_timeout"
+	WorkspaceAgentLifecycleStartError      WorkspaceAgentLifecycle = "start_error"
+	WorkspaceAgentLifecycleReady           WorkspaceAgentLifecycle = "ready"
+	WorkspaceAgentLifecycleShuttingDown    WorkspaceAgentLifecycle = "shutting_down"
+	WorkspaceAgentLifecycleShutdownTimeout WorkspaceAgentLifecycle = "shutdown_timeout"
+	WorkspaceAgentLifecycleShutdownError   WorkspaceAgentLifecycle = "shutdown_error"
+	WorkspaceAgentLifecycleOff             WorkspaceAgentLifecycle = "off"
+)
+
+// WorkspaceAgentLifecycleOrder is the order in which workspace agent
+// lifecycle states are expected to be reported during the lifetime of
+// the agent process.
+var WorkspaceAgentLifecycleOrder = []WorkspaceAgentLifecycle{
+	WorkspaceAgentLifecycleCreated,
+	WorkspaceAgentLifecycleStarting,
+	WorkspaceAgentLifecycleStartTimeout,
+	WorkspaceAgentLifecycleStartError,
+	WorkspaceAgentLifecycleReady,
+	WorkspaceAgentLifecycleShuttingDown,
+	WorkspaceAgentLifecycleShutdownTimeout,
+	WorkspaceAgentLifecycleShutdownError,
+	WorkspaceAgentLifecycleOff,
+}
+
+// Starting returns true if the agent is in the process of starting.
+func (l WorkspaceAgentLifecycle) Starting() bool {
+	return l == WorkspaceAgentLifecycleCreated || l == WorkspaceAgentLifecycleStarting
+}
+
+// ShutDown returns true if the agent has finished shutting down, whether
+// or not its shutdown script succeeded.
+func (l WorkspaceAgentLifecycle) ShutDown() bool {
+	switch l {
+	case WorkspaceAgentLifecycleShutdownTimeout, WorkspaceAgentLifecycleShutdownError, WorkspaceAgentLifecycleOff:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultShutdownScriptTimeout bounds shutdown scripts that don't set a
+// timeout, so a hung script can't keep a workspace from stopping.
+const DefaultShutdownScriptTimeout = 5 * time.Minute
+
 type WorkspaceAgent struct {
 	ID                   uuid.UUID            `json:"id"`
 	CreatedAt            time.Time            `json:"created_at"`
@@ -52,6 +107,22 @@ type WorkspaceAgent struct {
 	Directory            string               `json:"directory,omitempty"`
 	Version              string               `json:"version"`
 	Apps                 []WorkspaceApp       `json:"apps"`
+	// StartupLogsLength is the total length in bytes of the startup
+	// logs the agent has sent.
+	StartupLogsLength     int32                   `json:"startup_logs_length"`
+	StartupLogsOverflowed bool                    `json:"startup_logs_overflowed"`
+	LifecycleState        WorkspaceAgentLifecycle `json:"lifecycle_state"`
+	// StartupScriptTimeoutSeconds is the number of seconds to wait for the
+	// startup script to complete. Zero means there is no timeout.
+	StartupScriptTimeoutSeconds int32 `json:"startup_script_timeout_seconds"`
+	// LoginBeforeReady allows users to log in before the agent is ready
+	// (i.e. before the startup script has finished). If false, clients
+	// wait for the agent to be ready before logging in.
+	LoginBeforeReady bool   `json:"login_before_ready"`
+	ShutdownScript   string `json:"shutdown_script,omitempty"`
+	// ShutdownScriptTimeoutSeconds is the number of seconds to wait for the
+	// shutdown script to complete. Zero means DefaultShutdownScriptTimeout.
+	ShutdownScriptTimeoutSeconds int32 `json:"shutdown_script_timeout_seconds"`
 	// DERPLatency is mapped by region name (e.g. "New York City", "Seattle").
 	DERPLatency map[string]DERPRegion `json:"latency,omitempty"`
 }
@@ -122,12 +193,33 @@ type WorkspaceAgentMetadata struct {
 	// GitAuthConfigs stores the number of Git configurations
 	// the Coder deployment has. If this number is >0, we
 	// set up special configuration in the workspace.
-	GitAuthConfigs       int               `json:"git_auth_configs"`
-	Apps                 []WorkspaceApp    `json:"apps"`
-	DERPMap              *tailcfg.DERPMap  `json:"derpmap"`
-	EnvironmentVariables map[string]string `json:"environment_variables"`
-	StartupScript        string            `json:"startup_script"`
-	Directory            string            `json:"directory"`
+	GitAuthConfigs        int               `json:"git_auth_configs"`
+	Apps                  []WorkspaceApp    `json:"apps"`
+	DERPMap               *tailcfg.DERPMap  `json:"derpmap"`
+	EnvironmentVariables  map[string]string `json:"environment_variables"`
+	StartupScript         string            `json:"startup_script"`
+	StartupScriptTimeout  time.Duration     `json:"startup_script_timeout"`
+	ShutdownScript        string            `json:"shutdown_script"`
+	ShutdownScriptTimeout time.Duration     `json:"shutdown_script_timeout"`
+	Directory             string            `json:"directory"`
+	// Metadata describes the scripts the agent runs periodically to
+	// collect metadata values.
+	Metadata []WorkspaceAgentMetadataDescription `json:"metadata"`
+	// PortForwardingAllowlist is the ports and port ranges of the template
+	// that may be forwarded with SSH or over tailnet. Empty allows all ports.
+	PortForwardingAllowlist []string `json:"port_forwarding_allowlist"`
+	// SSHCertificateAuthorities are the authorized_keys encoded keys the
+	// agent trusts to sign SSH user certificates.
+	SSHCertificateAuthorities []string `json:"ssh_certificate_authorities"`
+	// SSHCertificatePrincipal is the principal a certificate must be valid
+	// for to connect to the agent.
+	SSHCertificatePrincipal string `json:"ssh_certificate_principal"`
+	// SSHRequireCertificate rejects SSH connections that don't authenticate
+	// with a valid certificate.
+	SSHRequireCertificate bool `json:"ssh_require_certificate"`
+	// RecordSessions makes the agent record terminal sessions and upload
+	// them when they end.
+	RecordSessions bool `json:"record_sessions"`
 }
 
 // AuthWorkspaceGoogleInstanceIdentity uses the Google Compute Engine Metadata API to
@@ -343,6 +435,13 @@ type DialWorkspaceAgentOptions struct {
 	Logger slog.Logger
 	// BlockEndpoints forced a direct connection through DERP.
 	BlockEndpoints bool
+	// ConnectionStatsInterval is how often the connection is reported to
+	// coderd for network telemetry. Defaults to one minute, a negative value
+	// disables reporting.
+	ConnectionStatsInterval time.Duration
+	// DisableDERPMapUpdates keeps the DERP map the connection was dialed
+	// with, instead of following changes streamed by coderd.
+	DisableDERPMapUpdates bool
 }
 
 func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, options *DialWorkspaceAgentOptions) (*AgentConn, error) {
@@ -363,17 +462,6 @@ func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, opti
 		return nil, xerrors.Errorf("decode conn info: %w", err)
 	}
 
-	ip := tailnet.IP()
-	conn, err := tailnet.NewConn(&tailnet.Options{
-		Addresses:      []netip.Prefix{netip.PrefixFrom(ip, 128)},
-		DERPMap:        connInfo.DERPMap,
-		Logger:         options.Logger,
-		BlockEndpoints: options.BlockEndpoints,
-	})
-	if err != nil {
-		return nil, xerrors.Errorf("create tailnet: %w", err)
-	}
-
 	coordinateURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/workspaceagents/%s/coordinate", agentID))
 	if err != nil {
 		return nil, xerrors.Errorf("parse url: %w", err)
@@ -390,6 +478,106 @@ func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, opti
 		Jar:       jar,
 		Transport: c.HTTPClient.Transport,
 	}
+	agentConn, err := DialCoordinatedAgent(ctx, httpClient, coordinateURL, connInfo.DERPMap, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.ConnectionStatsInterval >= 0 {
+		c.reportConnectionStats(ctx, agentID, agentConn, options)
+	}
+	if !options.DisableDERPMapUpdates {
+		c.followDERPMap(ctx, agentConn, options)
+	}
+	return agentConn, nil
+}
+
+// followDERPMap applies DERP map updates from coderd to the connection until
+// it's closed.
+func (c *Client) followDERPMap(ctx context.Context, conn *AgentConn, options *DialWorkspaceAgentOptions) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		derpMaps, err := c.WatchDERPMap(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				options.Logger.Debug(ctx, "watch derp map", slog.Error(err))
+			}
+			return
+		}
+		for derpMap := range derpMaps {
+			conn.SetDERPMap(derpMap)
+		}
+	}()
+	closeFunc := conn.CloseFunc
+	conn.CloseFunc = func() {
+		cancel()
+		<-done
+		closeFunc()
+	}
+}
+
+// reportConnectionStats periodically reports the connection to the agent
+// until it's closed.
+func (c *Client) reportConnectionStats(ctx context.Context, agentID uuid.UUID, conn *AgentConn, options *DialWorkspaceAgentOptions) {
+	interval := options.ConnectionStatsInterval
+	if interval == 0 {
+		interval = time.Minute
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			peersCtx, peersCancel := context.WithTimeout(ctx, 5*time.Second)
+			stats := ConnectionStatsFromPeers(conn.PeerStats(peersCtx))
+			peersCancel()
+			if len(stats) == 0 {
+				continue
+			}
+			err := c.PostWorkspaceAgentConnectionStats(ctx, agentID, PostWorkspaceAgentConnectionStatsRequest{
+				Connections: stats,
+			})
+			if err != nil && ctx.Err() == nil {
+				options.Logger.Debug(ctx, "report connection stats", slog.Error(err))
+			}
+		}
+	}()
+	closeFunc := conn.CloseFunc
+	conn.CloseFunc = func() {
+		cancel()
+		<-done
+		closeFunc()
+	}
+}
+
+// DialCoordinatedAgent creates a tailnet connection using the given DERP map
+// and exchanges nodes with an agent through the coordinator websocket at
+// coordinateURL. The HTTP client must carry whatever credentials the
+// coordinator endpoint requires. It is used directly by workspace proxies,
+// which coordinate through their own endpoint on coderd.
+func DialCoordinatedAgent(ctx context.Context, httpClient *http.Client, coordinateURL *url.URL, derpMap *tailcfg.DERPMap, options *DialWorkspaceAgentOptions) (*AgentConn, error) {
+	if options == nil {
+		options = &DialWorkspaceAgentOptions{}
+	}
+	ip := tailnet.IP()
+	conn, err := tailnet.NewConn(&tailnet.Options{
+		Addresses:      []netip.Prefix{netip.PrefixFrom(ip, 128)},
+		DERPMap:        derpMap,
+		Logger:         options.Logger,
+		BlockEndpoints: options.BlockEndpoints,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("create tailnet: %w", err)
+	}
+
 	ctx, cancelFunc := context.WithCancel(ctx)
 	closed := make(chan struct{})
 	first := make(chan error)
@@ -493,6 +681,175 @@ func (c *Client) PostWorkspaceAgentVersion(ctx context.Context, version string)
 	return nil
 }
 
+// @typescript-ignore PostWorkspaceAgentLifecycleRequest
+type PostWorkspaceAgentLifecycleRequest struct {
+	State WorkspaceAgentLifecycle `json:"state"`
+}
+
+// PostWorkspaceAgentLifecycle reports the lifecycle state of the
+// authenticated workspace agent.
+func (c *Client) PostWorkspaceAgentLifecycle(ctx context.Context, req PostWorkspaceAgentLifecycleRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/report-lifecycle", req)
+	if err != nil {
+		return xerrors.Errorf("agent state post request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WorkspaceAgentMetadataDescription describes a metadata item defined by
+// the template on a coder_agent. Interval and Timeout are in seconds.
+type WorkspaceAgentMetadataDescription struct {
+	DisplayName string `json:"display_name"`
+	Key         string `json:"key"`
+	Script      string `json:"script"`
+	Interval    int64  `json:"interval"`
+	Timeout     int64  `json:"timeout"`
+}
+
+// WorkspaceAgentMetadataResult is the outcome of a single run of a metadata
+// script.
+type WorkspaceAgentMetadataResult struct {
+	CollectedAt time.Time `json:"collected_at"`
+	// Age is the number of seconds since the metadata was collected.
+	// It is provided in addition to CollectedAt to protect against clock skew.
+	Age   int64  `json:"age"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// WorkspaceAgentMetadatum is a metadata item with its latest result.
+type WorkspaceAgentMetadatum struct {
+	Result      WorkspaceAgentMetadataResult      `json:"result"`
+	Description WorkspaceAgentMetadataDescription `json:"description"`
+}
+
+// @typescript-ignore PostWorkspaceAgentMetadataRequest
+type PostWorkspaceAgentMetadataRequest WorkspaceAgentMetadataResult
+
+// PostWorkspaceAgentMetadata reports the result of the metadata script
+// identified by key for the authenticated workspace agent.
+func (c *Client) PostWorkspaceAgentMetadata(ctx context.Context, key string, req PostWorkspaceAgentMetadataRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/metadata/"+url.PathEscape(key), req)
+	if err != nil {
+		return xerrors.Errorf("post workspace agent metadata: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WatchWorkspaceAgentMetadata streams the metadata of a workspace agent.
+// The full set of metadata is sent every time any item changes. The error
+// channel receives a value and both channels are closed when the stream
+// ends.
+func (c *Client) WatchWorkspaceAgentMetadata(ctx context.Context, id uuid.UUID) (<-chan []WorkspaceAgentMetadatum, <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	metadataChan := make(chan []WorkspaceAgentMetadatum, 256)
+	errorChan := make(chan error, 1)
+
+	watch := func() error {
+		//nolint:bodyclose
+		res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/watch-metadata", id), nil)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode != http.StatusOK {
+			return readBodyAsError(res)
+		}
+		defer res.Body.Close()
+
+		nextEvent := ServerSentEventReader(res.Body)
+		for {
+			sse, err := nextEvent()
+			if err != nil {
+				return err
+			}
+
+			b, ok := sse.Data.([]byte)
+			if !ok && sse.Type != ServerSentEventTypePing {
+				return xerrors.Errorf("unexpected data type: %T", sse.Data)
+			}
+
+			switch sse.Type {
+			case ServerSentEventTypeData:
+				var met []WorkspaceAgentMetadatum
+				err = json.Unmarshal(b, &met)
+				if err != nil {
+					return xerrors.Errorf("unmarshal metadata: %w", err)
+				}
+				select {
+				case metadataChan <- met:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			case ServerSentEventTypeError:
+				var r Response
+				err = json.Unmarshal(b, &r)
+				if err != nil {
+					return xerrors.Errorf("unmarshal error: %w", err)
+				}
+				return xerrors.Errorf("%+v", r)
+			}
+		}
+	}
+
+	go func() {
+		defer close(errorChan)
+		defer close(metadataChan)
+		defer cancel()
+		errorChan <- watch()
+	}()
+
+	return metadataChan, errorChan
+}
+
+// WaitForWorkspaceAgentShutdown blocks until coderd signals that the
+// workspace of the authenticated agent is being stopped, so the agent can
+// run its shutdown script before the compute is destroyed.
+func (c *Client) WaitForWorkspaceAgentShutdown(ctx context.Context) error {
+	serverURL, err := c.URL.Parse("/api/v2/workspaceagents/me/shutdown")
+	if err != nil {
+		return xerrors.Errorf("parse url: %w", err)
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return xerrors.Errorf("create cookie jar: %w", err)
+	}
+	jar.SetCookies(serverURL, []*http.Cookie{{
+		Name:  SessionTokenKey,
+		Value: c.SessionToken,
+	}})
+	httpClient := &http.Client{
+		Jar:       jar,
+		Transport: c.HTTPClient.Transport,
+	}
+	conn, res, err := websocket.Dial(ctx, serverURL.String(), &websocket.DialOptions{
+		HTTPClient:      httpClient,
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return err
+		}
+		return readBodyAsError(res)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	// The server only writes once, when the shutdown is signaled.
+	_, _, err = conn.Read(ctx)
+	if err != nil {
+		return xerrors.Errorf("read shutdown signal: %w", err)
+	}
+	return nil
+}
+
 // WorkspaceAgentReconnectingPTY spawns a PTY that reconnects using the token provided.
 // It communicates using `agent.ReconnectingPTYRequest` marshaled as JSON.
 // Responses are PTY output that can be rendered.
@@ -539,6 +896,35 @@ func (c *Client) WorkspaceAgentListeningPorts(ctx context.Context, agentID uuid.
 	return listeningPorts, json.NewDecoder(res.Body).Decode(&listeningPorts)
 }
 
+type IssueWorkspaceAgentSSHCertificateRequest struct {
+	// PublicKey is the authorized_keys encoded key to certify.
+	PublicKey string `json:"public_key" validate:"required"`
+}
+
+// WorkspaceAgentSSHCertificate is a short-lived SSH user certificate that
+// the agent accepts for authentication.
+type WorkspaceAgentSSHCertificate struct {
+	// Certificate is authorized_keys encoded, the format OpenSSH expects in
+	// a CertificateFile.
+	Certificate string    `json:"certificate"`
+	ExpiresAt   time.Time `json:"expires_at" format:"date-time"`
+}
+
+// IssueWorkspaceAgentSSHCertificate signs a certificate for the public key
+// that's valid for connecting to the agent over SSH.
+func (c *Client) IssueWorkspaceAgentSSHCertificate(ctx context.Context, agentID uuid.UUID, req IssueWorkspaceAgentSSHCertificateRequest) (WorkspaceAgentSSHCertificate, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/%s/ssh-certificate", agentID), req)
+	if err != nil {
+		return WorkspaceAgentSSHCertificate{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return WorkspaceAgentSSHCertificate{}, readBodyAsError(res)
+	}
+	var cert WorkspaceAgentSSHCertificate
+	return cert, json.NewDecoder(res.Body).Decode(&cert)
+}
+
 // Stats records the Agent's network connection statistics for use in
 // user-facing metrics and debugging.
 // Each member value must be written and read with atomic.
@@ -547,6 +933,8 @@ type AgentStats struct {
 	NumConns int64 `json:"num_comms"`
 	RxBytes  int64 `json:"rx_bytes"`
 	TxBytes  int64 `json:"tx_bytes"`
+	// Connections has a record for every tailnet peer of the agent.
+	Connections []ConnectionStat `json:"connections,omitempty"`
 }
 
 // AgentReportStats begins a stat streaming connection with the Coder server.
@@ -611,9 +999,10 @@ func (c *Client) AgentReportStats(
 					s := stats()
 
 					resp := AgentStatsReportResponse{
-						NumConns: s.NumConns,
-						RxBytes:  s.RxBytes,
-						TxBytes:  s.TxBytes,
+						NumConns:    s.NumConns,
+						RxBytes:     s.RxBytes,
+						TxBytes:     s.TxBytes,
+						Connections: s.Connections,
 					}
 
 					err = wsjson.Write(ctx, conn, resp)
@@ -675,3 +1064,121 @@ func (c *Client) WorkspaceAgentGitAuth(ctx context.Context, gitURL string, liste
 	var authResp WorkspaceAgentGitAuthResponse
 	return authResp, json.NewDecoder(res.Body).Decode(&authResp)
 }
+
+// WorkspaceAgentStartupLog is a line of output from the startup script of
+// a workspace agent.
+type WorkspaceAgentStartupLog struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Output    string    `json:"output"`
+	// EOF is set on the final log sent after the startup script exits.
+	EOF bool `json:"eof"`
+}
+
+// @typescript-ignore StartupLog
+type StartupLog struct {
+	CreatedAt time.Time `json:"created_at"`
+	Output    string    `json:"output"`
+	EOF       bool      `json:"eof"`
+}
+
+// @typescript-ignore PatchStartupLogs
+type PatchStartupLogs struct {
+	Logs []StartupLog `json:"logs"`
+}
+
+// PatchStartupLogs writes logs from the workspace agent startup script
+// to the database. It must be called with the agent's session token.
+func (c *Client) PatchStartupLogs(ctx context.Context, req PatchStartupLogs) error {
+	res, err := c.Request(ctx, http.MethodPatch, "/api/v2/workspaceagents/me/startup-logs", req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WorkspaceAgentStartupLogsAfter returns the startup logs of the agent with
+// an ID greater than after. If follow is true, the channel streams new logs
+// until the final log is sent or the closer is closed. Otherwise, the logs
+// are sent in a single batch.
+func (c *Client) WorkspaceAgentStartupLogsAfter(ctx context.Context, agentID uuid.UUID, after int64, follow bool) (<-chan []WorkspaceAgentStartupLog, io.Closer, error) {
+	afterQuery := ""
+	if after != 0 {
+		afterQuery = fmt.Sprintf("after=%d", after)
+	}
+	if !follow {
+		res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/startup-logs?%s", agentID, afterQuery), nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, nil, readBodyAsError(res)
+		}
+		var logs []WorkspaceAgentStartupLog
+		err = json.NewDecoder(res.Body).Decode(&logs)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("decode startup logs: %w", err)
+		}
+		logsChan := make(chan []WorkspaceAgentStartupLog, 1)
+		logsChan <- logs
+		close(logsChan)
+		return logsChan, closeFunc(func() error { return nil }), nil
+	}
+
+	followURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/workspaceagents/%s/startup-logs?follow&%s", agentID, afterQuery))
+	if err != nil {
+		return nil, nil, err
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("create cookie jar: %w", err)
+	}
+	jar.SetCookies(followURL, []*http.Cookie{{
+		Name:  SessionTokenKey,
+		Value: c.SessionToken,
+	}})
+	httpClient := &http.Client{
+		Jar:       jar,
+		Transport: c.HTTPClient.Transport,
+	}
+	conn, res, err := websocket.Dial(ctx, followURL.String(), &websocket.DialOptions{
+		HTTPClient:      httpClient,
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, nil, err
+		}
+		return nil, nil, readBodyAsError(res)
+	}
+	logChunks := make(chan []WorkspaceAgentStartupLog)
+	closed := make(chan struct{})
+	decoder := json.NewDecoder(websocket.NetConn(ctx, conn, websocket.MessageText))
+	go func() {
+		defer close(closed)
+		defer close(logChunks)
+		defer conn.Close(websocket.StatusGoingAway, "")
+		for {
+			var logs []WorkspaceAgentStartupLog
+			err = decoder.Decode(&logs)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case logChunks <- logs:
+			}
+		}
+	}()
+	return logChunks, closeFunc(func() error {
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+		<-closed
+		return nil
+	}), nil
+}
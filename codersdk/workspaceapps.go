@@ -1,7 +1,14 @@
 package codersdk
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
 )
 
 type WorkspaceAppHealth string
@@ -37,6 +44,9 @@ type WorkspaceApp struct {
 	// be accessible in the UI.
 	Subdomain    bool                     `json:"subdomain"`
 	SharingLevel WorkspaceAppSharingLevel `json:"sharing_level"`
+	// TCP is true if the app forwards raw TCP instead of HTTP. TCP apps are
+	// accessed on their subdomain with `coder tunnel`.
+	TCP bool `json:"tcp"`
 	// Healthcheck specifies the configuration for checking app health.
 	Healthcheck Healthcheck        `json:"healthcheck"`
 	Health      WorkspaceAppHealth `json:"health"`
@@ -56,3 +66,61 @@ type PostWorkspaceAppHealthsRequest struct {
 	// Healths is a map of the workspace app name and the health of the app.
 	Healths map[string]WorkspaceAppHealth
 }
+
+// DialWorkspaceAppTCP connects to an app that forwards raw TCP. host is the
+// subdomain hostname of the app, e.g. "db--main--dev--alice.apps.coder.com".
+// The connection is made to the deployment URL with host as the Host header,
+// so the wildcard hostname doesn't need to resolve on the client.
+func (c *Client) DialWorkspaceAppTCP(ctx context.Context, host string) (net.Conn, error) {
+	serverURL, err := c.URL.Parse("/")
+	if err != nil {
+		return nil, xerrors.Errorf("parse url: %w", err)
+	}
+	if port := serverURL.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, xerrors.Errorf("create cookie jar: %w", err)
+	}
+	jar.SetCookies(serverURL, []*http.Cookie{{
+		Name:  SessionTokenKey,
+		Value: c.SessionToken,
+	}})
+	transport := c.HTTPClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpClient := &http.Client{
+		Jar: jar,
+		Transport: &hostTransport{
+			host:      host,
+			transport: transport,
+		},
+	}
+	// nolint:bodyclose
+	conn, res, err := websocket.Dial(ctx, serverURL.String(), &websocket.DialOptions{
+		HTTPClient:      httpClient,
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, err
+		}
+		return nil, readBodyAsError(res)
+	}
+	return websocket.NetConn(ctx, conn, websocket.MessageBinary), nil
+}
+
+// hostTransport sends every request with a fixed Host header.
+// @typescript-ignore hostTransport
+type hostTransport struct {
+	host      string
+	transport http.RoundTripper
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Host = t.host
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,140 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// WorkspaceProxyAuthTokenHeader is the header workspace proxies use to
+// authenticate with the primary coderd deployment.
+// nolint: gosec
+const WorkspaceProxyAuthTokenHeader = "Coder-Workspace-Proxy-Auth-Token"
+
+type WorkspaceProxy struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name"`
+	Icon        string    `json:"icon"`
+	// URL is the access URL of the proxy. It is empty until the proxy
+	// registers itself.
+	URL string `json:"url"`
+	// WildcardHostname is the wildcard hostname the proxy serves subdomain
+	// applications on, e.g. "*.us.example.com". Empty if subdomain apps are
+	// not served by the proxy.
+	WildcardHostname string `json:"wildcard_hostname"`
+	DERPEnabled      bool   `json:"derp_enabled"`
+	// RegionID is the unique numeric identifier of the proxy. It is used to
+	// build the DERP region ID for the proxy.
+	RegionID  int32     `json:"region_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is bumped every time the proxy registers, so it doubles as a
+	// last seen time.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateWorkspaceProxyRequest struct {
+	Name        string `json:"name" validate:"required,username"`
+	DisplayName string `json:"display_name"`
+	Icon        string `json:"icon"`
+}
+
+type CreateWorkspaceProxyResponse struct {
+	Proxy WorkspaceProxy `json:"proxy"`
+	// ProxyToken is the token the proxy uses to authenticate with coderd.
+	// It is only returned once, on creation.
+	ProxyToken string `json:"proxy_token"`
+}
+
+// CreateWorkspaceProxy creates a new workspace proxy and returns the token it
+// should use to register with coderd.
+func (c *Client) CreateWorkspaceProxy(ctx context.Context, req CreateWorkspaceProxyRequest) (CreateWorkspaceProxyResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceproxies", req)
+	if err != nil {
+		return CreateWorkspaceProxyResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return CreateWorkspaceProxyResponse{}, readBodyAsError(res)
+	}
+	var resp CreateWorkspaceProxyResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// WorkspaceProxies lists all workspace proxies.
+func (c *Client) WorkspaceProxies(ctx context.Context) ([]WorkspaceProxy, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/workspaceproxies", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var proxies []WorkspaceProxy
+	return proxies, json.NewDecoder(res.Body).Decode(&proxies)
+}
+
+// DeleteWorkspaceProxyByName deletes a workspace proxy. The proxy will no
+// longer be able to authenticate with coderd.
+func (c *Client) DeleteWorkspaceProxyByName(ctx context.Context, name string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/workspaceproxies/%s", name), nil)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// Region is a location that workspace applications and DERP connections can
+// be served from. The primary coderd deployment is always a region, and
+// every registered workspace proxy adds another.
+type Region struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name"`
+	IconURL     string    `json:"icon_url"`
+	// Healthy is false if a workspace proxy has not registered recently.
+	Healthy bool `json:"healthy"`
+	// PathAppURL is the URL to the base path for path apps. Optional unless
+	// wildcard hostname is set.
+	// E.g. https://us.example.com
+	PathAppURL string `json:"path_app_url"`
+	// WildcardHostname is the wildcard hostname for subdomain apps.
+	// E.g. *.us.example.com
+	// E.g. *--suffix.au.example.com
+	// Optional. Does not need to be on the same domain as PathAppURL.
+	WildcardHostname string `json:"wildcard_hostname"`
+}
+
+type RegionsResponse struct {
+	Regions []Region `json:"regions"`
+}
+
+// Regions lists the regions a client can connect to workspace applications
+// through. Clients should measure latency to each healthy region's
+// PathAppURL and pick the lowest.
+func (c *Client) Regions(ctx context.Context) ([]Region, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/regions", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var resp RegionsResponse
+	return resp.Regions, json.NewDecoder(res.Body).Decode(&resp)
+}
@@ -29,6 +29,13 @@ type Workspace struct {
 	AutostartSchedule *string        `json:"autostart_schedule,omitempty"`
 	TTLMillis         *int64         `json:"ttl_ms,omitempty"`
 	LastUsedAt        time.Time      `json:"last_used_at"`
+	// DormantAt is set if the workspace was marked dormant after being
+	// unused for the inactivity TTL of its template. Dormant workspaces
+	// cannot be started until they are activated.
+	DormantAt *time.Time `json:"dormant_at,omitempty"`
+	// DeletingAt is the time a dormant workspace will be deleted, if the
+	// template has a dormant delete TTL.
+	DeletingAt *time.Time `json:"deleting_at,omitempty"`
 }
 
 type WorkspacesRequest struct {
@@ -55,6 +62,10 @@ type CreateWorkspaceBuildRequest struct {
 	// This will overwrite any existing parameters with the same name.
 	// This will not delete old params not included in this list.
 	ParameterValues []CreateParameterRequest `json:"parameter_values,omitempty"`
+	// RichParameterValues are values for the template's coder_parameter
+	// data sources. Parameters that are omitted keep the value of the
+	// previous build, or use their default value if they're new.
+	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values,omitempty"`
 }
 
 type WorkspaceOptions struct {
@@ -257,6 +268,27 @@ func (c *Client) PutExtendWorkspace(ctx context.Context, id uuid.UUID, req PutEx
 	return nil
 }
 
+// UpdateWorkspaceDormancy is a request to mark a workspace dormant or to
+// activate a dormant workspace.
+type UpdateWorkspaceDormancy struct {
+	Dormant bool `json:"dormant"`
+}
+
+// UpdateWorkspaceDormancy marks a workspace dormant or activates it again.
+// Dormant workspaces cannot be started.
+func (c *Client) UpdateWorkspaceDormancy(ctx context.Context, id uuid.UUID, req UpdateWorkspaceDormancy) error {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/dormant", id.String())
+	res, err := c.Request(ctx, http.MethodPut, path, req)
+	if err != nil {
+		return xerrors.Errorf("update workspace dormancy: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
 type WorkspaceFilter struct {
 	// Owner can be "me" or a username
 	Owner string `json:"owner,omitempty" typescript:"-"`
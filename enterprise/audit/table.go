@@ -40,30 +40,44 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"updated_at":      ActionIgnore, // Changes, but is implicit and not helpful in a diff.
 		"roles":           ActionTrack,
 	},
+	&database.AuditableOrganizationMember{}: {
+		"user_id":         ActionTrack,
+		"organization_id": ActionTrack,
+		"username":        ActionIgnore, // Only used as the target of the audit log.
+		"created_at":      ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":      ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"roles":           ActionTrack,
+	},
 	&database.Organization{}: {
-		"id":          ActionTrack,
-		"name":        ActionTrack,
-		"description": ActionTrack,
-		"created_at":  ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
-		"updated_at":  ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"id":                   ActionTrack,
+		"name":                 ActionTrack,
+		"description":          ActionTrack,
+		"created_at":           ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":           ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"user_workspace_quota": ActionTrack,
 	},
 	&database.Template{}: {
-		"id":                     ActionTrack,
-		"created_at":             ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
-		"updated_at":             ActionIgnore, // Changes, but is implicit and not helpful in a diff.
-		"organization_id":        ActionIgnore, /// Never changes.
-		"deleted":                ActionIgnore, // Changes, but is implicit when a delete event is fired.
-		"name":                   ActionTrack,
-		"provisioner":            ActionTrack,
-		"active_version_id":      ActionTrack,
-		"description":            ActionTrack,
-		"icon":                   ActionTrack,
-		"max_ttl":                ActionTrack,
-		"min_autostart_interval": ActionTrack,
-		"created_by":             ActionTrack,
-		"is_private":             ActionTrack,
-		"group_acl":              ActionTrack,
-		"user_acl":               ActionTrack,
+		"id":                        ActionTrack,
+		"created_at":                ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":                ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"organization_id":           ActionIgnore, /// Never changes.
+		"deleted":                   ActionIgnore, // Changes, but is implicit when a delete event is fired.
+		"name":                      ActionTrack,
+		"provisioner":               ActionTrack,
+		"active_version_id":         ActionTrack,
+		"description":               ActionTrack,
+		"icon":                      ActionTrack,
+		"max_ttl":                   ActionTrack,
+		"min_autostart_interval":    ActionTrack,
+		"created_by":                ActionTrack,
+		"autostop_requirement_days": ActionTrack,
+		"inactivity_ttl":            ActionTrack,
+		"dormant_delete_ttl":        ActionTrack,
+		"port_forwarding_allowlist": ActionTrack,
+		"record_sessions":           ActionTrack,
+		"is_private":                ActionTrack,
+		"group_acl":                 ActionTrack,
+		"user_acl":                  ActionTrack,
 	},
 	&database.TemplateVersion{}: {
 		"id":              ActionTrack,
@@ -77,18 +91,21 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"created_by":      ActionTrack,
 	},
 	&database.User{}: {
-		"id":              ActionTrack,
-		"email":           ActionTrack,
-		"username":        ActionTrack,
-		"hashed_password": ActionSecret, // Do not expose a users hashed password.
-		"created_at":      ActionIgnore, // Never changes.
-		"updated_at":      ActionIgnore, // Changes, but is implicit and not helpful in a diff.
-		"status":          ActionTrack,
-		"rbac_roles":      ActionTrack,
-		"login_type":      ActionIgnore,
-		"avatar_url":      ActionIgnore,
-		"last_seen_at":    ActionIgnore,
-		"deleted":         ActionTrack,
+		"id":                   ActionTrack,
+		"email":                ActionTrack,
+		"username":             ActionTrack,
+		"hashed_password":      ActionSecret, // Do not expose a users hashed password.
+		"created_at":           ActionIgnore, // Never changes.
+		"updated_at":           ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"status":               ActionTrack,
+		"rbac_roles":           ActionTrack,
+		"login_type":           ActionIgnore,
+		"avatar_url":           ActionIgnore,
+		"last_seen_at":         ActionIgnore,
+		"deleted":              ActionTrack,
+		"quiet_hours_schedule": ActionTrack,
+		"service_account":      ActionTrack,
+		"contact":              ActionTrack,
 	},
 	&database.Workspace{}: {
 		"id":                 ActionTrack,
@@ -102,6 +119,7 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"autostart_schedule": ActionTrack,
 		"ttl":                ActionTrack,
 		"last_used_at":       ActionIgnore,
+		"dormant_at":         ActionTrack,
 	},
 	&database.Group{}: {
 		"id":              ActionTrack,
@@ -123,6 +141,52 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"job_id":              ActionIgnore,
 		"deadline":            ActionIgnore,
 		"reason":              ActionIgnore,
+		"max_deadline":        ActionIgnore,
+	},
+	&database.WorkspaceProxy{}: {
+		"id":                  ActionTrack,
+		"name":                ActionTrack,
+		"display_name":        ActionTrack,
+		"icon":                ActionTrack,
+		"url":                 ActionTrack,
+		"wildcard_hostname":   ActionTrack,
+		"derp_enabled":        ActionTrack,
+		"region_id":           ActionIgnore, // Never changes.
+		"token_hashed_secret": ActionSecret,
+		"created_at":          ActionIgnore, // Never changes.
+		"updated_at":          ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"deleted":             ActionIgnore, // Changes, but is implicit when a delete event is fired.
+	},
+	&database.RateLimitPolicy{}: {
+		"id":             ActionTrack,
+		"subject_type":   ActionTrack,
+		"subject":        ActionTrack,
+		"count":          ActionTrack,
+		"window_seconds": ActionTrack,
+		"created_at":     ActionIgnore, // Never changes.
+		"updated_at":     ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+	},
+	&database.WorkspaceSessionRecording{}: {
+		"id":           ActionTrack,
+		"created_at":   ActionIgnore, // Never changes.
+		"workspace_id": ActionTrack,
+		"agent_id":     ActionTrack,
+		"file_id":      ActionTrack,
+		"type":         ActionTrack,
+		"started_at":   ActionTrack,
+		"ended_at":     ActionTrack,
+		"user_id":      ActionTrack,
+	},
+	&database.CustomRole{}: {
+		"id":               ActionTrack,
+		"name":             ActionTrack,
+		"display_name":     ActionTrack,
+		"organization_id":  ActionTrack,
+		"site_permissions": ActionTrack,
+		"org_permissions":  ActionTrack,
+		"user_permissions": ActionTrack,
+		"created_at":       ActionIgnore, // Never changes.
+		"updated_at":       ActionIgnore, // Changes, but is implicit and not helpful in a diff.
 	},
 })
 
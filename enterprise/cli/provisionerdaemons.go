@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	agpl "github.com/coder/coder/cli"
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/cli/deployment"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/terraform"
+	"github.com/coder/coder/provisionerd"
+	provisionerdproto "github.com/coder/coder/provisionerd/proto"
+	"github.com/coder/coder/provisionersdk"
+	"github.com/coder/coder/provisionersdk/proto"
+)
+
+func provisionerDaemons() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "provisionerd",
+		Short: "Manage provisioner daemons",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		provisionerDaemonStart(),
+	)
+
+	return cmd
+}
+
+func provisionerDaemonStart() *cobra.Command {
+	var (
+		cacheDir     string
+		preSharedKey string
+		rawTags      []string
+		pollInterval time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Run a provisioner daemon that connects to a Coder deployment",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			notifyCtx, notifyStop := signal.NotifyContext(ctx, os.Interrupt)
+			defer notifyStop()
+
+			tags, err := agpl.ParseProvisionerTags(rawTags)
+			if err != nil {
+				return err
+			}
+			// The organization is only taken from the flag, not from "coder
+			// organizations switch", so that a daemon started with a
+			// pre-shared key serves every organization unless told otherwise.
+			organization, err := cmd.Flags().GetString("org")
+			if err != nil {
+				return err
+			}
+
+			// Without a pre-shared key, the daemon authenticates as the
+			// logged in user, who must be allowed to create provisioner
+			// daemons in the organization.
+			var client *codersdk.Client
+			if preSharedKey != "" {
+				client, err = agpl.CreateUnauthenticatedClient(cmd)
+			} else {
+				client, err = agpl.CreateClient(cmd)
+			}
+			if err != nil {
+				return xerrors.Errorf("create client: %w", err)
+			}
+
+			logger := slog.Make(sloghuman.Sink(cmd.ErrOrStderr()))
+			errCh := make(chan error, 1)
+
+			err = os.MkdirAll(cacheDir, 0o700)
+			if err != nil {
+				return xerrors.Errorf("mkdir %q: %w", cacheDir, err)
+			}
+
+			terraformClient, terraformServer := provisionersdk.TransportPipe()
+			go func() {
+				<-ctx.Done()
+				_ = terraformClient.Close()
+				_ = terraformServer.Close()
+			}()
+			go func() {
+				defer cancel()
+
+				err := terraform.Serve(ctx, &terraform.ServeOptions{
+					ServeOptions: &provisionersdk.ServeOptions{
+						Listener: terraformServer,
+					},
+					CachePath: cacheDir,
+					Logger:    logger.Named("terraform"),
+				})
+				if err != nil && !xerrors.Is(err, context.Canceled) {
+					select {
+					case errCh <- err:
+					default:
+					}
+				}
+			}()
+
+			tempDir, err := os.MkdirTemp("", "provisionerd")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tempDir)
+
+			provisioners := provisionerd.Provisioners{
+				string(codersdk.ProvisionerTypeTerraform): proto.NewDRPCProvisionerClient(provisionersdk.Conn(terraformClient)),
+			}
+			srv := provisionerd.New(func(ctx context.Context) (provisionerdproto.DRPCProvisionerDaemonClient, error) {
+				return client.ServeProvisionerDaemon(ctx, []codersdk.ProvisionerType{
+					codersdk.ProvisionerTypeTerraform,
+				}, tags, organization, preSharedKey)
+			}, &provisionerd.Options{
+				Logger:         logger.Named("provisionerd"),
+				PollInterval:   pollInterval,
+				UpdateInterval: 500 * time.Millisecond,
+				Provisioners:   provisioners,
+				WorkDirectory:  tempDir,
+			})
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Started provisioner daemon connected to %s!\n", cliui.Styles.Field.Render(client.URL.String()))
+
+			var exitErr error
+			select {
+			case <-notifyCtx.Done():
+				exitErr = notifyCtx.Err()
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), cliui.Styles.Bold.Render(
+					"Interrupt caught, gracefully exiting. Use ctrl+\\ to force quit",
+				))
+			case exitErr = <-errCh:
+			}
+			if exitErr != nil && !xerrors.Is(exitErr, context.Canceled) {
+				cmd.Printf("Unexpected error, shutting down server: %s\n", exitErr)
+			}
+
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer shutdownCancel()
+			err = srv.Shutdown(shutdownCtx)
+			if err != nil {
+				cmd.Printf("Failed to shut down provisioner daemon: %s\n", err)
+			}
+			cancel()
+			if xerrors.Is(exitErr, context.Canceled) {
+				return nil
+			}
+			return exitErr
+		},
+	}
+
+	cliflag.StringVarP(cmd.Flags(), &cacheDir, "cache-dir", "c", "CODER_CACHE_DIRECTORY", deployment.DefaultCacheDir(),
+		"Specify a directory to cache provisioner job files.")
+	cliflag.StringVarP(cmd.Flags(), &preSharedKey, "psk", "", "CODER_PROVISIONER_DAEMON_PSK", "",
+		"Pre-shared key to authenticate with Coder. This must match the value of \"coder server --provisioner-daemon-psk\". If unset, the daemon authenticates as the logged in user.")
+	cliflag.StringArrayVarP(cmd.Flags(), &rawTags, "tag", "t", "CODER_PROVISIONERD_TAGS", []string{},
+		"Specify a list of tags to target provisioner jobs, in the format key=value.")
+	cliflag.DurationVarP(cmd.Flags(), &pollInterval, "poll-interval", "", "CODER_PROVISIONERD_POLL_INTERVAL", time.Second,
+		"How often to poll for provisioner jobs.")
+
+	return cmd
+}
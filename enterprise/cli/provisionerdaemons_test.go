@@ -0,0 +1,95 @@
+package cli_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/enterprise/cli"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestProvisionerDaemonStart(t *testing.T) {
+	t.Parallel()
+	t.Run("OrganizationAdmin", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		orgAdmin := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleOrgAdmin(user.OrganizationID))
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		org, err := client.Organization(ctx, user.OrganizationID)
+		require.NoError(t, err)
+
+		// Without a pre-shared key, the daemon authenticates as the
+		// organization admin and serves the organization's jobs.
+		cmd, root := clitest.NewWithSubcommands(t, cli.EnterpriseSubcommands(), "provisionerd", "start",
+			"--org", org.Name,
+			"--cache-dir", t.TempDir(),
+		)
+		clitest.SetupConfig(t, orgAdmin, root)
+		pty := ptytest.New(t)
+		cmd.SetOut(pty.Output())
+		cmd.SetErr(pty.Output())
+
+		errC := make(chan error, 1)
+		go func() {
+			errC <- cmd.ExecuteContext(ctx)
+		}()
+		pty.ExpectMatch("Started provisioner daemon")
+
+		require.Eventually(t, func() bool {
+			daemons, err := client.ProvisionerDaemons(ctx)
+			return err == nil && len(daemons) == 1 &&
+				daemons[0].OrganizationID != nil && *daemons[0].OrganizationID == org.ID
+		}, testutil.WaitLong, testutil.IntervalFast)
+		cancel()
+		<-errC
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		cmd, root := clitest.NewWithSubcommands(t, cli.EnterpriseSubcommands(), "provisionerd", "start",
+			"--psk", "provisionersftw",
+			"--cache-dir", t.TempDir(),
+		)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetOut(pty.Output())
+		cmd.SetErr(pty.Output())
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		errC := make(chan error, 1)
+		go func() {
+			errC <- cmd.ExecuteContext(ctx)
+		}()
+		pty.ExpectMatch("Started provisioner daemon")
+
+		require.Eventually(t, func() bool {
+			daemons, err := client.ProvisionerDaemons(ctx)
+			return err == nil && len(daemons) == 1
+		}, testutil.WaitLong, testutil.IntervalFast)
+		cancel()
+		<-errC
+	})
+}
@@ -12,6 +12,8 @@ func enterpriseOnly() []*cobra.Command {
 		features(),
 		licenses(),
 		groups(),
+		provisionerDaemons(),
+		workspaceProxy(),
 	}
 }
 
@@ -13,6 +13,7 @@ import (
 	"tailscale.com/types/key"
 
 	"github.com/coder/coder/cli/deployment"
+	"github.com/coder/coder/coderd/workspaceapps"
 	"github.com/coder/coder/cryptorand"
 	"github.com/coder/coder/enterprise/audit"
 	"github.com/coder/coder/enterprise/audit/backends"
@@ -50,6 +51,26 @@ func server() *cobra.Command {
 		}
 		options.DERPServer.SetMeshKey(meshKey)
 
+		appSecurityKeyStr, err := options.Database.GetAppSecurityKey(ctx)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return nil, nil, xerrors.Errorf("get app security key: %w", err)
+			}
+			appSecurityKey, err := workspaceapps.GenerateSecurityKey()
+			if err != nil {
+				return nil, nil, xerrors.Errorf("generate app security key: %w", err)
+			}
+			appSecurityKeyStr = appSecurityKey.String()
+			err = options.Database.InsertAppSecurityKey(ctx, appSecurityKeyStr)
+			if err != nil {
+				return nil, nil, xerrors.Errorf("insert app security key: %w", err)
+			}
+		}
+		options.AppSecurityKey, err = workspaceapps.KeyFromString(appSecurityKeyStr)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("decode app security key from database: %w", err)
+		}
+
 		if options.DeploymentConfig.AuditLogging.Value {
 			options.Auditor = audit.NewAuditor(audit.DefaultFilter,
 				backends.NewPostgres(options.Database, true),
@@ -62,6 +83,7 @@ func server() *cobra.Command {
 			BrowserOnly:            options.DeploymentConfig.BrowserOnly.Value,
 			SCIMAPIKey:             []byte(options.DeploymentConfig.SCIMAPIKey.Value),
 			UserWorkspaceQuota:     options.DeploymentConfig.UserWorkspaceQuota.Value,
+			ProvisionerDaemonPSK:   options.DeploymentConfig.ProvisionerDaemonPSK.Value,
 			RBAC:                   true,
 			DERPServerRelayAddress: options.DeploymentConfig.DERP.Server.RelayURL.Value,
 			DERPServerRegionID:     options.DeploymentConfig.DERP.Server.RegionID.Value,
@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	agpl "github.com/coder/coder/cli"
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/wsproxy"
+)
+
+func workspaceProxy() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "wsproxy",
+		Short:   "Manage workspace proxies",
+		Aliases: []string{"workspace-proxy"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		workspaceProxyServer(),
+		workspaceProxyCreate(),
+		workspaceProxyList(),
+		workspaceProxyDelete(),
+	)
+
+	return cmd
+}
+
+func workspaceProxyCreate() *cobra.Command {
+	var (
+		displayName string
+		icon        string
+	)
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a workspace proxy and print its session token",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := agpl.CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create client: %w", err)
+			}
+
+			res, err := client.CreateWorkspaceProxy(cmd.Context(), codersdk.CreateWorkspaceProxyRequest{
+				Name:        args[0],
+				DisplayName: displayName,
+				Icon:        icon,
+			})
+			if err != nil {
+				return xerrors.Errorf("create workspace proxy: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Successfully created workspace proxy %s!\n\n", cliui.Styles.Keyword.Render(res.Proxy.Name))
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Start the proxy with the following session token. It will not be shown again:")
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n", res.ProxyToken)
+			return nil
+		},
+	}
+
+	cliflag.StringVarP(cmd.Flags(), &displayName, "display-name", "", "CODER_WSPROXY_DISPLAY_NAME", "", "Display name of the proxy shown to users, e.g. \"Sydney\".")
+	cliflag.StringVarP(cmd.Flags(), &icon, "icon", "", "CODER_WSPROXY_ICON", "", "Icon URL of the proxy shown to users.")
+
+	return cmd
+}
+
+func workspaceProxyList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List workspace proxies",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := agpl.CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create client: %w", err)
+			}
+
+			proxies, err := client.WorkspaceProxies(cmd.Context())
+			if err != nil {
+				return xerrors.Errorf("get workspace proxies: %w", err)
+			}
+
+			if len(proxies) == 0 {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s No workspace proxies found! Create one:\n\n", agpl.Caret)
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), color.HiMagentaString("  $ coder wsproxy create <name>\n"))
+				return nil
+			}
+
+			out, err := displayWorkspaceProxies(proxies...)
+			if err != nil {
+				return xerrors.Errorf("display workspace proxies: %w", err)
+			}
+
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return nil
+		},
+	}
+	return cmd
+}
+
+type workspaceProxyTableRow struct {
+	Name             string    `table:"name"`
+	DisplayName      string    `table:"display_name"`
+	URL              string    `table:"url"`
+	WildcardHostname string    `table:"wildcard_hostname"`
+	DERPEnabled      bool      `table:"derp_enabled"`
+	ID               uuid.UUID `table:"id"`
+}
+
+func displayWorkspaceProxies(proxies ...codersdk.WorkspaceProxy) (string, error) {
+	rows := make([]workspaceProxyTableRow, 0, len(proxies))
+	for _, proxy := range proxies {
+		rows = append(rows, workspaceProxyTableRow{
+			Name:             proxy.Name,
+			DisplayName:      proxy.DisplayName,
+			URL:              proxy.URL,
+			WildcardHostname: proxy.WildcardHostname,
+			DERPEnabled:      proxy.DERPEnabled,
+			ID:               proxy.ID,
+		})
+	}
+
+	return cliui.DisplayTable(rows, "name", nil)
+}
+
+func workspaceProxyDelete() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a workspace proxy",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := agpl.CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create client: %w", err)
+			}
+
+			err = client.DeleteWorkspaceProxyByName(cmd.Context(), args[0])
+			if err != nil {
+				return xerrors.Errorf("delete workspace proxy: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Successfully deleted workspace proxy %s!\n", cliui.Styles.Keyword.Render(args[0]))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func workspaceProxyServer() *cobra.Command {
+	var (
+		primaryAccessURL    string
+		proxySessionToken   string
+		accessURL           string
+		wildcardAccessURL   string
+		address             string
+		derpEnabled         bool
+		secureAuthCookie    bool
+		trustedProxyHeaders []string
+		trustedOrigins      []string
+	)
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "Run a workspace proxy that serves apps and relays DERP traffic for a Coder deployment",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			notifyCtx, notifyStop := signal.NotifyContext(ctx, os.Interrupt)
+			defer notifyStop()
+
+			if proxySessionToken == "" {
+				return xerrors.New("a proxy session token must be provided with --proxy-session-token")
+			}
+			primaryURL, err := url.Parse(primaryAccessURL)
+			if err != nil {
+				return xerrors.Errorf("parse primary access URL %q: %w", primaryAccessURL, err)
+			}
+			proxyURL, err := url.Parse(accessURL)
+			if err != nil {
+				return xerrors.Errorf("parse access URL %q: %w", accessURL, err)
+			}
+
+			var (
+				appHostname      string
+				appHostnameRegex *regexp.Regexp
+			)
+			if wildcardAccessURL != "" {
+				appHostname = strings.TrimPrefix(wildcardAccessURL, "http://")
+				appHostname = strings.TrimPrefix(appHostname, "https://")
+				appHostnameRegex, err = httpapi.CompileHostnamePattern(appHostname)
+				if err != nil {
+					return xerrors.Errorf("parse wildcard access URL %q: %w", appHostname, err)
+				}
+			}
+
+			realIPConfig, err := httpmw.ParseRealIPConfig(trustedProxyHeaders, trustedOrigins)
+			if err != nil {
+				return xerrors.Errorf("parse real ip config: %w", err)
+			}
+
+			logger := slog.Make(sloghuman.Sink(cmd.ErrOrStderr()))
+			srv, err := wsproxy.New(ctx, &wsproxy.Options{
+				Logger:            logger.Named("wsproxy"),
+				PrimaryAccessURL:  primaryURL,
+				AccessURL:         proxyURL,
+				AppHostname:       appHostname,
+				AppHostnameRegex:  appHostnameRegex,
+				RealIPConfig:      realIPConfig,
+				ProxySessionToken: proxySessionToken,
+				DERPEnabled:       derpEnabled,
+				SecureAuthCookie:  secureAuthCookie,
+			})
+			if err != nil {
+				return xerrors.Errorf("create workspace proxy: %w", err)
+			}
+			defer srv.Close()
+
+			listener, err := net.Listen("tcp", address)
+			if err != nil {
+				return xerrors.Errorf("listen %q: %w", address, err)
+			}
+			defer listener.Close()
+
+			// ReadHeaderTimeout is purposefully not enabled. It caused some
+			// issues with websockets over the dev tunnel.
+			// See: https://github.com/coder/coder/pull/3730
+			//nolint:gosec
+			httpServer := &http.Server{
+				Handler: srv.Handler,
+				BaseContext: func(_ net.Listener) context.Context {
+					return ctx
+				},
+			}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- httpServer.Serve(listener)
+			}()
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Started workspace proxy on %s, connected to %s!\n",
+				cliui.Styles.Field.Render(proxyURL.String()), cliui.Styles.Field.Render(primaryURL.String()))
+
+			var exitErr error
+			select {
+			case <-notifyCtx.Done():
+				exitErr = notifyCtx.Err()
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), cliui.Styles.Bold.Render(
+					"Interrupt caught, gracefully exiting. Use ctrl+\\ to force quit",
+				))
+			case exitErr = <-errCh:
+			}
+			if exitErr != nil && !xerrors.Is(exitErr, context.Canceled) {
+				cmd.Printf("Unexpected error, shutting down server: %s\n", exitErr)
+			}
+
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer shutdownCancel()
+			err = httpServer.Shutdown(shutdownCtx)
+			if err != nil {
+				cmd.Printf("Failed to shut down workspace proxy: %s\n", err)
+			}
+			cancel()
+			if xerrors.Is(exitErr, context.Canceled) {
+				return nil
+			}
+			return exitErr
+		},
+	}
+
+	cliflag.StringVarP(cmd.Flags(), &primaryAccessURL, "primary-access-url", "", "CODER_PRIMARY_ACCESS_URL", "",
+		"URL of the primary Coder deployment the proxy registers with.")
+	cliflag.StringVarP(cmd.Flags(), &proxySessionToken, "proxy-session-token", "", "CODER_PROXY_SESSION_TOKEN", "",
+		"Session token returned by \"coder wsproxy create\" used to authenticate with the primary.")
+	cliflag.StringVarP(cmd.Flags(), &accessURL, "access-url", "", "CODER_ACCESS_URL", "",
+		"External URL users and agents use to reach this proxy.")
+	cliflag.StringVarP(cmd.Flags(), &wildcardAccessURL, "wildcard-access-url", "", "CODER_WILDCARD_ACCESS_URL", "",
+		"Wildcard hostname this proxy serves subdomain apps on, e.g. \"*.apac.coder.com\".")
+	cliflag.StringVarP(cmd.Flags(), &address, "http-address", "", "CODER_HTTP_ADDRESS", "127.0.0.1:3000",
+		"HTTP bind address of the proxy.")
+	cliflag.BoolVarP(cmd.Flags(), &derpEnabled, "derp-server-enable", "", "CODER_DERP_SERVER_ENABLE", true,
+		"Run a DERP relay on the proxy and advertise it as a region to clients and agents.")
+	cliflag.BoolVarP(cmd.Flags(), &secureAuthCookie, "secure-auth-cookie", "", "CODER_SECURE_AUTH_COOKIE", false,
+		"Set the Secure attribute on cookies set by the proxy. Enable this when the proxy is served over HTTPS.")
+	cliflag.StringArrayVarP(cmd.Flags(), &trustedProxyHeaders, "proxy-trusted-headers", "", "CODER_PROXY_TRUSTED_HEADERS", []string{},
+		"Headers to trust for forwarding IP addresses, e.g. Cf-Connecting-Ip, True-Client-Ip, X-Forwarded-For.")
+	cliflag.StringArrayVarP(cmd.Flags(), &trustedOrigins, "proxy-trusted-origins", "", "CODER_PROXY_TRUSTED_ORIGINS", []string{},
+		"Origin addresses to respect \"proxy-trusted-headers\", e.g. 192.168.1.0/24.")
+
+	return cmd
+}
@@ -0,0 +1,82 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/enterprise/cli"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceProxy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CreateListDelete", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			WorkspaceProxy: true,
+		})
+		ctx, _ := testutil.Context(t)
+
+		cmd, root := clitest.NewWithSubcommands(t, cli.EnterpriseSubcommands(), "wsproxy", "create", "sydney", "--display-name", "Sydney")
+		pty := ptytest.New(t)
+		cmd.SetOut(pty.Output())
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		pty.ExpectMatch("Successfully created workspace proxy")
+
+		proxies, err := client.WorkspaceProxies(ctx)
+		require.NoError(t, err)
+		require.Len(t, proxies, 1)
+		require.Equal(t, "Sydney", proxies[0].DisplayName)
+		pty.ExpectMatch(proxies[0].ID.String() + ":")
+
+		cmd, root = clitest.NewWithSubcommands(t, cli.EnterpriseSubcommands(), "wsproxy", "list")
+		pty = ptytest.New(t)
+		cmd.SetOut(pty.Output())
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.NoError(t, err)
+		pty.ExpectMatch("sydney")
+		pty.ExpectMatch("Sydney")
+
+		cmd, root = clitest.NewWithSubcommands(t, cli.EnterpriseSubcommands(), "wsproxy", "delete", "sydney")
+		pty = ptytest.New(t)
+		cmd.SetOut(pty.Output())
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.NoError(t, err)
+		pty.ExpectMatch("Successfully deleted workspace proxy")
+
+		proxies, err = client.WorkspaceProxies(ctx)
+		require.NoError(t, err)
+		require.Len(t, proxies, 0)
+	})
+
+	t.Run("ListNone", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			WorkspaceProxy: true,
+		})
+
+		cmd, root := clitest.NewWithSubcommands(t, cli.EnterpriseSubcommands(), "wsproxy", "list")
+		pty := ptytest.New(t)
+		cmd.SetErr(pty.Output())
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		pty.ExpectMatch("No workspace proxies found")
+	})
+}
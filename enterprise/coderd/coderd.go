@@ -7,16 +7,19 @@ import (
 	"crypto/x509"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/xerrors"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/go-chi/chi/v5"
+	"tailscale.com/tailcfg"
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd"
 	agplaudit "github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
@@ -42,9 +45,6 @@ func New(ctx context.Context, options *Options) (*API, error) {
 	if options.Options == nil {
 		options.Options = &coderd.Options{}
 	}
-	if options.Options.Authorizer == nil {
-		options.Options.Authorizer = rbac.NewAuthorizer()
-	}
 	ctx, cancelFunc := context.WithCancel(ctx)
 	api := &API{
 		AGPL:                   coderd.New(options.Options),
@@ -112,6 +112,47 @@ func New(ctx context.Context, options *Options) (*API, error) {
 			r.Delete("/", api.deleteGroup)
 		})
 
+		r.Route("/provisionerdaemons/serve", func(r chi.Router) {
+			r.Use(
+				api.provisionerDaemonsEnabledMW,
+				// Daemons may authenticate with the pre-shared key
+				// instead of a session token.
+				httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
+					DB:            options.Database,
+					OAuth2Configs: oauthConfigs,
+					Optional:      true,
+				}),
+			)
+			r.Get("/", api.provisionerDaemonServe)
+		})
+
+		r.Route("/workspaceproxies", func(r chi.Router) {
+			r.Use(api.workspaceProxyEnabledMW)
+			r.Route("/me", func(r chi.Router) {
+				r.Use(httpmw.ExtractWorkspaceProxy(api.Database))
+				r.Post("/register", api.registerWorkspaceProxy)
+				r.Post("/issue-signed-app-token", api.issueSignedAppToken)
+				r.Post("/app-api-key", api.exchangeAppAPIKey)
+				r.Get("/coordinate", api.workspaceProxyCoordinate)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(apiKeyMiddleware)
+				r.Post("/", api.postWorkspaceProxy)
+				r.Get("/", api.workspaceProxies)
+				r.Route("/{workspaceproxy}", func(r chi.Router) {
+					r.Use(httpmw.ExtractWorkspaceProxyParam(api.Database))
+					r.Delete("/", api.deleteWorkspaceProxy)
+				})
+			})
+		})
+		r.Route("/regions", func(r chi.Router) {
+			r.Use(
+				api.workspaceProxyEnabledMW,
+				apiKeyMiddleware,
+			)
+			r.Get("/", api.regions)
+		})
+
 		r.Route("/workspace-quota", func(r chi.Router) {
 			r.Use(apiKeyMiddleware)
 			r.Route("/{user}", func(r chi.Router) {
@@ -119,6 +160,15 @@ func New(ctx context.Context, options *Options) (*API, error) {
 				r.Get("/", api.workspaceQuota)
 			})
 		})
+		r.Route("/organizations/{organization}/members/{user}/workspace-quota", func(r chi.Router) {
+			r.Use(
+				apiKeyMiddleware,
+				httpmw.ExtractOrganizationParam(options.Database),
+				httpmw.ExtractUserParam(options.Database, false),
+				httpmw.ExtractOrganizationMemberParam(options.Database),
+			)
+			r.Get("/", api.organizationWorkspaceQuota)
+		})
 	})
 
 	if len(options.SCIMAPIKey) != 0 {
@@ -173,6 +223,10 @@ func New(ctx context.Context, options *Options) (*API, error) {
 	}
 	go api.runEntitlementsLoop(ctx)
 
+	derpMapper := api.workspaceProxyDERPMapper
+	api.AGPL.DERPMapper.Store(&derpMapper)
+	go api.runWorkspaceProxyDERPRefreshLoop(ctx)
+
 	return api, nil
 }
 
@@ -185,6 +239,9 @@ type Options struct {
 	BrowserOnly        bool
 	SCIMAPIKey         []byte
 	UserWorkspaceQuota int
+	// Enables external provisioner daemons that authenticate with
+	// this pre-shared key.
+	ProvisionerDaemonPSK string
 
 	// Used for high availability.
 	DERPServerRelayAddress string
@@ -203,6 +260,9 @@ type API struct {
 	// Meshes DERP connections from multiple replicas.
 	derpMesh *derpmesh.Mesh
 
+	// Caches the DERP regions served by workspace proxies.
+	workspaceProxyDERPRegions atomic.Pointer[[]*tailcfg.DERPRegion]
+
 	cancelEntitlementsLoop func()
 	entitlementsMu         sync.RWMutex
 	entitlements           codersdk.Entitlements
@@ -219,14 +279,21 @@ func (api *API) updateEntitlements(ctx context.Context) error {
 	api.entitlementsMu.Lock()
 	defer api.entitlementsMu.Unlock()
 
+	proxies, err := api.Database.GetWorkspaceProxies(ctx)
+	if err != nil {
+		return xerrors.Errorf("get workspace proxies: %w", err)
+	}
+
 	entitlements, err := license.Entitlements(ctx, api.Database, api.Logger, len(api.replicaManager.All()), len(api.GitAuthConfigs), api.Keys, map[string]bool{
-		codersdk.FeatureAuditLog:         api.AuditLogging,
-		codersdk.FeatureBrowserOnly:      api.BrowserOnly,
-		codersdk.FeatureSCIM:             len(api.SCIMAPIKey) != 0,
-		codersdk.FeatureWorkspaceQuota:   api.UserWorkspaceQuota != 0,
-		codersdk.FeatureHighAvailability: api.DERPServerRelayAddress != "",
-		codersdk.FeatureMultipleGitAuth:  len(api.GitAuthConfigs) > 1,
-		codersdk.FeatureTemplateRBAC:     api.RBAC,
+		codersdk.FeatureAuditLog:                   api.AuditLogging,
+		codersdk.FeatureBrowserOnly:                api.BrowserOnly,
+		codersdk.FeatureSCIM:                       len(api.SCIMAPIKey) != 0,
+		codersdk.FeatureWorkspaceQuota:             api.UserWorkspaceQuota != 0,
+		codersdk.FeatureHighAvailability:           api.DERPServerRelayAddress != "",
+		codersdk.FeatureMultipleGitAuth:            len(api.GitAuthConfigs) > 1,
+		codersdk.FeatureTemplateRBAC:               api.RBAC,
+		codersdk.FeatureExternalProvisionerDaemons: api.ProvisionerDaemonPSK != "",
+		codersdk.FeatureWorkspaceProxy:             len(proxies) > 0,
 	})
 	if err != nil {
 		return err
@@ -269,6 +336,14 @@ func (api *API) updateEntitlements(ctx context.Context) error {
 		api.AGPL.WorkspaceQuotaEnforcer.Store(&enforcer)
 	}
 
+	if changed, enabled := featureChanged(codersdk.FeatureTemplateRBAC); changed {
+		var syncGroups func(ctx context.Context, tx database.Store, user database.User, groups []string) error
+		if enabled {
+			syncGroups = api.syncOIDCGroups
+		}
+		api.AGPL.OIDCGroupSyncer.Store(&syncGroups)
+	}
+
 	if changed, enabled := featureChanged(codersdk.FeatureHighAvailability); changed {
 		coordinator := agpltailnet.NewCoordinator()
 		if enabled {
@@ -33,23 +33,32 @@ func TestAuthorizeAllEndpoints(t *testing.T) {
 	ctx, _ := testutil.Context(t)
 	admin := coderdtest.CreateFirstUser(t, client)
 	license := coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
-		TemplateRBAC: true,
+		TemplateRBAC:   true,
+		WorkspaceProxy: true,
 	})
 	group, err := client.CreateGroup(ctx, admin.OrganizationID, codersdk.CreateGroupRequest{
 		Name: "testgroup",
 	})
 	require.NoError(t, err)
+	proxy, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+		Name: "testproxy",
+	})
+	require.NoError(t, err)
 
 	groupObj := rbac.ResourceGroup.InOrg(admin.OrganizationID)
 	a := coderdtest.NewAuthTester(ctx, t, client, api.AGPL, admin)
 	a.URLParams["licenses/{id}"] = fmt.Sprintf("licenses/%d", license.ID)
 	a.URLParams["groups/{group}"] = fmt.Sprintf("groups/%s", group.ID.String())
 	a.URLParams["{groupName}"] = group.Name
+	a.URLParams["{workspaceproxy}"] = proxy.Proxy.ID.String()
 
 	skipRoutes, assertRoute := coderdtest.AGPLRoutes(a)
 	assertRoute["GET:/api/v2/entitlements"] = coderdtest.RouteCheck{
 		NoAuthorize: true,
 	}
+	assertRoute["GET:/api/v2/provisionerdaemons/serve"] = coderdtest.RouteCheck{
+		NoAuthorize: true,
+	}
 	assertRoute["POST:/api/v2/licenses"] = coderdtest.RouteCheck{
 		AssertAction: rbac.ActionCreate,
 		AssertObject: rbac.ResourceLicense,
@@ -97,5 +106,40 @@ func TestAuthorizeAllEndpoints(t *testing.T) {
 		AssertObject: groupObj,
 	}
 
+	assertRoute["GET:/api/v2/organizations/{organization}/members/{user}/workspace-quota"] = coderdtest.RouteCheck{
+		AssertAction: rbac.ActionRead,
+		AssertObject: rbac.ResourceOrganizationMember.InOrg(admin.OrganizationID),
+	}
+
+	assertRoute["POST:/api/v2/workspaceproxies"] = coderdtest.RouteCheck{
+		AssertAction: rbac.ActionCreate,
+		AssertObject: rbac.ResourceWorkspaceProxy,
+	}
+	assertRoute["GET:/api/v2/workspaceproxies"] = coderdtest.RouteCheck{
+		AssertAction: rbac.ActionRead,
+		AssertObject: rbac.ResourceWorkspaceProxy,
+	}
+	assertRoute["DELETE:/api/v2/workspaceproxies/{workspaceproxy}"] = coderdtest.RouteCheck{
+		AssertAction: rbac.ActionDelete,
+		AssertObject: rbac.ResourceWorkspaceProxy,
+	}
+	assertRoute["GET:/api/v2/regions"] = coderdtest.RouteCheck{
+		NoAuthorize: true,
+	}
+	// Workspace proxy routes authenticate with a proxy token instead of a
+	// user session, so they never hit the authorizer.
+	assertRoute["POST:/api/v2/workspaceproxies/me/register"] = coderdtest.RouteCheck{
+		NoAuthorize: true,
+	}
+	assertRoute["POST:/api/v2/workspaceproxies/me/issue-signed-app-token"] = coderdtest.RouteCheck{
+		NoAuthorize: true,
+	}
+	assertRoute["POST:/api/v2/workspaceproxies/me/app-api-key"] = coderdtest.RouteCheck{
+		NoAuthorize: true,
+	}
+	assertRoute["GET:/api/v2/workspaceproxies/me/coordinate"] = coderdtest.RouteCheck{
+		NoAuthorize: true,
+	}
+
 	a.Test(context.Background(), assertRoute, skipRoutes)
 }
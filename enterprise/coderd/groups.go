@@ -1,6 +1,7 @@
 package coderd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
@@ -8,12 +9,14 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
+	"cdr.dev/slog"
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/util/slice"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -66,7 +69,7 @@ func (api *API) postGroupByOrganization(rw http.ResponseWriter, r *http.Request)
 	}
 	aReq.New = group
 
-	httpapi.Write(ctx, rw, http.StatusCreated, convertGroup(group, nil))
+	httpapi.Write(ctx, rw, http.StatusCreated, convertGroup(api.AGPL.CustomRoles, group, nil))
 }
 
 func (api *API) patchGroup(rw http.ResponseWriter, r *http.Request) {
@@ -216,7 +219,7 @@ func (api *API) patchGroup(rw http.ResponseWriter, r *http.Request) {
 
 	aReq.New = group
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertGroup(group, members))
+	httpapi.Write(ctx, rw, http.StatusOK, convertGroup(api.AGPL.CustomRoles, group, members))
 }
 
 func (api *API) deleteGroup(rw http.ResponseWriter, r *http.Request) {
@@ -257,6 +260,84 @@ func (api *API) deleteGroup(rw http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// syncOIDCGroups makes a user that logs in with OIDC a member of exactly the
+// named groups in each of their organizations. Groups that don't exist yet
+// are created, unless their name isn't valid.
+func (api *API) syncOIDCGroups(ctx context.Context, tx database.Store, user database.User, groupNames []string) error {
+	memberships, err := tx.GetOrganizationMembershipsByUserID(ctx, user.ID)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("get organization memberships: %w", err)
+	}
+	current, err := tx.GetUserGroups(ctx, user.ID)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("get user groups: %w", err)
+	}
+
+	wanted := make([]string, 0, len(groupNames))
+	for _, name := range groupNames {
+		// Everyone is a member of the all users group.
+		if name == "" || name == database.AllUsersGroup || slice.Contains(wanted, name) {
+			continue
+		}
+		wanted = append(wanted, name)
+	}
+
+	for _, membership := range memberships {
+		var has []string
+		for _, group := range current {
+			if group.OrganizationID != membership.OrganizationID {
+				continue
+			}
+			if slice.Contains(wanted, group.Name) {
+				has = append(has, group.Name)
+				continue
+			}
+			err = tx.DeleteGroupMemberFromGroup(ctx, database.DeleteGroupMemberFromGroupParams{
+				UserID:  user.ID,
+				GroupID: group.ID,
+			})
+			if err != nil {
+				return xerrors.Errorf("remove user from group %q: %w", group.Name, err)
+			}
+		}
+
+		for _, name := range wanted {
+			if slice.Contains(has, name) {
+				continue
+			}
+			group, err := tx.GetGroupByOrgAndName(ctx, database.GetGroupByOrgAndNameParams{
+				OrganizationID: membership.OrganizationID,
+				Name:           name,
+			})
+			if xerrors.Is(err, sql.ErrNoRows) {
+				// Names in the claim aren't trusted, so only create groups
+				// with names that follow the same rules as usernames.
+				if validErr := httpapi.UsernameValid(name); validErr != nil {
+					api.Logger.Warn(ctx, "not creating oidc group with invalid name",
+						slog.F("name", name), slog.Error(validErr))
+					continue
+				}
+				group, err = tx.InsertGroup(ctx, database.InsertGroupParams{
+					ID:             uuid.New(),
+					Name:           name,
+					OrganizationID: membership.OrganizationID,
+				})
+			}
+			if err != nil {
+				return xerrors.Errorf("get or create group %q: %w", name, err)
+			}
+			err = tx.InsertGroupMember(ctx, database.InsertGroupMemberParams{
+				GroupID: group.ID,
+				UserID:  user.ID,
+			})
+			if err != nil {
+				return xerrors.Errorf("add user to group %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (api *API) group(rw http.ResponseWriter, r *http.Request) {
 	var (
 		ctx   = r.Context()
@@ -274,7 +355,7 @@ func (api *API) group(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertGroup(group, users))
+	httpapi.Write(ctx, rw, http.StatusOK, convertGroup(api.AGPL.CustomRoles, group, users))
 }
 
 func (api *API) groups(rw http.ResponseWriter, r *http.Request) {
@@ -307,13 +388,13 @@ func (api *API) groups(rw http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		resp = append(resp, convertGroup(group, members))
+		resp = append(resp, convertGroup(api.AGPL.CustomRoles, group, members))
 	}
 
 	httpapi.Write(ctx, rw, http.StatusOK, resp)
 }
 
-func convertGroup(g database.Group, users []database.User) codersdk.Group {
+func convertGroup(customRoles *rbac.CustomRoles, g database.Group, users []database.User) codersdk.Group {
 	// It's ridiculous to query all the orgs of a user here
 	// especially since as of the writing of this comment there
 	// is only one org. So we pretend everyone is only part of
@@ -327,11 +408,11 @@ func convertGroup(g database.Group, users []database.User) codersdk.Group {
 		Name:           g.Name,
 		OrganizationID: g.OrganizationID,
 		AvatarURL:      g.AvatarURL,
-		Members:        convertUsers(users, orgs),
+		Members:        convertUsers(customRoles, users, orgs),
 	}
 }
 
-func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User {
+func convertUser(customRoles *rbac.CustomRoles, user database.User, organizationIDs []uuid.UUID) codersdk.User {
 	convertedUser := codersdk.User{
 		ID:              user.ID,
 		Email:           user.Email,
@@ -342,21 +423,26 @@ func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User
 		OrganizationIDs: organizationIDs,
 		Roles:           make([]codersdk.Role, 0, len(user.RBACRoles)),
 		AvatarURL:       user.AvatarURL.String,
+		ServiceAccount:  user.ServiceAccount,
+		Contact:         user.Contact,
 	}
 
 	for _, roleName := range user.RBACRoles {
-		rbacRole, _ := rbac.RoleByName(roleName)
+		rbacRole, err := customRoles.RoleByName(roleName)
+		if err != nil {
+			rbacRole = rbac.Role{Name: roleName}
+		}
 		convertedUser.Roles = append(convertedUser.Roles, convertRole(rbacRole))
 	}
 
 	return convertedUser
 }
 
-func convertUsers(users []database.User, organizationIDsByUserID map[uuid.UUID][]uuid.UUID) []codersdk.User {
+func convertUsers(customRoles *rbac.CustomRoles, users []database.User, organizationIDsByUserID map[uuid.UUID][]uuid.UUID) []codersdk.User {
 	converted := make([]codersdk.User, 0, len(users))
 	for _, u := range users {
 		userOrganizationIDs := organizationIDsByUserID[u.ID]
-		converted = append(converted, convertUser(u, userOrganizationIDs))
+		converted = append(converted, convertUser(customRoles, u, userOrganizationIDs))
 	}
 	return converted
 }
@@ -123,6 +123,18 @@ func Entitlements(
 				Enabled:     true,
 			}
 		}
+		if claims.Features.ExternalProvisionerDaemons > 0 {
+			entitlements.Features[codersdk.FeatureExternalProvisionerDaemons] = codersdk.Feature{
+				Entitlement: entitlement,
+				Enabled:     enablements[codersdk.FeatureExternalProvisionerDaemons],
+			}
+		}
+		if claims.Features.WorkspaceProxy > 0 {
+			entitlements.Features[codersdk.FeatureWorkspaceProxy] = codersdk.Feature{
+				Entitlement: entitlement,
+				Enabled:     enablements[codersdk.FeatureWorkspaceProxy],
+			}
+		}
 		if claims.AllFeatures {
 			allFeatures = true
 		}
@@ -244,14 +256,16 @@ var (
 )
 
 type Features struct {
-	UserLimit        int64 `json:"user_limit"`
-	AuditLog         int64 `json:"audit_log"`
-	BrowserOnly      int64 `json:"browser_only"`
-	SCIM             int64 `json:"scim"`
-	WorkspaceQuota   int64 `json:"workspace_quota"`
-	TemplateRBAC     int64 `json:"template_rbac"`
-	HighAvailability int64 `json:"high_availability"`
-	MultipleGitAuth  int64 `json:"multiple_git_auth"`
+	UserLimit                  int64 `json:"user_limit"`
+	AuditLog                   int64 `json:"audit_log"`
+	BrowserOnly                int64 `json:"browser_only"`
+	SCIM                       int64 `json:"scim"`
+	WorkspaceQuota             int64 `json:"workspace_quota"`
+	TemplateRBAC               int64 `json:"template_rbac"`
+	HighAvailability           int64 `json:"high_availability"`
+	MultipleGitAuth            int64 `json:"multiple_git_auth"`
+	ExternalProvisionerDaemons int64 `json:"external_provisioner_daemons"`
+	WorkspaceProxy             int64 `json:"workspace_proxy"`
 }
 
 type Claims struct {
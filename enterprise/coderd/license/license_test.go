@@ -20,13 +20,15 @@ import (
 func TestEntitlements(t *testing.T) {
 	t.Parallel()
 	all := map[string]bool{
-		codersdk.FeatureAuditLog:         true,
-		codersdk.FeatureBrowserOnly:      true,
-		codersdk.FeatureSCIM:             true,
-		codersdk.FeatureWorkspaceQuota:   true,
-		codersdk.FeatureHighAvailability: true,
-		codersdk.FeatureTemplateRBAC:     true,
-		codersdk.FeatureMultipleGitAuth:  true,
+		codersdk.FeatureAuditLog:                   true,
+		codersdk.FeatureBrowserOnly:                true,
+		codersdk.FeatureSCIM:                       true,
+		codersdk.FeatureWorkspaceQuota:             true,
+		codersdk.FeatureHighAvailability:           true,
+		codersdk.FeatureTemplateRBAC:               true,
+		codersdk.FeatureMultipleGitAuth:            true,
+		codersdk.FeatureExternalProvisionerDaemons: true,
+		codersdk.FeatureWorkspaceProxy:             true,
 	}
 
 	t.Run("Defaults", func(t *testing.T) {
@@ -62,14 +64,16 @@ func TestEntitlements(t *testing.T) {
 		db := databasefake.New()
 		db.InsertLicense(context.Background(), database.InsertLicenseParams{
 			JWT: coderdenttest.GenerateLicense(t, coderdenttest.LicenseOptions{
-				UserLimit:        100,
-				AuditLog:         true,
-				BrowserOnly:      true,
-				SCIM:             true,
-				WorkspaceQuota:   true,
-				HighAvailability: true,
-				TemplateRBAC:     true,
-				MultipleGitAuth:  true,
+				UserLimit:                  100,
+				AuditLog:                   true,
+				BrowserOnly:                true,
+				SCIM:                       true,
+				WorkspaceQuota:             true,
+				HighAvailability:           true,
+				TemplateRBAC:               true,
+				MultipleGitAuth:            true,
+				ExternalProvisionerDaemons: true,
+				WorkspaceProxy:             true,
 			}),
 			Exp: time.Now().Add(time.Hour),
 		})
@@ -86,15 +90,17 @@ func TestEntitlements(t *testing.T) {
 		db := databasefake.New()
 		db.InsertLicense(context.Background(), database.InsertLicenseParams{
 			JWT: coderdenttest.GenerateLicense(t, coderdenttest.LicenseOptions{
-				UserLimit:        100,
-				AuditLog:         true,
-				BrowserOnly:      true,
-				SCIM:             true,
-				WorkspaceQuota:   true,
-				HighAvailability: true,
-				TemplateRBAC:     true,
-				GraceAt:          time.Now().Add(-time.Hour),
-				ExpiresAt:        time.Now().Add(time.Hour),
+				UserLimit:                  100,
+				AuditLog:                   true,
+				BrowserOnly:                true,
+				SCIM:                       true,
+				WorkspaceQuota:             true,
+				HighAvailability:           true,
+				TemplateRBAC:               true,
+				ExternalProvisionerDaemons: true,
+				WorkspaceProxy:             true,
+				GraceAt:                    time.Now().Add(-time.Hour),
+				ExpiresAt:                  time.Now().Add(time.Hour),
 			}),
 			Exp: time.Now().Add(time.Hour),
 		})
@@ -165,6 +171,28 @@ func TestEntitlements(t *testing.T) {
 		require.True(t, entitlements.HasLicense)
 		require.Contains(t, entitlements.Warnings, "Your deployment has 2 active users but is only licensed for 1.")
 	})
+	t.Run("ServiceAccountsAreNotUsers", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		db.InsertUser(context.Background(), database.InsertUserParams{
+			Username: "test1",
+		})
+		db.InsertUser(context.Background(), database.InsertUserParams{
+			Username:       "ci-bot",
+			LoginType:      database.LoginTypeToken,
+			ServiceAccount: true,
+		})
+		db.InsertLicense(context.Background(), database.InsertLicenseParams{
+			JWT: coderdenttest.GenerateLicense(t, coderdenttest.LicenseOptions{
+				UserLimit: 1,
+			}),
+			Exp: time.Now().Add(time.Hour),
+		})
+		entitlements, err := license.Entitlements(context.Background(), db, slog.Logger{}, 1, 1, coderdenttest.Keys, map[string]bool{})
+		require.NoError(t, err)
+		require.True(t, entitlements.HasLicense)
+		require.Empty(t, entitlements.Warnings)
+	})
 	t.Run("MaximizeUserLimit", func(t *testing.T) {
 		t.Parallel()
 		db := databasefake.New()
@@ -0,0 +1,197 @@
+package coderd
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/yamux"
+	"github.com/moby/moby/pkg/namesgenerator"
+	"golang.org/x/exp/slices"
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+func (api *API) provisionerDaemonsEnabledMW(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		api.entitlementsMu.RLock()
+		epd := api.entitlements.Features[codersdk.FeatureExternalProvisionerDaemons].Enabled
+		api.entitlementsMu.RUnlock()
+
+		if !epd {
+			httpapi.RouteNotFound(rw)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// provisionerDaemonPSKValid returns whether the request provides the
+// pre-shared key configured for external provisioner daemons.
+func (api *API) provisionerDaemonPSKValid(r *http.Request) bool {
+	psk := []byte(r.Header.Get(codersdk.ProvisionerDaemonPSKHeader))
+	return len(api.ProvisionerDaemonPSK) > 0 && subtle.ConstantTimeCompare(psk, []byte(api.ProvisionerDaemonPSK)) == 1
+}
+
+// provisionerDaemonServe serves the provisioner daemon protobuf API over a
+// WebSocket to a daemon running outside of coderd.
+func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !r.URL.Query().Has("provisioner") {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "The provisioner query parameter must be specified.",
+		})
+		return
+	}
+
+	provisioners := make([]database.ProvisionerType, 0)
+	for _, provisioner := range r.URL.Query()["provisioner"] {
+		switch provisioner {
+		case string(codersdk.ProvisionerTypeEcho), string(codersdk.ProvisionerTypeTerraform):
+			if slices.Contains(provisioners, database.ProvisionerType(provisioner)) {
+				continue
+			}
+			provisioners = append(provisioners, database.ProvisionerType(provisioner))
+		default:
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Unknown provisioner type %q.", provisioner),
+			})
+			return
+		}
+	}
+
+	tags := database.StringMap{}
+	for _, tag := range r.URL.Query()["tag"] {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) < 2 || parts[0] == "" {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid tag %q. Tags must be in the format key=value.", tag),
+			})
+			return
+		}
+		tags[parts[0]] = parts[1]
+	}
+
+	// Daemons can be restricted to the jobs of an organization, which is
+	// passed by name or ID.
+	var organizationID uuid.NullUUID
+	if organization := r.URL.Query().Get("organization"); organization != "" {
+		org, err := api.AGPL.OrganizationByNameOrID(ctx, organization)
+		if errors.Is(err, sql.ErrNoRows) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Organization %q does not exist.", organization),
+			})
+			return
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching organization.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		organizationID = uuid.NullUUID{UUID: org.ID, Valid: true}
+	}
+
+	// Daemons authenticate with the deployment wide pre-shared key, or as a
+	// user who can create provisioner daemons. Daemons that serve the jobs
+	// of every organization need a deployment wide credential, so users
+	// that can only manage an organization must restrict the daemon to it.
+	if r.Header.Get(codersdk.ProvisionerDaemonPSKHeader) != "" {
+		if !api.provisionerDaemonPSKValid(r) {
+			httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+				Message: "A valid provisioner daemon pre-shared key is required.",
+			})
+			return
+		}
+	} else {
+		if _, ok := httpmw.APIKeyOptional(r); !ok {
+			httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+				Message: "A provisioner daemon pre-shared key or a session token is required.",
+			})
+			return
+		}
+		object := rbac.ResourceProvisionerDaemon
+		if organizationID.Valid {
+			object = object.InOrg(organizationID.UUID)
+		}
+		if !api.AGPL.Authorize(r, rbac.ActionCreate, object) {
+			httpapi.Forbidden(rw)
+			return
+		}
+	}
+
+	name := namesgenerator.GetRandomName(1)
+	daemon, err := api.Database.InsertProvisionerDaemon(ctx, database.InsertProvisionerDaemonParams{
+		ID:             uuid.New(),
+		CreatedAt:      database.Now(),
+		Name:           name,
+		Provisioners:   provisioners,
+		Tags:           tags,
+		OrganizationID: organizationID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error writing provisioner daemon.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	api.AGPL.WebsocketWaitMutex.Lock()
+	api.AGPL.WebsocketWaitGroup.Add(1)
+	api.AGPL.WebsocketWaitMutex.Unlock()
+	defer api.AGPL.WebsocketWaitGroup.Done()
+
+	conn, err := websocket.Accept(rw, r, &websocket.AcceptOptions{
+		// Need to disable compression to avoid a data-race.
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Internal error accepting websocket connection.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Align with the frame size of yamux.
+	conn.SetReadLimit(256 * 1024)
+
+	// Multiplexes the incoming connection using yamux.
+	// This allows multiple function calls to occur over
+	// the same connection.
+	config := yamux.DefaultConfig()
+	config.LogOutput = io.Discard
+	session, err := yamux.Server(websocket.NetConn(ctx, conn, websocket.MessageBinary), config)
+	if err != nil {
+		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("multiplex server: %s", err))
+		return
+	}
+
+	api.Logger.Info(ctx, "external provisioner daemon connected",
+		slog.F("name", daemon.Name),
+		slog.F("provisioners", daemon.Provisioners),
+		slog.F("tags", daemon.Tags),
+		slog.F("organization_id", daemon.OrganizationID.UUID))
+	err = api.AGPL.ServeProvisionerDaemon(ctx, session, daemon)
+	if err != nil && !xerrors.Is(err, io.EOF) {
+		api.Logger.Debug(ctx, "provisioner daemon disconnected", slog.F("name", daemon.Name), slog.Error(err))
+		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("serve: %s", err))
+		return
+	}
+	_ = conn.Close(websocket.StatusGoingAway, "")
+}
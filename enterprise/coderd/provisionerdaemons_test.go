@@ -0,0 +1,295 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionerd"
+	provisionerdproto "github.com/coder/coder/provisionerd/proto"
+	"github.com/coder/coder/provisionersdk"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestProvisionerDaemonServe(t *testing.T) {
+	t.Parallel()
+	t.Run("NoLicense", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		_ = coderdtest.CreateFirstUser(t, client)
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := client.ServeProvisionerDaemon(ctx, []codersdk.ProvisionerType{
+			codersdk.ProvisionerTypeEcho,
+		}, nil, "", "provisionersftw")
+		require.Error(t, err)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusNotFound, apiError.StatusCode())
+	})
+
+	t.Run("BadPSK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := client.ServeProvisionerDaemon(ctx, []codersdk.ProvisionerType{
+			codersdk.ProvisionerTypeEcho,
+		}, nil, "", "wrong")
+		require.Error(t, err)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusUnauthorized, apiError.StatusCode())
+	})
+
+	t.Run("UnknownProvisioner", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := client.ServeProvisionerDaemon(ctx, []codersdk.ProvisionerType{
+			"unknown",
+		}, nil, "", "provisionersftw")
+		require.Error(t, err)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusBadRequest, apiError.StatusCode())
+	})
+
+	t.Run("Build", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		serveEchoProvisionerDaemon(t, client, nil)
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		version = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, version.Job.Status)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		daemons, err := client.ProvisionerDaemons(ctx)
+		require.NoError(t, err)
+		require.Len(t, daemons, 1)
+		require.Equal(t, []codersdk.ProvisionerType{codersdk.ProvisionerTypeEcho}, daemons[0].Provisioners)
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		// This daemon has none of the tags the job requires.
+		serveEchoProvisionerDaemon(t, client, map[string]string{
+			"region": "us",
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		data, err := echo.Tar(nil)
+		require.NoError(t, err)
+		file, err := client.Upload(ctx, codersdk.ContentTypeTar, data)
+		require.NoError(t, err)
+		version, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			FileID:        file.ID,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			ProvisionerTags: map[string]string{
+				"region": "eu",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"region": "eu"}, version.Job.Tags)
+
+		require.Never(t, func() bool {
+			version, err := client.TemplateVersion(ctx, version.ID)
+			return err != nil || version.Job.Status != codersdk.ProvisionerJobPending
+		}, testutil.IntervalSlow, testutil.IntervalFast)
+
+		// A daemon with a superset of the job's tags acquires it.
+		serveEchoProvisionerDaemon(t, client, map[string]string{
+			"region": "eu",
+			"env":    "prod",
+		})
+		version = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, version.Job.Status)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		other, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "other",
+		})
+		require.NoError(t, err)
+		serveOrganizationEchoProvisionerDaemon(t, client, nil, other.Name, "provisionersftw")
+
+		// The daemon doesn't acquire jobs of other organizations.
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		require.Never(t, func() bool {
+			version, err := client.TemplateVersion(ctx, version.ID)
+			return err != nil || version.Job.Status != codersdk.ProvisionerJobPending
+		}, testutil.IntervalSlow, testutil.IntervalFast)
+
+		version = coderdtest.CreateTemplateVersion(t, client, other.ID, nil)
+		version = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, version.Job.Status)
+
+		daemons, err := client.ProvisionerDaemons(ctx)
+		require.NoError(t, err)
+		require.Len(t, daemons, 1)
+		require.NotNil(t, daemons[0].OrganizationID)
+		require.Equal(t, other.ID, *daemons[0].OrganizationID)
+	})
+
+	t.Run("OrganizationAdmin", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		other, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "other",
+		})
+		require.NoError(t, err)
+		orgAdmin := coderdtest.CreateAnotherUser(t, client, other.ID, rbac.RoleOrgAdmin(other.ID))
+		member := coderdtest.CreateAnotherUser(t, client, other.ID)
+
+		serve := func(client *codersdk.Client, organization string) error {
+			_, err := client.ServeProvisionerDaemon(ctx, []codersdk.ProvisionerType{
+				codersdk.ProvisionerTypeEcho,
+			}, nil, organization, "")
+			return err
+		}
+		var apiError *codersdk.Error
+		// Only deployment wide credentials can serve every organization.
+		err = serve(orgAdmin, "")
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusForbidden, apiError.StatusCode())
+		err = serve(orgAdmin, user.OrganizationID.String())
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusForbidden, apiError.StatusCode())
+		err = serve(member, other.Name)
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusForbidden, apiError.StatusCode())
+		err = serve(codersdk.New(client.URL), other.Name)
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusUnauthorized, apiError.StatusCode())
+
+		serveOrganizationEchoProvisionerDaemon(t, orgAdmin, nil, other.Name, "")
+		version := coderdtest.CreateTemplateVersion(t, client, other.ID, nil)
+		version = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, version.Job.Status)
+	})
+
+	t.Run("UnknownOrganization", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			ProvisionerDaemonPSK: "provisionersftw",
+		})
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		_, err := client.ServeProvisionerDaemon(ctx, []codersdk.ProvisionerType{
+			codersdk.ProvisionerTypeEcho,
+		}, nil, "unknown", "provisionersftw")
+		require.Error(t, err)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusBadRequest, apiError.StatusCode())
+	})
+}
+
+// serveEchoProvisionerDaemon starts an echo provisioner daemon that
+// connects to the deployment as an external daemon with the tags provided.
+func serveEchoProvisionerDaemon(t *testing.T, client *codersdk.Client, tags map[string]string) {
+	t.Helper()
+	serveOrganizationEchoProvisionerDaemon(t, client, tags, "", "provisionersftw")
+}
+
+// serveOrganizationEchoProvisionerDaemon starts an echo provisioner daemon
+// that only acquires jobs of the organization provided. Without a pre-shared
+// key, the daemon authenticates with the session token of the client.
+func serveOrganizationEchoProvisionerDaemon(t *testing.T, client *codersdk.Client, tags map[string]string, organization string, preSharedKey string) {
+	t.Helper()
+	echoClient, echoServer := provisionersdk.TransportPipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		_ = echoClient.Close()
+		_ = echoServer.Close()
+		cancel()
+	})
+	fs := afero.NewMemMapFs()
+	go func() {
+		_ = echo.Serve(ctx, fs, &provisionersdk.ServeOptions{
+			Listener: echoServer,
+		})
+	}()
+	closer := provisionerd.New(func(ctx context.Context) (provisionerdproto.DRPCProvisionerDaemonClient, error) {
+		return client.ServeProvisionerDaemon(ctx, []codersdk.ProvisionerType{
+			codersdk.ProvisionerTypeEcho,
+		}, tags, organization, preSharedKey)
+	}, &provisionerd.Options{
+		Filesystem:   fs,
+		Logger:       slogtest.Make(t, nil).Named("provisionerd").Leveled(slog.LevelDebug),
+		PollInterval: testutil.IntervalFast,
+		Provisioners: provisionerd.Provisioners{
+			string(codersdk.ProvisionerTypeEcho): proto.NewDRPCProvisionerClient(provisionersdk.Conn(echoClient)),
+		},
+		WorkDirectory: t.TempDir(),
+	})
+	t.Cleanup(func() {
+		_ = closer.Close()
+	})
+}
@@ -81,13 +81,13 @@ func (api *API) templateACL(rw http.ResponseWriter, r *http.Request) {
 		}
 
 		groups = append(groups, codersdk.TemplateGroup{
-			Group: convertGroup(group.Group, members),
+			Group: convertGroup(api.AGPL.CustomRoles, group.Group, members),
 			Role:  convertToTemplateRole(group.Actions),
 		})
 	}
 
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.TemplateACL{
-		Users:  convertTemplateUsers(users, organizationIDsByUserID),
+		Users:  convertTemplateUsers(api.AGPL.CustomRoles, users, organizationIDsByUserID),
 		Groups: groups,
 	})
 }
@@ -219,12 +219,12 @@ func validateTemplateACLPerms(ctx context.Context, db database.Store, perms map[
 	return validErrs
 }
 
-func convertTemplateUsers(tus []database.TemplateUser, orgIDsByUserIDs map[uuid.UUID][]uuid.UUID) []codersdk.TemplateUser {
+func convertTemplateUsers(customRoles *rbac.CustomRoles, tus []database.TemplateUser, orgIDsByUserIDs map[uuid.UUID][]uuid.UUID) []codersdk.TemplateUser {
 	users := make([]codersdk.TemplateUser, 0, len(tus))
 
 	for _, tu := range tus {
 		users = append(users, codersdk.TemplateUser{
-			User: convertUser(tu.User, orgIDsByUserIDs[tu.User.ID]),
+			User: convertUser(customRoles, tu.User, orgIDsByUserIDs[tu.User.ID]),
 			Role: convertToTemplateRole(tu.Actions),
 		})
 	}
@@ -0,0 +1,156 @@
+package coderd_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestUserOIDC(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SyncGroups", func(t *testing.T) {
+		t.Parallel()
+
+		conf := coderdtest.NewOIDCConfig(t, "")
+		config := conf.OIDCConfig()
+		config.AllowSignups = true
+		config.GroupField = "groups"
+		config.GroupMapping = map[string]string{
+			"okta-developers": "developers",
+		}
+
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			Options: &coderdtest.Options{
+				OIDCConfig: config,
+			},
+		})
+		first := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			TemplateRBAC: true,
+		})
+
+		ctx, _ := testutil.Context(t)
+		_, err := client.CreateGroup(ctx, first.OrganizationID, codersdk.CreateGroupRequest{
+			Name: "existing",
+		})
+		require.NoError(t, err)
+
+		resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email":  "jon@coder.com",
+			"groups": []string{"okta-developers", "existing"},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		user, err := client.User(ctx, "jon")
+		require.NoError(t, err)
+		requireGroupMember := func(name string, member bool) {
+			t.Helper()
+			group, err := client.GroupByOrgAndName(ctx, first.OrganizationID, name)
+			require.NoError(t, err)
+			found := false
+			for _, groupMember := range group.Members {
+				if groupMember.ID == user.ID {
+					found = true
+				}
+			}
+			require.Equal(t, member, found, "membership of group %q", name)
+		}
+		// Mapped groups that don't exist are created.
+		requireGroupMember("developers", true)
+		requireGroupMember("existing", true)
+
+		// Groups that are no longer in the claim are left.
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email":  "jon@coder.com",
+			"groups": "existing",
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		requireGroupMember("developers", false)
+		requireGroupMember("existing", true)
+
+		// Groups are left alone when the claim is missing.
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "jon@coder.com",
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		requireGroupMember("existing", true)
+
+		// Groups with invalid names aren't created.
+		invalid := []string{"not_a_group", strings.Repeat("a", 33)}
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email":  "jon@coder.com",
+			"groups": append([]string{"existing"}, invalid...),
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		requireGroupMember("existing", true)
+		for _, name := range invalid {
+			_, err = client.GroupByOrgAndName(ctx, first.OrganizationID, name)
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+		}
+	})
+
+	t.Run("NotEntitled", func(t *testing.T) {
+		t.Parallel()
+
+		conf := coderdtest.NewOIDCConfig(t, "")
+		config := conf.OIDCConfig()
+		config.AllowSignups = true
+		config.GroupField = "groups"
+
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			Options: &coderdtest.Options{
+				OIDCConfig: config,
+			},
+		})
+		first := coderdtest.CreateFirstUser(t, client)
+
+		resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email":  "jon@coder.com",
+			"groups": []string{"developers"},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		// Groups aren't synced without a license.
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			TemplateRBAC: true,
+		})
+		ctx, _ := testutil.Context(t)
+		_, err := client.GroupByOrgAndName(ctx, first.OrganizationID, "developers")
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
+
+func oidcCallback(t *testing.T, client *codersdk.Client, code string) *http.Response {
+	t.Helper()
+	client = codersdk.New(client.URL)
+	client.HTTPClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	oauthURL, err := client.URL.Parse(fmt.Sprintf("/api/v2/users/oidc/callback?code=%s&state=somestate", code))
+	require.NoError(t, err)
+	req, err := http.NewRequestWithContext(context.Background(), "GET", oauthURL.String(), nil)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{
+		Name:  codersdk.OAuth2StateKey,
+		Value: "somestate",
+	})
+	res, err := client.HTTPClient.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	return res
+}
@@ -0,0 +1,522 @@
+package coderd
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+	"tailscale.com/tailcfg"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/cryptorand"
+	"github.com/coder/coder/enterprise/wsproxy/wsproxysdk"
+)
+
+const (
+	// workspaceProxyDERPRegionOffset is added to a proxy's region ID to build
+	// its DERP region ID, so proxy regions never collide with the primary
+	// region or regions from a custom DERP map.
+	workspaceProxyDERPRegionOffset = 10000
+	// workspaceProxyHealthyTimeout is how long after its last registration a
+	// proxy is still considered healthy. Proxies re-register every 30 seconds.
+	workspaceProxyHealthyTimeout = 90 * time.Second
+	// workspaceProxyDERPRefreshInterval is how often the cached proxy DERP
+	// regions are refreshed, to pick up proxies registered with other
+	// replicas.
+	workspaceProxyDERPRefreshInterval = 15 * time.Second
+)
+
+func (api *API) workspaceProxyEnabledMW(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		api.entitlementsMu.RLock()
+		entitlement := api.entitlements.Features[codersdk.FeatureWorkspaceProxy].Entitlement
+		api.entitlementsMu.RUnlock()
+
+		// Enablement tracks whether any proxies exist, so check the
+		// entitlement instead to allow creating the first one.
+		if entitlement == codersdk.EntitlementNotEntitled {
+			httpapi.RouteNotFound(rw)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (api *API) postWorkspaceProxy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		auditor           = api.AGPL.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.WorkspaceProxy](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceWorkspaceProxy) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.CreateWorkspaceProxyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	secret, err := cryptorand.String(32)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	hashedSecret := sha256.Sum256([]byte(secret))
+	displayName := req.DisplayName
+	if displayName == "" {
+		displayName = req.Name
+	}
+	now := database.Now()
+
+	proxy, err := api.Database.InsertWorkspaceProxy(ctx, database.InsertWorkspaceProxyParams{
+		ID:                uuid.New(),
+		Name:              req.Name,
+		DisplayName:       displayName,
+		Icon:              req.Icon,
+		TokenHashedSecret: hashedSecret[:],
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	})
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Workspace proxy with name %q already exists.", req.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	aReq.New = proxy
+
+	err = api.updateEntitlements(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "update entitlements after creating workspace proxy", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.CreateWorkspaceProxyResponse{
+		Proxy:      convertWorkspaceProxy(proxy),
+		ProxyToken: fmt.Sprintf("%s:%s", proxy.ID, secret),
+	})
+}
+
+func (api *API) workspaceProxies(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceWorkspaceProxy) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	proxies, err := api.Database.GetWorkspaceProxies(ctx)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	apiProxies := make([]codersdk.WorkspaceProxy, 0, len(proxies))
+	for _, proxy := range proxies {
+		apiProxies = append(apiProxies, convertWorkspaceProxy(proxy))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiProxies)
+}
+
+func (api *API) deleteWorkspaceProxy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		proxy             = httpmw.WorkspaceProxyParam(r)
+		auditor           = api.AGPL.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.WorkspaceProxy](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = proxy
+
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceWorkspaceProxy) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	err := api.Database.UpdateWorkspaceProxyDeleted(ctx, database.UpdateWorkspaceProxyDeletedParams{
+		ID:      proxy.ID,
+		Deleted: true,
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	api.refreshWorkspaceProxyDERPRegions(ctx)
+	err = api.updateEntitlements(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "update entitlements after deleting workspace proxy", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: "Proxy has been deleted!",
+	})
+}
+
+// regions lists the primary deployment and every registered workspace proxy.
+// Any authenticated user can list regions so their client can choose the
+// closest one.
+func (api *API) regions(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	proxies, err := api.Database.GetWorkspaceProxies(ctx)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	regions := []codersdk.Region{{
+		// The primary region doesn't have a proxy ID.
+		ID:               uuid.Nil,
+		Name:             "primary",
+		DisplayName:      "Default",
+		Healthy:          true,
+		PathAppURL:       api.AccessURL.String(),
+		WildcardHostname: api.AppHostname,
+	}}
+	for _, proxy := range proxies {
+		if proxy.Url == "" {
+			// Proxies that have never registered can't serve anything.
+			continue
+		}
+		regions = append(regions, codersdk.Region{
+			ID:               proxy.ID,
+			Name:             proxy.Name,
+			DisplayName:      proxy.DisplayName,
+			IconURL:          proxy.Icon,
+			Healthy:          workspaceProxyHealthy(proxy),
+			PathAppURL:       proxy.Url,
+			WildcardHostname: proxy.WildcardHostname,
+		})
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.RegionsResponse{
+		Regions: regions,
+	})
+}
+
+// registerWorkspaceProxy is called by the proxy itself on startup and
+// periodically afterwards.
+func (api *API) registerWorkspaceProxy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx   = r.Context()
+		proxy = httpmw.WorkspaceProxy(r)
+	)
+
+	var req wsproxysdk.RegisterWorkspaceProxyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	accessURL, err := url.Parse(req.AccessURL)
+	if err != nil || accessURL.Host == "" || (accessURL.Scheme != "http" && accessURL.Scheme != "https") {
+		detail := "URL must have an http or https scheme and a host."
+		if err != nil {
+			detail = err.Error()
+		}
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid access URL.",
+			Detail:  detail,
+		})
+		return
+	}
+	if req.WildcardHostname != "" {
+		_, err = httpapi.CompileHostnamePattern(req.WildcardHostname)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid wildcard hostname.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	proxy, err = api.Database.RegisterWorkspaceProxy(ctx, database.RegisterWorkspaceProxyParams{
+		ID:               proxy.ID,
+		Url:              req.AccessURL,
+		WildcardHostname: req.WildcardHostname,
+		DerpEnabled:      req.DERPEnabled,
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	api.refreshWorkspaceProxyDERPRegions(ctx)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, wsproxysdk.RegisterWorkspaceProxyResponse{
+		AppSecurityKey: api.AppSecurityKey.String(),
+		DERPMap:        api.AGPL.CurrentDERPMap(),
+		DERPRegionID:   workspaceProxyDERPRegionOffset + int(proxy.RegionID),
+	})
+}
+
+// issueSignedAppToken resolves an app request on behalf of the user whose
+// session token the proxy forwarded, and signs a token the proxy can verify
+// with the app security key.
+func (api *API) issueSignedAppToken(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req wsproxysdk.IssueSignedAppTokenRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if err := req.AppRequest.Validate(); err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid app request.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Authenticate the user with the same middleware regular requests use by
+	// rebuilding the request with only the forwarded session token.
+	userReq := r.Clone(ctx)
+	userReq.Header = http.Header{}
+	if req.SessionToken != "" {
+		userReq.Header.Set(codersdk.SessionCustomHeader, req.SessionToken)
+	}
+	userReq.URL.RawQuery = ""
+
+	httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
+		DB: api.Database,
+		OAuth2Configs: &httpmw.OAuth2Configs{
+			Github: api.GithubOAuth2Config,
+			OIDC:   api.OIDCConfig,
+		},
+		RedirectToLogin: false,
+		Optional:        true,
+	})(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		token, err := api.AGPL.ResolveWorkspaceApp(r, req.AppRequest)
+		switch {
+		case xerrors.Is(err, workspaceapps.ErrUnauthenticated):
+			httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+				Message: httpmw.SignedOutErrorMessage,
+			})
+			return
+		case xerrors.Is(err, workspaceapps.ErrNotFound):
+			httpapi.ResourceNotFound(rw)
+			return
+		case err != nil:
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Failed to resolve workspace app.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+
+		tokenStr, err := api.AppSecurityKey.SignToken(token)
+		if err != nil {
+			httpapi.InternalServerError(rw, err)
+			return
+		}
+		httpapi.Write(ctx, rw, http.StatusCreated, wsproxysdk.IssueSignedAppTokenResponse{
+			SignedTokenStr: tokenStr,
+		})
+	})).ServeHTTP(rw, userReq)
+}
+
+// exchangeAppAPIKey decrypts an API key smuggled to the proxy through the app
+// auth-redirect flow.
+func (api *API) exchangeAppAPIKey(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req wsproxysdk.ExchangeAppAPIKeyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	apiKey, err := api.AGPL.DecryptSmuggledAPIKey(ctx, req.EncryptedAPIKey)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Could not decrypt API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, wsproxysdk.ExchangeAppAPIKeyResponse{
+		APIKey: apiKey,
+	})
+}
+
+// workspaceProxyCoordinate lets a proxy exchange tailnet nodes with an agent.
+// The proxy only dials agents it holds a signed app token for.
+func (api *API) workspaceProxyCoordinate(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	agentID, err := uuid.Parse(r.URL.Query().Get("agent_id"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid agent_id query parameter.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	_, err = api.Database.GetWorkspaceAgentByID(ctx, agentID)
+	if err != nil {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	api.AGPL.WebsocketWaitMutex.Lock()
+	api.AGPL.WebsocketWaitGroup.Add(1)
+	api.AGPL.WebsocketWaitMutex.Unlock()
+	defer api.AGPL.WebsocketWaitGroup.Done()
+
+	conn, err := websocket.Accept(rw, r, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	go httpapi.Heartbeat(ctx, conn)
+
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	err = (*api.AGPL.TailnetCoordinator.Load()).ServeClient(websocket.NetConn(ctx, conn, websocket.MessageBinary), uuid.New(), agentID)
+	if err != nil {
+		_ = conn.Close(websocket.StatusInternalError, err.Error())
+		return
+	}
+}
+
+// workspaceProxyDERPMapper adds a DERP region for every healthy proxy that
+// runs a DERP server. It's installed as the AGPL DERPMapper.
+func (api *API) workspaceProxyDERPMapper(derpMap *tailcfg.DERPMap) *tailcfg.DERPMap {
+	regions := api.workspaceProxyDERPRegions.Load()
+	if regions == nil || len(*regions) == 0 {
+		return derpMap
+	}
+	if derpMap.Regions == nil {
+		derpMap.Regions = map[int]*tailcfg.DERPRegion{}
+	}
+	for _, region := range *regions {
+		if _, exists := derpMap.Regions[region.RegionID]; exists {
+			continue
+		}
+		derpMap.Regions[region.RegionID] = region
+	}
+	return derpMap
+}
+
+// refreshWorkspaceProxyDERPRegions rebuilds the cached DERP regions served
+// by workspace proxies.
+func (api *API) refreshWorkspaceProxyDERPRegions(ctx context.Context) {
+	proxies, err := api.Database.GetWorkspaceProxies(ctx)
+	if err != nil {
+		api.Logger.Warn(ctx, "get workspace proxies for DERP map", slog.Error(err))
+		return
+	}
+
+	regions := make([]*tailcfg.DERPRegion, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !proxy.DerpEnabled || proxy.Url == "" || !workspaceProxyHealthy(proxy) {
+			continue
+		}
+		region, err := workspaceProxyDERPRegion(proxy)
+		if err != nil {
+			api.Logger.Warn(ctx, "build DERP region for workspace proxy",
+				slog.F("proxy", proxy.Name), slog.Error(err))
+			continue
+		}
+		regions = append(regions, region)
+	}
+	api.workspaceProxyDERPRegions.Store(&regions)
+}
+
+func (api *API) runWorkspaceProxyDERPRefreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(workspaceProxyDERPRefreshInterval)
+	defer ticker.Stop()
+	for {
+		api.refreshWorkspaceProxyDERPRegions(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func workspaceProxyDERPRegion(proxy database.WorkspaceProxy) (*tailcfg.DERPRegion, error) {
+	u, err := url.Parse(proxy.Url)
+	if err != nil {
+		return nil, xerrors.Errorf("parse proxy URL: %w", err)
+	}
+	port := 443
+	if u.Scheme == "http" {
+		port = 80
+	}
+	if u.Port() != "" {
+		port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return nil, xerrors.Errorf("parse proxy URL port: %w", err)
+		}
+	}
+
+	regionID := workspaceProxyDERPRegionOffset + int(proxy.RegionID)
+	return &tailcfg.DERPRegion{
+		RegionID:   regionID,
+		RegionCode: "coder_" + proxy.Name,
+		RegionName: proxy.DisplayName,
+		Nodes: []*tailcfg.DERPNode{{
+			Name:     fmt.Sprintf("%da", regionID),
+			RegionID: regionID,
+			HostName: u.Hostname(),
+			DERPPort: port,
+			// Proxies don't run a STUN server.
+			STUNPort:  -1,
+			ForceHTTP: u.Scheme == "http",
+		}},
+	}, nil
+}
+
+func workspaceProxyHealthy(proxy database.WorkspaceProxy) bool {
+	return proxy.Url != "" && database.Now().Sub(proxy.UpdatedAt) < workspaceProxyHealthyTimeout
+}
+
+func convertWorkspaceProxy(proxy database.WorkspaceProxy) codersdk.WorkspaceProxy {
+	return codersdk.WorkspaceProxy{
+		ID:               proxy.ID,
+		Name:             proxy.Name,
+		DisplayName:      proxy.DisplayName,
+		Icon:             proxy.Icon,
+		URL:              proxy.Url,
+		WildcardHostname: proxy.WildcardHostname,
+		DERPEnabled:      proxy.DerpEnabled,
+		RegionID:         proxy.RegionID,
+		CreatedAt:        proxy.CreatedAt,
+		UpdatedAt:        proxy.UpdatedAt,
+	}
+}
@@ -0,0 +1,288 @@
+package coderd_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/enterprise/wsproxy/wsproxysdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceProxyCRUD(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoLicense", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		_, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+			Name: "proxy",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			WorkspaceProxy: true,
+		})
+		ctx, _ := testutil.Context(t)
+
+		res, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+			Name:        "sydney",
+			DisplayName: "Sydney",
+			Icon:        "/emojis/1f1e6-1f1fa.png",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "sydney", res.Proxy.Name)
+		require.NotEmpty(t, res.ProxyToken)
+
+		_, err = client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+			Name: "sydney",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		proxies, err := client.WorkspaceProxies(ctx)
+		require.NoError(t, err)
+		require.Len(t, proxies, 1)
+		require.Equal(t, res.Proxy.ID, proxies[0].ID)
+
+		entitlements, err := client.Entitlements(ctx)
+		require.NoError(t, err)
+		require.True(t, entitlements.Features[codersdk.FeatureWorkspaceProxy].Enabled)
+
+		err = client.DeleteWorkspaceProxyByName(ctx, "sydney")
+		require.NoError(t, err)
+
+		proxies, err = client.WorkspaceProxies(ctx)
+		require.NoError(t, err)
+		require.Len(t, proxies, 0)
+	})
+
+	t.Run("MemberCannotCreate", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			WorkspaceProxy: true,
+		})
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleMember())
+		ctx, _ := testutil.Context(t)
+
+		_, err := member.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+			Name: "proxy",
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestWorkspaceProxyRegister(t *testing.T) {
+	t.Parallel()
+
+	client := coderdenttest.New(t, nil)
+	_ = coderdtest.CreateFirstUser(t, client)
+	_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+		WorkspaceProxy: true,
+	})
+	ctx, _ := testutil.Context(t)
+
+	res, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+		Name: "frankfurt",
+	})
+	require.NoError(t, err)
+
+	t.Run("BadToken", func(t *testing.T) {
+		t.Parallel()
+		proxyClient := wsproxysdk.New(client.URL, res.Proxy.ID.String()+":wrong")
+		_, err := proxyClient.RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+			AccessURL: "https://frankfurt.coder.com",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidURL", func(t *testing.T) {
+		t.Parallel()
+		proxyClient := wsproxysdk.New(client.URL, res.ProxyToken)
+		_, err := proxyClient.RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+			AccessURL: "frankfurt.coder.com",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		proxyClient := wsproxysdk.New(client.URL, res.ProxyToken)
+		registered, err := proxyClient.RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+			AccessURL:        "https://frankfurt.coder.com",
+			WildcardHostname: "*.frankfurt.coder.com",
+			DERPEnabled:      true,
+		})
+		require.NoError(t, err)
+
+		_, err = workspaceapps.KeyFromString(registered.AppSecurityKey)
+		require.NoError(t, err)
+		region, ok := registered.DERPMap.Regions[registered.DERPRegionID]
+		require.True(t, ok, "proxy DERP region should be in the DERP map")
+		require.Len(t, region.Nodes, 1)
+		require.Equal(t, "frankfurt.coder.com", region.Nodes[0].HostName)
+
+		regions, err := client.Regions(ctx)
+		require.NoError(t, err)
+		require.Len(t, regions, 2)
+		require.Equal(t, uuid.Nil, regions[0].ID)
+		require.Equal(t, res.Proxy.ID, regions[1].ID)
+		require.True(t, regions[1].Healthy)
+		require.Equal(t, "https://frankfurt.coder.com", regions[1].PathAppURL)
+		require.Equal(t, "*.frankfurt.coder.com", regions[1].WildcardHostname)
+	})
+}
+
+func TestIssueSignedAppToken(t *testing.T) {
+	t.Parallel()
+
+	client, _, api := coderdenttest.NewWithAPI(t, &coderdenttest.Options{
+		Options: &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+		},
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+		WorkspaceProxy: true,
+	})
+	ctx, _ := testutil.Context(t)
+
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: "agent",
+							Auth: &proto.Agent_Token{
+								Token: uuid.NewString(),
+							},
+							Apps: []*proto.App{{
+								Slug:         "app",
+								DisplayName:  "app",
+								SharingLevel: proto.AppSharingLevel_OWNER,
+								Url:          "http://127.0.0.1:8080",
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	res, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+		Name: "proxy",
+	})
+	require.NoError(t, err)
+	proxyClient := wsproxysdk.New(client.URL, res.ProxyToken)
+
+	me, err := client.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+	appReq := workspaceapps.Request{
+		AccessMethod:      workspaceapps.AccessMethodPath,
+		BasePath:          "/@" + me.Username + "/" + workspace.Name + "/apps/app/",
+		UsernameOrID:      me.Username,
+		WorkspaceAndAgent: workspace.Name,
+		AppSlugOrPort:     "app",
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		issued, err := proxyClient.IssueSignedAppToken(ctx, wsproxysdk.IssueSignedAppTokenRequest{
+			AppRequest:   appReq,
+			SessionToken: client.SessionToken,
+		})
+		require.NoError(t, err)
+
+		token, err := api.AGPL.AppSecurityKey.VerifySignedToken(issued.SignedTokenStr)
+		require.NoError(t, err)
+		require.True(t, token.MatchesRequest(appReq))
+		require.Equal(t, me.ID, token.UserID)
+		require.Equal(t, workspace.ID, token.WorkspaceID)
+		require.Equal(t, "http://127.0.0.1:8080", token.AppURL)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		t.Parallel()
+		_, err := proxyClient.IssueSignedAppToken(ctx, wsproxysdk.IssueSignedAppTokenRequest{
+			AppRequest: appReq,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("NoAccess", func(t *testing.T) {
+		t.Parallel()
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleMember())
+		_, err := proxyClient.IssueSignedAppToken(ctx, wsproxysdk.IssueSignedAppTokenRequest{
+			AppRequest:   appReq,
+			SessionToken: member.SessionToken,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidRequest", func(t *testing.T) {
+		t.Parallel()
+		badReq := appReq
+		badReq.UsernameOrID = "me"
+		_, err := proxyClient.IssueSignedAppToken(ctx, wsproxysdk.IssueSignedAppTokenRequest{
+			AppRequest:   badReq,
+			SessionToken: client.SessionToken,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("ProxyAuthRequired", func(t *testing.T) {
+		t.Parallel()
+		u, err := url.Parse(client.URL.String())
+		require.NoError(t, err)
+		_, err = wsproxysdk.New(u, "").IssueSignedAppToken(ctx, wsproxysdk.IssueSignedAppTokenRequest{
+			AppRequest:   appReq,
+			SessionToken: client.SessionToken,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+}
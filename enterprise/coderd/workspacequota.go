@@ -21,16 +21,24 @@ func NewEnforcer(userWorkspaceLimit int) workspacequota.Enforcer {
 	}
 }
 
-func (e *enforcer) UserWorkspaceLimit() int {
+// UserWorkspaceLimit returns the quota of the organization, falling back to
+// the deployment wide quota if the organization doesn't set one. The
+// organization quota can't exceed the deployment wide quota, which may have
+// been lowered since the organization set it.
+func (e *enforcer) UserWorkspaceLimit(organizationQuota int) int {
+	if organizationQuota > 0 && (e.userWorkspaceLimit == 0 || organizationQuota < e.userWorkspaceLimit) {
+		return organizationQuota
+	}
 	return e.userWorkspaceLimit
 }
 
-func (e *enforcer) CanCreateWorkspace(count int) bool {
-	if e.userWorkspaceLimit == 0 {
+func (e *enforcer) CanCreateWorkspace(organizationQuota int, count int) bool {
+	limit := e.UserWorkspaceLimit(organizationQuota)
+	if limit == 0 {
 		return true
 	}
 
-	return count < e.userWorkspaceLimit
+	return count < limit
 }
 
 func (api *API) workspaceQuota(rw http.ResponseWriter, r *http.Request) {
@@ -55,6 +63,39 @@ func (api *API) workspaceQuota(rw http.ResponseWriter, r *http.Request) {
 	e := *api.AGPL.WorkspaceQuotaEnforcer.Load()
 	httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.WorkspaceQuota{
 		UserWorkspaceCount: len(workspaces),
-		UserWorkspaceLimit: e.UserWorkspaceLimit(),
+		UserWorkspaceLimit: e.UserWorkspaceLimit(0),
+	})
+}
+
+// organizationWorkspaceQuota returns the quota of a member in an
+// organization, which may differ from the deployment wide quota.
+func (api *API) organizationWorkspaceQuota(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx          = r.Context()
+		organization = httpmw.OrganizationParam(r)
+		member       = httpmw.OrganizationMemberParam(r)
+	)
+
+	if !api.AGPL.Authorize(r, rbac.ActionRead, rbac.ResourceOrganizationMember.InOrg(organization.ID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	count, err := api.Database.GetWorkspaceCountByOrganizationAndOwner(ctx, database.GetWorkspaceCountByOrganizationAndOwnerParams{
+		OrganizationID: organization.ID,
+		OwnerID:        member.UserID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace count.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	e := *api.AGPL.WorkspaceQuotaEnforcer.Load()
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceQuota{
+		UserWorkspaceCount: int(count),
+		UserWorkspaceLimit: e.UserWorkspaceLimit(int(organization.UserWorkspaceQuota)),
 	})
 }
@@ -2,6 +2,7 @@ package coderd_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd"
 	"github.com/coder/coder/enterprise/coderd/coderdenttest"
 	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionersdk/proto"
@@ -119,4 +121,77 @@ func TestWorkspaceQuota(t *testing.T) {
 		require.EqualValues(t, q1.UserWorkspaceCount, 1)
 		require.EqualValues(t, q1.UserWorkspaceLimit, max)
 	})
+	t.Run("OrganizationLimit", func(t *testing.T) {
+		t.Parallel()
+		// Organizations that set their quota before the deployment wide
+		// quota was lowered are held to the deployment wide quota.
+		enforcer := coderd.NewEnforcer(3)
+		require.Equal(t, 3, enforcer.UserWorkspaceLimit(0))
+		require.Equal(t, 2, enforcer.UserWorkspaceLimit(2))
+		require.Equal(t, 3, enforcer.UserWorkspaceLimit(5))
+		require.Equal(t, 5, coderd.NewEnforcer(0).UserWorkspaceLimit(5))
+	})
+	t.Run("Organization", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			UserWorkspaceQuota: 3,
+			Options: &coderdtest.Options{
+				IncludeProvisionerDaemon: true,
+			},
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			WorkspaceQuota: true,
+		})
+		org, err := client.UpdateOrganization(ctx, user.OrganizationID, codersdk.UpdateOrganizationRequest{
+			UserWorkspaceQuota: ptr.Ref(1),
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, org.UserWorkspaceQuota)
+		// Organizations can't raise the deployment wide quota.
+		_, err = client.UpdateOrganization(ctx, user.OrganizationID, codersdk.UpdateOrganizationRequest{
+			UserWorkspaceQuota: ptr.Ref(4),
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		other, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "other",
+		})
+		require.NoError(t, err)
+
+		createWorkspace := func(organizationID uuid.UUID, name string) error {
+			version := coderdtest.CreateTemplateVersion(t, client, organizationID, nil)
+			coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+			template := coderdtest.CreateTemplate(t, client, organizationID, version.ID)
+			_, err := client.CreateWorkspace(ctx, organizationID, codersdk.Me, codersdk.CreateWorkspaceRequest{
+				TemplateID: template.ID,
+				Name:       name,
+			})
+			return err
+		}
+		require.NoError(t, createWorkspace(user.OrganizationID, "first"))
+		err = createWorkspace(user.OrganizationID, "second")
+		require.ErrorContains(t, err, "User workspace limit of 1")
+
+		q, err := client.OrganizationWorkspaceQuota(ctx, user.OrganizationID, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceQuota{UserWorkspaceCount: 1, UserWorkspaceLimit: 1}, q)
+
+		// The other organization uses the deployment wide quota, which
+		// counts the workspaces of all organizations.
+		require.NoError(t, createWorkspace(other.ID, "third"))
+		q, err = client.OrganizationWorkspaceQuota(ctx, other.ID, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceQuota{UserWorkspaceCount: 1, UserWorkspaceLimit: 3}, q)
+		require.NoError(t, createWorkspace(other.ID, "fourth"))
+		err = createWorkspace(other.ID, "fifth")
+		require.ErrorContains(t, err, "User workspace limit of 3")
+
+		q, err = client.WorkspaceQuota(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceQuota{UserWorkspaceCount: 3, UserWorkspaceLimit: 3}, q)
+	})
 }
@@ -0,0 +1,517 @@
+// Package wsproxy implements a workspace proxy: a regional server that serves
+// workspace applications and a DERP relay close to users, while delegating
+// authentication and authorization to the primary coderd deployment.
+package wsproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"tailscale.com/derp"
+	"tailscale.com/derp/derphttp"
+	"tailscale.com/tailcfg"
+	"tailscale.com/types/key"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/coderd/wsconncache"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/wsproxy/wsproxysdk"
+	"github.com/coder/coder/site"
+	"github.com/coder/coder/tailnet"
+)
+
+// DefaultRegisterInterval is how often a proxy re-registers with the primary.
+// The primary considers proxies unhealthy if they have not registered
+// recently.
+const DefaultRegisterInterval = 30 * time.Second
+
+type Options struct {
+	Logger slog.Logger
+
+	// PrimaryAccessURL is the URL of the primary coderd deployment.
+	PrimaryAccessURL *url.URL
+	// AccessURL is the URL users use to reach this proxy.
+	AccessURL *url.URL
+	// AppHostname is the wildcard hostname subdomain apps are served on, e.g.
+	// "*.apac.coder.com". Subdomain apps are disabled if empty.
+	AppHostname string
+	// AppHostnameRegex is the compiled AppHostname and must be set if
+	// AppHostname is set.
+	AppHostnameRegex *regexp.Regexp
+
+	RealIPConfig *httpmw.RealIPConfig
+	// ProxySessionToken authenticates the proxy with the primary. It is
+	// returned when creating the proxy with "coder wsproxy create".
+	ProxySessionToken string
+	// DERPEnabled serves a DERP relay on /derp and advertises it as a region in
+	// the DERP map.
+	DERPEnabled bool
+	// SecureAuthCookie sets the Secure attribute on cookies set by the proxy.
+	SecureAuthCookie bool
+
+	// HTTPClient is used for requests to the primary. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// RegisterInterval defaults to DefaultRegisterInterval.
+	RegisterInterval time.Duration
+}
+
+func (o *Options) Validate() error {
+	if o.PrimaryAccessURL == nil || o.PrimaryAccessURL.Scheme == "" || o.PrimaryAccessURL.Host == "" {
+		return xerrors.New("primary access URL must be an absolute URL")
+	}
+	if o.AccessURL == nil || o.AccessURL.Scheme == "" || o.AccessURL.Host == "" {
+		return xerrors.New("access URL must be an absolute URL")
+	}
+	if o.AppHostname != "" && o.AppHostnameRegex == nil {
+		return xerrors.New("app hostname regex must be set when app hostname is set")
+	}
+	if o.ProxySessionToken == "" {
+		return xerrors.New("proxy session token is required")
+	}
+	return nil
+}
+
+// Server is a workspace proxy server.
+type Server struct {
+	Options *Options
+	Handler chi.Router
+
+	// SDKClient is authenticated as this proxy to the primary.
+	SDKClient *wsproxysdk.Client
+	// DERPServer is nil if DERP is disabled.
+	DERPServer *derp.Server
+
+	appSecurityKey atomic.Pointer[workspaceapps.SecurityKey]
+	derpMap        atomic.Pointer[tailcfg.DERPMap]
+	agentCache     *wsconncache.Cache
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// New registers the proxy with the primary and returns a server ready to
+// serve requests. The proxy re-registers periodically until Close is called.
+func New(ctx context.Context, opts *Options) (*Server, error) {
+	if opts.RegisterInterval == 0 {
+		opts.RegisterInterval = DefaultRegisterInterval
+	}
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	client := wsproxysdk.New(opts.PrimaryAccessURL, opts.ProxySessionToken)
+	if opts.HTTPClient != nil {
+		client.SDKClient.HTTPClient = opts.HTTPClient
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Server{
+		Options:   opts,
+		SDKClient: client,
+		ctx:       ctx,
+		cancel:    cancel,
+		closed:    make(chan struct{}),
+	}
+
+	err = s.register(ctx)
+	if err != nil {
+		cancel()
+		return nil, xerrors.Errorf("register proxy with primary: %w", err)
+	}
+	s.agentCache = wsconncache.New(s.dialWorkspaceAgent, 0)
+
+	r := chi.NewRouter()
+	r.Use(
+		httpmw.Recover(opts.Logger),
+		httpmw.ExtractRealIP(opts.RealIPConfig),
+		httpmw.Logger(opts.Logger),
+		s.handleSubdomainApplications,
+	)
+
+	apps := func(r chi.Router) {
+		r.HandleFunc("/*", s.workspaceAppsProxyPath)
+	}
+	// See the comment on the same routes in coderd.
+	r.Route("/%40{user}/{workspace_and_agent}/apps/{workspaceapp}", apps)
+	r.Route("/@{user}/{workspace_and_agent}/apps/{workspaceapp}", apps)
+
+	if opts.DERPEnabled {
+		s.DERPServer = derp.NewServer(key.NewNode(), tailnet.Logger(opts.Logger.Named("derp")))
+		r.Route("/derp", func(r chi.Router) {
+			r.Get("/", derphttp.Handler(s.DERPServer).ServeHTTP)
+			// This is used when UDP is blocked, and latency must be checked via HTTP(s).
+			r.Get("/latency-check", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+		})
+	}
+
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	})
+	r.NotFound(func(rw http.ResponseWriter, r *http.Request) {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusNotFound,
+			Title:        "Not Found",
+			Description:  "Workspace proxies only serve workspace applications. Use the dashboard to find your applications.",
+			RetryEnabled: false,
+			DashboardURL: opts.PrimaryAccessURL.String(),
+		})
+	})
+	s.Handler = r
+
+	go s.registerLoop()
+	return s, nil
+}
+
+// Close stops re-registering with the primary and closes all agent
+// connections.
+func (s *Server) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.cancel()
+		<-s.closed
+		err = s.agentCache.Close()
+		if s.DERPServer != nil {
+			_ = s.DERPServer.Close()
+		}
+	})
+	return err
+}
+
+// DERPMap returns the DERP map most recently returned by the primary.
+func (s *Server) DERPMap() *tailcfg.DERPMap {
+	return s.derpMap.Load()
+}
+
+func (s *Server) register(ctx context.Context) error {
+	res, err := s.SDKClient.RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+		AccessURL:        s.Options.AccessURL.String(),
+		WildcardHostname: s.Options.AppHostname,
+		DERPEnabled:      s.Options.DERPEnabled,
+	})
+	if err != nil {
+		return err
+	}
+	appSecurityKey, err := workspaceapps.KeyFromString(res.AppSecurityKey)
+	if err != nil {
+		return xerrors.Errorf("parse app security key: %w", err)
+	}
+	s.appSecurityKey.Store(&appSecurityKey)
+	s.derpMap.Store(res.DERPMap)
+	return nil
+}
+
+func (s *Server) registerLoop() {
+	defer close(s.closed)
+	ticker := time.NewTicker(s.Options.RegisterInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := s.register(s.ctx)
+		if err != nil && s.ctx.Err() == nil {
+			s.Options.Logger.Warn(s.ctx, "failed to re-register workspace proxy with primary", slog.Error(err))
+		}
+	}
+}
+
+func (s *Server) dialWorkspaceAgent(_ *http.Request, agentID uuid.UUID) (*codersdk.AgentConn, error) {
+	// Agent connections are cached beyond the lifetime of the request that
+	// dialed them, so they are bound to the server context instead.
+	return s.SDKClient.DialWorkspaceAgent(s.ctx, agentID, s.derpMap.Load(), &codersdk.DialWorkspaceAgentOptions{
+		Logger: s.Options.Logger.Named("agent-dialer"),
+	})
+}
+
+// handleSubdomainApplications serves subdomain app requests that match the
+// proxy's wildcard hostname and passes everything else on.
+func (s *Server) handleSubdomainApplications(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if s.Options.AppHostnameRegex == nil {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		host := httpapi.RequestHost(r)
+		if host == "" || httpapi.HostnamesMatch(s.Options.AccessURL.Hostname(), host) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		subdomain, ok := httpapi.ExecuteHostnamePattern(s.Options.AppHostnameRegex, host)
+		if !ok {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		app, err := httpapi.ParseSubdomainAppURL(subdomain)
+		if err != nil {
+			s.renderError(rw, r, http.StatusBadRequest, "Invalid application URL", "Could not parse subdomain application URL: "+err.Error())
+			return
+		}
+
+		appSlugOrPort := app.AppSlug
+		if app.Port != 0 {
+			appSlugOrPort = strconv.Itoa(int(app.Port))
+		}
+		s.proxyApp(rw, r, workspaceapps.Request{
+			AccessMethod:      workspaceapps.AccessMethodSubdomain,
+			BasePath:          "/",
+			UsernameOrID:      app.Username,
+			WorkspaceAndAgent: app.WorkspaceName + "." + app.AgentName,
+			AppSlugOrPort:     appSlugOrPort,
+		}, r.URL.Path)
+	})
+}
+
+// workspaceAppsProxyPath serves path-based app requests.
+func (s *Server) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request) {
+	// Web applications typically request paths relative to the root URL.
+	// This allows for routing behind a proxy or subpath. See
+	// https://github.com/coder/code-server/issues/241 for examples.
+	chiPath := chi.URLParam(r, "*")
+	basePath := strings.TrimSuffix(r.URL.Path, chiPath)
+	if !strings.HasSuffix(basePath, "/") {
+		u := *r.URL
+		u.Path += "/"
+		http.Redirect(rw, r, u.String(), http.StatusTemporaryRedirect)
+		return
+	}
+
+	s.proxyApp(rw, r, workspaceapps.Request{
+		AccessMethod:      workspaceapps.AccessMethodPath,
+		BasePath:          basePath,
+		UsernameOrID:      chi.URLParam(r, "user"),
+		WorkspaceAndAgent: chi.URLParam(r, "workspace_and_agent"),
+		AppSlugOrPort:     chi.URLParam(r, "workspaceapp"),
+	}, "/"+chiPath)
+}
+
+// proxyApp authorizes the request with a signed app token and proxies it to
+// the app through the agent.
+func (s *Server) proxyApp(rw http.ResponseWriter, r *http.Request, appReq workspaceapps.Request, path string) {
+	token, ok := s.resolveSignedToken(rw, r, appReq)
+	if !ok {
+		return
+	}
+
+	// Filter IP headers from untrusted origins!
+	httpmw.FilterUntrustedOriginHeaders(s.Options.RealIPConfig, r)
+	// Ensure proper IP headers get sent to the forwarded application.
+	err := httpmw.EnsureXForwardedForHeader(r)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	appURL, err := url.Parse(token.AppURL)
+	if err != nil {
+		s.renderError(rw, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("Application has an invalid URL %q: %s", token.AppURL, err.Error()))
+		return
+	}
+	if workspaceapps.IsTCP(appURL) {
+		s.proxyAppTCP(rw, r, appReq, appURL, token.AgentID)
+		return
+	}
+	if path == "/" && r.URL.RawQuery == "" && appURL.RawQuery != "" {
+		// If the application defines a default set of query parameters, we
+		// should always respect them. See the comment in coderd for details.
+		r.URL.RawQuery = appURL.RawQuery
+		http.Redirect(rw, r, r.URL.String(), http.StatusTemporaryRedirect)
+		return
+	}
+	r.URL.Path = path
+	appURL.RawQuery = ""
+
+	conn, release, err := s.agentCache.Acquire(r, token.AgentID)
+	if err != nil {
+		s.renderError(rw, r, http.StatusBadGateway, "Bad Gateway", "Could not connect to workspace agent: "+err.Error())
+		return
+	}
+	defer release()
+
+	proxy := httputil.NewSingleHostReverseProxy(appURL)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		s.renderError(w, r, http.StatusBadGateway, "Bad Gateway", "Failed to proxy request to application: "+err.Error())
+	}
+	proxy.Transport = conn.HTTPTransport()
+
+	// This strips the session token from a workspace app request.
+	cookieHeaders := r.Header.Values("Cookie")[:]
+	r.Header.Del("Cookie")
+	for _, cookieHeader := range cookieHeaders {
+		r.Header.Add("Cookie", httpapi.StripCoderCookies(cookieHeader))
+	}
+
+	proxy.ServeHTTP(rw, r)
+}
+
+// proxyAppTCP forwards a websocket to a TCP app. Like coderd, TCP apps are
+// only served on subdomains.
+func (s *Server) proxyAppTCP(rw http.ResponseWriter, r *http.Request, appReq workspaceapps.Request, appURL *url.URL, agentID uuid.UUID) {
+	if appReq.AccessMethod != workspaceapps.AccessMethodSubdomain {
+		s.renderError(rw, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("Application %q forwards TCP and must be accessed on a subdomain.", appReq.AppSlugOrPort))
+		return
+	}
+	if appURL.Port() == "" {
+		s.renderError(rw, r, http.StatusBadRequest, "Bad Request", fmt.Sprintf("Application URL %q must include a port.", appURL.String()))
+		return
+	}
+
+	conn, release, err := s.agentCache.Acquire(r, agentID)
+	if err != nil {
+		s.renderError(rw, r, http.StatusBadGateway, "Bad Gateway", "Could not connect to workspace agent: "+err.Error())
+		return
+	}
+	defer release()
+
+	workspaceapps.ProxyTCP(rw, r, appURL, conn.DialContext)
+}
+
+// resolveSignedToken returns a valid signed token for the request, either from
+// the request cookie or by asking the primary to issue one. If false is
+// returned, a response has already been written.
+func (s *Server) resolveSignedToken(rw http.ResponseWriter, r *http.Request, appReq workspaceapps.Request) (workspaceapps.SignedToken, bool) {
+	ctx := r.Context()
+	appSecurityKey := s.appSecurityKey.Load()
+
+	cookie, err := r.Cookie(codersdk.SignedAppTokenCookie)
+	if err == nil && cookie.Value != "" {
+		token, err := appSecurityKey.VerifySignedToken(cookie.Value)
+		if err == nil && token.MatchesRequest(appReq) {
+			return token, true
+		}
+	}
+
+	res, err := s.SDKClient.IssueSignedAppToken(ctx, wsproxysdk.IssueSignedAppTokenRequest{
+		AppRequest:   appReq,
+		SessionToken: httpmw.APITokenFromRequest(r),
+	})
+	if err != nil {
+		var sdkErr *codersdk.Error
+		if !xerrors.As(err, &sdkErr) {
+			s.Options.Logger.Warn(ctx, "failed to issue signed app token", slog.Error(err))
+			s.renderError(rw, r, http.StatusBadGateway, "Bad Gateway", "Could not authorize the request with the primary Coder deployment.")
+			return workspaceapps.SignedToken{}, false
+		}
+		switch sdkErr.StatusCode() {
+		case http.StatusUnauthorized:
+			s.redirectToAuth(rw, r, appReq)
+		case http.StatusNotFound:
+			s.renderError(rw, r, http.StatusNotFound, "Application Not Found", "The application or workspace you are trying to access does not exist or you do not have permission to access it.")
+		case http.StatusBadRequest:
+			s.renderError(rw, r, http.StatusBadRequest, "Bad Request", sdkErr.Message)
+		default:
+			s.renderError(rw, r, http.StatusBadGateway, "Bad Gateway", "Could not authorize the request with the primary Coder deployment: "+sdkErr.Message)
+		}
+		return workspaceapps.SignedToken{}, false
+	}
+
+	token, err := appSecurityKey.VerifySignedToken(res.SignedTokenStr)
+	if err != nil {
+		s.Options.Logger.Error(ctx, "primary issued an invalid signed app token", slog.Error(err))
+		s.renderError(rw, r, http.StatusInternalServerError, "Internal Server Error", "The signed app token issued by the primary Coder deployment is invalid.")
+		return workspaceapps.SignedToken{}, false
+	}
+
+	http.SetCookie(rw, &http.Cookie{
+		Name:     codersdk.SignedAppTokenCookie,
+		Value:    res.SignedTokenStr,
+		Path:     appReq.BasePath,
+		Expires:  token.Expiry,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   s.Options.SecureAuthCookie,
+	})
+	return token, true
+}
+
+// redirectToAuth sends an unauthenticated user through the primary's app
+// auth flow, or completes the flow if the primary has redirected back with an
+// encrypted API key.
+func (s *Server) redirectToAuth(rw http.ResponseWriter, r *http.Request, appReq workspaceapps.Request) {
+	ctx := r.Context()
+
+	if encryptedAPIKey := r.URL.Query().Get(workspaceapps.SubdomainProxyAPIKeyParam); encryptedAPIKey != "" {
+		res, err := s.SDKClient.ExchangeAppAPIKey(ctx, wsproxysdk.ExchangeAppAPIKeyRequest{
+			EncryptedAPIKey: encryptedAPIKey,
+		})
+		if err != nil {
+			// Retry is disabled because the user needs to remove the query
+			// parameter before they try again.
+			s.renderError(rw, r, http.StatusBadRequest, "Bad Request", "Could not decrypt API key. Please remove the query parameter and try again.")
+			return
+		}
+
+		cookie := &http.Cookie{
+			Name:     httpmw.DevURLSessionTokenCookie,
+			Value:    res.APIKey,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   s.Options.SecureAuthCookie,
+		}
+		if appReq.AccessMethod == workspaceapps.AccessMethodSubdomain {
+			// Share the cookie with all subdomain apps on this proxy. The app
+			// hostname is validated on startup so it always has a parent.
+			hostSplit := strings.SplitN(s.Options.AppHostname, ".", 2)
+			if len(hostSplit) == 2 {
+				cookie.Domain = "." + hostSplit[1]
+			}
+		}
+		http.SetCookie(rw, cookie)
+
+		// Strip the query parameter.
+		u := *r.URL
+		q := u.Query()
+		q.Del(workspaceapps.SubdomainProxyAPIKeyParam)
+		u.RawQuery = q.Encode()
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		http.Redirect(rw, r, u.RequestURI(), http.StatusTemporaryRedirect)
+		return
+	}
+
+	redirectURI := *r.URL
+	redirectURI.Scheme = s.Options.AccessURL.Scheme
+	redirectURI.Host = httpapi.RequestHost(r)
+
+	u := *s.Options.PrimaryAccessURL
+	u.Path = "/api/v2/applications/auth-redirect"
+	q := u.Query()
+	q.Add(workspaceapps.RedirectURIQueryParam, redirectURI.String())
+	u.RawQuery = q.Encode()
+
+	http.Redirect(rw, r, u.String(), http.StatusTemporaryRedirect)
+}
+
+func (s *Server) renderError(rw http.ResponseWriter, r *http.Request, status int, title, description string) {
+	site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+		Status:       status,
+		Title:        title,
+		Description:  description,
+		RetryEnabled: status >= http.StatusInternalServerError,
+		DashboardURL: s.Options.PrimaryAccessURL.String(),
+	})
+}
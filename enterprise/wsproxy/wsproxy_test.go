@@ -0,0 +1,217 @@
+package wsproxy_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/enterprise/wsproxy"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+const appBody = "hello from the app"
+
+func TestWorkspaceProxyPathApp(t *testing.T) {
+	t.Parallel()
+
+	// The app the agent will forward requests to.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	appServer := http.Server{
+		ReadHeaderTimeout: time.Minute,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := r.Cookie(codersdk.SignedAppTokenCookie)
+			assert.ErrorIs(t, err, http.ErrNoCookie)
+			_, _ = w.Write([]byte(appBody))
+		}),
+	}
+	t.Cleanup(func() {
+		_ = appServer.Close()
+		_ = ln.Close()
+	})
+	go appServer.Serve(ln)
+	tcpAddr, ok := ln.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+
+	client := coderdenttest.New(t, &coderdenttest.Options{
+		Options: &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+		},
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+		WorkspaceProxy: true,
+	})
+	ctx, _ := testutil.Context(t)
+
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: "agent",
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							Apps: []*proto.App{{
+								Slug:         "app",
+								DisplayName:  "app",
+								SharingLevel: proto.AppSharingLevel_OWNER,
+								Url:          fmt.Sprintf("http://127.0.0.1:%d", tcpAddr.Port),
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	created, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+		Name: "proxy",
+	})
+	require.NoError(t, err)
+
+	// The proxy handler is only known after the proxy registers, which
+	// requires its access URL.
+	var proxyHandler http.Handler
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		proxyHandler.ServeHTTP(rw, r)
+	}))
+	t.Cleanup(proxyServer.Close)
+	proxyURL, err := url.Parse(proxyServer.URL)
+	require.NoError(t, err)
+
+	proxy, err := wsproxy.New(ctx, &wsproxy.Options{
+		Logger:            slogtest.Make(t, nil).Named("wsproxy"),
+		PrimaryAccessURL:  client.URL,
+		AccessURL:         proxyURL,
+		ProxySessionToken: created.ProxyToken,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = proxy.Close()
+	})
+	proxyHandler = proxy.Handler
+
+	proxies, err := client.WorkspaceProxies(ctx)
+	require.NoError(t, err)
+	require.Len(t, proxies, 1)
+	require.Equal(t, proxyURL.String(), proxies[0].URL)
+
+	me, err := client.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+	appPath := fmt.Sprintf("/@%s/%s/apps/app", me.Username, workspace.Name)
+
+	httpClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	request := func(t *testing.T, path string, sessionToken string, cookies ...*http.Cookie) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxyServer.URL+path, nil)
+		require.NoError(t, err)
+		if sessionToken != "" {
+			req.Header.Set(codersdk.SessionCustomHeader, sessionToken)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		res, err := httpClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = res.Body.Close()
+		})
+		return res
+	}
+
+	t.Run("RedirectsWithSlash", func(t *testing.T) {
+		t.Parallel()
+		res := request(t, appPath, client.SessionToken)
+		require.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+		loc, err := res.Location()
+		require.NoError(t, err)
+		require.Equal(t, appPath+"/", loc.Path)
+	})
+
+	t.Run("RedirectsToPrimaryWithoutAuth", func(t *testing.T) {
+		t.Parallel()
+		res := request(t, appPath+"/", "")
+		require.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+		loc, err := res.Location()
+		require.NoError(t, err)
+		require.Equal(t, client.URL.Host, loc.Host)
+		require.Equal(t, "/api/v2/applications/auth-redirect", loc.Path)
+		redirectURI, err := url.Parse(loc.Query().Get("redirect_uri"))
+		require.NoError(t, err)
+		require.Equal(t, proxyURL.Host, redirectURI.Host)
+	})
+
+	t.Run("NoAccessShould404", func(t *testing.T) {
+		t.Parallel()
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		res := request(t, appPath+"/", member.SessionToken)
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("ProxiesApp", func(t *testing.T) {
+		t.Parallel()
+		res := request(t, appPath+"/", client.SessionToken)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+		require.Equal(t, appBody, string(body))
+
+		// The signed token alone is enough to access the app again without
+		// talking to the primary.
+		var signedToken *http.Cookie
+		for _, cookie := range res.Cookies() {
+			if cookie.Name == codersdk.SignedAppTokenCookie {
+				signedToken = cookie
+			}
+		}
+		require.NotNil(t, signedToken, "signed app token cookie should be set")
+		res = request(t, appPath+"/", "", signedToken)
+		body, err = io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+		require.Equal(t, appBody, string(body))
+	})
+}
@@ -0,0 +1,173 @@
+// Package wsproxysdk is the client workspace proxies use to talk to the
+// primary coderd deployment.
+package wsproxysdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"tailscale.com/tailcfg"
+
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/codersdk"
+)
+
+// Client is an HTTP client for the workspace proxy API on coderd. Requests
+// are authenticated with the proxy token.
+type Client struct {
+	SDKClient *codersdk.Client
+	token     string
+}
+
+// New creates a new workspace proxy client.
+func New(serverURL *url.URL, proxyToken string) *Client {
+	return &Client{
+		SDKClient: codersdk.New(serverURL),
+		token:     proxyToken,
+	}
+}
+
+// Request wraps the underlying codersdk.Client's Request method, adding the
+// workspace proxy token header.
+func (c *Client) Request(ctx context.Context, method, path string, body interface{}, opts ...codersdk.RequestOption) (*http.Response, error) {
+	opts = append(opts, func(r *http.Request) {
+		r.Header.Set(codersdk.WorkspaceProxyAuthTokenHeader, c.token)
+	})
+	return c.SDKClient.Request(ctx, method, path, body, opts...)
+}
+
+type RegisterWorkspaceProxyRequest struct {
+	// AccessURL that hits the workspace proxy api.
+	AccessURL string `json:"access_url"`
+	// WildcardHostname that the workspace proxy api is serving for subdomain
+	// apps.
+	WildcardHostname string `json:"wildcard_hostname"`
+	// DERPEnabled is true if the proxy runs a DERP server that clients and
+	// agents should use.
+	DERPEnabled bool `json:"derp_enabled"`
+}
+
+type RegisterWorkspaceProxyResponse struct {
+	// AppSecurityKey is the hex encoded key used to verify signed app tokens.
+	AppSecurityKey string `json:"app_security_key"`
+	// DERPMap is the DERP map clients of this proxy should use when dialing
+	// agents.
+	DERPMap *tailcfg.DERPMap `json:"derp_map"`
+	// DERPRegionID is the region ID of the DERP server run by this proxy.
+	DERPRegionID int `json:"derp_region_id"`
+}
+
+// RegisterWorkspaceProxy registers the proxy's URLs with coderd. Proxies call
+// this periodically, which also serves as a health check.
+func (c *Client) RegisterWorkspaceProxy(ctx context.Context, req RegisterWorkspaceProxyRequest) (RegisterWorkspaceProxyResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceproxies/me/register", req)
+	if err != nil {
+		return RegisterWorkspaceProxyResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return RegisterWorkspaceProxyResponse{}, codersdk.ReadBodyAsError(res)
+	}
+	var resp RegisterWorkspaceProxyResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+type IssueSignedAppTokenRequest struct {
+	AppRequest workspaceapps.Request `json:"app_request"`
+	// SessionToken is the session token of the user accessing the app, if
+	// any. Public apps may be accessed without one.
+	SessionToken string `json:"session_token"`
+}
+
+type IssueSignedAppTokenResponse struct {
+	// SignedTokenStr should be set as a cookie on the response.
+	SignedTokenStr string `json:"signed_token_str"`
+}
+
+// IssueSignedAppToken asks coderd to resolve and authorize an app request on
+// behalf of a user. The returned error is a *codersdk.Error for non-2xx
+// responses: 401 means the user must log in, 404 means the app does not exist
+// or the user cannot access it.
+func (c *Client) IssueSignedAppToken(ctx context.Context, req IssueSignedAppTokenRequest) (IssueSignedAppTokenResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceproxies/me/issue-signed-app-token", req)
+	if err != nil {
+		return IssueSignedAppTokenResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return IssueSignedAppTokenResponse{}, codersdk.ReadBodyAsError(res)
+	}
+	var resp IssueSignedAppTokenResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+type ExchangeAppAPIKeyRequest struct {
+	// EncryptedAPIKey is the value of the query parameter coderd appends to
+	// the redirect URI after app authentication.
+	EncryptedAPIKey string `json:"encrypted_api_key"`
+}
+
+type ExchangeAppAPIKeyResponse struct {
+	// APIKey is an application_connect scoped API key for the user.
+	APIKey string `json:"api_key"`
+}
+
+// ExchangeAppAPIKey decrypts an API key smuggled to the proxy by the
+// auth-redirect flow. Only coderd can decrypt these keys as it requires the
+// database.
+func (c *Client) ExchangeAppAPIKey(ctx context.Context, req ExchangeAppAPIKeyRequest) (ExchangeAppAPIKeyResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceproxies/me/app-api-key", req)
+	if err != nil {
+		return ExchangeAppAPIKeyResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return ExchangeAppAPIKeyResponse{}, codersdk.ReadBodyAsError(res)
+	}
+	var resp ExchangeAppAPIKeyResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// DialWorkspaceAgent connects to a workspace agent using the proxy's
+// coordinator endpoint on coderd. The proxy must already be authorized to
+// access the agent via a signed app token.
+func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, derpMap *tailcfg.DERPMap, options *codersdk.DialWorkspaceAgentOptions) (*codersdk.AgentConn, error) {
+	coordinateURL, err := c.SDKClient.URL.Parse(fmt.Sprintf("/api/v2/workspaceproxies/me/coordinate?agent_id=%s", agentID))
+	if err != nil {
+		return nil, xerrors.Errorf("parse url: %w", err)
+	}
+	transport := c.SDKClient.HTTPClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpClient := &http.Client{
+		Transport: &headerTransport{
+			transport: transport,
+			header:    http.Header{codersdk.WorkspaceProxyAuthTokenHeader: []string{c.token}},
+		},
+	}
+	return codersdk.DialCoordinatedAgent(ctx, httpClient, coordinateURL, derpMap, options)
+}
+
+type headerTransport struct {
+	transport http.RoundTripper
+	header    http.Header
+}
+
+func (h *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range h.header {
+		for _, vv := range v {
+			req.Header.Add(k, vv)
+		}
+	}
+	return h.transport.RoundTrip(req)
+}
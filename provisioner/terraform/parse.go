@@ -5,11 +5,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"github.com/mitchellh/go-wordwrap"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/provisionersdk/proto"
@@ -42,15 +48,205 @@ func (*server) Parse(request *proto.Parse_Request, stream proto.DRPCProvisioner_
 		parameters = append(parameters, schema)
 	}
 
+	richParameters, err := parseRichParameters(module)
+	if err != nil {
+		return err
+	}
+
 	return stream.Send(&proto.Parse_Response{
 		Type: &proto.Parse_Response_Complete{
 			Complete: &proto.Parse_Complete{
 				ParameterSchemas: parameters,
+				RichParameters:   richParameters,
 			},
 		},
 	})
 }
 
+// richParameterBlock is the schema of a coder_parameter data source.
+type richParameterBlock struct {
+	Name        string                         `hcl:"name"`
+	DisplayName string                         `hcl:"display_name,optional"`
+	Description string                         `hcl:"description,optional"`
+	Type        string                         `hcl:"type,optional"`
+	Mutable     bool                           `hcl:"mutable,optional"`
+	Default     string                         `hcl:"default,optional"`
+	Icon        string                         `hcl:"icon,optional"`
+	Order       int32                          `hcl:"order,optional"`
+	Options     []richParameterOptionBlock     `hcl:"option,block"`
+	Validation  []richParameterValidationBlock `hcl:"validation,block"`
+	Remain      hcl.Body                       `hcl:",remain"`
+}
+
+type richParameterOptionBlock struct {
+	Name        string `hcl:"name"`
+	Description string `hcl:"description,optional"`
+	Value       string `hcl:"value"`
+	Icon        string `hcl:"icon,optional"`
+}
+
+type richParameterValidationBlock struct {
+	Min   *int32 `hcl:"min,optional"`
+	Max   *int32 `hcl:"max,optional"`
+	Regex string `hcl:"regex,optional"`
+	Error string `hcl:"error,optional"`
+}
+
+// richParameterEvalContext is used to evaluate coder_parameter attributes.
+// Attributes must be static so they can be read without running Terraform,
+// but jsonencode is allowed for list(string) defaults.
+var richParameterEvalContext = &hcl.EvalContext{
+	Functions: map[string]function.Function{
+		"jsonencode": stdlib.JSONEncodeFunc,
+	},
+}
+
+// parseRichParameters extracts coder_parameter data sources from the module.
+// They're returned ordered by their "order" attribute, then by position in
+// the source.
+func parseRichParameters(module *tfconfig.Module) ([]*proto.RichParameter, error) {
+	resources := make([]*tfconfig.Resource, 0)
+	for _, resource := range module.DataResources {
+		if resource.Type != "coder_parameter" {
+			continue
+		}
+		resources = append(resources, resource)
+	}
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return compareSourcePos(resources[i].Pos, resources[j].Pos)
+	})
+
+	parser := hclparse.NewParser()
+	parameters := make([]*proto.RichParameter, 0, len(resources))
+	names := map[string]struct{}{}
+	for _, resource := range resources {
+		block, err := findDataBlock(parser, resource)
+		if err != nil {
+			return nil, err
+		}
+		var decoded richParameterBlock
+		diags := gohcl.DecodeBody(block.Body, richParameterEvalContext, &decoded)
+		if diags.HasErrors() {
+			return nil, xerrors.Errorf("decode coder_parameter %q: %s", resource.Name, diags.Error())
+		}
+		parameter, err := convertRichParameter(decoded)
+		if err != nil {
+			return nil, xerrors.Errorf("coder_parameter %q: %w", resource.Name, err)
+		}
+		if _, exists := names[parameter.Name]; exists {
+			return nil, xerrors.Errorf("coder_parameter %q: duplicate parameter name %q", resource.Name, parameter.Name)
+		}
+		names[parameter.Name] = struct{}{}
+		parameters = append(parameters, parameter)
+	}
+	sort.SliceStable(parameters, func(i, j int) bool {
+		return parameters[i].Order < parameters[j].Order
+	})
+	return parameters, nil
+}
+
+// findDataBlock returns the HCL block that declares the data resource.
+func findDataBlock(parser *hclparse.Parser, resource *tfconfig.Resource) (*hcl.Block, error) {
+	var (
+		file  *hcl.File
+		diags hcl.Diagnostics
+	)
+	if strings.HasSuffix(resource.Pos.Filename, ".json") {
+		file, diags = parser.ParseJSONFile(resource.Pos.Filename)
+	} else {
+		file, diags = parser.ParseHCLFile(resource.Pos.Filename)
+	}
+	if diags.HasErrors() {
+		return nil, xerrors.Errorf("parse %q: %s", resource.Pos.Filename, diags.Error())
+	}
+	content, _, diags := file.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{
+			Type:       "data",
+			LabelNames: []string{"type", "name"},
+		}},
+	})
+	if diags.HasErrors() {
+		return nil, xerrors.Errorf("read %q: %s", resource.Pos.Filename, diags.Error())
+	}
+	for _, block := range content.Blocks {
+		if block.Labels[0] == resource.Type && block.Labels[1] == resource.Name {
+			return block, nil
+		}
+	}
+	return nil, xerrors.Errorf("data %q %q not found in %q", resource.Type, resource.Name, resource.Pos.Filename)
+}
+
+func convertRichParameter(block richParameterBlock) (*proto.RichParameter, error) {
+	parameter := &proto.RichParameter{
+		Name:         block.Name,
+		DisplayName:  block.DisplayName,
+		Description:  block.Description,
+		Type:         block.Type,
+		Mutable:      block.Mutable,
+		DefaultValue: block.Default,
+		Icon:         block.Icon,
+		Order:        block.Order,
+	}
+	if parameter.Name == "" {
+		return nil, xerrors.New("name must not be empty")
+	}
+	if parameter.Type == "" {
+		parameter.Type = "string"
+	}
+	switch parameter.Type {
+	case "string", "number", "bool", "list(string)":
+	default:
+		return nil, xerrors.Errorf("unsupported type %q", parameter.Type)
+	}
+	if len(block.Validation) > 1 {
+		return nil, xerrors.New("only one validation block is allowed")
+	}
+	if len(block.Validation) == 1 {
+		validation := block.Validation[0]
+		if validation.Regex != "" {
+			if parameter.Type != "string" {
+				return nil, xerrors.New("a regex can only be used to validate string parameters")
+			}
+			_, err := regexp.Compile(validation.Regex)
+			if err != nil {
+				return nil, xerrors.Errorf("compile validation regex: %w", err)
+			}
+		}
+		if (validation.Min != nil || validation.Max != nil) && parameter.Type != "number" {
+			return nil, xerrors.New("a min or max can only be used to validate number parameters")
+		}
+		parameter.ValidationRegex = validation.Regex
+		parameter.ValidationError = validation.Error
+		parameter.ValidationMin = validation.Min
+		parameter.ValidationMax = validation.Max
+	}
+	if len(block.Options) > 0 && parameter.Type == "bool" {
+		return nil, xerrors.New("options can't be used with bool parameters")
+	}
+	values := map[string]struct{}{}
+	for _, option := range block.Options {
+		if _, exists := values[option.Value]; exists {
+			return nil, xerrors.Errorf("duplicate option value %q", option.Value)
+		}
+		values[option.Value] = struct{}{}
+		parameter.Options = append(parameter.Options, &proto.RichParameterOption{
+			Name:        option.Name,
+			Description: option.Description,
+			Value:       option.Value,
+			Icon:        option.Icon,
+		})
+	}
+	if parameter.DefaultValue != "" && len(values) > 0 {
+		if _, exists := values[parameter.DefaultValue]; !exists {
+			return nil, xerrors.Errorf("default value %q must be one of the options", parameter.DefaultValue)
+		}
+	}
+	return parameter, nil
+}
+
 // Converts a Terraform variable to a provisioner parameter.
 func convertVariableToParameter(variable *tfconfig.Variable) (*proto.ParameterSchema, error) {
 	schema := &proto.ParameterSchema{
@@ -161,6 +161,106 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "rich-parameters",
+			Files: map[string]string{
+				"main.tf": `data "coder_parameter" "region" {
+					name         = "region"
+					display_name = "Region"
+					description  = "Where to deploy"
+					default      = "eu"
+					icon         = "/emojis/1f30e.png"
+					order        = 2
+					option {
+						name  = "Europe"
+						value = "eu"
+					}
+					option {
+						name        = "United States"
+						description = "Closest to HQ"
+						value       = "us"
+					}
+				}
+				data "coder_parameter" "cpu" {
+					name    = "cpu"
+					type    = "number"
+					mutable = true
+					default = 2
+					order   = 1
+					validation {
+						min = 1
+						max = 8
+					}
+				}
+				data "coder_parameter" "dotfiles" {
+					name    = "dotfiles"
+					type    = "list(string)"
+					default = jsonencode(["git@github.com:coder/dotfiles"])
+					order   = 2
+				}`,
+			},
+			Response: &proto.Parse_Response{
+				Type: &proto.Parse_Response_Complete{
+					Complete: &proto.Parse_Complete{
+						RichParameters: []*proto.RichParameter{{
+							Name:          "cpu",
+							Type:          "number",
+							Mutable:       true,
+							DefaultValue:  "2",
+							ValidationMin: ptrInt32(1),
+							ValidationMax: ptrInt32(8),
+							Order:         1,
+						}, {
+							Name:         "region",
+							DisplayName:  "Region",
+							Description:  "Where to deploy",
+							Type:         "string",
+							DefaultValue: "eu",
+							Icon:         "/emojis/1f30e.png",
+							Order:        2,
+							Options: []*proto.RichParameterOption{{
+								Name:  "Europe",
+								Value: "eu",
+							}, {
+								Name:        "United States",
+								Description: "Closest to HQ",
+								Value:       "us",
+							}},
+						}, {
+							Name:         "dotfiles",
+							Type:         "list(string)",
+							DefaultValue: `["git@github.com:coder/dotfiles"]`,
+							Order:        2,
+						}},
+					},
+				},
+			},
+		},
+		{
+			Name: "rich-parameter-default-not-an-option",
+			Files: map[string]string{
+				"main.tf": `data "coder_parameter" "region" {
+					name    = "region"
+					default = "ap"
+					option {
+						name  = "Europe"
+						value = "eu"
+					}
+				}`,
+			},
+			ErrorContains: `default value "ap" must be one of the options`,
+		},
+		{
+			Name: "rich-parameter-not-static",
+			Files: map[string]string{
+				"main.tf": `variable "region" {}
+				data "coder_parameter" "region" {
+					name    = "region"
+					default = var.region
+				}`,
+			},
+			ErrorContains: `decode coder_parameter "region"`,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -210,3 +310,7 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func ptrInt32(number int32) *int32 {
+	return &number
+}
@@ -2,6 +2,8 @@ package terraform
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -201,6 +203,9 @@ func provisionEnv(start *proto.Provision_Start) ([]string, error) {
 	for key, value := range provisionersdk.AgentScriptEnv() {
 		env = append(env, key+"="+value)
 	}
+	for _, param := range start.RichParameterValues {
+		env = append(env, richParameterEnvironmentVariable(param.Name)+"="+param.Value)
+	}
 	for _, param := range start.ParameterValues {
 		switch param.DestinationScheme {
 		case proto.ParameterDestination_ENVIRONMENT_VARIABLE:
@@ -214,6 +219,15 @@ func provisionEnv(start *proto.Provision_Start) ([]string, error) {
 	return env, nil
 }
 
+// richParameterEnvironmentVariable returns the environment variable the
+// coder_parameter data source reads its value from. Parameter names are
+// hashed since they may contain characters that aren't valid in variable
+// names.
+func richParameterEnvironmentVariable(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return "CODER_PARAMETER_" + hex.EncodeToString(sum[:])
+}
+
 var (
 	// tfEnvSafeToPrint is the set of terraform environment variables that we are quite sure won't contain secrets,
 	// and therefore it's ok to log their values
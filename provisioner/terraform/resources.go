@@ -22,6 +22,27 @@ type agentAttributes struct {
 	Token           string            `mapstructure:"token"`
 	Env             map[string]string `mapstructure:"env"`
 	StartupScript   string            `mapstructure:"startup_script"`
+	// StartupScriptTimeoutSeconds is zero if unset, which means the
+	// startup script never times out.
+	StartupScriptTimeoutSeconds int32 `mapstructure:"startup_script_timeout"`
+	// LoginBeforeReady is a pointer so older providers that don't set it
+	// keep the default of allowing login immediately.
+	LoginBeforeReady *bool  `mapstructure:"login_before_ready"`
+	ShutdownScript   string `mapstructure:"shutdown_script"`
+	// ShutdownScriptTimeoutSeconds is zero if unset, which means the
+	// shutdown script times out after codersdk.DefaultShutdownScriptTimeout.
+	ShutdownScriptTimeoutSeconds int32                     `mapstructure:"shutdown_script_timeout"`
+	Metadata                     []agentMetadataAttributes `mapstructure:"metadata"`
+}
+
+// A mapping of attributes on the "metadata" block of a "coder_agent".
+type agentMetadataAttributes struct {
+	Key         string `mapstructure:"key"`
+	DisplayName string `mapstructure:"display_name"`
+	Script      string `mapstructure:"script"`
+	// Interval and Timeout are in seconds.
+	Interval int64 `mapstructure:"interval"`
+	Timeout  int64 `mapstructure:"timeout"`
 }
 
 // A mapping of attributes on the "coder_app" resource.
@@ -125,6 +146,23 @@ func ConvertResources(module *tfjson.StateModule, rawGraph string) ([]*proto.Res
 			OperatingSystem: attrs.OperatingSystem,
 			Architecture:    attrs.Architecture,
 			Directory:       attrs.Directory,
+
+			StartupScriptTimeoutSeconds:  attrs.StartupScriptTimeoutSeconds,
+			LoginBeforeReady:             true,
+			ShutdownScript:               attrs.ShutdownScript,
+			ShutdownScriptTimeoutSeconds: attrs.ShutdownScriptTimeoutSeconds,
+		}
+		if attrs.LoginBeforeReady != nil {
+			agent.LoginBeforeReady = *attrs.LoginBeforeReady
+		}
+		for _, md := range attrs.Metadata {
+			agent.Metadata = append(agent.Metadata, &proto.Agent_Metadata{
+				Key:         md.Key,
+				DisplayName: md.DisplayName,
+				Script:      md.Script,
+				Interval:    md.Interval,
+				Timeout:     md.Timeout,
+			})
 		}
 		switch attrs.Auth {
 		case "token":
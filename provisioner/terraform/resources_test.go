@@ -33,10 +33,11 @@ func TestConvertResources(t *testing.T) {
 			Name: "b",
 			Type: "null_resource",
 			Agents: []*proto.Agent{{
-				Name:            "main",
-				OperatingSystem: "linux",
-				Architecture:    "amd64",
-				Auth:            &proto.Agent_Token{},
+				Name:             "main",
+				OperatingSystem:  "linux",
+				Architecture:     "amd64",
+				Auth:             &proto.Agent_Token{},
+				LoginBeforeReady: true,
 			}},
 		}},
 		// This can happen when resources hierarchically conflict.
@@ -46,10 +47,11 @@ func TestConvertResources(t *testing.T) {
 			Name: "first",
 			Type: "null_resource",
 			Agents: []*proto.Agent{{
-				Name:            "main",
-				OperatingSystem: "linux",
-				Architecture:    "amd64",
-				Auth:            &proto.Agent_Token{},
+				Name:             "main",
+				OperatingSystem:  "linux",
+				Architecture:     "amd64",
+				Auth:             &proto.Agent_Token{},
+				LoginBeforeReady: true,
 			}},
 		}, {
 			Name: "second",
@@ -60,10 +62,11 @@ func TestConvertResources(t *testing.T) {
 			Name: "main",
 			Type: "null_resource",
 			Agents: []*proto.Agent{{
-				Name:            "main",
-				OperatingSystem: "linux",
-				Architecture:    "amd64",
-				Auth:            &proto.Agent_InstanceId{},
+				Name:             "main",
+				OperatingSystem:  "linux",
+				Architecture:     "amd64",
+				Auth:             &proto.Agent_InstanceId{},
+				LoginBeforeReady: true,
 			}},
 		}},
 		// Ensures that calls to resources through modules work
@@ -72,10 +75,11 @@ func TestConvertResources(t *testing.T) {
 			Name: "example",
 			Type: "null_resource",
 			Agents: []*proto.Agent{{
-				Name:            "main",
-				OperatingSystem: "linux",
-				Architecture:    "amd64",
-				Auth:            &proto.Agent_Token{},
+				Name:             "main",
+				OperatingSystem:  "linux",
+				Architecture:     "amd64",
+				Auth:             &proto.Agent_Token{},
+				LoginBeforeReady: true,
 			}},
 		}},
 		// Ensures the attachment of multiple agents to a single
@@ -84,20 +88,32 @@ func TestConvertResources(t *testing.T) {
 			Name: "dev",
 			Type: "null_resource",
 			Agents: []*proto.Agent{{
-				Name:            "dev1",
-				OperatingSystem: "linux",
-				Architecture:    "amd64",
-				Auth:            &proto.Agent_Token{},
+				Name:             "dev1",
+				OperatingSystem:  "linux",
+				Architecture:     "amd64",
+				Auth:             &proto.Agent_Token{},
+				LoginBeforeReady: true,
+				Metadata: []*proto.Agent_Metadata{{
+					Key:         "process_count",
+					DisplayName: "Process Count",
+					Script:      "ps -ef | wc -l",
+					Interval:    5,
+					Timeout:     1,
+				}},
 			}, {
-				Name:            "dev2",
-				OperatingSystem: "darwin",
-				Architecture:    "amd64",
-				Auth:            &proto.Agent_Token{},
+				Name:                        "dev2",
+				OperatingSystem:             "darwin",
+				Architecture:                "amd64",
+				Auth:                        &proto.Agent_Token{},
+				StartupScriptTimeoutSeconds: 30,
 			}, {
-				Name:            "dev3",
-				OperatingSystem: "windows",
-				Architecture:    "arm64",
-				Auth:            &proto.Agent_Token{},
+				Name:                         "dev3",
+				OperatingSystem:              "windows",
+				Architecture:                 "arm64",
+				Auth:                         &proto.Agent_Token{},
+				LoginBeforeReady:             true,
+				ShutdownScript:               "echo bye",
+				ShutdownScriptTimeoutSeconds: 60,
 			}},
 		}},
 		// Ensures multiple applications can be set for a single agent.
@@ -131,7 +147,8 @@ func TestConvertResources(t *testing.T) {
 						Subdomain:   false,
 					},
 				},
-				Auth: &proto.Agent_Token{},
+				Auth:             &proto.Agent_Token{},
+				LoginBeforeReady: true,
 			}},
 		}},
 		// Tests fetching metadata about workspace resources.
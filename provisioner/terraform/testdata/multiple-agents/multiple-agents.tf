@@ -10,16 +10,27 @@ terraform {
 resource "coder_agent" "dev1" {
   os   = "linux"
   arch = "amd64"
+  metadata {
+    key          = "process_count"
+    display_name = "Process Count"
+    script       = "ps -ef | wc -l"
+    interval     = 5
+    timeout      = 1
+  }
 }
 
 resource "coder_agent" "dev2" {
-  os   = "darwin"
-  arch = "amd64"
+  os                     = "darwin"
+  arch                   = "amd64"
+  startup_script_timeout = 30
+  login_before_ready     = false
 }
 
 resource "coder_agent" "dev3" {
-  os   = "windows"
-  arch = "arm64"
+  os                      = "windows"
+  arch                    = "arm64"
+  shutdown_script         = "echo bye"
+  shutdown_script_timeout = 60
 }
 
 resource "null_resource" "dev" {
@@ -515,13 +515,14 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 	if err != nil {
 		return nil, r.failedJobf("write log: %s", err)
 	}
-	parameterSchemas, err := r.runTemplateImportParse(ctx)
+	parameterSchemas, richParameters, err := r.runTemplateImportParse(ctx)
 	if err != nil {
 		return nil, r.failedJobf("run parse: %s", err)
 	}
 	updateResponse, err := r.update(ctx, &proto.UpdateJobRequest{
 		JobId:            r.job.JobId,
 		ParameterSchemas: parameterSchemas,
+		RichParameters:   richParameters,
 	})
 	if err != nil {
 		return nil, r.failedJobf("update job: %s", err)
@@ -537,6 +538,14 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 			return nil, r.failedJobf("%s: %s", MissingParameterErrorText, parameterSchema.Name)
 		}
 	}
+	// Workspaces don't exist yet, so detect resources using the defaults.
+	richParameterValues := make([]*sdkproto.RichParameterValue, 0, len(richParameters))
+	for _, richParameter := range richParameters {
+		richParameterValues = append(richParameterValues, &sdkproto.RichParameterValue{
+			Name:  richParameter.Name,
+			Value: richParameter.DefaultValue,
+		})
+	}
 
 	// Determine persistent resources
 	_, err = r.update(ctx, &proto.UpdateJobRequest{
@@ -551,7 +560,7 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 	if err != nil {
 		return nil, r.failedJobf("write log: %s", err)
 	}
-	startResources, err := r.runTemplateImportProvision(ctx, updateResponse.ParameterValues, &sdkproto.Provision_Metadata{
+	startResources, err := r.runTemplateImportProvision(ctx, updateResponse.ParameterValues, richParameterValues, nil, &sdkproto.Provision_Metadata{
 		CoderUrl:            r.job.GetTemplateImport().Metadata.CoderUrl,
 		WorkspaceTransition: sdkproto.WorkspaceTransition_START,
 	})
@@ -572,7 +581,7 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 	if err != nil {
 		return nil, r.failedJobf("write log: %s", err)
 	}
-	stopResources, err := r.runTemplateImportProvision(ctx, updateResponse.ParameterValues, &sdkproto.Provision_Metadata{
+	stopResources, err := r.runTemplateImportProvision(ctx, updateResponse.ParameterValues, richParameterValues, nil, &sdkproto.Provision_Metadata{
 		CoderUrl:            r.job.GetTemplateImport().Metadata.CoderUrl,
 		WorkspaceTransition: sdkproto.WorkspaceTransition_STOP,
 	})
@@ -591,8 +600,8 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 	}, nil
 }
 
-// Parses parameter schemas from source.
-func (r *Runner) runTemplateImportParse(ctx context.Context) ([]*sdkproto.ParameterSchema, error) {
+// Parses parameter schemas and rich parameters from source.
+func (r *Runner) runTemplateImportParse(ctx context.Context) ([]*sdkproto.ParameterSchema, []*sdkproto.RichParameter, error) {
 	ctx, span := r.startTrace(ctx, tracing.FuncName())
 	defer span.End()
 
@@ -600,13 +609,13 @@ func (r *Runner) runTemplateImportParse(ctx context.Context) ([]*sdkproto.Parame
 		Directory: r.workDirectory,
 	})
 	if err != nil {
-		return nil, xerrors.Errorf("parse source: %w", err)
+		return nil, nil, xerrors.Errorf("parse source: %w", err)
 	}
 	defer stream.Close()
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
-			return nil, xerrors.Errorf("recv parse source: %w", err)
+			return nil, nil, xerrors.Errorf("recv parse source: %w", err)
 		}
 		switch msgType := msg.Type.(type) {
 		case *sdkproto.Parse_Response_Log:
@@ -626,15 +635,16 @@ func (r *Runner) runTemplateImportParse(ctx context.Context) ([]*sdkproto.Parame
 				}},
 			})
 			if err != nil {
-				return nil, xerrors.Errorf("update job: %w", err)
+				return nil, nil, xerrors.Errorf("update job: %w", err)
 			}
 		case *sdkproto.Parse_Response_Complete:
 			r.logger.Info(context.Background(), "parse complete",
-				slog.F("parameter_schemas", msgType.Complete.ParameterSchemas))
+				slog.F("parameter_schemas", msgType.Complete.ParameterSchemas),
+				slog.F("rich_parameters", msgType.Complete.RichParameters))
 
-			return msgType.Complete.ParameterSchemas, nil
+			return msgType.Complete.ParameterSchemas, msgType.Complete.RichParameters, nil
 		default:
-			return nil, xerrors.Errorf("invalid message type %q received from provisioner",
+			return nil, nil, xerrors.Errorf("invalid message type %q received from provisioner",
 				reflect.TypeOf(msg.Type).String())
 		}
 	}
@@ -643,7 +653,7 @@ func (r *Runner) runTemplateImportParse(ctx context.Context) ([]*sdkproto.Parame
 // Performs a dry-run provision when importing a template.
 // This is used to detect resources that would be provisioned
 // for a workspace in various states.
-func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkproto.ParameterValue, metadata *sdkproto.Provision_Metadata) ([]*sdkproto.Resource, error) {
+func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkproto.ParameterValue, richValues []*sdkproto.RichParameterValue, state []byte, metadata *sdkproto.Provision_Metadata) ([]*sdkproto.Resource, error) {
 	ctx, span := r.startTrace(ctx, tracing.FuncName())
 	defer span.End()
 
@@ -676,10 +686,12 @@ func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkpr
 	err = stream.Send(&sdkproto.Provision_Request{
 		Type: &sdkproto.Provision_Request_Start{
 			Start: &sdkproto.Provision_Start{
-				Directory:       r.workDirectory,
-				ParameterValues: values,
-				DryRun:          true,
-				Metadata:        metadata,
+				Directory:           r.workDirectory,
+				ParameterValues:     values,
+				RichParameterValues: richValues,
+				State:               state,
+				DryRun:              true,
+				Metadata:            metadata,
 			},
 		},
 	})
@@ -739,8 +751,9 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 	defer span.End()
 
 	// Ensure all metadata fields are set as they are all optional for dry-run.
+	// The transition is left as-is, since dry-runs against an existing
+	// workspace preview the transition of its latest build.
 	metadata := r.job.GetTemplateDryRun().GetMetadata()
-	metadata.WorkspaceTransition = sdkproto.WorkspaceTransition_START
 	if metadata.CoderUrl == "" {
 		metadata.CoderUrl = "http://localhost:3000"
 	}
@@ -769,6 +782,8 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 	// Run the template import provision task since it's already a dry run.
 	resources, err := r.runTemplateImportProvision(ctx,
 		r.job.GetTemplateDryRun().GetParameterValues(),
+		r.job.GetTemplateDryRun().GetRichParameterValues(),
+		r.job.GetTemplateDryRun().GetState(),
 		metadata,
 	)
 	if err != nil {
@@ -834,10 +849,11 @@ func (r *Runner) runWorkspaceBuild(ctx context.Context) (*proto.CompletedJob, *p
 	err = stream.Send(&sdkproto.Provision_Request{
 		Type: &sdkproto.Provision_Request_Start{
 			Start: &sdkproto.Provision_Start{
-				Directory:       r.workDirectory,
-				ParameterValues: r.job.GetWorkspaceBuild().ParameterValues,
-				Metadata:        r.job.GetWorkspaceBuild().Metadata,
-				State:           r.job.GetWorkspaceBuild().State,
+				Directory:           r.workDirectory,
+				ParameterValues:     r.job.GetWorkspaceBuild().ParameterValues,
+				RichParameterValues: r.job.GetWorkspaceBuild().RichParameterValues,
+				Metadata:            r.job.GetWorkspaceBuild().Metadata,
+				State:               r.job.GetWorkspaceBuild().State,
 			},
 		},
 	})
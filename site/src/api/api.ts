@@ -210,6 +210,62 @@ export const getTemplateVersionResources = async (
   return response.data
 }
 
+export const createTemplateVersionDryRun = async (
+  versionId: string,
+  data: TypesGen.CreateTemplateVersionDryRunRequest,
+): Promise<TypesGen.ProvisionerJob> => {
+  const response = await axios.post<TypesGen.ProvisionerJob>(
+    `/api/v2/templateversions/${versionId}/dry-run`,
+    data,
+  )
+  return response.data
+}
+
+export const getTemplateVersionDryRun = async (
+  versionId: string,
+  jobId: string,
+): Promise<TypesGen.ProvisionerJob> => {
+  const response = await axios.get<TypesGen.ProvisionerJob>(
+    `/api/v2/templateversions/${versionId}/dry-run/${jobId}`,
+  )
+  return response.data
+}
+
+export const getTemplateVersionDryRunDiff = async (
+  versionId: string,
+  jobId: string,
+): Promise<TypesGen.WorkspaceUpdateDiff> => {
+  const response = await axios.get<TypesGen.WorkspaceUpdateDiff>(
+    `/api/v2/templateversions/${versionId}/dry-run/${jobId}/diff`,
+  )
+  return response.data
+}
+
+// getWorkspaceUpdateDiff dry-runs a template version against a workspace and
+// waits for the job to finish, returning what an update would change.
+export const getWorkspaceUpdateDiff = async (
+  workspaceId: string,
+  versionId: string,
+): Promise<TypesGen.WorkspaceUpdateDiff> => {
+  let job = await createTemplateVersionDryRun(versionId, {
+    workspace_name: "",
+    parameter_values: [],
+    workspace_id: workspaceId,
+  })
+  while (
+    job.status === "pending" ||
+    job.status === "running" ||
+    job.status === "canceling"
+  ) {
+    await new Promise((resolve) => setTimeout(resolve, 1000))
+    job = await getTemplateVersionDryRun(versionId, job.id)
+  }
+  if (job.status !== "succeeded") {
+    throw new Error(job.error ?? `Dry-run ${job.status}`)
+  }
+  return getTemplateVersionDryRunDiff(versionId, job.id)
+}
+
 export const getTemplateVersions = async (
   templateId: string,
 ): Promise<TypesGen.TemplateVersion[]> => {
@@ -264,6 +320,19 @@ export const watchWorkspace = (workspaceId: string): EventSource => {
   )
 }
 
+/**
+ *
+ * @param agentId
+ * @returns An EventSource that emits the agent's metadata
+ * (WorkspaceAgentMetadatum[]) every time an item changes
+ */
+export const watchAgentMetadata = (agentId: string): EventSource => {
+  return new EventSource(
+    `${location.protocol}//${location.host}/api/v2/workspaceagents/${agentId}/watch-metadata`,
+    { withCredentials: true },
+  )
+}
+
 interface SearchParamOptions extends TypesGen.Pagination {
   q?: string
 }
@@ -644,6 +713,24 @@ export const getAgentListeningPorts = async (
   return response.data
 }
 
+export const getAgentFileURL = (agentID: string, path: string): string => {
+  const params = new URLSearchParams({ path })
+  return `/api/v2/workspaceagents/${agentID}/files?${params.toString()}`
+}
+
+export const uploadAgentFile = async (
+  agentID: string,
+  path: string,
+  file: File,
+): Promise<TypesGen.AgentFileChecksum> => {
+  const response = await axios.put(getAgentFileURL(agentID, path), file, {
+    headers: {
+      "Content-Type": "application/octet-stream",
+    },
+  })
+  return response.data
+}
+
 export const getDeploymentConfig =
   async (): Promise<TypesGen.DeploymentConfig> => {
     const response = await axios.get(`/api/v2/config/deployment`)
@@ -10,6 +10,7 @@ export interface APIKey {
   readonly updated_at: string
   readonly login_type: LoginType
   readonly scope: APIKeyScope
+  readonly scopes: string[]
   readonly lifetime_seconds: number
 }
 
@@ -18,6 +19,13 @@ export interface AddLicenseRequest {
   readonly license: string
 }
 
+// From codersdk/workspaceagentfiles.go
+export interface AgentFileChecksum {
+  readonly path: string
+  readonly size: number
+  readonly sha256: string
+}
+
 // From codersdk/gitsshkey.go
 export interface AgentGitSSHKey {
   readonly public_key: string
@@ -29,6 +37,7 @@ export interface AgentStatsReportResponse {
   readonly num_comms: number
   readonly rx_bytes: number
   readonly tx_bytes: number
+  readonly connections?: ConnectionStat[]
 }
 
 // From codersdk/roles.go
@@ -138,6 +147,36 @@ export interface ComputedParameter extends Parameter {
   readonly default_source_value: boolean
 }
 
+// From codersdk/connectionstats.go
+export interface ConnectionStat {
+  readonly peer_ip: string
+  readonly direct: boolean
+  readonly endpoint?: string
+  readonly derp_region_id: number
+  readonly latency_ms: number
+  readonly rx_bytes: number
+  readonly tx_bytes: number
+}
+
+// From codersdk/connectionstats.go
+export interface ConnectionStatsRegion {
+  readonly derp_region_id: number
+  readonly derp_region_name: string
+  readonly source: ConnectionStatSource
+  readonly connections: number
+  readonly direct_connections: number
+  readonly average_latency_ms: number
+}
+
+// From codersdk/roles.go
+export interface CreateCustomRoleRequest {
+  readonly name: string
+  readonly display_name: string
+  readonly site_permissions: Permission[]
+  readonly org_permissions: Permission[]
+  readonly user_permissions: Permission[]
+}
+
 // From codersdk/users.go
 export interface CreateFirstUserRequest {
   readonly email: string
@@ -187,6 +226,8 @@ export interface CreateTemplateRequest {
 export interface CreateTemplateVersionDryRunRequest {
   readonly workspace_name: string
   readonly parameter_values: CreateParameterRequest[]
+  readonly rich_parameter_values?: WorkspaceBuildParameter[]
+  readonly workspace_id?: string
 }
 
 // From codersdk/organizations.go
@@ -197,6 +238,7 @@ export interface CreateTemplateVersionRequest {
   readonly file_id: string
   readonly provisioner: ProvisionerType
   readonly parameter_values?: CreateParameterRequest[]
+  readonly tags?: Record<string, string>
 }
 
 // From codersdk/audit.go
@@ -204,11 +246,15 @@ export interface CreateTestAuditLogRequest {
   readonly action?: AuditAction
   readonly resource_type?: ResourceType
   readonly resource_id?: string
+  readonly organization_id?: string
 }
 
 // From codersdk/apikey.go
 export interface CreateTokenRequest {
   readonly scope: APIKeyScope
+  readonly scopes?: string[]
+  // This is likely an enum in an external package ("time.Duration")
+  readonly lifetime?: number
 }
 
 // From codersdk/users.go
@@ -217,6 +263,8 @@ export interface CreateUserRequest {
   readonly username: string
   readonly password: string
   readonly organization_id: string
+  readonly service_account: boolean
+  readonly contact: string
 }
 
 // From codersdk/workspaces.go
@@ -227,6 +275,20 @@ export interface CreateWorkspaceBuildRequest {
   readonly state?: string
   readonly orphan?: boolean
   readonly parameter_values?: CreateParameterRequest[]
+  readonly rich_parameter_values?: WorkspaceBuildParameter[]
+}
+
+// From codersdk/workspaceproxy.go
+export interface CreateWorkspaceProxyRequest {
+  readonly name: string
+  readonly display_name: string
+  readonly icon: string
+}
+
+// From codersdk/workspaceproxy.go
+export interface CreateWorkspaceProxyResponse {
+  readonly proxy: WorkspaceProxy
+  readonly proxy_token: string
 }
 
 // From codersdk/organizations.go
@@ -236,6 +298,20 @@ export interface CreateWorkspaceRequest {
   readonly autostart_schedule?: string
   readonly ttl_ms?: number
   readonly parameter_values?: CreateParameterRequest[]
+  readonly rich_parameter_values?: WorkspaceBuildParameter[]
+}
+
+// From codersdk/roles.go
+export interface CustomRole {
+  readonly id: string
+  readonly name: string
+  readonly display_name: string
+  readonly organization_id?: string
+  readonly site_permissions: Permission[]
+  readonly org_permissions: Permission[]
+  readonly user_permissions: Permission[]
+  readonly created_at: string
+  readonly updated_at: string
 }
 
 // From codersdk/templates.go
@@ -254,6 +330,17 @@ export interface DERP {
 export interface DERPConfig {
   readonly url: DeploymentConfigField<string>
   readonly path: DeploymentConfigField<string>
+  readonly refresh_interval: DeploymentConfigField<number>
+}
+
+// From codersdk/derpmap.go
+export interface DERPNodeHealth {
+  readonly name: string
+  readonly host_name: string
+  readonly stun_only: boolean
+  readonly derp_latency_ms: number
+  readonly stun_latency_ms: number
+  readonly error?: string
 }
 
 // From codersdk/workspaceagents.go
@@ -262,6 +349,17 @@ export interface DERPRegion {
   readonly latency_ms: number
 }
 
+// From codersdk/derpmap.go
+export interface DERPRegionHealth {
+  readonly region_id: number
+  readonly region_code: string
+  readonly region_name: string
+  readonly status: DERPRegionStatus
+  readonly latency_ms: number
+  readonly nodes: DERPNodeHealth[]
+  readonly checked_at: string
+}
+
 // From codersdk/deploymentconfig.go
 export interface DERPServerConfig {
   readonly enable: DeploymentConfigField<boolean>
@@ -295,6 +393,8 @@ export interface DeploymentConfig {
   readonly trace: TraceConfig
   readonly secure_auth_cookie: DeploymentConfigField<boolean>
   readonly ssh_keygen_algorithm: DeploymentConfigField<string>
+  readonly ssh_certificate_ttl: DeploymentConfigField<number>
+  readonly ssh_require_certificates: DeploymentConfigField<boolean>
   readonly auto_import_templates: DeploymentConfigField<string[]>
   readonly metrics_cache_refresh_interval: DeploymentConfigField<number>
   readonly agent_stat_refresh_interval: DeploymentConfigField<number>
@@ -302,6 +402,8 @@ export interface DeploymentConfig {
   readonly browser_only: DeploymentConfigField<boolean>
   readonly scim_api_key: DeploymentConfigField<string>
   readonly user_workspace_quota: DeploymentConfigField<number>
+  readonly provisioner_daemon_psk: DeploymentConfigField<string>
+  readonly max_service_account_token_lifetime: DeploymentConfigField<number>
 }
 
 // From codersdk/deploymentconfig.go
@@ -380,6 +482,11 @@ export interface Healthcheck {
   readonly threshold: number
 }
 
+// From codersdk/workspaceagents.go
+export interface IssueWorkspaceAgentSSHCertificateRequest {
+  readonly public_key: string
+}
+
 // From codersdk/licenses.go
 export interface License {
   readonly id: number
@@ -432,8 +539,12 @@ export interface OIDCConfig {
   readonly client_id: DeploymentConfigField<string>
   readonly client_secret: DeploymentConfigField<string>
   readonly email_domain: DeploymentConfigField<string>
+  readonly group_field: DeploymentConfigField<string>
+  readonly group_mapping: DeploymentConfigField<string>
   readonly issuer_url: DeploymentConfigField<string>
   readonly scopes: DeploymentConfigField<string[]>
+  readonly user_role_field: DeploymentConfigField<string>
+  readonly user_role_mapping: DeploymentConfigField<string>
 }
 
 // From codersdk/organizations.go
@@ -442,6 +553,7 @@ export interface Organization {
   readonly name: string
   readonly created_at: string
   readonly updated_at: string
+  readonly user_workspace_quota: number
 }
 
 // From codersdk/organizationmember.go
@@ -453,6 +565,17 @@ export interface OrganizationMember {
   readonly roles: Role[]
 }
 
+// From codersdk/organizationmember.go
+export interface OrganizationMemberWithUser {
+  readonly user_id: string
+  readonly organization_id: string
+  readonly username: string
+  readonly email: string
+  readonly created_at: string
+  readonly updated_at: string
+  readonly roles: Role[]
+}
+
 // From codersdk/pagination.go
 export interface Pagination {
   readonly after_id?: string
@@ -501,6 +624,18 @@ export interface PatchGroupRequest {
   readonly avatar_url?: string
 }
 
+// From codersdk/roles.go
+export interface Permission {
+  readonly negate: boolean
+  readonly resource_type: string
+  readonly action: string
+}
+
+// From codersdk/connectionstats.go
+export interface PostWorkspaceAgentConnectionStatsRequest {
+  readonly connections: ConnectionStat[]
+}
+
 // From codersdk/deploymentconfig.go
 export interface PprofConfig {
   readonly enable: DeploymentConfigField<boolean>
@@ -520,6 +655,8 @@ export interface ProvisionerDaemon {
   readonly updated_at?: string
   readonly name: string
   readonly provisioners: ProvisionerType[]
+  readonly tags: Record<string, string>
+  readonly organization_id?: string
 }
 
 // From codersdk/provisionerdaemons.go
@@ -533,6 +670,7 @@ export interface ProvisionerJob {
   readonly status: ProvisionerJobStatus
   readonly worker_id?: string
   readonly file_id: string
+  readonly tags: Record<string, string>
 }
 
 // From codersdk/provisionerdaemons.go
@@ -550,6 +688,33 @@ export interface PutExtendWorkspaceRequest {
   readonly deadline: string
 }
 
+// From codersdk/ratelimits.go
+export interface RateLimitPolicy {
+  readonly id: string
+  readonly subject_type: RateLimitSubjectType
+  readonly subject: string
+  readonly count: number
+  readonly window_seconds: number
+  readonly created_at: string
+  readonly updated_at: string
+}
+
+// From codersdk/workspaceproxy.go
+export interface Region {
+  readonly id: string
+  readonly name: string
+  readonly display_name: string
+  readonly icon_url: string
+  readonly healthy: boolean
+  readonly path_app_url: string
+  readonly wildcard_hostname: string
+}
+
+// From codersdk/workspaceproxy.go
+export interface RegionsResponse {
+  readonly regions: Region[]
+}
+
 // From codersdk/replicas.go
 export interface Replica {
   readonly id: string
@@ -614,6 +779,11 @@ export interface Template {
   readonly icon: string
   readonly max_ttl_ms: number
   readonly min_autostart_interval_ms: number
+  readonly autostop_requirement_days: number
+  readonly inactivity_ttl_ms: number
+  readonly dormant_delete_ttl_ms: number
+  readonly port_forwarding_allowlist: string[]
+  readonly record_sessions: boolean
   readonly created_by_id: string
   readonly created_by_name: string
 }
@@ -659,6 +829,31 @@ export interface TemplateVersion {
   readonly created_by: User
 }
 
+// From codersdk/templateversions.go
+export interface TemplateVersionParameter {
+  readonly name: string
+  readonly display_name?: string
+  readonly description: string
+  readonly type: string
+  readonly mutable: boolean
+  readonly default_value: string
+  readonly icon: string
+  readonly options: TemplateVersionParameterOption[]
+  readonly validation_error?: string
+  readonly validation_regex?: string
+  readonly validation_min?: number
+  readonly validation_max?: number
+  readonly required: boolean
+}
+
+// From codersdk/templateversions.go
+export interface TemplateVersionParameterOption {
+  readonly name: string
+  readonly description: string
+  readonly value: string
+  readonly icon: string
+}
+
 // From codersdk/templates.go
 export interface TemplateVersionsByTemplateRequest extends Pagination {
   readonly template_id: string
@@ -675,6 +870,20 @@ export interface UpdateActiveTemplateVersion {
   readonly id: string
 }
 
+// From codersdk/roles.go
+export interface UpdateCustomRoleRequest {
+  readonly display_name: string
+  readonly site_permissions: Permission[]
+  readonly org_permissions: Permission[]
+  readonly user_permissions: Permission[]
+}
+
+// From codersdk/organizations.go
+export interface UpdateOrganizationRequest {
+  readonly name?: string
+  readonly user_workspace_quota?: number
+}
+
 // From codersdk/users.go
 export interface UpdateRoles {
   readonly roles: string[]
@@ -693,6 +902,11 @@ export interface UpdateTemplateMeta {
   readonly icon?: string
   readonly max_ttl_ms?: number
   readonly min_autostart_interval_ms?: number
+  readonly autostop_requirement_days?: number
+  readonly inactivity_ttl_ms?: number
+  readonly dormant_delete_ttl_ms?: number
+  readonly port_forwarding_allowlist?: string[]
+  readonly record_sessions?: boolean
 }
 
 // From codersdk/users.go
@@ -706,11 +920,21 @@ export interface UpdateUserProfileRequest {
   readonly username: string
 }
 
+// From codersdk/users.go
+export interface UpdateUserQuietHoursScheduleRequest {
+  readonly schedule: string
+}
+
 // From codersdk/workspaces.go
 export interface UpdateWorkspaceAutostartRequest {
   readonly schedule?: string
 }
 
+// From codersdk/workspaces.go
+export interface UpdateWorkspaceDormancy {
+  readonly dormant: boolean
+}
+
 // From codersdk/workspaces.go
 export interface UpdateWorkspaceRequest {
   readonly name?: string
@@ -726,6 +950,14 @@ export interface UploadResponse {
   readonly hash: string
 }
 
+// From codersdk/ratelimits.go
+export interface UpsertRateLimitPolicyRequest {
+  readonly subject_type: RateLimitSubjectType
+  readonly subject: string
+  readonly count: number
+  readonly window_seconds: number
+}
+
 // From codersdk/users.go
 export interface User {
   readonly id: string
@@ -737,6 +969,17 @@ export interface User {
   readonly organization_ids: string[]
   readonly roles: Role[]
   readonly avatar_url: string
+  readonly service_account: boolean
+  readonly contact: string
+}
+
+// From codersdk/users.go
+export interface UserQuietHoursScheduleResponse {
+  readonly raw_schedule: string
+  readonly user_set: boolean
+  readonly time: string
+  readonly timezone: string
+  readonly next: string
 }
 
 // From codersdk/users.go
@@ -772,6 +1015,8 @@ export interface Workspace {
   readonly autostart_schedule?: string
   readonly ttl_ms?: number
   readonly last_used_at: string
+  readonly dormant_at?: string
+  readonly deleting_at?: string
 }
 
 // From codersdk/workspaceagents.go
@@ -793,9 +1038,34 @@ export interface WorkspaceAgent {
   readonly directory?: string
   readonly version: string
   readonly apps: WorkspaceApp[]
+  readonly startup_logs_length: number
+  readonly startup_logs_overflowed: boolean
+  readonly lifecycle_state: WorkspaceAgentLifecycle
+  readonly startup_script_timeout_seconds: number
+  readonly login_before_ready: boolean
+  readonly shutdown_script?: string
+  readonly shutdown_script_timeout_seconds: number
   readonly latency?: Record<string, DERPRegion>
 }
 
+// From codersdk/connectionstats.go
+export interface WorkspaceAgentConnectionStat {
+  readonly id: string
+  readonly created_at: string
+  readonly source: ConnectionStatSource
+  readonly agent_id: string
+  readonly workspace_id: string
+  readonly user_id: string
+  readonly peer_ip: string
+  readonly direct: boolean
+  readonly endpoint?: string
+  readonly derp_region_id: number
+  readonly derp_region_name: string
+  readonly latency_ms: number
+  readonly rx_bytes: number
+  readonly tx_bytes: number
+}
+
 // From codersdk/workspaceagents.go
 export interface WorkspaceAgentGitAuthResponse {
   readonly username: string
@@ -816,6 +1086,29 @@ export interface WorkspaceAgentInstanceMetadata {
   readonly vnc: boolean
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataDescription {
+  readonly display_name: string
+  readonly key: string
+  readonly script: string
+  readonly interval: number
+  readonly timeout: number
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataResult {
+  readonly collected_at: string
+  readonly age: number
+  readonly value: string
+  readonly error: string
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadatum {
+  readonly result: WorkspaceAgentMetadataResult
+  readonly description: WorkspaceAgentMetadataDescription
+}
+
 // From codersdk/workspaceagents.go
 export interface WorkspaceAgentResourceMetadata {
   readonly memory_total: number
@@ -825,6 +1118,20 @@ export interface WorkspaceAgentResourceMetadata {
   readonly cpu_mhz: number
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentSSHCertificate {
+  readonly certificate: string
+  readonly expires_at: string
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentStartupLog {
+  readonly id: number
+  readonly created_at: string
+  readonly output: string
+  readonly eof: boolean
+}
+
 // From codersdk/workspaceapps.go
 export interface WorkspaceApp {
   readonly id: string
@@ -834,6 +1141,7 @@ export interface WorkspaceApp {
   readonly icon?: string
   readonly subdomain: boolean
   readonly sharing_level: WorkspaceAppSharingLevel
+  readonly tcp: boolean
   readonly healthcheck: Healthcheck
   readonly health: WorkspaceAppHealth
 }
@@ -856,9 +1164,24 @@ export interface WorkspaceBuild {
   readonly reason: BuildReason
   readonly resources: WorkspaceResource[]
   readonly deadline?: string
+  readonly max_deadline?: string
   readonly status: WorkspaceStatus
 }
 
+// From codersdk/workspacebuilds.go
+export interface WorkspaceBuildParameter {
+  readonly name: string
+  readonly value: string
+}
+
+// From codersdk/templateversions.go
+export interface WorkspaceBuildParameterDiff {
+  readonly name: string
+  readonly old_value: string
+  readonly new_value: string
+  readonly change: WorkspaceUpdateDiffChange
+}
+
 // From codersdk/workspaces.go
 export interface WorkspaceBuildsRequest extends Pagination {
   readonly WorkspaceID: string
@@ -885,6 +1208,20 @@ export interface WorkspaceOptions {
   readonly include_deleted?: boolean
 }
 
+// From codersdk/workspaceproxy.go
+export interface WorkspaceProxy {
+  readonly id: string
+  readonly name: string
+  readonly display_name: string
+  readonly icon: string
+  readonly url: string
+  readonly wildcard_hostname: string
+  readonly derp_enabled: boolean
+  readonly region_id: number
+  readonly created_at: string
+  readonly updated_at: string
+}
+
 // From codersdk/workspacequota.go
 export interface WorkspaceQuota {
   readonly user_workspace_count: number
@@ -905,6 +1242,13 @@ export interface WorkspaceResource {
   readonly metadata?: WorkspaceResourceMetadata[]
 }
 
+// From codersdk/templateversions.go
+export interface WorkspaceResourceDiff {
+  readonly type: string
+  readonly name: string
+  readonly change: WorkspaceUpdateDiffChange
+}
+
 // From codersdk/workspacebuilds.go
 export interface WorkspaceResourceMetadata {
   readonly key: string
@@ -912,6 +1256,25 @@ export interface WorkspaceResourceMetadata {
   readonly sensitive: boolean
 }
 
+// From codersdk/sessionrecordings.go
+export interface WorkspaceSessionRecording {
+  readonly id: string
+  readonly created_at: string
+  readonly workspace_id: string
+  readonly agent_id: string
+  readonly type: SessionRecordingType
+  readonly started_at: string
+  readonly ended_at: string
+  readonly user_id: string
+}
+
+// From codersdk/templateversions.go
+export interface WorkspaceUpdateDiff {
+  readonly workspace_id: string
+  readonly resources: WorkspaceResourceDiff[]
+  readonly parameters: WorkspaceBuildParameterDiff[]
+}
+
 // From codersdk/workspaces.go
 export interface WorkspacesRequest extends Pagination {
   readonly q?: string
@@ -924,7 +1287,18 @@ export type APIKeyScope = "all" | "application_connect"
 export type AuditAction = "create" | "delete" | "start" | "stop" | "write"
 
 // From codersdk/workspacebuilds.go
-export type BuildReason = "autostart" | "autostop" | "initiator"
+export type BuildReason =
+  | "autodelete"
+  | "autostart"
+  | "autostop"
+  | "dormancy"
+  | "initiator"
+
+// From codersdk/connectionstats.go
+export type ConnectionStatSource = "agent" | "client"
+
+// From codersdk/derpmap.go
+export type DERPRegionStatus = "degraded" | "healthy" | "unhealthy"
 
 // From codersdk/features.go
 export type Entitlement = "entitled" | "grace_period" | "not_entitled"
@@ -971,27 +1345,50 @@ export type ProvisionerStorageMethod = "file"
 // From codersdk/organizations.go
 export type ProvisionerType = "echo" | "terraform"
 
+// From codersdk/ratelimits.go
+export type RateLimitSubjectType = "role" | "scope" | "user"
+
 // From codersdk/audit.go
 export type ResourceType =
   | "api_key"
+  | "custom_role"
   | "git_ssh_key"
   | "group"
   | "organization"
+  | "organization_member"
+  | "rate_limit_policy"
+  | "session_recording"
   | "template"
   | "template_version"
   | "user"
   | "workspace"
   | "workspace_build"
+  | "workspace_proxy"
 
 // From codersdk/sse.go
 export type ServerSentEventType = "data" | "error" | "ping"
 
+// From codersdk/sessionrecordings.go
+export type SessionRecordingType = "reconnecting_pty" | "ssh"
+
 // From codersdk/templates.go
 export type TemplateRole = "" | "admin" | "use"
 
 // From codersdk/users.go
 export type UserStatus = "active" | "suspended"
 
+// From codersdk/workspaceagents.go
+export type WorkspaceAgentLifecycle =
+  | "created"
+  | "off"
+  | "ready"
+  | "shutdown_error"
+  | "shutdown_timeout"
+  | "shutting_down"
+  | "start_error"
+  | "start_timeout"
+  | "starting"
+
 // From codersdk/workspaceagents.go
 export type WorkspaceAgentStatus = "connected" | "connecting" | "disconnected"
 
@@ -1021,5 +1418,8 @@ export type WorkspaceStatus =
 // From codersdk/workspacebuilds.go
 export type WorkspaceTransition = "delete" | "start" | "stop"
 
+// From codersdk/templateversions.go
+export type WorkspaceUpdateDiffChange = "added" | "changed" | "removed"
+
 // From codersdk/deploymentconfig.go
 export type Flaggable = string | number | boolean | string[] | GitAuthConfig[]
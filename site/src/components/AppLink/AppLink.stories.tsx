@@ -71,3 +71,15 @@ HealthUnhealthy.args = {
   },
   agent: MockWorkspaceAgent,
 }
+
+export const TCP = Template.bind({})
+TCP.args = {
+  workspace: MockWorkspace,
+  app: {
+    ...MockWorkspaceApp,
+    name: "postgres",
+    subdomain: true,
+    tcp: true,
+  },
+  agent: MockWorkspaceAgent,
+}
@@ -75,6 +75,11 @@ export const AppLink: FC<AppLinkProps> = ({
     primaryTooltip =
       "Your admin has not configured subdomain application access"
   }
+  if (app.tcp && canClick) {
+    // TCP apps can't be opened in the browser.
+    canClick = false
+    primaryTooltip = `Run "coder tunnel ${workspace.name} ${appSlug}" to connect`
+  }
 
   const button = (
     <Button
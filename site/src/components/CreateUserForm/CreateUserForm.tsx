@@ -52,6 +52,8 @@ export const CreateUserForm: FC<
         password: "",
         username: "",
         organization_id: myOrgId,
+        service_account: false,
+        contact: "",
       },
       validationSchema,
       onSubmit,
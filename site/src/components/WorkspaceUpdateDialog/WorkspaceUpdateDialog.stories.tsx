@@ -0,0 +1,39 @@
+import { ComponentMeta, Story } from "@storybook/react"
+import { MockWorkspaceUpdateDiff } from "testHelpers/entities"
+import {
+  WorkspaceUpdateDialog,
+  WorkspaceUpdateDialogProps,
+} from "./WorkspaceUpdateDialog"
+
+export default {
+  title: "Components/WorkspaceUpdateDialog",
+  component: WorkspaceUpdateDialog,
+  argTypes: {
+    onCancel: {
+      action: "onCancel",
+    },
+    onConfirm: {
+      action: "onConfirm",
+    },
+  },
+} as ComponentMeta<typeof WorkspaceUpdateDialog>
+
+const Template: Story<WorkspaceUpdateDialogProps> = (args) => (
+  <WorkspaceUpdateDialog {...args} />
+)
+
+export const WithChanges = Template.bind({})
+WithChanges.args = {
+  isOpen: true,
+  diff: MockWorkspaceUpdateDiff,
+}
+
+export const NoChanges = Template.bind({})
+NoChanges.args = {
+  isOpen: true,
+  diff: {
+    ...MockWorkspaceUpdateDiff,
+    resources: [],
+    parameters: [],
+  },
+}
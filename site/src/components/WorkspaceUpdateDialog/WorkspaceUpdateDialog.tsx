@@ -0,0 +1,87 @@
+import { makeStyles } from "@material-ui/core/styles"
+import { FC } from "react"
+import { MONOSPACE_FONT_FAMILY } from "theme/constants"
+import * as TypesGen from "../../api/typesGenerated"
+import { ConfirmDialog } from "../Dialogs/ConfirmDialog/ConfirmDialog"
+
+export interface WorkspaceUpdateDialogProps {
+  isOpen: boolean
+  diff?: TypesGen.WorkspaceUpdateDiff
+  onConfirm: () => void
+  onCancel: () => void
+}
+
+const changeSymbol: Record<TypesGen.WorkspaceUpdateDiffChange, string> = {
+  added: "+",
+  removed: "-",
+  changed: "~",
+}
+
+export const WorkspaceUpdateDialog: FC<WorkspaceUpdateDialogProps> = ({
+  isOpen,
+  diff,
+  onConfirm,
+  onCancel,
+}) => {
+  const styles = useStyles()
+
+  const description =
+    !diff ||
+    (diff.resources.length === 0 && diff.parameters.length === 0) ? (
+    "Updating won't change any resources or parameters."
+  ) : (
+    <ul className={styles.changes}>
+      {diff.resources.map((resource) => (
+        <li key={`${resource.type}.${resource.name}`}>
+          <span className={styles[resource.change]}>
+            {changeSymbol[resource.change]}
+          </span>{" "}
+          <strong>{`${resource.type}.${resource.name}`}</strong>
+        </li>
+      ))}
+      {diff.parameters.map((parameter) => (
+        <li key={parameter.name}>
+          <span className={styles[parameter.change]}>
+            {changeSymbol[parameter.change]}
+          </span>{" "}
+          <strong>{parameter.name}</strong>
+          {parameter.change === "changed" &&
+            `: ${parameter.old_value} → ${parameter.new_value}`}
+          {parameter.change === "added" && `: ${parameter.new_value}`}
+        </li>
+      ))}
+    </ul>
+  )
+
+  return (
+    <ConfirmDialog
+      type="info"
+      hideCancel={false}
+      open={isOpen}
+      title="Update workspace?"
+      confirmText="Update"
+      onConfirm={onConfirm}
+      onClose={onCancel}
+      description={description}
+    />
+  )
+}
+
+const useStyles = makeStyles((theme) => ({
+  changes: {
+    margin: 0,
+    padding: 0,
+    listStyle: "none",
+    textAlign: "left",
+    fontFamily: MONOSPACE_FONT_FAMILY,
+  },
+  added: {
+    color: theme.palette.success.light,
+  },
+  removed: {
+    color: theme.palette.error.light,
+  },
+  changed: {
+    color: theme.palette.warning.light,
+  },
+}))
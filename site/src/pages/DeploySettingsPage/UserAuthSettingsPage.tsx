@@ -46,6 +46,8 @@ const UserAuthSettingsPage: React.FC = () => {
               email_domain: deploymentConfig.oidc.email_domain,
               issuer_url: deploymentConfig.oidc.issuer_url,
               scopes: deploymentConfig.oidc.scopes,
+              group_field: deploymentConfig.oidc.group_field,
+              user_role_field: deploymentConfig.oidc.user_role_field,
             }}
           />
         </div>
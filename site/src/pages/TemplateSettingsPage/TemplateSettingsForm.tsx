@@ -74,6 +74,12 @@ export const TemplateSettingsForm: FC<TemplateSettingsForm> = ({
         // on display, convert from ms => hours
         max_ttl_ms: template.max_ttl_ms / MS_HOUR_CONVERSION,
         icon: template.icon,
+        // not editable here yet, but must be sent to avoid resetting them
+        autostop_requirement_days: template.autostop_requirement_days,
+        inactivity_ttl_ms: template.inactivity_ttl_ms,
+        dormant_delete_ttl_ms: template.dormant_delete_ttl_ms,
+        port_forwarding_allowlist: template.port_forwarding_allowlist,
+        record_sessions: template.record_sessions,
       },
       validationSchema,
       onSubmit: (formData) => {
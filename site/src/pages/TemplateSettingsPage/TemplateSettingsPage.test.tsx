@@ -34,7 +34,15 @@ const fillAndSubmitForm = async ({
   description,
   max_ttl_ms,
   icon,
-}: Omit<Required<UpdateTemplateMeta>, "min_autostart_interval_ms">) => {
+}: Omit<
+  Required<UpdateTemplateMeta>,
+  | "min_autostart_interval_ms"
+  | "autostop_requirement_days"
+  | "inactivity_ttl_ms"
+  | "dormant_delete_ttl_ms"
+  | "port_forwarding_allowlist"
+  | "record_sessions"
+>) => {
   const nameField = await screen.findByLabelText(FormLanguage.nameLabel)
   await userEvent.clear(nameField)
   await userEvent.type(nameField, name)
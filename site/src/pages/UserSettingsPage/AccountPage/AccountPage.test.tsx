@@ -43,6 +43,8 @@ describe("AccountPage", () => {
           roles: [],
           avatar_url: "",
           last_seen_at: new Date().toString(),
+          service_account: false,
+          contact: "",
           ...data,
         }),
       )
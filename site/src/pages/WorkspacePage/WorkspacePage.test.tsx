@@ -24,6 +24,7 @@ import {
   MockWorkspaceAgentDisconnected,
   MockWorkspaceBuild,
   MockWorkspaceResource2,
+  MockWorkspaceUpdateDiff,
   renderWithAuth,
 } from "../../testHelpers/renderHelpers"
 import { server } from "../../testHelpers/server"
@@ -194,6 +195,9 @@ describe("WorkspacePage", () => {
     jest.spyOn(api, "startWorkspace").mockResolvedValueOnce({
       ...MockWorkspaceBuild,
     })
+    jest
+      .spyOn(api, "getWorkspaceUpdateDiff")
+      .mockResolvedValueOnce(MockWorkspaceUpdateDiff)
 
     server.use(
       rest.get(
@@ -208,6 +212,11 @@ describe("WorkspacePage", () => {
     const button = await screen.findByText(buttonText, { exact: true })
     fireEvent.click(button)
 
+    // The changes are previewed before the update is confirmed.
+    const dialog = await screen.findByRole("dialog")
+    expect(within(dialog).getByText("docker_volume.home")).toBeDefined()
+    fireEvent.click(within(dialog).getByRole("button", { name: "Update" }))
+
     await waitFor(() =>
       expect(api.startWorkspace).toBeCalledWith(
         "test-outdated-workspace",
@@ -16,6 +16,7 @@ import {
   Workspace,
   WorkspaceErrors,
 } from "../../components/Workspace/Workspace"
+import { WorkspaceUpdateDialog } from "../../components/WorkspaceUpdateDialog/WorkspaceUpdateDialog"
 import { pageTitle } from "../../util/page"
 import { getFaviconByStatus } from "../../util/workspace"
 import { XServiceContext } from "../../xServices/StateContext"
@@ -53,6 +54,7 @@ export const WorkspaceReadyPage = ({
     cancellationError,
     applicationsHost,
     permissions,
+    updateDiff,
   } = workspaceState.context
   if (workspace === undefined) {
     throw Error("Workspace is undefined")
@@ -146,6 +148,14 @@ export const WorkspaceReadyPage = ({
           workspaceSend({ type: "DELETE" })
         }}
       />
+      <WorkspaceUpdateDialog
+        diff={updateDiff}
+        isOpen={workspaceState.matches({
+          ready: { build: { updatingWorkspace: "askingUpdate" } },
+        })}
+        onCancel={() => workspaceSend({ type: "CANCEL_UPDATE" })}
+        onConfirm={() => workspaceSend({ type: "CONFIRM_UPDATE" })}
+      />
     </>
   )
 }
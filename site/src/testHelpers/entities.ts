@@ -80,6 +80,8 @@ export const MockUser: TypesGen.User = {
   roles: [MockOwnerRole],
   avatar_url: "https://avatars.githubusercontent.com/u/95932066?s=200&v=4",
   last_seen_at: "",
+  service_account: false,
+  contact: "",
 }
 
 export const MockUserAdmin: TypesGen.User = {
@@ -92,6 +94,8 @@ export const MockUserAdmin: TypesGen.User = {
   roles: [MockUserAdminRole],
   avatar_url: "",
   last_seen_at: "",
+  service_account: false,
+  contact: "",
 }
 
 export const MockUser2: TypesGen.User = {
@@ -104,6 +108,8 @@ export const MockUser2: TypesGen.User = {
   roles: [],
   avatar_url: "",
   last_seen_at: "2022-09-14T19:12:21Z",
+  service_account: false,
+  contact: "",
 }
 
 export const SuspendedMockUser: TypesGen.User = {
@@ -116,6 +122,8 @@ export const SuspendedMockUser: TypesGen.User = {
   roles: [],
   avatar_url: "",
   last_seen_at: "",
+  service_account: false,
+  contact: "",
 }
 
 export const MockOrganization: TypesGen.Organization = {
@@ -123,6 +131,7 @@ export const MockOrganization: TypesGen.Organization = {
   name: "Test Organization",
   created_at: "",
   updated_at: "",
+  user_workspace_quota: 0,
 }
 
 export const MockProvisioner: TypesGen.ProvisionerDaemon = {
@@ -130,6 +139,7 @@ export const MockProvisioner: TypesGen.ProvisionerDaemon = {
   id: "test-provisioner",
   name: "Test Provisioner",
   provisioners: ["echo"],
+  tags: {},
 }
 
 export const MockProvisionerJob: TypesGen.ProvisionerJob = {
@@ -138,6 +148,7 @@ export const MockProvisionerJob: TypesGen.ProvisionerJob = {
   status: "succeeded",
   file_id: "fc0774ce-cc9e-48d4-80ae-88f7a4d4a8b0",
   completed_at: "2022-05-17T17:39:01.382927298Z",
+  tags: {},
 }
 
 export const MockFailedProvisionerJob: TypesGen.ProvisionerJob = {
@@ -196,6 +207,11 @@ export const MockTemplate: TypesGen.Template = {
   description: "This is a test description.",
   max_ttl_ms: 24 * 60 * 60 * 1000,
   min_autostart_interval_ms: 60 * 60 * 1000,
+  autostop_requirement_days: 0,
+  inactivity_ttl_ms: 0,
+  dormant_delete_ttl_ms: 0,
+  port_forwarding_allowlist: [],
+  record_sessions: false,
   created_by_id: "test-creator-id",
   created_by_name: "test_creator",
   icon: "/icon/code.svg",
@@ -207,6 +223,7 @@ export const MockWorkspaceApp: TypesGen.WorkspaceApp = {
   display_name: "Test App",
   icon: "",
   subdomain: false,
+  tcp: false,
   health: "disabled",
   sharing_level: "owner",
   healthcheck: {
@@ -228,6 +245,12 @@ export const MockWorkspaceAgent: TypesGen.WorkspaceAgent = {
   status: "connected",
   updated_at: "",
   version: MockBuildInfo.version,
+  startup_logs_length: 0,
+  startup_logs_overflowed: false,
+  lifecycle_state: "ready",
+  startup_script_timeout_seconds: 0,
+  login_before_ready: true,
+  shutdown_script_timeout_seconds: 0,
   latency: {
     "Coder Embedded DERP": {
       latency_ms: 32.55,
@@ -426,6 +449,22 @@ export const MockWorkspace: TypesGen.Workspace = {
   last_used_at: "",
 }
 
+export const MockWorkspaceUpdateDiff: TypesGen.WorkspaceUpdateDiff = {
+  workspace_id: MockWorkspace.id,
+  resources: [
+    { type: "docker_container", name: "workspace", change: "changed" },
+    { type: "docker_volume", name: "home", change: "added" },
+  ],
+  parameters: [
+    {
+      name: "region",
+      old_value: "us-east",
+      new_value: "eu-west",
+      change: "changed",
+    },
+  ],
+}
+
 export const MockStoppedWorkspace: TypesGen.Workspace = {
   ...MockWorkspace,
   id: "test-stopped-workspace",
@@ -80,6 +80,8 @@ export const getDisplayWorkspaceBuildInitiatedBy = (
       return build.initiator_name
     case "autostart":
     case "autostop":
+    case "dormancy":
+    case "autodelete":
       return "Coder"
   }
 }
@@ -54,6 +54,8 @@ export interface WorkspaceContext {
   workspace?: TypesGen.Workspace
   template?: TypesGen.Template
   build?: TypesGen.WorkspaceBuild
+  // changes the update will make, shown before confirming it
+  updateDiff?: TypesGen.WorkspaceUpdateDiff
   getWorkspaceError?: Error | unknown
   // these are labeled as warnings because they don't make the page unusable
   refreshWorkspaceWarning?: Error | unknown
@@ -81,6 +83,8 @@ export type WorkspaceEvent =
   | { type: "DELETE" }
   | { type: "CANCEL_DELETE" }
   | { type: "UPDATE" }
+  | { type: "CONFIRM_UPDATE" }
+  | { type: "CANCEL_UPDATE" }
   | { type: "CANCEL" }
   | {
       type: "REFRESH_TIMELINE"
@@ -130,6 +134,9 @@ export const workspaceMachine = createMachine(
         getTemplate: {
           data: TypesGen.Template
         }
+        getUpdateDiff: {
+          data: TypesGen.WorkspaceUpdateDiff
+        }
         startWorkspaceWithLatestTemplate: {
           data: TypesGen.WorkspaceBuild
         }
@@ -299,7 +306,7 @@ export const workspaceMachine = createMachine(
                       id: "refreshTemplate",
                       src: "getTemplate",
                       onDone: {
-                        target: "startingWithLatestTemplate",
+                        target: "previewingUpdate",
                         actions: ["assignTemplate"],
                       },
                       onError: {
@@ -308,6 +315,31 @@ export const workspaceMachine = createMachine(
                       },
                     },
                   },
+                  previewingUpdate: {
+                    entry: "clearUpdateDiff",
+                    invoke: {
+                      id: "getUpdateDiff",
+                      src: "getUpdateDiff",
+                      onDone: {
+                        target: "askingUpdate",
+                        actions: ["assignUpdateDiff"],
+                      },
+                      onError: {
+                        target: "#workspaceState.ready.build.idle",
+                        actions: ["assignBuildError"],
+                      },
+                    },
+                  },
+                  askingUpdate: {
+                    on: {
+                      CONFIRM_UPDATE: {
+                        target: "startingWithLatestTemplate",
+                      },
+                      CANCEL_UPDATE: {
+                        target: "#workspaceState.ready.build.idle",
+                      },
+                    },
+                  },
                   startingWithLatestTemplate: {
                     invoke: {
                       id: "startWorkspaceWithLatestTemplate",
@@ -508,6 +540,12 @@ export const workspaceMachine = createMachine(
       assignTemplate: assign({
         template: (_, event) => event.data,
       }),
+      assignUpdateDiff: assign({
+        updateDiff: (_, event) => event.data,
+      }),
+      clearUpdateDiff: assign({
+        updateDiff: (_) => undefined,
+      }),
       assignPermissions: assign({
         // Setting event.data as Permissions to be more stricted. So we know
         // what permissions we asked for.
@@ -620,6 +658,16 @@ export const workspaceMachine = createMachine(
           throw Error("Cannot get template without workspace")
         }
       },
+      getUpdateDiff: async (context) => {
+        if (context.workspace && context.template) {
+          return await API.getWorkspaceUpdateDiff(
+            context.workspace.id,
+            context.template.active_version_id,
+          )
+        } else {
+          throw Error("Cannot preview update without workspace")
+        }
+      },
       startWorkspaceWithLatestTemplate: (context) => async (send) => {
         if (context.workspace && context.template) {
           const startWorkspacePromise = await API.startWorkspace(
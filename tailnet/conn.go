@@ -17,6 +17,7 @@ import (
 	"tailscale.com/hostinfo"
 	"tailscale.com/ipn/ipnstate"
 	"tailscale.com/net/dns"
+	"tailscale.com/net/netcheck"
 	"tailscale.com/net/netns"
 	"tailscale.com/net/tsdial"
 	"tailscale.com/net/tstun"
@@ -73,6 +74,7 @@ func NewConn(options *Options) (*Conn, error) {
 		NodeKey:    nodePublicKey,
 		PrivateKey: nodePrivateKey,
 		Addresses:  options.Addresses,
+		DERPMap:    options.DERPMap,
 		PacketFilter: []filter.Match{{
 			// Allow any protocol!
 			IPProto: []ipproto.Proto{ipproto.TCP, ipproto.UDP, ipproto.ICMPv4, ipproto.ICMPv6, ipproto.SCTP},
@@ -274,6 +276,7 @@ type Conn struct {
 	wireguardEngine    wgengine.Engine
 	listeners          map[listenKey]*listener
 	forwardTCPCallback func(conn net.Conn, listenerExists bool) net.Conn
+	forwardTCPFilter   func(port uint16) bool
 
 	lastMutex   sync.Mutex
 	nodeSending bool
@@ -299,6 +302,15 @@ func (c *Conn) SetForwardTCPCallback(callback func(conn net.Conn, listenerExists
 	c.forwardTCPCallback = callback
 }
 
+// SetForwardTCPFilter is called every time a TCP connection is initiated
+// inbound to a port without a listener. If it returns false, the connection
+// is closed instead of being forwarded to the local listening port.
+func (c *Conn) SetForwardTCPFilter(filter func(port uint16) bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.forwardTCPFilter = filter
+}
+
 func (c *Conn) SetNodeCallback(callback func(node *Node)) {
 	c.lastMutex.Lock()
 	c.nodeCallback = callback
@@ -400,6 +412,107 @@ func (c *Conn) Ping(ip netip.Addr, pingType tailcfg.PingType, cb func(*ipnstate.
 	c.wireguardEngine.Ping(ip, pingType, cb)
 }
 
+// PeerStat describes the connection to a single peer.
+type PeerStat struct {
+	// IP is the peer's tailnet IP.
+	IP netip.Addr
+	// Endpoint is the ip:port of the peer if traffic flows over a direct
+	// connection. It's empty if traffic is relayed through DERP.
+	Endpoint string
+	// DERPRegionID is the region traffic is relayed through, or the
+	// peer's home region for direct connections.
+	DERPRegionID int
+	// Latency is the round trip time of a ping to the peer, or zero if the
+	// ping didn't complete.
+	Latency time.Duration
+	RxBytes int64
+	TxBytes int64
+}
+
+// PeerStats returns a record for every peer a handshake was completed with.
+// Each peer is pinged concurrently to measure latency until ctx is done.
+func (c *Conn) PeerStats(ctx context.Context) []PeerStat {
+	derpMap := c.DERPMap()
+	regionIDs := map[string]int{}
+	if derpMap != nil {
+		for id, region := range derpMap.Regions {
+			regionIDs[region.RegionCode] = id
+		}
+	}
+
+	// The engine doesn't know the addresses of peers, so they're looked up
+	// by node key.
+	c.mutex.Lock()
+	peerIPs := make(map[key.NodePublic]netip.Addr, len(c.peerMap))
+	for _, node := range c.peerMap {
+		if len(node.Addresses) > 0 {
+			peerIPs[node.Key] = node.Addresses[0].Addr()
+		}
+	}
+	c.mutex.Unlock()
+
+	status := c.Status()
+	stats := make([]PeerStat, 0, len(status.Peer))
+	for nodeKey, peer := range status.Peer {
+		ip, ok := peerIPs[nodeKey]
+		if !ok || peer.LastHandshake.IsZero() {
+			continue
+		}
+		stats = append(stats, PeerStat{
+			IP:           ip,
+			Endpoint:     peer.CurAddr,
+			DERPRegionID: regionIDs[peer.Relay],
+			RxBytes:      peer.RxBytes,
+			TxBytes:      peer.TxBytes,
+		})
+	}
+
+	latencies := make([]chan time.Duration, len(stats))
+	for i, stat := range stats {
+		latency := make(chan time.Duration, 1)
+		latencies[i] = latency
+		go c.Ping(stat.IP, tailcfg.PingDisco, func(pr *ipnstate.PingResult) {
+			var d time.Duration
+			if pr.Err == "" {
+				d = time.Duration(pr.LatencySeconds * float64(time.Second))
+			}
+			select {
+			case latency <- d:
+			default:
+			}
+		})
+	}
+	for i, latency := range latencies {
+		select {
+		case stats[i].Latency = <-latency:
+		case <-ctx.Done():
+		}
+	}
+	return stats
+}
+
+// DERPMap returns the DERP map the connection is currently using.
+func (c *Conn) DERPMap() *tailcfg.DERPMap {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.netMap.DERPMap
+}
+
+// Netcheck probes the DERP servers in the current DERP map over STUN and
+// HTTPS. The report says whether UDP is blocked, which public endpoints
+// were discovered, whether the NAT maps endpoints per destination and which
+// DERP region is preferred.
+func (c *Conn) Netcheck(ctx context.Context) (*netcheck.Report, error) {
+	client := &netcheck.Client{
+		Logf: Logger(c.logger.Named("netcheck")),
+	}
+	report, err := client.GetReport(ctx, c.DERPMap())
+	if err != nil {
+		return nil, xerrors.Errorf("get report: %w", err)
+	}
+	return report, nil
+}
+
 // Closed is a channel that ends when the connection has
 // been closed.
 func (c *Conn) Closed() <-chan struct{} {
@@ -527,6 +640,12 @@ func (c *Conn) DialContextUDP(ctx context.Context, ipp netip.AddrPort) (*gonet.U
 func (c *Conn) forwardTCP(conn net.Conn, port uint16) {
 	c.mutex.Lock()
 	ln, ok := c.listeners[listenKey{"tcp", "", fmt.Sprint(port)}]
+	if !ok && c.forwardTCPFilter != nil && !c.forwardTCPFilter(port) {
+		c.mutex.Unlock()
+		c.logger.Debug(c.dialContext, "forwarding denied", slog.F("port", port))
+		_ = conn.Close()
+		return
+	}
 	if c.forwardTCPCallback != nil {
 		conn = c.forwardTCPCallback(conn, ok)
 	}